@@ -0,0 +1,59 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// CheckToken confirms that the server accepts the refresh token and the resulting access token.
+// Unlike GetToken, it never attempts registration, so it is safe to use for diagnostics.
+func (c *Communications) CheckToken() error {
+
+	rToken := c.conf.AP.Get(global.ConfigRefreshToken).String()
+	if rToken == "" {
+		return errors.New("no refresh token, the agent is not registered")
+	}
+
+	serverURL := c.conf.AP.Get(global.ConfigServerURL).String()
+	if serverURL == "" {
+		return errors.New("server URL is not set")
+	}
+
+	req := schema.RefreshRequest{
+		RefreshToken:    rToken,
+		ClientPublicSig: c.conf.AP.Get(global.ConfigAgentECPublicSig).String(),
+		ClientPublicEnc: c.conf.AP.Get(global.ConfigAgentECPublicEnc).String(),
+	}
+
+	data, err := c.post(serverURL, schema.EndpointRefresh, false, req)
+	if err != nil {
+		return fmt.Errorf("token refresh failed: %w", err)
+	}
+
+	var refreshResponse schema.APITokenRefreshResponse
+	err = json.Unmarshal(data, &refreshResponse)
+	if err != nil {
+		return fmt.Errorf("deserialization failed: %w", err)
+	}
+
+	if refreshResponse.Code != 200 || refreshResponse.AccessToken == "" {
+		return fmt.Errorf("refresh token rejected with code %d", refreshResponse.Code)
+	}
+
+	// Use the access token for an authenticated request
+	c.setToken(refreshResponse.AccessToken)
+	_, err = c.get(serverURL, schema.EndpointPing, true)
+	if err != nil {
+		return fmt.Errorf("access token rejected: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+)
+
+// checkClock compares the server's time with the local clock. If they differ by more than
+// ClockSkewThreshold seconds the skew is logged and an alert is sent with the next sync.
+// Servers that don't report their time are ignored.
+func (c *Communications) checkClock(serverTime int64) {
+	if serverTime == 0 {
+		return
+	}
+
+	skew := time.Now().Unix() - serverTime
+	skewed := skew > global.ClockSkewThreshold || skew < -global.ClockSkewThreshold
+
+	c.clockMu.Lock()
+	defer c.clockMu.Unlock()
+
+	// Only log changes
+	if skewed == c.clockSkewed {
+		return
+	}
+	c.clockSkewed = skewed
+
+	if !skewed {
+		c.logger.Info(8034, "local clock is in sync with the server", nil)
+		c.pendingClockAlert = ""
+		return
+	}
+
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+		skew = -skew
+	}
+	msg := fmt.Sprintf("clock skew detected: local clock is %d seconds %s the server", skew, direction)
+	c.logger.Warning(8033, msg, fields.NewFields(
+		fields.NewField("local_time", time.Now().Unix()),
+		fields.NewField("server_time", serverTime)))
+	c.pendingClockAlert = msg
+}
+
+// takePendingClockAlert returns and clears any clock skew alert waiting to be sent
+func (c *Communications) takePendingClockAlert() string {
+	c.clockMu.Lock()
+	defer c.clockMu.Unlock()
+	msg := c.pendingClockAlert
+	c.pendingClockAlert = ""
+	return msg
+}
+
+// requeueClockAlert restores a clock skew alert that could not be sent, unless the clock has
+// since been corrected
+func (c *Communications) requeueClockAlert(msg string) {
+	c.clockMu.Lock()
+	defer c.clockMu.Unlock()
+	if msg != "" && c.clockSkewed && c.pendingClockAlert == "" {
+		c.pendingClockAlert = msg
+	}
+}
@@ -7,11 +7,15 @@ package communications
 
 import (
 	"errors"
+	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/UnifyEM/UnifyEM/agent/global"
 	"github.com/UnifyEM/UnifyEM/agent/queues"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
 type Communications struct {
@@ -20,9 +24,26 @@ type Communications struct {
 	conf                *global.AgentConfig
 	requests            *queues.RequestQueue
 	responses           *queues.ResponseQueue
+	tokenMu             sync.Mutex
 	jwt                 string
+	transport           http.RoundTripper // replaces the default transport, used for testing
 	recoveryMu          sync.Mutex
 	pendingRecoveryInfo string
+	authMu              sync.Mutex
+	authFailures        int
+	retryAfter          time.Time
+	clockMu             sync.Mutex
+	clockSkewed         bool
+	pendingClockAlert   string
+	configMu            sync.Mutex
+	pendingConfigAlert  string                // settings that changed but require a restart
+	rejectedTriggers    *schema.AgentTriggers // the last triggers that failed verification, reported once
+	reconfigureLogger   func() error          // applies changed logging settings
+	serverGzip          atomic.Bool           // the server accepts gzip request bodies
+	deferredWaiting     atomic.Bool           // a deferred registration failed to reach the server
+	syncMu              sync.Mutex
+	syncNotBefore       time.Time // the server asked the agent not to sync before this time
+	syncJitter          float64   // applied to the sync interval, see SyncInterval
 }
 
 func New(options ...func(*Communications) error) (*Communications, error) {
@@ -91,7 +112,15 @@ func WithResponseQueue(responses *queues.ResponseQueue) func(*Communications) er
 	}
 }
 
+// RetryRequired returns true if the last sync failed and should be retried, unless repeated
+// authentication failures or an overloaded server have delayed the next attempt
 func (c *Communications) RetryRequired() bool {
+	if c.authBackoff() > 0 || c.SyncDeferred() {
+		return false
+	}
+
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
 	if c.retryRequired || c.jwt == "" {
 		c.retryRequired = false
 		return true
@@ -99,6 +128,17 @@ func (c *Communications) RetryRequired() bool {
 	return false
 }
 
+// QueueResponse adds a response to be sent with the next sync. It is used by commands that
+// continue in the background after returning an initial response.
+func (c *Communications) QueueResponse(response schema.AgentResponse) {
+	c.responses.Add(response)
+}
+
+// ResponsesPending returns true if responses are waiting to be sent to the server
+func (c *Communications) ResponsesPending() bool {
+	return c.responses.Pending()
+}
+
 // SetPendingRecoveryInfo stores encrypted recovery info to be included in the next sync
 func (c *Communications) SetPendingRecoveryInfo(info string) {
 	c.recoveryMu.Lock()
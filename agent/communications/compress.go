@@ -0,0 +1,56 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// compressThreshold is the size of the smallest request body that is compressed. Smaller
+// bodies gain little and cost CPU time on both ends.
+const compressThreshold = 1024
+
+// compressionEnabled returns true unless compression has been disabled, for example to
+// make traffic easier to inspect when debugging
+func (c *Communications) compressionEnabled() bool {
+	return c.conf.AC.Get(schema.ConfigAgentCompression).Bool()
+}
+
+// checkServerEncoding records whether the server accepts gzip request bodies, which it
+// advertises with Accept-Encoding in its responses (RFC 7694). Older servers do not.
+func (c *Communications) checkServerEncoding(resp *http.Response) {
+	accepted := false
+	for _, enc := range strings.Split(resp.Header.Get("Accept-Encoding"), ",") {
+		if strings.EqualFold(strings.TrimSpace(enc), "gzip") {
+			accepted = true
+			break
+		}
+	}
+	c.serverGzip.Store(accepted)
+}
+
+// compressBody returns the request body and the Content-Encoding to send it with. Large
+// bodies are compressed if compression is enabled and the server accepts it.
+func (c *Communications) compressBody(data []byte) ([]byte, string) {
+	if len(data) < compressThreshold || !c.compressionEnabled() || !c.serverGzip.Load() {
+		return data, ""
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return data, ""
+	}
+	if err := gz.Close(); err != nil {
+		return data, ""
+	}
+	return buf.Bytes(), "gzip"
+}
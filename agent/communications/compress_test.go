@@ -0,0 +1,45 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func TestCompressBody(t *testing.T) {
+	c, _ := newPinTest(t)
+	large := []byte(strings.Repeat("x", compressThreshold))
+
+	// Servers that have not advertised gzip support receive uncompressed bodies
+	if _, encoding := c.compressBody(large); encoding != "" {
+		t.Fatalf("body compressed before the server advertised gzip")
+	}
+
+	c.checkServerEncoding(&http.Response{Header: http.Header{"Accept-Encoding": {"gzip"}}})
+	body, encoding := c.compressBody(large)
+	if encoding != "gzip" || len(body) >= len(large) {
+		t.Errorf("large body was not compressed")
+	}
+	if _, encoding = c.compressBody(large[:compressThreshold-1]); encoding != "" {
+		t.Errorf("small body was compressed")
+	}
+
+	c.conf.AC.Set(schema.ConfigAgentCompression, false)
+	if _, encoding = c.compressBody(large); encoding != "" {
+		t.Errorf("body compressed with compression disabled")
+	}
+
+	// A server that stops advertising gzip, such as after a downgrade, is respected
+	c.conf.AC.Set(schema.ConfigAgentCompression, true)
+	c.checkServerEncoding(&http.Response{Header: http.Header{}})
+	if _, encoding = c.compressBody(large); encoding != "" {
+		t.Errorf("body compressed after the server stopped advertising gzip")
+	}
+}
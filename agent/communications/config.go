@@ -0,0 +1,144 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// configApply describes how a change to an agent setting pushed by the server takes effect
+type configApply int
+
+const (
+	configOnUse   configApply = iota // read each time it is used, no action is required
+	configLogger                     // applied by reconfiguring the logger
+	configRestart                    // read only at startup, the agent must be restarted
+)
+
+// configKeys lists how each agent setting takes effect. Settings that aren't listed, such as
+// those added by a newer server, are treated as configOnUse.
+var configKeys = map[string]configApply{
+	schema.ConfigAgentSyncInterval:     configOnUse,
+	schema.ConfigAgentSyncPending:      configOnUse,
+	schema.ConfigAgentSyncRetry:        configOnUse,
+	schema.ConfigAgentSyncLost:         configOnUse,
+	schema.ConfigAgentSyncJitter:       configOnUse,
+	schema.ConfigAgentStatusInterval:   configOnUse,
+	schema.ConfigAgentStatusTimeout:    configOnUse,
+	schema.ConfigAgentLogRetention:     configLogger,
+	schema.ConfigAgentLogStdout:        configLogger,
+	schema.ConfigAgentLogWindowsDisk:   configLogger,
+	schema.ConfigAgentLogWindowsEvents: configRestart, // the event source is registered at startup
+	schema.ConfigAgentLogMacOSDisk:     configLogger,
+	schema.ConfigAgentLogLinuxDisk:     configLogger,
+	schema.ConfigAgentLogMaxSize:       configLogger,
+	schema.ConfigAgentLogMaxFiles:      configLogger,
+	schema.ConfigAgentDebug:            configLogger,
+	schema.ConfigAgentPinCA:            configOnUse, // checked on each TLS handshake
+	schema.ConfigAgentVerification:     configOnUse,
+	schema.ConfigAgentRecoveryInfo:     configOnUse,
+	schema.ConfigAgentFileFetchMax:     configOnUse,
+	schema.ConfigAgentLostWiFi:         configOnUse,
+	schema.ConfigAgentShell:            configOnUse,
+	schema.ConfigAgentCompression:      configOnUse,
+	schema.ConfigAgentPublisherKey:     configOnUse,
+	schema.ConfigAgentWipePaths:        configOnUse,
+	schema.ConfigAgentLocalStatus:      configOnUse, // checked for each connection
+	schema.ConfigAgentDisabledCommands: configOnUse, // checked for each request
+	schema.ConfigAgentExecAllowedPaths: configOnUse,
+}
+
+// WithLoggerReconfigure sets a function that applies the logging settings to the logger in
+// use. Without it, changes to the logging settings take effect when the agent is restarted.
+func WithLoggerReconfigure(f func() error) func(*Communications) error {
+	return func(c *Communications) error {
+		if f == nil {
+			return errors.New("logger reconfigure function is nil")
+		}
+		c.reconfigureLogger = f
+		return nil
+	}
+}
+
+// ApplyConfig applies agent settings read from the configuration, such as when the service is
+// reloaded, in the same way as settings received from the server. It returns the settings that
+// changed.
+func (c *Communications) ApplyConfig(conf map[string]string) []string {
+	return c.applyConfig(conf)
+}
+
+// applyConfig stores the agent configuration received from the server, applies the settings
+// that changed, and returns them
+func (c *Communications) applyConfig(conf map[string]string) []string {
+	before := c.conf.AC.GetMap()
+	c.conf.AC.SetStringMap(conf)
+	after := c.conf.AC.GetMap()
+
+	var changed []string
+	for key, value := range after {
+		if before[key] != value {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+
+	var logging bool
+	var restart []string
+	for _, key := range changed {
+		c.logger.Info(8064, "agent setting changed", fields.NewFields(
+			fields.NewField("key", key),
+			fields.NewField("value", after[key])))
+
+		switch configKeys[key] {
+		case configLogger:
+			logging = true
+		case configRestart:
+			restart = append(restart, key)
+		}
+	}
+
+	if logging && c.reconfigureLogger != nil {
+		if err := c.reconfigureLogger(); err != nil {
+			c.logger.Errorf(8065, "error applying logging settings: %s", err.Error())
+		}
+	}
+
+	if len(restart) > 0 {
+		msg := "agent restart required to apply settings: " + strings.Join(restart, ", ")
+		c.logger.Warning(8066, msg, nil)
+		c.configMu.Lock()
+		c.pendingConfigAlert = msg
+		c.configMu.Unlock()
+	}
+	return changed
+}
+
+// takePendingConfigAlert returns and clears any restart alert waiting to be sent
+func (c *Communications) takePendingConfigAlert() string {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	msg := c.pendingConfigAlert
+	c.pendingConfigAlert = ""
+	return msg
+}
+
+// requeueConfigAlert restores a restart alert that could not be sent, unless a newer one is
+// waiting
+func (c *Communications) requeueConfigAlert(msg string) {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	if msg != "" && c.pendingConfigAlert == "" {
+		c.pendingConfigAlert = msg
+	}
+}
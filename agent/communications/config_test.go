@@ -0,0 +1,65 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/uconfig"
+)
+
+func TestApplyConfig(t *testing.T) {
+	cfg := uconfig.Null()
+	conf := &global.AgentConfig{C: cfg, AC: schema.SetAgentDefaults(cfg), AP: cfg.NewSet("protected")}
+
+	reconfigured := 0
+	c, err := New(WithLogger(null.Logger()), WithConfig(conf),
+		WithLoggerReconfigure(func() error {
+			reconfigured++
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("failed to create communications: %v", err)
+	}
+
+	// The server sends the whole configuration on every sync, so unchanged settings do nothing
+	c.applyConfig(conf.AC.GetMap())
+	if reconfigured != 0 || c.takePendingConfigAlert() != "" {
+		t.Fatalf("unchanged settings were applied")
+	}
+
+	// Intervals are read on use and need no action
+	changed := c.ApplyConfig(map[string]string{schema.ConfigAgentSyncInterval: "600"})
+	if len(changed) != 1 || changed[0] != schema.ConfigAgentSyncInterval {
+		t.Errorf("expected %s to be reported as changed, got %v", schema.ConfigAgentSyncInterval, changed)
+	}
+	if conf.AC.Get(schema.ConfigAgentSyncInterval).Int() != 600 {
+		t.Errorf("sync interval was not stored")
+	}
+	if reconfigured != 0 || c.takePendingConfigAlert() != "" {
+		t.Errorf("sync interval change should not reconfigure the logger or require a restart")
+	}
+
+	// Logging settings reconfigure the logger once
+	c.applyConfig(map[string]string{schema.ConfigAgentDebug: "true", schema.ConfigAgentLogRetention: "10"})
+	if reconfigured != 1 {
+		t.Errorf("expected the logger to be reconfigured once, got %d", reconfigured)
+	}
+
+	// Settings read only at startup are reported with the next sync
+	c.applyConfig(map[string]string{schema.ConfigAgentLogWindowsEvents: "false"})
+	alert := c.takePendingConfigAlert()
+	if !strings.Contains(alert, schema.ConfigAgentLogWindowsEvents) {
+		t.Errorf("expected a restart alert for %s, got %q", schema.ConfigAgentLogWindowsEvents, alert)
+	}
+	if reconfigured != 1 {
+		t.Errorf("restart-required setting should not reconfigure the logger")
+	}
+}
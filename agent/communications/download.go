@@ -15,11 +15,16 @@ import (
 	"github.com/UnifyEM/UnifyEM/agent/global"
 )
 
+// DownloadDir returns the directory that Download saves files in
+func DownloadDir() string {
+	return os.TempDir()
+}
+
 func (c *Communications) Download(url string) (string, error) {
 	var err error
 
 	// Create a temporary file
-	tmpFile, err := os.CreateTemp("", global.Name+"-tmp-*")
+	tmpFile, err := os.CreateTemp(DownloadDir(), global.Name+"-tmp-*")
 	if err != nil {
 		return "", fmt.Errorf("error creating temporary file: %w", err)
 	}
@@ -62,9 +67,13 @@ func (c *Communications) Download(url string) (string, error) {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	}
 
-	// Create an HTTP client and send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// Use the pinned client for our server, other servers use the system defaults
+	var resp *http.Response
+	if server == ourServer {
+		resp, err = c.do(req)
+	} else {
+		resp, err = (&http.Client{}).Do(req)
+	}
 	if err != nil {
 		closeDelete(tmpFile)
 		return "", fmt.Errorf("error sending http request: %w", err)
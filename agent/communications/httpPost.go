@@ -29,49 +29,57 @@ func (c *Communications) post(server string, path string, auth bool, data any) (
 		return nil, err
 	}
 
-	// Create a new HTTP POST agent
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
+	// If the access token is rejected, the request is retried once with a new token
+	for attempt := 1; ; attempt++ {
 
-	// If authentication is required, obtain and set the bearer token
-	// GetToken() will attempt refresh or registration if required
-	if auth {
-		token, tErr := c.GetToken()
-		if tErr != nil {
-			return nil, tErr
+		// Create a new HTTP POST agent, compressing the body if the server accepts it
+		reqBody, encoding := c.compressBody(jsonData)
+		req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBody))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if encoding != "" {
+			req.Header.Set("Content-Encoding", encoding)
 		}
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	}
 
-	// Create a custom HTTP client to support CA pinning
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: c.TLSConfig(),
-		},
-	}
+		// If authentication is required, obtain and set the bearer token
+		// GetToken() will attempt refresh or registration if required
+		token := ""
+		if auth {
+			token, err = c.GetToken()
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		}
 
-	// Perform the HTTP POST
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
+		// Perform the HTTP POST using a client that supports CA pinning
+		resp, err := c.do(req)
+		if err != nil {
+			return nil, err
+		}
 
-	// If authentication is required, check for 401 status code
-	if auth && resp.StatusCode == http.StatusUnauthorized {
-		// Clear the token to trigger a refresh on the next request
-		c.ClearToken()
-	}
+		c.checkServerEncoding(resp)
 
-	// Read the response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+		// Read the response body
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if auth {
+			if resp.StatusCode != http.StatusUnauthorized {
+				c.authSucceeded()
+			} else {
+				// Clear the token to trigger a refresh
+				c.ClearToken(token)
+				if attempt == 1 {
+					continue
+				}
+			}
+		}
+		return body, nil
 	}
-	return body, nil
 }
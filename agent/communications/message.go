@@ -17,6 +17,21 @@ import (
 
 // SendMessage sends a message to the server
 func (c *Communications) SendMessage(message string) error {
+	return c.sendMessage(schema.AgentEventMessage, message)
+}
+
+// SendUninstallStatus reports the progress of an uninstall to the server
+func (c *Communications) SendUninstallStatus(status string) error {
+	return c.sendMessage(schema.AgentEventUninstall, status)
+}
+
+// SendWipeStatus reports the progress of a wipe to the server
+func (c *Communications) SendWipeStatus(status string) error {
+	return c.sendMessage(schema.AgentEventWipe, status)
+}
+
+// sendMessage sends a message of the specified type to the server
+func (c *Communications) sendMessage(messageType, message string) error {
 
 	// Get the agent ID
 	agentID := c.conf.AP.Get(global.ConfigAgentID).String()
@@ -38,7 +53,7 @@ func (c *Communications) SendMessage(message string) error {
 		Messages: []schema.AgentMessage{{
 			AgentID:     agentID,
 			Sent:        time.Now(),
-			MessageType: schema.AgentEventMessage,
+			MessageType: messageType,
 			Message:     message}}}
 
 	// Send the sync request
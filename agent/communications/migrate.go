@@ -0,0 +1,355 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// An agent is moved to another server with set_server_url. The new server is checked and a
+// test sync is performed with it using settings held in memory, so the configuration is only
+// changed once the agent is known to work with the new server. If the new server doesn't
+// accept the agent's refresh token, for example because it doesn't have a copy of the old
+// server's database, the agent registers with the migration token and keeps its agent ID.
+
+const (
+	healthPath  = "/health"
+	moveTimeout = 60 * time.Second
+)
+
+// ServerMove describes a move to another server
+type ServerMove struct {
+	RequestID      string
+	URL            string               // URL of the new server
+	MigrationToken string               // registers the agent with the new server if required
+	Signature      string               // the current server's signature of the request
+	Response       schema.AgentResponse // sent to the current server once the new server has been tested
+}
+
+// moveSession is the result of authenticating with the new server
+type moveSession struct {
+	accessToken     string
+	refreshToken    string // only set if the agent registered with the new server
+	serverPublicSig string
+	serverPublicEnc string
+}
+
+// MoveServer moves the agent to another server. If any step fails, the agent stays with the
+// current server and nothing is changed. Otherwise the move's response is sent to the current
+// server, which will not hear from the agent again, and the new server's settings are saved.
+func (c *Communications) MoveServer(move ServerMove) error {
+	agentID := c.conf.AP.Get(global.ConfigAgentID).String()
+	if agentID == "" {
+		return errors.New("agent ID is empty")
+	}
+	current := c.conf.AP.Get(global.ConfigServerURL).String()
+
+	// The move must be authorized by the current server
+	err := c.verifyServerMove(agentID, move)
+	if err != nil {
+		c.logger.Error(8075, "server move rejected", fields.NewFields(
+			fields.NewField("error", err.Error()),
+			fields.NewField("url", move.URL),
+			fields.NewField("request_id", move.RequestID)))
+		return err
+	}
+
+	server, _, err := validateServerAndToken(move.URL, "")
+	if err != nil {
+		return err
+	}
+	if server == current {
+		return fmt.Errorf("the agent is already using %s", server)
+	}
+
+	// The migration token must be for the new server. It may include a certificate pin.
+	var pin, regToken string
+	if move.MigrationToken != "" {
+		pin, err = tokenPin(move.MigrationToken)
+		if err != nil {
+			return err
+		}
+		var tokenServer string
+		tokenServer, regToken, err = splitToken(move.MigrationToken)
+		if err != nil {
+			return err
+		}
+		if tokenServer != server {
+			return fmt.Errorf("the migration token is for %s, not %s", tokenServer, server)
+		}
+	}
+
+	client := c.moveClient(pin)
+
+	status, _, err := moveRequest(client, server, healthPath, "", nil)
+	if err == nil && status != http.StatusOK {
+		err = fmt.Errorf("status %d", status)
+	}
+	if err != nil {
+		return fmt.Errorf("%s health check failed: %w", server, err)
+	}
+
+	session, err := c.moveAuthenticate(client, server, agentID, regToken)
+	if err != nil {
+		return err
+	}
+
+	// Perform a test sync with the new server
+	syncResponse, err := moveSync(client, server, session.accessToken, schema.AgentSyncRequest{
+		Version: global.Version,
+		Build:   global.Build,
+		Messages: []schema.AgentMessage{{
+			AgentID:     agentID,
+			Sent:        time.Now(),
+			MessageType: schema.AgentEventMessage,
+			Message:     fmt.Sprintf("agent moved from %s", current)}}})
+	if err != nil {
+		return fmt.Errorf("test sync with %s failed: %w", server, err)
+	}
+
+	// Send the response to the current server while the agent still uses it. The move goes
+	// ahead even if this fails, the request will expire on the current server.
+	err = c.sendResponse(current, move.Response)
+	if err != nil {
+		c.logger.Warning(8076, "unable to send response to the previous server", fields.NewFields(
+			fields.NewField("error", err.Error()),
+			fields.NewField("server", current)))
+	}
+
+	// Save the new server's settings. The certificate pins for the current server don't apply
+	// to the new one, and the CA is pinned again when the agent first connects if enabled.
+	c.conf.AP.Set(global.ConfigServerURL, server)
+	c.conf.AP.Set(global.ConfigRegToken, move.MigrationToken)
+	c.conf.AP.Set(global.ConfigTLSPin, pin)
+	c.conf.AP.Set(global.ConfigTLSPinNext, "")
+	c.conf.AP.Set(global.ConfigCAHash, "")
+	if session.refreshToken != "" {
+		c.conf.AP.Set(global.ConfigRefreshToken, session.refreshToken)
+	}
+	if session.serverPublicSig != "" {
+		c.conf.AP.Set(global.ConfigServerPublicSig, session.serverPublicSig)
+	}
+	if session.serverPublicEnc != "" {
+		c.conf.AP.Set(global.ConfigServerPublicEnc, session.serverPublicEnc)
+	}
+	c.setToken(session.accessToken)
+	c.authSucceeded()
+
+	err = c.conf.Checkpoint()
+	if err != nil {
+		c.logger.Errorf(8077, "error checkpointing configuration: %s", err.Error())
+	}
+
+	c.logger.Warning(8078, "agent moved to new server", fields.NewFields(
+		fields.NewField("previous", current),
+		fields.NewField("server", server),
+		fields.NewField("registered", session.refreshToken != ""),
+		fields.NewField("request_id", move.RequestID)))
+
+	// Queue any requests that the new server sent with the test sync and apply its settings
+	for _, req := range syncResponse.Requests {
+		req.Received = time.Now()
+		c.requests.Add(req)
+	}
+	c.applyConfig(syncResponse.Conf)
+	return nil
+}
+
+// verifyServerMove checks the current server's signature of a move
+func (c *Communications) verifyServerMove(agentID string, move ServerMove) error {
+	serverPublicSig := c.conf.AP.Get(global.ConfigServerPublicSig).String()
+	if serverPublicSig == "" {
+		return errors.New("server public signature key not available")
+	}
+	if move.Signature == "" {
+		return errors.New("the request is not signed by the server")
+	}
+
+	data := schema.ServerMoveData(agentID, move.RequestID, move.URL, move.MigrationToken)
+	valid, err := crypto.Verify(data, move.Signature, serverPublicSig)
+	if err != nil || !valid {
+		return errors.New("the server's signature of the request is not valid")
+	}
+	return nil
+}
+
+// moveAuthenticate obtains an access token from the new server. The refresh token is tried
+// first, since the new server may have the old server's database, and if it is rejected the
+// agent registers with the migration token, keeping its agent ID.
+func (c *Communications) moveAuthenticate(client *http.Client, server, agentID, regToken string) (moveSession, error) {
+	var session moveSession
+
+	status, data, err := moveRequest(client, server, schema.EndpointRefresh, "", schema.RefreshRequest{
+		RefreshToken:    c.conf.AP.Get(global.ConfigRefreshToken).String(),
+		ClientPublicSig: c.conf.AP.Get(global.ConfigAgentECPublicSig).String(),
+		ClientPublicEnc: c.conf.AP.Get(global.ConfigAgentECPublicEnc).String(),
+	})
+	if err != nil {
+		return session, fmt.Errorf("token refresh with %s failed: %w", server, err)
+	}
+
+	var refreshResponse schema.APITokenRefreshResponse
+	if status == http.StatusOK && json.Unmarshal(data, &refreshResponse) == nil &&
+		refreshResponse.Code == http.StatusOK && refreshResponse.AccessToken != "" {
+		session.accessToken = refreshResponse.AccessToken
+		session.serverPublicSig = refreshResponse.ServerPublicSig
+		session.serverPublicEnc = refreshResponse.ServerPublicEnc
+		return session, nil
+	}
+	if status != http.StatusUnauthorized {
+		return session, fmt.Errorf("token refresh with %s failed with status %d", server, status)
+	}
+	if regToken == "" {
+		return session, fmt.Errorf("%s did not accept the agent and no migration token was provided", server)
+	}
+
+	status, data, err = moveRequest(client, server, schema.EndpointRegister, "", schema.AgentRegisterRequest{
+		Token:           regToken,
+		Version:         global.Version,
+		Build:           global.Build,
+		ClientPublicSig: c.conf.AP.Get(global.ConfigAgentECPublicSig).String(),
+		ClientPublicEnc: c.conf.AP.Get(global.ConfigAgentECPublicEnc).String(),
+		AgentID:         agentID,
+	})
+	if err != nil {
+		return session, fmt.Errorf("registration with %s failed: %w", server, err)
+	}
+
+	var regResponse schema.APIRegisterResponse
+	err = json.Unmarshal(data, &regResponse)
+	if err != nil || status != http.StatusOK || regResponse.Code != http.StatusOK {
+		return session, fmt.Errorf("registration with %s failed with status %d", server, status)
+	}
+	if regResponse.AgentID != agentID {
+		return session, fmt.Errorf("%s did not keep the agent ID", server)
+	}
+
+	session.accessToken = regResponse.AccessToken
+	session.refreshToken = regResponse.RefreshToken
+	session.serverPublicSig = regResponse.ServerPublicSig
+	session.serverPublicEnc = regResponse.ServerPublicEnc
+	return session, nil
+}
+
+// moveSync sends a sync request to the new server
+func moveSync(client *http.Client, server, token string, request schema.AgentSyncRequest) (schema.APISyncResponse, error) {
+	var syncResponse schema.APISyncResponse
+	status, data, err := moveRequest(client, server, schema.EndpointSync, token, request)
+	if err != nil {
+		return syncResponse, err
+	}
+
+	err = json.Unmarshal(data, &syncResponse)
+	if err != nil {
+		return syncResponse, fmt.Errorf("deserialization error: %w", err)
+	}
+	if status != http.StatusOK || syncResponse.Code != http.StatusOK {
+		return syncResponse, fmt.Errorf("failed with HTTP code %d: %s", status, syncResponse.Details)
+	}
+	return syncResponse, nil
+}
+
+// sendResponse sends a response to a server immediately rather than with the next sync
+func (c *Communications) sendResponse(server string, response schema.AgentResponse) error {
+	resp, err := c.post(server, schema.EndpointSync, true, schema.AgentSyncRequest{
+		Version:   global.Version,
+		Build:     global.Build,
+		Responses: []schema.AgentResponse{response}})
+	if err != nil {
+		return fmt.Errorf("post error: %w", err)
+	}
+
+	var serverResponse schema.APISyncResponse
+	err = json.Unmarshal(resp, &serverResponse)
+	if err != nil {
+		return fmt.Errorf("deserialization error: %w", err)
+	}
+	if serverResponse.Code != 200 {
+		return fmt.Errorf("failed with HTTP code %d: %s", serverResponse.Code, serverResponse.Details)
+	}
+	return nil
+}
+
+// moveClient returns an HTTP client for the new server. The current server's certificate pins
+// don't apply to it, so only the pin in the migration token, if any, is checked.
+func (c *Communications) moveClient(pin string) *http.Client {
+	if c.transport != nil {
+		return &http.Client{Transport: c.transport, Timeout: moveTimeout}
+	}
+
+	tlsConfig := &tls.Config{}
+	if pin != "" {
+		tlsConfig.VerifyPeerCertificate = func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+			for _, chain := range verifiedChains {
+				for _, cert := range chain {
+					if schema.TLSPinMatches(cert, pin) {
+						return nil
+					}
+				}
+			}
+			return ErrPinMismatch
+		}
+	}
+
+	return &http.Client{
+		Timeout: moveTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+			Proxy:           c.Proxy(),
+		},
+	}
+}
+
+// moveRequest sends a request to the new server and returns the status code and the body. The
+// request is a POST of body as JSON unless body is nil.
+func moveRequest(client *http.Client, server, path, token string, body any) (int, []byte, error) {
+	url, err := buildURL(server, path)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	method := "GET"
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		method = "POST"
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, data, err
+}
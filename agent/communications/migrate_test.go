@@ -0,0 +1,57 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func TestVerifyServerMove(t *testing.T) {
+	c, _ := newPinTest(t)
+
+	privateSig, publicSig, _, _, err := crypto.GenerateKeyPairs()
+	if err != nil {
+		t.Fatalf("failed to generate keys: %v", err)
+	}
+
+	move := func(agentID string) ServerMove {
+		m := ServerMove{RequestID: "R-1", URL: "https://uem.new.example.com", MigrationToken: "token"}
+		m.Signature, err = crypto.Sign(schema.ServerMoveData(agentID, m.RequestID, m.URL, m.MigrationToken), privateSig)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		return m
+	}
+
+	// Without the server's key, nothing can be verified
+	if c.verifyServerMove("agentA", move("agentA")) == nil {
+		t.Errorf("move accepted without a server key")
+	}
+
+	c.conf.AP.Set(global.ConfigServerPublicSig, publicSig)
+	if err := c.verifyServerMove("agentA", move("agentA")); err != nil {
+		t.Errorf("signed move rejected: %v", err)
+	}
+
+	// Moves signed for another agent, altered, or unsigned are rejected
+	if c.verifyServerMove("agentA", move("agentB")) == nil {
+		t.Errorf("move signed for another agent accepted")
+	}
+	altered := move("agentA")
+	altered.URL = "https://uem.attacker.example.com"
+	if c.verifyServerMove("agentA", altered) == nil {
+		t.Errorf("altered move accepted")
+	}
+	unsigned := move("agentA")
+	unsigned.Signature = ""
+	if c.verifyServerMove("agentA", unsigned) == nil {
+		t.Errorf("unsigned move accepted")
+	}
+}
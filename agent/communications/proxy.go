@@ -0,0 +1,323 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+)
+
+// ErrProxy is returned when the server can not be reached because of the proxy
+var ErrProxy = errors.New("proxy error")
+
+// systemProxyTTL is how long the operating system's proxy settings are cached
+const systemProxyTTL = time.Minute
+
+var systemProxyCache struct {
+	sync.Mutex
+	proxy   *systemProxy
+	expires time.Time
+}
+
+// systemProxy is the proxy configured in the operating system
+type systemProxy struct {
+	http   string   // host:port for http URLs
+	https  string   // host:port for https URLs
+	bypass []string // hosts, domain suffixes, and wildcards that are reached directly
+	local  bool     // names without a dot are reached directly
+}
+
+// Proxy returns the function used by the transport to select a proxy. An explicitly
+// configured proxy takes precedence. Otherwise, if enabled, the environment variables
+// HTTPS_PROXY, HTTP_PROXY, and NO_PROXY are used, followed by the operating system's
+// settings (WinHTTP on Windows and SystemConfiguration on macOS).
+func (c *Communications) Proxy() func(*http.Request) (*url.URL, error) {
+	if proxyURL := c.proxyURL(); proxyURL != nil {
+		return http.ProxyURL(proxyURL)
+	}
+
+	if !c.conf.AP.Get(global.ConfigAgentProxyUseSystem).Bool() {
+		return nil
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := http.ProxyFromEnvironment(req)
+		if err != nil || proxyURL != nil {
+			return proxyURL, err
+		}
+		if sp := getSystemProxy(); sp != nil {
+			return sp.proxyFor(req.URL), nil
+		}
+		return nil, nil
+	}
+}
+
+// ProxyDescription describes the proxy that is used to reach the server, without credentials
+func (c *Communications) ProxyDescription(serverURL string) string {
+	if proxyURL := c.proxyURL(); proxyURL != nil {
+		if proxyURL.User != nil {
+			return fmt.Sprintf("%s (configured, authenticated as %s)", proxyURL.Redacted(), proxyURL.User.Username())
+		}
+		return proxyURL.String() + " (configured)"
+	}
+
+	proxy := c.Proxy()
+	if proxy == nil {
+		return "none (system proxy disabled)"
+	}
+
+	req, err := http.NewRequest("GET", serverURL, nil)
+	if err != nil {
+		return "unknown, server URL is not set or invalid"
+	}
+	proxyURL, err := proxy(req)
+	if err != nil {
+		return "invalid system proxy: " + err.Error()
+	}
+	if proxyURL == nil {
+		return "none"
+	}
+	return proxyURL.Redacted() + " (system)"
+}
+
+// proxyURL returns the explicitly configured proxy, including any credentials, or nil
+func (c *Communications) proxyURL() *url.URL {
+	raw := strings.TrimSpace(c.conf.AP.Get(global.ConfigAgentProxyURL).String())
+	if raw == "" {
+		return nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	proxyURL, err := url.Parse(raw)
+	if err != nil || proxyURL.Host == "" {
+		c.logger.Error(8057, "invalid proxy URL, connecting directly",
+			fields.NewFields(fields.NewField("proxy_url", raw)))
+		return nil
+	}
+
+	user := c.conf.AP.Get(global.ConfigAgentProxyUser).String()
+	if user != "" {
+		proxyURL.User = url.UserPassword(user, c.conf.AP.Get(global.ConfigAgentProxyPassword).String())
+	}
+	return proxyURL
+}
+
+// do sends a request with the pinned client. Failures caused by the proxy, including a
+// proxy that requires authentication, are logged and returned as ErrProxy.
+func (c *Communications) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		if IsProxyError(err) {
+			c.logProxyError(req, err.Error())
+			return nil, fmt.Errorf("%w: %w", ErrProxy, err)
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		_ = resp.Body.Close()
+		c.logProxyError(req, "proxy authentication required")
+		return nil, fmt.Errorf("%w: proxy authentication required", ErrProxy)
+	}
+	return resp, nil
+}
+
+func (c *Communications) logProxyError(req *http.Request, details string) {
+	c.logger.Error(8058, "unable to connect through proxy",
+		fields.NewFields(
+			fields.NewField("url", req.URL.Redacted()),
+			fields.NewField("error", details)))
+}
+
+// IsProxyError returns true if the error occurred connecting to or through the proxy
+func IsProxyError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return true
+	}
+
+	// CONNECT failures for https URLs are returned as the proxy's status text
+	msg := err.Error()
+	return strings.Contains(msg, "Proxy Authentication Required") || strings.Contains(msg, "proxyconnect")
+}
+
+// getSystemProxy returns the proxy configured in the operating system, or nil if there is
+// none. The settings are cached briefly because reading them may run an external command.
+func getSystemProxy() *systemProxy {
+	systemProxyCache.Lock()
+	defer systemProxyCache.Unlock()
+
+	if time.Now().After(systemProxyCache.expires) {
+		systemProxyCache.proxy = readSystemProxy()
+		systemProxyCache.expires = time.Now().Add(systemProxyTTL)
+	}
+	return systemProxyCache.proxy
+}
+
+// proxyFor returns the proxy for the URL, or nil if it should be reached directly
+func (p *systemProxy) proxyFor(u *url.URL) *url.URL {
+	hostPort := p.http
+	if u.Scheme == "https" {
+		hostPort = p.https
+	}
+	if hostPort == "" || p.bypassed(u.Hostname()) {
+		return nil
+	}
+	return &url.URL{Scheme: "http", Host: hostPort}
+}
+
+// bypassed returns true if the host is excluded from the proxy
+func (p *systemProxy) bypassed(host string) bool {
+	host = strings.ToLower(host)
+	if p.local && !strings.Contains(host, ".") {
+		return true
+	}
+
+	for _, b := range p.bypass {
+		b = strings.ToLower(strings.TrimSpace(b))
+		switch {
+		case b == "":
+			continue
+		case b == host:
+			return true
+		case strings.HasPrefix(b, "*"):
+			if strings.HasSuffix(host, strings.TrimPrefix(b, "*")) {
+				return true
+			}
+		case strings.HasPrefix(b, "."):
+			if strings.HasSuffix(host, b) {
+				return true
+			}
+		case strings.Contains(b, "/"):
+			// CIDR ranges, as used by macOS
+			if _, network, err := net.ParseCIDR(b); err == nil {
+				if ip := net.ParseIP(host); ip != nil && network.Contains(ip) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// parseWinHTTPSettings parses the WinHttpSettings registry value written by
+// 'netsh winhttp set proxy'. It contains a header of three little-endian DWORDs (the size,
+// a counter, and flags), followed by the length-prefixed proxy server and bypass list.
+func parseWinHTTPSettings(data []byte) *systemProxy {
+	const proxyTypeProxy = 0x2
+
+	if len(data) < 16 || binary.LittleEndian.Uint32(data[8:12])&proxyTypeProxy == 0 {
+		return nil
+	}
+
+	readString := func(offset int) (string, int) {
+		if offset+4 > len(data) {
+			return "", offset
+		}
+		n := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
+		offset += 4
+		if n < 0 || offset+n > len(data) {
+			return "", offset
+		}
+		return string(data[offset : offset+n]), offset + n
+	}
+
+	server, offset := readString(12)
+	bypass, _ := readString(offset)
+	if server == "" {
+		return nil
+	}
+
+	p := &systemProxy{}
+
+	// The proxy is either host:port for all schemes or a list such as http=host:port;https=host:port
+	for _, entry := range strings.Split(server, ";") {
+		scheme, hostPort, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			p.http, p.https = scheme, scheme
+			continue
+		}
+		switch strings.ToLower(scheme) {
+		case "http":
+			p.http = hostPort
+		case "https":
+			p.https = hostPort
+		}
+	}
+
+	for _, b := range strings.Split(bypass, ";") {
+		b = strings.TrimSpace(b)
+		if strings.EqualFold(b, "<local>") {
+			p.local = true
+		} else if b != "" {
+			p.bypass = append(p.bypass, b)
+		}
+	}
+	return p
+}
+
+// parseScutilProxy parses the output of 'scutil --proxy' on macOS
+func parseScutilProxy(output string) *systemProxy {
+	values := make(map[string]string)
+	var exceptions []string
+	inExceptions := false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if inExceptions {
+			if line == "}" {
+				inExceptions = false
+			} else if _, v, found := strings.Cut(line, " : "); found {
+				exceptions = append(exceptions, strings.TrimSpace(v))
+			}
+			continue
+		}
+
+		k, v, found := strings.Cut(line, " : ")
+		if !found {
+			continue
+		}
+		k = strings.TrimSpace(k)
+		if k == "ExceptionsList" {
+			inExceptions = true
+			continue
+		}
+		values[k] = strings.TrimSpace(v)
+	}
+
+	p := &systemProxy{bypass: exceptions, local: values["ExcludeSimpleHostnames"] == "1"}
+	if values["HTTPEnable"] == "1" && values["HTTPProxy"] != "" {
+		p.http = net.JoinHostPort(values["HTTPProxy"], defaultPort(values["HTTPPort"]))
+	}
+	if values["HTTPSEnable"] == "1" && values["HTTPSProxy"] != "" {
+		p.https = net.JoinHostPort(values["HTTPSProxy"], defaultPort(values["HTTPSPort"]))
+	}
+	if p.http == "" && p.https == "" {
+		return nil
+	}
+	return p
+}
+
+func defaultPort(port string) string {
+	if port == "" {
+		return "80"
+	}
+	return port
+}
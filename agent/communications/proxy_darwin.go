@@ -0,0 +1,21 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"os/exec"
+)
+
+// readSystemProxy reads the proxy from SystemConfiguration
+func readSystemProxy() *systemProxy {
+	output, err := exec.Command("/usr/sbin/scutil", "--proxy").Output()
+	if err != nil {
+		return nil
+	}
+	return parseScutilProxy(string(output))
+}
@@ -0,0 +1,14 @@
+//go:build !windows && !darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+// readSystemProxy returns nil because other platforms have no system-wide proxy setting
+// beyond the environment variables
+func readSystemProxy() *systemProxy {
+	return nil
+}
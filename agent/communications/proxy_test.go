@@ -0,0 +1,149 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+func TestConfiguredProxy(t *testing.T) {
+	c, _ := newPinTest(t)
+
+	// The proxy requires basic authentication and answers for the server
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("proxyuser:secret"))
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Proxy-Authorization") != auth {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		if req.URL.Host != "uem.example.invalid" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(proxy.Close)
+
+	send := func() (*http.Response, error) {
+		req, _ := http.NewRequest("GET", "http://uem.example.invalid/api/v1/ping", nil)
+		return c.do(req)
+	}
+
+	c.conf.AP.Set(global.ConfigAgentProxyURL, proxy.URL)
+	if _, err := send(); !errors.Is(err, ErrProxy) {
+		t.Fatalf("expected ErrProxy without credentials, got %v", err)
+	}
+
+	c.conf.AP.Set(global.ConfigAgentProxyUser, "proxyuser")
+	c.conf.AP.Set(global.ConfigAgentProxyPassword, "secret")
+	resp, err := send()
+	if err != nil {
+		t.Fatalf("request through the proxy failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 through the proxy, got %d", resp.StatusCode)
+	}
+
+	// Credentials are not displayed
+	if d := c.ProxyDescription("http://uem.example.invalid"); d != "http://proxyuser:xxxxx@"+proxy.Listener.Addr().String()+" (configured, authenticated as proxyuser)" {
+		t.Errorf("unexpected description %q", d)
+	}
+
+	// A proxy that can't be reached is reported as a proxy error
+	proxy.Close()
+	if _, err = send(); !errors.Is(err, ErrProxy) {
+		t.Errorf("expected ErrProxy for an unreachable proxy, got %v", err)
+	}
+
+	// Without a configured proxy or the system proxy, connections are direct
+	c.conf.AP.Set(global.ConfigAgentProxyURL, "")
+	c.conf.AP.Set(global.ConfigAgentProxyUseSystem, false)
+	if c.Proxy() != nil {
+		t.Errorf("proxy used with the system proxy disabled")
+	}
+}
+
+func TestParseWinHTTPSettings(t *testing.T) {
+	value := func(flags uint32, server, bypass string) []byte {
+		b := binary.LittleEndian.AppendUint32(nil, 0x28)
+		b = binary.LittleEndian.AppendUint32(b, 0)
+		b = binary.LittleEndian.AppendUint32(b, flags)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(server)))
+		b = append(b, server...)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(bypass)))
+		return append(b, bypass...)
+	}
+
+	// Direct access
+	if p := parseWinHTTPSettings(value(1, "", "")); p != nil {
+		t.Errorf("expected no proxy, got %+v", p)
+	}
+
+	p := parseWinHTTPSettings(value(3, "proxy.corp:8080", "<local>;*.corp.example.com;10.1.2.3"))
+	if p == nil {
+		t.Fatalf("proxy not parsed")
+	}
+	tests := map[string]string{
+		"https://uem.example.com":      "proxy.corp:8080",
+		"http://uem.example.com":       "proxy.corp:8080",
+		"https://uem":                  "",
+		"https://uem.corp.example.com": "",
+		"https://10.1.2.3":             "",
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		got := ""
+		if proxyURL := p.proxyFor(u); proxyURL != nil {
+			got = proxyURL.Host
+		}
+		if got != want {
+			t.Errorf("%s: expected proxy %q, got %q", raw, want, got)
+		}
+	}
+
+	p = parseWinHTTPSettings(value(3, "http=web:80;https=secure:443", ""))
+	if p == nil || p.http != "web:80" || p.https != "secure:443" {
+		t.Errorf("per-scheme proxies not parsed: %+v", p)
+	}
+}
+
+func TestParseScutilProxy(t *testing.T) {
+	output := `<dictionary> {
+  ExceptionsList : <array> {
+    0 : *.local
+    1 : 169.254/16
+    2 : 10.0.0.0/8
+  }
+  ExcludeSimpleHostnames : 1
+  FTPPassive : 1
+  HTTPEnable : 1
+  HTTPPort : 3128
+  HTTPProxy : proxy.example.com
+  HTTPSEnable : 1
+  HTTPSPort : 3129
+  HTTPSProxy : proxy.example.com
+}`
+	p := parseScutilProxy(output)
+	if p == nil || p.http != "proxy.example.com:3128" || p.https != "proxy.example.com:3129" || !p.local {
+		t.Fatalf("unexpected proxy %+v", p)
+	}
+	if !p.bypassed("printer.local") || !p.bypassed("10.1.2.3") || p.bypassed("uem.example.com") {
+		t.Errorf("exceptions not applied: %+v", p.bypass)
+	}
+
+	if p = parseScutilProxy("<dictionary> {\n  HTTPEnable : 0\n}"); p != nil {
+		t.Errorf("expected no proxy, got %+v", p)
+	}
+}
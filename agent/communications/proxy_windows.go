@@ -0,0 +1,31 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"golang.org/x/sys/windows/registry"
+)
+
+// readSystemProxy reads the WinHTTP proxy, which applies to services, rather than the
+// per-user proxy configured in the browser settings
+func readSystemProxy() *systemProxy {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE,
+		`SOFTWARE\Microsoft\Windows\CurrentVersion\Internet Settings\Connections`, registry.QUERY_VALUE)
+	if err != nil {
+		return nil
+	}
+	defer func(k registry.Key) {
+		_ = k.Close()
+	}(k)
+
+	data, _, err := k.GetBinaryValue("WinHttpSettings")
+	if err != nil {
+		return nil
+	}
+	return parseWinHTTPSettings(data)
+}
@@ -8,6 +8,7 @@ package communications
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
@@ -17,18 +18,38 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
+// ErrRegistrationDeferred is returned while a deferred registration is waiting for the
+// server to be reachable. It is logged once rather than on every attempt.
+var ErrRegistrationDeferred = errors.New("registration deferred until the server can be reached")
+
 // Register is called from other packages when a condition such as a null agent ID is detected
 func (c *Communications) Register() {
-	_, err := c.register()
+	token, err := c.register()
 	if err != nil {
-		c.logger.Warningf(8019, "registration failed: %s", err.Error())
+		if !errors.Is(err, ErrRegistrationDeferred) {
+			c.logger.Warningf(8019, "registration failed: %s", err.Error())
+		}
 		return
 	}
+	c.setToken(token)
+}
+
+// ValidateToken checks the format of a registration token without contacting the server
+func ValidateToken(token string) error {
+	_, err := tokenPin(token)
+	if err != nil {
+		return err
+	}
+	_, _, err = splitToken(token)
+	return err
 }
 
 // register with the UEM server
 func (c *Communications) register() (string, error) {
-	c.logger.Info(8015, "attempting registration", nil)
+	deferred := c.conf.AP.Get(global.ConfigRegDeferred).Bool()
+	if !deferred || !c.deferredWaiting.Load() {
+		c.logger.Info(8015, "attempting registration", nil)
+	}
 
 	// Get the registration key
 	regToken := c.conf.AP.Get(global.ConfigRegToken).String()
@@ -36,6 +57,17 @@ func (c *Communications) register() (string, error) {
 		return "", fmt.Errorf("registration required but token is null")
 	}
 
+	// Pin the server certificate if the token includes a pin, before contacting the server
+	pin, err := tokenPin(regToken)
+	if err != nil {
+		return "", err
+	}
+	if pin != "" && pin != c.conf.AP.Get(global.ConfigTLSPin).String() {
+		c.conf.AP.Set(global.ConfigTLSPin, pin)
+		c.conf.AP.Set(global.ConfigTLSPinNext, "")
+		c.logger.Info(8042, "server certificate pin set from registration token", nil)
+	}
+
 	// Split the token to get the server URL
 	server, regToken, err := splitToken(regToken)
 	if err != nil {
@@ -61,6 +93,15 @@ func (c *Communications) register() (string, error) {
 	// Send the registration request
 	resp, err := c.post(server, schema.EndpointRegister, false, req)
 	if err != nil {
+		// A deferred registration is expected to fail until the machine is on the network
+		if deferred {
+			if !c.deferredWaiting.Swap(true) {
+				c.logger.Info(8059, "deferred registration is waiting for the server to be reachable",
+					fields.NewFields(fields.NewField("error", err.Error())))
+			}
+			return "", fmt.Errorf("%w: %w", ErrRegistrationDeferred, err)
+		}
+
 		// Check for connection error
 		if strings.Contains(err.Error(), "No connection could be made") {
 			return "", fmt.Errorf("unable to connect to server")
@@ -84,6 +125,10 @@ func (c *Communications) register() (string, error) {
 	c.conf.AP.Set(global.ConfigAgentID, serverResponse.AgentID)
 	c.conf.AP.Set(global.ConfigRefreshToken, serverResponse.RefreshToken)
 
+	// The deferred registration token has been used
+	c.conf.AP.Set(global.ConfigRegDeferred, false)
+	c.deferredWaiting.Store(false)
+
 	// Save server public keys (key pinning - only store if not empty)
 	if serverResponse.ServerPublicSig != "" {
 		c.conf.AP.Set(global.ConfigServerPublicSig, serverResponse.ServerPublicSig)
@@ -94,8 +139,6 @@ func (c *Communications) register() (string, error) {
 		c.logger.Info(8019, "server public encryption key received and stored", nil)
 	}
 
-	// Store the access token and server info locally
-	c.jwt = serverResponse.AccessToken
 	c.logger.Info(8016, "registration successful", fields.NewFields(fields.NewField("agent_id", serverResponse.AgentID)))
 
 	// Checkpoint the configuration
@@ -110,7 +153,7 @@ func (c *Communications) register() (string, error) {
 		_ = c.conf.Checkpoint()
 	}
 
-	return c.jwt, nil
+	return serverResponse.AccessToken, nil
 }
 
 // splitToken splits the token into server URL and registration token.
@@ -134,6 +177,26 @@ func splitToken(token string) (string, string, error) {
 	return parseLegacyToken(token)
 }
 
+// tokenPin returns the server certificate pin included in a base64-encoded token, if any
+func tokenPin(token string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return "", nil
+	}
+
+	var tokenData struct {
+		P string `json:"p"` // server certificate pin
+	}
+	if json.Unmarshal(decoded, &tokenData) != nil || tokenData.P == "" {
+		return "", nil
+	}
+
+	if !schema.ValidTLSPin(tokenData.P) {
+		return "", fmt.Errorf("invalid token format: certificate pin")
+	}
+	return tokenData.P, nil
+}
+
 // validateServerAndToken validates the server URL and token, ensuring proper scheme.
 func validateServerAndToken(serverURL, regToken string) (string, string, error) {
 	parsedURL, err := url.Parse(serverURL)
@@ -0,0 +1,44 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// ShellRelay sends output from a remote shell session to the server and returns the input
+// waiting for the shell. If the server no longer has the session, Close is set in the response.
+func (c *Communications) ShellRelay(sessionID string, out schema.ShellAgentIO) (schema.APIShellAgentResponse, error) {
+
+	// Get the server URL
+	serverURL := c.conf.AP.Get(global.ConfigServerURL).String()
+	if serverURL == "" {
+		return schema.APIShellAgentResponse{}, errors.New("unable to obtain server URL")
+	}
+
+	body, err := c.post(serverURL, schema.EndpointAgentShell+"/"+url.PathEscape(sessionID), true, out)
+	if err != nil {
+		return schema.APIShellAgentResponse{}, err
+	}
+
+	var resp schema.APIShellAgentResponse
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return schema.APIShellAgentResponse{}, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+
+	if resp.Code != http.StatusOK && !resp.Close {
+		return resp, fmt.Errorf("shell relay failed with code %d: %s", resp.Code, resp.Details)
+	}
+	return resp, nil
+}
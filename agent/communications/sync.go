@@ -7,6 +7,8 @@ package communications
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
 	"time"
 
 	"github.com/UnifyEM/UnifyEM/agent/global"
@@ -19,6 +21,9 @@ func (c *Communications) Sync() {
 	var err error
 	var serverURL string
 
+	// Vary the time until the next sync
+	defer c.newSyncJitter()
+
 	// Get the server URL
 	serverURL = c.conf.AP.Get(global.ConfigServerURL).String()
 	if serverURL == "" {
@@ -26,6 +31,9 @@ func (c *Communications) Sync() {
 		// This is likely because the agent is not registered get
 		// Requesting the token will trigger a refresh or registration
 		_, err = c.GetToken()
+		if errors.Is(err, ErrRegistrationDeferred) {
+			return
+		}
 		if err != nil {
 			c.logger.Error(8020, "server URL not set and unable to refresh or register", nil)
 			return
@@ -71,12 +79,50 @@ func (c *Communications) Sync() {
 			})
 	}
 
+	// Report clock skew detected since the last sync
+	clockAlert := c.takePendingClockAlert()
+	if clockAlert != "" {
+		request.Messages = append(request.Messages,
+			schema.AgentMessage{
+				AgentID:     agentID,
+				Sent:        time.Now(),
+				MessageType: schema.AgentEventAlert,
+				Message:     clockAlert,
+			})
+	}
+
+	// Report settings received since the last sync that require a restart
+	configAlert := c.takePendingConfigAlert()
+	if configAlert != "" {
+		request.Messages = append(request.Messages,
+			schema.AgentMessage{
+				AgentID:     agentID,
+				Sent:        time.Now(),
+				MessageType: schema.AgentEventMessage,
+				Message:     configAlert,
+			})
+	}
+
+	// Report changes to protected settings made outside the agent
+	tamperAlert := c.conf.TamperAlert()
+	if tamperAlert != "" {
+		request.Messages = append(request.Messages,
+			schema.AgentMessage{
+				AgentID:     agentID,
+				Sent:        time.Now(),
+				MessageType: schema.AgentEventTamper,
+				Message:     tamperAlert,
+			})
+	}
+
 	// Send the sync request
 	resp, err := c.post(serverURL, schema.EndpointSync, true, request)
 	if err != nil {
 		c.logger.Errorf(8024, "error sending sync request: %s", err.Error())
 		c.responses.ReQueue(responses)
 		c.SetPendingRecoveryInfo(recoveryInfo)
+		c.requeueClockAlert(clockAlert)
+		c.requeueConfigAlert(configAlert)
 		return
 	}
 
@@ -87,18 +133,34 @@ func (c *Communications) Sync() {
 		c.logger.Errorf(8025, "error unmarshalling sync response: %s", err.Error())
 		c.responses.ReQueue(responses)
 		c.SetPendingRecoveryInfo(recoveryInfo)
+		c.requeueClockAlert(clockAlert)
+		c.requeueConfigAlert(configAlert)
 		return
 	}
 
+	// The server includes its time in both successful and failed responses
+	c.checkClock(serverResponse.ServerTime)
+
 	if serverResponse.Code != 200 {
-		c.logger.Errorf(8026, "sync failed with code %d: %s", serverResponse.Code, serverResponse.Details)
+		if serverResponse.Code == http.StatusTooManyRequests {
+			c.deferSync(serverResponse.RetryAfter)
+		} else {
+			c.logger.Errorf(8026, "sync failed with code %d: %s", serverResponse.Code, serverResponse.Details)
+		}
 		c.responses.ReQueue(responses)
 		c.SetPendingRecoveryInfo(recoveryInfo)
+		c.requeueClockAlert(clockAlert)
+		c.requeueConfigAlert(configAlert)
 		return
 	}
 
-	// Check for triggers
-	if c.AnyTriggerChanges(serverResponse.Triggers) {
+	// The tamper alert was delivered, so the current settings can be sealed
+	if tamperAlert != "" {
+		c.conf.ClearTamperAlert()
+	}
+
+	// Check for triggers, which are only acted on if the server signed them
+	if c.AnyTriggerChanges(serverResponse.Triggers) && c.verifyTriggers(agentID, serverResponse) {
 		c.ProcessTriggers(serverResponse.Triggers)
 	}
 
@@ -110,11 +172,12 @@ func (c *Communications) Sync() {
 				fields.NewField("requestID", req.RequestID),
 				fields.NewField("requester", req.Requester)))
 
+		req.Received = time.Now()
 		c.requests.Add(req)
 	}
 
-	// Update the agent config (includes sync intervals)
-	c.conf.AC.SetStringMap(serverResponse.Conf)
+	// Update the agent config (includes sync intervals) and apply any changes
+	c.applyConfig(serverResponse.Conf)
 
 	// Store service credentials if provided (encrypted with agent's public key)
 	if serverResponse.ServiceCredentials != "" {
@@ -133,6 +196,14 @@ func (c *Communications) Sync() {
 		c.logger.Info(8032, "received recovery public key from server", nil)
 	}
 
+	// Store the next server certificate pin, if offered, to support certificate rotation
+	c.processNextPin(serverResponse.TLSPinNext, serverResponse.TLSPinNextSig)
+
+	// Record the sync for diagnostics
+	c.conf.AP.Set(global.ConfigLastSync, time.Now().Unix())
+	c.conf.AP.Set(global.ConfigLastSyncRequests, c.requests.Size())
+	c.conf.AP.Set(global.ConfigLastSyncResponses, len(responses))
+
 	// Checkpoint the configuration
 	err = c.conf.Checkpoint()
 	if err != nil {
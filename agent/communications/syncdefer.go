@@ -0,0 +1,61 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// An overloaded server answers syncs with 429 and the number of seconds to wait. Separately,
+// the sync interval is varied randomly by up to sync_jitter percent so that agents that synced
+// together, for example after a network outage, drift apart.
+
+// maxSyncDefer limits how long the server can defer syncs
+const maxSyncDefer = 3600
+
+// deferSync prevents syncs for the number of seconds requested by the server. If the server
+// didn't specify a time, the sync retry interval is used.
+func (c *Communications) deferSync(seconds int) {
+	if seconds <= 0 {
+		seconds = c.conf.AC.Get(schema.ConfigAgentSyncRetry).Int()
+	}
+	seconds = min(seconds, maxSyncDefer)
+
+	c.syncMu.Lock()
+	c.syncNotBefore = time.Now().Add(time.Duration(seconds) * time.Second)
+	c.syncMu.Unlock()
+
+	c.logger.Warningf(8080, "server is busy, next sync in %d seconds", seconds)
+}
+
+// SyncDeferred returns true if the server has asked the agent to wait before syncing again
+func (c *Communications) SyncDeferred() bool {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+	return time.Now().Before(c.syncNotBefore)
+}
+
+// SyncInterval returns the sync interval with the jitter chosen after the last sync applied
+func (c *Communications) SyncInterval() int64 {
+	interval := c.conf.AC.Get(schema.ConfigAgentSyncInterval).Int64()
+	percent := c.conf.AC.Get(schema.ConfigAgentSyncJitter).Int64()
+
+	c.syncMu.Lock()
+	jitter := c.syncJitter
+	c.syncMu.Unlock()
+
+	return interval + int64(float64(interval*percent)/100*jitter)
+}
+
+// newSyncJitter chooses the jitter for the next sync interval, between -1 and 1
+func (c *Communications) newSyncJitter() {
+	c.syncMu.Lock()
+	c.syncJitter = rand.Float64()*2 - 1
+	c.syncMu.Unlock()
+}
@@ -0,0 +1,67 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func TestSyncDeferred(t *testing.T) {
+	c := newTestComms(t, &fakeServer{})
+
+	if c.SyncDeferred() {
+		t.Fatalf("sync deferred before the server asked")
+	}
+
+	c.retryRequired = true
+	c.deferSync(30)
+	if !c.SyncDeferred() {
+		t.Errorf("expected the sync to be deferred")
+	}
+	if c.RetryRequired() {
+		t.Errorf("a deferred sync should not be retried")
+	}
+
+	// The deferral is limited
+	c.deferSync(1000000)
+	if time.Until(c.syncNotBefore) > maxSyncDefer*time.Second {
+		t.Errorf("deferral not limited")
+	}
+}
+
+func TestSyncInterval(t *testing.T) {
+	c := newTestComms(t, &fakeServer{})
+	c.conf.AC.Set(schema.ConfigAgentSyncInterval, 300)
+	c.conf.AC.Set(schema.ConfigAgentSyncJitter, 10)
+
+	// No jitter until the first sync
+	if interval := c.SyncInterval(); interval != 300 {
+		t.Errorf("expected 300, got %d", interval)
+	}
+
+	varied := false
+	for x := 0; x < 50; x++ {
+		c.newSyncJitter()
+		interval := c.SyncInterval()
+		if interval < 270 || interval > 330 {
+			t.Fatalf("interval %d is outside 10%% of 300", interval)
+		}
+		if interval != 300 {
+			varied = true
+		}
+	}
+	if !varied {
+		t.Errorf("interval was never varied")
+	}
+
+	c.conf.AC.Set(schema.ConfigAgentSyncJitter, 0)
+	if interval := c.SyncInterval(); interval != 300 {
+		t.Errorf("expected 300 with jitter disabled, got %d", interval)
+	}
+}
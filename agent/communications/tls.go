@@ -10,17 +10,36 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
+// httpClient returns an HTTP client that uses the custom TLS configuration to support CA pinning
+func (c *Communications) httpClient() *http.Client {
+	if c.transport != nil {
+		return &http.Client{Transport: c.transport}
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: c.TLSConfig(),
+			Proxy:           c.Proxy(),
+
+			// Unless disabled, the transport requests and decompresses gzip responses
+			DisableCompression: !c.compressionEnabled(),
+		},
+	}
+}
+
 // TLSConfig returns a custom TLS configuration for the HTTP client
 // In the case of a failure, it returns a default TLS configuration
 // to avoid breaking the agent.
 func (c *Communications) TLSConfig() *tls.Config {
-	var err error
 
 	// Load system root CA certificates
 	roots, err := x509.SystemCertPool()
@@ -39,6 +58,12 @@ func (c *Communications) TLSConfig() *tls.Config {
 				return fmt.Errorf("no verified chains found")
 			}
 
+			// Check the server certificate pin, if one was provided at registration
+			err := c.verifyPin(verifiedChains)
+			if err != nil {
+				return err
+			}
+
 			// If CA pinning is not enabled, return nil to accept the chain
 			if !c.conf.AC.Get(schema.ConfigAgentPinCA).Bool() {
 				return nil
@@ -79,3 +104,66 @@ func (c *Communications) TLSConfig() *tls.Config {
 		},
 	}
 }
+
+// ErrPinMismatch is returned when the server certificate does not match the pinned key
+var ErrPinMismatch = errors.New("server certificate does not match the pinned key")
+
+// verifyPin confirms that a verified chain contains a certificate that matches the pin. A match
+// with the next pin offered by the server completes a certificate rotation.
+func (c *Communications) verifyPin(verifiedChains [][]*x509.Certificate) error {
+	pin := c.conf.AP.Get(global.ConfigTLSPin).String()
+	if pin == "" {
+		return nil
+	}
+	next := c.conf.AP.Get(global.ConfigTLSPinNext).String()
+
+	for _, chain := range verifiedChains {
+		for _, cert := range chain {
+			if schema.TLSPinMatches(cert, pin) {
+				return nil
+			}
+		}
+	}
+
+	if next != "" {
+		for _, chain := range verifiedChains {
+			for _, cert := range chain {
+				if schema.TLSPinMatches(cert, next) {
+					c.conf.AP.Set(global.ConfigTLSPin, next)
+					c.conf.AP.Set(global.ConfigTLSPinNext, "")
+					_ = c.conf.Checkpoint()
+					c.logger.Info(8039, "server certificate rotated, next pin is now the current pin", nil)
+					return nil
+				}
+			}
+		}
+	}
+
+	c.logger.Error(8036, "server certificate pinning failed, the connection may have been intercepted",
+		fields.NewFields(fields.NewField("subject", verifiedChains[0][0].Subject.String())))
+	return ErrPinMismatch
+}
+
+// processNextPin stores the next certificate pin offered by the server. It is only accepted
+// if the agent is already pinned and the server signed the rotation from the current pin.
+func (c *Communications) processNextPin(next, signature string) {
+	pin := c.conf.AP.Get(global.ConfigTLSPin).String()
+	if next == "" || pin == "" || next == pin || next == c.conf.AP.Get(global.ConfigTLSPinNext).String() {
+		return
+	}
+
+	serverPublicSig := c.conf.AP.Get(global.ConfigServerPublicSig).String()
+	if serverPublicSig == "" {
+		c.logger.Warning(8043, "next certificate pin ignored, server public signature key not available", nil)
+		return
+	}
+
+	valid, err := crypto.Verify(schema.TLSPinData(pin, next), signature, serverPublicSig)
+	if err != nil || !valid || !schema.ValidTLSPin(next) {
+		c.logger.Error(8038, "next certificate pin rejected, signature is not valid", nil)
+		return
+	}
+
+	c.conf.AP.Set(global.ConfigTLSPinNext, next)
+	c.logger.Info(8037, "next certificate pin received from server", nil)
+}
@@ -0,0 +1,118 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/uconfig"
+)
+
+func newPinTest(t *testing.T) (*Communications, *x509.Certificate) {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	cfg := uconfig.Null()
+	conf := &global.AgentConfig{C: cfg, AC: schema.SetAgentDefaults(cfg), AP: cfg.NewSet("protected")}
+
+	c, err := New(WithLogger(null.Logger()), WithConfig(conf))
+	if err != nil {
+		t.Fatalf("failed to create communications: %v", err)
+	}
+	return c, server.Certificate()
+}
+
+func spkiPin(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func TestVerifyPin(t *testing.T) {
+	c, cert := newPinTest(t)
+	chains := [][]*x509.Certificate{{cert}}
+	other := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	// Without a pin, any verified chain is accepted
+	if err := c.verifyPin(chains); err != nil {
+		t.Fatalf("unpinned agent rejected the chain: %v", err)
+	}
+
+	// Both the SPKI hash and the certificate fingerprint are accepted
+	fingerprint := sha256.Sum256(cert.Raw)
+	for _, pin := range []string{spkiPin(cert), "sha256/" + spkiPin(cert), strings.ToUpper(hex.EncodeToString(fingerprint[:]))} {
+		c.conf.AP.Set(global.ConfigTLSPin, pin)
+		if err := c.verifyPin(chains); err != nil {
+			t.Errorf("pin %s rejected: %v", pin, err)
+		}
+	}
+
+	// A different certificate, such as an intercepting proxy, is rejected
+	c.conf.AP.Set(global.ConfigTLSPin, other)
+	if err := c.verifyPin(chains); !errors.Is(err, ErrPinMismatch) {
+		t.Fatalf("expected ErrPinMismatch, got %v", err)
+	}
+
+	// Presenting the next certificate completes the rotation
+	c.conf.AP.Set(global.ConfigTLSPinNext, spkiPin(cert))
+	if err := c.verifyPin(chains); err != nil {
+		t.Fatalf("next pin rejected: %v", err)
+	}
+	if c.conf.AP.Get(global.ConfigTLSPin).String() != spkiPin(cert) || c.conf.AP.Get(global.ConfigTLSPinNext).String() != "" {
+		t.Errorf("next pin was not promoted")
+	}
+}
+
+func TestProcessNextPin(t *testing.T) {
+	c, cert := newPinTest(t)
+	current := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	next := spkiPin(cert)
+
+	privateSig, publicSig, _, _, err := crypto.GenerateKeyPairs()
+	if err != nil {
+		t.Fatalf("failed to generate keys: %v", err)
+	}
+	c.conf.AP.Set(global.ConfigServerPublicSig, publicSig)
+
+	sign := func(from, to string) string {
+		sig, err := crypto.Sign(schema.TLSPinData(from, to), privateSig)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		return sig
+	}
+
+	// Agents that are not pinned ignore the next pin
+	c.processNextPin(next, sign(current, next))
+	if c.conf.AP.Get(global.ConfigTLSPinNext).String() != "" {
+		t.Fatalf("unpinned agent stored the next pin")
+	}
+
+	// A signature of a rotation from a different pin is rejected
+	c.conf.AP.Set(global.ConfigTLSPin, current)
+	c.processNextPin(next, sign(next, next))
+	if c.conf.AP.Get(global.ConfigTLSPinNext).String() != "" {
+		t.Fatalf("next pin stored with an invalid signature")
+	}
+
+	c.processNextPin(next, sign(current, next))
+	if c.conf.AP.Get(global.ConfigTLSPinNext).String() != next {
+		t.Fatalf("signed next pin was not stored")
+	}
+}
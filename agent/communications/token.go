@@ -9,30 +9,92 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/UnifyEM/UnifyEM/agent/global"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
-// GetToken returns the token, refreshing if required
+// GetToken returns the token, refreshing if required. Only one refresh runs at a time, and
+// callers that arrive while it is running wait for its result rather than starting another.
 func (c *Communications) GetToken() (string, error) {
-	var err error
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
 
 	if c.jwt == "" {
+		// Don't contact the server until the authentication backoff has passed
+		if wait := c.authBackoff(); wait > 0 {
+			return "", fmt.Errorf("authentication failed repeatedly, retrying in %s", wait.Round(time.Second))
+		}
+
 		// Refresh the token
-		c.jwt, err = c.refreshToken()
+		token, err := c.refreshToken()
 		if err != nil {
+			// A deferred registration is retried without a backoff so that it completes
+			// as soon as the server can be reached
+			if !errors.Is(err, ErrRegistrationDeferred) {
+				c.authFailed()
+			}
 			return "", err
 		}
+		c.jwt = token
 	}
 	return c.jwt, nil
 }
 
-// ClearToken is used to clear the token when an auth failure occurs
-func (c *Communications) ClearToken() {
-	// Clear the token
-	c.jwt = ""
+// ClearToken is used to clear the token when the server rejects it. If another request has
+// already replaced the token, the new token is kept and the failure is not counted again.
+func (c *Communications) ClearToken(token string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
 	c.retryRequired = true
+	if c.jwt != token {
+		return
+	}
+	c.jwt = ""
+	c.authFailed()
+}
+
+// setToken replaces the access token
+func (c *Communications) setToken(token string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	c.jwt = token
+}
+
+// authFailed records a consecutive authentication failure. The first failure is retried as
+// usual, normally because the access token expired. Further failures delay the next attempt by
+// AuthRetryBackoff seconds, doubling each time up to AuthRetryBackoffMax, so that an agent that
+// can't authenticate, for example because its clock is wrong, doesn't hammer the server.
+func (c *Communications) authFailed() {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	c.authFailures++
+	if c.authFailures < 2 {
+		return
+	}
+
+	backoff := min(global.AuthRetryBackoff<<min(c.authFailures-2, 10), global.AuthRetryBackoffMax)
+	c.retryAfter = time.Now().Add(time.Duration(backoff) * time.Second)
+	c.logger.Warningf(8035, "%d consecutive authentication failures, next attempt in %d seconds", c.authFailures, backoff)
+}
+
+// authSucceeded resets the authentication failure count
+func (c *Communications) authSucceeded() {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.authFailures = 0
+	c.retryAfter = time.Time{}
+}
+
+// authBackoff returns the time remaining before authentication may be retried
+func (c *Communications) authBackoff() time.Duration {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return time.Until(c.retryAfter)
 }
 
 // refreshToken will attempt to refresh the token and will register if necessary
@@ -75,9 +137,18 @@ func (c *Communications) refreshToken() (string, error) {
 			return "", fmt.Errorf("deserialization failed %w", err)
 		}
 
+		// The server includes its time in both successful and failed responses
+		c.checkClock(refreshResponse.ServerTime)
+
 		if refreshResponse.Code != 200 {
 			c.logger.Errorf(8011, "token refresh failed with code %d", refreshResponse.Code)
 
+			// Only register again if the server rejected the refresh token, other errors are
+			// likely to be temporary
+			if refreshResponse.Code != http.StatusUnauthorized {
+				return "", fmt.Errorf("token refresh failed with code %d", refreshResponse.Code)
+			}
+
 			// Attempt re-registration
 			token, rErr := c.register()
 			if rErr != nil {
@@ -117,7 +188,7 @@ func (c *Communications) refreshToken() (string, error) {
 
 	// Registration is required
 	token, rErr := c.register()
-	if rErr != nil {
+	if rErr != nil && !errors.Is(rErr, ErrRegistrationDeferred) {
 		c.logger.Errorf(8014, "registration failed: %s", rErr.Error())
 	}
 	return token, rErr
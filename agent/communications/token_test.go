@@ -0,0 +1,195 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/uconfig"
+)
+
+// fakeServer simulates the token handling of a UEM server. Refresh tokens are single-use, and
+// each refresh invalidates the access tokens issued before it.
+type fakeServer struct {
+	mu            sync.Mutex
+	refreshToken  string
+	accessToken   string
+	refreshStatus int // status returned when the refresh token is rejected
+	refreshes     int
+	registrations int
+	registerOK    bool // accept registrations
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case schema.EndpointRefresh:
+		f.refreshes++
+		var req schema.RefreshRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.RefreshToken == "" || req.RefreshToken != f.refreshToken {
+			writeJSON(w, f.refreshStatus, schema.API401{Status: schema.APIStatusError, Code: f.refreshStatus})
+			return
+		}
+		f.refreshToken = ""
+		f.accessToken = fmt.Sprintf("access-%d", f.refreshes)
+		writeJSON(w, http.StatusOK, schema.APITokenRefreshResponse{Status: schema.APIStatusOK, Code: http.StatusOK, AccessToken: f.accessToken})
+
+	case schema.EndpointRegister:
+		f.registrations++
+		if f.registerOK {
+			f.refreshToken = "refresh-registered"
+			f.accessToken = "access-registered"
+			writeJSON(w, http.StatusOK, schema.APIRegisterResponse{Status: schema.APIStatusOK, Code: http.StatusOK,
+				AgentID: "A-1234", RefreshToken: f.refreshToken, AccessToken: f.accessToken})
+			return
+		}
+		writeJSON(w, http.StatusUnauthorized, schema.API401{Status: schema.APIStatusError, Code: http.StatusUnauthorized})
+
+	default:
+		// Give concurrent requests time to arrive with the same token
+		time.Sleep(20 * time.Millisecond)
+		if r.Header.Get("Authorization") != "Bearer "+f.accessToken {
+			writeJSON(w, http.StatusUnauthorized, schema.API401{Status: schema.APIStatusError, Code: http.StatusUnauthorized})
+			return
+		}
+		writeJSON(w, http.StatusOK, schema.APIGenericResponse{Status: schema.APIStatusOK, Code: http.StatusOK})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newTestComms returns a Communications instance that uses the fake server. The agent holds an
+// access token that has expired and the refresh token refresh-1.
+func newTestComms(t *testing.T, f *fakeServer) *Communications {
+	t.Helper()
+
+	server := httptest.NewTLSServer(f)
+	t.Cleanup(server.Close)
+
+	cfg := uconfig.Null()
+	conf := &global.AgentConfig{C: cfg, AC: schema.SetAgentDefaults(cfg), AP: cfg.NewSet("protected")}
+	conf.AP.Set(global.ConfigServerURL, server.URL)
+	conf.AP.Set(global.ConfigRefreshToken, "refresh-1")
+	conf.AP.Set(global.ConfigRegToken, server.URL+"/token")
+
+	c, err := New(WithLogger(null.Logger()), WithConfig(conf))
+	if err != nil {
+		t.Fatalf("failed to create communications: %v", err)
+	}
+	c.transport = server.Client().Transport
+	c.jwt = "expired"
+	return c
+}
+
+func TestConcurrentTokenRefresh(t *testing.T) {
+	f := &fakeServer{refreshToken: "refresh-1", refreshStatus: http.StatusUnauthorized}
+	c := newTestComms(t, f)
+	serverURL := c.conf.AP.Get(global.ConfigServerURL).String()
+
+	// All requests are rejected with the expired token at the same time
+	const n = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.get(serverURL, schema.EndpointPing, true)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("request failed: %v", err)
+		}
+	}
+	if f.refreshes != 1 || f.registrations != 0 {
+		t.Errorf("expected 1 refresh and no registration, got %d refreshes and %d registrations", f.refreshes, f.registrations)
+	}
+	if c.authBackoff() > 0 {
+		t.Errorf("successful retries should not delay the next attempt")
+	}
+}
+
+func TestRefreshFailure(t *testing.T) {
+	// A server error is not a reason to register again
+	f := &fakeServer{refreshToken: "revoked", refreshStatus: http.StatusInternalServerError}
+	c := newTestComms(t, f)
+	c.jwt = ""
+
+	if _, err := c.GetToken(); err == nil {
+		t.Fatalf("expected refresh to fail")
+	}
+	if f.refreshes != 1 || f.registrations != 0 {
+		t.Errorf("expected 1 refresh and no registration, got %d refreshes and %d registrations", f.refreshes, f.registrations)
+	}
+
+	// A rejected refresh token falls back to registration
+	f = &fakeServer{refreshToken: "revoked", refreshStatus: http.StatusUnauthorized}
+	c = newTestComms(t, f)
+	c.jwt = ""
+
+	if _, err := c.GetToken(); err == nil {
+		t.Fatalf("expected registration to fail")
+	}
+	if f.refreshes != 1 || f.registrations != 1 {
+		t.Errorf("expected 1 refresh and 1 registration, got %d refreshes and %d registrations", f.refreshes, f.registrations)
+	}
+}
+
+func TestDeferredRegistration(t *testing.T) {
+	f := &fakeServer{registerOK: true}
+	c := newTestComms(t, f)
+	serverURL := c.conf.AP.Get(global.ConfigServerURL).String()
+	c.jwt = ""
+	c.conf.AP.Set(global.ConfigServerURL, "")
+	c.conf.AP.Set(global.ConfigRefreshToken, "")
+	c.conf.AP.Set(global.ConfigRegDeferred, true)
+
+	// While the server can't be reached, attempts fail without a backoff
+	c.conf.AP.Set(global.ConfigRegToken, "https://127.0.0.1:1/token")
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetToken(); !errors.Is(err, ErrRegistrationDeferred) {
+			t.Fatalf("expected ErrRegistrationDeferred, got %v", err)
+		}
+	}
+	if c.authBackoff() > 0 {
+		t.Errorf("deferred registration should not delay the next attempt")
+	}
+
+	// Registration completes once the server is reachable and the token is marked as used
+	c.conf.AP.Set(global.ConfigRegToken, serverURL+"/token")
+	token, err := c.GetToken()
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+	if token != "access-registered" || c.conf.AP.Get(global.ConfigAgentID).String() != "A-1234" {
+		t.Errorf("registration did not store the agent identity")
+	}
+	if c.conf.AP.Get(global.ConfigRegDeferred).Bool() {
+		t.Errorf("deferred registration still pending after registering")
+	}
+}
@@ -9,10 +9,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/UnifyEM/UnifyEM/agent/execute"
 
 	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/common/crypto"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
@@ -33,6 +37,48 @@ func (c *Communications) AnyTriggerChanges(newTriggers schema.AgentTriggers) boo
 	return changed
 }
 
+// verifyTriggers checks the server's signature of the triggers in a sync response using the
+// server key obtained at registration. The signed server time must be within ClockSkewThreshold
+// of the local clock and later than that of the last triggers accepted, so that a captured
+// response can not be replayed. Triggers that fail are ignored and reported to the server as
+// tampering, once until different triggers fail.
+func (c *Communications) verifyTriggers(agentID string, response schema.APISyncResponse) bool {
+	var reason string
+	serverPublicSig := c.conf.AP.Get(global.ConfigServerPublicSig).String()
+	skew := time.Now().Unix() - response.ServerTime
+	switch {
+	case serverPublicSig == "":
+		reason = "server public signature key not available"
+	case response.TriggersSig == "":
+		reason = "triggers are not signed"
+	default:
+		valid, err := crypto.Verify(schema.TriggersData(agentID, response.ServerTime, response.Triggers), response.TriggersSig, serverPublicSig)
+		switch {
+		case err != nil || !valid:
+			reason = "trigger signature is not valid"
+		case skew > global.ClockSkewThreshold || skew < -global.ClockSkewThreshold:
+			reason = "trigger signature is not recent or the local clock is wrong"
+		case response.ServerTime <= c.conf.AP.Get(global.ConfigTriggersTime).Int64():
+			reason = "trigger signature is older than the triggers already accepted"
+		}
+	}
+
+	if reason == "" {
+		c.rejectedTriggers = nil
+		c.conf.AP.Set(global.ConfigTriggersTime, response.ServerTime)
+		_ = c.conf.Checkpoint()
+		return true
+	}
+
+	if c.rejectedTriggers == nil || *c.rejectedTriggers != response.Triggers {
+		triggers := response.Triggers
+		c.rejectedTriggers = &triggers
+		c.logger.Error(8074, "triggers rejected: "+reason, nil)
+		c.logMessageError(c.sendMessage(schema.AgentEventTamper, "triggers rejected: "+reason))
+	}
+	return false
+}
+
 // ProcessTriggers is run as a goroutine to send an immediate reply to the server
 // and then activate the triggers. Errors are logged and handled to the extent possible.
 func (c *Communications) ProcessTriggers(triggers schema.AgentTriggers) {
@@ -64,7 +110,7 @@ func (c *Communications) ProcessTriggers(triggers schema.AgentTriggers) {
 
 	if triggers.Wipe && !triggerStatus.Wipe {
 		triggerStatus.Wipe = true
-		c.triggerWipe()
+		c.triggerWipe(triggers.WipeMode)
 	}
 
 	// Update the local copy
@@ -99,12 +145,19 @@ func (c *Communications) triggerSetLost(lost bool) {
 func (c *Communications) triggerUninstall() {
 	c.triggerLogAndSend("uninstall")
 
+	// Acknowledge so that the server can distinguish an uninstall from a lost agent
+	err := c.SendUninstallStatus(schema.UninstallStarting)
+	if err != nil {
+		c.logger.Errorf(8047, "error sending uninstall status: %s", err.Error())
+	}
+
 	prog, err := os.Executable()
 	if err != nil {
 		c.logger.Errorf(8045, "error getting executable path: %s", err.Error())
 		return
 	}
 
+	// The uninstall process reports completion itself because stopping the service ends this one
 	args := []string{"uninstall"}
 	err = execute.Execute(c.logger, prog, args)
 	if err != nil {
@@ -113,12 +166,24 @@ func (c *Communications) triggerUninstall() {
 	}
 }
 
-func (c *Communications) triggerWipe() {
+func (c *Communications) triggerWipe(mode string) {
 	c.triggerLogAndSend("wipe")
 
-	// TODO
-	// Initiate wipe
-	// TODO
+	// Progress is reported before each step so that the server can see that the wipe began,
+	// even if the device can not report again afterward
+	report := func(status string) {
+		c.logger.Warning(8067, status, nil)
+		err := c.SendWipeStatus(status)
+		if err != nil {
+			c.logger.Errorf(8068, "error sending wipe status: %s", err.Error())
+		}
+	}
+
+	paths := strings.Split(c.conf.AC.Get(schema.ConfigAgentWipePaths).String(), ",")
+	err := osActions.New(c.logger).Wipe(mode, paths, report)
+	if err != nil {
+		c.logger.Errorf(8069, "error wiping device: %s", err.Error())
+	}
 }
 
 func (c *Communications) triggerLogAndSend(triggerName string) {
@@ -0,0 +1,105 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func TestVerifyTriggers(t *testing.T) {
+	c, _ := newPinTest(t)
+
+	privateSig, publicSig, _, _, err := crypto.GenerateKeyPairs()
+	if err != nil {
+		t.Fatalf("failed to generate keys: %v", err)
+	}
+
+	now := time.Now().Unix()
+	signed := func(agentID string, serverTime int64, triggers schema.AgentTriggers) schema.APISyncResponse {
+		sig, err := crypto.Sign(schema.TriggersData(agentID, serverTime, triggers), privateSig)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		return schema.APISyncResponse{Triggers: triggers, TriggersSig: sig, ServerTime: serverTime}
+	}
+	response := func(agentID string, triggers schema.AgentTriggers) schema.APISyncResponse {
+		now++
+		return signed(agentID, now, triggers)
+	}
+	lost := schema.AgentTriggers{Lost: true}
+
+	// Without the server's key, nothing can be verified
+	if c.verifyTriggers("agentA", response("agentA", lost)) {
+		t.Errorf("triggers accepted without a server key")
+	}
+
+	c.conf.AP.Set(global.ConfigServerPublicSig, publicSig)
+	if !c.verifyTriggers("agentA", response("agentA", lost)) {
+		t.Errorf("signed triggers rejected")
+	}
+
+	// Triggers signed for another agent, altered, or unsigned are rejected
+	if c.verifyTriggers("agentA", response("agentB", lost)) {
+		t.Errorf("triggers signed for another agent accepted")
+	}
+	altered := response("agentA", lost)
+	altered.Triggers.Wipe = true
+	if c.verifyTriggers("agentA", altered) {
+		t.Errorf("altered triggers accepted")
+	}
+	if c.verifyTriggers("agentA", schema.APISyncResponse{Triggers: lost}) {
+		t.Errorf("unsigned triggers accepted")
+	}
+}
+
+func TestVerifyTriggersReplay(t *testing.T) {
+	c, _ := newPinTest(t)
+
+	privateSig, publicSig, _, _, err := crypto.GenerateKeyPairs()
+	if err != nil {
+		t.Fatalf("failed to generate keys: %v", err)
+	}
+	c.conf.AP.Set(global.ConfigServerPublicSig, publicSig)
+
+	signed := func(serverTime int64, triggers schema.AgentTriggers) schema.APISyncResponse {
+		sig, err := crypto.Sign(schema.TriggersData("agentA", serverTime, triggers), privateSig)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		return schema.APISyncResponse{Triggers: triggers, TriggersSig: sig, ServerTime: serverTime}
+	}
+	now := time.Now().Unix()
+	wipe := schema.AgentTriggers{Wipe: true}
+
+	// A captured response signed long ago is rejected
+	if c.verifyTriggers("agentA", signed(now-global.ClockSkewThreshold-60, wipe)) {
+		t.Errorf("stale triggers accepted")
+	}
+	if c.verifyTriggers("agentA", signed(now+global.ClockSkewThreshold+60, wipe)) {
+		t.Errorf("triggers signed in the future accepted")
+	}
+
+	// Once triggers are accepted, a response signed earlier, or the same response, is rejected
+	captured := signed(now-10, wipe)
+	if !c.verifyTriggers("agentA", signed(now, schema.AgentTriggers{})) {
+		t.Fatalf("current triggers rejected")
+	}
+	if c.verifyTriggers("agentA", captured) {
+		t.Errorf("triggers signed before the last accepted triggers were accepted")
+	}
+	reset := signed(now, schema.AgentTriggers{})
+	if c.verifyTriggers("agentA", reset) {
+		t.Errorf("replayed triggers accepted")
+	}
+	if !c.verifyTriggers("agentA", signed(now+1, wipe)) {
+		t.Errorf("newer triggers rejected")
+	}
+}
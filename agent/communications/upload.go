@@ -0,0 +1,83 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Upload streams the contents of r to the server as the result of the
+// specified file_fetch request and returns the server's record of the upload
+func (c *Communications) Upload(requestID, name string, r io.Reader) (schema.AgentUpload, error) {
+
+	// Get the server URL
+	serverURL := c.conf.AP.Get(global.ConfigServerURL).String()
+	if serverURL == "" {
+		return schema.AgentUpload{}, errors.New("unable to obtain server URL")
+	}
+
+	// Build the URL with some validation
+	uploadURL, err := buildURL(serverURL, schema.EndpointAgentUpload+"/"+url.PathEscape(requestID))
+	if err != nil {
+		return schema.AgentUpload{}, err
+	}
+	uploadURL += "?name=" + url.QueryEscape(name)
+
+	// Obtain the bearer token, GetToken() will attempt refresh or registration if required
+	token, err := c.GetToken()
+	if err != nil {
+		return schema.AgentUpload{}, err
+	}
+
+	req, err := http.NewRequest("POST", uploadURL, r)
+	if err != nil {
+		return schema.AgentUpload{}, fmt.Errorf("error creating http request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	// Use a client that supports CA pinning
+	resp, err := c.do(req)
+	if err != nil {
+		return schema.AgentUpload{}, fmt.Errorf("error sending http request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	// Clear the token to trigger a refresh on the next request. The upload is not retried
+	// because the file has already been read.
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.ClearToken(token)
+	} else {
+		c.authSucceeded()
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return schema.AgentUpload{}, fmt.Errorf("error reading response: %w", err)
+	}
+
+	var uploadResponse schema.APIAgentUploadResponse
+	err = json.Unmarshal(body, &uploadResponse)
+	if err != nil {
+		return schema.AgentUpload{}, fmt.Errorf("upload failed with status code %d", resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return schema.AgentUpload{}, fmt.Errorf("upload failed with code %d: %s", resp.StatusCode, uploadResponse.Details)
+	}
+
+	return uploadResponse.Data, nil
+}
@@ -14,50 +14,124 @@ import (
 	"github.com/UnifyEM/UnifyEM/agent/communications"
 	"github.com/UnifyEM/UnifyEM/agent/execute"
 	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/crypto"
 	"github.com/UnifyEM/UnifyEM/common/hasher"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
+// Payload describes how a downloaded file is verified. The hash is the base64 hash of the file
+// using HashAlg, which defaults to SHA256. If Signature is set, it must be a signature of the
+// file's hash made with the private key matching PublisherKey.
+type Payload struct {
+	Hash         string
+	HashAlg      string
+	Signature    string
+	PublisherKey string
+}
+
+// Verification records how a downloaded file was verified
+type Verification struct {
+	HashAlg   string
+	Hash      string
+	Signature string
+}
+
+// Map returns the verification result for inclusion in a response
+func (v Verification) Map() map[string]string {
+	return map[string]string{
+		"hash_alg":  v.HashAlg,
+		"hash":      v.Hash,
+		"signature": v.Signature,
+	}
+}
+
 func Download(logger interfaces.Logger, comms *communications.Communications, url string, hash string) (string, error) {
+	tmpFile, _, err := DownloadPayload(logger, comms, url, Payload{Hash: hash})
+	return tmpFile, err
+}
+
+// DownloadPayload downloads a file and verifies its hash and, if provided, its signature.
+// Disabling hash verification does not disable signature verification.
+func DownloadPayload(logger interfaces.Logger, comms *communications.Communications, url string, p Payload) (string, Verification, error) {
+	v := Verification{
+		HashAlg:   p.HashAlg,
+		Hash:      schema.PayloadNotProvided,
+		Signature: schema.PayloadNotProvided,
+	}
+	if v.HashAlg == "" {
+		v.HashAlg = hasher.SHA256
+	}
 
-	// Check for the (usually) required hash
-	if hash == "" {
+	if !hasher.ValidAlg(v.HashAlg) {
+		return "", v, fmt.Errorf("unsupported hash algorithm %s, refusing to download %s", v.HashAlg, url)
+	}
+
+	if p.Signature != "" && p.PublisherKey == "" {
+		v.Signature = schema.PayloadFailed
+		return "", v, fmt.Errorf("signature provided but no publisher key is configured, refusing to download %s", url)
+	}
+
+	// Check for the (usually) required hash. A signature covers the hash, so either will do.
+	if p.Hash == "" && p.Signature == "" {
 		if global.DisableHash {
 			logger.Warningf(8101, "No hash provided, but continuing because hash verification is disabled")
 		} else {
-			return "", fmt.Errorf("empty hash string received, refusing to download %s", url)
+			return "", v, fmt.Errorf("empty hash string received, refusing to download %s", url)
 		}
 	}
 
 	// Download the file
 	tmpFile, err := comms.Download(url)
 	if err != nil {
-		return "", fmt.Errorf("error downloading %s: %w", url, err)
+		return "", v, fmt.Errorf("error downloading %s: %w", url, err)
 	}
 
 	logger.Infof(8102, "Downloaded %s to %s", url, tmpFile)
 
 	// Verify the hash
-	h := hasher.New()
-	if !h.SHA256File(tmpFile).Compare(hash) {
-		if global.DisableHash {
-			logger.Warningf(8103, "Hash verification failed, but continuing because hash verification is disabled")
+	sum := hasher.New().File(v.HashAlg, tmpFile)
+	if p.Hash != "" {
+		if sum.Compare(p.Hash) {
+			v.Hash = schema.PayloadVerified
+			logger.Infof(8104, "Hash verification succeeded for %s", tmpFile)
 		} else {
+			v.Hash = schema.PayloadFailed
+			if global.DisableHash {
+				logger.Warningf(8103, "Hash verification failed, but continuing because hash verification is disabled")
+			} else {
+				_ = os.Remove(tmpFile)
+				return "", v, fmt.Errorf("hash verification failed, deleted %s", tmpFile)
+			}
+		}
+	} else if global.DisableHash {
+		v.Hash = schema.PayloadSkipped
+	}
+
+	// Verify the signature
+	if p.Signature != "" {
+		ok, err := crypto.Verify(schema.PayloadSignatureData(v.HashAlg, sum.Base64()), p.Signature, p.PublisherKey)
+		if err != nil || !ok {
+			v.Signature = schema.PayloadFailed
 			_ = os.Remove(tmpFile)
-			return "", fmt.Errorf("hash verification failed, deleted %s", tmpFile)
+			if err != nil {
+				return "", v, fmt.Errorf("signature verification failed, deleted %s: %w", tmpFile, err)
+			}
+			return "", v, fmt.Errorf("signature verification failed, deleted %s", tmpFile)
 		}
+		v.Signature = schema.PayloadVerified
+		logger.Infof(8108, "Signature verification succeeded for %s", tmpFile)
 	}
 
-	logger.Infof(8104, "Hash verification succeeded for %s", tmpFile)
-	return tmpFile, nil
+	return tmpFile, v, nil
 }
 
-func DownloadExecute(logger interfaces.Logger, comms *communications.Communications, url string, args []string, hash string) error {
+func DownloadExecute(logger interfaces.Logger, comms *communications.Communications, url string, args []string, p Payload) (Verification, error) {
 
 	// Download the file
-	tmpFile, err := Download(logger, comms, url, hash)
+	tmpFile, v, err := DownloadPayload(logger, comms, url, p)
 	if err != nil {
-		return err
+		return v, err
 	}
 
 	// On Windows, we need to add the .exe extension to the file
@@ -69,7 +143,7 @@ func DownloadExecute(logger interfaces.Logger, comms *communications.Communicati
 			err = os.Rename(tmpFile, newName)
 			if err != nil {
 				_ = os.Remove(tmpFile)
-				return fmt.Errorf("error renaming %s to %s: %w", tmpFile, newName, err)
+				return v, fmt.Errorf("error renaming %s to %s: %w", tmpFile, newName, err)
 			}
 			tmpFile = newName
 		}
@@ -79,11 +153,11 @@ func DownloadExecute(logger interfaces.Logger, comms *communications.Communicati
 	err = os.Chmod(tmpFile, 0755)
 	if err != nil {
 		_ = os.Remove(tmpFile)
-		return fmt.Errorf("error making %s executable: %w", tmpFile, err)
+		return v, fmt.Errorf("error making %s executable: %w", tmpFile, err)
 	}
 
 	logger.Infof(8105, "executing %s with argument(s) %v", tmpFile, args)
-	return execute.Execute(logger, tmpFile, args)
+	return v, execute.Execute(logger, tmpFile, args)
 }
 
 // FileToMap reads the specified JSON file and returns a map[string]string of the contents
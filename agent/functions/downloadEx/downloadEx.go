@@ -49,15 +49,18 @@ func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error)
 		return response, errors.New(response.Response)
 	}
 
-	// Check for the hash parameter
+	// Check for the hash parameter. A signature also covers the hash.
 	hash, ok := request.Parameters["hash"]
-	if !ok {
-		if global.DisableHash {
-			hash = ""
-		} else {
-			response.Response = fmt.Sprintf("hash parameter is not specified")
-			return response, errors.New(response.Response)
-		}
+	if !ok && request.Parameters["signature"] == "" && !global.DisableHash {
+		response.Response = fmt.Sprintf("hash parameter is not specified")
+		return response, errors.New(response.Response)
+	}
+
+	payload := common.Payload{
+		Hash:         hash,
+		HashAlg:      request.Parameters["hash_alg"],
+		Signature:    request.Parameters["signature"],
+		PublisherKey: h.config.AC.Get(schema.ConfigAgentPublisherKey).String(),
 	}
 
 	// Collect options from params in the correct order
@@ -71,7 +74,8 @@ func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error)
 		args = append(args, value)
 	}
 
-	err := common.DownloadExecute(h.logger, h.comms, url, args, hash)
+	verification, err := common.DownloadExecute(h.logger, h.comms, url, args, payload)
+	response.Data = verification.Map()
 	if err != nil {
 		response.Response = fmt.Sprintf("error downloading and executing %s: %s", url, err.Error())
 		return response, err
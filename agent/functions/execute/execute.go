@@ -76,12 +76,21 @@ func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error)
 	sshParam := strings.ToLower(request.Parameters["ssh"])
 	useSSH := sshParam == "true" || sshParam == "1" || sshParam == "yes"
 
+	// Optionally run the command as a logged-in user
+	runAs := strings.TrimSpace(request.Parameters["run_as"])
+	if runAs != "" && useSSH {
+		response.Response = "run_as and ssh cannot be used together"
+		response.Success = false
+		return response, errors.New(response.Response)
+	}
+
 	// Assemble log fields
 	f := fields.NewFields(
 		fields.NewField("cmd", request.Request),
 		fields.NewField("requester", request.Requester),
 		fields.NewField("request_id", request.RequestID),
 		fields.NewField("ssh", useSSH),
+		fields.NewField("run_as", runAs),
 	)
 
 	// Log the event
@@ -108,8 +117,24 @@ func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error)
 			response.Response = "executed via SSH"
 		}
 	} else {
-		// Direct execution
+		// Direct execution, as root or the specified user
 		command := exec.Command(cmd, args...)
+		if runAs != "" {
+			var userContext string
+			var release func()
+			command, userContext, release, err = userCommand(runAs, cmd, args)
+			if err != nil {
+				h.logger.Infof(8256, "unable to run \"%s\" as %s: %s", humanReadable, runAs, err.Error())
+				response.Response = fmt.Sprintf("unable to run as %s: %s", runAs, err.Error())
+				response.Success = false
+				return response, err
+			}
+			defer release()
+
+			// Record the user context for audit
+			returnData["run_as"] = userContext
+			h.logger.Info(8257, fmt.Sprintf("running \"%s\" as %s", humanReadable, userContext), f)
+		}
 
 		var outputBuffer bytes.Buffer
 		command.Stdout = &outputBuffer
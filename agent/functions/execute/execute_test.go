@@ -0,0 +1,71 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package execute
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func TestRunAsRequiresLoggedInUser(t *testing.T) {
+	h := New(nil, null.Logger(), nil)
+
+	request := schema.AgentRequest{
+		RequestID:  "R-1",
+		Request:    "execute",
+		Parameters: map[string]string{"cmd": "whoami", "run_as": "uem-no-such-user"},
+	}
+	response, err := h.Cmd(request)
+	if err == nil || response.Success {
+		t.Fatalf("expected failure for an unknown user, got %+v", response)
+	}
+	if !strings.HasPrefix(response.Response, "unable to run as uem-no-such-user") {
+		t.Errorf("unexpected response: %s", response.Response)
+	}
+	if data, ok := response.Data.(*map[string]string); !ok || (*data)["run_as"] != "" {
+		t.Errorf("unexpected response data: %v", response.Data)
+	}
+
+	request.Parameters["ssh"] = "true"
+	if response, err = h.Cmd(request); err == nil || response.Response != "run_as and ssh cannot be used together" {
+		t.Errorf("expected run_as with ssh to be rejected, got %s", response.Response)
+	}
+}
+
+// FuzzExecuteArgs checks that arguments reach the command exactly as sent, without being
+// interpreted by a shell
+func FuzzExecuteArgs(f *testing.F) {
+	if runtime.GOOS == "windows" {
+		f.Skip("uses printf")
+	}
+	for _, s := range []string{"plain", "$(id)", "`id`", "a;id", "a|id", "'; id; '", "*", "~", "-rf", "a\nb"} {
+		f.Add(s)
+	}
+
+	h := New(nil, null.Logger(), nil)
+	f.Fuzz(func(t *testing.T, value string) {
+		// Arguments can not contain NUL
+		if strings.Contains(value, "\x00") {
+			return
+		}
+
+		response, err := h.Cmd(schema.AgentRequest{
+			Request:    "execute",
+			Parameters: map[string]string{"cmd": "printf", "arg1": "[%s]", "arg2": value},
+		})
+		if err != nil || !response.Success {
+			t.Fatalf("%q: execute failed: %v %s", value, err, response.Response)
+		}
+		data := *response.Data.(*map[string]string)
+		if want := "[" + value + "]"; data["output"] != want && len(want) <= maxOutputSize {
+			t.Errorf("%q: command received %q", value, data["output"])
+		}
+	})
+}
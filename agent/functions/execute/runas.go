@@ -0,0 +1,25 @@
+//go:build !windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package execute
+
+import (
+	"fmt"
+	"os/user"
+)
+
+// lookupUser returns a local user other than root, which commands already run as
+func lookupUser(username string) (*user.User, error) {
+	u, err := user.Lookup(username)
+	if err != nil {
+		return nil, fmt.Errorf("user %s not found", username)
+	}
+	if u.Uid == "0" {
+		return nil, fmt.Errorf("run_as is not required for %s, commands run as root by default", username)
+	}
+	return u, nil
+}
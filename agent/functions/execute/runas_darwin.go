@@ -0,0 +1,48 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package execute
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// userCommand returns a command that runs as the specified user, who must be logged in. As
+// with the AppleScript used for status, launchctl asuser places the command in the user's
+// login session and sudo switches to the user. It also returns a description of the user
+// context for the response.
+func userCommand(username, cmd string, args []string) (*exec.Cmd, string, func(), error) {
+	u, err := lookupUser(username)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	output, err := exec.Command("/usr/bin/who").Output()
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("unable to list logged in users: %w", err)
+	}
+
+	var terminal string
+	for _, line := range strings.Split(string(output), "\n") {
+		f := strings.Fields(line)
+		if len(f) > 1 && f[0] == u.Username {
+			terminal = f[1]
+			break
+		}
+	}
+	if terminal == "" {
+		return nil, "", nil, fmt.Errorf("user %s is not logged in", username)
+	}
+
+	command := exec.Command("/bin/launchctl",
+		append([]string{"asuser", u.Uid, "/usr/bin/sudo", "-n", "-H", "-u", u.Username, "--", cmd}, args...)...)
+	command.Dir = u.HomeDir
+
+	return command, fmt.Sprintf("%s (uid %s, %s)", u.Username, u.Uid, terminal), func() {}, nil
+}
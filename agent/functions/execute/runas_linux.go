@@ -0,0 +1,67 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package execute
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// userCommand returns a command that runs as the specified user, who must be logged in. The
+// process switches to the user's IDs after fork and is given the environment of their session.
+// It also returns a description of the user context for the response.
+func userCommand(username, cmd string, args []string) (*exec.Cmd, string, func(), error) {
+	u, err := lookupUser(username)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("invalid uid %s for user %s", u.Uid, username)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("invalid gid %s for user %s", u.Gid, username)
+	}
+
+	// The user's systemd instance also runs while they linger, so only count sessions
+	output, err := exec.Command("loginctl", "show-user", u.Uid, "--property=State", "--value").Output()
+	state := strings.TrimSpace(string(output))
+	if err != nil || (state != "active" && state != "online") {
+		return nil, "", nil, fmt.Errorf("user %s is not logged in", username)
+	}
+
+	var groups []uint32
+	groupIDs, _ := u.GroupIds()
+	for _, g := range groupIDs {
+		if id, err := strconv.ParseUint(g, 10, 32); err == nil {
+			groups = append(groups, uint32(id))
+		}
+	}
+
+	runtimeDir := "/run/user/" + u.Uid
+	command := exec.Command(cmd, args...)
+	command.Dir = u.HomeDir
+	command.Env = []string{
+		"HOME=" + u.HomeDir,
+		"USER=" + u.Username,
+		"LOGNAME=" + u.Username,
+		"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+		"XDG_RUNTIME_DIR=" + runtimeDir,
+		"DBUS_SESSION_BUS_ADDRESS=unix:path=" + runtimeDir + "/bus",
+	}
+	command.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: groups},
+	}
+
+	return command, fmt.Sprintf("%s (uid %s, session %s)", u.Username, u.Uid, state), func() {}, nil
+}
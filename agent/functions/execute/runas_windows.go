@@ -0,0 +1,100 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package execute
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// userCommand returns a command that runs as the specified user, who must be logged in. The
+// process is created with the token of the user's session (CreateProcessAsUser) and the
+// user's environment. It also returns a description of the user context for the response and
+// a function that releases the token once the command has finished.
+func userCommand(username, cmd string, args []string) (*exec.Cmd, string, func(), error) {
+	token, session, account, err := sessionToken(username)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	release := func() { _ = token.Close() }
+
+	env, err := token.Environ(false)
+	if err != nil {
+		release()
+		return nil, "", nil, fmt.Errorf("unable to get the environment of %s: %w", account, err)
+	}
+
+	command := exec.Command(cmd, args...)
+	command.Env = env
+	command.SysProcAttr = &syscall.SysProcAttr{Token: syscall.Token(token), HideWindow: true}
+	if profile, err := token.GetUserProfileDirectory(); err == nil {
+		command.Dir = profile
+	}
+
+	return command, fmt.Sprintf("%s (session %d)", account, session), release, nil
+}
+
+// sessionToken returns the token of a session belonging to the user, who may be specified
+// with or without a domain. Only services running as LocalSystem, as the agent does, can
+// retrieve session tokens.
+func sessionToken(username string) (windows.Token, uint32, string, error) {
+	var sessions *windows.WTS_SESSION_INFO
+	var count uint32
+	if err := windows.WTSEnumerateSessions(0, 0, 1, &sessions, &count); err != nil {
+		return 0, 0, "", fmt.Errorf("unable to list sessions: %w", err)
+	}
+	defer windows.WTSFreeMemory(uintptr(unsafe.Pointer(sessions)))
+
+	for _, s := range unsafe.Slice(sessions, count) {
+		// Disconnected sessions are still logged in
+		if s.State != windows.WTSActive && s.State != windows.WTSDisconnected {
+			continue
+		}
+
+		var token windows.Token
+		if err := windows.WTSQueryUserToken(s.SessionID, &token); err != nil {
+			continue
+		}
+
+		account, err := tokenUsername(token)
+		if err == nil && accountMatches(account, username) {
+			return token, s.SessionID, account, nil
+		}
+		_ = token.Close()
+	}
+	return 0, 0, "", fmt.Errorf("user %s is not logged in", username)
+}
+
+// accountMatches compares an account in the form DOMAIN\user with a user name that may not
+// include the domain
+func accountMatches(account, username string) bool {
+	if strings.Contains(username, `\`) {
+		return strings.EqualFold(account, username)
+	}
+	_, name, _ := strings.Cut(account, `\`)
+	return strings.EqualFold(name, username)
+}
+
+// tokenUsername returns the name of a token's user in the form DOMAIN\user
+func tokenUsername(token windows.Token) (string, error) {
+	tokenUser, err := token.GetTokenUser()
+	if err != nil {
+		return "", fmt.Errorf("unable to get token user: %w", err)
+	}
+
+	account, domain, _, err := tokenUser.User.Sid.LookupAccount("")
+	if err != nil {
+		return "", fmt.Errorf("unable to look up token user: %w", err)
+	}
+	return domain + `\` + account, nil
+}
@@ -0,0 +1,93 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package fdeKeyEscrow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Handler obtains the full disk encryption recovery key and returns it encrypted with the
+// server's public key so that it is never sent or logged in plain text
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	// Assemble log fields
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+	)
+
+	serverPublicEnc := h.config.AP.Get(global.ConfigServerPublicEnc).String()
+	if serverPublicEnc == "" {
+		h.logger.Error(8233, "recovery key escrow failed: server public key not available", f)
+		response.Response = "server public key not available"
+		return response, errors.New(response.Response)
+	}
+
+	a := osActions.New(h.logger)
+	key, err := a.FDERecoveryKey(osActions.UserInfo{
+		AdminUser:     request.Parameters["admin_user"],
+		AdminPassword: request.Parameters["admin_password"],
+	})
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8233, "recovery key escrow failed", f)
+		response.Response = fmt.Sprintf("failed to obtain recovery key: %s", err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	plaintext, err := json.Marshal(key)
+	if err != nil {
+		response.Response = fmt.Sprintf("failed to marshal recovery key: %s", err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	response.RecoveryKey, err = crypto.Encrypt(plaintext, serverPublicEnc)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8233, "recovery key escrow failed", f)
+		response.Response = fmt.Sprintf("failed to encrypt recovery key: %s", err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	f.Append(fields.NewField("type", key.Type))
+	h.logger.Info(8232, "recovery key escrowed", f)
+
+	response.Success = true
+	response.Response = fmt.Sprintf("%s recovery key escrowed", key.Type)
+	response.Data = map[string]string{"type": key.Type, "id": key.ID}
+	return response, nil
+}
@@ -0,0 +1,137 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package fileFetch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/hasher"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// This command reads a file from the agent and uploads it to the server
+
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	path := request.Parameters["path"]
+	if path == "" || !filepath.IsAbs(path) {
+		response.Response = "path must be an absolute path"
+		return response, errors.New(response.Response)
+	}
+	path = filepath.Clean(path)
+
+	// Assemble log fields
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+		fields.NewField("path", path),
+	)
+
+	maxBytes := int64(h.config.AC.Get(schema.ConfigAgentFileFetchMax).Int()) * 1024 * 1024
+	size, err := checkFile(path, maxBytes)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8229, "file fetch failed", f)
+		response.Response = fmt.Sprintf("unable to fetch %s: %s", path, err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	// Hash the file before uploading so the server's copy can be verified
+	hash := hasher.New().SHA256File(path)
+	if len(hash.Bytes()) == 0 {
+		f.Append(fields.NewField("error", "unable to hash file"))
+		h.logger.Error(8229, "file fetch failed", f)
+		response.Response = fmt.Sprintf("error hashing %s", path)
+		return response, errors.New(response.Response)
+	}
+	sha256 := hash.Base64()
+
+	in, err := os.Open(path)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8229, "file fetch failed", f)
+		response.Response = fmt.Sprintf("error opening %s: %s", path, err.Error())
+		return response, errors.New(response.Response)
+	}
+	defer func(in *os.File) {
+		_ = in.Close()
+	}(in)
+
+	upload, err := h.comms.Upload(request.RequestID, filepath.Base(path), in)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8229, "file fetch failed", f)
+		response.Response = fmt.Sprintf("error uploading %s: %s", path, err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	// The file may have changed while it was being read
+	if upload.SHA256 != sha256 {
+		f.Append(fields.NewField("error", "hash mismatch"))
+		h.logger.Error(8229, "file fetch failed", f)
+		response.Response = fmt.Sprintf("%s changed during upload, hash mismatch", path)
+		return response, errors.New(response.Response)
+	}
+
+	f.Append(fields.NewField("size", size), fields.NewField("sha256", sha256))
+	h.logger.Info(8228, "file fetched", f)
+
+	response.Success = true
+	response.Response = fmt.Sprintf("%s uploaded (%d bytes)", path, upload.Size)
+	response.Data = map[string]string{
+		"path":   path,
+		"name":   upload.Name,
+		"size":   strconv.FormatInt(upload.Size, 10),
+		"sha256": sha256,
+	}
+	return response, nil
+}
+
+// checkFile verifies that path is a regular file no larger than maxBytes and returns its size
+func checkFile(path string, maxBytes int64) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if !info.Mode().IsRegular() {
+		return 0, errors.New("not a regular file")
+	}
+
+	if info.Size() > maxBytes {
+		return 0, fmt.Errorf("file size %d exceeds the maximum of %d bytes", info.Size(), maxBytes)
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,307 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package filePush
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/functions/common"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/hasher"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// This command downloads a file, verifies its hash, and writes it to the specified path without executing it
+
+// defaultMode is used if the request does not specify a mode
+const defaultMode = 0644
+
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	// Determine the download URL
+	url, err := h.sourceURL(request.Parameters)
+	if err != nil {
+		response.Response = err.Error()
+		return response, err
+	}
+
+	// Check for the hash parameter
+	hash, ok := request.Parameters["hash"]
+	if !ok {
+		if global.DisableHash {
+			hash = ""
+		} else {
+			response.Response = "hash parameter is not specified"
+			return response, errors.New(response.Response)
+		}
+	}
+
+	// Check the destination
+	path, err := h.safePath(request.Parameters["path"])
+	if err != nil {
+		response.Response = fmt.Sprintf("refusing to write %s: %s", request.Parameters["path"], err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	mode := os.FileMode(defaultMode)
+	if m, ok := request.Parameters["mode"]; ok {
+		v, err := strconv.ParseUint(m, 8, 32)
+		if err != nil || v > 0777 {
+			response.Response = "mode must be octal permissions such as 0644"
+			return response, errors.New(response.Response)
+		}
+		mode = os.FileMode(v)
+	}
+
+	owner := request.Parameters["owner"]
+
+	// Assemble log fields
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+		fields.NewField("url", url),
+		fields.NewField("path", path),
+		fields.NewField("mode", fmt.Sprintf("%04o", mode)),
+		fields.NewField("owner", owner),
+	)
+
+	// Download the file and verify the hash
+	tmpFile, err := common.Download(h.logger, h.comms, url, hash)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8227, "file push failed", f)
+		response.Response = fmt.Sprintf("error downloading %s: %s", url, err.Error())
+		return response, errors.New(response.Response)
+	}
+	defer func(name string) {
+		_ = os.Remove(name)
+	}(tmpFile)
+
+	err = h.install(tmpFile, path, mode, owner)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8227, "file push failed", f)
+		response.Response = fmt.Sprintf("error writing %s: %s", path, err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	// Report the hash of the file as written
+	finalHash := hasher.New().SHA256File(path).Base64()
+	f.Append(fields.NewField("sha256", finalHash))
+	h.logger.Info(8226, "file pushed", f)
+
+	response.Success = true
+	response.Response = fmt.Sprintf("%s written to %s", url, path)
+	response.Data = map[string]string{
+		"path":   path,
+		"sha256": finalHash,
+		"mode":   fmt.Sprintf("%04o", mode),
+	}
+	return response, nil
+}
+
+// sourceURL returns the url parameter or, if a server-hosted filename was specified,
+// the URL of that file on the server
+func (h *Handler) sourceURL(parameters map[string]string) (string, error) {
+	if url, ok := parameters["url"]; ok && url != "" {
+		return url, nil
+	}
+
+	file, ok := parameters["file"]
+	if !ok || file == "" {
+		return "", errors.New("url or file parameter is required")
+	}
+
+	serverURL := h.config.AP.Get(global.ConfigServerURL).String()
+	if serverURL == "" {
+		return "", errors.New("unable to obtain server URL")
+	}
+	return fmt.Sprintf("%s%s/%s", serverURL, schema.EndpointFiles, file), nil
+}
+
+// install copies src to a temporary file in the destination directory, sets its mode
+// and owner, and renames it over the destination so the change is atomic
+func (h *Handler) install(src, dst string, mode os.FileMode, owner string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func(in *os.File) {
+		_ = in.Close()
+	}(in)
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	// Clean up the temporary file on any failure
+	success := false
+	defer func() {
+		if !success {
+			_ = tmp.Close()
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	if _, err = io.Copy(tmp, in); err != nil {
+		return err
+	}
+
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Chmod(tmpName, mode); err != nil {
+		return err
+	}
+
+	if owner != "" {
+		if err = chown(tmpName, owner); err != nil {
+			return err
+		}
+	}
+
+	if err = os.Rename(tmpName, dst); err != nil {
+		return err
+	}
+
+	success = true
+	return nil
+}
+
+// safePath cleans the destination path and refuses paths that would overwrite the
+// agent's binary, configuration, or data directory
+func (h *Handler) safePath(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("path is required")
+	}
+
+	if !filepath.IsAbs(path) {
+		return "", errors.New("path must be absolute")
+	}
+	path = filepath.Clean(path)
+
+	// The parent directory must exist
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", fmt.Errorf("destination directory is not accessible: %w", err)
+	}
+
+	// Resolve symlinks so that a link can not be used to reach a protected file
+	resolved := filepath.Join(dir, filepath.Base(path))
+	if info, err := os.Lstat(resolved); err == nil {
+		if info.IsDir() {
+			return "", errors.New("path is a directory")
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if resolved, err = filepath.EvalSymlinks(resolved); err != nil {
+				return "", fmt.Errorf("unable to resolve symlink: %w", err)
+			}
+		}
+	}
+
+	// Protected files
+	var protected []string
+	if exe, err := os.Executable(); err == nil {
+		protected = append(protected, exe)
+	}
+	protected = append(protected, global.UnixConfigFiles...)
+	protected = append(protected, global.UnixBackupFiles...)
+
+	for _, p := range withResolved(protected) {
+		if samePath(resolved, p) || samePath(path, p) {
+			return "", errors.New("path is a protected agent file")
+		}
+	}
+
+	// Protected directories
+	if dataDir := h.config.AP.Get(global.ConfigAgentDataDir).String(); dataDir != "" {
+		for _, d := range withResolved([]string{dataDir}) {
+			if inDir(resolved, d) || inDir(path, d) {
+				return "", errors.New("path is in the agent's data directory")
+			}
+		}
+	}
+
+	return path, nil
+}
+
+// withResolved returns the paths along with their symlink-resolved equivalents,
+// e.g. /var/root and /private/var/root on macOS
+func withResolved(paths []string) []string {
+	var result []string
+	for _, p := range paths {
+		result = append(result, p)
+		if dir, err := filepath.EvalSymlinks(filepath.Dir(p)); err == nil {
+			result = append(result, filepath.Join(dir, filepath.Base(p)))
+		}
+		if real, err := filepath.EvalSymlinks(p); err == nil {
+			result = append(result, real)
+		}
+	}
+	return result
+}
+
+// samePath compares two paths, ignoring case on Windows
+func samePath(a, b string) bool {
+	a = filepath.Clean(a)
+	b = filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// inDir returns true if path is dir or is within it
+func inDir(path, dir string) bool {
+	dir = filepath.Clean(dir)
+	if samePath(path, dir) {
+		return true
+	}
+
+	prefix := dir + string(os.PathSeparator)
+	if runtime.GOOS == "windows" {
+		return strings.HasPrefix(strings.ToLower(path), strings.ToLower(prefix))
+	}
+	return strings.HasPrefix(path, prefix)
+}
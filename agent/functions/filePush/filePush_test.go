@@ -0,0 +1,104 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package filePush
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/uconfig"
+)
+
+func newTestHandler(t *testing.T, dataDir string) *Handler {
+	t.Helper()
+	c := uconfig.Null()
+	conf := &global.AgentConfig{C: c, AP: c.NewSet(global.ConfigPrivate)}
+	conf.AP.Set(global.ConfigAgentDataDir, dataDir)
+	return New(conf, nil, nil)
+}
+
+func TestSafePath(t *testing.T) {
+	base := t.TempDir()
+	dataDir := filepath.Join(base, "data")
+	if err := os.Mkdir(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHandler(t, dataDir)
+
+	// A symlink pointing into the data directory
+	link := filepath.Join(base, "link")
+	if err := os.Symlink(filepath.Join(dataDir, "state"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{filepath.Join(base, "app.conf"), true},
+		{filepath.Join(base, "sub", "..", "app.conf"), true},
+		{"relative/app.conf", false},
+		{"", false},
+		{base, false},
+		{filepath.Join(base, "missing", "app.conf"), false},
+		{filepath.Join(dataDir, "state"), false},
+		{dataDir + "/../data/state", false},
+		{link, false},
+		{exe, false},
+		{global.UnixConfigFiles[0], false},
+	}
+
+	for _, tc := range tests {
+		_, err := h.safePath(tc.path)
+		if tc.ok && err != nil {
+			t.Errorf("safePath(%q) failed: %v", tc.path, err)
+		}
+		if !tc.ok && err == nil {
+			t.Errorf("safePath(%q) should have been refused", tc.path)
+		}
+	}
+}
+
+func TestInstall(t *testing.T) {
+	dir := t.TempDir()
+	h := newTestHandler(t, "")
+
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	if err := os.WriteFile(src, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.install(src, dst, 0640, ""); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	data, err := os.ReadFile(dst)
+	if err != nil || string(data) != "new" {
+		t.Errorf("unexpected destination contents: %q, %v", data, err)
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("unexpected destination mode: %v, %v", info.Mode().Perm(), err)
+	}
+
+	// Only the source and destination should remain
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("expected 2 files after install, found %d", len(entries))
+	}
+}
@@ -0,0 +1,49 @@
+//go:build darwin || linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package filePush
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// chown sets the owner of the file. owner is in the form user or user:group
+func chown(path, owner string) error {
+	userName, groupName, hasGroup := strings.Cut(owner, ":")
+
+	u, err := user.Lookup(userName)
+	if err != nil {
+		return fmt.Errorf("unknown user %s: %w", userName, err)
+	}
+
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return fmt.Errorf("invalid uid for %s: %w", userName, err)
+	}
+
+	// Default to the user's primary group
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return fmt.Errorf("invalid gid for %s: %w", userName, err)
+	}
+
+	if hasGroup {
+		g, err := user.LookupGroup(groupName)
+		if err != nil {
+			return fmt.Errorf("unknown group %s: %w", groupName, err)
+		}
+		if gid, err = strconv.Atoi(g.Gid); err != nil {
+			return fmt.Errorf("invalid gid for %s: %w", groupName, err)
+		}
+	}
+
+	return os.Chown(path, uid, gid)
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package filePush
+
+import "errors"
+
+// chown is not supported on Windows, where the file inherits the ACL of its directory
+func chown(_, _ string) error {
+	return errors.New("owner is not supported on Windows")
+}
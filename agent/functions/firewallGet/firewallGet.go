@@ -0,0 +1,64 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package firewallGet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Handler returns the detailed (per-profile) state of the host firewall
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	// Assemble log fields
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+	)
+
+	a := osActions.New(h.logger)
+	state, err := a.GetFirewall()
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8223, "failed to obtain firewall state", f)
+		response.Response = fmt.Sprintf("failed to obtain firewall state: %s", err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	h.logger.Info(8222, "firewall state obtained", f)
+	response.Success = true
+	response.Response = "collected"
+	response.Data = &state
+	return response, nil
+}
@@ -0,0 +1,89 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package firewallSet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Handler enables or disables the host firewall
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	var enable bool
+	state := strings.ToLower(request.Parameters["state"])
+	switch state {
+	case "on", "true", "yes", "enable", "enabled":
+		enable = true
+	case "off", "false", "no", "disable", "disabled":
+		enable = false
+	default:
+		response.Response = "state must be on or off"
+		return response, errors.New(response.Response)
+	}
+
+	// Assemble log fields
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+		fields.NewField("state", state),
+	)
+
+	a := osActions.New(h.logger)
+	err := a.SetFirewall(enable)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8221, "failed to set firewall state", f)
+		response.Response = fmt.Sprintf("failed to turn firewall %s: %s", onOff(enable), err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	h.logger.Info(8220, "firewall state set", f)
+	response.Success = true
+	response.Response = fmt.Sprintf("firewall turned %s", onOff(enable))
+
+	// Include the resulting state if it can be obtained
+	if fwState, err := a.GetFirewall(); err == nil {
+		response.Data = &fwState
+	}
+	return response, nil
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
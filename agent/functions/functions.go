@@ -6,15 +6,31 @@
 package functions
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 
 	"github.com/UnifyEM/UnifyEM/agent/communications"
 	"github.com/UnifyEM/UnifyEM/agent/functions/downloadEx"
 	"github.com/UnifyEM/UnifyEM/agent/functions/execute"
+	"github.com/UnifyEM/UnifyEM/agent/functions/fdeKeyEscrow"
+	"github.com/UnifyEM/UnifyEM/agent/functions/fileFetch"
+	"github.com/UnifyEM/UnifyEM/agent/functions/filePush"
+	"github.com/UnifyEM/UnifyEM/agent/functions/firewallGet"
+	"github.com/UnifyEM/UnifyEM/agent/functions/firewallSet"
+	"github.com/UnifyEM/UnifyEM/agent/functions/logsFetch"
+	"github.com/UnifyEM/UnifyEM/agent/functions/patchInstall"
+	"github.com/UnifyEM/UnifyEM/agent/functions/patchStatus"
 	"github.com/UnifyEM/UnifyEM/agent/functions/ping"
+	"github.com/UnifyEM/UnifyEM/agent/functions/processKill"
+	"github.com/UnifyEM/UnifyEM/agent/functions/processList"
 	"github.com/UnifyEM/UnifyEM/agent/functions/reboot"
 	"github.com/UnifyEM/UnifyEM/agent/functions/refreshServiceAccount"
+	"github.com/UnifyEM/UnifyEM/agent/functions/screenLockSet"
+	"github.com/UnifyEM/UnifyEM/agent/functions/serviceControl"
+	"github.com/UnifyEM/UnifyEM/agent/functions/setLogLevel"
+	"github.com/UnifyEM/UnifyEM/agent/functions/setServerURL"
+	"github.com/UnifyEM/UnifyEM/agent/functions/shellStart"
 	"github.com/UnifyEM/UnifyEM/agent/functions/shutdown"
 	"github.com/UnifyEM/UnifyEM/agent/functions/status"
 	"github.com/UnifyEM/UnifyEM/agent/functions/upgrade"
@@ -26,17 +42,23 @@ import (
 	"github.com/UnifyEM/UnifyEM/agent/functions/userPassword"
 	"github.com/UnifyEM/UnifyEM/agent/functions/userUnlock"
 	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/schema/commands"
 )
 
+// Command dispatches requests to the command handlers. The agent creates one at startup and
+// reuses it for every request. Handlers hold only their dependencies and keep no state between
+// requests, so a Command is safe to reuse.
 type Command struct {
 	logger         interfaces.Logger
 	config         *global.AgentConfig
 	comms          *communications.Communications
 	handlers       map[string]CmdHandler
 	userDataSource status.UserDataSource
+	userRequester  status.UserRequester
 }
 
 type CmdHandler interface {
@@ -68,9 +90,24 @@ func New(options ...func(*Command) error) (*Command, error) {
 	// Add command handlers
 	c.addHandler(commands.DownloadExecute, downloadEx.New(c.config, c.logger, c.comms))
 	c.addHandler(commands.Execute, execute.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.FDEKeyEscrow, fdeKeyEscrow.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.FilePush, filePush.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.FileFetch, fileFetch.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.FirewallGet, firewallGet.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.FirewallSet, firewallSet.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.LogsFetch, logsFetch.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.PatchInstall, patchInstall.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.PatchStatus, patchStatus.New(c.config, c.logger, c.comms))
 	c.addHandler(commands.Status, status.New(c.config, c.logger, c.comms, c.userDataSource))
 	c.addHandler(commands.Ping, ping.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.ProcessKill, processKill.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.ProcessList, processList.New(c.config, c.logger, c.comms))
 	c.addHandler(commands.Reboot, reboot.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.ScreenLockSet, screenLockSet.New(c.config, c.logger, c.comms, c.userRequester))
+	c.addHandler(commands.ServiceControl, serviceControl.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.SetLogLevel, setLogLevel.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.SetServerURL, setServerURL.New(c.config, c.logger, c.comms))
+	c.addHandler(commands.ShellStart, shellStart.New(c.config, c.logger, c.comms))
 	c.addHandler(commands.Shutdown, shutdown.New(c.config, c.logger, c.comms))
 	c.addHandler(commands.Upgrade, upgrade.New(c.config, c.logger, c.comms))
 	c.addHandler(commands.RefreshServiceAccount, refreshServiceAccount.New(c.config, c.logger, c.comms))
@@ -118,6 +155,11 @@ func WithComms(comms *communications.Communications) func(*Command) error {
 func WithUserDataSource(userDataSource status.UserDataSource) func(*Command) error {
 	return func(c *Command) error {
 		c.userDataSource = userDataSource
+
+		// The user data source may also be able to perform requests in user context
+		if requester, ok := userDataSource.(status.UserRequester); ok {
+			c.userRequester = requester
+		}
 		return nil
 	}
 }
@@ -144,6 +186,19 @@ func (c *Command) ExecuteRequest(request schema.AgentRequest) schema.AgentRespon
 		return response
 	}
 
+	// Requests refused by the command policy are reported with a status of their own
+	err = c.checkPolicy(request)
+	if err != nil {
+		c.logger.Warning(8258, "request refused by policy", fields.NewFields(
+			fields.NewField("cmd", request.Request),
+			fields.NewField("request_id", request.RequestID),
+			fields.NewField("requester", request.Requester),
+			fields.NewField("reason", err.Error())))
+		response.Response = err.Error()
+		response.Refused = true
+		return response
+	}
+
 	// Check if the command exists in the handlers map
 	handler, exists := c.handlers[request.Request]
 	if !exists {
@@ -160,5 +215,39 @@ func (c *Command) ExecuteRequest(request schema.AgentRequest) schema.AgentRespon
 	} else {
 		response.Success = true
 	}
+
+	// Only the server can read responses to sensitive commands. If the response can't be
+	// encrypted it is discarded rather than sent in plaintext.
+	if response.Success && !response.InProgress && commands.IsSensitive(request.Request) {
+		err = c.encryptResponse(&response)
+		if err != nil {
+			response.Response = fmt.Sprintf("unable to encrypt response: %s", err.Error())
+			response.Data = nil
+			response.Success = false
+		}
+	}
 	return response
 }
+
+// encryptResponse replaces the response details and data with a copy encrypted with the
+// server's public key
+func (c *Command) encryptResponse(response *schema.AgentResponse) error {
+	serverPublicEnc := c.config.AP.Get(global.ConfigServerPublicEnc).String()
+	if serverPublicEnc == "" {
+		return errors.New("server public key not available")
+	}
+
+	plaintext, err := json.Marshal(schema.ResponseBody{Response: response.Response, Data: response.Data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	response.EncryptedResponse, err = crypto.Encrypt(plaintext, serverPublicEnc)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt response: %w", err)
+	}
+
+	response.Response = schema.ResponseEncryptedDetails
+	response.Data = nil
+	return nil
+}
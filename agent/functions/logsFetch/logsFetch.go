@@ -0,0 +1,282 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package logsFetch
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+)
+
+// This command returns the agent's own log, either in the response or, if it is too
+// large for the response, by uploading it to the server as file_fetch does
+
+const (
+	defaultLines   = 200       // Lines returned if neither lines nor since is specified
+	inlineMaxBytes = 64 * 1024 // Logs larger than this are uploaded rather than returned in the response
+	timeLayout     = "2006-01-02 15:04:05"
+)
+
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	// Assemble log fields
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+	)
+
+	lines := defaultLines
+	var since time.Time
+	var err error
+
+	if v, ok := request.Parameters["since"]; ok {
+		since, err = commands.ParseSince(v)
+		if err != nil {
+			response.Response = err.Error()
+			return response, err
+		}
+		lines = 0
+	} else if v, ok = request.Parameters["lines"]; ok {
+		lines, err = strconv.Atoi(v)
+		if err != nil || lines < 1 || lines > commands.MaxLogLines {
+			response.Response = fmt.Sprintf("lines must be between 1 and %d", commands.MaxLogLines)
+			return response, errors.New(response.Response)
+		}
+	}
+
+	logFile := h.config.AP.Get(global.ConfigAgentLogFile).String()
+	if logFile == "" {
+		response.Response = "log file is not configured"
+		return response, errors.New(response.Response)
+	}
+
+	maxBytes := int64(h.config.AC.Get(schema.ConfigAgentFileFetchMax).Int()) * 1024 * 1024
+	log, count, truncated, err := readLog(logFiles(logFile, since), lines, since, maxBytes)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8231, "logs fetch failed", f)
+		response.Response = fmt.Sprintf("unable to read log: %s", err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	f.Append(fields.NewField("lines", count), fields.NewField("size", len(log)))
+	response.Data = map[string]string{
+		"lines":     strconv.Itoa(count),
+		"size":      strconv.Itoa(len(log)),
+		"truncated": strconv.FormatBool(truncated),
+	}
+
+	// Small logs are returned in the response
+	if len(log) <= inlineMaxBytes {
+		h.logger.Info(8230, "logs fetched", f)
+		response.Data.(map[string]string)["log"] = string(log)
+		response.Success = true
+		response.Response = fmt.Sprintf("%d lines returned", count)
+		return response, nil
+	}
+
+	// Larger logs are uploaded
+	name := fmt.Sprintf("%s-%s.log", global.LogName, time.Now().Format("20060102-150405"))
+	upload, err := h.comms.Upload(request.RequestID, name, bytes.NewReader(log))
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8231, "logs fetch failed", f)
+		response.Response = fmt.Sprintf("error uploading log: %s", err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	f.Append(fields.NewField("sha256", upload.SHA256))
+	h.logger.Info(8230, "logs fetched", f)
+
+	response.Data.(map[string]string)["name"] = upload.Name
+	response.Data.(map[string]string)["sha256"] = upload.SHA256
+	response.Success = true
+	response.Response = fmt.Sprintf("%d lines uploaded (%d bytes)", count, upload.Size)
+	return response, nil
+}
+
+// logFiles returns the log file and any rotated log files that may contain entries
+// after since, oldest first. If since is zero, all rotated log files are returned.
+// Rotated files are named <log file>-YYYYMMDD by the logger.
+func logFiles(logFile string, since time.Time) []string {
+	var files []string
+
+	base := filepath.Base(logFile)
+	entries, err := os.ReadDir(filepath.Dir(logFile))
+	if err == nil {
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || len(name) != len(base)+9 || !strings.HasPrefix(name, base+"-") {
+				continue
+			}
+
+			date, err := time.ParseInLocation("20060102", name[len(base)+1:], time.Local)
+			if err != nil {
+				continue
+			}
+
+			// A rotated file contains entries up to the end of the day in its name
+			if !since.IsZero() && date.AddDate(0, 0, 1).Before(since) {
+				continue
+			}
+			files = append(files, filepath.Join(filepath.Dir(logFile), name))
+		}
+	}
+
+	// The dates sort correctly as strings
+	sort.Strings(files)
+	return append(files, logFile)
+}
+
+// readLog returns the last n lines of the log files, or if n is zero, the lines logged at
+// or after since. If the result would exceed maxBytes, the oldest lines are dropped and
+// truncated is true. The files must be ordered oldest first and the last must exist.
+func readLog(files []string, n int, since time.Time, maxBytes int64) ([]byte, int, bool, error) {
+	var lines []string
+	truncated := false
+
+	if n > 0 {
+		// Read the newest files first until there are enough lines
+		for x := len(files) - 1; x >= 0 && len(lines) < n; x-- {
+			need := n - len(lines)
+			fileLines, partial, err := readFile(files[x], time.Time{}, need, maxBytes)
+			if err != nil {
+				if x == len(files)-1 {
+					return nil, 0, false, err
+				}
+				continue
+			}
+			lines = append(fileLines, lines...)
+
+			// Older files cannot be used if the start of this one was skipped
+			if partial && len(fileLines) < need {
+				truncated = true
+				break
+			}
+		}
+	} else {
+		for x, file := range files {
+			fileLines, partial, err := readFile(file, since, 0, maxBytes)
+			if err != nil {
+				if x == len(files)-1 {
+					return nil, 0, false, err
+				}
+				continue
+			}
+			truncated = truncated || partial
+			lines = append(lines, fileLines...)
+		}
+	}
+
+	// Drop the oldest lines to stay within maxBytes
+	var size int64
+	for _, line := range lines {
+		size += int64(len(line)) + 1
+	}
+	for size > maxBytes && len(lines) > 0 {
+		size -= int64(len(lines[0])) + 1
+		lines = lines[1:]
+		truncated = true
+	}
+
+	if len(lines) == 0 {
+		return []byte{}, 0, truncated, nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), len(lines), truncated, nil
+}
+
+// readFile returns the lines of a log file logged at or after since, or if keep is greater
+// than zero, the last keep lines. Lines without a timestamp are kept with the preceding line.
+// Only the last maxBytes of the file are read, in which case partial is true.
+func readFile(path string, since time.Time, keep int, maxBytes int64) ([]string, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	partial := false
+	info, err := f.Stat()
+	if err == nil && info.Size() > maxBytes {
+		_, err = f.Seek(info.Size()-maxBytes, io.SeekStart)
+		if err != nil {
+			return nil, false, err
+		}
+		partial = true
+	}
+
+	var lines []string
+	include := since.IsZero()
+	skip := partial
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		// Skip the remainder of the line that was split by seeking
+		if skip {
+			skip = false
+			continue
+		}
+
+		if !since.IsZero() && len(line) >= len(timeLayout) {
+			if t, err := time.ParseInLocation(timeLayout, line[:len(timeLayout)], time.Local); err == nil {
+				include = !t.Before(since)
+			}
+		}
+		if !include {
+			continue
+		}
+
+		lines = append(lines, line)
+		if keep > 0 && len(lines) > keep {
+			lines = lines[1:]
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, partial, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return lines, partial, nil
+}
@@ -0,0 +1,70 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package logsFetch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadLog(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "uem-agent.log")
+
+	files := map[string]string{
+		logFile + "-20260101": "2026-01-01 10:00:00 old one\n2026-01-01 11:00:00 old two\n",
+		logFile + "-20260102": "2026-01-02 10:00:00 rotated one\ncontinued\n2026-01-02 11:00:00 rotated two\n",
+		logFile:               "2026-01-03 10:00:00 current one\r\n2026-01-03 11:00:00 current two\r\n",
+		logFile + ".bak":      "2026-01-03 12:00:00 ignored\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The last lines span the current and rotated files
+	log, count, truncated, err := readLog(logFiles(logFile, time.Time{}), 4, time.Time{}, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "continued\n2026-01-02 11:00:00 rotated two\n2026-01-03 10:00:00 current one\n2026-01-03 11:00:00 current two\n"
+	if string(log) != expected || count != 4 || truncated {
+		t.Errorf("unexpected lines result (%d, %v):\n%s", count, truncated, log)
+	}
+
+	// Entries since a time include continuation lines and skip older rotated files
+	since := time.Date(2026, 1, 2, 10, 30, 0, 0, time.Local)
+	list := logFiles(logFile, since)
+	if len(list) != 2 {
+		t.Errorf("expected 2 files since %v, got %v", since, list)
+	}
+	since = time.Date(2026, 1, 2, 9, 0, 0, 0, time.Local)
+	log, count, _, err = readLog(logFiles(logFile, since), 0, since, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 || string(log[:len("2026-01-02 10:00:00")]) != "2026-01-02 10:00:00" {
+		t.Errorf("unexpected since result (%d):\n%s", count, log)
+	}
+
+	// The oldest lines are dropped to stay within the size limit
+	log, _, truncated, err = readLog(logFiles(logFile, time.Time{}), 0, time.Time{}, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(log) != "2026-01-03 11:00:00 current two\n" || !truncated {
+		t.Errorf("unexpected truncated result (%v):\n%s", truncated, log)
+	}
+
+	// The current log must exist
+	_, _, _, err = readLog([]string{filepath.Join(dir, "missing.log")}, 10, time.Time{}, 1024)
+	if err == nil {
+		t.Error("expected an error for a missing log file")
+	}
+}
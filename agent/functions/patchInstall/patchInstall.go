@@ -0,0 +1,161 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package patchInstall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+)
+
+const (
+	outputLines = 20  // lines of installer output included in the response
+	restartWait = 300 // seconds to wait for the final response to be sent before restarting
+)
+
+// installing is package level because a new handler is created for each batch of requests
+var installing atomic.Bool
+
+// Handler installs operating system updates. Installation can take a long time, so it runs
+// in the background: the handler responds immediately with InProgress set, and the final
+// response, including the remaining pending updates, is queued when the installation ends.
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	// Assemble log fields
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+	)
+
+	// Parameters have been validated
+	names := commands.PatchNames(request.Parameters["updates"])
+	rebootAllowed, _ := strconv.ParseBool(request.Parameters["reboot_allowed"])
+
+	if !installing.CompareAndSwap(false, true) {
+		h.logger.Warning(8237, "update installation already in progress", f)
+		response.Response = "an update installation is already in progress"
+		return response, errors.New(response.Response)
+	}
+
+	f.Append(fields.NewField("updates", strings.Join(names, ",")), fields.NewField("reboot_allowed", rebootAllowed))
+	h.logger.Info(8236, "starting update installation", f)
+	go h.install(request, names, rebootAllowed, f)
+
+	response.InProgress = true
+	if len(names) == 0 {
+		response.Response = "installing all pending updates"
+	} else {
+		response.Response = fmt.Sprintf("installing %d updates", len(names))
+	}
+	return response, nil
+}
+
+// install installs the updates and queues the final response. If a restart is required and
+// permitted, the system is restarted once the response has been sent.
+func (h *Handler) install(request schema.AgentRequest, names []string, rebootAllowed bool, f *fields.Fields) {
+	defer installing.Store(false)
+
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+
+	ctx, cancel := context.WithTimeout(context.Background(), global.PatchInstallTimeout*time.Second)
+	defer cancel()
+
+	a := osActions.New(h.logger)
+	out, rebootRequired, err := a.InstallPatches(ctx, names)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8239, "update installation failed", f)
+		response.Response = fmt.Sprintf("failed to install updates: %s", err.Error())
+	} else {
+		h.logger.Info(8238, "update installation complete", f)
+		response.Success = true
+		response.Response = lastLines(out, outputLines)
+	}
+
+	// Include the updates that remain so that the server's patch status is current
+	status, err := a.PatchStatus()
+	if err == nil {
+		status.RebootRequired = status.RebootRequired || rebootRequired
+		response.Data = &status
+	}
+
+	restart := rebootRequired && rebootAllowed
+	switch {
+	case restart:
+		response.Response += "\nrestarting to complete the installation"
+	case rebootRequired:
+		response.Response += "\na restart is required to complete the installation"
+	}
+
+	h.comms.QueueResponse(response)
+	if restart {
+		h.restart(a, f)
+	}
+}
+
+// restart waits for queued responses to be sent, then restarts the system
+func (h *Handler) restart(a *osActions.Actions, f *fields.Fields) {
+	for i := 0; i < restartWait/global.TaskTicker && h.comms.ResponsesPending(); i++ {
+		time.Sleep(global.TaskTicker * time.Second)
+	}
+
+	// Allow time for the sync to complete transmission
+	time.Sleep(5 * time.Second)
+
+	h.logger.Info(8240, "restarting to complete update installation", f)
+	if err := a.Reboot(); err != nil {
+		h.logger.Errorf(8241, "restart failed: %s", err.Error())
+	}
+}
+
+// lastLines returns the last n non-empty lines of the installer output
+func lastLines(out string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -6,6 +6,8 @@
 package ping
 
 import (
+	"time"
+
 	"github.com/UnifyEM/UnifyEM/agent/communications"
 	"github.com/UnifyEM/UnifyEM/agent/global"
 	"github.com/UnifyEM/UnifyEM/common/fields"
@@ -13,7 +15,8 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
-// Ping responds with "pong" to a "ping" request
+// Ping responds with "pong" to a "ping" request, including the times the request was received
+// and responded to so that the server can report latency
 
 type Handler struct {
 	config *global.AgentConfig
@@ -38,6 +41,19 @@ func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error)
 	response.Response = "pong"
 	response.Success = true
 
+	// The elapsed time uses the monotonic clock, and the response time is derived from it so
+	// that the two timestamps are consistent even if the wall clock changes
+	received := request.Received
+	if received.IsZero() {
+		received = time.Now()
+	}
+	elapsed := time.Since(received)
+	response.Data = schema.PingData{
+		Received:  received,
+		Responded: received.Add(elapsed),
+		ElapsedMs: elapsed.Milliseconds(),
+	}
+
 	// Assemble log fields
 	f := fields.NewFields(
 		fields.NewField("cmd", request.Request),
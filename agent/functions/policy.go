@@ -0,0 +1,127 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package functions
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+)
+
+// The command policy restricts the requests that the agent accepts. disabled_commands lists
+// commands that are refused, and exec_allowed_paths restricts execute and download_execute to
+// programs under the listed directories. Both are agent settings pushed by the server, but the
+// same restrictions can also be pinned locally with "uem-agent policy", which the server can't
+// change. A command disabled by either is refused, and a program must be allowed by each list
+// that is set, so the server can add to a pinned policy but not relax it.
+
+// checkPolicy returns an error if the command policy does not allow the request
+func (c *Command) checkPolicy(request schema.AgentRequest) error {
+	disabled := append(policyList(c.config.AC.Get(schema.ConfigAgentDisabledCommands).String()),
+		policyList(c.config.AP.Get(global.ConfigPolicyDisabled).String())...)
+	for _, name := range disabled {
+		if strings.EqualFold(name, request.Request) {
+			return fmt.Errorf("%s is disabled by policy", request.Request)
+		}
+	}
+
+	// download_execute runs the payload from the download directory
+	var program string
+	switch request.Request {
+	case commands.Execute:
+		program = programPath(request.Parameters["cmd"])
+	case commands.DownloadExecute:
+		program = resolvePath(communications.DownloadDir())
+	default:
+		return nil
+	}
+
+	for _, allowed := range [][]string{
+		policyList(c.config.AC.Get(schema.ConfigAgentExecAllowedPaths).String()),
+		policyList(c.config.AP.Get(global.ConfigPolicyExecPaths).String()),
+	} {
+		if len(allowed) > 0 && !pathAllowed(program, allowed) {
+			return fmt.Errorf("%s of %s is disabled by policy, it is not in an allowed path", request.Request, program)
+		}
+	}
+	return nil
+}
+
+// policyList splits a comma-separated policy setting, ignoring empty items
+func policyList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// programPath returns the absolute path of the program that a command runs. A command without a
+// path is found using PATH, as it is when it runs. If the program can't be found, the command is
+// returned as it is and is not in any allowed path.
+func programPath(cmd string) string {
+	if !strings.ContainsAny(cmd, `/\`) {
+		found, err := exec.LookPath(cmd)
+		if err != nil {
+			return cmd
+		}
+		cmd = found
+	}
+	abs, err := filepath.Abs(cmd)
+	if err != nil {
+		return cmd
+	}
+	return resolvePath(abs)
+}
+
+// resolvePath follows symbolic links so that a link can't be used to escape an allowed path
+func resolvePath(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return resolved
+}
+
+// pathAllowed returns true if path is one of the allowed directories or inside one. Relative
+// entries are ignored.
+func pathAllowed(path string, allowed []string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+	}
+
+	for _, dir := range allowed {
+		if !filepath.IsAbs(dir) {
+			continue
+		}
+		dir = resolvePath(dir)
+		if runtime.GOOS == "windows" {
+			dir = strings.ToLower(dir)
+		}
+		if path == dir {
+			return true
+		}
+		if !strings.HasSuffix(dir, string(filepath.Separator)) {
+			dir += string(filepath.Separator)
+		}
+		if strings.HasPrefix(path, dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package functions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/common/uconfig"
+)
+
+func newTestCommand(t *testing.T) *Command {
+	t.Helper()
+	c := uconfig.Null()
+	conf := &global.AgentConfig{C: c, AC: schema.SetAgentDefaults(c), AP: c.NewSet(global.ConfigPrivate)}
+	cmd, err := New(WithLogger(null.Logger()), WithConfig(conf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cmd
+}
+
+func TestPolicyDisabledCommands(t *testing.T) {
+	c := newTestCommand(t)
+	ping := schema.AgentRequest{RequestID: "R-1", Request: commands.Ping, Parameters: map[string]string{"agent_id": "A-1"}}
+
+	c.config.AC.Set(schema.ConfigAgentDisabledCommands, "execute, PING")
+	response := c.ExecuteRequest(ping)
+	if response.Success || !response.Refused || response.Response != "ping is disabled by policy" {
+		t.Errorf("expected ping to be refused, got %+v", response)
+	}
+
+	// The server can't relax a pinned policy
+	c.config.AC.Set(schema.ConfigAgentDisabledCommands, "")
+	c.config.AP.Set(global.ConfigPolicyDisabled, "ping")
+	if response = c.ExecuteRequest(ping); !response.Refused {
+		t.Errorf("expected ping to be refused by the pinned policy, got %+v", response)
+	}
+
+	c.config.AP.Set(global.ConfigPolicyDisabled, "")
+	if response = c.ExecuteRequest(ping); !response.Success || response.Refused {
+		t.Errorf("expected ping to succeed, got %+v", response)
+	}
+}
+
+func TestPolicyExecAllowedPaths(t *testing.T) {
+	c := newTestCommand(t)
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exe = resolvePath(exe)
+	dir := filepath.Dir(exe)
+	request := func(cmd string) schema.AgentRequest {
+		return schema.AgentRequest{RequestID: "R-1", Request: commands.Execute, Parameters: map[string]string{"cmd": cmd}}
+	}
+
+	// Without allowed paths, nothing is refused
+	if err = c.checkPolicy(request(exe)); err != nil {
+		t.Errorf("unexpected refusal: %v", err)
+	}
+
+	c.config.AC.Set(schema.ConfigAgentExecAllowedPaths, dir)
+	if err = c.checkPolicy(request(exe)); err != nil {
+		t.Errorf("unexpected refusal of %s: %v", exe, err)
+	}
+	if err = c.checkPolicy(request(filepath.Join(dir, "..", filepath.Base(dir)+"x", "prog"))); err == nil {
+		t.Error("expected a sibling directory sharing the prefix to be refused")
+	}
+	if err = c.checkPolicy(request("uem-no-such-program")); err == nil || !strings.Contains(err.Error(), "disabled by policy") {
+		t.Errorf("expected an unknown program to be refused, got %v", err)
+	}
+
+	// A symlink in an allowed directory to a program elsewhere is refused
+	linkDir := t.TempDir()
+	link := filepath.Join(linkDir, "prog")
+	if err = os.Symlink(exe, link); err == nil {
+		c.config.AC.Set(schema.ConfigAgentExecAllowedPaths, linkDir)
+		if err = c.checkPolicy(request(link)); err == nil {
+			t.Error("expected a symlink out of the allowed path to be refused")
+		}
+	}
+
+	// Each list that is set must allow the program, so the server can't relax a pinned list
+	c.config.AC.Set(schema.ConfigAgentExecAllowedPaths, dir)
+	c.config.AP.Set(global.ConfigPolicyExecPaths, linkDir)
+	if err = c.checkPolicy(request(exe)); err == nil {
+		t.Error("expected the pinned allowed paths to apply")
+	}
+	c.config.AC.Set(schema.ConfigAgentExecAllowedPaths, "")
+	if err = c.checkPolicy(request(exe)); err == nil {
+		t.Error("expected the pinned allowed paths to apply without server allowed paths")
+	}
+
+	// Other commands are not affected
+	if err = c.checkPolicy(schema.AgentRequest{Request: commands.Ping}); err != nil {
+		t.Errorf("unexpected refusal of ping: %v", err)
+	}
+}
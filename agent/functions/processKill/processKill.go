@@ -0,0 +1,87 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package processKill
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Handler terminates the processes matching a PID and/or an exact name
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	// Parameters have been validated
+	pid, _ := strconv.Atoi(request.Parameters["pid"])
+	name := request.Parameters["name"]
+	force, _ := strconv.ParseBool(request.Parameters["force"])
+
+	// Assemble log fields
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+		fields.NewField("pid", pid),
+		fields.NewField("name", name),
+		fields.NewField("force", force),
+	)
+
+	a := osActions.New(h.logger)
+	killed, err := a.KillProcesses(pid, name, force)
+
+	// Report the processes that were killed even if others could not be
+	var list []string
+	for _, p := range killed {
+		list = append(list, fmt.Sprintf("%d (%s)", p.PID, p.Name))
+	}
+	if len(killed) > 0 {
+		f.Append(fields.NewField("killed", strings.Join(list, ", ")))
+		response.Data = map[string]any{"killed": killed}
+	}
+
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8245, "process kill failed", f)
+		response.Response = err.Error()
+		if len(killed) > 0 {
+			response.Response = fmt.Sprintf("killed %s; %s", strings.Join(list, ", "), err.Error())
+		}
+		return response, errors.New(response.Response)
+	}
+
+	h.logger.Info(8244, "processes killed", f)
+	response.Success = true
+	response.Response = fmt.Sprintf("killed %s", strings.Join(list, ", "))
+	return response, nil
+}
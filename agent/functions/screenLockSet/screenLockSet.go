@@ -0,0 +1,81 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package screenLockSet
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/functions/status"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Handler configures the screen to lock after a period of inactivity and require a password
+type Handler struct {
+	config        *global.AgentConfig
+	logger        interfaces.Logger
+	comms         *communications.Communications
+	userRequester status.UserRequester // used on platforms where settings must be applied in user context
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications, userRequester status.UserRequester) *Handler {
+	return &Handler{
+		config:        config,
+		logger:        logger,
+		comms:         comms,
+		userRequester: userRequester,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	delay, err := strconv.Atoi(request.Parameters["delay_minutes"])
+	if err != nil || delay < osActions.ScreenLockMinDelay || delay > osActions.ScreenLockMaxDelay {
+		response.Response = fmt.Sprintf("delay_minutes must be a number between %d and %d",
+			osActions.ScreenLockMinDelay, osActions.ScreenLockMaxDelay)
+		return response, errors.New(response.Response)
+	}
+
+	// Assemble log fields
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+		fields.NewField("delay_minutes", delay),
+	)
+
+	settings, err := h.setScreenLock(request, delay)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8225, "failed to set screen lock", f)
+		response.Response = fmt.Sprintf("failed to set screen lock: %s", err.Error())
+		return response, errors.New(response.Response)
+	}
+
+	f.Append(
+		fields.NewField("user", settings.User),
+		fields.NewField("effective_delay_minutes", settings.DelayMinutes),
+		fields.NewField("require_password", settings.RequirePassword))
+	h.logger.Info(8224, "screen lock set", f)
+
+	response.Success = true
+	response.Response = fmt.Sprintf("screen lock set for %s: locks after %d minutes, password required: %t",
+		settings.User, settings.DelayMinutes, settings.RequirePassword)
+	response.Data = &settings
+	return response, nil
+}
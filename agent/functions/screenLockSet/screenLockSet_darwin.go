@@ -0,0 +1,49 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package screenLockSet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/functions/status"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// setScreenLock asks the console user's helper to apply the settings because the
+// screensaver preferences are per-user and can not be written from the daemon
+func (h *Handler) setScreenLock(request schema.AgentRequest, delay int) (osActions.ScreenLockSettings, error) {
+	var settings osActions.ScreenLockSettings
+
+	if h.userRequester == nil {
+		return settings, errors.New("user-helper support is not available")
+	}
+
+	result, err := h.userRequester.UserRequest(status.UserRequest{
+		ID:         request.RequestID,
+		Type:       request.Request,
+		Parameters: map[string]string{"delay_minutes": strconv.Itoa(delay)},
+	}, global.UserRequestTimeout*time.Second)
+	if err != nil {
+		return settings, err
+	}
+
+	if !result.Success {
+		return settings, errors.New(result.Response)
+	}
+
+	if err = json.Unmarshal(result.Data, &settings); err != nil {
+		return settings, fmt.Errorf("invalid result from user-helper: %w", err)
+	}
+	return settings, nil
+}
@@ -0,0 +1,18 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package screenLockSet
+
+import (
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// setScreenLock applies the settings directly; the agent has sufficient privileges to change the user's settings
+func (h *Handler) setScreenLock(_ schema.AgentRequest, delay int) (osActions.ScreenLockSettings, error) {
+	return osActions.New(h.logger).SetScreenLock(delay)
+}
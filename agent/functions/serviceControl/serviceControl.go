@@ -0,0 +1,71 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package serviceControl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Handler starts, stops, restarts, or queries a service using the OS service manager
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	// Parameters have been validated, including that the service is not the agent
+	name := request.Parameters["name"]
+	action := request.Parameters["action"]
+
+	// Assemble log fields
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+		fields.NewField("name", name),
+		fields.NewField("action", action),
+	)
+
+	a := osActions.New(h.logger)
+	state, err := a.ServiceControl(name, action)
+	response.Data = &state
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8247, "service control failed", f)
+		response.Response = err.Error()
+		return response, errors.New(response.Response)
+	}
+
+	f.Append(fields.NewField("state", state.State))
+	h.logger.Info(8246, "service control complete", f)
+	response.Success = true
+	response.Response = fmt.Sprintf("%s %s: %s", action, name, state.State)
+	return response, nil
+}
@@ -0,0 +1,105 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package setLogLevel
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/ulogger"
+)
+
+// This command changes the level of the running agent's logger, for example to collect debug
+// messages from a misbehaving agent without changing its configuration. If a duration is
+// specified, the configured level is restored when it expires, otherwise when the agent restarts.
+
+// levelLogger is implemented by loggers whose level can be changed while in use
+type levelLogger interface {
+	Level() ulogger.Level
+	SetLevelOverride(ulogger.Level, time.Duration) error
+}
+
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	level, err := ulogger.ParseLevel(request.Parameters["level"])
+	if err != nil {
+		response.Response = err.Error()
+		return response, err
+	}
+
+	var duration time.Duration
+	if v, ok := request.Parameters["duration_minutes"]; ok {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes < 1 {
+			response.Response = "duration_minutes must be a positive number"
+			return response, errors.New(response.Response)
+		}
+		duration = time.Duration(minutes) * time.Minute
+	}
+
+	l, ok := h.logger.(levelLogger)
+	if !ok {
+		response.Response = "the agent's logger does not support changing the level"
+		return response, errors.New(response.Response)
+	}
+
+	previous := l.Level()
+	if err = l.SetLevelOverride(level, duration); err != nil {
+		h.logger.Errorf(8253, "failed to set log level: %s", err.Error())
+		response.Response = fmt.Sprintf("failed to set log level: %s", err.Error())
+		return response, err
+	}
+
+	until := "until the agent restarts"
+	if duration > 0 {
+		until = "until " + time.Now().Add(duration).Format(time.RFC3339)
+	}
+
+	// Logged as a warning so that it is recorded at every level
+	h.logger.Warning(8252, "log level changed", fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+		fields.NewField("previous", previous.String()),
+		fields.NewField("level", level.String()),
+		fields.NewField("until", until)))
+
+	response.Success = true
+	response.Response = fmt.Sprintf("log level changed from %s to %s %s", previous, level, until)
+	response.Data = map[string]string{
+		"previous": previous.String(),
+		"level":    level.String(),
+		"until":    until,
+	}
+	return response, nil
+}
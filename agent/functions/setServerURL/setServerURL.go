@@ -0,0 +1,90 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package setServerURL
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// This command moves the agent to another server. The move must be signed by the current
+// server, and the configuration is only changed after a test sync with the new server succeeds.
+// The response is sent to the current server before the agent switches, since the agent does
+// not sync with it again. If the move fails, the agent stays with the current server and the
+// failure is reported with the next sync.
+
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	if h.comms == nil {
+		response.Response = "communications not available"
+		return response, errors.New(response.Response)
+	}
+
+	previous := h.config.AP.Get(global.ConfigServerURL).String()
+	url := request.Parameters["url"]
+
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+		fields.NewField("previous", previous),
+		fields.NewField("url", url))
+
+	// The response that the current server receives if the move succeeds
+	moved := response
+	moved.Success = true
+	moved.Response = fmt.Sprintf("agent moved from %s to %s", previous, url)
+	moved.Data = map[string]string{
+		"previous": previous,
+		"server":   url,
+	}
+
+	err := h.comms.MoveServer(communications.ServerMove{
+		RequestID:      request.RequestID,
+		URL:            url,
+		MigrationToken: request.Parameters["migration_token"],
+		Signature:      request.Parameters["signature"],
+		Response:       moved,
+	})
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8254, "server move failed", f)
+		response.Response = fmt.Sprintf("server move failed, the agent is still using %s: %s", previous, err.Error())
+		return response, err
+	}
+
+	h.logger.Info(8255, "server move complete", f)
+
+	// The current server already has the response, so it must not be queued for the new one
+	moved.Sent = true
+	return moved, nil
+}
@@ -0,0 +1,191 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package shellStart
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// maxChunk is the most shell output sent to the server in one relay
+const maxChunk = 64 * 1024
+
+// Handler starts a remote shell session. The shell runs in the background and its input and
+// output are relayed through the server until the session ends: the handler responds
+// immediately with InProgress set, and the final response is queued when the session ends.
+type Handler struct {
+	config *global.AgentConfig
+	logger interfaces.Logger
+	comms  *communications.Communications
+}
+
+func New(config *global.AgentConfig, logger interfaces.Logger, comms *communications.Communications) *Handler {
+	return &Handler{
+		config: config,
+		logger: logger,
+		comms:  comms,
+	}
+}
+
+func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
+
+	// Create a response to the server
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = false
+
+	// Parameters have been validated
+	sessionID := request.Parameters["session_id"]
+
+	// Assemble log fields
+	f := fields.NewFields(
+		fields.NewField("cmd", request.Request),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("request_id", request.RequestID),
+		fields.NewField("session_id", sessionID),
+	)
+
+	// The server also checks this setting, but the agent has the final say
+	if !h.config.AC.Get(schema.ConfigAgentShell).Bool() {
+		h.logger.Warning(8248, "remote shell is disabled", f)
+		response.Response = "remote shell is disabled on this agent"
+		return response, errors.New(response.Response)
+	}
+
+	a := osActions.New(h.logger)
+	sh, err := a.StartShell()
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		h.logger.Error(8249, "failed to start remote shell", f)
+		response.Response = err.Error()
+		return response, err
+	}
+
+	h.logger.Info(8250, "remote shell session started", f)
+	go h.relay(request, sessionID, sh, f)
+
+	response.InProgress = true
+	response.Response = "shell session started"
+	return response, nil
+}
+
+// relay sends shell output to the server and writes the input it returns to the shell until
+// the shell exits, the server ends the session, or the server cannot be reached
+func (h *Handler) relay(request schema.AgentRequest, sessionID string, sh *osActions.Shell, f *fields.Fields) {
+	// Read output in the background so that relaying is not blocked by an idle shell
+	output := make(chan []byte, 16)
+	go func() {
+		defer close(output)
+		for {
+			buf := make([]byte, 16*1024)
+			n, err := sh.Read(buf)
+			if n > 0 {
+				output <- buf[:n]
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var pending []byte
+	exited := false
+	lastContact := time.Now()
+	reason := ""
+
+	for {
+		// Wait briefly for output unless some is already waiting to be sent
+		if !exited && len(pending) == 0 {
+			select {
+			case data, ok := <-output:
+				if ok {
+					pending = append(pending, data...)
+				} else {
+					exited = true
+				}
+			case <-time.After(global.ShellPollInterval * time.Millisecond):
+			}
+		}
+
+		// Collect any further output that is ready
+	collect:
+		for !exited && len(pending) < maxChunk {
+			select {
+			case data, ok := <-output:
+				if !ok {
+					exited = true
+					break collect
+				}
+				pending = append(pending, data...)
+			default:
+				break collect
+			}
+		}
+
+		out := schema.ShellAgentIO{Output: pending[:min(len(pending), maxChunk)]}
+		if exited && len(pending) <= maxChunk {
+			out.Closed = true
+			out.Reason = "shell exited"
+		}
+
+		resp, err := h.comms.ShellRelay(sessionID, out)
+		if err != nil {
+			if time.Since(lastContact) > global.ShellRelayTimeout*time.Second {
+				reason = "unable to reach the server"
+				f.Append(fields.NewField("error", err.Error()))
+				break
+			}
+			time.Sleep(time.Second)
+			continue
+		}
+		lastContact = time.Now()
+		pending = pending[len(out.Output):]
+
+		if resp.Rows > 0 && resp.Cols > 0 {
+			_ = sh.Resize(resp.Rows, resp.Cols)
+		}
+
+		if len(resp.Input) > 0 && !exited {
+			_, _ = sh.Write(resp.Input)
+		}
+
+		if out.Closed {
+			reason = out.Reason
+			break
+		}
+		if resp.Close {
+			reason = "session closed by server"
+			if resp.Details != "" {
+				reason = resp.Details
+			}
+			break
+		}
+	}
+
+	// Closing the shell ends the reader, discard any output it has not delivered
+	_ = sh.Close()
+	for range output {
+	}
+
+	f.Append(fields.NewField("reason", reason))
+	h.logger.Info(8251, "remote shell session ended", f)
+
+	response := schema.NewAgentResponse()
+	response.Cmd = request.Request
+	response.RequestID = request.RequestID
+	response.Success = true
+	response.Response = fmt.Sprintf("shell session ended: %s", reason)
+	h.comms.QueueResponse(response)
+}
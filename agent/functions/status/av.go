@@ -5,6 +5,12 @@
 
 package status
 
+import (
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
 // Check for running antivirus processes
 //
 //goland:noinspection SpellCheckingInspection
@@ -25,6 +31,15 @@ var antivirusProcesses = []string{
 	"freshrpms",
 }
 
+// Product names of processes in antivirusProcesses whose application in macAntivirusPaths
+// has a different name, so that an installed product that is running is reported once
+//
+//goland:noinspection SpellCheckingInspection
+var macAntivirusProcessProducts = map[string]string{
+	"Norton":        "Norton Security",
+	"SentinelAgent": "SentinelOne",
+}
+
 // List of common antivirus applications and their installation paths on macOS
 //
 //goland:noinspection SpellCheckingInspection
@@ -51,3 +66,28 @@ var windowsAntivirusKeys = []string{
 	`SOFTWARE\Sentinel Labs`,
 	`SOFTWARE\Microsoft\Windows Defender`,
 }
+
+// antivirusProcessProducts returns a product for each process in antivirusProcesses found in
+// the output of ps. The product is running, but whether it is up to date is not known.
+func antivirusProcessProducts(psOutput string) []schema.AntivirusProduct {
+	var products []schema.AntivirusProduct
+	for _, process := range antivirusProcesses {
+		if strings.Contains(psOutput, process) {
+			products = append(products, schema.AntivirusProduct{Name: process, Enabled: "yes", Updated: "unknown"})
+		}
+	}
+	return products
+}
+
+// decodeProductState decodes the productState reported by Windows Security Center. Bit 12 is
+// set if real-time protection is enabled, and bit 4 is set if the signatures are out of date.
+func decodeProductState(state uint32) (enabled string, updated string) {
+	enabled, updated = "no", "yes"
+	if state&0x1000 != 0 {
+		enabled = "yes"
+	}
+	if state&0x10 != 0 {
+		updated = "no"
+	}
+	return enabled, updated
+}
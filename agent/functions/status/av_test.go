@@ -0,0 +1,52 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"testing"
+)
+
+// TestDecodeProductState tests decoding of Windows Security Center product states
+func TestDecodeProductState(t *testing.T) {
+	tests := []struct {
+		state   uint32
+		enabled string
+		updated string
+	}{
+		{0x061100, "yes", "yes"}, // Windows Defender, on and up to date
+		{0x061110, "yes", "no"},  // on and out of date
+		{0x060100, "no", "yes"},  // off and up to date
+		{0x041010, "yes", "no"},
+		{0x000000, "no", "yes"},
+	}
+
+	for _, tt := range tests {
+		enabled, updated := decodeProductState(tt.state)
+		if enabled != tt.enabled || updated != tt.updated {
+			t.Errorf("decodeProductState(%#x) = %s, %s, expected %s, %s", tt.state, enabled, updated, tt.enabled, tt.updated)
+		}
+	}
+}
+
+// TestAntivirusProcessProducts tests that each running antivirus process is reported
+func TestAntivirusProcessProducts(t *testing.T) {
+	ps := `USER  PID COMMAND
+root    1 /sbin/init
+clamav 812 /usr/sbin/clamd --foreground=true
+clamav 813 /usr/bin/freshclam -d --foreground=true
+`
+	products := antivirusProcessProducts(ps)
+	if len(products) != 2 || products[0].Name != "clamd" || products[1].Name != "freshclam" {
+		t.Fatalf("unexpected products: %+v", products)
+	}
+	if products[0].Enabled != "yes" || products[0].Updated != "unknown" {
+		t.Errorf("unexpected state: %+v", products[0])
+	}
+
+	if products = antivirusProcessProducts("root 1 /sbin/init"); len(products) != 0 {
+		t.Errorf("expected no products, got %+v", products)
+	}
+}
@@ -0,0 +1,89 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// hardwareKeys are the keys that hardware adds
+var hardwareKeys = []string{
+	schema.StatusDiskTotal,
+	schema.StatusDiskFree,
+	schema.StatusMemory,
+	schema.StatusCPUModel,
+	schema.StatusSerialNumber,
+	schema.StatusHardwareUUID,
+	schema.StatusChassisType,
+}
+
+// hardware adds hardware inventory to the status details. Each item is collected
+// independently so that a failure only results in that item being "unknown".
+func (h *Handler) hardware(ctx context.Context, details *schema.StatusDetails) {
+	total, free, err := h.diskSpace()
+	if h.hardwareError(schema.StatusDiskTotal, err) {
+		details.Set(schema.StatusDiskTotal, "unknown")
+		details.Set(schema.StatusDiskFree, "unknown")
+	} else {
+		details.Set(schema.StatusDiskTotal, strconv.FormatUint(total, 10))
+		details.Set(schema.StatusDiskFree, strconv.FormatUint(free, 10))
+	}
+
+	mem, err := h.memory(ctx)
+	if h.hardwareError(schema.StatusMemory, err) {
+		details.Set(schema.StatusMemory, "unknown")
+	} else {
+		details.Set(schema.StatusMemory, strconv.FormatUint(mem, 10))
+	}
+
+	details.Set(schema.StatusCPUModel, h.hardwareString(ctx, schema.StatusCPUModel, h.cpuModel))
+	details.Set(schema.StatusSerialNumber, h.hardwareString(ctx, schema.StatusSerialNumber, h.serialNumber))
+	details.Set(schema.StatusHardwareUUID, h.hardwareString(ctx, schema.StatusHardwareUUID, h.hardwareUUID))
+	details.Set(schema.StatusChassisType, h.hardwareString(ctx, schema.StatusChassisType, h.chassisType))
+}
+
+// hardwareString calls f and returns its result, or "unknown" if it fails or returns nothing
+func (h *Handler) hardwareString(ctx context.Context, key string, f func(context.Context) (string, error)) string {
+	value, err := f(ctx)
+	if h.hardwareError(key, err) {
+		return "unknown"
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// hardwareError logs err, if any, and returns true if there was an error
+func (h *Handler) hardwareError(key string, err error) bool {
+	if err == nil {
+		return false
+	}
+	if h.logger != nil {
+		h.logger.Debugf(2717, "unable to collect %s: %s", key, err.Error())
+	}
+	return true
+}
+
+// chassisFromSMBIOS maps an SMBIOS system enclosure type to a chassis type
+func chassisFromSMBIOS(code int) string {
+	switch code {
+	case 8, 9, 10, 11, 14, 30, 31, 32: // portable, laptop, notebook, hand held, sub notebook, tablet, convertible, detachable
+		return schema.ChassisLaptop
+	case 3, 4, 5, 6, 7, 13, 15, 16, 24, 35, 36: // desktop, low profile, pizza box, mini tower, tower, all in one, space-saving, lunch box, sealed-case, mini PC, stick PC
+		return schema.ChassisDesktop
+	case 17, 23, 25, 28, 29: // main server, rack mount, multi-system, blade, blade enclosure
+		return schema.ChassisServer
+	default:
+		return schema.ChassisUnknown
+	}
+}
@@ -0,0 +1,99 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// memory returns the installed RAM in bytes
+func (h *Handler) memory(ctx context.Context) (uint64, error) {
+	out, err := exec.CommandContext(ctx, "sysctl", "-n", "hw.memsize").Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+}
+
+// cpuModel returns the processor brand string
+func (h *Handler) cpuModel(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "sysctl", "-n", "machdep.cpu.brand_string").Output()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// serialNumber returns the machine serial number
+func (h *Handler) serialNumber(ctx context.Context) (string, error) {
+	return platformExpertValue(ctx, "IOPlatformSerialNumber")
+}
+
+// hardwareUUID returns the hardware UUID
+func (h *Handler) hardwareUUID(ctx context.Context) (string, error) {
+	return platformExpertValue(ctx, "IOPlatformUUID")
+}
+
+// chassisType determines the chassis type from the model name, e.g. "MacBook Pro"
+func (h *Handler) chassisType(ctx context.Context) (string, error) {
+
+	// kern.hv_vmm_present is 1 when running under a hypervisor
+	out, err := exec.CommandContext(ctx, "sysctl", "-n", "kern.hv_vmm_present").Output()
+	if err == nil && strings.TrimSpace(string(out)) == "1" {
+		return schema.ChassisVM, nil
+	}
+
+	out, err = exec.CommandContext(ctx, "system_profiler", "SPHardwareDataType").Output()
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		name, ok := strings.CutPrefix(strings.TrimSpace(line), "Model Name:")
+		if !ok {
+			continue
+		}
+
+		name = strings.TrimSpace(name)
+		switch {
+		case strings.HasPrefix(name, "MacBook"):
+			return schema.ChassisLaptop, nil
+		case strings.HasPrefix(name, "Xserve"):
+			return schema.ChassisServer, nil
+		case strings.Contains(name, "Virtual"):
+			return schema.ChassisVM, nil
+		default:
+			// iMac, Mac mini, Mac Studio, Mac Pro
+			return schema.ChassisDesktop, nil
+		}
+	}
+	return "", fmt.Errorf("model name not found in system_profiler output")
+}
+
+// platformExpertValue returns a string property of the IOPlatformExpertDevice from ioreg
+func platformExpertValue(ctx context.Context, key string) (string, error) {
+	out, err := exec.CommandContext(ctx, "ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+	if err != nil {
+		return "", err
+	}
+
+	// Lines are formatted as: "IOPlatformSerialNumber" = "C02XXXXXXXXX"
+	for _, line := range strings.Split(string(out), "\n") {
+		k, v, found := strings.Cut(strings.TrimSpace(line), "=")
+		if found && strings.Trim(strings.TrimSpace(k), `"`) == key {
+			return strings.Trim(strings.TrimSpace(v), `"`), nil
+		}
+	}
+	return "", fmt.Errorf("%s not found in ioreg output", key)
+}
@@ -0,0 +1,116 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+const dmiPath = "/sys/class/dmi/id/"
+
+// memory returns the installed RAM in bytes
+func (h *Handler) memory(ctx context.Context) (uint64, error) {
+	value, err := procValue("/proc/meminfo", "MemTotal")
+	if err != nil {
+		return 0, err
+	}
+
+	// The value is in kB, e.g. "16318480 kB"
+	kb, err := strconv.ParseUint(strings.Fields(value)[0], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return kb * 1024, nil
+}
+
+// cpuModel returns the processor model name
+func (h *Handler) cpuModel(ctx context.Context) (string, error) {
+	value, err := procValue("/proc/cpuinfo", "model name")
+	if err == nil {
+		return value, nil
+	}
+
+	// ARM systems generally do not report a model name
+	return procValue("/proc/cpuinfo", "Model")
+}
+
+// serialNumber returns the system serial number
+func (h *Handler) serialNumber(ctx context.Context) (string, error) {
+	return dmiValue(ctx, "product_serial", "system-serial-number")
+}
+
+// hardwareUUID returns the system UUID
+func (h *Handler) hardwareUUID(ctx context.Context) (string, error) {
+	return dmiValue(ctx, "product_uuid", "system-uuid")
+}
+
+// chassisType determines the chassis type from the virtualization state and SMBIOS enclosure type
+func (h *Handler) chassisType(ctx context.Context) (string, error) {
+
+	// systemd-detect-virt prints "none" and exits non-zero on bare metal
+	out, err := exec.CommandContext(ctx, "systemd-detect-virt").Output()
+	if err == nil {
+		virt := strings.TrimSpace(string(out))
+		if virt != "" && virt != "none" {
+			return schema.ChassisVM, nil
+		}
+	}
+
+	data, err := os.ReadFile(dmiPath + "chassis_type")
+	if err != nil {
+		return "", err
+	}
+
+	code, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return "", fmt.Errorf("unexpected chassis_type value: %s", strings.TrimSpace(string(data)))
+	}
+	return chassisFromSMBIOS(code), nil
+}
+
+// dmiValue reads a DMI attribute from sysfs, falling back to dmidecode
+func dmiValue(ctx context.Context, file, keyword string) (string, error) {
+	data, err := os.ReadFile(dmiPath + file)
+	if err == nil {
+		return string(data), nil
+	}
+
+	out, err := exec.CommandContext(ctx, "dmidecode", "-s", keyword).Output()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// procValue returns the value of the first "key : value" line with a matching key in a /proc file
+func procValue(path, key string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		k, v, found := strings.Cut(scanner.Text(), ":")
+		if found && strings.TrimSpace(k) == key && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v), nil
+		}
+	}
+	return "", fmt.Errorf("%s not found in %s", key, path)
+}
@@ -0,0 +1,56 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"context"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// TestChassisFromSMBIOS tests the mapping of SMBIOS enclosure types to chassis types
+func TestChassisFromSMBIOS(t *testing.T) {
+	tests := map[int]string{
+		2:  schema.ChassisUnknown,
+		3:  schema.ChassisDesktop,
+		9:  schema.ChassisLaptop,
+		10: schema.ChassisLaptop,
+		13: schema.ChassisDesktop,
+		23: schema.ChassisServer,
+		31: schema.ChassisLaptop,
+		99: schema.ChassisUnknown,
+	}
+
+	for code, want := range tests {
+		if got := chassisFromSMBIOS(code); got != want {
+			t.Errorf("chassisFromSMBIOS(%d) = %s, expected %s", code, got, want)
+		}
+	}
+}
+
+// TestHardwareKeys tests that every hardware key is populated, even if collection fails
+func TestHardwareKeys(t *testing.T) {
+	h := &Handler{}
+	details := schema.NewStatusDetails()
+	h.hardware(context.Background(), &details)
+
+	keys := []string{
+		schema.StatusDiskTotal,
+		schema.StatusDiskFree,
+		schema.StatusMemory,
+		schema.StatusCPUModel,
+		schema.StatusSerialNumber,
+		schema.StatusHardwareUUID,
+		schema.StatusChassisType,
+	}
+
+	for _, key := range keys {
+		if details.Get(key) == "" {
+			t.Errorf("hardware() did not populate %s", key)
+		}
+	}
+}
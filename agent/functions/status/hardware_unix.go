@@ -0,0 +1,22 @@
+//go:build darwin || linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import "syscall"
+
+// diskSpace returns the total and available space, in bytes, of the root volume
+func (h *Handler) diskSpace() (uint64, uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs("/", &st); err != nil {
+		return 0, 0, err
+	}
+
+	// Bavail is the space available to unprivileged users, which is what users see as free
+	bsize := uint64(st.Bsize)
+	return st.Blocks * bsize, st.Bavail * bsize, nil
+}
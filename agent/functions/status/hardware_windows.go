@@ -0,0 +1,124 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/StackExchange/wmi"
+	"golang.org/x/sys/windows"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// diskSpace returns the total and available space, in bytes, of the system drive
+func (h *Handler) diskSpace() (uint64, uint64, error) {
+	drive := os.Getenv("SystemDrive")
+	if drive == "" {
+		drive = "C:"
+	}
+
+	path, err := windows.UTF16PtrFromString(drive + `\`)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var free, total, totalFree uint64
+	if err = windows.GetDiskFreeSpaceEx(path, &free, &total, &totalFree); err != nil {
+		return 0, 0, err
+	}
+	return total, free, nil
+}
+
+// memory returns the installed RAM in bytes
+func (h *Handler) memory(ctx context.Context) (uint64, error) {
+	var cs []struct {
+		TotalPhysicalMemory uint64
+	}
+	if err := wmi.Query("SELECT TotalPhysicalMemory FROM Win32_ComputerSystem", &cs); err != nil {
+		return 0, err
+	}
+	if len(cs) == 0 {
+		return 0, errors.New("no Win32_ComputerSystem instance")
+	}
+	return cs[0].TotalPhysicalMemory, nil
+}
+
+// cpuModel returns the processor name
+func (h *Handler) cpuModel(ctx context.Context) (string, error) {
+	var cpus []struct {
+		Name string
+	}
+	if err := wmi.Query("SELECT Name FROM Win32_Processor", &cpus); err != nil {
+		return "", err
+	}
+	if len(cpus) == 0 {
+		return "", errors.New("no Win32_Processor instance")
+	}
+	return cpus[0].Name, nil
+}
+
+// serialNumber returns the BIOS serial number
+func (h *Handler) serialNumber(ctx context.Context) (string, error) {
+	var bios []struct {
+		SerialNumber string
+	}
+	if err := wmi.Query("SELECT SerialNumber FROM Win32_BIOS", &bios); err != nil {
+		return "", err
+	}
+	if len(bios) == 0 {
+		return "", errors.New("no Win32_BIOS instance")
+	}
+	return bios[0].SerialNumber, nil
+}
+
+// hardwareUUID returns the SMBIOS system UUID
+func (h *Handler) hardwareUUID(ctx context.Context) (string, error) {
+	var products []struct {
+		UUID string
+	}
+	if err := wmi.Query("SELECT UUID FROM Win32_ComputerSystemProduct", &products); err != nil {
+		return "", err
+	}
+	if len(products) == 0 {
+		return "", errors.New("no Win32_ComputerSystemProduct instance")
+	}
+	return products[0].UUID, nil
+}
+
+// chassisType determines the chassis type from the system model and SMBIOS enclosure type
+func (h *Handler) chassisType(ctx context.Context) (string, error) {
+	var cs []struct {
+		Manufacturer string
+		Model        string
+	}
+	if err := wmi.Query("SELECT Manufacturer, Model FROM Win32_ComputerSystem", &cs); err == nil && len(cs) > 0 {
+		// Virtual machines identify themselves through the manufacturer and model, e.g.
+		// "Microsoft Corporation Virtual Machine" or "VMware, Inc. VMware7,1"
+		model := strings.ToLower(cs[0].Manufacturer + " " + cs[0].Model)
+		for _, vm := range []string{"virtual", "vmware", "kvm", "qemu", "xen", "parallels"} {
+			if strings.Contains(model, vm) {
+				return schema.ChassisVM, nil
+			}
+		}
+	}
+
+	var enclosures []struct {
+		ChassisTypes []uint16
+	}
+	if err := wmi.Query("SELECT ChassisTypes FROM Win32_SystemEnclosure", &enclosures); err != nil {
+		return "", err
+	}
+	if len(enclosures) == 0 || len(enclosures[0].ChassisTypes) == 0 {
+		return "", errors.New("no chassis type reported")
+	}
+	return chassisFromSMBIOS(int(enclosures[0].ChassisTypes[0])), nil
+}
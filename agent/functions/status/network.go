@@ -0,0 +1,72 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// lostMode adds network context that may help to locate a lost device. It is only called
+// while the agent is in lost mode. The public IP address is recorded by the server. The
+// Wi-Fi network is only collected if enabled by the lost_wifi agent setting.
+func (h *Handler) lostMode(ctx context.Context, details *schema.StatusDetails) {
+	details.Set(schema.StatusGatewayMAC, h.hardwareString(ctx, schema.StatusGatewayMAC, h.gatewayMAC))
+
+	if h.lostWiFi() {
+		details.Set(schema.StatusWiFiSSID, h.hardwareString(ctx, schema.StatusWiFiSSID, h.wifiSSID))
+	}
+}
+
+// lostModeKeys returns the keys that lostMode adds
+func (h *Handler) lostModeKeys() []string {
+	if h.lostWiFi() {
+		return []string{schema.StatusGatewayMAC, schema.StatusWiFiSSID}
+	}
+	return []string{schema.StatusGatewayMAC}
+}
+
+func (h *Handler) lostWiFi() bool {
+	return h.config != nil && h.config.AC.Get(schema.ConfigAgentLostWiFi).Bool()
+}
+
+// fieldValue returns the value of the first line of output in the form "name: value"
+// or "name : value", ignoring case and leading whitespace
+func fieldValue(output, name string) string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if found && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// normalizeMAC returns a MAC address in the form aa:bb:cc:dd:ee:ff, or an empty string
+// if it is not valid. Windows uses dashes, and macOS omits leading zeros.
+func normalizeMAC(mac string) string {
+	parts := strings.FieldsFunc(strings.TrimSpace(mac), func(r rune) bool { return r == ':' || r == '-' })
+	if len(parts) != 6 {
+		return ""
+	}
+
+	for x, part := range parts {
+		if len(part) == 1 {
+			parts[x] = "0" + part
+		}
+	}
+
+	hw, err := net.ParseMAC(strings.Join(parts, ":"))
+	if err != nil {
+		return ""
+	}
+	return hw.String()
+}
@@ -0,0 +1,76 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// gatewayMAC returns the MAC address of the default gateway using route and arp
+func (h *Handler) gatewayMAC(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "route", "-n", "get", "default").Output()
+	if err != nil {
+		return "", err
+	}
+
+	gateway := fieldValue(string(out), "gateway")
+	if gateway == "" {
+		return "", errors.New("no default route")
+	}
+
+	// ? (192.168.1.1) at a4:5e:60:e1:2:3 on en0 ifscope [ethernet]
+	out, err = exec.CommandContext(ctx, "arp", "-n", gateway).Output()
+	if err != nil {
+		return "", err
+	}
+
+	f := strings.Fields(string(out))
+	for x := 0; x < len(f)-1; x++ {
+		if f[x] == "at" {
+			if mac := normalizeMAC(f[x+1]); mac != "" {
+				return mac, nil
+			}
+		}
+	}
+	return "", errors.New("default gateway not found in ARP table")
+}
+
+// wifiSSID returns the SSID of the connected Wi-Fi network using networksetup
+func (h *Handler) wifiSSID(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "networksetup", "-listallhardwareports").Output()
+	if err != nil {
+		return "", err
+	}
+
+	// Find the device for the Wi-Fi port, usually en0
+	device := ""
+	lines := strings.Split(string(out), "\n")
+	for x, line := range lines {
+		if strings.TrimSpace(line) == "Hardware Port: Wi-Fi" && x+1 < len(lines) {
+			device = fieldValue(lines[x+1], "Device")
+			break
+		}
+	}
+	if device == "" {
+		return "", errors.New("no Wi-Fi interface")
+	}
+
+	out, err = exec.CommandContext(ctx, "networksetup", "-getairportnetwork", device).Output()
+	if err != nil {
+		return "", err
+	}
+
+	ssid := fieldValue(string(out), "Current Wi-Fi Network")
+	if ssid == "" {
+		return "", errors.New("not connected to a Wi-Fi network")
+	}
+	return ssid, nil
+}
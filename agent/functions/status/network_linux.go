@@ -0,0 +1,86 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// gatewayMAC returns the MAC address of the default gateway from the kernel's routing and ARP tables
+func (h *Handler) gatewayMAC(ctx context.Context) (string, error) {
+	routes, err := os.ReadFile("/proc/net/route")
+	if err != nil {
+		return "", err
+	}
+
+	gateway, err := defaultGateway(string(routes))
+	if err != nil {
+		return "", err
+	}
+
+	arp, err := os.ReadFile("/proc/net/arp")
+	if err != nil {
+		return "", err
+	}
+
+	// IP address, HW type, Flags, HW address, Mask, Device
+	for _, line := range strings.Split(string(arp), "\n")[1:] {
+		f := strings.Fields(line)
+		if len(f) >= 4 && f[0] == gateway {
+			if mac := normalizeMAC(f[3]); mac != "" && mac != "00:00:00:00:00:00" {
+				return mac, nil
+			}
+		}
+	}
+	return "", errors.New("default gateway not found in ARP table")
+}
+
+// defaultGateway returns the gateway of the default route in /proc/net/route, where
+// addresses are hexadecimal in host (little-endian) byte order
+func defaultGateway(routes string) (string, error) {
+	for _, line := range strings.Split(routes, "\n")[1:] {
+		f := strings.Fields(line)
+		if len(f) < 3 || f[1] != "00000000" {
+			continue
+		}
+
+		b, err := hex.DecodeString(f[2])
+		if err != nil || len(b) != 4 {
+			continue
+		}
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(b))
+		if !ip.IsUnspecified() {
+			return ip.String(), nil
+		}
+	}
+	return "", errors.New("no default route")
+}
+
+// wifiSSID returns the SSID of the connected Wi-Fi network using NetworkManager
+func (h *Handler) wifiSSID(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "nmcli", "-t", "-f", "active,ssid", "dev", "wifi").Output()
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if ssid, found := strings.CutPrefix(line, "yes:"); found {
+			// nmcli escapes colons in terse output
+			return strings.ReplaceAll(ssid, `\:`, ":"), nil
+		}
+	}
+	return "", errors.New("not connected to a Wi-Fi network")
+}
@@ -0,0 +1,44 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"testing"
+)
+
+// TestFieldValue tests parsing of "name: value" command output
+func TestFieldValue(t *testing.T) {
+	netsh := "    Name                   : Wi-Fi\n" +
+		"    BSSID                  : aa:bb:cc:dd:ee:ff\n" +
+		"    SSID                   : Office: 2nd floor\n"
+
+	if got := fieldValue(netsh, "SSID"); got != "Office: 2nd floor" {
+		t.Errorf("expected Office: 2nd floor, got %q", got)
+	}
+	if got := fieldValue("   route to: default\n    gateway: 192.168.1.1\n", "gateway"); got != "192.168.1.1" {
+		t.Errorf("expected 192.168.1.1, got %q", got)
+	}
+	if got := fieldValue(netsh, "missing"); got != "" {
+		t.Errorf("expected an empty string, got %q", got)
+	}
+}
+
+// TestNormalizeMAC tests that MAC addresses from each OS are normalized
+func TestNormalizeMAC(t *testing.T) {
+	tests := map[string]string{
+		"A4-5E-60-E1-02-03":  "a4:5e:60:e1:02:03",
+		"a4:5e:60:e1:2:3":    "a4:5e:60:e1:02:03",
+		"a4:5e:60:e1:02:03 ": "a4:5e:60:e1:02:03",
+		"(incomplete)":       "",
+		"a4:5e:60":           "",
+	}
+
+	for mac, want := range tests {
+		if got := normalizeMAC(mac); got != want {
+			t.Errorf("normalizeMAC(%q) = %q, expected %q", mac, got, want)
+		}
+	}
+}
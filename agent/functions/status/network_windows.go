@@ -0,0 +1,45 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+// gatewayMAC returns the MAC address of the default gateway with the lowest route metric
+func (h *Handler) gatewayMAC(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command",
+		"$r = Get-NetRoute -DestinationPrefix '0.0.0.0/0' -ErrorAction Stop | Sort-Object RouteMetric | Select-Object -First 1; "+
+			"(Get-NetNeighbor -IPAddress $r.NextHop -InterfaceIndex $r.InterfaceIndex -ErrorAction Stop).LinkLayerAddress").Output()
+	if err != nil {
+		return "", err
+	}
+
+	mac := normalizeMAC(string(out))
+	if mac == "" {
+		return "", errors.New("default gateway not found in neighbor table")
+	}
+	return mac, nil
+}
+
+// wifiSSID returns the SSID of the connected Wi-Fi network using netsh
+func (h *Handler) wifiSSID(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "netsh", "wlan", "show", "interfaces").Output()
+	if err != nil {
+		return "", err
+	}
+
+	// The SSID line is distinct from the BSSID line, which contains the access point's MAC address
+	ssid := fieldValue(string(out), "SSID")
+	if ssid == "" {
+		return "", errors.New("not connected to a Wi-Fi network")
+	}
+	return ssid, nil
+}
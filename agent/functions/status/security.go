@@ -0,0 +1,193 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recentUpdateWindow is how recently automatic updates must have run to be reported as recent
+const recentUpdateWindow = 7 * 24 * time.Hour
+
+// sshdMaxIncludeDepth is the maximum nesting of Include directives, as enforced by sshd
+const sshdMaxIncludeDepth = 16
+
+// parseMDMEnrollment parses the output of 'profiles status -type enrollment', which includes
+// a line such as "MDM enrollment: Yes (User Approved)"
+func parseMDMEnrollment(output string) string {
+	value := strings.ToLower(fieldValue(output, "MDM enrollment"))
+	switch {
+	case strings.HasPrefix(value, "yes"):
+		return "yes"
+	case strings.HasPrefix(value, "no"):
+		return "no"
+	default:
+		return "unknown"
+	}
+}
+
+// parseActivationLock parses the JSON output of 'system_profiler -json SPHardwareDataType'.
+// activation_lock_status is only present on Macs that support Activation Lock, which are
+// those with Apple silicon or a T2 security chip.
+func parseActivationLock(output []byte) string {
+	var profile struct {
+		Hardware []struct {
+			ActivationLock string `json:"activation_lock_status"`
+		} `json:"SPHardwareDataType"`
+	}
+	if err := json.Unmarshal(output, &profile); err != nil || len(profile.Hardware) == 0 {
+		return "unknown"
+	}
+
+	switch profile.Hardware[0].ActivationLock {
+	case "activation_lock_enabled":
+		return "yes"
+	case "activation_lock_disabled":
+		return "no"
+	default:
+		return "unknown"
+	}
+}
+
+// parseSystemIntegrity parses the output of 'csrutil status', such as
+// "System Integrity Protection status: enabled." A custom configuration, where only some
+// protections are enabled, is reported as "no".
+func parseSystemIntegrity(output string) string {
+	value := strings.ToLower(fieldValue(output, "System Integrity Protection status"))
+	switch {
+	case strings.HasPrefix(value, "enabled") && !strings.Contains(value, "custom"):
+		return "yes"
+	case strings.HasPrefix(value, "disabled"), strings.Contains(value, "custom"):
+		return "no"
+	default:
+		return "unknown"
+	}
+}
+
+// parseSELinuxMode parses /sys/fs/selinux/enforce, which contains "1" or "0", or the output
+// of getenforce. It returns "" if SELinux is disabled or the mode is not recognized.
+func parseSELinuxMode(output string) string {
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "1", "enforcing":
+		return "selinux enforcing"
+	case "0", "permissive":
+		return "selinux permissive"
+	default:
+		return ""
+	}
+}
+
+// parseAppArmorProfiles parses /sys/kernel/security/apparmor/profiles, which lists each loaded
+// profile and its mode, such as "/usr/sbin/cupsd (enforce)". AppArmor without any profiles
+// does not confine anything, so it is reported as "disabled".
+func parseAppArmorProfiles(profiles string) string {
+	complain := false
+	scanner := bufio.NewScanner(strings.NewReader(profiles))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasSuffix(line, "(enforce)"):
+			return "apparmor enforcing"
+		case strings.HasSuffix(line, "(complain)"):
+			complain = true
+		}
+	}
+
+	if complain {
+		return "apparmor complain"
+	}
+	return "disabled"
+}
+
+// parseSSHDPasswordAuth parses the effective configuration printed by 'sshd -T'
+func parseSSHDPasswordAuth(output string) string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.EqualFold(fields[0], "passwordauthentication") {
+			return yesNo(fields[1])
+		}
+	}
+	return "unknown"
+}
+
+// sshdConfigOption returns the value of an option in an sshd_config file. As with sshd, the
+// first value found is used, files named by Include directives are read in place, and relative
+// Include paths are relative to dir. Options in Match blocks do not apply to every connection,
+// so reading stops at the first Match directive. done is true if reading should stop.
+func sshdConfigOption(path, dir, option string, depth int) (value string, done bool) {
+	if depth > sshdMaxIncludeDepth {
+		return "", true
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Keywords and arguments are separated by whitespace or an equals sign
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == '\t' || r == '=' })
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch strings.ToLower(fields[0]) {
+		case "match":
+			return "", true
+		case "include":
+			for _, pattern := range fields[1:] {
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(dir, pattern)
+				}
+				matches, _ := filepath.Glob(pattern)
+				for _, match := range matches {
+					if value, done = sshdConfigOption(match, dir, option, depth+1); value != "" || done {
+						return value, done
+					}
+				}
+			}
+		case strings.ToLower(option):
+			return fields[1], true
+		}
+	}
+	return "", false
+}
+
+// parseUnixTimestamp parses a time printed by systemctl with --timestamp=unix, such as
+// "@1760000000". A timer that has never been triggered has no value.
+func parseUnixTimestamp(output string) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(output), "@"), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+// yesNo returns "yes" or "no" for a yes or no value, and "unknown" for anything else
+func yesNo(value string) string {
+	switch strings.ToLower(value) {
+	case "yes":
+		return "yes"
+	case "no":
+		return "no"
+	default:
+		return "unknown"
+	}
+}
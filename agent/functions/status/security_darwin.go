@@ -0,0 +1,68 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// platformCollectors returns the collectors for the macOS security state
+func (h *Handler) platformCollectors() []collector {
+	return []collector{
+		stringCollector(schema.StatusMDMEnrolled, h.mdmEnrolled),
+		stringCollector(schema.StatusActivationLock, h.activationLock),
+		stringCollector(schema.StatusSystemIntegrity, h.systemIntegrity),
+	}
+}
+
+// mdmEnrolled returns "yes" if the Mac is enrolled in an MDM service
+func (h *Handler) mdmEnrolled(ctx context.Context) string {
+	out, err := h.securityCommand(ctx, "/usr/bin/profiles", "status", "-type", "enrollment")
+	if err != nil {
+		return "unknown"
+	}
+	return parseMDMEnrollment(out)
+}
+
+// activationLock returns "yes" if Activation Lock is enabled
+func (h *Handler) activationLock(ctx context.Context) string {
+	out, err := h.securityCommand(ctx, "/usr/sbin/system_profiler", "-json", "SPHardwareDataType")
+	if err != nil {
+		return "unknown"
+	}
+	return parseActivationLock([]byte(out))
+}
+
+// systemIntegrity returns "yes" if System Integrity Protection is fully enabled
+func (h *Handler) systemIntegrity(ctx context.Context) string {
+	out, err := h.securityCommand(ctx, "/usr/bin/csrutil", "status")
+	if err != nil {
+		return "unknown"
+	}
+	return parseSystemIntegrity(out)
+}
+
+// securityCommand runs a command used to determine the security state. A command that is not
+// present, for example on an older version of macOS, or that fails because it requires more
+// privileges, is logged and results in "unknown". LC_ALL is set so that output that can not be
+// requested in a structured format is not localized.
+func (h *Handler) securityCommand(ctx context.Context, cmdAndArgs ...string) (string, error) {
+	if _, err := exec.LookPath(cmdAndArgs[0]); err != nil {
+		if h.logger != nil {
+			h.logger.Debugf(2720, "%s is not available: %s", cmdAndArgs[0], err.Error())
+		}
+		return "", err
+	}
+
+	out, err := h.runner().Env(map[string]string{"LC_ALL": "C"}).StdoutCtx(ctx, cmdAndArgs...)
+	if err != nil && h.logger != nil {
+		h.logger.Debugf(2720, "unable to determine security state: %s", err.Error())
+	}
+	return out, err
+}
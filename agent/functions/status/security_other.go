@@ -0,0 +1,13 @@
+//go:build !darwin && !linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+// platformCollectors returns no additional collectors on this platform
+func (h *Handler) platformCollectors() []collector {
+	return nil
+}
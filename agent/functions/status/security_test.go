@@ -0,0 +1,150 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseMDMEnrollment(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{"Enrolled via DEP: Yes\nMDM enrollment: Yes (User Approved)\nMDM server: https://mdm.example.com\n", "yes"},
+		{"Enrolled via DEP: No\nMDM enrollment: No\n", "no"},
+		{"profiles: this command requires root\n", "unknown"},
+		{"", "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := parseMDMEnrollment(tt.output); got != tt.expected {
+			t.Errorf("parseMDMEnrollment(%q) = %q, expected %q", tt.output, got, tt.expected)
+		}
+	}
+}
+
+func TestParseActivationLock(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{`{"SPHardwareDataType": [{"machine_model": "Mac14,2", "activation_lock_status": "activation_lock_enabled"}]}`, "yes"},
+		{`{"SPHardwareDataType": [{"machine_model": "Mac14,2", "activation_lock_status": "activation_lock_disabled"}]}`, "no"},
+		{`{"SPHardwareDataType": [{"machine_model": "MacBookPro11,1"}]}`, "unknown"},
+		{`{"SPHardwareDataType": []}`, "unknown"},
+		{"Hardware:\n", "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := parseActivationLock([]byte(tt.output)); got != tt.expected {
+			t.Errorf("parseActivationLock(%q) = %q, expected %q", tt.output, got, tt.expected)
+		}
+	}
+}
+
+func TestParseSystemIntegrity(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{"System Integrity Protection status: enabled.\n", "yes"},
+		{"System Integrity Protection status: disabled.\n", "no"},
+		{"System Integrity Protection status: unknown (Custom Configuration).\n\nConfiguration:\n\tKext Signing: disabled\n", "no"},
+		{"System Integrity Protection status: enabled (Custom Configuration).\n", "no"},
+		{"csrutil: command not found\n", "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := parseSystemIntegrity(tt.output); got != tt.expected {
+			t.Errorf("parseSystemIntegrity(%q) = %q, expected %q", tt.output, got, tt.expected)
+		}
+	}
+}
+
+func TestAccessControlModes(t *testing.T) {
+	selinux := map[string]string{"1\n": "selinux enforcing", "0": "selinux permissive", "Enforcing\n": "selinux enforcing",
+		"Permissive": "selinux permissive", "Disabled\n": ""}
+	for output, expected := range selinux {
+		if got := parseSELinuxMode(output); got != expected {
+			t.Errorf("parseSELinuxMode(%q) = %q, expected %q", output, got, expected)
+		}
+	}
+
+	apparmor := map[string]string{
+		"/usr/sbin/cupsd (complain)\n/usr/bin/man (enforce)\n": "apparmor enforcing",
+		"/usr/sbin/cupsd (complain)\n":                         "apparmor complain",
+		"":                                                     "disabled",
+	}
+	for profiles, expected := range apparmor {
+		if got := parseAppArmorProfiles(profiles); got != expected {
+			t.Errorf("parseAppArmorProfiles(%q) = %q, expected %q", profiles, got, expected)
+		}
+	}
+}
+
+func TestParseSSHDPasswordAuth(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{"port 22\npasswordauthentication no\npubkeyauthentication yes\n", "no"},
+		{"port 22\npasswordauthentication yes\n", "yes"},
+		{"", "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := parseSSHDPasswordAuth(tt.output); got != tt.expected {
+			t.Errorf("parseSSHDPasswordAuth(%q) = %q, expected %q", tt.output, got, tt.expected)
+		}
+	}
+}
+
+func TestSSHDConfigOption(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// The first value wins, so the included file takes precedence over the main file
+	write("sshd_config.d/50-cloud-init.conf", "PasswordAuthentication=no\n")
+	main := write("sshd_config", "Include sshd_config.d/*.conf\n# PasswordAuthentication yes\nPasswordAuthentication yes\n")
+	if value, _ := sshdConfigOption(main, dir, "PasswordAuthentication", 0); value != "no" {
+		t.Errorf("expected the included value, got %q", value)
+	}
+
+	// Options in a Match block do not apply to every connection
+	main = write("sshd_config", "Match User backup\n\tPasswordAuthentication yes\n")
+	if value, _ := sshdConfigOption(main, dir, "PasswordAuthentication", 0); value != "" {
+		t.Errorf("expected no value, got %q", value)
+	}
+
+	// An Include of itself is stopped by the depth limit
+	main = write("sshd_config", "Include sshd_config\n")
+	if value, _ := sshdConfigOption(main, dir, "PasswordAuthentication", 0); value != "" {
+		t.Errorf("expected no value, got %q", value)
+	}
+}
+
+func TestParseUnixTimestamp(t *testing.T) {
+	if ts, ok := parseUnixTimestamp("@1760000000\n"); !ok || ts.Unix() != 1760000000 {
+		t.Errorf("unexpected result: %v, %v", ts, ok)
+	}
+	for _, output := range []string{"", "n/a", "@0"} {
+		if _, ok := parseUnixTimestamp(output); ok {
+			t.Errorf("expected %q not to be parsed", output)
+		}
+	}
+}
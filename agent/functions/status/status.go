@@ -6,6 +6,8 @@
 package status
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/UnifyEM/UnifyEM/agent/global"
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/runCmd"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
@@ -23,13 +26,44 @@ type UserDataSource interface {
 	GetConsoleUserData() (UserContextData, bool)
 }
 
+// UserRequester is an interface for performing requests in the console user's context via the user-helper
+type UserRequester interface {
+	UserRequest(request UserRequest, timeout time.Duration) (UserRequestResult, error)
+}
+
+// User-helper message types. Data messages carry collected user-context data,
+// while poll messages only check for pending requests and return results.
+const (
+	UserMessageData = ""
+	UserMessagePoll = "poll"
+)
+
 // UserContextData represents user-specific context information
 type UserContextData struct {
+	Type            string
 	Username        string
 	Timestamp       time.Time
 	ScreenLock      string
 	ScreenLockDelay string
 	RawData         map[string]string
+	Results         []UserRequestResult
+}
+
+// UserRequest is sent to the user-helper in reply to a message, asking it to
+// perform an action that must run in the console user's context
+type UserRequest struct {
+	ID         string
+	Type       string
+	Parameters map[string]string
+}
+
+// UserRequestResult is returned by the user-helper in a subsequent message
+type UserRequestResult struct {
+	ID       string
+	Type     string
+	Success  bool
+	Response string
+	Data     json.RawMessage
 }
 
 type Handler struct {
@@ -50,6 +84,7 @@ func New(config *global.AgentConfig, logger interfaces.Logger, comms *communicat
 
 func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error) {
 	responseData := h.CollectStatusData()
+	responseData.Details.StartReason = global.TakeStartReason()
 	response := schema.NewAgentResponse()
 	response.Cmd = request.Request
 	response.RequestID = request.RequestID
@@ -63,48 +98,233 @@ func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error)
 		fields.NewField("requester", request.Requester),
 		fields.NewField("request_id", request.RequestID),
 	)
-	f.AppendMapString(responseData.Details)
+	f.AppendMapString(responseData.Details.Map())
 
 	// Log the response using separate fields
 	h.logger.Info(2703, "status data", f)
 	return response, nil
 }
 
+// collector gathers one or more status items. Collectors run concurrently, each adding to its
+// own AgentStatusData, which is merged into the status data if the collector finishes in time.
+type collector struct {
+	name    string
+	keys    []string // reported as "unknown" if the collector does not finish in time
+	collect func(ctx context.Context, data *schema.AgentStatusData)
+}
+
+// stringCollector returns a collector for a single status item
+func stringCollector(key string, f func(ctx context.Context) string) collector {
+	return collector{
+		name: key,
+		keys: []string{key},
+		collect: func(ctx context.Context, data *schema.AgentStatusData) {
+			data.Details.Set(key, f(ctx))
+		},
+	}
+}
+
 // CollectStatusData gathers all status items into AgentStatusData for reporting or testing.
+// Items that are slow to collect are reported as "unknown" so that a command that does not
+// respond can not delay the status indefinitely.
 func (h *Handler) CollectStatusData() schema.AgentStatusData {
-	details := make(map[string]string)
-	details["uem_agent"] = fmt.Sprintf("%s-%d", global.Version, global.Build)
-	details["collected"] = time.Now().Format("2006-01-02T15:04:05-07:00")
-	details["os"] = h.osName()
-	details["os_version"] = h.osVersion()
-	details["firewall"] = h.firewall()
-	details["antivirus"] = h.antivirus()
-	details["auto_updates"] = h.autoUpdates()
-	details["full_disk_encryption"] = h.fde()
-	details["password"] = h.password()
-	lock, err := h.screenLock()
-	if err != nil && h.logger != nil {
-		h.logger.Error(2704, err.Error(), nil)
-		details["screen_lock"] = "unknown"
-	} else {
-		details["screen_lock"] = lock
-	}
-	details["screen_lock_delay"] = h.screenLockDelay()
-	details["hostname"] = h.hostname()
-	details["last_user"] = h.lastUser()
-	details["boot_time"] = h.bootTime()
-	details["ip"] = h.ip()
+	data := h.runCollectors(h.collectors(), global.StatusCollectorTimeout*time.Second, h.statusTimeout())
+	data.Details.UEMAgent = fmt.Sprintf("%s-%d", global.Version, global.Build)
+	data.Details.Collected = time.Now()
+	data.Details.OS = h.osName()
+	data.Details.Hostname = h.hostname()
+	data.Details.IP = h.ip()
+	data.Details.Lost = global.Lost
+
+	if !global.HaveServiceAccount {
+		data.Details.ServiceAccount = "n/a"
+	}
+	return data
+}
+
+// collectors returns the collectors for status items that may run external commands
+func (h *Handler) collectors() []collector {
+	c := []collector{
+		stringCollector("os_version", h.osVersion),
+		stringCollector("firewall", h.firewall),
+		stringCollector("antivirus", h.antivirus),
+		stringCollector("auto_updates", h.autoUpdates),
+		stringCollector("full_disk_encryption", h.fde),
+		stringCollector("password", h.password),
+		stringCollector("screen_lock", h.screenLockStatus),
+		stringCollector("screen_lock_delay", h.screenLockDelay),
+		stringCollector("last_user", h.lastUser),
+		stringCollector("boot_time", h.bootTime),
+		{
+			name: "hardware",
+			keys: hardwareKeys,
+			collect: func(ctx context.Context, data *schema.AgentStatusData) {
+				h.hardware(ctx, &data.Details)
+			},
+		},
+		{
+			name: "antivirus_products",
+			collect: func(ctx context.Context, data *schema.AgentStatusData) {
+				data.AntivirusProducts = h.antivirusProducts(ctx)
+			},
+		},
+		{
+			name: "info",
+			collect: func(ctx context.Context, data *schema.AgentStatusData) {
+				data.Info = h.info(ctx)
+			},
+		},
+	}
+
+	c = append(c, h.platformCollectors()...)
+
+	if global.Lost {
+		c = append(c, collector{
+			name: "lost_mode",
+			keys: h.lostModeKeys(),
+			collect: func(ctx context.Context, data *schema.AgentStatusData) {
+				h.lostMode(ctx, &data.Details)
+			},
+		})
+	}
 
 	if global.HaveServiceAccount {
-		details["service_account"] = h.checkServiceAccount()
-	} else {
-		details["service_account"] = "n/a"
+		c = append(c, stringCollector("service_account", h.checkServiceAccount))
 	}
+	return c
+}
+
+// runCollectors runs the collectors concurrently and merges their results. The context passed
+// to each collector expires after collectorTimeout, or when the ceiling for the whole status is
+// reached. A collector that has not finished by then is abandoned and its keys are reported as
+// "unknown". Commands started with the context are killed when it expires.
+func (h *Handler) runCollectors(collectors []collector, collectorTimeout, ceiling time.Duration) schema.AgentStatusData {
+	ctx, cancel := context.WithTimeout(context.Background(), ceiling)
+	defer cancel()
 
-	return schema.AgentStatusData{
-		Details: details,
-		Info:    h.info(),
+	type running struct {
+		ctx     context.Context
+		cancel  context.CancelFunc
+		data    *schema.AgentStatusData
+		done    chan struct{}
+		expired bool // the context expired before the collector returned
 	}
+
+	runs := make([]running, len(collectors))
+	for x, c := range collectors {
+		cctx, ccancel := context.WithTimeout(ctx, collectorTimeout)
+		runs[x] = running{
+			ctx:    cctx,
+			cancel: ccancel,
+			data:   &schema.AgentStatusData{Details: schema.NewStatusDetails()},
+			done:   make(chan struct{}),
+		}
+
+		go func(c collector, r *running) {
+			defer close(r.done)
+			c.collect(r.ctx, r.data)
+			r.expired = r.ctx.Err() != nil
+		}(c, &runs[x])
+	}
+
+	result := schema.AgentStatusData{Details: schema.NewStatusDetails()}
+	for x, c := range collectors {
+		r := &runs[x]
+
+		// Results from a collector that returned after its context expired are incomplete,
+		// because the commands it was running were killed
+		timedOut := true
+		select {
+		case <-r.done:
+			timedOut = r.expired
+		case <-r.ctx.Done():
+			select {
+			case <-r.done:
+				timedOut = r.expired
+			default:
+			}
+		}
+		r.cancel()
+
+		if timedOut {
+			h.collectorTimeout(c, r.ctx.Err(), &result.Details)
+			continue
+		}
+
+		result.Details.Merge(r.data.Details)
+		result.Info = append(result.Info, r.data.Info...)
+		result.AntivirusProducts = append(result.AntivirusProducts, r.data.AntivirusProducts...)
+	}
+	return result
+}
+
+// collectorTimeout logs a collector that did not finish in time and reports its keys as "unknown"
+func (h *Handler) collectorTimeout(c collector, err error, details *schema.StatusDetails) {
+	for _, key := range c.keys {
+		details.Set(key, "unknown")
+	}
+
+	if h.logger != nil {
+		h.logger.Warning(2718, "status collection timed out",
+			fields.NewFields(
+				fields.NewField("collector", c.name),
+				fields.NewField("error", err.Error())))
+	}
+}
+
+// statusTimeout returns the maximum time to wait for status collection
+func (h *Handler) statusTimeout() time.Duration {
+	if h.config == nil {
+		return global.StatusCollectorTimeout * time.Second
+	}
+	return time.Duration(h.config.AC.Get(schema.ConfigAgentStatusTimeout).Int()) * time.Second
+}
+
+// runner returns a runner for collectors' commands, with output limited in case a command
+// produces more than expected
+func (h *Handler) runner() *runCmd.Runner {
+	return runCmd.New(runCmd.WithLogger(h.logger), runCmd.WithMaxOutput(global.MaxCommandOutput))
+}
+
+// screenLockStatus returns the screen lock status, or "unknown" if it could not be determined
+func (h *Handler) screenLockStatus(ctx context.Context) string {
+	lock, err := h.screenLock(ctx)
+	if err != nil {
+		if h.logger != nil {
+			h.logger.Error(2704, err.Error(), nil)
+		}
+		return "unknown"
+	}
+	return lock
+}
+
+// consoleUserData returns the data most recently sent by the console user's user-helper, if it
+// is recent. The user-helper runs in the user's session, so its view of per-user settings such
+// as the screen lock is preferred to reading them from the service.
+func (h *Handler) consoleUserData() (UserContextData, bool) {
+	if h.userDataSource == nil {
+		return UserContextData{}, false
+	}
+	userData, exists := h.userDataSource.GetConsoleUserData()
+	if !exists || time.Since(userData.Timestamp) >= 10*time.Minute {
+		return UserContextData{}, false
+	}
+	return userData, true
+}
+
+// ScreenLock is the exported version for external packages
+func (h *Handler) ScreenLock() (string, error) {
+	return h.screenLock(context.Background())
+}
+
+// ScreenLockDelay is the exported version for external packages
+func (h *Handler) ScreenLockDelay() string {
+	return h.screenLockDelay(context.Background())
+}
+
+// LastUser is the exported version for external packages
+func (h *Handler) LastUser() string {
+	return h.lastUser(context.Background())
 }
 
 // trapError is a helper function to log errors
@@ -6,6 +6,7 @@
 package status
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/UnifyEM/UnifyEM/agent/osActions"
 	"github.com/UnifyEM/UnifyEM/common"
+	"github.com/UnifyEM/UnifyEM/common/schema"
 	"howett.net/plist"
 )
 
@@ -53,18 +55,18 @@ func (h *Handler) osName() string {
 	return "macOS"
 }
 
-func (h *Handler) osVersion() string {
-	out, err := exec.Command("sw_vers", "-productVersion").Output()
+func (h *Handler) osVersion(ctx context.Context) string {
+	out, err := exec.CommandContext(ctx, "sw_vers", "-productVersion").Output()
 	if err != nil {
 		return "unknown"
 	}
 	return strings.TrimSpace(string(out))
 }
 
-func (h *Handler) firewall() string {
+func (h *Handler) firewall(ctx context.Context) string {
 
 	// Try plist first
-	state, err := h.getPlistValue("/Library/Preferences/com.apple.alf", "globalstate")
+	state, err := h.getPlistValue(ctx, "/Library/Preferences/com.apple.alf", "globalstate")
 	if err == nil {
 		if state == "1" {
 			return "yes"
@@ -73,7 +75,7 @@ func (h *Handler) firewall() string {
 	}
 
 	// Try socketfilterfw
-	out, err := exec.Command("/usr/libexec/ApplicationFirewall/socketfilterfw", "--getglobalstate").Output()
+	out, err := exec.CommandContext(ctx, "/usr/libexec/ApplicationFirewall/socketfilterfw", "--getglobalstate").Output()
 	if err != nil {
 		return "unknown"
 	}
@@ -86,14 +88,14 @@ func (h *Handler) firewall() string {
 	return "unknown"
 }
 
-func (h *Handler) antivirus() string {
+func (h *Handler) antivirus(ctx context.Context) string {
 	for _, path := range macAntivirusPaths {
-		if _, err := exec.Command("test", "-e", path).Output(); err == nil {
+		if _, err := exec.CommandContext(ctx, "test", "-e", path).Output(); err == nil {
 			return "yes"
 		}
 	}
 
-	out, err := exec.Command("ps", "aux").Output()
+	out, err := exec.CommandContext(ctx, "ps", "aux").Output()
 	if err != nil {
 		return "unknown"
 	}
@@ -107,8 +109,54 @@ func (h *Handler) antivirus() string {
 	return "no"
 }
 
-func (h *Handler) autoUpdates() string {
-	out, err := h.getPlistValue("/Library/Preferences/com.apple.SoftwareUpdate",
+// antivirusProducts returns the known antivirus applications that are installed, with their
+// versions, and the products of known antivirus processes that are running
+func (h *Handler) antivirusProducts(ctx context.Context) []schema.AntivirusProduct {
+	var products []schema.AntivirusProduct
+	for _, path := range macAntivirusPaths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		product := schema.AntivirusProduct{
+			Name:    strings.TrimSuffix(filepath.Base(path), ".app"),
+			Enabled: "unknown",
+			Updated: "unknown",
+		}
+		version, err := h.runner().StdoutCtx(ctx, "defaults", "read", path+"/Contents/Info", "CFBundleShortVersionString")
+		if err == nil {
+			product.Version = strings.TrimSpace(version)
+		}
+		products = append(products, product)
+	}
+
+	out, err := h.runner().StdoutCtx(ctx, "ps", "aux")
+	if err != nil {
+		return products
+	}
+
+	// A running process means that the product is enabled
+	for _, running := range antivirusProcessProducts(out) {
+		if name, ok := macAntivirusProcessProducts[running.Name]; ok {
+			running.Name = name
+		}
+
+		found := false
+		for x := range products {
+			if products[x].Name == running.Name {
+				products[x].Enabled = "yes"
+				found = true
+			}
+		}
+		if !found {
+			products = append(products, running)
+		}
+	}
+	return products
+}
+
+func (h *Handler) autoUpdates(ctx context.Context) string {
+	out, err := h.getPlistValue(ctx, "/Library/Preferences/com.apple.SoftwareUpdate",
 		"AutomaticallyInstallMacOSUpdates")
 	if err != nil {
 		return "unknown"
@@ -121,8 +169,8 @@ func (h *Handler) autoUpdates() string {
 	return "no"
 }
 
-func (h *Handler) fde() string {
-	out, err := exec.Command("fdesetup", "status").Output()
+func (h *Handler) fde(ctx context.Context) string {
+	out, err := exec.CommandContext(ctx, "fdesetup", "status").Output()
 	if err != nil {
 		return "unknown"
 	}
@@ -133,8 +181,8 @@ func (h *Handler) fde() string {
 	return "no"
 }
 
-func (h *Handler) password() string {
-	out, err := h.getPlistValue("/Library/Preferences/com.apple.loginwindow", "autoLoginUser")
+func (h *Handler) password(ctx context.Context) string {
+	out, err := h.getPlistValue(ctx, "/Library/Preferences/com.apple.loginwindow", "autoLoginUser")
 	if err != nil {
 		// Check if the exit code indicates the key does not exist
 		var exitError *exec.ExitError
@@ -152,7 +200,7 @@ func (h *Handler) password() string {
 	return "no"
 }
 
-func (h *Handler) screenLockDelay() string {
+func (h *Handler) screenLockDelay(ctx context.Context) string {
 	// NOTE: On macOS 13+ (Ventura/Sequoia), the password delay setting
 	// ("Require password after screen saver begins: After X seconds")
 	// is NOT accessible via any API (AppleScript, plists, or system databases).
@@ -160,29 +208,26 @@ func (h *Handler) screenLockDelay() string {
 	// See: https://github.com/UnifyEM/UnifyEM/issues/XXX
 
 	// First try console user-helper data
-	if h.userDataSource != nil {
-		userData, exists := h.userDataSource.GetConsoleUserData()
-		if exists && time.Since(userData.Timestamp) < 10*time.Minute {
-			h.logger.Debugf(2716, "Using screen lock delay from console user helper: %s", userData.ScreenLockDelay)
-			return userData.ScreenLockDelay
-		}
+	if userData, exists := h.consoleUserData(); exists {
+		h.logger.Debugf(2716, "Using screen lock delay from console user helper: %s", userData.ScreenLockDelay)
+		return userData.ScreenLockDelay
 	}
 
 	// Fallback to reading screen saver idle time
 	// This is the time before the screen saver STARTS, not the password delay
 
 	// Try defaults command first (works in user mode without TCC)
-	out, err := exec.Command("defaults", "-currentHost", "read", "com.apple.screensaver", "idleTime").Output()
+	out, err := exec.CommandContext(ctx, "defaults", "-currentHost", "read", "com.apple.screensaver", "idleTime").Output()
 	if err == nil {
 		return strings.TrimSpace(string(out))
 	}
 
 	// If that fails, try plist reading
-	username := h.lastUser()
+	username := h.lastUser(ctx)
 	if username == "unknown" {
 		return "unknown"
 	}
-	enabled, _, delay, err := h.getUserScreenSaverStatus(username)
+	enabled, _, delay, err := h.getUserScreenSaverStatus(ctx, username)
 	if err != nil {
 		return "unknown"
 	}
@@ -192,34 +237,26 @@ func (h *Handler) screenLockDelay() string {
 	return fmt.Sprintf("%d", delay)
 }
 
-// ScreenLockDelay is the exported version for external packages
-func (h *Handler) ScreenLockDelay() string {
-	return h.screenLockDelay()
-}
-
-func (h *Handler) screenLock() (string, error) {
+func (h *Handler) screenLock(ctx context.Context) (string, error) {
 	// Returns whether password is required to wake from sleep/screensaver
 	// Uses: System Events -> security preferences -> require password to wake
 	// This works on all macOS versions via AppleScript
 
 	// First try to get data from user-helper (console user)
-	if h.userDataSource != nil {
-		userData, exists := h.userDataSource.GetConsoleUserData()
-		if exists && time.Since(userData.Timestamp) < 10*time.Minute {
-			h.logger.Debugf(2715, "Using screen lock data from console user helper: %s", userData.ScreenLock)
-			return userData.ScreenLock, nil
-		}
+	if userData, exists := h.consoleUserData(); exists {
+		h.logger.Debugf(2715, "Using screen lock data from console user helper: %s", userData.ScreenLock)
+		return userData.ScreenLock, nil
 	}
 
 	// Fallback to plist/AppleScript methods
-	username := h.lastUser()
+	username := h.lastUser(ctx)
 	if username == "unknown" {
 		return "unknown", fmt.Errorf("could not determine last user")
 	}
-	enabled, requirePassword, _, err := h.getUserScreenSaverStatus(username)
+	enabled, requirePassword, _, err := h.getUserScreenSaverStatus(ctx, username)
 	if err != nil {
 		// Fallback to AppleScript for current user context
-		out, err2 := h.getAppleScript("tell application \"System Events\" to tell security preferences to get require password to wake")
+		out, err2 := h.getAppleScript(ctx, "tell application \"System Events\" to tell security preferences to get require password to wake")
 		if err2 != nil {
 			h.logger.Errorf(2710, "error getting screen lock status from AppleScript: %s [%s]",
 				err2.Error(), out)
@@ -236,13 +273,8 @@ func (h *Handler) screenLock() (string, error) {
 	return "no", nil
 }
 
-// ScreenLock is the exported version for external packages
-func (h *Handler) ScreenLock() (string, error) {
-	return h.screenLock()
-}
-
 // getUserScreenSaverStatus checks the screensaver/lock status for a given user
-func (h *Handler) getUserScreenSaverStatus(username string) (enabled bool, requirePassword bool, delay int, err error) {
+func (h *Handler) getUserScreenSaverStatus(ctx context.Context, username string) (enabled bool, requirePassword bool, delay int, err error) {
 	usr, err := user.Lookup(username)
 	if err != nil {
 		return false, false, 0, fmt.Errorf("could not lookup user: %w", err)
@@ -346,7 +378,7 @@ func (h *Handler) getUserScreenSaverStatus(username string) (enabled bool, requi
 	// If askForPassword is not set, try AppleScript as a last resort
 	if askForPassword == 0 {
 		script := `tell application "System Events" to get require password to wake of security preferences`
-		out, err := h.runUserAppleScript(username, script)
+		out, err := h.runUserAppleScript(ctx, username, script)
 		if err == nil {
 			requirePassword = out == "true"
 			return enabled, requirePassword, delay, nil
@@ -359,8 +391,8 @@ func (h *Handler) getUserScreenSaverStatus(username string) (enabled bool, requi
 	return enabled, requirePassword, delay, nil
 }
 
-func (h *Handler) bootTime() string {
-	out, err := exec.Command("sysctl", "-n", "kern.boottime").Output()
+func (h *Handler) bootTime(ctx context.Context) string {
+	out, err := exec.CommandContext(ctx, "sysctl", "-n", "kern.boottime").Output()
 	if err != nil {
 		return "unknown"
 	}
@@ -387,22 +419,17 @@ func (h *Handler) bootTime() string {
 	return bootTimeInt.Format("2006-01-02T15:04:05-07:00")
 }
 
-func (h *Handler) lastUser() string {
-	out, err := exec.Command("defaults", "read", "/Library/Preferences/com.apple.loginwindow", "lastUserName").Output()
+func (h *Handler) lastUser(ctx context.Context) string {
+	out, err := exec.CommandContext(ctx, "defaults", "read", "/Library/Preferences/com.apple.loginwindow", "lastUserName").Output()
 	if err != nil {
 		return "unknown"
 	}
 	return strings.TrimSpace(string(out))
 }
 
-// LastUser is the exported version for external packages
-func (h *Handler) LastUser() string {
-	return h.lastUser()
-}
-
 // getPlistValue retrieves the value associated with name from a plist at location
-func (h *Handler) getPlistValue(location string, name string) (string, error) {
-	value, err := exec.Command("defaults", "-currentHost", "read", location, name).Output()
+func (h *Handler) getPlistValue(ctx context.Context, location string, name string) (string, error) {
+	value, err := exec.CommandContext(ctx, "defaults", "-currentHost", "read", location, name).Output()
 	if err != nil {
 		return "", err
 	}
@@ -416,9 +443,9 @@ If running as a regular user (user-helper), it executes directly.
 If username is empty, it falls back to the last user.
 Returns the trimmed output or an error.
 */
-func (h *Handler) runUserAppleScript(username, script string) (string, error) {
+func (h *Handler) runUserAppleScript(ctx context.Context, username, script string) (string, error) {
 	if username == "" {
-		username = h.lastUser()
+		username = h.lastUser(ctx)
 	}
 	if username == "unknown" {
 		return "", fmt.Errorf("no user available to run AppleScript")
@@ -433,12 +460,12 @@ func (h *Handler) runUserAppleScript(username, script string) (string, error) {
 		cmdString = fmt.Sprintf("/bin/launchctl asuser %s sudo -u %s /usr/bin/osascript -e '%s'",
 			username, username, script)
 		h.logger.Debugf(2712, "executing %s", cmdString)
-		cmd = exec.Command("/bin/launchctl", "asuser", username, "sudo", "-u", username, "/usr/bin/osascript", "-e", script)
+		cmd = exec.CommandContext(ctx, "/bin/launchctl", "asuser", username, "sudo", "-u", username, "/usr/bin/osascript", "-e", script)
 	} else {
 		// Running as regular user (user-helper mode) - execute directly
 		cmdString = fmt.Sprintf("/usr/bin/osascript -e '%s'", script)
 		h.logger.Debugf(2712, "executing %s", cmdString)
-		cmd = exec.Command("/usr/bin/osascript", "-e", script)
+		cmd = exec.CommandContext(ctx, "/usr/bin/osascript", "-e", script)
 	}
 
 	out, err := cmd.CombinedOutput()
@@ -452,9 +479,9 @@ func (h *Handler) runUserAppleScript(username, script string) (string, error) {
 }
 
 // getCurrentOrLastUser returns the currently logged-in user, or falls back to lastUser().
-func (h *Handler) getCurrentOrLastUser() string {
+func (h *Handler) getCurrentOrLastUser(ctx context.Context) string {
 	// Try "who" to get the console user
-	out, err := exec.Command("/usr/bin/who").Output()
+	out, err := exec.CommandContext(ctx, "/usr/bin/who").Output()
 	if err == nil {
 		lines := strings.Split(string(out), "\n")
 		for _, line := range lines {
@@ -465,17 +492,17 @@ func (h *Handler) getCurrentOrLastUser() string {
 		}
 	}
 	// Fallback to lastUser()
-	return h.lastUser()
+	return h.lastUser(ctx)
 }
 
 // getAppleScript runs an AppleScript as the current or last user (for backward compatibility)
-func (h *Handler) getAppleScript(script string) (string, error) {
-	username := h.getCurrentOrLastUser()
-	return h.runUserAppleScript(username, script)
+func (h *Handler) getAppleScript(ctx context.Context, script string) (string, error) {
+	username := h.getCurrentOrLastUser(ctx)
+	return h.runUserAppleScript(ctx, username, script)
 }
 
 // checkServiceAccount tests if the service account credentials in memory are valid
-func (h *Handler) checkServiceAccount() string {
+func (h *Handler) checkServiceAccount(ctx context.Context) string {
 	// Get credentials from config
 	username, password, err := h.config.GetServiceCredentials()
 	if err != nil {
@@ -502,11 +529,11 @@ func (h *Handler) checkServiceAccount() string {
 }
 
 // info returns platform-specific informational items
-func (h *Handler) info() []string {
+func (h *Handler) info(ctx context.Context) []string {
 	var items []string
 
 	// Check remote login status
-	cmd := exec.Command("systemsetup", "-getremotelogin")
+	cmd := exec.CommandContext(ctx, "systemsetup", "-getremotelogin")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		// Error occurred - return both stdout and stderr
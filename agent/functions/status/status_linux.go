@@ -8,12 +8,18 @@ package status
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/runCmd"
+	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
 // osName returns the OS name
@@ -22,7 +28,7 @@ func (h *Handler) osName() string {
 }
 
 // osVersion returns the Linux distribution and version
-func (h *Handler) osVersion() string {
+func (h *Handler) osVersion(ctx context.Context) string {
 	// Try /etc/os-release (standard on all modern distros)
 	f, err := os.Open("/etc/os-release")
 	if err == nil {
@@ -46,7 +52,7 @@ func (h *Handler) osVersion() string {
 		}
 	}
 	// Fallback to lsb_release -d (may not be installed by default)
-	out, err := exec.Command("lsb_release", "-d").Output()
+	out, err := exec.CommandContext(ctx, "lsb_release", "-d").Output()
 	if err == nil {
 		parts := strings.SplitN(string(out), ":", 2)
 		if len(parts) == 2 {
@@ -57,10 +63,10 @@ func (h *Handler) osVersion() string {
 }
 
 // firewall returns "yes" if a firewall is enabled, "no" if not, "unknown" otherwise
-func (h *Handler) firewall() string {
+func (h *Handler) firewall(ctx context.Context) string {
 
 	// Check for ufw
-	out, err := exec.Command("ufw", "status").Output()
+	out, err := exec.CommandContext(ctx, "ufw", "status").Output()
 	if err == nil {
 		if bytes.Contains(out, []byte("Status: active")) {
 			return "yes"
@@ -69,7 +75,7 @@ func (h *Handler) firewall() string {
 
 	// Check for firewalld - note that err with exit code 4 means inactive, it's not an error
 	// If it is running, err==nil, exit code -
-	out, err = exec.Command("systemctl", "is-active", "firewalld").Output()
+	out, err = exec.CommandContext(ctx, "systemctl", "is-active", "firewalld").Output()
 	if err == nil {
 		if strings.TrimSpace(string(out)) == "active" {
 			return "yes"
@@ -77,7 +83,7 @@ func (h *Handler) firewall() string {
 	}
 
 	// Check for iptables rules
-	out, err = exec.Command("iptables", "-L").Output()
+	out, err = exec.CommandContext(ctx, "iptables", "-L").Output()
 	if err == nil && len(out) > 0 {
 		// If there are any rules other than default ACCEPT, assume firewall is active
 		if !bytes.Contains(out, []byte("Chain INPUT (policy ACCEPT)")) {
@@ -90,8 +96,8 @@ func (h *Handler) firewall() string {
 }
 
 // antivirus returns "yes" if a known AV process is running, "no" if not, "unknown" otherwise
-func (h *Handler) antivirus() string {
-	out, err := exec.Command("ps", "aux").Output()
+func (h *Handler) antivirus(ctx context.Context) string {
+	out, err := exec.CommandContext(ctx, "ps", "aux").Output()
 	if err != nil {
 		return "unknown"
 	}
@@ -103,8 +109,17 @@ func (h *Handler) antivirus() string {
 	return "no"
 }
 
+// antivirusProducts returns the known antivirus processes that are running
+func (h *Handler) antivirusProducts(ctx context.Context) []schema.AntivirusProduct {
+	out, err := h.runner().StdoutCtx(ctx, "ps", "aux")
+	if err != nil {
+		return nil
+	}
+	return antivirusProcessProducts(out)
+}
+
 // autoUpdates returns "yes" if automatic updates are enabled, "no" if not, "unknown" otherwise
-func (h *Handler) autoUpdates() string {
+func (h *Handler) autoUpdates(ctx context.Context) string {
 
 	// Check for unattended-upgrades (Debian/Ubuntu)
 	f, err := os.Open("/etc/apt/apt.conf.d/20auto-upgrades")
@@ -123,7 +138,7 @@ func (h *Handler) autoUpdates() string {
 	}
 
 	// Check for dnf-automatic (Fedora/RHEL)
-	out, err := exec.Command("systemctl", "is-enabled", "dnf-automatic.timer").Output()
+	out, err := exec.CommandContext(ctx, "systemctl", "is-enabled", "dnf-automatic.timer").Output()
 	if err == nil {
 		if strings.Contains(string(out), "enabled") {
 			return "yes"
@@ -136,11 +151,11 @@ func (h *Handler) autoUpdates() string {
 
 // fde returns "yes" if full disk encryption is enabled, otherwise "no"
 // This function uses multiple detection methods to identify LUKS-encrypted drives on Ubuntu and other Linux distros.
-func (h *Handler) fde() string {
+func (h *Handler) fde(ctx context.Context) string {
 
 	// Method 1: Check lsblk for crypt devices with FSTYPE column
 	// This method detects LUKS volumes by examining device type and filesystem type
-	out, err := exec.Command("lsblk", "-o", "NAME,TYPE,FSTYPE,MOUNTPOINT", "-n").Output()
+	out, err := exec.CommandContext(ctx, "lsblk", "-o", "NAME,TYPE,FSTYPE,MOUNTPOINT", "-n").Output()
 	if err == nil {
 		lines := strings.Split(string(out), "\n")
 		for _, line := range lines {
@@ -191,7 +206,7 @@ func (h *Handler) fde() string {
 	}
 
 	// Method 3: Check for active LUKS devices via dmsetup
-	out, err = exec.Command("dmsetup", "ls", "--target", "crypt").Output()
+	out, err = exec.CommandContext(ctx, "dmsetup", "ls", "--target", "crypt").Output()
 	if err == nil {
 		if len(strings.TrimSpace(string(out))) > 0 {
 			// dmsetup found active crypt targets
@@ -202,7 +217,7 @@ func (h *Handler) fde() string {
 	// Method 4: Check cryptsetup status for common device names
 	commonNames := []string{"root", "cryptroot", "luks", "crypt", "sda1_crypt", "sda2_crypt", "nvme0n1p1_crypt"}
 	for _, name := range commonNames {
-		out, err = exec.Command("cryptsetup", "status", name).Output()
+		out, err = exec.CommandContext(ctx, "cryptsetup", "status", name).Output()
 		if err == nil {
 			if strings.Contains(string(out), "/dev/mapper/") {
 				return "yes"
@@ -211,7 +226,7 @@ func (h *Handler) fde() string {
 	}
 
 	// Method 5: Check for eCryptfs
-	out, err = exec.Command("mount").Output()
+	out, err = exec.CommandContext(ctx, "mount").Output()
 	if err == nil {
 		if bytes.Contains(out, []byte("ecryptfs")) {
 			return "yes"
@@ -223,7 +238,7 @@ func (h *Handler) fde() string {
 }
 
 // password returns "yes" if the current user has a password set, "no" if not, "unknown" otherwise
-func (h *Handler) password() string {
+func (h *Handler) password(ctx context.Context) string {
 	currentUser := os.Getenv("USER")
 	if currentUser == "" {
 		// Try LOGNAME as a fallback
@@ -271,11 +286,11 @@ func parseGSettingsValue(output string) string {
 
 // getDisplayEnv tries to find a DISPLAY environment variable for a running X11/Wayland session.
 // Returns the DISPLAY value and true if found, otherwise "" and false.
-func (h *Handler) getDisplayEnv() (string, bool) {
+func (h *Handler) getDisplayEnv(ctx context.Context) (string, bool) {
 	// Check for X11 sockets
 	if files, err := os.ReadDir("/tmp/.X11-unix"); err == nil && len(files) > 0 {
 		// Try to find a DISPLAY from a running Xorg/X process
-		out, err := exec.Command("ps", "axo", "pid,comm").Output()
+		out, err := exec.CommandContext(ctx, "ps", "axo", "pid,comm").Output()
 		if err == nil {
 			lines := strings.Split(string(out), "\n")
 			for _, line := range lines {
@@ -303,7 +318,7 @@ func (h *Handler) getDisplayEnv() (string, bool) {
 		waylandSock := filepath.Join(dir, "wayland-0")
 		if _, err := os.Stat(waylandSock); err == nil {
 			// Try to find WAYLAND_DISPLAY from a compositor process
-			out, err := exec.Command("ps", "axo", "pid,comm").Output()
+			out, err := exec.CommandContext(ctx, "ps", "axo", "pid,comm").Output()
 			if err == nil {
 				lines := strings.Split(string(out), "\n")
 				for _, line := range lines {
@@ -329,20 +344,58 @@ func (h *Handler) getDisplayEnv() (string, bool) {
 	return "", false
 }
 
+// displayRunner returns a runner for commands that need the user's display. DISPLAY is set for
+// each command rather than in the agent's environment, which is shared by concurrent collectors.
+func (h *Handler) displayRunner(display string) *runCmd.Runner {
+	return h.runner().Env(map[string]string{"DISPLAY": display})
+}
+
+// noDisplay returns the screen lock status when no display was found. On a server without a
+// graphical session the screen lock does not apply, but if systemd reports a graphical session
+// that could not be found, the status is not known.
+func (h *Handler) noDisplay(ctx context.Context) string {
+	out, err := exec.CommandContext(ctx, "loginctl", "list-sessions", "--no-legend").Output()
+	if err != nil {
+		return "n/a"
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		sessionType, err := exec.CommandContext(ctx, "loginctl", "show-session", fields[0], "--property=Type", "--value").Output()
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(string(sessionType)) {
+		case "x11", "wayland", "mir":
+			return "unknown"
+		}
+	}
+	return "n/a"
+}
+
 // screenLock returns "yes" if the user's screen will automatically lock after inactivity, "no" if not, "unknown" otherwise
-func (h *Handler) screenLock() (string, error) {
-	display, found := h.getDisplayEnv()
+func (h *Handler) screenLock(ctx context.Context) (string, error) {
+	// The user-helper reads the settings from the user's own session
+	if userData, exists := h.consoleUserData(); exists {
+		h.logger.Debugf(2715, "Using screen lock data from console user helper: %s", userData.ScreenLock)
+		return userData.ScreenLock, nil
+	}
+
+	display, found := h.getDisplayEnv(ctx)
 	if !found {
-		return "n/a", nil
+		return h.noDisplay(ctx), nil
 	}
-	// Set DISPLAY for child commands
-	os.Setenv("DISPLAY", display)
+	runner := h.displayRunner(display)
+
 	// Check GNOME settings: lock-enabled and idle-delay
-	lockOut, err1 := exec.Command("gsettings", "get", "org.gnome.desktop.screensaver", "lock-enabled").Output()
-	idleOut, err2 := exec.Command("gsettings", "get", "org.gnome.desktop.session", "idle-delay").Output()
+	lockOut, err1 := runner.StdoutCtx(ctx, "gsettings", "get", "org.gnome.desktop.screensaver", "lock-enabled")
+	idleOut, err2 := runner.StdoutCtx(ctx, "gsettings", "get", "org.gnome.desktop.session", "idle-delay")
 	if err1 == nil && err2 == nil {
-		lockVal := strings.TrimSpace(string(lockOut))
-		idleVal := parseGSettingsValue(string(idleOut))
+		lockVal := strings.TrimSpace(lockOut)
+		idleVal := parseGSettingsValue(idleOut)
 		if lockVal == "true" {
 			// idle-delay is in seconds, must be > 0
 			if idleSec, err := strconv.Atoi(idleVal); err == nil && idleSec > 0 {
@@ -354,35 +407,40 @@ func (h *Handler) screenLock() (string, error) {
 	}
 
 	// Try xdg-screensaver (generic X11) as a fallback
-	out, err := exec.Command("xdg-screensaver", "status").Output()
+	out, err := runner.StdoutCtx(ctx, "xdg-screensaver", "status")
 	if err == nil {
-		if strings.Contains(string(out), "enabled") {
+		if strings.Contains(out, "enabled") {
 			return "yes", nil
 		}
-		if strings.Contains(string(out), "disabled") {
+		if strings.Contains(out, "disabled") {
 			return "no", nil
 		}
 	}
 	return "unknown", nil
 }
 
-func (h *Handler) screenLockDelay() string {
-	display, found := h.getDisplayEnv()
+func (h *Handler) screenLockDelay(ctx context.Context) string {
+	if userData, exists := h.consoleUserData(); exists {
+		h.logger.Debugf(2716, "Using screen lock delay from console user helper: %s", userData.ScreenLockDelay)
+		return userData.ScreenLockDelay
+	}
+
+	display, found := h.getDisplayEnv(ctx)
 	if !found {
-		return "n/a"
+		return h.noDisplay(ctx)
 	}
-	// Set DISPLAY for child commands
-	os.Setenv("DISPLAY", display)
+	runner := h.displayRunner(display)
+
 	// Try gsettings (GNOME, Ubuntu, Debian, CentOS default)
-	out, err := exec.Command("gsettings", "get", "org.gnome.desktop.session", "idle-delay").Output()
+	out, err := runner.StdoutCtx(ctx, "gsettings", "get", "org.gnome.desktop.session", "idle-delay")
 	if err == nil {
-		return parseGSettingsValue(string(out))
+		return parseGSettingsValue(out)
 	}
 
 	// Try xfconf-query (XFCE)
-	out, err = exec.Command("xfconf-query", "-c", "xfce4-session", "-p", "/general/LockCommand").Output()
+	out, err = runner.StdoutCtx(ctx, "xfconf-query", "-c", "xfce4-session", "-p", "/general/LockCommand")
 	if err == nil {
-		val := strings.TrimSpace(string(out))
+		val := strings.TrimSpace(out)
 		if val != "" {
 			return val
 		}
@@ -398,7 +456,11 @@ func (h *Handler) screenLockDelay() string {
 			scanner := bufio.NewScanner(f)
 			for scanner.Scan() {
 				line := scanner.Text()
+				// The timeout is in minutes
 				if val, ok := strings.CutPrefix(line, "Timeout="); ok {
+					if minutes, err := strconv.Atoi(strings.TrimSpace(val)); err == nil {
+						return strconv.Itoa(minutes * 60)
+					}
 					return val
 				}
 			}
@@ -409,12 +471,13 @@ func (h *Handler) screenLockDelay() string {
 }
 
 // lastUser returns the last logged-in user
-func (h *Handler) lastUser() string {
-	out, err := exec.Command("last", "-w").Output()
+func (h *Handler) lastUser(ctx context.Context) string {
+	// The output includes every login recorded in wtmp, so it is limited by the runner
+	out, err := h.runner().StdoutCtx(ctx, "last", "-w")
 	if err != nil {
 		return "unknown"
 	}
-	lines := strings.Split(string(out), "\n")
+	lines := strings.Split(out, "\n")
 	for _, line := range lines {
 		fields := strings.Fields(line)
 		if len(fields) > 0 && fields[0] != "reboot" && fields[0] != "wtmp" {
@@ -425,7 +488,7 @@ func (h *Handler) lastUser() string {
 }
 
 // bootTime returns the system boot time as an ISO8601 string
-func (h *Handler) bootTime() string {
+func (h *Handler) bootTime(ctx context.Context) string {
 	// Try /proc/stat for btime
 	f, err := os.Open("/proc/stat")
 	if err == nil {
@@ -446,19 +509,188 @@ func (h *Handler) bootTime() string {
 		}
 	}
 	// Fallback to uptime
-	out, err := exec.Command("uptime", "-s").Output()
+	out, err := exec.CommandContext(ctx, "uptime", "-s").Output()
 	if err == nil {
 		return strings.TrimSpace(string(out))
 	}
 	return "unknown"
 }
 
+// Files updated each time automatic updates run. unattended-upgrades (Debian/Ubuntu) updates
+// its stamp, and systemd records when persistent timers such as dnf-automatic (Fedora/RHEL)
+// were last triggered.
+var updateStamps = []string{
+	"/var/lib/apt/periodic/unattended-upgrades-stamp",
+	"/var/lib/systemd/timers/stamp-dnf-automatic.timer",
+	"/var/lib/systemd/timers/stamp-dnf-automatic-install.timer",
+	"/var/lib/systemd/timers/stamp-dnf5-automatic.timer",
+}
+
+// Timers that run automatic updates, used if there is no stamp file
+var updateTimers = []string{"apt-daily-upgrade.timer", "dnf-automatic.timer", "dnf-automatic-install.timer", "dnf5-automatic.timer"}
+
+// platformCollectors returns the collectors for the Linux security state
+func (h *Handler) platformCollectors() []collector {
+	return []collector{
+		stringCollector(schema.StatusAccessControl, h.accessControl),
+		stringCollector(schema.StatusSSHPasswordAuth, h.sshPasswordAuth),
+		stringCollector(schema.StatusUpdatesRecent, h.updatesRecent),
+		stringCollector(schema.StatusRebootRequired, h.rebootRequired),
+	}
+}
+
+// accessControl returns the SELinux or AppArmor enforcement mode
+func (h *Handler) accessControl(ctx context.Context) string {
+	// SELinux (RHEL, Fedora, and derivatives)
+	if enforce, err := os.ReadFile("/sys/fs/selinux/enforce"); err == nil {
+		if mode := parseSELinuxMode(string(enforce)); mode != "" {
+			return mode
+		}
+	} else if out, err := exec.CommandContext(ctx, "getenforce").Output(); err == nil {
+		if mode := parseSELinuxMode(string(out)); mode != "" {
+			return mode
+		}
+	}
+
+	// AppArmor (Debian, Ubuntu, and SUSE)
+	enabled, err := os.ReadFile("/sys/module/apparmor/parameters/enabled")
+	if err != nil || strings.TrimSpace(string(enabled)) != "Y" {
+		return "disabled"
+	}
+	profiles, err := os.ReadFile("/sys/kernel/security/apparmor/profiles")
+	if err != nil {
+		return "unknown"
+	}
+	return parseAppArmorProfiles(string(profiles))
+}
+
+// sshPasswordAuth returns "yes" if the SSH server accepts password authentication, "no" if not,
+// "n/a" if it is not installed, and "unknown" otherwise
+func (h *Handler) sshPasswordAuth(ctx context.Context) string {
+	if _, err := os.Stat("/etc/ssh/sshd_config"); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "n/a"
+		}
+		return "unknown"
+	}
+
+	// sshd -T prints the effective configuration, including included files and defaults
+	out, err := exec.CommandContext(ctx, "/usr/sbin/sshd", "-T").Output()
+	if err == nil {
+		if auth := parseSSHDPasswordAuth(string(out)); auth != "unknown" {
+			return auth
+		}
+	}
+
+	// Read the configuration files, which fails if the server would not start
+	value, _ := sshdConfigOption("/etc/ssh/sshd_config", "/etc/ssh", "PasswordAuthentication", 0)
+	if value == "" {
+		// The OpenSSH default
+		return "yes"
+	}
+	return yesNo(value)
+}
+
+// updatesRecent returns "yes" if automatic updates have run within recentUpdateWindow, "no" if
+// not, and "unknown" if the package manager is not supported
+func (h *Handler) updatesRecent(ctx context.Context) string {
+	var last time.Time
+	for _, stamp := range updateStamps {
+		if info, err := os.Stat(stamp); err == nil && info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+
+	// Timers that are not persistent do not have a stamp file
+	if last.IsZero() {
+		for _, timer := range updateTimers {
+			out, err := exec.CommandContext(ctx, "systemctl", "show", timer,
+				"--property=LastTriggerUSec", "--value", "--timestamp=unix").Output()
+			if err != nil {
+				continue
+			}
+			if triggered, ok := parseUnixTimestamp(string(out)); ok && triggered.After(last) {
+				last = triggered
+			}
+		}
+	}
+
+	if !last.IsZero() {
+		if time.Since(last) <= recentUpdateWindow {
+			return "yes"
+		}
+		return "no"
+	}
+
+	// Automatic updates are not enabled or have never run
+	for _, manager := range []string{"apt-get", "dnf", "yum"} {
+		if _, err := exec.LookPath(manager); err == nil {
+			return "no"
+		}
+	}
+	return "unknown"
+}
+
+// rebootRequired returns "yes" if installed updates require a reboot, "no" if not, "unknown" otherwise
+func (h *Handler) rebootRequired(ctx context.Context) string {
+	// Debian and Ubuntu packages that require a reboot create this file
+	if _, err := os.Stat("/var/run/reboot-required"); err == nil {
+		return "yes"
+	}
+
+	// needs-restarting (Fedora/RHEL) exits with 1 if a reboot is required, and
+	// zypper (SUSE) exits with 102
+	checks := []struct {
+		cmdAndArgs []string
+		reboot     int
+	}{
+		{[]string{"needs-restarting", "-r"}, 1},
+		{[]string{"dnf", "needs-restarting", "-r"}, 1},
+		{[]string{"zypper", "needs-rebooting"}, 102},
+	}
+	for _, check := range checks {
+		if _, err := exec.LookPath(check.cmdAndArgs[0]); err != nil {
+			continue
+		}
+		code, err := exitCode(ctx, check.cmdAndArgs...)
+		if err != nil {
+			continue
+		}
+		switch code {
+		case 0:
+			return "no"
+		case check.reboot:
+			return "yes"
+		}
+	}
+
+	// The file is created by update-notifier, so its absence only means something if it is installed
+	if _, err := os.Stat("/usr/share/update-notifier/notify-reboot-required"); err == nil {
+		return "no"
+	}
+	return "unknown"
+}
+
+// exitCode runs a command and returns its exit code
+func exitCode(ctx context.Context, cmdAndArgs ...string) (int, error) {
+	err := exec.CommandContext(ctx, cmdAndArgs[0], cmdAndArgs[1:]...).Run()
+	if err == nil {
+		return 0, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		return exitErr.ExitCode(), nil
+	}
+	return -1, err
+}
+
 // checkServiceAccount is not implemented for Linux
-func (h *Handler) checkServiceAccount() string {
+func (h *Handler) checkServiceAccount(ctx context.Context) string {
 	return "n/a"
 }
 
 // info returns platform-specific informational items
-func (h *Handler) info() []string {
+func (h *Handler) info(ctx context.Context) []string {
 	return []string{}
 }
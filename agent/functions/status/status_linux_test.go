@@ -6,6 +6,7 @@
 package status
 
 import (
+	"context"
 	"testing"
 )
 
@@ -16,7 +17,7 @@ func TestFDEBasic(t *testing.T) {
 	h := &Handler{}
 
 	// Test that fde() returns a valid response (yes, no, or unknown)
-	result := h.fde()
+	result := h.fde(context.Background())
 
 	validResponses := map[string]bool{
 		"yes":     true,
@@ -32,7 +33,7 @@ func TestFDEBasic(t *testing.T) {
 // TestFDEReturnTypes tests that fde() always returns one of the expected values
 func TestFDEReturnTypes(t *testing.T) {
 	h := &Handler{}
-	result := h.fde()
+	result := h.fde(context.Background())
 
 	if result != "yes" && result != "no" && result != "unknown" {
 		t.Errorf("fde() must return 'yes', 'no', or 'unknown', got: %s", result)
@@ -44,9 +45,9 @@ func TestFDEConsistency(t *testing.T) {
 	h := &Handler{}
 
 	// Call fde() multiple times and ensure consistent results
-	result1 := h.fde()
-	result2 := h.fde()
-	result3 := h.fde()
+	result1 := h.fde(context.Background())
+	result2 := h.fde(context.Background())
+	result3 := h.fde(context.Background())
 
 	if result1 != result2 || result2 != result3 {
 		t.Errorf("fde() returned inconsistent results: %s, %s, %s", result1, result2, result3)
@@ -56,7 +57,7 @@ func TestFDEConsistency(t *testing.T) {
 // TestFDENoEmptyString tests that fde() never returns an empty string
 func TestFDENoEmptyString(t *testing.T) {
 	h := &Handler{}
-	result := h.fde()
+	result := h.fde(context.Background())
 
 	if result == "" {
 		t.Error("fde() returned empty string, expected 'yes', 'no', or 'unknown'")
@@ -80,3 +81,19 @@ func TestFDENoEmptyString(t *testing.T) {
 // - eCryptfs-only system
 // - Unencrypted system (all methods return false)
 // - System where commands fail (permission denied, tools missing)
+
+// TestDefaultGateway tests parsing of the default route from /proc/net/route
+func TestDefaultGateway(t *testing.T) {
+	local := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"eth0\t0001A8C0\t00000000\t0001\t0\t0\t100\t00FFFFFF\t0\t0\t0\n"
+	routes := local + "eth0\t00000000\t0101A8C0\t0003\t0\t0\t100\t00000000\t0\t0\t0\n"
+
+	gateway, err := defaultGateway(routes)
+	if err != nil || gateway != "192.168.1.1" {
+		t.Errorf("expected 192.168.1.1, got %q (%v)", gateway, err)
+	}
+
+	if _, err = defaultGateway(local); err == nil {
+		t.Errorf("expected an error without a default route")
+	}
+}
@@ -0,0 +1,130 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// sleepCollector returns a collector that reports value after sleeping for delay, unless its
+// context expires first
+func sleepCollector(key, value string, delay time.Duration) collector {
+	return stringCollector(key, func(ctx context.Context) string {
+		select {
+		case <-time.After(delay):
+			return value
+		case <-ctx.Done():
+			return "cancelled"
+		}
+	})
+}
+
+// stuckCollector returns a collector that ignores its context, like a command that does not exit
+func stuckCollector(key string, release chan struct{}) collector {
+	return stringCollector(key, func(ctx context.Context) string {
+		<-release
+		return "late"
+	})
+}
+
+// TestRunCollectorsConcurrently tests that collectors run at the same time
+func TestRunCollectorsConcurrently(t *testing.T) {
+	h := &Handler{}
+	collectors := []collector{
+		sleepCollector("a", "1", 200*time.Millisecond),
+		sleepCollector("b", "2", 200*time.Millisecond),
+		sleepCollector("c", "3", 200*time.Millisecond),
+	}
+
+	start := time.Now()
+	data := h.runCollectors(collectors, time.Second, 5*time.Second)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("collectors took %s, expected them to run concurrently", elapsed)
+	}
+
+	for key, want := range map[string]string{"a": "1", "b": "2", "c": "3"} {
+		if data.Details.Get(key) != want {
+			t.Errorf("expected %s=%s, got %q", key, want, data.Details.Get(key))
+		}
+	}
+}
+
+// TestRunCollectorsTimeout tests that a slow collector is reported as unknown without
+// affecting the others
+func TestRunCollectorsTimeout(t *testing.T) {
+	h := &Handler{}
+	release := make(chan struct{})
+	defer close(release)
+
+	collectors := []collector{
+		sleepCollector("fast", "yes", 0),
+		sleepCollector("slow", "yes", 10*time.Second),
+		stuckCollector("stuck", release),
+		{
+			name: "multi",
+			keys: []string{"m1", "m2"},
+			collect: func(ctx context.Context, data *schema.AgentStatusData) {
+				data.Details.Set("m1", "partial")
+				<-ctx.Done()
+			},
+		},
+	}
+
+	start := time.Now()
+	data := h.runCollectors(collectors, 100*time.Millisecond, 5*time.Second)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("collection took %s, expected it to stop after the collector timeout", elapsed)
+	}
+
+	expected := map[string]string{
+		"fast":  "yes",
+		"slow":  "unknown",
+		"stuck": "unknown",
+		"m1":    "unknown",
+		"m2":    "unknown",
+	}
+	if len(data.Details.Map()) != len(expected) {
+		t.Errorf("expected %d details, got %v", len(expected), data.Details.Map())
+	}
+	for key, want := range expected {
+		if data.Details.Get(key) != want {
+			t.Errorf("expected %s=%s, got %q", key, want, data.Details.Get(key))
+		}
+	}
+}
+
+// TestRunCollectorsCeiling tests that the ceiling bounds the whole collection even if the
+// collector timeout is longer
+func TestRunCollectorsCeiling(t *testing.T) {
+	h := &Handler{}
+	collectors := []collector{
+		sleepCollector("a", "yes", 50*time.Millisecond),
+		sleepCollector("b", "yes", 10*time.Second),
+		{
+			name: "info",
+			collect: func(ctx context.Context, data *schema.AgentStatusData) {
+				data.Info = []string{"info"}
+			},
+		},
+	}
+
+	start := time.Now()
+	data := h.runCollectors(collectors, 10*time.Second, 200*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("collection took %s, expected it to stop at the ceiling", elapsed)
+	}
+
+	if data.Details.Get("a") != "yes" || data.Details.Get("b") != "unknown" {
+		t.Errorf("unexpected details: %v", data.Details.Map())
+	}
+	if len(data.Info) != 1 || data.Info[0] != "info" {
+		t.Errorf("unexpected info: %v", data.Info)
+	}
+}
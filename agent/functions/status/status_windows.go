@@ -6,15 +6,19 @@
 package status
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os/exec"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/StackExchange/wmi"
+	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/registry"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
 var screenLockDelayValue string
@@ -23,93 +27,17 @@ func (h *Handler) osName() string {
 	return "Windows"
 }
 
-func (h *Handler) osVersion() string {
-
-	// Try to use PowerShell to get OS information
-	out, err := exec.Command("powershell", "-Command",
-		"Get-CimInstance Win32_OperatingSystem | Select-Object Caption, Version, BuildNumber | ConvertTo-Json").Output()
-	if err == nil {
-		output := strings.TrimSpace(string(out))
-		// Parse the JSON output and extract the version information
-		if strings.Contains(output, "Caption") && strings.Contains(output, "Version") {
-			// Extract caption and version
-			caption := ""
-			version := ""
-
-			if strings.Contains(output, "\"Caption\":") {
-				captionParts := strings.Split(strings.Split(output, "\"Caption\":")[1], "\"")
-				if len(captionParts) > 1 {
-					caption = captionParts[1]
-				}
-			}
-
-			if strings.Contains(output, "\"Version\":") {
-				versionParts := strings.Split(strings.Split(output, "\"Version\":")[1], "\"")
-				if len(versionParts) > 1 {
-					version = versionParts[1]
-				}
-			}
-
-			if caption != "" && version != "" {
-				return caption + " (Version " + version + ")"
-			} else if caption != "" {
-				return caption
-			}
-		}
-	}
-
-	// If that fails, try the registry
-	k, err := registry.OpenKey(registry.LOCAL_MACHINE,
-		`SOFTWARE\Microsoft\Windows NT\CurrentVersion`, registry.QUERY_VALUE)
-	if err == nil {
-		defer func(k registry.Key) {
-			_ = k.Close()
-		}(k)
-
-		productName, _, _ := k.GetStringValue("ProductName")
-		displayVersion, _, _ := k.GetStringValue("DisplayVersion")
-
-		if productName != "" {
-			if displayVersion != "" {
-				return productName + " " + displayVersion
-			}
-			return productName
-		}
-	}
-
-	// If that files, try using the ver command as last resort
-	out, err = exec.Command("cmd", "/c", "ver").Output()
-	if err == nil {
-		return strings.TrimSpace(string(out))
-	}
-
-	return "unknown"
+// osVersion returns the Windows edition and version
+func (h *Handler) osVersion(ctx context.Context) string {
+	return windowsOSVersion(winSystem{h})
 }
 
-func (h *Handler) firewall() string {
-	out, err := exec.Command("netsh", "advfirewall", "show", "allprofiles").Output()
-	if err != nil {
-		return "unknown"
-	}
-	output := strings.ToLower(string(out))
-	lines := strings.Split(output, "\n")
-	stateOnCount := 0
-	for _, line := range lines {
-		if strings.Contains(line, "state") {
-			state := strings.Fields(line)
-			if len(state) > 1 && state[1] == "on" {
-				stateOnCount++
-			}
-		}
-	}
-
-	if stateOnCount > 0 {
-		return "yes"
-	}
-	return "no"
+// firewall returns "yes" if Windows Firewall is enabled for any profile
+func (h *Handler) firewall(ctx context.Context) string {
+	return windowsFirewall(winSystem{h})
 }
 
-func (h *Handler) antivirus() string {
+func (h *Handler) antivirus(ctx context.Context) string {
 
 	for _, keyPath := range windowsAntivirusKeys {
 		k, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
@@ -122,7 +50,30 @@ func (h *Handler) antivirus() string {
 	return "no"
 }
 
-func (h *Handler) autoUpdates() string {
+// antivirusProducts returns the antivirus products registered with Windows Security Center,
+// which is not available on Windows Server
+func (h *Handler) antivirusProducts(ctx context.Context) []schema.AntivirusProduct {
+	var registered []struct {
+		DisplayName  string
+		ProductState uint32
+	}
+	err := wmi.QueryNamespace("SELECT displayName, productState FROM AntiVirusProduct", &registered, `root\SecurityCenter2`)
+	if err != nil {
+		if h.logger != nil {
+			h.logger.Debugf(2719, "unable to query Security Center: %s", err.Error())
+		}
+		return nil
+	}
+
+	products := make([]schema.AntivirusProduct, 0, len(registered))
+	for _, r := range registered {
+		enabled, updated := decodeProductState(r.ProductState)
+		products = append(products, schema.AntivirusProduct{Name: r.DisplayName, Enabled: enabled, Updated: updated})
+	}
+	return products
+}
+
+func (h *Handler) autoUpdates(ctx context.Context) string {
 	noAutoUpdate, err := h.registryGetInt(registry.LOCAL_MACHINE, "SOFTWARE\\Policies\\Microsoft\\Windows\\WindowsUpdate\\AU", "NoAutoUpdate")
 	if err != nil {
 		if errors.Is(err, registry.ErrNotExist) {
@@ -136,22 +87,12 @@ func (h *Handler) autoUpdates() string {
 	return "yes"
 }
 
-func (h *Handler) fde() string {
-	out, err := exec.Command("powershell", "Get-BitLockerVolume", "|", "Select-Object", "-ExpandProperty", "VolumeStatus").Output()
-	if err != nil {
-		return "unknown"
-	}
-	output := strings.TrimSpace(string(out))
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		if !strings.Contains(line, "FullyEncrypted") && !strings.Contains(line, "EncryptionInProgress") {
-			return "no"
-		}
-	}
-	return "yes"
+// fde returns "yes" if every volume that BitLocker can encrypt is encrypted
+func (h *Handler) fde(ctx context.Context) string {
+	return windowsFDE(winSystem{h})
 }
 
-func (h *Handler) password() string {
+func (h *Handler) password(ctx context.Context) string {
 
 	// Check AutoAdminLogon
 	autoAdminLogon, err := h.registryGetString(registry.LOCAL_MACHINE, "SOFTWARE\\Microsoft\\Windows NT\\CurrentVersion\\Winlogon", "AutoAdminLogon")
@@ -185,9 +126,16 @@ func (h *Handler) password() string {
 	return "yes"
 }
 
-func (h *Handler) screenLock() (string, error) {
+func (h *Handler) screenLock(ctx context.Context) (string, error) {
 	screenLockDelayValue = "0"
 
+	// The user-helper reads the settings from the user's own session
+	if userData, exists := h.consoleUserData(); exists {
+		h.logger.Debugf(2715, "Using screen lock data from console user helper: %s", userData.ScreenLock)
+		screenLockDelayValue = userData.ScreenLockDelay
+		return userData.ScreenLock, nil
+	}
+
 	screenSaverSecure, screenSaverTimeout, err := h.screenSaver()
 	if err != nil {
 		return "unknown", fmt.Errorf("error checking screen saver setting: %w", err)
@@ -235,7 +183,7 @@ func (h *Handler) screenLock() (string, error) {
 	return "no", nil
 }
 
-func (h *Handler) screenLockDelay() string {
+func (h *Handler) screenLockDelay(ctx context.Context) string {
 	// On Windows it is easiest to get this at the same time as screenLock() so it saves it
 	return screenLockDelayValue
 }
@@ -284,30 +232,12 @@ func (h *Handler) screenSaver() (bool, uint32, error) {
 	return secure, timeout, nil
 }
 
-func (h *Handler) lastUser() string {
-
-	// Check the currently logged-in user
-	out, err := exec.Command("query", "user").Output()
-	if err == nil {
-		output := strings.TrimSpace(string(out))
-		lines := strings.Split(output, "\n")
-		if len(lines) > 1 {
-			fields := strings.Fields(lines[1])
-			if len(fields) > 0 {
-				return fields[0]
-			}
-		}
-	}
-
-	// Check the last logged-in user from the registry
-	val, err := h.registryGetString(registry.LOCAL_MACHINE, "SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Authentication\\LogonUI", "LastLoggedOnUser")
-	if err != nil {
-		return "unknown"
-	}
-	return val
+// lastUser returns the user logged in at the console, or the last user to log in
+func (h *Handler) lastUser(ctx context.Context) string {
+	return windowsLastUser(winSystem{h})
 }
 
-func (h *Handler) bootTime() string {
+func (h *Handler) bootTime(ctx context.Context) string {
 	// Get system uptime
 	kernel32 := syscall.NewLazyDLL("kernel32.dll")
 	getTickCount64 := kernel32.NewProc("GetTickCount64")
@@ -418,11 +348,82 @@ func (h *Handler) getUserRegistryKey() (registry.Key, error) {
 }
 
 // checkServiceAccount is not implemented for Windows
-func (h *Handler) checkServiceAccount() string {
+func (h *Handler) checkServiceAccount(ctx context.Context) string {
 	return "n/a"
 }
 
 // info returns platform-specific informational items
-func (h *Handler) info() []string {
+func (h *Handler) info(ctx context.Context) []string {
 	return []string{}
 }
+
+// winSystem reads the system information used by the Windows collectors
+type winSystem struct {
+	h *Handler
+}
+
+func (w winSystem) operatingSystem() (string, string, error) {
+	var systems []struct {
+		Caption string
+		Version string
+	}
+	if err := wmi.Query("SELECT Caption, Version FROM Win32_OperatingSystem", &systems); err != nil {
+		return "", "", err
+	}
+	if len(systems) == 0 {
+		return "", "", errors.New("no operating system returned")
+	}
+	return strings.TrimSpace(systems[0].Caption), systems[0].Version, nil
+}
+
+func (w winSystem) registryString(path, name string) (string, error) {
+	return w.h.registryGetString(registry.LOCAL_MACHINE, path, name)
+}
+
+func (w winSystem) registryInt(path, name string) (uint64, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE)
+	if err != nil {
+		return 0, err
+	}
+	defer func(k registry.Key) {
+		_ = k.Close()
+	}(k)
+
+	val, _, err := k.GetIntegerValue(name)
+	return val, err
+}
+
+func (w winSystem) kernelVersion() (uint32, uint32, uint32) {
+	v := windows.RtlGetVersion()
+	return v.MajorVersion, v.MinorVersion, v.BuildNumber
+}
+
+func (w winSystem) volumeConversionStatus() ([]uint32, error) {
+	var volumes []struct {
+		ConversionStatus uint32
+	}
+	err := wmi.QueryNamespace("SELECT ConversionStatus FROM Win32_EncryptableVolume", &volumes,
+		`root\CIMV2\Security\MicrosoftVolumeEncryption`)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]uint32, 0, len(volumes))
+	for _, v := range volumes {
+		status = append(status, v.ConversionStatus)
+	}
+	return status, nil
+}
+
+func (w winSystem) consoleUser() (string, error) {
+	var cs []struct {
+		UserName *string
+	}
+	if err := wmi.Query("SELECT UserName FROM Win32_ComputerSystem", &cs); err != nil {
+		return "", err
+	}
+	if len(cs) == 0 || cs[0].UserName == nil {
+		return "", nil
+	}
+	return *cs[0].UserName, nil
+}
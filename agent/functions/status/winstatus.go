@@ -0,0 +1,146 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The Windows collectors read the system through windowsProvider rather than running commands
+// such as wmic, netsh, and query, which are not present on every version of Windows. The logic
+// is in this file, without a build constraint, so that it can be tested on any platform.
+
+// windowsProvider reads the information used by the Windows collectors
+type windowsProvider interface {
+	// operatingSystem returns the caption and version from WMI Win32_OperatingSystem
+	operatingSystem() (caption string, version string, err error)
+
+	// registryString returns a string value under HKEY_LOCAL_MACHINE
+	registryString(path, name string) (string, error)
+
+	// registryInt returns an integer value under HKEY_LOCAL_MACHINE
+	registryInt(path, name string) (uint64, error)
+
+	// kernelVersion returns the version reported by RtlGetVersion
+	kernelVersion() (major, minor, build uint32)
+
+	// volumeConversionStatus returns the BitLocker conversion status of each encryptable volume
+	volumeConversionStatus() ([]uint32, error)
+
+	// consoleUser returns the user logged in at the console as DOMAIN\user, or "" if none
+	consoleUser() (string, error)
+}
+
+const (
+	winCurrentVersion  = `SOFTWARE\Microsoft\Windows NT\CurrentVersion`
+	winFirewallPolicy  = `SOFTWARE\Policies\Microsoft\WindowsFirewall`
+	winFirewallProfile = `SYSTEM\CurrentControlSet\Services\SharedAccess\Parameters\FirewallPolicy`
+	winLogonUI         = `SOFTWARE\Microsoft\Windows\CurrentVersion\Authentication\LogonUI`
+)
+
+// Windows Firewall profiles, as named in the policy and in the service's parameters
+var winFirewallProfiles = []struct {
+	policy  string
+	profile string
+}{
+	{"DomainProfile", "DomainProfile"},
+	{"PrivateProfile", "StandardProfile"},
+	{"PublicProfile", "PublicProfile"},
+}
+
+// BitLocker conversion states of Win32_EncryptableVolume
+const (
+	bitLockerFullyEncrypted       = 1
+	bitLockerEncryptionInProgress = 2
+)
+
+// windowsOSVersion returns the Windows edition and version in the form
+// "Microsoft Windows 11 Pro (Version 10.0.26100)", or from the registry or kernel if WMI fails
+func windowsOSVersion(p windowsProvider) string {
+	caption, version, err := p.operatingSystem()
+	if err == nil && caption != "" {
+		if version != "" {
+			return caption + " (Version " + version + ")"
+		}
+		return caption
+	}
+
+	productName, _ := p.registryString(winCurrentVersion, "ProductName")
+	if productName != "" {
+		displayVersion, _ := p.registryString(winCurrentVersion, "DisplayVersion")
+		if displayVersion != "" {
+			return productName + " " + displayVersion
+		}
+		return productName
+	}
+
+	// The same format as the ver command
+	major, minor, build := p.kernelVersion()
+	if major == 0 {
+		return "unknown"
+	}
+	return fmt.Sprintf("Microsoft Windows [Version %d.%d.%d]", major, minor, build)
+}
+
+// windowsFirewall returns "yes" if the firewall is enabled for any profile, "no" if it is
+// disabled for all of them, and "unknown" if the state could not be read. Group policy takes
+// precedence over the local setting.
+func windowsFirewall(p windowsProvider) string {
+	known := false
+	for _, profile := range winFirewallProfiles {
+		enabled, err := p.registryInt(winFirewallPolicy+`\`+profile.policy, "EnableFirewall")
+		if err != nil {
+			enabled, err = p.registryInt(winFirewallProfile+`\`+profile.profile, "EnableFirewall")
+		}
+		if err != nil {
+			continue
+		}
+		if enabled != 0 {
+			return "yes"
+		}
+		known = true
+	}
+
+	if known {
+		return "no"
+	}
+	return "unknown"
+}
+
+// windowsFDE returns "yes" if every encryptable volume is encrypted or being encrypted,
+// "no" if any is not, and "unknown" if BitLocker could not be queried
+func windowsFDE(p windowsProvider) string {
+	volumes, err := p.volumeConversionStatus()
+	if err != nil || len(volumes) == 0 {
+		return "unknown"
+	}
+
+	for _, status := range volumes {
+		if status != bitLockerFullyEncrypted && status != bitLockerEncryptionInProgress {
+			return "no"
+		}
+	}
+	return "yes"
+}
+
+// windowsLastUser returns the user logged in at the console, without the domain, or the last
+// user to log in if there is none
+func windowsLastUser(p windowsProvider) string {
+	user, err := p.consoleUser()
+	if err == nil && user != "" {
+		if _, name, found := strings.Cut(user, `\`); found {
+			return name
+		}
+		return user
+	}
+
+	val, err := p.registryString(winLogonUI, "LastLoggedOnUser")
+	if err != nil {
+		return "unknown"
+	}
+	return val
+}
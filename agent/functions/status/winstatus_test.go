@@ -0,0 +1,153 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package status
+
+import (
+	"errors"
+	"testing"
+)
+
+var errFake = errors.New("fake error")
+
+// fakeWindows is a windowsProvider that returns fixed values
+type fakeWindows struct {
+	caption, version string
+	osErr            error
+	strings          map[string]string
+	ints             map[string]uint64
+	major, minor     uint32
+	build            uint32
+	volumes          []uint32
+	volumesErr       error
+	user             string
+	userErr          error
+}
+
+func (f *fakeWindows) operatingSystem() (string, string, error) {
+	return f.caption, f.version, f.osErr
+}
+
+func (f *fakeWindows) registryString(path, name string) (string, error) {
+	if v, ok := f.strings[path+`\`+name]; ok {
+		return v, nil
+	}
+	return "", errFake
+}
+
+func (f *fakeWindows) registryInt(path, name string) (uint64, error) {
+	if v, ok := f.ints[path+`\`+name]; ok {
+		return v, nil
+	}
+	return 0, errFake
+}
+
+func (f *fakeWindows) kernelVersion() (uint32, uint32, uint32) {
+	return f.major, f.minor, f.build
+}
+
+func (f *fakeWindows) volumeConversionStatus() ([]uint32, error) {
+	return f.volumes, f.volumesErr
+}
+
+func (f *fakeWindows) consoleUser() (string, error) {
+	return f.user, f.userErr
+}
+
+func TestWindowsOSVersion(t *testing.T) {
+	registry := map[string]string{
+		winCurrentVersion + `\ProductName`:    "Windows 10 Pro",
+		winCurrentVersion + `\DisplayVersion`: "24H2",
+	}
+
+	tests := []struct {
+		name     string
+		p        *fakeWindows
+		expected string
+	}{
+		{"wmi", &fakeWindows{caption: "Microsoft Windows 11 Pro", version: "10.0.26100", strings: registry},
+			"Microsoft Windows 11 Pro (Version 10.0.26100)"},
+		{"wmi caption only", &fakeWindows{caption: "Microsoft Windows 11 Pro"}, "Microsoft Windows 11 Pro"},
+		{"registry", &fakeWindows{osErr: errFake, strings: registry}, "Windows 10 Pro 24H2"},
+		{"registry product only", &fakeWindows{osErr: errFake, strings: map[string]string{
+			winCurrentVersion + `\ProductName`: "Windows Server 2019 Standard"}}, "Windows Server 2019 Standard"},
+		{"kernel", &fakeWindows{osErr: errFake, major: 10, minor: 0, build: 26100},
+			"Microsoft Windows [Version 10.0.26100]"},
+		{"none", &fakeWindows{osErr: errFake}, "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := windowsOSVersion(tt.p); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestWindowsFirewall(t *testing.T) {
+	local := func(profile string) string { return winFirewallProfile + `\` + profile + `\EnableFirewall` }
+	policy := func(profile string) string { return winFirewallPolicy + `\` + profile + `\EnableFirewall` }
+
+	tests := []struct {
+		name     string
+		ints     map[string]uint64
+		expected string
+	}{
+		{"all enabled", map[string]uint64{local("DomainProfile"): 1, local("StandardProfile"): 1, local("PublicProfile"): 1}, "yes"},
+		{"one enabled", map[string]uint64{local("DomainProfile"): 0, local("StandardProfile"): 0, local("PublicProfile"): 1}, "yes"},
+		{"all disabled", map[string]uint64{local("DomainProfile"): 0, local("StandardProfile"): 0, local("PublicProfile"): 0}, "no"},
+		{"policy disables", map[string]uint64{local("PublicProfile"): 1, policy("PublicProfile"): 0}, "no"},
+		{"policy enables", map[string]uint64{local("StandardProfile"): 0, policy("PrivateProfile"): 1}, "yes"},
+		{"unreadable", nil, "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := windowsFirewall(&fakeWindows{ints: tt.ints}); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestWindowsFDE(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        *fakeWindows
+		expected string
+	}{
+		{"encrypted", &fakeWindows{volumes: []uint32{1, 1}}, "yes"},
+		{"in progress", &fakeWindows{volumes: []uint32{1, 2}}, "yes"},
+		{"one decrypted", &fakeWindows{volumes: []uint32{1, 0}}, "no"},
+		{"paused", &fakeWindows{volumes: []uint32{4}}, "no"},
+		{"no volumes", &fakeWindows{}, "unknown"},
+		{"error", &fakeWindows{volumesErr: errFake}, "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := windowsFDE(tt.p); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestWindowsLastUser(t *testing.T) {
+	lastUser := map[string]string{winLogonUI + `\LastLoggedOnUser`: `.\alice`}
+
+	tests := []struct {
+		name     string
+		p        *fakeWindows
+		expected string
+	}{
+		{"console user", &fakeWindows{user: `CORP\bob`, strings: lastUser}, "bob"},
+		{"console user without domain", &fakeWindows{user: "bob"}, "bob"},
+		{"last user", &fakeWindows{strings: lastUser}, `.\alice`},
+		{"error", &fakeWindows{userErr: errFake, strings: lastUser}, `.\alice`},
+		{"none", &fakeWindows{}, "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := windowsLastUser(tt.p); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
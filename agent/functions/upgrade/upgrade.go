@@ -17,9 +17,11 @@ import (
 	"github.com/UnifyEM/UnifyEM/agent/global"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/semver"
 )
 
-// Upgrade downloads the latest agent for the current OS and architecture from the server and installs it
+// Upgrade downloads the latest agent for the current OS and architecture from the server and installs it.
+// The entry for this platform is selected from the deploy information, which provides the file name and hash.
 
 type Handler struct {
 	config *global.AgentConfig
@@ -54,10 +56,11 @@ func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error)
 		}
 	}
 
-	// Determine our operating system and architecture for the download URL
-	requestFile := fmt.Sprintf("uem-agent-%s-%s", runtime.GOOS, runtime.GOARCH)
-	if runtime.GOOS == "windows" {
-		requestFile += ".exe"
+	// The server includes the channel if one is assigned to this agent
+	channel := request.Parameters["channel"]
+	if !schema.ValidChannel(channel) {
+		response.Response = fmt.Sprintf("invalid channel: %s", channel)
+		return response, errors.New(response.Response)
 	}
 
 	// Get the URL
@@ -68,37 +71,56 @@ func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error)
 	}
 
 	// Download the information file
-	url := strings.ToLower(fmt.Sprintf("%s%s/%s", serverURL, schema.EndpointFiles, schema.DeployInfoFile))
+	url := strings.ToLower(fmt.Sprintf("%s%s/%s", serverURL, schema.EndpointFiles, schema.DeployInfoFileName(channel)))
 	infoFile, err := common.Download(h.logger, h.comms, url, hash)
 	if err != nil {
 		response.Response = fmt.Sprintf("error downloading %s: %s", url, err.Error())
 		return response, err
 	}
 
-	// Read the JSON file
-	upgradeInfo, err := common.FileToMap(infoFile)
+	// Read the deploy information, which may be a manifest or a map of file names to hashes
+	data, err := os.ReadFile(infoFile)
+	_ = os.Remove(infoFile)
 	if err != nil {
 		response.Response = fmt.Sprintf("error reading %s: %s", infoFile, err.Error())
 		return response, err
 	}
+	manifest, err := schema.ParseDeployInfo(data)
+	if err != nil {
+		response.Response = err.Error()
+		return response, err
+	}
 
-	// Delete the file
-	_ = os.Remove(infoFile)
-
-	// Get the hash for our desired upgrade
-	hash, ok = upgradeInfo[requestFile]
+	// Select the entry for our operating system and architecture
+	entry, ok := manifest.Entry(runtime.GOOS, runtime.GOARCH)
 	if !ok {
-		if global.DisableHash {
-			hash = ""
-		} else {
-			response.Response = fmt.Sprintf("hash for %s not found in %s", requestFile, infoFile)
-			return response, errors.New(response.Response)
+		response.Response = fmt.Sprintf("no agent for %s/%s in %s", runtime.GOOS, runtime.GOARCH, schema.DeployInfoFileName(channel))
+		return response, errors.New(response.Response)
+	}
+	if err = entry.Validate(); err != nil {
+		response.Response = err.Error()
+		return response, err
+	}
+
+	// Agents older than the minimum supported version must upgrade to an intermediate version first
+	if entry.MinVersion != "" {
+		if err = checkMinVersion(entry.MinVersion); err != nil {
+			response.Response = err.Error()
+			return response, err
 		}
 	}
 
-	url = strings.ToLower(fmt.Sprintf("%s%s/%s", serverURL, schema.EndpointFiles, requestFile))
-	var args = []string{"upgrade"}
-	err = common.DownloadExecute(h.logger, h.comms, url, args, hash)
+	hash = entry.SHA256
+	if hash == "" && !global.DisableHash {
+		response.Response = fmt.Sprintf("hash for %s not found in %s", entry.File, schema.DeployInfoFileName(channel))
+		return response, errors.New(response.Response)
+	}
+	requestFile := entry.File
+
+	url = strings.ToLower(fmt.Sprintf("%s%s/%s", serverURL, schema.EndpointFiles, schema.ChannelFilePath(channel, requestFile)))
+	// Pass the request ID so that the result of the upgrade can be reported when the agent restarts
+	var args = []string{"upgrade", request.RequestID}
+	_, err = common.DownloadExecute(h.logger, h.comms, url, args, common.Payload{Hash: hash})
 	if err != nil {
 		response.Response = fmt.Sprintf("error downloading and executing %s: %s", url, err.Error())
 		return response, err
@@ -106,6 +128,26 @@ func (h *Handler) Cmd(request schema.AgentRequest) (schema.AgentResponse, error)
 
 	// Update and return response
 	response.Response = "Successfully downloaded and executed " + url
+	if entry.Version != "" {
+		response.Response += " version " + entry.Version
+	}
 	response.Success = true
 	return response, nil
 }
+
+// checkMinVersion returns an error if this agent is older than the minimum version that can
+// upgrade to a deploy entry
+func checkMinVersion(minimum string) error {
+	minVersion, err := semver.Parse(minimum)
+	if err != nil {
+		return fmt.Errorf("invalid minimum version %s: %w", minimum, err)
+	}
+	agentVersion, err := semver.New(global.Version, global.Build)
+	if err != nil {
+		return fmt.Errorf("unable to parse agent version: %w", err)
+	}
+	if agentVersion.Less(minVersion) {
+		return fmt.Errorf("agent version %s is older than the minimum version %s required to upgrade", agentVersion, minVersion)
+	}
+	return nil
+}
@@ -46,6 +46,7 @@ func WriteBackup(conf *AgentConfig) error {
 			ECPublicSig:     conf.AP.Get(ConfigAgentECPublicSig).String(),
 			ECPrivateEnc:    conf.AP.Get(ConfigAgentECPrivateEnc).String(),
 			ECPublicEnc:     conf.AP.Get(ConfigAgentECPublicEnc).String(),
+			TLSPin:          conf.AP.Get(ConfigTLSPin).String(),
 		},
 	}
 
@@ -104,6 +105,25 @@ func ReadBackup() (*schema.BackupConfig, error) {
 	return nil, lastErr
 }
 
+// DeleteBackup removes all backup files in UnixBackupFiles. Files that do not
+// exist are ignored. It is a no-op on Windows.
+func DeleteBackup() error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	var lastErr error
+	for _, path := range UnixBackupFiles {
+		for _, name := range []string{path, path + ".tmp", path + ".bak"} {
+			err := os.Remove(name)
+			if err != nil && !os.IsNotExist(err) {
+				lastErr = err
+			}
+		}
+	}
+	return lastErr
+}
+
 // RestoreFromBackup copies non-empty fields from backup.Agent into conf.AP.
 // Returns true if the agent identity (AgentID) was successfully restored.
 // Returns false if backup is nil, backup.Agent is nil, or AgentID is empty.
@@ -140,6 +160,9 @@ func RestoreFromBackup(conf *AgentConfig, backup *schema.BackupConfig) bool {
 	if a.ECPublicEnc != "" {
 		conf.AP.Set(ConfigAgentECPublicEnc, a.ECPublicEnc)
 	}
+	if a.TLSPin != "" {
+		conf.AP.Set(ConfigTLSPin, a.TLSPin)
+	}
 
 	return true
 }
@@ -11,9 +11,11 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/UnifyEM/UnifyEM/common/crypto"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/uconfig"
 )
 
@@ -25,6 +27,11 @@ type AgentConfig struct {
 	// Non-exported fields for encrypted service credentials
 	serviceCredentialsEncrypted string // Encrypted "username:password" with agent's public key
 	credentialsPendingSend      bool   // True if credentials updated but not sent to server
+
+	// Non-exported fields for sealing protected settings, see integrity.go
+	sealMu  sync.Mutex
+	sealKey []byte // nil if the key can't be stored, in which case nothing is sealed
+	tamper  string // changes to protected settings found when the configuration was loaded
 }
 
 // Config loads the configuration from the registry or file system
@@ -47,6 +54,9 @@ func Config() (*AgentConfig, error) {
 	// Set constraints, including default values
 	c.AC, c.AP = setDefaults(c.C)
 
+	// Check for changes to protected settings before anything else changes them
+	c.checkSeal()
+
 	// If agent identity is missing, attempt recovery from backup
 	if c.AP.Get(ConfigAgentID).String() == "" {
 		if backup, backupErr := ReadBackup(); backupErr != nil {
@@ -107,7 +117,25 @@ func Config() (*AgentConfig, error) {
 	return c, err
 }
 
+// ReadAgentSettings reads the agent settings from the configuration file or registry without
+// changing the configuration in use, so that they can be applied when the service is reloaded.
+// Protected settings are maintained by the agent and are not read.
+func ReadAgentSettings() (map[string]string, error) {
+	var c interfaces.Config
+	var err error
+	if runtime.GOOS == "windows" {
+		c, err = uconfig.New(uconfig.WithWindowsRegistry(Name))
+	} else {
+		c, err = uconfig.New(uconfig.WithFind(UnixConfigFiles))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return schema.SetAgentDefaults(c).GetMap(), nil
+}
+
 func (c *AgentConfig) Checkpoint() error {
+	c.seal()
 	err := c.C.Checkpoint()
 	if err == nil {
 		_ = WriteBackup(c)
@@ -16,6 +16,7 @@ import (
 const (
 	ConfigPrivate               = "client_private"
 	ConfigRegToken              = "reg_token"
+	ConfigRegDeferred           = "reg_deferred"
 	ConfigLost                  = "config_lost"
 	ConfigAgentLogFile          = "log_file"
 	ConfigAgentDataDir          = "data_dir"
@@ -23,6 +24,8 @@ const (
 	ConfigServerURL             = "server_url"
 	ConfigRefreshToken          = "refresh_token"
 	ConfigCAHash                = "ca_hash"
+	ConfigTLSPin                = "tls_pin"
+	ConfigTLSPinNext            = "tls_pin_next"
 	ConfigServerPublicSig       = "server_public_sig"
 	ConfigServerPublicEnc       = "server_public_enc"
 	ConfigAgentECPrivateSig     = "ec_private_sig"
@@ -33,6 +36,20 @@ const (
 	ConfigRecoveryPublicKeyHash = "recovery_public_key_hash"
 	ConfigRecoveryInfoPending   = "recovery_info_pending"
 	ConfigFriendlyName          = "install_friendly_name"
+	ConfigUpgradeRequestID      = "upgrade_request_id"
+	ConfigUpgradeSuccess        = "upgrade_success"
+	ConfigUpgradeResult         = "upgrade_result"
+	ConfigLastSync              = "last_sync"
+	ConfigTriggersTime          = "triggers_time"
+	ConfigLastSyncRequests      = "last_sync_requests"
+	ConfigLastSyncResponses     = "last_sync_responses"
+	ConfigAgentProxyURL         = "proxy_url"
+	ConfigAgentProxyUser        = "proxy_user"
+	ConfigAgentProxyPassword    = "proxy_password"
+	ConfigAgentProxyUseSystem   = "proxy_use_system"
+	ConfigPolicyDisabled        = "policy_disabled_commands"
+	ConfigPolicyExecPaths       = "policy_exec_allowed_paths"
+	ConfigSeal                  = "config_seal"
 )
 
 // setDefaults makes sure the sets exist, sets default values, and constraints
@@ -43,12 +60,15 @@ func setDefaults(c interfaces.Config) (interfaces.Parameters, interfaces.Paramet
 
 	ap := c.NewSet(ConfigPrivate)
 	ap.SetConstraint(ConfigRegToken, 0, 0, "")
+	ap.SetConstraint(ConfigRegDeferred, 0, 0, false) // register with reg_token when the server is reachable, set by install --defer-registration
 	ap.SetConstraint(ConfigLost, 0, 0, false)
 	ap.SetConstraint(ConfigAgentLogFile, 0, 0, "")
 	ap.SetConstraint(ConfigAgentDataDir, 0, 0, "")
 	ap.SetConstraint(ConfigAgentID, 0, 0, "")
 	ap.SetConstraint(ConfigServerURL, 0, 0, "")
 	ap.SetConstraint(ConfigCAHash, 0, 0, "")
+	ap.SetConstraint(ConfigTLSPin, 0, 0, "")
+	ap.SetConstraint(ConfigTLSPinNext, 0, 0, "")
 	ap.SetConstraint(ConfigServerPublicSig, 0, 0, "")
 	ap.SetConstraint(ConfigServerPublicEnc, 0, 0, "")
 	ap.SetConstraint(ConfigAgentECPrivateSig, 0, 0, "")
@@ -59,6 +79,20 @@ func setDefaults(c interfaces.Config) (interfaces.Parameters, interfaces.Paramet
 	ap.SetConstraint(ConfigRecoveryPublicKeyHash, 0, 0, "")
 	ap.SetConstraint(ConfigRecoveryInfoPending, 0, 0, false)
 	ap.SetConstraint(ConfigFriendlyName, 0, 0, "")
+	ap.SetConstraint(ConfigUpgradeRequestID, 0, 0, "")
+	ap.SetConstraint(ConfigUpgradeSuccess, 0, 0, false)
+	ap.SetConstraint(ConfigUpgradeResult, 0, 0, "")
+	ap.SetConstraint(ConfigLastSync, 0, 0, 0)
+	ap.SetConstraint(ConfigTriggersTime, 0, 0, 0) // server time of the last accepted triggers, earlier signatures are rejected
+	ap.SetConstraint(ConfigLastSyncRequests, 0, 0, 0)
+	ap.SetConstraint(ConfigLastSyncResponses, 0, 0, 0)
+	ap.SetConstraint(ConfigAgentProxyURL, 0, 0, "")         // proxy for connections to the server, e.g. http://proxy.example.com:3128
+	ap.SetConstraint(ConfigAgentProxyUser, 0, 0, "")        // basic authentication for the proxy (optional)
+	ap.SetConstraint(ConfigAgentProxyPassword, 0, 0, "")    // basic authentication for the proxy (optional)
+	ap.SetConstraint(ConfigAgentProxyUseSystem, 0, 0, true) // without a proxy URL, use the environment and OS proxy settings
+	ap.SetConstraint(ConfigPolicyDisabled, 0, 0, "")        // commands refused in addition to disabled_commands, set by the policy command
+	ap.SetConstraint(ConfigPolicyExecPaths, 0, 0, "")       // paths that also restrict exec_allowed_paths, set by the policy command
+	ap.SetConstraint(ConfigSeal, 0, 0, "")                  // HMAC of each protected setting, see integrity.go
 
 	// Return the sets
 	return ac, ap
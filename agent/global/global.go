@@ -7,6 +7,8 @@
 package global
 
 import (
+	"sync"
+
 	"github.com/UnifyEM/UnifyEM/common"
 )
 
@@ -24,9 +26,18 @@ const (
 	TaskQueueSize             = 100 // maximum number of tasks to queue
 	UserHelperFlag            = "--user-helper"
 	CollectionIntervalFlag    = "--collection-interval"
-	DefaultCollectionInterval = 300 // 5 minutes in seconds
-	SocketPath                = "/var/run/uem-agent.sock"
-	SocketPerms               = 0666 // Allow user processes to connect
+	DefaultCollectionInterval = 300     // 5 minutes in seconds
+	UserRequestPollInterval   = 15      // seconds between user-helper checks for pending requests
+	UserRequestTimeout        = 60      // seconds to wait for the user-helper to complete a request
+	StatusCollectorTimeout    = 30      // seconds before a status item that has not been collected is reported as "unknown"
+	PatchInstallTimeout       = 14400   // seconds before an update installation is cancelled
+	ShellPollInterval         = 250     // milliseconds between remote shell relays when there is no output
+	ShellRelayTimeout         = 60      // seconds a remote shell continues without reaching the server
+	ClockSkewThreshold        = 120     // seconds the local clock may differ from the server's before clock skew is reported
+	AuthRetryBackoff          = 30      // seconds before retrying after repeated authentication failures, doubled for each failure
+	AuthRetryBackoffMax       = 900     // maximum seconds between authentication retries
+	MaxUserDataSize           = 262144  // bytes accepted from a user-helper in one message
+	MaxCommandOutput          = 4194304 // bytes of output captured from each stream of a command
 )
 
 // These constants are intended for development purposes only and disable important security features.
@@ -48,3 +59,26 @@ var (
 	Debug                   = true
 	Lost                    = false
 )
+
+// startReason is reported in the first status after the service starts
+var (
+	startReason   string
+	startReasonMu sync.Mutex
+)
+
+// SetStartReason records how the service was started
+func SetStartReason(reason string) {
+	startReasonMu.Lock()
+	defer startReasonMu.Unlock()
+	startReason = reason
+}
+
+// TakeStartReason returns the reason recorded by SetStartReason and clears it, so that it is only
+// reported once
+func TakeStartReason() string {
+	startReasonMu.Lock()
+	defer startReasonMu.Unlock()
+	reason := startReason
+	startReason = ""
+	return reason
+}
@@ -0,0 +1,150 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package global
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+)
+
+// Protected settings are sealed with an HMAC of each value whenever the configuration is saved.
+// The HMAC key is stored outside the configuration, in the keychain on macOS, protected with
+// DPAPI on Windows, and in a file readable only by root on Linux. Changes made by editing the
+// configuration, including deleting a setting, are detected when the agent starts and reported
+// to the server. Root can still defeat this, but it can't be done by editing the configuration.
+
+// protectedKeys lists the settings that only the agent may change
+var protectedKeys = []string{
+	ConfigAgentID,
+	ConfigServerURL,
+	ConfigLost,
+	ConfigServerPublicSig,
+	ConfigServerPublicEnc,
+	ConfigTLSPin,
+	ConfigCAHash,
+	ConfigPolicyDisabled,
+	ConfigPolicyExecPaths,
+}
+
+// sealKeySize is the size of the HMAC key in bytes
+const sealKeySize = 32
+
+// checkSeal compares the protected settings with the seal and records any differences as a
+// tamper alert. It must run before anything changes the loaded configuration.
+func (c *AgentConfig) checkSeal() {
+	seal := c.AP.Get(ConfigSeal).String()
+
+	key, err := loadSealKey()
+	if errors.Is(err, os.ErrNotExist) {
+		// Generate a key when the agent is first installed or upgraded from a version without one
+		key = make([]byte, sealKeySize)
+		if _, err = rand.Read(key); err == nil {
+			err = storeSealKey(key)
+		}
+		if err == nil && seal != "" {
+			c.tamper = "configuration seal key is missing"
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: protected settings can not be sealed: %v\n", err)
+		return
+	}
+	c.sealKey = key
+
+	if c.tamper != "" {
+		return
+	}
+
+	if seal == "" {
+		// An unsealed configuration is only expected before the agent registers
+		if c.AP.Get(ConfigAgentID).String() != "" {
+			c.tamper = "configuration seal is missing"
+		}
+		return
+	}
+
+	if changed := unsealedKeys(key, seal, c.AP); len(changed) > 0 {
+		c.tamper = "protected settings changed outside the agent: " + strings.Join(changed, ", ")
+	}
+}
+
+// seal stores the HMAC of each protected setting. Once tampering is detected, the seal is left
+// as it is until the tamper alert has been sent to the server.
+func (c *AgentConfig) seal() {
+	c.sealMu.Lock()
+	defer c.sealMu.Unlock()
+
+	if c.sealKey == nil || c.tamper != "" {
+		return
+	}
+	c.AP.Set(ConfigSeal, sealValues(c.sealKey, c.AP))
+}
+
+// TamperAlert returns a description of the changes to protected settings that were found when
+// the configuration was loaded, or "" if there were none
+func (c *AgentConfig) TamperAlert() string {
+	c.sealMu.Lock()
+	defer c.sealMu.Unlock()
+	return c.tamper
+}
+
+// ClearTamperAlert is called once the tamper alert has been sent to the server. The current
+// settings are sealed the next time the configuration is saved.
+func (c *AgentConfig) ClearTamperAlert() {
+	c.sealMu.Lock()
+	defer c.sealMu.Unlock()
+	c.tamper = ""
+}
+
+// sealValues returns the seal of the protected settings
+func sealValues(key []byte, ap interfaces.Parameters) string {
+	macs := make(map[string]string, len(protectedKeys))
+	for _, name := range protectedKeys {
+		macs[name] = sealMAC(key, name, ap.Get(name).String())
+	}
+
+	data, _ := json.Marshal(macs)
+	return string(data)
+}
+
+// unsealedKeys returns the protected settings that don't match the seal
+func unsealedKeys(key []byte, seal string, ap interfaces.Parameters) []string {
+	var macs map[string]string
+	if err := json.Unmarshal([]byte(seal), &macs); err != nil {
+		return []string{ConfigSeal}
+	}
+
+	var changed []string
+	for _, name := range protectedKeys {
+		// A seal from an earlier version doesn't include settings that were protected later.
+		// They are accepted while they have their default (empty) value.
+		if _, ok := macs[name]; !ok && ap.Get(name).String() == "" {
+			continue
+		}
+		expected, err := base64.StdEncoding.DecodeString(macs[name])
+		actual, _ := base64.StdEncoding.DecodeString(sealMAC(key, name, ap.Get(name).String()))
+		if err != nil || !hmac.Equal(expected, actual) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// sealMAC returns the HMAC of a setting
+func sealMAC(key []byte, name, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name + "\n" + value))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
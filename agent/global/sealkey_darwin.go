@@ -0,0 +1,55 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package global
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// The key used to seal protected settings is stored in the System keychain
+const (
+	sealKeychain = "/Library/Keychains/System.keychain"
+	sealService  = "uem-agent-seal"
+	sealAccount  = "root"
+	securityPath = "/usr/bin/security"
+
+	securityNotFound = 44 // exit code of security when the item does not exist
+)
+
+// loadSealKey reads the key used to seal protected settings, returning an error that wraps
+// os.ErrNotExist if there isn't one
+func loadSealKey() ([]byte, error) {
+	out, err := exec.Command(securityPath, "find-generic-password",
+		"-s", sealService, "-a", sealAccount, "-w", sealKeychain).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == securityNotFound {
+			return nil, os.ErrNotExist
+		}
+		return nil, fmt.Errorf("unable to read seal key from keychain: %w", err)
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil || len(key) != sealKeySize {
+		return nil, fmt.Errorf("seal key in keychain is damaged: %w", os.ErrNotExist)
+	}
+	return key, nil
+}
+
+// storeSealKey stores the key used to seal protected settings, replacing any existing key
+func storeSealKey(key []byte) error {
+	err := exec.Command(securityPath, "add-generic-password", "-U",
+		"-s", sealService, "-a", sealAccount, "-w", hex.EncodeToString(key), sealKeychain).Run()
+	if err != nil {
+		return fmt.Errorf("unable to store seal key in keychain: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package global
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// sealKeyFile is kept apart from the configuration files so that the seal can't be recomputed
+// by someone who can only edit the configuration
+const sealKeyFile = "/var/lib/uem-agent/seal.key"
+
+// loadSealKey reads the key used to seal protected settings, returning an error that wraps
+// os.ErrNotExist if there isn't one
+func loadSealKey() ([]byte, error) {
+	key, err := os.ReadFile(sealKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != sealKeySize {
+		return nil, fmt.Errorf("%s is damaged: %w", sealKeyFile, os.ErrNotExist)
+	}
+	return key, nil
+}
+
+// storeSealKey stores the key used to seal protected settings
+func storeSealKey(key []byte) error {
+	if err := os.MkdirAll(filepath.Dir(sealKeyFile), 0700); err != nil {
+		return err
+	}
+
+	tmp := sealKeyFile + ".tmp"
+	if err := os.WriteFile(tmp, key, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, sealKeyFile); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
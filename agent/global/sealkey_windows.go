@@ -0,0 +1,82 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package global
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// sealKeyFile holds the key used to seal protected settings, protected with DPAPI so that it
+// can only be read on this computer. It is kept apart from the configuration in the registry.
+var sealKeyFile = filepath.Join(WindowsDefaultDataPaths[0], "seal.key")
+
+// loadSealKey reads the key used to seal protected settings, returning an error that wraps
+// os.ErrNotExist if there isn't one
+func loadSealKey() ([]byte, error) {
+	blob, err := os.ReadFile(sealKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := dpapi(blob, false)
+	if err != nil || len(key) != sealKeySize {
+		return nil, fmt.Errorf("%s is damaged: %w", sealKeyFile, os.ErrNotExist)
+	}
+	return key, nil
+}
+
+// storeSealKey stores the key used to seal protected settings
+func storeSealKey(key []byte) error {
+	blob, err := dpapi(key, true)
+	if err != nil {
+		return fmt.Errorf("unable to protect seal key: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(sealKeyFile), 0700); err != nil {
+		return err
+	}
+	tmp := sealKeyFile + ".tmp"
+	if err = os.WriteFile(tmp, blob, 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, sealKeyFile); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// dpapi protects or unprotects data with the machine's DPAPI key
+func dpapi(data []byte, protect bool) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no data")
+	}
+
+	in := windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	var out windows.DataBlob
+	var err error
+	if protect {
+		err = windows.CryptProtectData(&in, nil, nil, 0, nil,
+			windows.CRYPTPROTECT_LOCAL_MACHINE|windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	} else {
+		err = windows.CryptUnprotectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	}()
+
+	result := make([]byte, out.Size)
+	copy(result, unsafe.Slice(out.Data, out.Size))
+	return result, nil
+}
@@ -0,0 +1,15 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package global
+
+const (
+	SocketPath  = "/var/run/uem-agent.sock" // where the agent listens for the user-helper
+	SocketGroup = "staff"                   // local users, who may connect to the socket
+	SocketPerms = 0660                      // allow SocketGroup to connect
+
+	StatusSocketPath  = "/var/run/uem-agent-status.sock" // read-only status for local tools
+	StatusSocketPerms = 0666                             // allow any local user to connect
+)
@@ -0,0 +1,13 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package global
+
+// SocketPath is where the agent listens for the user-helper. The leading @ denotes an abstract
+// socket, which has no file and is removed when the agent exits.
+const SocketPath = "@uem-agent"
+
+// StatusSocketPath is where the agent provides read-only status to local tools
+const StatusSocketPath = "@uem-agent-status"
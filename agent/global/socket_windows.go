@@ -0,0 +1,12 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package global
+
+// SocketPath is the named pipe where the agent listens for the user-helper
+const SocketPath = `\\.\pipe\uem-agent`
+
+// StatusSocketPath is the named pipe where the agent provides read-only status to local tools
+const StatusSocketPath = `\\.\pipe\uem-agent-status`
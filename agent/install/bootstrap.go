@@ -0,0 +1,147 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+)
+
+// Deployment tools such as MDM and GPO that can't pass arguments to the installer, or that would
+// expose the installation key in the process list, can provide it in a bootstrap file instead.
+// The file is read by "install" when no installation key is specified and is deleted after the
+// agent is installed.
+
+// Bootstrap is the installation key and optional server URL provided by a deployment tool
+type Bootstrap struct {
+	InstallKey string `json:"install_key"`          // Installation key, or the registration token alone if ServerURL is set
+	ServerURL  string `json:"server_url,omitempty"` // Public URL of the server, if not included in InstallKey
+	source     string // File or registry key that it was read from
+	file       bool   // Source is a file that is deleted after installation
+}
+
+// BootstrapFile returns the location of the bootstrap file on this platform
+func BootstrapFile() string {
+	switch runtime.GOOS {
+	case "windows":
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return filepath.Join(programData, "UnifyEM", "bootstrap.json")
+	case "darwin":
+		return "/Library/Application Support/UnifyEM/bootstrap.json"
+	default:
+		return "/etc/unifyem/bootstrap.json"
+	}
+}
+
+// ReadBootstrap returns the installation key from the bootstrap file or, on Windows, from the
+// policy registry key written by GPO. It returns nil if neither is present.
+func ReadBootstrap() (*Bootstrap, error) {
+	b, err := readBootstrapFile(BootstrapFile())
+	if b != nil || err != nil {
+		return b, err
+	}
+	return readBootstrapRegistry()
+}
+
+func readBootstrapFile(path string) (*Bootstrap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	b := &Bootstrap{source: path, file: true}
+	if err = json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// ReadKeyFile returns the installation key from a file named on the command line, which contains
+// either the installation key alone or the same JSON as the bootstrap file. The file belongs to
+// the installer that created it and is not removed.
+func ReadKeyFile(path string) (*Bootstrap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w, error reading %s: %w", ErrInvalidKey, path, err)
+	}
+
+	b := &Bootstrap{source: path}
+	content := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(content, "{") {
+		b.InstallKey = content
+		return b, nil
+	}
+
+	if err = json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("%w, error parsing %s: %w", ErrInvalidKey, path, err)
+	}
+	return b, nil
+}
+
+// Token returns the installation token, combining the server URL and registration token if
+// they are provided separately
+func (b *Bootstrap) Token() (string, error) {
+	key := strings.TrimSpace(b.InstallKey)
+	if key == "" {
+		return "", fmt.Errorf("%w, %s does not contain an installation key", ErrInvalidKey, b.source)
+	}
+
+	serverURL := strings.TrimSpace(b.ServerURL)
+	if serverURL != "" && communications.ValidateToken(key) != nil {
+		key = strings.TrimSuffix(serverURL, "/") + "/" + key
+	}
+
+	if err := communications.ValidateToken(key); err != nil {
+		return "", fmt.Errorf("%w in %s: %w", ErrInvalidKey, b.source, err)
+	}
+	return key, nil
+}
+
+// Source returns the file or registry key that the installation key was read from
+func (b *Bootstrap) Source() string {
+	return b.source
+}
+
+// Remove deletes the bootstrap file after overwriting its contents, so that the installation key
+// does not remain on the disk. Registry values are managed by GPO and are left in place.
+func (b *Bootstrap) Remove() error {
+	if !b.file {
+		return nil
+	}
+
+	f, err := os.OpenFile(b.source, os.O_WRONLY, 0)
+	if err == nil {
+		var info os.FileInfo
+		if info, err = f.Stat(); err == nil {
+			_, err = f.Write(make([]byte, info.Size()))
+		}
+		if err == nil {
+			err = f.Sync()
+		}
+		_ = f.Close()
+	}
+	if err != nil {
+		fmt.Printf("Warning: could not overwrite %s: %v\n", b.source, err)
+	}
+
+	if err = os.Remove(b.source); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing %s: %w", b.source, err)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+// readBootstrapRegistry returns nil because other platforms only support the bootstrap file
+func readBootstrapRegistry() (*Bootstrap, error) {
+	return nil, nil
+}
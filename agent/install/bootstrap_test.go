@@ -0,0 +1,68 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBootstrapFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bootstrap.json")
+
+	// A missing file is not an error
+	if b, err := readBootstrapFile(path); b != nil || err != nil {
+		t.Fatalf("expected nothing for a missing file, got %+v %v", b, err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		token   string
+		wantErr bool
+	}{
+		{"full key", `{"install_key": "https://uem.example.com/abc123"}`, "https://uem.example.com/abc123", false},
+		{"separate server", `{"install_key": " abc123 ", "server_url": "https://uem.example.com/"}`, "https://uem.example.com/abc123", false},
+		{"key takes precedence", `{"install_key": "https://uem.example.com/abc123", "server_url": "https://other.example.com"}`, "https://uem.example.com/abc123", false},
+		{"no key", `{"server_url": "https://uem.example.com"}`, "", true},
+		{"no server", `{"install_key": "abc123"}`, "", true},
+		{"http server", `{"install_key": "abc123", "server_url": "http://uem.example.com"}`, "", true},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		b, err := readBootstrapFile(path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		token, err := b.Token()
+		if (err != nil) != tt.wantErr || token != tt.token {
+			t.Errorf("%s: got %q, %v", tt.name, token, err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBootstrapFile(path); err == nil {
+		t.Error("expected an error for an invalid file")
+	}
+
+	// The file is removed once it has been used
+	if err := os.WriteFile(path, []byte(`{"install_key": "https://uem.example.com/abc123"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := readBootstrapFile(path)
+	if err := b.Remove(); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the bootstrap file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// Registry key and values that GPO can use to provide the installation key
+const (
+	bootstrapKeyPath   = `SOFTWARE\Policies\UnifyEM\Agent`
+	bootstrapKeyValue  = "InstallKey"
+	bootstrapURLValue  = "ServerURL"
+	bootstrapKeySource = `HKLM\` + bootstrapKeyPath
+)
+
+// readBootstrapRegistry returns the installation key from the policy registry key, or nil if
+// it is not set
+func readBootstrapRegistry() (*Bootstrap, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, bootstrapKeyPath, registry.QUERY_VALUE)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error opening %s: %w", bootstrapKeySource, err)
+	}
+	defer func() { _ = key.Close() }()
+
+	installKey, _, err := key.GetStringValue(bootstrapKeyValue)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading %s\\%s: %w", bootstrapKeySource, bootstrapKeyValue, err)
+	}
+
+	serverURL, _, err := key.GetStringValue(bootstrapURLValue)
+	if err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return nil, fmt.Errorf("error reading %s\\%s: %w", bootstrapKeySource, bootstrapURLValue, err)
+	}
+	return &Bootstrap{InstallKey: installKey, ServerURL: serverURL, source: bootstrapKeySource}, nil
+}
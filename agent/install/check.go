@@ -0,0 +1,454 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"archive/zip"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/queues"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+const (
+	logTailLines   = 200              // Number of log lines included in the report
+	logTailBytes   = 256 * 1024       // Maximum amount of the log file read for the tail
+	networkTimeout = 10 * time.Second // Timeout for each connectivity test
+)
+
+// redactKeys lists substrings of configuration keys whose values must never be displayed
+var redactKeys = []string{"token", "private", "password", "secret", "credential"}
+
+// Diagnostics is the report produced by Check
+type Diagnostics struct {
+	Generated     time.Time                    `json:"generated"`
+	Version       string                       `json:"version"`
+	Build         int                          `json:"build"`
+	OS            string                       `json:"os"`
+	Arch          string                       `json:"arch"`
+	AgentID       string                       `json:"agent_id"`
+	ServerURL     string                       `json:"server_url"`
+	Proxy         string                       `json:"proxy"`
+	Service       string                       `json:"service"`
+	Recovery      string                       `json:"recovery"`
+	Connectivity  []CheckResult                `json:"connectivity"`
+	Token         CheckResult                  `json:"token"`
+	LastSync      *time.Time                   `json:"last_sync,omitempty"`
+	SyncRequests  int                          `json:"sync_requests"`  // Requests queued by the last sync
+	SyncResponses int                          `json:"sync_responses"` // Responses sent by the last sync
+	Config        map[string]map[string]string `json:"config"`
+	LogFile       string                       `json:"log_file"`
+	LogTail       []string                     `json:"log_tail"`
+}
+
+// CheckResult is the outcome of a single diagnostic test
+type CheckResult struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Details string `json:"details"`
+}
+
+// Check gathers diagnostic information and displays it. If bundle is true, a zip file
+// containing the report, the configuration, and the log tail is written to the data directory.
+func (i *Install) Check(jsonOutput bool, bundle bool) error {
+	d := i.Diagnose()
+
+	if jsonOutput {
+		b, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error serializing report: %w", err)
+		}
+		fmt.Println(string(b))
+	} else {
+		d.Print()
+	}
+
+	if bundle {
+		path, err := i.writeBundle(d)
+		if err != nil {
+			return fmt.Errorf("error writing diagnostic bundle: %w", err)
+		}
+
+		// Keep JSON output parsable
+		if jsonOutput {
+			fmt.Fprintf(os.Stderr, "Diagnostic bundle written to %s\n", path)
+		} else {
+			fmt.Printf("Diagnostic bundle written to %s\n", path)
+		}
+	}
+	return nil
+}
+
+// Diagnose gathers diagnostic information. Secrets are redacted from the configuration.
+func (i *Install) Diagnose() Diagnostics {
+	d := Diagnostics{
+		Generated: time.Now().UTC(),
+		Version:   global.Version,
+		Build:     global.Build,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		AgentID:   i.config.AP.Get(global.ConfigAgentID).String(),
+		ServerURL: i.config.AP.Get(global.ConfigServerURL).String(),
+		Service:   i.serviceStatus(),
+		Recovery:  i.serviceRecovery(),
+		LogFile:   i.config.AP.Get(global.ConfigAgentLogFile).String(),
+	}
+
+	if last := i.config.AP.Get(global.ConfigLastSync).Int64(); last > 0 {
+		t := time.Unix(last, 0).UTC()
+		d.LastSync = &t
+		d.SyncRequests = i.config.AP.Get(global.ConfigLastSyncRequests).Int()
+		d.SyncResponses = i.config.AP.Get(global.ConfigLastSyncResponses).Int()
+	}
+
+	d.Config = map[string]map[string]string{
+		"agent":   redact(i.config.AC.GetMap()),
+		"private": redact(i.config.AP.GetMap()),
+	}
+
+	var err error
+	d.LogTail, err = tail(d.LogFile, logTailLines)
+	if err != nil {
+		d.LogTail = []string{fmt.Sprintf("unable to read log file: %v", err)}
+	}
+
+	comms, err := communications.New(
+		communications.WithLogger(i.logger),
+		communications.WithConfig(i.config),
+		communications.WithRequestQueue(queues.NewRequestQueue(global.TaskQueueSize)),
+		communications.WithResponseQueue(queues.NewResponseQueue(global.TaskQueueSize)))
+
+	if err != nil {
+		d.Token = CheckResult{Name: "token", Details: err.Error()}
+		return d
+	}
+
+	d.Proxy = comms.ProxyDescription(d.ServerURL)
+	d.Connectivity = connectivity(d.ServerURL, comms.TLSConfig(), comms.Proxy())
+	d.Token = CheckResult{Name: "token"}
+
+	// Only test the token if the server is reachable
+	if len(d.Connectivity) > 0 && d.Connectivity[len(d.Connectivity)-1].OK {
+		err = comms.CheckToken()
+		if err != nil {
+			d.Token.Details = err.Error()
+		} else {
+			d.Token.OK = true
+			d.Token.Details = "refresh and access tokens accepted"
+		}
+	} else {
+		d.Token.Details = "skipped, server is not reachable"
+	}
+
+	return d
+}
+
+// Print displays the report in a human-readable format
+func (d Diagnostics) Print() {
+	fmt.Printf("Agent\n")
+	fmt.Printf("  Version:    %s (build %d) %s/%s\n", d.Version, d.Build, d.OS, d.Arch)
+	fmt.Printf("  Agent ID:   %s\n", d.AgentID)
+	fmt.Printf("  Server URL: %s\n", d.ServerURL)
+	fmt.Printf("  Proxy:      %s\n", d.Proxy)
+	fmt.Printf("  Service:    %s\n", d.Service)
+	fmt.Printf("  Recovery:   %s\n", d.Recovery)
+
+	fmt.Printf("\nConnectivity\n")
+	for _, r := range append(d.Connectivity, d.Token) {
+		fmt.Printf("  %-6s %-5s %s\n", r.Name+":", status(r.OK), r.Details)
+	}
+
+	fmt.Printf("\nLast sync\n")
+	if d.LastSync == nil {
+		fmt.Printf("  Never\n")
+	} else {
+		fmt.Printf("  Time:      %s (%s ago)\n", d.LastSync.Format(time.RFC3339), time.Since(*d.LastSync).Round(time.Second))
+		fmt.Printf("  Requests:  %d queued\n", d.SyncRequests)
+		fmt.Printf("  Responses: %d sent\n", d.SyncResponses)
+	}
+
+	for _, set := range []string{"agent", "private"} {
+		fmt.Printf("\nConfiguration (%s)\n", set)
+		keys := make([]string, 0, len(d.Config[set]))
+		for k := range d.Config[set] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("  %s: %s\n", k, d.Config[set][k])
+		}
+	}
+
+	fmt.Printf("\nLog (%s, last %d lines)\n", d.LogFile, len(d.LogTail))
+	for _, line := range d.LogTail {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Println()
+}
+
+// connectivity tests DNS, TCP, TLS, and HTTP connectivity to the server in order,
+// stopping at the first failure. If the server is reached through a proxy, the
+// connection to the proxy is tested instead of DNS, TCP, and TLS, and failures of the
+// proxy are reported separately from failures of the server.
+func connectivity(serverURL string, tlsConfig *tls.Config, proxy func(*http.Request) (*url.URL, error)) []CheckResult {
+	var results []CheckResult
+
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Hostname() == "" {
+		return append(results, CheckResult{Name: "dns", Details: "server URL is not set or invalid"})
+	}
+
+	var proxyURL *url.URL
+	if proxy != nil {
+		proxyURL, err = proxy(&http.Request{URL: u})
+		if err != nil {
+			return append(results, CheckResult{Name: "proxy", Details: err.Error()})
+		}
+	}
+	if proxyURL != nil {
+		return append(results, viaProxy(serverURL, tlsConfig, proxyURL)...)
+	}
+
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	address := net.JoinHostPort(host, port)
+
+	// DNS
+	addrs, err := net.LookupHost(host)
+	if err != nil {
+		return append(results, CheckResult{Name: "dns", Details: err.Error()})
+	}
+	results = append(results, CheckResult{Name: "dns", OK: true, Details: strings.Join(addrs, ", ")})
+
+	// TCP
+	conn, err := net.DialTimeout("tcp", address, networkTimeout)
+	if err != nil {
+		return append(results, CheckResult{Name: "tcp", Details: err.Error()})
+	}
+	_ = conn.Close()
+	results = append(results, CheckResult{Name: "tcp", OK: true, Details: "connected to " + address})
+
+	// TLS
+	if u.Scheme == "https" {
+		tlsConn, tErr := tls.DialWithDialer(&net.Dialer{Timeout: networkTimeout}, "tcp", address, tlsConfig)
+		if tErr != nil {
+			return append(results, CheckResult{Name: "tls", Details: tErr.Error()})
+		}
+		state := tlsConn.ConnectionState()
+		_ = tlsConn.Close()
+
+		details := tls.VersionName(state.Version)
+		if len(state.PeerCertificates) > 0 {
+			cert := state.PeerCertificates[0]
+			details += fmt.Sprintf(", certificate %s expires %s", cert.Subject.CommonName, cert.NotAfter.Format(time.DateOnly))
+		}
+		results = append(results, CheckResult{Name: "tls", OK: true, Details: details})
+	}
+
+	return append(results, ping(serverURL, &http.Transport{TLSClientConfig: tlsConfig}))
+}
+
+// viaProxy tests the connection to the proxy and then HTTP connectivity to the server
+// through it
+func viaProxy(serverURL string, tlsConfig *tls.Config, proxyURL *url.URL) []CheckResult {
+	port := proxyURL.Port()
+	if port == "" {
+		port = "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	address := net.JoinHostPort(proxyURL.Hostname(), port)
+
+	conn, err := net.DialTimeout("tcp", address, networkTimeout)
+	if err != nil {
+		return []CheckResult{{Name: "proxy", Details: err.Error()}}
+	}
+	_ = conn.Close()
+
+	result := CheckResult{Name: "proxy", OK: true, Details: "connected to " + proxyURL.Redacted()}
+	return []CheckResult{result, ping(serverURL, &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyURL(proxyURL)})}
+}
+
+// ping tests HTTP connectivity. An unauthenticated ping is expected to be rejected, which
+// confirms that the API is responding.
+func ping(serverURL string, transport *http.Transport) CheckResult {
+	client := &http.Client{
+		Timeout:   networkTimeout,
+		Transport: transport,
+	}
+
+	resp, err := client.Get(strings.TrimSuffix(serverURL, "/") + schema.EndpointPing)
+	if err != nil {
+		if communications.IsProxyError(err) {
+			return CheckResult{Name: "proxy", Details: err.Error()}
+		}
+		return CheckResult{Name: "http", Details: err.Error()}
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		return CheckResult{Name: "proxy", Details: "proxy responded with " + resp.Status}
+	}
+
+	ok := resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusUnauthorized
+	return CheckResult{Name: "http", OK: ok, Details: "API responded with " + resp.Status}
+}
+
+// redact returns a copy of the configuration with secret values replaced
+func redact(config map[string]string) map[string]string {
+	r := make(map[string]string, len(config))
+	for k, v := range config {
+		r[k] = v
+		if v == "" {
+			continue
+		}
+		for _, s := range redactKeys {
+			if strings.Contains(strings.ToLower(k), s) {
+				r[k] = "[redacted]"
+				break
+			}
+		}
+	}
+	return r
+}
+
+// tail returns up to n lines from the end of the file
+func tail(path string, n int) ([]string, error) {
+	if path == "" {
+		return nil, fmt.Errorf("log file is not set")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only read the end of large files
+	offset := info.Size() - logTailBytes
+	if offset > 0 {
+		_, err = f.Seek(offset, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\r\n"), "\n")
+
+	// Discard the partial first line
+	if offset > 0 && len(lines) > 1 {
+		lines = lines[1:]
+	}
+
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	for x := range lines {
+		lines[x] = strings.TrimRight(lines[x], "\r")
+	}
+	return lines, nil
+}
+
+// writeBundle writes a zip file containing the report, the redacted configuration, and the
+// log tail to the data directory and returns its path
+func (i *Install) writeBundle(d Diagnostics) (string, error) {
+	dir := i.config.AP.Get(global.ConfigAgentDataDir).String()
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-diagnostics-%s.zip", global.Name, d.Generated.Format("20060102-150405")))
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	err = writeZip(f, d)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// writeZip writes the bundle contents to w
+func writeZip(w io.Writer, d Diagnostics) error {
+	report, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	config, err := json.MarshalIndent(d.Config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"diagnostics.json", report},
+		{"config.json", config},
+		{"log-tail.txt", []byte(strings.Join(d.LogTail, "\n") + "\n")},
+	}
+
+	z := zip.NewWriter(w)
+	for _, file := range files {
+		fw, zErr := z.Create(file.name)
+		if zErr != nil {
+			return zErr
+		}
+		if _, zErr = fw.Write(file.data); zErr != nil {
+			return zErr
+		}
+	}
+	return z.Close()
+}
+
+// status returns a short description of a test result
+func status(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "FAIL"
+}
@@ -0,0 +1,41 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+func TestRedact(t *testing.T) {
+	config := map[string]string{
+		global.ConfigRegToken:          "reg",
+		global.ConfigRefreshToken:      "refresh",
+		global.ConfigAgentECPrivateSig: "sig",
+		global.ConfigAgentECPrivateEnc: "enc",
+		"service_password":             "password",
+		global.ConfigAgentID:           "A-1234",
+		global.ConfigServerURL:         "https://uem.example.com",
+	}
+
+	r := redact(config)
+
+	for _, key := range []string{global.ConfigRegToken, global.ConfigRefreshToken,
+		global.ConfigAgentECPrivateSig, global.ConfigAgentECPrivateEnc, "service_password"} {
+		if r[key] != "[redacted]" {
+			t.Errorf("%s was not redacted: %q", key, r[key])
+		}
+	}
+
+	if r[global.ConfigAgentID] != "A-1234" || r[global.ConfigServerURL] != "https://uem.example.com" {
+		t.Errorf("non-secret values were changed: %v", r)
+	}
+
+	if config[global.ConfigRefreshToken] != "refresh" {
+		t.Error("redact modified the original configuration")
+	}
+}
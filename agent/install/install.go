@@ -10,10 +10,9 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"runtime"
-	"strings"
-	"time"
+	"path/filepath"
 
+	"github.com/UnifyEM/UnifyEM/agent/communications"
 	"github.com/UnifyEM/UnifyEM/agent/global"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 )
@@ -25,7 +24,14 @@ type Install struct {
 	user         string
 	pass         string
 	friendlyName string
+	requestID    string
 	isUpgrade    bool
+	keepData     bool
+	force        bool
+	deferReg     bool
+	quiet        bool
+	progress     io.Writer
+	beforeRemove func()
 }
 
 // Option is a functional option for configuring Install
@@ -76,6 +82,43 @@ func WithUpgrade() Option {
 	}
 }
 
+// WithRequestID sets the ID of the server request that initiated an upgrade (optional)
+func WithRequestID(requestID string) Option {
+	return func(i *Install) {
+		i.requestID = requestID
+	}
+}
+
+// WithForce discards an existing agent identity so that the agent registers again (optional)
+func WithForce() Option {
+	return func(i *Install) {
+		i.force = true
+	}
+}
+
+// WithDeferRegistration stores the installation token without contacting the server. The
+// agent registers when the service starts and the server can be reached (optional).
+func WithDeferRegistration() Option {
+	return func(i *Install) {
+		i.deferReg = true
+	}
+}
+
+// WithKeepData preserves the configuration, data, and logs on uninstall (optional)
+func WithKeepData() Option {
+	return func(i *Install) {
+		i.keepData = true
+	}
+}
+
+// WithBeforeRemove sets a function called during uninstall after the service
+// has been stopped and before the binary is removed (optional)
+func WithBeforeRemove(fn func()) Option {
+	return func(i *Install) {
+		i.beforeRemove = fn
+	}
+}
+
 // New creates a new Install instance with the provided options
 func New(opts ...Option) (*Install, error) {
 	i := &Install{}
@@ -94,30 +137,6 @@ func New(opts ...Option) (*Install, error) {
 	return i, nil
 }
 
-// Check displays the current configuration
-func (i *Install) Check() {
-
-	// Display the configuration
-	fmt.Printf("Reg Token: %s\n", i.config.AP.Get(global.ConfigRegToken).String())
-	fmt.Printf("Server URL: %s\n", i.config.AP.Get(global.ConfigServerURL).String())
-	fmt.Printf("Agent ID: %s\n", i.config.AP.Get(global.ConfigAgentID).String())
-	fmt.Printf("\n")
-
-	acDump, err := i.config.AC.Dump()
-	if err != nil {
-		fmt.Printf("Error dumping AC configuration: %v\n", err)
-		return
-	}
-	fmt.Printf("AC: %v\n\n", acDump)
-
-	apDump, err := i.config.AP.Dump()
-	if err != nil {
-		fmt.Printf("Error dumping AP configuration: %v\n", err)
-		return
-	}
-	fmt.Printf("AP: %v\n\n", apDump)
-}
-
 func (i *Install) Install() error {
 	var err error
 
@@ -126,22 +145,45 @@ func (i *Install) Install() error {
 		return errors.New("installation token is required")
 	}
 
-	// Check if we already have valid credentials (agent ID and refresh token)
-	existingAgentID := i.config.AP.Get(global.ConfigAgentID).String()
-	existingRefreshToken := i.config.AP.Get(global.ConfigRefreshToken).String()
+	mode := i.installMode(serviceInstalled())
 
-	// Only clear credentials if we don't have both agent ID and refresh token
-	if existingAgentID != "" && existingRefreshToken != "" {
-		i.logger.Info(8604, "existing agent credentials found, preserving for reuse", nil)
-		// Keep existing credentials, just update the installation token as backup
-		i.config.AP.Set(global.ConfigRegToken, i.token)
-	} else {
-		// No valid existing credentials, prepare for new registration
-		i.config.AP.Set(global.ConfigRegToken, i.token)
-		i.config.AP.Set(global.ConfigRefreshToken, "")
-		i.config.AP.Set(global.ConfigServerURL, "")
+	// A quiet installation reports an existing installation instead of repairing it
+	if i.quiet && mode == modeRepair {
+		return fmt.Errorf("%w as agent %s, use --force to register again", ErrAlreadyInstalled,
+			i.config.AP.Get(global.ConfigAgentID).String())
+	}
+
+	// A deferred registration must not reuse an identity, for example one that would be
+	// captured in an image, and the token can only be checked offline
+	if i.deferReg || i.quiet {
+		err = communications.ValidateToken(i.token)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+	}
+	i.report(StepKey)
+	if i.deferReg && mode != modeForce && i.config.AP.Get(global.ConfigAgentID).String() != "" {
+		return errors.New("the agent is already registered, use reset --for-imaging or install --force first")
 	}
 
+	switch mode {
+	case modeRepair:
+		return i.repair()
+	case modeForce:
+		fmt.Printf("Discarding existing agent identity %s, the agent will register again\n",
+			i.config.AP.Get(global.ConfigAgentID).String())
+		i.logger.Info(8613, "existing agent identity discarded by forced install", nil)
+
+		// Remove the backup so that the identity is not restored when the config is loaded
+		err = global.DeleteBackup()
+		if err != nil {
+			fmt.Printf("Warning: could not remove identity backup: %v\n", err)
+		}
+	}
+
+	i.prepareIdentity(mode)
+	i.config.AP.Set(global.ConfigRegDeferred, i.deferReg)
+
 	err = i.config.Checkpoint()
 	if err != nil {
 		return err
@@ -153,6 +195,12 @@ func (i *Install) Install() error {
 		_ = i.config.Checkpoint()
 	}
 
+	if i.deferReg {
+		fmt.Printf("Registration deferred, the agent will register when the server can be reached\n")
+		fmt.Printf("The service account is not created, run 'service-account' after the agent has registered\n\n")
+		i.logger.Info(8616, "installed with deferred registration", nil)
+	}
+
 	// Call the private function for os specific install
 	return i.installService()
 }
@@ -165,81 +213,115 @@ func (i *Install) Stop() error {
 
 func (i *Install) Uninstall() error {
 	// Call the private function for os specific uninstall
-	return i.uninstallService(true)
+	return i.uninstallService(!i.keepData)
 }
 
-func (i *Install) Upgrade() error {
-	var err error
+// installMode describes how Install treats an existing installation
+type installMode int
 
-	// Set upgrade flag to skip service account operations
-	i.isUpgrade = true
+const (
+	modeFresh  installMode = iota // Install, preserving credentials if they exist
+	modeRepair                    // Reinstall the binary and service, keeping the agent identity
+	modeForce                     // Discard the agent identity and register again
+)
 
-	// Call the os specific upgrade
-	err = i.upgradeService()
-	if err != nil {
-		i.logger.Errorf(8600, "upgrade failed, attempting recovery: %s", err.Error())
+// installMode determines whether this is a fresh install, a repair of an existing
+// installation, or a forced reinstall. A repair requires both an installed service
+// and a configured agent ID.
+func (i *Install) installMode(serviceInstalled bool) installMode {
+	agentID := i.config.AP.Get(global.ConfigAgentID).String()
 
-		// Attempt to uninstall the service
-		err = i.recoverUninstall()
-		if err != nil {
-			// On Windows, if the service exists and can't be removed, that's a problem
-			// On Linux or macOS the binary might be locked, but it's worth trying to continue
-			if runtime.GOOS == "windows" {
-				if !strings.Contains(err.Error(), "service does not exist") {
-					i.logger.Errorf(8601, "unable to uninstall the existing service: %s", err.Error())
-					return err
-				}
-			}
-		}
+	if i.force && (serviceInstalled || agentID != "") {
+		return modeForce
+	}
 
-		// sleep for 5 seconds
-		time.Sleep(5 * time.Second)
+	if serviceInstalled && agentID != "" {
+		return modeRepair
+	}
 
-		// Attempt to install the service
-		err = i.recoverInstall()
-		if err != nil {
-			i.logger.Errorf(8602, "recovery failed: %s", err.Error())
-			return err
-		}
-		i.logger.Info(8603, "upgrade recovery was successful", nil)
+	return modeFresh
+}
+
+// prepareIdentity updates the stored credentials for the install mode. The caller
+// is responsible for saving the configuration.
+func (i *Install) prepareIdentity(mode installMode) {
+	switch mode {
+	case modeRepair:
+		// Leave the agent identity untouched
+		return
+
+	case modeForce:
+		i.config.AP.Set(global.ConfigAgentID, "")
+		i.config.AP.Set(global.ConfigRegToken, i.token)
+		i.config.AP.Set(global.ConfigRefreshToken, "")
+		i.config.AP.Set(global.ConfigServerURL, "")
+		return
+	}
+
+	// Check if we already have valid credentials (agent ID and refresh token)
+	existingAgentID := i.config.AP.Get(global.ConfigAgentID).String()
+	existingRefreshToken := i.config.AP.Get(global.ConfigRefreshToken).String()
+
+	// Only clear credentials if we don't have both agent ID and refresh token
+	if existingAgentID != "" && existingRefreshToken != "" {
+		i.logger.Info(8604, "existing agent credentials found, preserving for reuse", nil)
+		// Keep existing credentials, just update the installation token as backup
+		i.config.AP.Set(global.ConfigRegToken, i.token)
+	} else {
+		// No valid existing credentials, prepare for new registration
+		i.config.AP.Set(global.ConfigRegToken, i.token)
+		i.config.AP.Set(global.ConfigRefreshToken, "")
+		i.config.AP.Set(global.ConfigServerURL, "")
 	}
-	return nil
 }
 
-func (i *Install) recoverUninstall() error {
-	var err error
+// repair reinstalls the binary and service definitions of an existing installation
+// without changing the agent identity or registering again
+func (i *Install) repair() error {
+	agentID := i.config.AP.Get(global.ConfigAgentID).String()
+	fmt.Printf("Existing installation found for agent %s, repairing\n", agentID)
+	fmt.Printf("Use install --force to discard the agent identity and register again\n\n")
+	i.logger.Info(8614, "repairing existing installation", nil)
 
-	// Try to stop the service
-	err = i.stopService()
-	if err != nil {
-		return fmt.Errorf("failed to stop service: %w", err)
+	if i.friendlyName != "" {
+		fmt.Printf("Friendly name ignored, it can only be set on a new installation\n")
 	}
 
-	err = i.uninstallService(false)
+	// The service must be stopped so that the binary can be replaced, it may already be stopped
+	_ = i.stopService()
+
+	// Skip service account creation, it was done by the original installation
+	i.isUpgrade = true
+
+	err := i.installService()
 	if err != nil {
-		return fmt.Errorf("uninstall failed: %w", err)
+		return fmt.Errorf("repair failed: %w", err)
 	}
+
+	fmt.Printf("\nRepaired: binary, service definition, and service state. Agent identity unchanged.\n")
 	return nil
 }
 
-func (i *Install) recoverInstall() error {
-	var err error
-	var origFail string
+// notifyBeforeRemove calls the beforeRemove function, if set
+func (i *Install) notifyBeforeRemove() {
+	if i.beforeRemove != nil {
+		i.beforeRemove()
+	}
+}
 
-	// attempt to install the service
-	err = i.installService()
-	if err != nil {
-		origFail = err.Error()
+func (i *Install) Upgrade() error {
 
-		// sleep for 10 seconds
-		time.Sleep(10 * time.Second)
+	// Set upgrade flag to skip service account operations
+	i.isUpgrade = true
 
-		// try again
-		err = i.installService()
-		if err != nil {
-			return fmt.Errorf("install failed twice: [%s] [%s]", origFail, err.Error())
-		}
+	// Call the os specific upgrade, which restores the previous version on failure
+	err := i.upgradeService()
+	if err != nil {
+		i.logger.Errorf(8600, "upgrade failed: %s", err.Error())
+		return err
 	}
+
+	i.logger.Info(8603, "upgrade was successful", nil)
 	return nil
 }
 
@@ -265,6 +347,52 @@ func (i *Install) ReKey() error {
 	return nil
 }
 
+// deleteData removes the agent data directory, logs, identity backups, and configuration.
+// Each item is attempted even if an earlier one fails and all errors are returned.
+func (i *Install) deleteData() error {
+	var errs []error
+
+	// The data directory is only removed recursively if it is recognizably ours
+	dataDir := i.config.AP.Get(global.ConfigAgentDataDir).String()
+	if dataDir != "" {
+		dataDir = filepath.Clean(dataDir)
+		if filepath.IsAbs(dataDir) && filepath.Base(dataDir) == global.LogName {
+			err := os.RemoveAll(dataDir)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not remove data directory: %w", err))
+			}
+		} else {
+			errs = append(errs, fmt.Errorf("data directory %s not removed, please remove it manually", dataDir))
+		}
+	}
+
+	// The log file may be outside the data directory, rotated logs have a date suffix
+	logFile := i.config.AP.Get(global.ConfigAgentLogFile).String()
+	if logFile != "" {
+		rotated, _ := filepath.Glob(logFile + "-*")
+		for _, f := range append(rotated, logFile) {
+			err := os.Remove(f)
+			if err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("could not remove log file: %w", err))
+			}
+		}
+	}
+
+	// Remove the identity backup so that a reinstall does not restore it
+	err := global.DeleteBackup()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("could not remove backup: %w", err))
+	}
+
+	// Remove the configuration file or registry key
+	err = i.config.Delete()
+	if err != nil && !os.IsNotExist(err) {
+		errs = append(errs, fmt.Errorf("could not remove configuration: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
 // copyFile copies a file from src to dst
 func copyFile(src, dst string) error {
 	in, err := os.Open(src)
@@ -12,6 +12,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/UnifyEM/UnifyEM/agent/global"
@@ -22,6 +24,8 @@ const (
 	binaryPath      = "/usr/local/bin"
 	daemonPlistPath = "/Library/LaunchDaemons/com.tenebris.uem-agent.plist"
 	agentPlistPath  = "/Library/LaunchAgents/com.tenebris.uem-agent.plist"
+	daemonLabel     = "com.tenebris.uem-agent"
+	daemonTarget    = "system/" + daemonLabel
 )
 
 // Note that this must also be changed if binaryPath or global.UnixBinaryName are changed
@@ -124,6 +128,7 @@ func (i *Install) installService() error {
 	if err != nil {
 		return fmt.Errorf("could not set owner of binary to root: %w", err)
 	}
+	i.report(StepBinary)
 
 	// Create daemon plist
 	err = i.createPlist(daemonPlistPath, daemonPlistContent)
@@ -136,11 +141,15 @@ func (i *Install) installService() error {
 	if err != nil {
 		return err
 	}
+	i.report(StepService)
 
 	// Create service account and transmit credentials BEFORE starting the daemon
 	// to eliminate a race where the daemon starts syncing before credentials exist
-	if !i.isUpgrade {
+	if !i.isUpgrade && !i.deferReg {
 		if i.user == "" || i.pass == "" {
+			if i.quiet || !interactive() {
+				return errors.New("the username and password of an administrator are required to create the service account")
+			}
 			err = i.promptCredentials()
 			if err != nil {
 				return err
@@ -151,14 +160,16 @@ func (i *Install) installService() error {
 		if err != nil {
 			return err
 		}
+		i.report(StepRegister)
 	}
 
-	// Load the Launch Daemon
-	cmd = exec.Command("launchctl", "load", daemonPlistPath)
-	err = cmd.Run()
+	// Load and start the Launch Daemon, verifying that it is running
+	err = i.startService()
 	if err != nil {
-		return fmt.Errorf("could not load launch daemon: %w", err)
+		return fmt.Errorf("%w: %w", ErrServiceStart, err)
 	}
+	fmt.Printf("Launch daemon %s is running\n", daemonLabel)
+	i.report(StepStart)
 
 	// Bootstrap user agent for currently logged-in users
 	// Note: The plist in /Library/LaunchAgents/ will auto-load for users at login
@@ -186,6 +197,12 @@ func (i *Install) uninstallService(removeData bool) error {
 		}
 	}
 
+	// Remove the agent plist first so that users who log in later do not start it
+	err = os.Remove(agentPlistPath)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: could not remove agent plist: %v\n", err)
+	}
+
 	// Unload all user agent instances
 	unloadUserAgents()
 
@@ -210,11 +227,8 @@ func (i *Install) uninstallService(removeData bool) error {
 		return fmt.Errorf("could not remove daemon plist file: %w", err)
 	}
 
-	// Remove agent plist
-	err = os.Remove(agentPlistPath)
-	if err != nil {
-		fmt.Printf("Warning: could not remove agent plist: %v\n", err)
-	}
+	// Last opportunity to report before the binary is gone
+	i.notifyBeforeRemove()
 
 	// Remove the service binary
 	err = os.Remove(targetPath)
@@ -223,7 +237,11 @@ func (i *Install) uninstallService(removeData bool) error {
 	}
 
 	if removeData {
-		err = i.config.Delete()
+		removeUserLogs()
+		err = i.deleteData()
+		if err != nil {
+			return fmt.Errorf("service removed but data cleanup was incomplete: %w", err)
+		}
 	}
 
 	return nil
@@ -238,28 +256,18 @@ func isProcessRunning(name string) bool {
 
 // Upgrade the service
 func (i *Install) upgradeService() error {
-
-	fmt.Println("Uninstalling existing agent...")
-
-	// Remove the existing executable
-	err := i.uninstallService(false)
-	if err != nil {
-		return fmt.Errorf("could not remove existing service: %w", err)
-	}
-
-	// Delay for two seconds to allow the system to release the file
-	time.Sleep(2 * time.Second)
-
-	fmt.Println("\nInstalling new agent...")
-
-	// Install the new service
-	return i.installService()
+	return i.upgradeInPlace(binaryPath + string(os.PathSeparator) + serviceName)
 }
 
 // CheckRootPrivileges checks if the current user has root privileges and if not,
-// it will attempt to gain root privileges by running the current program with sudo
-func CheckRootPrivileges() error {
+// it will attempt to gain root privileges by running the current program with sudo.
+// If elevate is false or there is no terminal for sudo to prompt on, it returns
+// ErrNotPrivileged instead.
+func CheckRootPrivileges(elevate bool) error {
 	if os.Geteuid() != 0 {
+		if !elevate || !interactive() {
+			return ErrNotPrivileged
+		}
 		fmt.Println("\nThis program must be run as root, restarting with sudo...")
 		cmd := exec.Command("sudo", os.Args...)
 		cmd.Stdin = os.Stdin
@@ -292,37 +300,97 @@ func (i *Install) createPlist(path string, content string) error {
 	return nil
 }
 
-// stopService stops the service
+// stopService stops the service by removing it from launchd. The plist is
+// not removed, so the service will be loaded again at boot.
 func (i *Install) stopService() error {
-	cmd := exec.Command("launchctl", "stop", daemonPlistPath)
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("error stopping service: %w", err)
+	if !daemonLoaded() {
+		return nil
+	}
+
+	out, err := launchctl("bootout", daemonTarget)
+	if err != nil && daemonLoaded() {
+		// Fall back to the legacy subcommand for older versions of macOS
+		out, err = launchctl("unload", daemonPlistPath)
+	}
+
+	// bootout returns before the process has exited
+	for n := 0; n < 10 && daemonLoaded(); n++ {
+		time.Sleep(1 * time.Second)
+	}
+
+	if daemonLoaded() {
+		if err == nil {
+			err = errors.New("service is still loaded")
+		}
+		return launchctlError("could not stop "+daemonLabel, out, err)
 	}
 	return nil
 }
 
-// startService starts the service
+// startService loads the service if necessary and starts it. If the service
+// is already running, it is killed and restarted.
 func (i *Install) startService() error {
-	cmd := exec.Command("launchctl", "start", daemonPlistPath)
-	err := cmd.Run()
+	if !daemonLoaded() {
+		out, err := launchctl("bootstrap", "system", daemonPlistPath)
+		if err != nil && !daemonLoaded() {
+			// Fall back to the legacy subcommand for older versions of macOS
+			out, err = launchctl("load", "-w", daemonPlistPath)
+			if err != nil || !daemonLoaded() {
+				return launchctlError("could not load "+daemonPlistPath, out, err)
+			}
+		}
+	}
+
+	out, err := launchctl("kickstart", "-k", daemonTarget)
 	if err != nil {
-		return fmt.Errorf("error starting service: %w", err)
+		return launchctlError("could not start "+daemonLabel, out, err)
 	}
-	return nil
+	return waitForDaemon()
 }
 
 // restart the service
 func (i *Install) restartService() error {
-	err := i.stopService()
-	if err != nil {
-		return err
+	// kickstart -k restarts the service if it is running
+	return i.startService()
+}
+
+// launchctl runs launchctl with the specified arguments and returns the combined output
+func launchctl(args ...string) (string, error) {
+	out, err := exec.Command("launchctl", args...).CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+// launchctlError returns an error that includes launchctl's output, if any
+func launchctlError(msg string, out string, err error) error {
+	if out == "" {
+		return fmt.Errorf("%s: %w", msg, err)
 	}
+	return fmt.Errorf("%s: %w: %s", msg, err, out)
+}
 
-	// Delay 3 seconds
-	time.Sleep(3 * time.Second)
+// daemonLoaded returns true if launchd has loaded the daemon
+func daemonLoaded() bool {
+	_, err := launchctl("print", daemonTarget)
+	return err == nil
+}
 
-	return i.startService()
+// waitForDaemon waits for launchd to report that the daemon is running
+func waitForDaemon() error {
+	var out string
+	var err error
+	for n := 0; n < 10; n++ {
+		out, err = launchctl("print", daemonTarget)
+		if err == nil && strings.Contains(out, "state = running") {
+			return nil
+		}
+		time.Sleep(1 * time.Second)
+	}
+
+	if err != nil {
+		return launchctlError(daemonLabel+" is not loaded", out, err)
+	}
+	return fmt.Errorf("%s is loaded but not running, check /var/log/uem-agent.log and 'launchctl print %s'",
+		daemonLabel, daemonTarget)
 }
 
 // getLoggedInUsers returns a map of UID to username for currently logged-in users
@@ -463,6 +531,14 @@ func unloadUserAgents() {
 	_ = exec.Command("pkill", "-f", "uem-agent.*--user-helper").Run()
 }
 
+// removeUserLogs removes the log files written by the user-helper agents
+func removeUserLogs() {
+	logs, _ := filepath.Glob("/tmp/uem-agent-user*.log")
+	for _, f := range logs {
+		_ = os.Remove(f)
+	}
+}
+
 // Helper functions for string processing
 func splitLines(s string) []string {
 	var lines []string
@@ -515,3 +591,32 @@ func trimSpace(s string) string {
 
 	return s[start:end]
 }
+
+// serviceStatus returns a description of the service state for Check
+func (i *Install) serviceStatus() string {
+	out, err := launchctl("print", daemonTarget)
+	if err != nil {
+		return "not loaded"
+	}
+	if strings.Contains(out, "state = running") {
+		return "running"
+	}
+	return "loaded, not running"
+}
+
+// serviceInstalled returns true if the launch daemon plist exists
+func serviceInstalled() bool {
+	_, err := os.Stat(daemonPlistPath)
+	return err == nil
+}
+
+// serviceRunning returns true if launchd reports the daemon as running
+func serviceRunning() bool {
+	out, err := launchctl("print", daemonTarget)
+	return err == nil && strings.Contains(out, "state = running")
+}
+
+// serviceRecovery returns a description of the restart policy for Check
+func (i *Install) serviceRecovery() string {
+	return "launchd starts the daemon every 60 seconds if it is not running"
+}
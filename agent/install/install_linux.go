@@ -8,9 +8,12 @@
 package install
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -19,26 +22,44 @@ const (
 	binaryPath  = "/usr/local/bin"
 	servicePath = "/etc/systemd/system"
 	serviceFile = "uem-agent.service"
+
+	userServicePath = "/etc/systemd/user"
+	userServiceFile = "uem-agent-user.service"
 )
 
 // This must also be changed if binaryPath or serviceName are changed
 const serviceContent = `
 [Unit]
 Description=uem-agent
-After=network.target
+Wants=network-online.target
+After=network-online.target
 StartLimitIntervalSec=0
 
 [Service]
 WorkingDirectory=/tmp
 User=root
 Group=root
-Restart=always
-RestartSec=1
+Restart=on-failure
+RestartSec=5
 ExecStart=/usr/local/bin/uem-agent
+ExecReload=/bin/kill -HUP $MAINPID
 
 [Install]
 WantedBy=multi-user.target
-Alias=uem-agent.service
+`
+
+// The user-helper runs in each user's systemd instance to collect data from the user's session
+const userServiceContent = `
+[Unit]
+Description=uem-agent user-helper
+
+[Service]
+Restart=on-failure
+RestartSec=30
+ExecStart=/usr/local/bin/uem-agent --user-helper
+
+[Install]
+WantedBy=default.target
 `
 
 // Install the service
@@ -50,9 +71,10 @@ func (i *Install) installService() error {
 		return fmt.Errorf("could not find executable path: %w", err)
 	}
 
-	// Return error if servicePath doesn't exist
-	if _, err := os.Stat(servicePath); os.IsNotExist(err) {
-		return fmt.Errorf("%s does not exist - aborting install", servicePath)
+	// Only systemd is supported
+	err = checkSystemd()
+	if err != nil {
+		return err
 	}
 
 	// Set the target path
@@ -79,11 +101,12 @@ func (i *Install) installService() error {
 	}
 	fmt.Printf("Binary copied to %s\n", targetPath)
 
-	// Set the proper permissions on the binary
-	err = os.Chmod(targetPath, 0700)
+	// Set the proper permissions on the binary (755 allows user-helper mode to run as non-root)
+	err = os.Chmod(targetPath, 0755)
 	if err != nil {
 		return fmt.Errorf("could not set permissions on binary: %w", err)
 	}
+	i.report(StepBinary)
 
 	// Create the service file
 	err = i.createService()
@@ -91,17 +114,31 @@ func (i *Install) installService() error {
 		return err
 	}
 
-	// Create service account before starting the service
-	err = i.ServiceAccount()
+	// Create the user-helper unit
+	err = i.createUserService()
 	if err != nil {
-		return fmt.Errorf("failed to create service account: %w", err)
+		return err
+	}
+	i.report(StepService)
+
+	// Create service account before starting the service
+	if !i.isUpgrade && !i.deferReg {
+		err = i.ServiceAccount()
+		if err != nil {
+			return fmt.Errorf("failed to create service account: %w", err)
+		}
+		i.report(StepRegister)
 	}
 
 	// Start the service
 	err = i.startService()
 	if err != nil {
-		return fmt.Errorf("could not start service: %w", err)
+		return fmt.Errorf("%w: %w", ErrServiceStart, err)
 	}
+	i.report(StepStart)
+
+	// The user-helper starts at login, so start it for users who are already logged in
+	startUserServices()
 
 	return nil
 }
@@ -109,26 +146,50 @@ func (i *Install) installService() error {
 // Uninstall the service
 func (i *Install) uninstallService(removeData bool) error {
 
+	err := checkSystemd()
+	if err != nil {
+		return err
+	}
+
 	// Stop the service
-	err := i.stopService()
+	err = i.stopService()
 	if err != nil {
 		return fmt.Errorf("could not stop service: %w", err)
 	}
 
+	// Remove the user-helper unit first so that users who log in later do not start it
+	removeUserService()
+
+	// Disable the service, it may already be disabled
+	_, _ = systemctl("disable", serviceFile)
+
 	// Remove the service file
 	err = os.Remove(servicePath + string(os.PathSeparator) + serviceFile)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("could not remove service file: %w", err)
 	}
 
+	// Reload so systemd forgets the unit
+	out, err := systemctl("daemon-reload")
+	if err != nil {
+		return systemctlError("error reloading systemd daemon", out, err)
+	}
+
+	// Last opportunity to report before the binary is gone
+	i.notifyBeforeRemove()
+
 	// Remove the binary
 	err = os.Remove(binaryPath + string(os.PathSeparator) + serviceName)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("could not remove binary file: %w", err)
 	}
 
 	if removeData {
-		i.config.Delete()
+		removeUserLogs()
+		err = i.deleteData()
+		if err != nil {
+			return fmt.Errorf("service removed but data cleanup was incomplete: %w", err)
+		}
 	}
 
 	return nil
@@ -137,27 +198,34 @@ func (i *Install) uninstallService(removeData bool) error {
 // Upgrade the service
 func (i *Install) upgradeService() error {
 
-	fmt.Println("Uninstalling existing agent...")
-
-	// Remove the existing executable
-	err := i.uninstallService(false)
+	err := checkSystemd()
 	if err != nil {
-		return fmt.Errorf("could not remove existing service: %w", err)
+		return err
 	}
 
-	// Delay for two seconds to allow the system to release the file
-	time.Sleep(2 * time.Second)
+	// Refresh the unit files in case they have changed
+	err = i.createService()
+	if err != nil {
+		return err
+	}
 
-	fmt.Println("\nInstalling new agent...")
+	err = i.createUserService()
+	if err != nil {
+		return err
+	}
 
-	// Install the new service
-	return i.installService()
+	return i.upgradeInPlace(binaryPath + string(os.PathSeparator) + serviceName)
 }
 
 // CheckRootPrivileges checks if the current user has root privileges and if not,
-// it will attempt to gain root privileges by running the current program with sudo
-func CheckRootPrivileges() error {
+// it will attempt to gain root privileges by running the current program with sudo.
+// If elevate is false or there is no terminal for sudo to prompt on, it returns
+// ErrNotPrivileged instead.
+func CheckRootPrivileges(elevate bool) error {
 	if os.Geteuid() != 0 {
+		if !elevate || !interactive() {
+			return ErrNotPrivileged
+		}
 		fmt.Println("\nThis program must be run as root, restarting with sudo...")
 		cmd := exec.Command("sudo", os.Args...)
 		cmd.Stdin = os.Stdin
@@ -181,28 +249,106 @@ func (i *Install) createService() error {
 	}
 
 	// Reload the systemd daemon
-	cmd1 := exec.Command("systemctl", "daemon-reload")
-	err = cmd1.Run()
+	out, err := systemctl("daemon-reload")
 	if err != nil {
-		return fmt.Errorf("error reloading systemd daemon: %w", err)
+		return systemctlError("error reloading systemd daemon", out, err)
 	}
 
-	// Enable the service
-	cmd2 := exec.Command("systemctl", "enable", serviceName)
-	// The file is enabled by default, so an error here is not fatal
-	_ = cmd2.Run()
+	// Enable the service so that it starts at boot
+	out, err = systemctl("enable", serviceFile)
+	if err != nil {
+		return systemctlError("error enabling service", out, err)
+	}
 
 	fmt.Printf("Service file created at: %s\n", target)
 	return nil
 }
 
+// createUserService creates the user-helper unit and enables it for all users
+func (i *Install) createUserService() error {
+	err := os.MkdirAll(userServicePath, 0755)
+	if err != nil {
+		return fmt.Errorf("could not create directory %s: %w", userServicePath, err)
+	}
+
+	target := userServicePath + string(os.PathSeparator) + userServiceFile
+	err = os.WriteFile(target, []byte(userServiceContent), 0644)
+	if err != nil {
+		return fmt.Errorf("could not write user-helper unit file: %w", err)
+	}
+
+	// Enable the unit in every user's systemd instance
+	out, err := systemctl("--global", "enable", userServiceFile)
+	if err != nil {
+		return systemctlError("error enabling user-helper unit", out, err)
+	}
+
+	fmt.Printf("User-helper unit file created at: %s\n", target)
+	return nil
+}
+
+// startUserServices starts the user-helper for users who are currently logged in
+func startUserServices() {
+	out, err := exec.Command("loginctl", "list-users", "--no-legend").Output()
+	if err != nil {
+		fmt.Printf("Warning: could not get logged-in users: %v\n", err)
+		return
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] == "0" {
+			continue
+		}
+
+		// Best effort, the user-helper starts at the user's next login regardless
+		username := fields[1]
+		_, err := systemctl("--user", "--machine="+username+"@", "start", userServiceFile)
+		if err != nil {
+			fmt.Printf("Note: Could not start user helper for %s: %v\n", username, err)
+			continue
+		}
+		fmt.Printf("Started user helper for %s\n", username)
+	}
+}
+
+// removeUserService disables and removes the user-helper unit and stops any running user-helpers
+func removeUserService() {
+	_, _ = systemctl("--global", "disable", userServiceFile)
+
+	err := os.Remove(userServicePath + string(os.PathSeparator) + userServiceFile)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: could not remove user-helper unit file: %v\n", err)
+	}
+
+	// The users' systemd instances are not reloaded, so stop the processes directly
+	_ = exec.Command("pkill", "-f", "uem-agent --user-helper").Run()
+}
+
+// removeUserLogs removes the log files written by the user-helpers
+func removeUserLogs() {
+	logs, _ := filepath.Glob(filepath.Join(os.TempDir(), "uem-agent-user*.log"))
+	for _, f := range logs {
+		_ = os.Remove(f)
+	}
+}
+
 // stopService stops the service
 func (i *Install) stopService() error {
+	// Nothing to do if the unit is not installed
+	if _, err := systemctl("cat", serviceFile); err != nil {
+		return nil
+	}
+
 	fmt.Println("Stopping service...")
-	cmd := exec.Command("systemctl", "stop", serviceName)
-	err := cmd.Run()
+	out, err := systemctl("stop", serviceFile)
 	if err != nil {
-		return fmt.Errorf("error stopping service: %w", err)
+		return systemctlError("error stopping service", out, err)
+	}
+
+	// systemctl stop waits for the process to exit, but verify
+	if state := serviceState(); state == "active" || state == "deactivating" {
+		return fmt.Errorf("service is still %s after stopping", state)
 	}
 	return nil
 }
@@ -210,10 +356,16 @@ func (i *Install) stopService() error {
 // startService starts the service
 func (i *Install) startService() error {
 	fmt.Println("Starting service...")
-	cmd := exec.Command("systemctl", "start", serviceName)
-	err := cmd.Run()
+	out, err := systemctl("start", serviceFile)
 	if err != nil {
-		return fmt.Errorf("error starting service: %w", err)
+		return systemctlError("error starting service", out, err)
+	}
+
+	// A service that exits immediately after starting is reported as started
+	time.Sleep(2 * time.Second)
+	if state := serviceState(); state != "active" {
+		out, _ = systemctl("status", "--no-pager", "--lines=10", serviceFile)
+		return systemctlError("service failed to start", out, fmt.Errorf("service is %s", state))
 	}
 	return nil
 }
@@ -230,3 +382,74 @@ func (i *Install) restartService() error {
 
 	return i.startService()
 }
+
+// serviceStatus returns a description of the service state for Check
+func (i *Install) serviceStatus() string {
+	if err := checkSystemd(); err != nil {
+		return err.Error()
+	}
+
+	enabled, _ := systemctl("is-enabled", serviceFile)
+	return fmt.Sprintf("%s (%s)", serviceState(), enabled)
+}
+
+// checkSystemd returns an error if the system is not running systemd
+func checkSystemd() error {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return errors.New("systemctl not found, only systemd-based Linux distributions are supported")
+	}
+
+	// This directory only exists if systemd is the init system
+	if info, err := os.Stat("/run/systemd/system"); err != nil || !info.IsDir() {
+		return errors.New("systemd is not running, only systemd-based Linux distributions are supported")
+	}
+
+	if info, err := os.Stat(servicePath); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s does not exist, only systemd-based Linux distributions are supported", servicePath)
+	}
+	return nil
+}
+
+// serviceState returns the active state of the service, such as active, inactive, or failed
+func serviceState() string {
+	// is-active returns a non-zero exit code if the service is not active
+	out, _ := systemctl("is-active", serviceFile)
+	if out == "" {
+		return "unknown"
+	}
+	return out
+}
+
+// systemctl runs systemctl with the specified arguments and returns the combined output
+func systemctl(args ...string) (string, error) {
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+// systemctlError returns an error that includes systemctl's output, if any
+func systemctlError(msg string, out string, err error) error {
+	if out == "" {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	return fmt.Errorf("%s: %w: %s", msg, err, out)
+}
+
+// serviceInstalled returns true if the systemd unit file exists
+func serviceInstalled() bool {
+	_, err := os.Stat(servicePath + string(os.PathSeparator) + serviceFile)
+	return err == nil
+}
+
+// serviceRunning returns true if systemd reports the service as active
+func serviceRunning() bool {
+	return serviceState() == "active"
+}
+
+// serviceRecovery returns a description of the restart policy for Check
+func (i *Install) serviceRecovery() string {
+	out, err := systemctl("show", "--property=Restart,RestartUSec", serviceFile)
+	if err != nil {
+		return "unknown"
+	}
+	return strings.Join(strings.Fields(out), ", ")
+}
@@ -0,0 +1,107 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/uconfig"
+)
+
+func newTestInstall(t *testing.T, agentID string, opts ...Option) *Install {
+	t.Helper()
+
+	cfg := uconfig.Null()
+	conf := &global.AgentConfig{C: cfg, AP: cfg.NewSet(global.ConfigPrivate)}
+	if agentID != "" {
+		conf.AP.Set(global.ConfigAgentID, agentID)
+		conf.AP.Set(global.ConfigRefreshToken, "refresh")
+		conf.AP.Set(global.ConfigServerURL, "https://uem.example.com")
+	}
+
+	opts = append([]Option{WithConfig(conf), WithLogger(null.Logger()), WithToken("https://uem.example.com/new")}, opts...)
+	i, err := New(opts...)
+	if err != nil {
+		t.Fatalf("failed to create installer: %v", err)
+	}
+	return i
+}
+
+func TestInstallMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		agentID   string
+		installed bool
+		force     bool
+		expected  installMode
+	}{
+		{"fresh install", "", false, false, modeFresh},
+		{"repeat install", "A-1234", true, false, modeRepair},
+		{"service without identity", "", true, false, modeFresh},
+		{"identity without service", "A-1234", false, false, modeFresh},
+		{"forced reinstall", "A-1234", true, true, modeForce},
+		{"forced fresh install", "", false, true, modeFresh},
+	}
+
+	for _, test := range tests {
+		var opts []Option
+		if test.force {
+			opts = append(opts, WithForce())
+		}
+		i := newTestInstall(t, test.agentID, opts...)
+		if mode := i.installMode(test.installed); mode != test.expected {
+			t.Errorf("%s: expected mode %d, got %d", test.name, test.expected, mode)
+		}
+	}
+}
+
+func TestPrepareIdentity(t *testing.T) {
+	get := func(i *Install, key string) string {
+		return i.config.AP.Get(key).String()
+	}
+
+	// A fresh install prepares for registration
+	i := newTestInstall(t, "")
+	i.prepareIdentity(modeFresh)
+	if get(i, global.ConfigRegToken) != "https://uem.example.com/new" || get(i, global.ConfigRefreshToken) != "" {
+		t.Errorf("fresh install did not prepare for registration")
+	}
+
+	// A repeat install leaves the identity and registration token untouched
+	i = newTestInstall(t, "A-1234")
+	i.prepareIdentity(modeRepair)
+	if get(i, global.ConfigAgentID) != "A-1234" || get(i, global.ConfigRefreshToken) != "refresh" ||
+		get(i, global.ConfigServerURL) != "https://uem.example.com" || get(i, global.ConfigRegToken) != "" {
+		t.Errorf("repair changed the agent identity")
+	}
+
+	// A forced reinstall discards the identity so that the agent registers again
+	i = newTestInstall(t, "A-1234", WithForce())
+	i.prepareIdentity(modeForce)
+	if get(i, global.ConfigAgentID) != "" || get(i, global.ConfigRefreshToken) != "" || get(i, global.ConfigServerURL) != "" {
+		t.Errorf("forced reinstall did not discard the agent identity")
+	}
+	if get(i, global.ConfigRegToken) != "https://uem.example.com/new" {
+		t.Errorf("forced reinstall did not store the registration token")
+	}
+}
+
+func TestDeferredInstall(t *testing.T) {
+	// An existing identity must not be reused, for example by capturing it in an image
+	i := newTestInstall(t, "A-1234", WithDeferRegistration())
+	if err := i.Install(); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("expected deferred install of a registered agent to fail, got %v", err)
+	}
+
+	// The token can't be checked by the server, so it is checked offline
+	i = newTestInstall(t, "", WithDeferRegistration(), WithToken("not a token"))
+	if err := i.Install(); err == nil || !strings.Contains(err.Error(), "invalid installation token") {
+		t.Errorf("expected an invalid token to be rejected, got %v", err)
+	}
+}
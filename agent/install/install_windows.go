@@ -12,50 +12,25 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"syscall"
 	"time"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
 
 	"github.com/UnifyEM/UnifyEM/agent/global"
 	"github.com/UnifyEM/UnifyEM/common/uemservice/privcheck"
+	"github.com/UnifyEM/UnifyEM/common/uemservice/winsvcutil"
+	"github.com/UnifyEM/UnifyEM/common/ulogger"
 )
 
-// SERVICE_FAILURE_ACTIONS structure
-//
-//goland:noinspection GoSnakeCaseUsage
-type SERVICE_FAILURE_ACTIONS struct {
-	DwResetPeriod uint32
-	LpRebootMsg   *uint16
-	LpCommand     *uint16
-	CActions      uint32
-	LpActions     *SC_ACTION
-}
-
-// SC_ACTION_TYPE constants
-//
-//goland:noinspection ALL
 const (
-	SC_ACTION_NONE        = 0
-	SC_ACTION_RESTART     = 1
-	SC_ACTION_REBOOT      = 2
-	SC_ACTION_RUN_COMMAND = 3
+	runKeyPath   = `SOFTWARE\Microsoft\Windows\CurrentVersion\Run`
+	runValueName = "UEMAgentUser"
 )
 
-// SC_ACTION structure
-//
-//goland:noinspection GoSnakeCaseUsage
-type SC_ACTION struct {
-	Type  uint32
-	Delay uint32
-}
-
-//goland:noinspection GoSnakeCaseUsage
-const SERVICE_CONFIG_FAILURE_ACTIONS = 2
-
 // Install the service
 func (i *Install) installService() error {
 
@@ -85,11 +60,26 @@ func (i *Install) installService() error {
 		}
 	}
 	fmt.Printf("Binary copied to %s\n", targetPath)
+	i.report(StepBinary)
 
-	// Create service account before starting the service
-	err = i.ServiceAccount()
+	// Register the event log source so that events are displayed correctly
+	if err = ulogger.InstallEventSource(global.LogName); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	// Start the user-helper when users log in
+	err = addUserHelper(targetPath)
 	if err != nil {
-		return fmt.Errorf("failed to create service account: %w", err)
+		return err
+	}
+
+	// Create service account before starting the service
+	if !i.deferReg {
+		err = i.ServiceAccount()
+		if err != nil {
+			return fmt.Errorf("failed to create service account: %w", err)
+		}
+		i.report(StepRegister)
 	}
 
 	// Install the service
@@ -111,11 +101,19 @@ func (i *Install) installService() error {
 		if status.State == svc.Running {
 			return fmt.Errorf("service %s is already running", global.Name)
 		}
+		// Ensure that services created by earlier versions have the current recovery options
+		if cfgErr := winsvcutil.Configure(service.Handle, global.Description); cfgErr != nil {
+			fmt.Printf("Warning: %v\n", cfgErr)
+		}
+
+		i.report(StepService)
+
 		// Service exists but is stopped — start it and return
 		if startErr := service.Start(); startErr != nil {
-			return fmt.Errorf("failed to start existing service %s: %w", global.Name, startErr)
+			return fmt.Errorf("%w %s: %w", ErrServiceStart, global.Name, startErr)
 		}
 		fmt.Println("Windows service started")
+		i.report(StepStart)
 		return nil
 	}
 
@@ -134,51 +132,22 @@ func (i *Install) installService() error {
 
 	fmt.Println("Windows service created")
 
-	// Set the service failure actions
-	err = setServiceFailureActions(service.Handle)
+	// Set the description, delayed start, and failure actions
+	err = winsvcutil.Configure(service.Handle, global.Description)
 	if err != nil {
-		return fmt.Errorf("could not set failure actions: %w", err)
+		return err
 	}
-	fmt.Println("Windows service failure actions set")
+	fmt.Println("Windows service recovery options set")
+	i.report(StepService)
 
 	// Start the service
 	err = service.Start()
 	if err != nil {
-		return fmt.Errorf("error starting service: %w", err)
+		return fmt.Errorf("%w: %w", ErrServiceStart, err)
 	}
 
 	fmt.Println("Windows service started")
-
-	return nil
-}
-
-// setServiceFailureActions sets the failure actions for the service.
-func setServiceFailureActions(serviceHandle windows.Handle) error {
-	actions := []SC_ACTION{
-		{Type: SC_ACTION_RESTART, Delay: 10000}, // Restart the service after 10 seconds
-		{Type: SC_ACTION_RESTART, Delay: 10000}, // Restart the service after 10 seconds
-		{Type: SC_ACTION_RESTART, Delay: 60000}, // Restart the service after 60 seconds
-	}
-
-	// Prepare the SERVICE_FAILURE_ACTIONS structure
-	failureActions := SERVICE_FAILURE_ACTIONS{
-		DwResetPeriod: 86400, // Reset the failure count after 1 day (86400 seconds)
-		LpCommand:     nil,
-		LpRebootMsg:   nil,
-		CActions:      uint32(len(actions)),
-		LpActions:     &actions[0],
-	}
-
-	// Call the ChangeServiceConfig2 function to set the failure actions
-	r1, _, e1 := syscall.NewLazyDLL("advapi32.dll").NewProc("ChangeServiceConfig2W").Call(
-		uintptr(serviceHandle),
-		uintptr(SERVICE_CONFIG_FAILURE_ACTIONS),
-		uintptr(unsafe.Pointer(&failureActions)),
-	)
-
-	if r1 == 0 {
-		return fmt.Errorf("ChangeServiceConfig2W failed: %v", e1)
-	}
+	i.report(StepStart)
 
 	return nil
 }
@@ -235,10 +204,22 @@ func (i *Install) uninstallService(removeData bool) error {
 		return fmt.Errorf("error deleting service: %w", err)
 	}
 
+	// Remove the user-helper so that users who log in later do not start it, and stop any
+	// that are running so that the binary can be deleted
+	removeUserHelper()
+
+	// Remove the event log source, events already logged are kept
+	if err = ulogger.RemoveEventSource(global.LogName); err != nil {
+		fmt.Printf("Warning: unable to remove event log source: %v\n", err)
+	}
+
 	// Define the target directory and file
 	targetDir := filepath.Join(os.Getenv("ProgramFiles"), global.Name)
 	targetPath := filepath.Join(targetDir, global.WindowsBinaryName)
 
+	// Last opportunity to report before the binary is gone
+	i.notifyBeforeRemove()
+
 	// Delete the binary
 	err = i.deleteFile(targetPath)
 	if err != nil {
@@ -246,7 +227,10 @@ func (i *Install) uninstallService(removeData bool) error {
 	}
 
 	if removeData {
-		i.config.Delete()
+		err = i.deleteData()
+		if err != nil {
+			return fmt.Errorf("service removed but data cleanup was incomplete: %w", err)
+		}
 	}
 
 	return nil
@@ -271,34 +255,103 @@ func (i *Install) deleteFile(path string) error {
 
 // Upgrade the service
 func (i *Install) upgradeService() error {
-	var err error
 
-	fmt.Println("Uninstalling existing agent...")
+	// Ensure that services created by earlier versions have the current recovery options
+	err := configureService()
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
 
-	// Remove the existing executable
-	err = i.uninstallService(false)
+	// Register the event log source and user-helper for agents installed by earlier versions
+	if err = ulogger.InstallEventSource(global.LogName); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	targetDir := filepath.Join(os.Getenv("ProgramFiles"), global.Name)
+	targetPath := filepath.Join(targetDir, global.WindowsBinaryName)
+	if err = addUserHelper(targetPath); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	return i.upgradeInPlace(targetPath)
+}
+
+// addUserHelper adds the user-helper to the Run key so that it starts in the context of each
+// user who logs in
+func addUserHelper(targetPath string) error {
+	key, _, err := registry.CreateKey(registry.LOCAL_MACHINE, runKeyPath, registry.SET_VALUE)
 	if err != nil {
-		return fmt.Errorf("could not remove existing service: %w", err)
+		return fmt.Errorf("error opening Run key: %w", err)
 	}
+	defer func() { _ = key.Close() }()
 
-	// Delay for two seconds to allow the system to release the file
-	time.Sleep(1 * time.Second)
+	err = key.SetStringValue(runValueName, fmt.Sprintf(`"%s" %s`, targetPath, global.UserHelperFlag))
+	if err != nil {
+		return fmt.Errorf("error adding user-helper to Run key: %w", err)
+	}
 
-	fmt.Println("\nInstalling new agent...")
+	fmt.Println("User-helper will start when users log in")
+	return nil
+}
+
+// removeUserHelper removes the user-helper from the Run key and stops any user-helpers that
+// are running
+func removeUserHelper() {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, runKeyPath, registry.SET_VALUE)
+	if err == nil {
+		err = key.DeleteValue(runValueName)
+		if err != nil && err != registry.ErrNotExist {
+			fmt.Printf("Warning: could not remove user-helper from Run key: %v\n", err)
+		}
+		_ = key.Close()
+	}
 
-	// Install the new service
-	return i.installService()
+	stopUserHelpers()
+}
+
+// stopUserHelpers terminates the user-helpers, which are any other processes running the
+// agent binary. The installer and the process that launched it are skipped.
+func stopUserHelpers() {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return
+	}
+	defer func() { _ = windows.CloseHandle(snapshot) }()
+
+	self := uint32(os.Getpid())
+	parent := uint32(os.Getppid())
+
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		if entry.ProcessID == self || entry.ProcessID == parent ||
+			!strings.EqualFold(windows.UTF16ToString(entry.ExeFile[:]), global.WindowsBinaryName) {
+			continue
+		}
+
+		process, err := windows.OpenProcess(windows.PROCESS_TERMINATE, false, entry.ProcessID)
+		if err != nil {
+			continue
+		}
+		_ = windows.TerminateProcess(process, 0)
+		_ = windows.CloseHandle(process)
+	}
 }
 
 // CheckAdmin checks if the current process is running with administrator privileges,
 // and if not, it attempts to restart the process with administrator privileges.
-func CheckAdmin() error {
+// If elevate is false or there is no console to show the prompt, it returns
+// ErrNotPrivileged instead.
+func CheckAdmin(elevate bool) error {
 	admin, err := privcheck.Check()
 	if err != nil {
 		return err
 	}
 
 	if !admin {
+		if !elevate || !interactive() {
+			return ErrNotPrivileged
+		}
 		fmt.Println("Not running as admin, attempting to restart with admin privileges...")
 		err := runAsAdmin()
 		if err != nil {
@@ -345,8 +398,8 @@ func runAsAdmin() error {
 }
 
 // CheckRootPrivileges is an alias for checkAdmin for compatibility
-func CheckRootPrivileges() error {
-	return CheckAdmin()
+func CheckRootPrivileges(elevate bool) error {
+	return CheckAdmin(elevate)
 }
 
 // stopService stops the service
@@ -440,3 +493,122 @@ func (i *Install) restartService() error {
 
 	return i.startService()
 }
+
+// serviceStatus returns a description of the service state for Check
+func (i *Install) serviceStatus() string {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Sprintf("error connecting to service manager: %v", err)
+	}
+	defer func(m *mgr.Mgr) {
+		_ = m.Disconnect()
+	}(m)
+
+	service, err := m.OpenService(global.Name)
+	if err != nil {
+		return "not installed"
+	}
+	defer func(service *mgr.Service) {
+		_ = service.Close()
+	}(service)
+
+	status, err := service.Query()
+	if err != nil {
+		return fmt.Sprintf("error querying service: %v", err)
+	}
+
+	switch status.State {
+	case svc.Running:
+		return "running"
+	case svc.Stopped:
+		return "stopped"
+	case svc.StartPending:
+		return "starting"
+	case svc.StopPending:
+		return "stopping"
+	default:
+		return fmt.Sprintf("state %d", status.State)
+	}
+}
+
+// serviceInstalled returns true if the service is registered with the service manager
+func serviceInstalled() bool {
+	m, err := mgr.Connect()
+	if err != nil {
+		return false
+	}
+	defer func(m *mgr.Mgr) {
+		_ = m.Disconnect()
+	}(m)
+
+	service, err := m.OpenService(global.Name)
+	if err != nil {
+		return false
+	}
+	_ = service.Close()
+	return true
+}
+
+// serviceRunning returns true if the service manager reports the service as running
+func serviceRunning() bool {
+	m, err := mgr.Connect()
+	if err != nil {
+		return false
+	}
+	defer func(m *mgr.Mgr) {
+		_ = m.Disconnect()
+	}(m)
+
+	service, err := m.OpenService(global.Name)
+	if err != nil {
+		return false
+	}
+	defer func(service *mgr.Service) {
+		_ = service.Close()
+	}(service)
+
+	status, err := service.Query()
+	return err == nil && status.State == svc.Running
+}
+
+// serviceRecovery returns a description of the recovery actions for Check
+func (i *Install) serviceRecovery() string {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Sprintf("error connecting to service manager: %v", err)
+	}
+	defer func(m *mgr.Mgr) {
+		_ = m.Disconnect()
+	}(m)
+
+	service, err := m.OpenService(global.Name)
+	if err != nil {
+		return "not installed"
+	}
+	defer func(service *mgr.Service) {
+		_ = service.Close()
+	}(service)
+
+	return winsvcutil.RecoveryActions(service)
+}
+
+// configureService sets the description, delayed start, and failure actions on the installed service
+func configureService() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("error connecting to service manager: %w", err)
+	}
+	defer func(m *mgr.Mgr) {
+		_ = m.Disconnect()
+	}(m)
+
+	service, err := m.OpenService(global.Name)
+	if err != nil {
+		return fmt.Errorf("error opening service: %w", err)
+	}
+	defer func(service *mgr.Service) {
+		_ = service.Close()
+	}(service)
+
+	return winsvcutil.Configure(service.Handle, global.Description)
+}
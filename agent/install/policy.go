@@ -0,0 +1,99 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+)
+
+// Policy displays or changes the locally pinned command policy. The pinned policy applies in
+// addition to disabled_commands and exec_allowed_paths from the server, which can't relax it.
+// The service uses the new settings when it is restarted.
+//
+//	policy                                   display the settings
+//	policy disable <command>[,<command>...]  refuse the listed commands
+//	policy allow-paths <path>[,<path>...]    only run execute and download_execute programs under the listed paths
+//	policy clear                             remove the pinned policy
+func (i *Install) Policy(args []string) error {
+	if len(args) == 0 {
+		i.showPolicy()
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "disable":
+		if len(args) != 2 {
+			return errors.New("usage: policy disable <command>[,<command>...]")
+		}
+		list := splitList(args[1])
+		for _, name := range list {
+			if commands.ValidateCmd(name) != nil {
+				return fmt.Errorf("unknown command %q", name)
+			}
+		}
+		i.config.AP.Set(global.ConfigPolicyDisabled, strings.Join(list, ","))
+
+	case "allow-paths":
+		if len(args) != 2 {
+			return errors.New("usage: policy allow-paths <path>[,<path>...]")
+		}
+		list := splitList(args[1])
+		for _, path := range list {
+			if !filepath.IsAbs(path) {
+				return fmt.Errorf("%q is not an absolute path", path)
+			}
+		}
+		i.config.AP.Set(global.ConfigPolicyExecPaths, strings.Join(list, ","))
+
+	case "clear":
+		i.config.AP.Set(global.ConfigPolicyDisabled, "")
+		i.config.AP.Set(global.ConfigPolicyExecPaths, "")
+
+	default:
+		return fmt.Errorf("unknown policy command: %s", args[0])
+	}
+
+	err := i.config.Checkpoint()
+	if err != nil {
+		return fmt.Errorf("error saving configuration: %w", err)
+	}
+	i.logger.Info(8618, "pinned command policy changed", nil)
+	i.showPolicy()
+	fmt.Println("\nRestart the service for the changes to take effect")
+	return nil
+}
+
+func (i *Install) showPolicy() {
+	show := func(value string) string {
+		if value == "" {
+			return "none"
+		}
+		return value
+	}
+
+	fmt.Printf("Pinned disabled commands: %s\n", show(i.config.AP.Get(global.ConfigPolicyDisabled).String()))
+	fmt.Printf("Pinned allowed paths:     %s\n", show(i.config.AP.Get(global.ConfigPolicyExecPaths).String()))
+	fmt.Printf("Server disabled commands: %s\n", show(i.config.AC.Get(schema.ConfigAgentDisabledCommands).String()))
+	fmt.Printf("Server allowed paths:     %s\n", show(i.config.AC.Get(schema.ConfigAgentExecAllowedPaths).String()))
+}
+
+// splitList splits a comma-separated list, ignoring empty items
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,84 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+// Proxy displays or changes the proxy used to connect to the server. The running service
+// uses the new settings from its next connection.
+//
+//	proxy                            display the settings
+//	proxy set <url> [<user> <pass>]  use the specified proxy, optionally with basic authentication
+//	proxy clear                      remove the configured proxy
+//	proxy system on|off              whether to use the environment and OS settings without a configured proxy
+func (i *Install) Proxy(args []string) error {
+	if len(args) == 0 {
+		i.showProxy()
+		return nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "set":
+		if len(args) != 2 && len(args) != 4 {
+			return errors.New("usage: proxy set <url> [<user> <password>]")
+		}
+		u, err := url.Parse(args[1])
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid proxy URL %q, expected http://host:port", args[1])
+		}
+		i.config.AP.Set(global.ConfigAgentProxyURL, args[1])
+		i.config.AP.Set(global.ConfigAgentProxyUser, "")
+		i.config.AP.Set(global.ConfigAgentProxyPassword, "")
+		if len(args) == 4 {
+			i.config.AP.Set(global.ConfigAgentProxyUser, args[2])
+			i.config.AP.Set(global.ConfigAgentProxyPassword, args[3])
+		}
+
+	case "clear":
+		i.config.AP.Set(global.ConfigAgentProxyURL, "")
+		i.config.AP.Set(global.ConfigAgentProxyUser, "")
+		i.config.AP.Set(global.ConfigAgentProxyPassword, "")
+
+	case "system":
+		if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+			return errors.New("usage: proxy system on|off")
+		}
+		i.config.AP.Set(global.ConfigAgentProxyUseSystem, args[1] == "on")
+
+	default:
+		return fmt.Errorf("unknown proxy command: %s", args[0])
+	}
+
+	err := i.config.Checkpoint()
+	if err != nil {
+		return fmt.Errorf("error saving configuration: %w", err)
+	}
+	i.logger.Info(8615, "proxy configuration changed", nil)
+	i.showProxy()
+	return nil
+}
+
+func (i *Install) showProxy() {
+	proxyURL := i.config.AP.Get(global.ConfigAgentProxyURL).String()
+	if proxyURL == "" {
+		proxyURL = "none"
+	}
+	user := i.config.AP.Get(global.ConfigAgentProxyUser).String()
+	if user == "" {
+		user = "none"
+	}
+
+	fmt.Printf("Proxy URL:    %s\n", proxyURL)
+	fmt.Printf("Proxy user:   %s\n", user)
+	fmt.Printf("System proxy: %v\n", i.config.AP.Get(global.ConfigAgentProxyUseSystem).Bool())
+}
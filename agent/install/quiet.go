@@ -0,0 +1,111 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Installer packages such as MSI and PKG run "install --quiet", which never prompts or restarts
+// itself with elevated privileges, reports each step as a line of JSON on stdout, and exits with
+// one of the codes below so that the package can report why an installation failed.
+
+// Exit codes for a quiet installation
+const (
+	ExitOK               = 0
+	ExitFailed           = 1  // Any error without a more specific code
+	ExitAlreadyInstalled = 10 // The agent is installed and registered
+	ExitRegistration     = 11 // The server could not be reached or rejected the registration
+	ExitInvalidKey       = 12 // The installation key is missing or malformed
+	ExitServiceStart     = 13 // The service was installed but did not start
+	ExitNotPrivileged    = 14 // Not running as root or administrator
+)
+
+var (
+	ErrAlreadyInstalled = errors.New("the agent is already installed")
+	ErrRegistration     = errors.New("registration failed")
+	ErrInvalidKey       = errors.New("invalid installation token")
+	ErrServiceStart     = errors.New("could not start service")
+	ErrNotPrivileged    = errors.New("root or administrator privileges are required")
+)
+
+// Steps reported by a quiet installation
+const (
+	StepPrivileges = "privileges"
+	StepKey        = "key"
+	StepBinary     = "binary"
+	StepService    = "service"
+	StepRegister   = "register"
+	StepStart      = "start"
+	StepDone       = "done"
+)
+
+// Progress is a line of the output of a quiet installation
+type Progress struct {
+	Step     string `json:"step"`
+	Status   string `json:"status"` // "ok" or "failed"
+	Message  string `json:"message,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+}
+
+// WithQuiet prevents prompts and the repair of an existing installation, and writes
+// progress to w (optional)
+func WithQuiet(w io.Writer) Option {
+	return func(i *Install) {
+		i.quiet = true
+		i.progress = w
+	}
+}
+
+// ExitCode returns the exit code of a quiet installation that returned err
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrAlreadyInstalled):
+		return ExitAlreadyInstalled
+	case errors.Is(err, ErrInvalidKey):
+		return ExitInvalidKey
+	case errors.Is(err, ErrRegistration):
+		return ExitRegistration
+	case errors.Is(err, ErrServiceStart):
+		return ExitServiceStart
+	case errors.Is(err, ErrNotPrivileged):
+		return ExitNotPrivileged
+	}
+	return ExitFailed
+}
+
+// Report writes the result of a step to w. If err is not nil, the step failed and the
+// exit code is included.
+func Report(w io.Writer, step string, err error) {
+	p := Progress{Step: step, Status: "ok"}
+	if err != nil {
+		p.Status = "failed"
+		p.Message = err.Error()
+		p.ExitCode = ExitCode(err)
+	}
+
+	data, _ := json.Marshal(p)
+	_, _ = w.Write(append(data, '\n'))
+}
+
+// report writes the completion of a step if progress is enabled
+func (i *Install) report(step string) {
+	if i.progress != nil {
+		Report(i.progress, step, nil)
+	}
+}
+
+// interactive returns true if the user can be prompted, which requires a terminal
+func interactive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
@@ -0,0 +1,101 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, ExitOK},
+		{errors.New("something else"), ExitFailed},
+		{fmt.Errorf("%w as agent A-1234", ErrAlreadyInstalled), ExitAlreadyInstalled},
+		{fmt.Errorf("failed to create service account: %w", fmt.Errorf("%w: timeout", ErrRegistration)), ExitRegistration},
+		{fmt.Errorf("%w: missing server URL", ErrInvalidKey), ExitInvalidKey},
+		{fmt.Errorf("%w: unit failed", ErrServiceStart), ExitServiceStart},
+		{ErrNotPrivileged, ExitNotPrivileged},
+	}
+	for _, tt := range tests {
+		if code := ExitCode(tt.err); code != tt.code {
+			t.Errorf("%v: expected exit code %d, got %d", tt.err, tt.code, code)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	var buf bytes.Buffer
+	Report(&buf, StepBinary, nil)
+	Report(&buf, StepDone, fmt.Errorf("%w: unit failed", ErrServiceStart))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per step, got %q", buf.String())
+	}
+
+	var p Progress
+	if err := json.Unmarshal(lines[0], &p); err != nil || p != (Progress{Step: StepBinary, Status: "ok"}) {
+		t.Errorf("unexpected progress %+v %v", p, err)
+	}
+	p = Progress{}
+	if err := json.Unmarshal(lines[1], &p); err != nil || p.Status != "failed" || p.ExitCode != ExitServiceStart || p.Message == "" {
+		t.Errorf("unexpected failure %+v %v", p, err)
+	}
+}
+
+func TestQuietInstall(t *testing.T) {
+	// The key is checked before anything is installed
+	var buf bytes.Buffer
+	i := newTestInstall(t, "", WithQuiet(&buf), WithToken("not a token"))
+	if err := i.Install(); ExitCode(err) != ExitInvalidKey {
+		t.Errorf("expected an invalid key, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no progress, got %q", buf.String())
+	}
+}
+
+func TestReadKeyFile(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := ReadKeyFile(filepath.Join(dir, "missing")); ExitCode(err) != ExitInvalidKey {
+		t.Errorf("expected an invalid key for a missing file, got %v", err)
+	}
+
+	for content, token := range map[string]string{
+		"https://uem.example.com/abc123\n":                                   "https://uem.example.com/abc123",
+		`{"install_key": "abc123", "server_url": "https://uem.example.com"}`: "https://uem.example.com/abc123",
+	} {
+		path := filepath.Join(dir, "key")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		b, err := ReadKeyFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, err := b.Token(); err != nil || got != token {
+			t.Errorf("expected %q, got %q %v", token, got, err)
+		}
+
+		// The file belongs to the installer and is left in place
+		if err = b.Remove(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = os.Stat(path); err != nil {
+			t.Errorf("key file was removed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+// identityKeys are the settings that identify a registered agent. They are removed before a
+// machine is imaged so that every machine created from the image registers as a new agent.
+var identityKeys = []string{
+	global.ConfigAgentID,
+	global.ConfigRefreshToken,
+	global.ConfigServerURL,
+	global.ConfigServerPublicSig,
+	global.ConfigServerPublicEnc,
+	global.ConfigAgentECPrivateSig,
+	global.ConfigAgentECPublicSig,
+	global.ConfigAgentECPrivateEnc,
+	global.ConfigAgentECPublicEnc,
+	global.ConfigRecoveryPublicKeyHash,
+	global.ConfigRecoveryInfoPending,
+	global.ConfigFriendlyName,
+	global.ConfigLost,
+	global.ConfigUpgradeRequestID,
+	global.ConfigUpgradeSuccess,
+	global.ConfigUpgradeResult,
+	global.ConfigLastSync,
+	global.ConfigLastSyncRequests,
+	global.ConfigLastSyncResponses,
+}
+
+// ResetForImaging prepares an installed agent to be captured in a machine image. The service
+// is stopped and the agent identity, including its keys, is removed. The installation token
+// is kept and marked as unused, so each machine created from the image generates new keys and
+// registers when the service starts and the server can be reached.
+func (i *Install) ResetForImaging() error {
+	if i.config.AP.Get(global.ConfigRegToken).String() == "" {
+		return errors.New("no installation token is stored, use rekey <token> first")
+	}
+
+	// The service may already be stopped
+	err := i.stopService()
+	if err != nil {
+		fmt.Printf("Warning: could not stop the service: %v\n", err)
+	}
+
+	for _, key := range identityKeys {
+		i.config.AP.Delete(key)
+	}
+	i.config.AP.Set(global.ConfigRegDeferred, true)
+
+	// Remove the backup so that the identity is not restored when the config is loaded
+	err = global.DeleteBackup()
+	if err != nil {
+		return fmt.Errorf("could not remove identity backup: %w", err)
+	}
+
+	err = i.config.Checkpoint()
+	if err != nil {
+		return fmt.Errorf("error saving configuration: %w", err)
+	}
+
+	i.logger.Info(8617, "agent identity removed for imaging", nil)
+	return nil
+}
@@ -106,7 +106,7 @@ func (i *Install) sendServiceCredentialsToServer() error {
 	i.logger.Info(8420, "syncing with server to complete registration", nil)
 	err = i.syncWithRetry(comms, "registration", responseQueue)
 	if err != nil {
-		return fmt.Errorf("failed to complete registration sync: %w", err)
+		return fmt.Errorf("%w, could not complete sync: %w", ErrRegistration, err)
 	}
 
 	// Verify we have server public key
@@ -0,0 +1,180 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package install
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+// upgradeVerifyDelay is how long the new binary must keep running before the upgrade is considered successful
+const upgradeVerifyDelay = 15 * time.Second
+
+// upgradeInPlace replaces the installed binary with this executable and restarts the service.
+// The existing binary is kept as a .old backup and is restored if the new binary fails to start.
+// The service registration is left untouched so that settings such as failure actions are retained.
+func (i *Install) upgradeInPlace(target string) error {
+
+	// Get the path of the new binary
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not find executable path: %w", err)
+	}
+
+	if exePath == target {
+		return fmt.Errorf("upgrade must be run from the new binary, not %s", target)
+	}
+
+	// If the agent is not installed, perform a normal installation
+	info, err := os.Stat(target)
+	if os.IsNotExist(err) {
+		fmt.Printf("%s not found, installing new agent...\n", target)
+		return i.installService()
+	}
+	if err != nil {
+		return fmt.Errorf("could not access %s: %w", target, err)
+	}
+
+	// Stop the service so the binary can be replaced
+	err = i.stopService()
+	if err != nil {
+		return fmt.Errorf("could not stop service: %w", err)
+	}
+
+	// Delay for two seconds to allow the system to release the file
+	time.Sleep(2 * time.Second)
+
+	// Keep the existing binary so that it can be restored
+	backup := target + ".old"
+	_ = os.Remove(backup)
+	err = os.Rename(target, backup)
+	if err != nil {
+		_ = i.startService()
+		return fmt.Errorf("could not back up %s: %w", target, err)
+	}
+
+	err = i.replaceBinary(exePath, target, info.Mode().Perm())
+	if err != nil {
+		return i.rollback(target, backup, err)
+	}
+	fmt.Printf("Binary copied to %s\n", target)
+
+	// Record success before starting so that the new agent reports it
+	i.recordUpgradeResult(true, fmt.Sprintf("upgraded to version %s (build %d)", global.Version, global.Build))
+
+	err = i.startService()
+	if err == nil {
+		err = waitForService()
+	}
+	if err != nil {
+		return i.rollback(target, backup, err)
+	}
+
+	_ = os.Remove(backup)
+	return nil
+}
+
+// replaceBinary copies the new binary into place and confirms that it runs
+func (i *Install) replaceBinary(src, dst string, mode os.FileMode) error {
+	err := copyFile(src, dst)
+	if err != nil {
+		return fmt.Errorf("error copying file %s to %s: %w", src, dst, err)
+	}
+
+	if runtime.GOOS != "windows" {
+		err = os.Chmod(dst, mode)
+		if err != nil {
+			return fmt.Errorf("could not set permissions on %s: %w", dst, err)
+		}
+	}
+
+	return checkVersion(dst)
+}
+
+// rollback restores the previous binary after a failed upgrade and restarts the service.
+// The returned error always describes the failed upgrade.
+func (i *Install) rollback(target, backup string, cause error) error {
+	i.logger.Errorf(8609, "upgrade failed, restoring previous version: %s", cause.Error())
+	fmt.Printf("Upgrade failed, restoring previous version: %v\n", cause)
+
+	// The new binary may be running or restarting
+	_ = i.stopService()
+
+	_ = os.Remove(target)
+	err := os.Rename(backup, target)
+	if err != nil {
+		return fmt.Errorf("upgrade failed: %w; could not restore %s: %v", cause, backup, err)
+	}
+
+	i.recordUpgradeResult(false, fmt.Sprintf("upgrade to version %s (build %d) failed, previous version restored: %s",
+		global.Version, global.Build, cause.Error()))
+
+	err = i.startService()
+	if err != nil {
+		return fmt.Errorf("upgrade failed: %w; previous version restored but could not be started: %v", cause, err)
+	}
+
+	i.logger.Info(8610, "previous version restored after failed upgrade", nil)
+	return fmt.Errorf("upgrade failed, previous version restored: %w", cause)
+}
+
+// recordUpgradeResult saves the outcome of the upgrade so that the agent reports it to the server
+// when it starts. It must only be called while the service is stopped so that the service does not
+// overwrite the configuration.
+func (i *Install) recordUpgradeResult(success bool, details string) {
+	if i.requestID == "" {
+		return
+	}
+
+	// Reload the configuration because the service may have changed it since this process started
+	conf, err := global.Config()
+	if err != nil {
+		i.logger.Errorf(8611, "unable to load configuration to record upgrade result: %s", err.Error())
+		return
+	}
+
+	conf.AP.Set(global.ConfigUpgradeRequestID, i.requestID)
+	conf.AP.Set(global.ConfigUpgradeSuccess, success)
+	conf.AP.Set(global.ConfigUpgradeResult, details)
+
+	err = conf.Checkpoint()
+	if err != nil {
+		i.logger.Errorf(8612, "unable to record upgrade result: %s", err.Error())
+		return
+	}
+	i.config = conf
+}
+
+// checkVersion runs the binary to confirm that it executes and reports the expected version
+func checkVersion(path string) error {
+	out, err := exec.Command(path, "version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed to run: %w", path, err)
+	}
+
+	expected := fmt.Sprintf("version %s (build %d)", global.Version, global.Build)
+	if !strings.Contains(string(out), expected) {
+		return fmt.Errorf("%s did not report %s", path, expected)
+	}
+	return nil
+}
+
+// waitForService confirms that the service is still running after upgradeVerifyDelay
+func waitForService() error {
+	fmt.Printf("Verifying that the service is running...\n")
+	time.Sleep(upgradeVerifyDelay)
+
+	if !serviceRunning() {
+		return fmt.Errorf("service is not running %v after starting", upgradeVerifyDelay)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/agent/install"
+)
+
+// progress receives the machine-readable output of install --quiet. It is nil otherwise.
+var progress io.Writer
+
+// quietInstall returns true if the command is install --quiet. In this mode progress is
+// reported on stdout, so the caller redirects everything else to stderr.
+func quietInstall() bool {
+	if len(os.Args) < 2 || strings.ToLower(os.Args[1]) != "install" {
+		return false
+	}
+	for _, arg := range os.Args[2:] {
+		if strings.ToLower(arg) == "--quiet" {
+			return true
+		}
+	}
+	return false
+}
+
+// installCommand installs the agent and returns the exit code
+func installCommand() int {
+	// Remove the options so that they do not affect the positional arguments
+	args := make([]string, 0, len(os.Args))
+	force := false
+	deferReg := false
+	keyFile := ""
+	for n := 0; n < len(os.Args); n++ {
+		switch strings.ToLower(os.Args[n]) {
+		case "--force":
+			force = true
+			continue
+		case "--defer-registration":
+			deferReg = true
+			continue
+		case "--quiet":
+			continue
+		case "--key-from-file":
+			if n+1 >= len(os.Args) {
+				return installFailed(errors.New("--key-from-file requires a file name"))
+			}
+			n++
+			keyFile = os.Args[n]
+			continue
+		}
+		args = append(args, os.Args[n])
+	}
+
+	// Without an installation key on the command line, use one provided by a deployment tool.
+	// It takes the place of the first argument.
+	var bootstrap *install.Bootstrap
+	var err error
+	switch {
+	case keyFile != "":
+		bootstrap, err = install.ReadKeyFile(keyFile)
+	case len(args) < 3:
+		bootstrap, err = install.ReadBootstrap()
+		if err == nil && bootstrap == nil {
+			usage()
+			err = fmt.Errorf("%w: the installation key is required, either as an argument or in %s",
+				install.ErrInvalidKey, install.BootstrapFile())
+		}
+	}
+	if err != nil {
+		return installFailed(err)
+	}
+
+	if bootstrap != nil {
+		token, err := bootstrap.Token()
+		if err != nil {
+			return installFailed(err)
+		}
+		fmt.Printf("Using the installation key from %s\n", bootstrap.Source())
+		args = append(args[:2], append([]string{token}, args[2:]...)...)
+	}
+
+	ops := []install.Option{
+		install.WithConfig(conf),
+		install.WithLogger(logger),
+		install.WithToken(args[2]),
+	}
+
+	if runtime.GOOS == "darwin" {
+		// macOS: credentials are optional on the command line; if omitted the
+		// installer will prompt interactively. Friendly name is always optional.
+		switch len(args) {
+		case 4:
+			// friendly name only; credentials will be prompted
+			ops = append(ops, install.WithFriendlyName(args[3]))
+		case 5:
+			// credentials only
+			ops = append(ops, install.WithCredentials(args[3], args[4]))
+		case 6:
+			// credentials + friendly name
+			ops = append(ops, install.WithCredentials(args[3], args[4]))
+			ops = append(ops, install.WithFriendlyName(args[5]))
+		default:
+			if len(args) > 6 {
+				fmt.Println("Usage: install <token> [<admin-username> <admin-password> [<friendly-name>]]")
+				return installFailed(errors.New("too many arguments"))
+			}
+		}
+	} else {
+		// Linux/Windows: no credentials required or accepted
+		switch len(args) {
+		case 4:
+			// friendly name only
+			ops = append(ops, install.WithFriendlyName(args[3]))
+		default:
+			if len(args) > 4 {
+				fmt.Println("Usage: install <token> [<friendly-name>]")
+				return installFailed(errors.New("too many arguments"))
+			}
+		}
+	}
+
+	if force {
+		ops = append(ops, install.WithForce())
+	}
+
+	if deferReg {
+		ops = append(ops, install.WithDeferRegistration())
+	}
+
+	if progress != nil {
+		ops = append(ops, install.WithQuiet(progress))
+	}
+
+	// Instantiate installer
+	installer, err := install.New(ops...)
+	if err != nil {
+		return installFailed(err)
+	}
+
+	err = installer.Install()
+	if err != nil {
+		return installFailed(err)
+	}
+	fmt.Println("\nService installed successfully")
+	_ = conf.Checkpoint()
+
+	// Remove the bootstrap file so that the installation key does not remain on the disk
+	if bootstrap != nil {
+		if err = bootstrap.Remove(); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	if progress != nil {
+		install.Report(progress, install.StepDone, nil)
+	}
+	return 0
+}
+
+// installFailed displays err, reports it if the installation is quiet, and returns the exit code
+func installFailed(err error) int {
+	fmt.Printf("Installation failed: %v\n", err)
+	if progress != nil {
+		install.Report(progress, install.StepDone, err)
+	}
+	return install.ExitCode(err)
+}
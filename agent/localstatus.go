@@ -0,0 +1,85 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/localstatus"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+var localStatusServer *localstatus.Server
+
+// initLocalStatus starts the read-only status interface for local tools
+func initLocalStatus(log interfaces.Logger) {
+	localStatusServer = localstatus.New(log, localStatus, func() bool {
+		return conf.AC.Get(schema.ConfigAgentLocalStatus).Bool()
+	})
+	if err := localStatusServer.Start(); err != nil {
+		log.Errorf(3305, "Failed to start local status interface: %v", err)
+		localStatusServer = nil
+	}
+}
+
+// cleanupLocalStatus stops the local status interface
+func cleanupLocalStatus(log interfaces.Logger) {
+	if localStatusServer != nil {
+		if err := localStatusServer.Stop(); err != nil {
+			log.Errorf(3306, "Error stopping local status interface: %v", err)
+		}
+	}
+}
+
+// localStatus returns the status provided to local tools. It must not include secrets.
+func localStatus() localstatus.Status {
+	agentID := conf.AP.Get(global.ConfigAgentID).String()
+	return localstatus.Status{
+		Version:          global.Version,
+		Build:            global.Build,
+		AgentID:          localstatus.TruncateAgentID(agentID),
+		Registered:       agentID != "",
+		LastSync:         conf.AP.Get(global.ConfigLastSync).Int64(),
+		PendingRequests:  requestQueue.Size(),
+		PendingResponses: responseQueue.Size(),
+		Lost:             global.Lost,
+		Time:             time.Now().Unix(),
+	}
+}
+
+// printLocalStatus reads the status from the running agent and prints a summary. It does not
+// require elevated privileges.
+func printLocalStatus() int {
+	status, err := localstatus.Read(5 * time.Second)
+	if err != nil {
+		fmt.Printf("UEM agent is not responding: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("UEM agent version %s (build %d)\n", status.Version, status.Build)
+	if !status.Registered {
+		fmt.Println("Agent is not registered")
+	} else {
+		fmt.Printf("Agent ID:          %s...\n", status.AgentID)
+	}
+
+	if status.LastSync == 0 {
+		fmt.Println("Last sync:         never")
+	} else {
+		last := time.Unix(status.LastSync, 0)
+		fmt.Printf("Last sync:         %s (%s ago)\n", last.Format(time.RFC1123), time.Unix(status.Time, 0).Sub(last).Round(time.Second))
+	}
+
+	fmt.Printf("Pending requests:  %d\n", status.PendingRequests)
+	fmt.Printf("Pending responses: %d\n", status.PendingResponses)
+	if status.Lost {
+		fmt.Println("Lost mode:         enabled")
+	}
+	return 0
+}
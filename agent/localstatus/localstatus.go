@@ -0,0 +1,186 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+// Package localstatus provides a read-only status interface for local tools, such as a
+// helpdesk application, that run without administrative rights. The agent listens on a Unix
+// socket, or a named pipe on Windows, and writes a summary of its state to each connection
+// without reading anything from it. No secrets are included.
+package localstatus
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+)
+
+const (
+	// Connections are limited to ratePerSecond, with bursts of up to rateBurst
+	ratePerSecond = 5
+	rateBurst     = 10
+
+	// maxStatusSize limits how much a client reads
+	maxStatusSize = 4096
+
+	writeTimeout = 2 * time.Second
+)
+
+// Status is the information provided to local tools
+type Status struct {
+	Version          string `json:"version"`
+	Build            int    `json:"build"`
+	AgentID          string `json:"agent_id,omitempty"` // truncated, enough to identify the agent to a helpdesk
+	Registered       bool   `json:"registered"`
+	LastSync         int64  `json:"last_sync"` // Unix time of the last successful sync, 0 if none
+	PendingRequests  int    `json:"pending_requests"`
+	PendingResponses int    `json:"pending_responses"`
+	Lost             bool   `json:"lost"`
+	Time             int64  `json:"time"` // Unix time the status was generated
+}
+
+// TruncateAgentID returns the agent ID prefix and the first group of the UUID
+func TruncateAgentID(id string) string {
+	if len(id) > 10 {
+		return id[:10]
+	}
+	return id
+}
+
+// Server writes the agent's status to local clients
+type Server struct {
+	logger   interfaces.Logger
+	status   func() Status
+	enabled  func() bool
+	listener net.Listener
+	running  atomic.Bool
+	limiter  limiter
+}
+
+// New creates a Server. status returns the current status and enabled reports whether the
+// interface is enabled, which is checked for each connection so that it follows the agent's
+// configuration.
+func New(logger interfaces.Logger, status func() Status, enabled func() bool) *Server {
+	return &Server{
+		logger:  logger,
+		status:  status,
+		enabled: enabled,
+		limiter: limiter{tokens: rateBurst, last: time.Now()},
+	}
+}
+
+// Start begins listening on the status socket, or named pipe on Windows
+func (s *Server) Start() error {
+	listener, err := listen()
+	if err != nil {
+		return err
+	}
+
+	s.listener = listener
+	s.running.Store(true)
+	s.logger.Infof(3300, "Local status interface started on %s", global.StatusSocketPath)
+
+	go s.acceptLoop()
+	return nil
+}
+
+// acceptLoop handles incoming connections
+func (s *Server) acceptLoop() {
+	for s.running.Load() {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if s.running.Load() {
+				s.logger.Errorf(3301, "Error accepting local status connection: %v", err)
+			}
+			continue
+		}
+		go s.handleConnection(conn)
+	}
+}
+
+// handleConnection writes the status to a client. Nothing is read from the connection.
+func (s *Server) handleConnection(conn net.Conn) {
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	if !s.enabled() {
+		return
+	}
+
+	if !s.limiter.allow() {
+		s.logger.Debugf(3302, "Local status request rejected by rate limit")
+		return
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := json.NewEncoder(conn).Encode(s.status()); err != nil {
+		s.logger.Debugf(3303, "Error writing local status: %v", err)
+	}
+}
+
+// Stop closes the listener
+func (s *Server) Stop() error {
+	s.running.Store(false)
+
+	if s.listener != nil {
+		if err := s.listener.Close(); err != nil {
+			return err
+		}
+	}
+
+	removeSocket()
+	s.logger.Infof(3304, "Local status interface stopped")
+	return nil
+}
+
+// Read connects to the agent's status interface and returns the status
+func Read(timeout time.Duration) (Status, error) {
+	var status Status
+
+	conn, err := dial(timeout)
+	if err != nil {
+		return status, err
+	}
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	err = json.NewDecoder(io.LimitReader(conn, maxStatusSize)).Decode(&status)
+	if errors.Is(err, io.EOF) {
+		return status, errors.New("the agent did not provide its status (the interface may be disabled or busy)")
+	}
+	return status, err
+}
+
+// limiter is a token bucket shared by all clients
+type limiter struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// allow consumes a token if one is available
+func (l *limiter) allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = math.Min(rateBurst, l.tokens+now.Sub(l.last).Seconds()*ratePerSecond)
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,56 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package localstatus
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+)
+
+func TestLocalStatus(t *testing.T) {
+	var enabled atomic.Bool
+	enabled.Store(true)
+
+	s := New(null.Logger(), func() Status {
+		return Status{Version: "0.0.60", Build: 108, AgentID: TruncateAgentID("A-12345678-abcd-1234-5678-1234567890ab"), Registered: true, LastSync: 1700000000}
+	}, enabled.Load)
+	if err := s.Start(); err != nil {
+		t.Skipf("unable to start local status interface: %v", err)
+	}
+	defer func() { _ = s.Stop() }()
+
+	status, err := Read(time.Second)
+	if err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+	if status.Version != "0.0.60" || status.AgentID != "A-12345678" || status.LastSync != 1700000000 {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	// Nothing is provided while disabled
+	enabled.Store(false)
+	if _, err = Read(time.Second); err == nil {
+		t.Errorf("status provided while disabled")
+	}
+	enabled.Store(true)
+
+	// Bursts beyond the limit are refused
+	refused := false
+	for i := 0; i < rateBurst+5; i++ {
+		if _, err = Read(time.Second); err != nil {
+			refused = true
+			break
+		}
+	}
+	if !refused {
+		t.Errorf("expected requests to be rate limited")
+	}
+}
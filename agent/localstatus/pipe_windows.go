@@ -0,0 +1,34 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package localstatus
+
+import (
+	"net"
+	"time"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/npipe"
+)
+
+// pipeSDDL allows SYSTEM and administrators full access, and authenticated users to read
+const pipeSDDL = "D:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;GR;;;AU)"
+
+// listen creates the named pipe for the status interface. The agent can only write to it.
+func listen() (net.Listener, error) {
+	return npipe.Listen(global.StatusSocketPath, pipeSDDL, true)
+}
+
+// removeSocket does nothing because the pipe is removed when its last instance is closed
+func removeSocket() {}
+
+// dial connects to the status pipe for reading
+func dial(timeout time.Duration) (net.Conn, error) {
+	return npipe.Dial(global.StatusSocketPath, windows.GENERIC_READ, timeout)
+}
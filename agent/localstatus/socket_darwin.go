@@ -0,0 +1,44 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package localstatus
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+// listen creates the Unix socket for the status interface, which any local user can connect to
+func listen() (net.Listener, error) {
+	// Remove stale socket if it exists
+	_ = os.Remove(global.StatusSocketPath)
+
+	listener, err := net.Listen("unix", global.StatusSocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create status socket: %w", err)
+	}
+
+	if err = os.Chmod(global.StatusSocketPath, global.StatusSocketPerms); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to set status socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// removeSocket removes the socket file
+func removeSocket() {
+	_ = os.Remove(global.StatusSocketPath)
+}
+
+// dial connects to the status socket
+func dial(timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", global.StatusSocketPath, timeout)
+}
@@ -0,0 +1,34 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package localstatus
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+// listen creates the abstract Unix socket for the status interface. Abstract sockets have no
+// file permissions, so any local user can connect.
+func listen() (net.Listener, error) {
+	listener, err := net.Listen("unix", global.StatusSocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create status socket: %w", err)
+	}
+	return listener, nil
+}
+
+// removeSocket does nothing because abstract sockets are removed when they are closed
+func removeSocket() {}
+
+// dial connects to the status socket
+func dial(timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", global.StatusSocketPath, timeout)
+}
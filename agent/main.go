@@ -42,28 +42,46 @@ var lastSync int64
 var lastStatus int64
 var requestQueue *queues.RequestQueue
 var responseQueue *queues.ResponseQueue
+var cmdFunctions *functions.Command
 
 func main() {
 
-	// Check for version request
+	// Check for version and status requests, which do not require elevated privileges
 	if len(os.Args) == 2 {
-		if strings.ToLower(os.Args[1]) == "version" {
+		switch strings.ToLower(os.Args[1]) {
+		case "version":
 			common.Banner(global.Description, global.Version, global.Build)
 			exit(0, false)
+		case "status":
+			exit(printLocalStatus(), false)
 		}
 	}
 
-	// Check for user-helper mode (platform-specific, macOS only)
+	// Check for user-helper mode
 	if checkUserHelperMode() {
-		return // Will never reach here on macOS as it exits, but for clarity
+		return // Will never reach here as it exits, but for clarity
 	}
 
-	// Make sure this program is running with elevated privileges
-	err := install.CheckRootPrivileges()
+	// A quiet installation reports progress on stdout, everything else is written to stderr
+	if quietInstall() {
+		progress = os.Stdout
+		os.Stdout = os.Stderr
+	}
+
+	// Make sure this program is running with elevated privileges. Installer packages run
+	// without a terminal and must not be restarted with sudo or a UAC prompt.
+	err := install.CheckRootPrivileges(progress == nil)
 	if err != nil {
 		fmt.Printf("Unable to get root or admin privileges: %v\n", err)
+		if progress != nil {
+			install.Report(progress, install.StepPrivileges, err)
+			exit(install.ExitCode(err), false)
+		}
 		exit(1, true)
 	}
+	if progress != nil {
+		install.Report(progress, install.StepPrivileges, nil)
+	}
 
 	// check for foreground mode
 	if len(os.Args) > 1 && strings.ToLower(os.Args[1]) == "foreground" {
@@ -112,67 +130,7 @@ func console() int {
 	switch strings.ToLower(os.Args[1]) {
 
 	case "install":
-		if len(os.Args) < 3 {
-			fmt.Println("Installation key required")
-			usage()
-			return 1
-		}
-
-		ops := []install.Option{
-			install.WithConfig(conf),
-			install.WithLogger(logger),
-			install.WithToken(os.Args[2]),
-		}
-
-		if runtime.GOOS == "darwin" {
-			// macOS: credentials are optional on the command line; if omitted the
-			// installer will prompt interactively. Friendly name is always optional.
-			switch len(os.Args) {
-			case 4:
-				// friendly name only; credentials will be prompted
-				ops = append(ops, install.WithFriendlyName(os.Args[3]))
-			case 5:
-				// credentials only
-				ops = append(ops, install.WithCredentials(os.Args[3], os.Args[4]))
-			case 6:
-				// credentials + friendly name
-				ops = append(ops, install.WithCredentials(os.Args[3], os.Args[4]))
-				ops = append(ops, install.WithFriendlyName(os.Args[5]))
-			default:
-				if len(os.Args) > 6 {
-					fmt.Println("Usage: install <token> [<admin-username> <admin-password> [<friendly-name>]]")
-					return 1
-				}
-			}
-		} else {
-			// Linux/Windows: no credentials required or accepted
-			switch len(os.Args) {
-			case 4:
-				// friendly name only
-				ops = append(ops, install.WithFriendlyName(os.Args[3]))
-			default:
-				if len(os.Args) > 4 {
-					fmt.Println("Usage: install <token> [<friendly-name>]")
-					return 1
-				}
-			}
-		}
-
-		// Instantiate installer
-		installer, err = install.New(ops...)
-		if err != nil {
-			fmt.Printf("Fatal error instantiating installer: %v\n", err)
-			return 1
-		}
-
-		err = installer.Install()
-		if err != nil {
-			fmt.Printf("Installation failed: %v\n", err)
-			return 1
-		}
-		fmt.Println("\nService installed successfully")
-		_ = conf.Checkpoint()
-		return 0
+		return installCommand()
 
 	case "service-account":
 
@@ -237,6 +195,17 @@ func console() int {
 			return 1
 		}
 
+		if len(os.Args) > 2 && strings.ToLower(os.Args[2]) == "--for-imaging" {
+			err = installer.ResetForImaging()
+			if err != nil {
+				fmt.Printf("\nReset failed: %v\n", err)
+				return 1
+			}
+			fmt.Println("\nAgent identity removed, the image may now be captured")
+			fmt.Println("Machines created from the image register when the agent starts")
+			return 0
+		}
+
 		// Attempt to stop agent
 		err = installer.Stop()
 		if err != nil {
@@ -259,9 +228,29 @@ func console() int {
 		return 0
 
 	case "uninstall":
-		installer, err = install.New(
+		// Report completion on a best-effort basis. Agents that were never
+		// registered have no credentials and the message is simply not sent.
+		reporter, _ := communications.New(
+			communications.WithLogger(logger),
+			communications.WithConfig(conf))
+
+		installOptions := []install.Option{
 			install.WithConfig(conf),
-			install.WithLogger(logger))
+			install.WithLogger(logger),
+			install.WithBeforeRemove(func() {
+				if reporter != nil {
+					_ = reporter.SendUninstallStatus(schema.UninstallComplete)
+				}
+			})}
+
+		// Optionally preserve the configuration and data for a reinstall
+		for _, arg := range os.Args[2:] {
+			if strings.ToLower(arg) == "--keep-data" {
+				installOptions = append(installOptions, install.WithKeepData())
+			}
+		}
+
+		installer, err = install.New(installOptions...)
 
 		if err != nil {
 			fmt.Printf("Fatal error instantiating installer: %v\n", err)
@@ -281,10 +270,16 @@ func console() int {
 		fmt.Println("Waiting 30 seconds for the running service to send outstanding messages...")
 		time.Sleep(30 * time.Second)
 
-		// Upgrade the service
+		// Upgrade the service, the agent passes the ID of the upgrade request
+		var requestID string
+		if len(os.Args) > 2 {
+			requestID = os.Args[2]
+		}
+
 		installer, err = install.New(
 			install.WithConfig(conf),
-			install.WithLogger(logger))
+			install.WithLogger(logger),
+			install.WithRequestID(requestID))
 
 		if err != nil {
 			fmt.Printf("Fatal error instantiating installer: %v\n", err)
@@ -299,6 +294,40 @@ func console() int {
 		fmt.Println("\nService upgraded successfully")
 		return 0
 
+	case "proxy":
+		installer, err = install.New(
+			install.WithConfig(conf),
+			install.WithLogger(logger))
+
+		if err != nil {
+			fmt.Printf("Fatal error instantiating installer: %v\n", err)
+			return 1
+		}
+
+		err = installer.Proxy(os.Args[2:])
+		if err != nil {
+			fmt.Printf("Proxy configuration failed: %v\n", err)
+			return 1
+		}
+		return 0
+
+	case "policy":
+		installer, err = install.New(
+			install.WithConfig(conf),
+			install.WithLogger(logger))
+
+		if err != nil {
+			fmt.Printf("Fatal error instantiating installer: %v\n", err)
+			return 1
+		}
+
+		err = installer.Policy(os.Args[2:])
+		if err != nil {
+			fmt.Printf("Policy configuration failed: %v\n", err)
+			return 1
+		}
+		return 0
+
 	case "check":
 		installer, err = install.New(
 			install.WithConfig(conf),
@@ -309,7 +338,25 @@ func console() int {
 			return 1
 		}
 
-		installer.Check()
+		var jsonOutput, bundle bool
+		for _, arg := range os.Args[2:] {
+			switch strings.ToLower(arg) {
+			case "--json":
+				jsonOutput = true
+			case "--bundle":
+				bundle = true
+			default:
+				fmt.Printf("Unknown option: %s\n", arg)
+				usage()
+				return 1
+			}
+		}
+
+		err = installer.Check(jsonOutput, bundle)
+		if err != nil {
+			fmt.Printf("Check failed: %v\n", err)
+			return 1
+		}
 		return 0
 
 	default:
@@ -362,15 +409,20 @@ func usage() {
 	fmt.Printf("Usage: %s <command> <arguments>\n\n", os.Args[0])
 	fmt.Println("Commands:")
 
-	fmt.Printf("  check\n")
+	fmt.Printf("  check [--json] [--bundle]\n")
 
 	if runtime.GOOS == "darwin" {
-		fmt.Printf("  install <token> [<admin-username> <admin-password> [<friendly-name>]]\n")
+		fmt.Printf("  install <token> [<admin-username> <admin-password> [<friendly-name>]] [--force] [--defer-registration]\n")
 	} else {
-		fmt.Printf("  install <token> [<friendly-name>]\n")
+		fmt.Printf("  install <token> [<friendly-name>] [--force] [--defer-registration]\n")
 	}
+	fmt.Printf("  install [--force] [--defer-registration] (installation key from %s)\n", install.BootstrapFile())
+	fmt.Printf("  install --quiet [--key-from-file <path>] [--force] [--defer-registration]\n")
 
+	fmt.Printf("  policy [disable <command>[,...] | allow-paths <path>[,...] | clear]\n")
+	fmt.Printf("  proxy [set <url> [<user> <password>] | clear | system on|off]\n")
 	fmt.Printf("  rekey <token>\n")
+	fmt.Printf("  reset [--for-imaging]\n")
 
 	if runtime.GOOS == "darwin" {
 		fmt.Printf("  service-account <admin-username> <admin-password>\n")
@@ -378,15 +430,16 @@ func usage() {
 		fmt.Printf("  service-account\n")
 	}
 
-	fmt.Printf("  uninstall\n")
-	fmt.Printf("  upgrade\n")
+	fmt.Printf("  status\n")
+	fmt.Printf("  uninstall [--keep-data]\n")
+	fmt.Printf("  upgrade [<request_id>]\n")
 
 	fmt.Printf("  version\n")
 }
 
 func exit(code int, delay bool) {
 	if //goland:noinspection GoBoolExpressions
-	delay && global.ConsoleExitDelay > 0 {
+	delay && progress == nil && global.ConsoleExitDelay > 0 {
 		fmt.Printf("\nExiting with code %d in %d seconds...\n\n", code, global.ConsoleExitDelay)
 		time.Sleep(global.ConsoleExitDelay * time.Second)
 	} else {
@@ -411,8 +464,6 @@ func startService(optionalArgs ...bool) {
 		exit(1, false)
 	}
 
-	global.Debug = conf.AC.Get(schema.ConfigAgentDebug).Bool()
-
 	logger, logErr = newLogger()
 	if logErr != nil {
 		fmt.Printf("error creating logger: %v\n", logErr)
@@ -435,16 +486,27 @@ func startService(optionalArgs ...bool) {
 		communications.WithLogger(logger),
 		communications.WithConfig(conf),
 		communications.WithRequestQueue(requestQueue),
-		communications.WithResponseQueue(responseQueue))
+		communications.WithResponseQueue(responseQueue),
+		communications.WithLoggerReconfigure(reconfigureLogger))
 
 	if err != nil {
 		logger.Fatalf(8002, "unable to create communication object: %s", err.Error())
 		exit(1, false)
 	}
 
-	// Start user data listener (platform-specific, macOS only)
+	// Start the user data listener
 	initUserDataListener(logger)
 
+	// Start the read-only status interface for local tools
+	initLocalStatus(logger)
+
+	// Create the command functions once and reuse them for every request
+	cmdFunctions, err = newCommandFunctions()
+	if err != nil {
+		logger.Fatalf(8050, "error initializing command functions module: %s", err.Error())
+		exit(1, false)
+	}
+
 	// Check for foreground option
 	if foreground {
 		simulateService(logger, global.TaskTicker)
@@ -460,6 +522,7 @@ func startService(optionalArgs ...bool) {
 		uemservice.WithTasksFunc(ServiceTasks),
 		uemservice.WithStartFunc(ServiceStarting),
 		uemservice.WithStopFunc(ServiceStopping),
+		uemservice.WithReloadFunc(ServiceReload),
 		uemservice.WithSEid(8500))
 
 	if err != nil {
@@ -467,9 +530,6 @@ func startService(optionalArgs ...bool) {
 		return
 	}
 
-	// Try to tell the server we are starting
-	_ = communication.SendMessage(fmt.Sprintf("%s version %s (build %d) starting", global.Name, global.Version, global.Build))
-
 	err = service.Start()
 	if err != nil {
 		logger.Fatalf(8005, "service failed to start: %s", err.Error())
@@ -517,7 +577,12 @@ func ServiceTasks(interfaces.Logger) {
 }
 
 func syncTime(elapsed int64) bool {
-	if elapsed > conf.AC.Get(schema.ConfigAgentSyncInterval).Int64() {
+	// The server asked the agent to wait
+	if communication.SyncDeferred() {
+		return false
+	}
+
+	if elapsed > communication.SyncInterval() {
 		return true
 	}
 
@@ -539,6 +604,14 @@ func syncTime(elapsed int64) bool {
 // ServiceStarting will be called when the service starts
 func ServiceStarting(interfaces.Logger) {
 
+	// Try to tell the server we are starting and why. The reason is also included in the first status.
+	reason := service.StartInfo().String()
+	global.SetStartReason(reason)
+	_ = communication.SendMessage(fmt.Sprintf("%s version %s (build %d) starting, reason: %s", global.Name, global.Version, global.Build, reason))
+
+	// Report the result of an upgrade performed before this start
+	sendUpgradeResult()
+
 	// Initiate a sync to pick up service credentials
 	lastSync = time.Now().Unix()
 	communication.Sync()
@@ -547,24 +620,46 @@ func ServiceStarting(interfaces.Logger) {
 // ServiceStopping will be called when the service is stopping
 func ServiceStopping(interfaces.Logger) {
 
-	// Stop user data listener (platform-specific, macOS only)
+	// Stop the user data listener
 	cleanupUserDataListener(logger)
 
+	// Stop the local status interface
+	cleanupLocalStatus(logger)
+
 	// Try to tell the server
 	_ = communication.SendMessage(fmt.Sprintf("%s version %s (build %d) stopping", global.Name, global.Version, global.Build))
 }
 
-// processRequests reads requests from the agent queue and executes them
-func processRequests() {
+// ServiceReload will be called when the service is asked to reload its configuration, such as by
+// systemctl reload. The agent settings are applied as if they had been received from the server.
+func ServiceReload(interfaces.Logger) {
+	settings, err := global.ReadAgentSettings()
+	if err != nil {
+		logger.Errorf(8259, "error reloading configuration: %s", err.Error())
+		return
+	}
 
-	// Initialize the command functions package
-	cmd, err := functions.New(
+	changed := communication.ApplyConfig(settings)
+	logger.Info(8260, "configuration reloaded", fields.NewFields(
+		fields.NewField("changed", len(changed)),
+		fields.NewField("settings", strings.Join(changed, ", "))))
+}
+
+// newCommandFunctions initializes the command functions package. The user data listener
+// must be started first.
+func newCommandFunctions() (*functions.Command, error) {
+	return functions.New(
 		functions.WithLogger(logger),
 		functions.WithConfig(conf),
 		functions.WithComms(communication),
 		functions.WithUserDataSource(getUserDataSource()))
-	if err != nil {
-		logger.Errorf(8050, "error initializing command functions module: %s", err.Error())
+}
+
+// processRequests reads requests from the agent queue and executes them
+func processRequests() {
+
+	// Nothing to do on most ticks
+	if requestQueue.Size() == 0 {
 		return
 	}
 
@@ -577,7 +672,7 @@ func processRequests() {
 		}
 
 		// Execute the request
-		exeError := executeRequest(cmd, request)
+		exeError := executeRequest(cmdFunctions, request)
 		if exeError != nil {
 			// Log the error and continue to the next agent
 			logger.Errorf(8052, "error executing request [%s] %s: %s",
@@ -589,13 +684,26 @@ func processRequests() {
 // executeRequest executes a request using the command functions module
 func executeRequest(cmd *functions.Command, request schema.AgentRequest) error {
 
-	logFields := fields.NewFields(
+	// Leave room for the response fields so that appending them does not reallocate
+	logFields := &fields.Fields{Fields: make([]fields.Field, 0, 4)}
+	logFields.Append(
 		fields.NewField("request", request.Request),
 		fields.NewField("requestID", request.RequestID))
 
 	logger.Info(8051, "executing", logFields)
 
 	response := cmd.ExecuteRequest(request)
+
+	// Some handlers send the response themselves, for example before the agent moves to
+	// another server
+	if response.Sent {
+		logFields.Append(
+			fields.NewField("success", response.Success),
+			fields.NewField("response", response.Response))
+		logger.Info(8079, "response sent by handler", logFields)
+		return nil
+	}
+
 	if response.Response != "" {
 
 		// Add the response to the response queue
@@ -645,20 +753,14 @@ func executeRequest(cmd *functions.Command, request schema.AgentRequest) error {
 // sendStatus uses the existing status command to generate a response to the server without receiving
 // a request. This is useful for sending status information to the server on a regular basis.
 func sendStatus() {
-	cmd, err := functions.New(
-		functions.WithLogger(logger),
-		functions.WithConfig(conf),
-		functions.WithComms(communication),
-		functions.WithUserDataSource(getUserDataSource()))
-	if err != nil {
-		logger.Errorf(8060, "error initializing command module: %s", err.Error())
-		return
-	}
 
 	// Get our agentID from the configuration
 	agentID := conf.AP.Get(global.ConfigAgentID).String()
 	if agentID == "" {
-		logger.Warningf(8061, "null AgentID from config, attempting registration")
+		// Deferred registration failures are logged by the communications package
+		if !conf.AP.Get(global.ConfigRegDeferred).Bool() {
+			logger.Warningf(8061, "null AgentID from config, attempting registration")
+		}
 		communication.Register()
 		return
 	}
@@ -672,13 +774,42 @@ func sendStatus() {
 	request.Parameters["agent_id"] = agentID
 
 	// Execute the agent
-	err = executeRequest(cmd, request)
+	err := executeRequest(cmdFunctions, request)
 	if err != nil {
 		logger.Errorf(8062, "error executing status request: %s", err.Error())
 	}
 }
 
 // sendServiceCredentials creates an internal response to send encrypted service credentials to the server
+// sendUpgradeResult queues the outcome of an upgrade recorded by the installer
+func sendUpgradeResult() {
+	requestID := conf.AP.Get(global.ConfigUpgradeRequestID).String()
+	if requestID == "" {
+		return
+	}
+
+	response := schema.NewAgentResponse()
+	response.Cmd = commands.Upgrade
+	response.RequestID = requestID
+	response.Success = conf.AP.Get(global.ConfigUpgradeSuccess).Bool()
+	response.Response = conf.AP.Get(global.ConfigUpgradeResult).String()
+	responseQueue.Add(response)
+
+	logger.Info(8106, "upgrade result queued",
+		fields.NewFields(
+			fields.NewField("requestID", requestID),
+			fields.NewField("success", response.Success)))
+
+	// Only report the result once
+	conf.AP.Set(global.ConfigUpgradeRequestID, "")
+	conf.AP.Set(global.ConfigUpgradeSuccess, false)
+	conf.AP.Set(global.ConfigUpgradeResult, "")
+	err := conf.Checkpoint()
+	if err != nil {
+		logger.Errorf(8107, "error checkpointing configuration: %s", err.Error())
+	}
+}
+
 func sendServiceCredentials() {
 	// Only send if server public key is available
 	serverPublicEnc := conf.AP.Get(global.ConfigServerPublicEnc).String()
@@ -799,9 +930,9 @@ func simulateService(logger interfaces.Logger, taskTicker time.Duration) {
 	ticker := time.NewTicker(taskTicker)
 	defer ticker.Stop()
 
-	// Channel to listen for termination signals
+	// Channel to listen for termination and reload signals
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	// Run ServiceTasks at each tick
 	go func() {
@@ -810,8 +941,13 @@ func simulateService(logger interfaces.Logger, taskTicker time.Duration) {
 		}
 	}()
 
-	// Wait for termination signal
-	<-sigChan
+	// Reload on SIGHUP and wait for a termination signal
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		ServiceReload(logger)
+	}
 
 	// Call ServiceStopping when the application is terminated
 	ServiceStopping(logger)
@@ -819,16 +955,44 @@ func simulateService(logger interfaces.Logger, taskTicker time.Duration) {
 
 // newLogger creates a new logger on a best-effort basis
 func newLogger() (interfaces.Logger, error) {
-	var err error
-	var l interfaces.Logger
 
 	// Try a full logger first based on the loaded configuration
-	debug := conf.AC.Get(schema.ConfigAgentDebug).Bool() || global.Debug
+	l, err := ulogger.New(loggerOptions()...)
+	if err != nil {
+
+		// If that fails, create a console-only logger
+		return ulogger.New(
+			ulogger.WithPrefix(global.LogName),
+			ulogger.WithLogFile(""),
+			ulogger.WithLogStdout(true),
+			ulogger.WithRetention(0),
+			ulogger.WithDebug(true))
+	}
+	return l, nil
+}
+
+// reconfigureLogger applies the logging settings in the agent configuration to the logger in
+// use, so that changes pushed by the server take effect without a restart
+func reconfigureLogger() error {
+	r, ok := logger.(interface{ Reconfigure(...ulogger.Option) error })
+	if !ok {
+		return nil
+	}
+	return r.Reconfigure(loggerOptions()...)
+}
+
+// loggerOptions returns the logger options for the agent configuration. It also sets
+// global.Debug from the configuration, so that the setting is applied in one place whenever a
+// logger is created or reconfigured. A level set with set_log_level is kept by the logger.
+func loggerOptions() []ulogger.Option {
+	global.Debug = conf.AC.Get(schema.ConfigAgentDebug).Bool()
 	loggerOptions := []ulogger.Option{
 		ulogger.WithPrefix(global.LogName),
 		ulogger.WithLogStdout(conf.AC.Get(schema.ConfigAgentLogStdout).Bool()),
 		ulogger.WithRetention(conf.AC.Get(schema.ConfigAgentLogRetention).Int()),
-		ulogger.WithDebug(debug)}
+		ulogger.WithMaxSizeMB(conf.AC.Get(schema.ConfigAgentLogMaxSize).Int()),
+		ulogger.WithMaxFiles(conf.AC.Get(schema.ConfigAgentLogMaxFiles).Int()),
+		ulogger.WithDebug(global.Debug)}
 
 	var optKey string
 	switch runtime.GOOS {
@@ -845,23 +1009,10 @@ func newLogger() (interfaces.Logger, error) {
 		optKey = ""
 	}
 
-	if optKey != "" {
-		if conf.AC.Get(optKey).Bool() {
-			loggerOptions = append(loggerOptions, ulogger.WithLogFile(conf.AP.Get(global.ConfigAgentLogFile).String()))
-		}
+	// The log file is set explicitly so that disabling it takes effect when reconfiguring
+	logFile := ""
+	if optKey != "" && conf.AC.Get(optKey).Bool() {
+		logFile = conf.AP.Get(global.ConfigAgentLogFile).String()
 	}
-
-	// Create the logger
-	l, err = ulogger.New(loggerOptions...)
-	if err != nil {
-
-		// If that fails, create a console-only logger
-		return ulogger.New(
-			ulogger.WithPrefix(global.LogName),
-			ulogger.WithLogFile(""),
-			ulogger.WithLogStdout(true),
-			ulogger.WithRetention(0),
-			ulogger.WithDebug(true))
-	}
-	return l, nil
+	return append(loggerOptions, ulogger.WithLogFile(logFile))
 }
@@ -8,29 +8,18 @@
 package main
 
 import (
-	"github.com/UnifyEM/UnifyEM/agent/userdata"
-	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"fmt"
+	"os"
+	"path/filepath"
 )
 
-// checkUserHelperMode is a no-op on Linux
-func checkUserHelperMode() bool {
-	return false
+// userHelperLogPath returns the per-user log file for the user-helper
+func userHelperLogPath(username string) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("uem-agent-user-%s.log", username))
 }
 
-// initUserDataListener is a no-op on Linux
-func initUserDataListener(log interfaces.Logger) {
-	// Not implemented on Linux
-}
-
-// cleanupUserDataListener is a no-op on Linux
-func cleanupUserDataListener(log interfaces.Logger) {
-	// Not implemented on Linux
-}
-
-// getUserDataSource returns nil on Linux
-func getUserDataSource() *userdata.UserDataListener {
-	return nil
-}
+// detachConsole is a no-op on Linux
+func detachConsole() {}
 
 func getBitLockerInfo() string {
 	return ""
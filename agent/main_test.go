@@ -0,0 +1,66 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/agent/communications"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/queues"
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/uconfig"
+)
+
+func setupBenchmark(b *testing.B) {
+	b.Helper()
+
+	cfg := uconfig.Null()
+	conf = &global.AgentConfig{C: cfg, AC: schema.SetAgentDefaults(cfg), AP: cfg.NewSet(global.ConfigPrivate)}
+	logger = null.Logger()
+	requestQueue = queues.NewRequestQueue(global.TaskQueueSize)
+	responseQueue = queues.NewResponseQueue(global.TaskQueueSize)
+
+	var err error
+	communication, err = communications.New(
+		communications.WithLogger(logger),
+		communications.WithConfig(conf),
+		communications.WithRequestQueue(requestQueue),
+		communications.WithResponseQueue(responseQueue))
+	if err != nil {
+		b.Fatalf("failed to create communications: %v", err)
+	}
+
+	cmdFunctions, err = newCommandFunctions()
+	if err != nil {
+		b.Fatalf("failed to create command functions: %v", err)
+	}
+}
+
+// BenchmarkIdleTick measures processing an empty request queue, which is what happens on
+// almost every task tick
+func BenchmarkIdleTick(b *testing.B) {
+	setupBenchmark(b)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		processRequests()
+	}
+}
+
+// BenchmarkNewCommandFunctions measures creating the command functions, which used to be
+// done on every tick by both processRequests and sendStatus
+func BenchmarkNewCommandFunctions(b *testing.B) {
+	setupBenchmark(b)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := newCommandFunctions(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
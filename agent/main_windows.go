@@ -9,32 +9,25 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
-
-	"github.com/UnifyEM/UnifyEM/agent/userdata"
-	"github.com/UnifyEM/UnifyEM/common/interfaces"
 )
 
-// checkUserHelperMode is a no-op on Windows
-func checkUserHelperMode() bool {
-	return false
-}
-
-// initUserDataListener is a no-op on Windows
-func initUserDataListener(_ interfaces.Logger) {
-	// Not implemented on Windows
-}
-
-// cleanupUserDataListener is a no-op on Windows
-func cleanupUserDataListener(_ interfaces.Logger) {
-	// Not implemented on Windows
+// userHelperLogPath returns the per-user log file for the user-helper. The username includes
+// the domain, which is separated with a character that can not be used in a file name.
+func userHelperLogPath(username string) string {
+	name := strings.ReplaceAll(username, `\`, "_")
+	return filepath.Join(os.TempDir(), fmt.Sprintf("uem-agent-user-%s.log", name))
 }
 
-// getUserDataSource returns nil on Windows
-func getUserDataSource() *userdata.UserDataListener {
-	return nil
+// detachConsole closes the console window opened when the user-helper is started at logon
+func detachConsole() {
+	_, _, _ = syscall.NewLazyDLL("kernel32.dll").NewProc("FreeConsole").Call()
 }
 
 func getBitLockerInfo() string {
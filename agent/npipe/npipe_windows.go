@@ -0,0 +1,224 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+// Package npipe implements net.Listener and net.Conn with Windows named pipes
+package npipe
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Pipe handles are opened for overlapped I/O so that os.File supports read and write deadlines
+
+// bufferSize is the size of the pipe's input and output buffers
+const bufferSize = 65536
+
+// listener implements net.Listener with a named pipe, creating an instance for each connection
+type listener struct {
+	name     string
+	sa       *windows.SecurityAttributes
+	outbound bool
+	mu       sync.Mutex
+	next     windows.Handle // instance waiting for a connection, if any
+	closed   bool
+}
+
+// Conn is a connected pipe instance
+type Conn struct {
+	*os.File
+	name   string
+	handle windows.Handle
+	server bool
+}
+
+// addr is the address of a named pipe
+type addr string
+
+func (a addr) Network() string { return "pipe" }
+func (a addr) String() string  { return string(a) }
+
+// Listen creates a named pipe with the security descriptor sddl. If outbound is true, the
+// server can only write to the pipe and clients can only read from it.
+func Listen(name, sddl string, outbound bool) (net.Listener, error) {
+	sd, err := windows.SecurityDescriptorFromString(sddl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe security descriptor: %w", err)
+	}
+
+	l := &listener{
+		name:     name,
+		outbound: outbound,
+		sa: &windows.SecurityAttributes{
+			Length:             uint32(unsafe.Sizeof(windows.SecurityAttributes{})),
+			SecurityDescriptor: sd,
+		}}
+
+	// Create the first instance now so that another process already using the name is
+	// reported as an error rather than receiving the clients' connections
+	l.next, err = l.createInstance(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create named pipe: %w", err)
+	}
+	return l, nil
+}
+
+// createInstance creates an instance of the named pipe
+func (l *listener) createInstance(first bool) (windows.Handle, error) {
+	name, err := windows.UTF16PtrFromString(l.name)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+
+	flags := uint32(windows.PIPE_ACCESS_DUPLEX | windows.FILE_FLAG_OVERLAPPED)
+	if l.outbound {
+		flags = windows.PIPE_ACCESS_OUTBOUND | windows.FILE_FLAG_OVERLAPPED
+	}
+	if first {
+		flags |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+	}
+
+	return windows.CreateNamedPipe(name, flags,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		windows.PIPE_UNLIMITED_INSTANCES, bufferSize, bufferSize, 0, l.sa)
+}
+
+// Accept waits for a client to connect
+func (l *listener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+	h := l.next
+	l.next = 0
+	l.mu.Unlock()
+
+	var err error
+	if h == 0 {
+		h, err = l.createInstance(false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create named pipe: %w", err)
+		}
+	}
+
+	err = connectPipe(h)
+	if err != nil {
+		_ = windows.CloseHandle(h)
+		return nil, err
+	}
+
+	// Close connects to the pipe to release Accept
+	l.mu.Lock()
+	closed := l.closed
+	l.mu.Unlock()
+	if closed {
+		_ = windows.DisconnectNamedPipe(h)
+		_ = windows.CloseHandle(h)
+		return nil, net.ErrClosed
+	}
+
+	return &Conn{File: os.NewFile(uintptr(h), l.name), name: l.name, handle: h, server: true}, nil
+}
+
+// connectPipe waits for a client to connect to a pipe instance
+func connectPipe(h windows.Handle) error {
+	event, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = windows.CloseHandle(event) }()
+
+	overlapped := windows.Overlapped{HEvent: event}
+	err = windows.ConnectNamedPipe(h, &overlapped)
+	switch {
+	case err == nil, errors.Is(err, windows.ERROR_PIPE_CONNECTED):
+		return nil
+	case errors.Is(err, windows.ERROR_IO_PENDING):
+		var n uint32
+		return windows.GetOverlappedResult(h, &overlapped, &n, true)
+	default:
+		return err
+	}
+}
+
+// Close stops the listener
+func (l *listener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	h := l.next
+	l.next = 0
+	l.mu.Unlock()
+
+	// Close the instance if Accept is not waiting on it
+	if h != 0 {
+		return windows.CloseHandle(h)
+	}
+
+	// Accept blocks until a client connects, so connect to release it
+	conn, err := Dial(l.name, windows.GENERIC_READ, time.Second)
+	if err == nil {
+		_ = conn.Close()
+	}
+	return nil
+}
+
+// Addr returns the pipe name
+func (l *listener) Addr() net.Addr {
+	return addr(l.name)
+}
+
+func (c *Conn) LocalAddr() net.Addr  { return addr(c.name) }
+func (c *Conn) RemoteAddr() net.Addr { return addr(c.name) }
+
+// Handle returns the pipe handle
+func (c *Conn) Handle() windows.Handle {
+	return c.handle
+}
+
+// Close closes the connection. The server waits for the client to read what was written,
+// which would otherwise be discarded.
+func (c *Conn) Close() error {
+	if c.server {
+		_ = windows.FlushFileBuffers(c.handle)
+		_ = windows.DisconnectNamedPipe(c.handle)
+	}
+	return c.File.Close()
+}
+
+// Dial connects to a named pipe with the specified access, waiting up to timeout if all
+// instances are busy. The server may identify the client, but not act on its behalf.
+func Dial(name string, access uint32, timeout time.Duration) (net.Conn, error) {
+	pipeName, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		h, err := windows.CreateFile(pipeName, access, 0, nil,
+			windows.OPEN_EXISTING, windows.FILE_FLAG_OVERLAPPED|windows.SECURITY_SQOS_PRESENT|windows.SECURITY_IDENTIFICATION, 0)
+		if err == nil {
+			return &Conn{File: os.NewFile(uintptr(h), name), name: name, handle: h}, nil
+		}
+		if !errors.Is(err, windows.ERROR_PIPE_BUSY) || time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to connect to %s: %w", name, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -6,13 +6,22 @@
 package osActions
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/UnifyEM/UnifyEM/agent/global"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 	"github.com/UnifyEM/UnifyEM/common/runCmd"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
+// Reasons that TestCredentials rejected a username and password on Linux
+var (
+	ErrBadPassword    = errors.New("invalid credentials")
+	ErrAccountLocked  = errors.New("account is locked or expired")
+	ErrPAMUnavailable = errors.New("PAM authentication is not available")
+)
+
 type Actions struct {
 	logger interfaces.Logger
 	runner *runCmd.Runner
@@ -33,7 +42,7 @@ func (ui *UserInfo) Debug() string {
 func New(logger interfaces.Logger) *Actions {
 	return &Actions{
 		logger: logger,
-		runner: runCmd.New(runCmd.WithLogger(logger)),
+		runner: runCmd.New(runCmd.WithLogger(logger), runCmd.WithMaxOutput(global.MaxCommandOutput)),
 	}
 }
 
@@ -0,0 +1,212 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// Credentials are checked by running su as an unprivileged user in a pseudo-terminal, so that
+// PAM asks for the password as it would for a person. su run by root does not ask for one.
+// The password is only written to the terminal, never to arguments or logs.
+
+var (
+	credentialTimeout  = 20 * time.Second // before an unanswered check is abandoned
+	credentialMinDelay = 2 * time.Second  // minimum time for every check
+
+	// pamAuthenticate and shadowEntry are replaced by tests
+	pamAuthenticate = suAuthenticate
+	shadowEntry     = readShadowEntry
+)
+
+// testCredentials verifies that the username and password are valid. The error wraps
+// ErrBadPassword, ErrAccountLocked, or ErrPAMUnavailable.
+func (a *Actions) testCredentials(username string, password string) error {
+	if username == "" || password == "" {
+		return fmt.Errorf("username and password are required")
+	}
+
+	// Every check takes the same minimum time, so that the time does not reveal why it failed
+	start := time.Now()
+	err := checkCredentials(username, password)
+	if wait := credentialMinDelay - time.Since(start); wait > 0 {
+		time.Sleep(wait)
+	}
+
+	if err != nil {
+		a.logger.Debugf(8261, "credential test failed for user %s: %s", username, err.Error())
+		return fmt.Errorf("credential test failed for user %s: %w", username, err)
+	}
+	return nil
+}
+
+// checkCredentials checks the account and then authenticates with PAM
+func checkCredentials(username string, password string) error {
+	// pam_unix rejects locked and expired accounts with the same message as a wrong password, so
+	// local accounts are checked first. Other accounts, such as those in LDAP, are left to PAM.
+	entry, err := shadowEntry(username)
+	if err == nil && entry != "" {
+		if err = shadowStatus(entry, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), credentialTimeout)
+	defer cancel()
+
+	output, err := pamAuthenticate(ctx, username, password)
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: no response in %s", ErrPAMUnavailable, credentialTimeout)
+	}
+	return authResult(strings.ReplaceAll(output, password, "[redacted]"), err)
+}
+
+// authResult interprets the output and exit status of su
+func authResult(output string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		// su could not be started
+		return fmt.Errorf("%w: %v", ErrPAMUnavailable, err)
+	}
+
+	lower := strings.ToLower(output)
+	switch {
+	case strings.Contains(lower, "authentication failure"), strings.Contains(lower, "incorrect password"):
+		return ErrBadPassword
+	case strings.Contains(lower, "expired"), strings.Contains(lower, "no longer valid"),
+		strings.Contains(lower, "new one required"), strings.Contains(lower, "locked"):
+		return ErrAccountLocked
+	}
+
+	// Report the last message from su, such as a module that is not installed
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(output, "\r", "")), "\n")
+	return fmt.Errorf("%w: %s", ErrPAMUnavailable, strings.TrimSpace(lines[len(lines)-1]))
+}
+
+// shadowStatus returns ErrAccountLocked if a shadow entry is locked, has no password, or is expired
+func shadowStatus(entry string, now time.Time) error {
+	// name:password:lastchg:min:max:warn:inactive:expire:reserved, with dates in days since 1970
+	f := strings.Split(entry, ":")
+	if len(f) < 8 {
+		return nil
+	}
+
+	if f[1] == "" || strings.HasPrefix(f[1], "!") || strings.HasPrefix(f[1], "*") {
+		return fmt.Errorf("%w: the password is locked or not set", ErrAccountLocked)
+	}
+
+	today := now.Unix() / 86400
+	day := func(s string) (int64, bool) {
+		n, err := strconv.ParseInt(s, 10, 64)
+		return n, err == nil
+	}
+
+	if expire, ok := day(f[7]); ok && today >= expire {
+		return fmt.Errorf("%w: the account expired", ErrAccountLocked)
+	}
+	if lastChange, ok := day(f[2]); ok && lastChange == 0 {
+		return fmt.Errorf("%w: the password must be changed", ErrAccountLocked)
+	}
+
+	// An expired password can be changed until the inactive period ends
+	lastChange, okChange := day(f[2])
+	maxAge, okMax := day(f[4])
+	inactive, okInactive := day(f[6])
+	if okChange && okMax && okInactive && today > lastChange+maxAge+inactive {
+		return fmt.Errorf("%w: the password expired", ErrAccountLocked)
+	}
+	return nil
+}
+
+// readShadowEntry returns the /etc/shadow entry for a user, or "" if there is none
+func readShadowEntry(username string) (string, error) {
+	f, err := os.Open("/etc/shadow")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), username+":") {
+			return scanner.Text(), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// suAuthenticate runs su as nobody in a pseudo-terminal, answers the password prompt, and
+// returns the output and the result of su
+func suAuthenticate(ctx context.Context, username string, password string) (string, error) {
+	cmd := exec.CommandContext(ctx, "su", username, "-c", "true")
+	cmd.Env = []string{"LC_ALL=C", "PATH=/usr/sbin:/usr/bin:/sbin:/bin"}
+	cmd.Dir = "/"
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: unprivileged()}
+
+	ptmx, err := pty.Start(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	// Closing the terminal ends the read loop below if su or a PAM module does not respond
+	go func() {
+		<-ctx.Done()
+		_ = ptmx.Close()
+	}()
+	defer func() { _ = ptmx.Close() }()
+
+	var output bytes.Buffer
+	sent := false
+	buf := make([]byte, 1024)
+	for {
+		n, readErr := ptmx.Read(buf)
+		output.Write(buf[:n])
+		if !sent && strings.Contains(strings.ToLower(output.String()), "password:") {
+			sent = true
+			if _, err = ptmx.Write([]byte(password + "\n")); err != nil {
+				break
+			}
+		}
+		if readErr != nil {
+			// The terminal reports an error once su exits
+			break
+		}
+	}
+	return output.String(), cmd.Wait()
+}
+
+// unprivileged returns the credentials of the nobody user
+func unprivileged() *syscall.Credential {
+	uid, gid := 65534, 65534
+	if u, err := user.Lookup("nobody"); err == nil {
+		if n, err := strconv.Atoi(u.Uid); err == nil {
+			uid = n
+		}
+		if n, err := strconv.Atoi(u.Gid); err == nil {
+			gid = n
+		}
+	}
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+}
@@ -0,0 +1,96 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+)
+
+func TestShadowStatus(t *testing.T) {
+	now := time.Unix(20000*86400, 0)
+	tests := []struct {
+		entry  string
+		locked bool
+	}{
+		{"alice:$y$j9T$abc:19990:0:99999:7:::", false},
+		{"alice:!$y$j9T$abc:19990:0:99999:7:::", true}, // passwd -l
+		{"alice:*:19990:0:99999:7:::", true},
+		{"alice:$y$j9T$abc:19990:0:99999:7::20000:", true},  // account expired today
+		{"alice:$y$j9T$abc:19990:0:99999:7::20001:", false}, // expires tomorrow
+		{"alice:$y$j9T$abc:0:0:99999:7:::", true},           // password must be changed
+		{"alice:$y$j9T$abc:19900:0:90:7:5::", true},         // inactive after the password expired
+		{"alice:$y$j9T$abc:19900:0:90:7:30::", false},       // password expired but can be changed
+		{"malformed", false},
+	}
+	for _, tt := range tests {
+		err := shadowStatus(tt.entry, now)
+		if locked := errors.Is(err, ErrAccountLocked); locked != tt.locked {
+			t.Errorf("%s: expected locked %t, got %v", tt.entry, tt.locked, err)
+		}
+	}
+}
+
+func TestTestCredentials(t *testing.T) {
+	savedAuth, savedShadow, savedDelay, savedTimeout := pamAuthenticate, shadowEntry, credentialMinDelay, credentialTimeout
+	t.Cleanup(func() {
+		pamAuthenticate, shadowEntry, credentialMinDelay, credentialTimeout = savedAuth, savedShadow, savedDelay, savedTimeout
+	})
+	credentialMinDelay = 0
+	credentialTimeout = 100 * time.Millisecond
+
+	// A command that exits with status 1, as su does when authentication fails
+	exitErr := exec.Command("false").Run()
+	const password = "S3cret-pass"
+
+	tests := []struct {
+		name     string
+		shadow   string
+		output   string
+		err      error
+		hang     bool
+		expected error
+	}{
+		{name: "valid", output: "Password: \r\n"},
+		{name: "bad password", output: "Password: \r\nsu: Authentication failure\r\n", err: exitErr, expected: ErrBadPassword},
+		{name: "expired", output: "Password: \r\nYour account has expired; please contact your system administrator.\r\n", err: exitErr, expected: ErrAccountLocked},
+		{name: "locked", shadow: "alice:!$y$j9T$abc:19990:0:99999:7:::", expected: ErrAccountLocked},
+		{name: "no su", err: exec.ErrNotFound, expected: ErrPAMUnavailable},
+		{name: "module missing", output: "Password: " + password + "\r\nsu: Module is unknown\r\n", err: exitErr, expected: ErrPAMUnavailable},
+		{name: "no response", hang: true, expected: ErrPAMUnavailable},
+	}
+
+	a := New(null.Logger())
+	for _, tt := range tests {
+		shadowEntry = func(string) (string, error) { return tt.shadow, nil }
+		pamAuthenticate = func(ctx context.Context, username string, pass string) (string, error) {
+			if tt.shadow != "" {
+				t.Errorf("%s: PAM was called for a locked account", tt.name)
+			}
+			if tt.hang {
+				<-ctx.Done()
+				return "", ctx.Err()
+			}
+			return tt.output, tt.err
+		}
+
+		err := a.testCredentials("alice", password)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+		if err != nil && strings.Contains(err.Error(), password) {
+			t.Errorf("%s: the password is in the error %q", tt.name, err.Error())
+		}
+	}
+}
@@ -0,0 +1,24 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// FDERecoveryKey returns the full disk encryption recovery key for the system drive. On
+// macOS a new personal recovery key is generated, which requires the credentials of an
+// administrator who is enabled for FileVault.
+func (a *Actions) FDERecoveryKey(userInfo UserInfo) (schema.FDERecoveryKey, error) {
+
+	// Check for invalid characters in usernames and passwords
+	info, err := safeUserInfo(userInfo)
+	if err != nil {
+		return schema.FDERecoveryKey{}, err
+	}
+
+	return a.fdeRecoveryKey(info)
+}
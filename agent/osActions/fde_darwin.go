@@ -0,0 +1,59 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/runCmd"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Output is similar to "New personal recovery key = 'ABCD-EFGH-IJKL-MNOP-QRST-UVWX'"
+var fileVaultKey = regexp.MustCompile(`recovery key = '([A-Z0-9-]+)'`)
+
+// fdeRecoveryKey generates a new FileVault personal recovery key and returns it. The
+// previous personal recovery key can no longer be used.
+func (a *Actions) fdeRecoveryKey(userInfo UserInfo) (schema.FDERecoveryKey, error) {
+	if userInfo.AdminUser == "" || userInfo.AdminPassword == "" {
+		return schema.FDERecoveryKey{}, errors.New("admin_user and admin_password are required on macOS")
+	}
+
+	interactive := runCmd.Interactive{
+		Command: []string{"fdesetup", "changerecovery", "-personal"},
+		Actions: []runCmd.Action{
+			{
+				WaitFor:  "Enter the user name:",
+				Send:     userInfo.AdminUser,
+				DebugMsg: "Sending admin username",
+			},
+			{
+				WaitFor:  "Enter the password for user",
+				Send:     userInfo.AdminPassword,
+				DebugMsg: "Sending admin password",
+			},
+		},
+	}
+
+	a.logger.Info(8468, "calling fdesetup to generate a new personal recovery key",
+		fields.NewFields(fields.NewField("admin_user", userInfo.AdminUser)))
+
+	output, err := a.runner.TTY(interactive)
+	if err != nil {
+		return schema.FDERecoveryKey{}, fmt.Errorf("fdesetup failed to change the recovery key: %s", cmdOutput(output, err))
+	}
+
+	m := fileVaultKey.FindStringSubmatch(output)
+	if m == nil {
+		return schema.FDERecoveryKey{}, errors.New("fdesetup did not return a recovery key")
+	}
+	return schema.FDERecoveryKey{Type: schema.FDETypeFileVault, Key: m[1]}, nil
+}
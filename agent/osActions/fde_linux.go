@@ -0,0 +1,19 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// fdeRecoveryKey is not supported on Linux
+func (a *Actions) fdeRecoveryKey(_ UserInfo) (schema.FDERecoveryKey, error) {
+	return schema.FDERecoveryKey{}, errors.New("recovery key escrow is not supported on Linux")
+}
@@ -0,0 +1,46 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+var (
+	bitLockerID       = regexp.MustCompile(`ID:\s*(\{[0-9A-Fa-f-]+\})`)
+	bitLockerPassword = regexp.MustCompile(`\b\d{6}(-\d{6}){7}\b`)
+)
+
+// fdeRecoveryKey returns the numerical recovery password of the system drive using manage-bde
+func (a *Actions) fdeRecoveryKey(_ UserInfo) (schema.FDERecoveryKey, error) {
+	drive := os.Getenv("SystemDrive")
+	if drive == "" {
+		drive = "C:"
+	}
+
+	out, err := a.runner.Combined("manage-bde", "-protectors", "-get", drive, "-Type", "RecoveryPassword")
+	if err != nil {
+		return schema.FDERecoveryKey{}, fmt.Errorf("manage-bde failed to report key protectors: %s", cmdOutput(out, err))
+	}
+
+	password := bitLockerPassword.FindString(out)
+	if password == "" {
+		return schema.FDERecoveryKey{}, errors.New("no BitLocker recovery password found for " + drive)
+	}
+
+	key := schema.FDERecoveryKey{Type: schema.FDETypeBitLocker, Key: password}
+	if m := bitLockerID.FindStringSubmatch(out); m != nil {
+		key.ID = m[1]
+	}
+	return key, nil
+}
@@ -0,0 +1,44 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import "strings"
+
+// FirewallState describes the host firewall. Profiles contains the per-profile
+// (Windows), per-zone, or per-setting state reported by the backend.
+type FirewallState struct {
+	Backend  string            `json:"backend"`  // socketfilterfw, netsh, ufw, or firewalld
+	Enabled  bool              `json:"enabled"`  // true if the firewall is enabled (all profiles on Windows)
+	Profiles map[string]string `json:"profiles"` // backend-specific details
+}
+
+// SetFirewall enables or disables the host firewall
+func (a *Actions) SetFirewall(enable bool) error {
+	return a.setFirewall(enable)
+}
+
+// GetFirewall returns the state of the host firewall
+func (a *Actions) GetFirewall() (FirewallState, error) {
+	return a.getFirewall()
+}
+
+// onOff is a helper that converts a bool to the "on" or "off" strings used by most firewall tools
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// cmdOutput returns the trimmed command output for use in an error message,
+// falling back to the error itself if the command produced no output
+func cmdOutput(out string, err error) string {
+	out = strings.TrimSpace(out)
+	if out == "" && err != nil {
+		return err.Error()
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"fmt"
+	"strings"
+)
+
+const socketFilterFW = "/usr/libexec/ApplicationFirewall/socketfilterfw"
+
+// setFirewall enables or disables the application firewall using socketfilterfw
+func (a *Actions) setFirewall(enable bool) error {
+	out, err := a.runner.Combined(socketFilterFW, "--setglobalstate", onOff(enable))
+	if err != nil {
+		return fmt.Errorf("socketfilterfw failed to set global state to %s: %s", onOff(enable), cmdOutput(out, err))
+	}
+
+	// Verify that the change took effect
+	state, err := a.getFirewall()
+	if err != nil {
+		return err
+	}
+
+	if state.Enabled != enable {
+		return fmt.Errorf("socketfilterfw reported success but the firewall is still %s", onOff(state.Enabled))
+	}
+	return nil
+}
+
+// getFirewall returns the global, stealth mode, and block all settings of the application firewall
+func (a *Actions) getFirewall() (FirewallState, error) {
+	state := FirewallState{Backend: "socketfilterfw", Profiles: make(map[string]string)}
+
+	settings := []struct {
+		name string
+		flag string
+	}{
+		{"global", "--getglobalstate"},
+		{"stealth", "--getstealthmode"},
+		{"block_all", "--getblockall"},
+		{"logging", "--getloggingmode"},
+	}
+
+	for _, s := range settings {
+		out, err := a.runner.Combined(socketFilterFW, s.flag)
+		if err != nil {
+			if s.name == "global" {
+				return state, fmt.Errorf("socketfilterfw failed to report firewall state: %s", cmdOutput(out, err))
+			}
+			state.Profiles[s.name] = "unknown"
+			continue
+		}
+
+		// Output is similar to "Firewall is enabled. (State = 1)" or "Firewall stealth mode is off"
+		lower := strings.ToLower(out)
+		switch {
+		case strings.Contains(lower, "enabled"), strings.Contains(lower, " is on"), strings.Contains(lower, "mode is on"):
+			state.Profiles[s.name] = "on"
+		case strings.Contains(lower, "disabled"), strings.Contains(lower, " is off"), strings.Contains(lower, "mode is off"):
+			state.Profiles[s.name] = "off"
+		default:
+			state.Profiles[s.name] = strings.TrimSpace(out)
+		}
+	}
+
+	state.Enabled = state.Profiles["global"] == "on"
+	return state, nil
+}
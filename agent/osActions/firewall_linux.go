@@ -0,0 +1,114 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// linuxFirewallBackend returns the name of the installed firewall manager, preferring
+// ufw (Debian/Ubuntu) over firewalld (RHEL/Fedora)
+func linuxFirewallBackend() (string, error) {
+	if _, err := exec.LookPath("ufw"); err == nil {
+		return "ufw", nil
+	}
+	if _, err := exec.LookPath("firewall-cmd"); err == nil {
+		return "firewalld", nil
+	}
+	return "", errors.New("no supported firewall found: neither ufw nor firewalld is installed")
+}
+
+// setFirewall enables or disables ufw or firewalld, whichever is installed
+func (a *Actions) setFirewall(enable bool) error {
+	backend, err := linuxFirewallBackend()
+	if err != nil {
+		return err
+	}
+
+	switch backend {
+	case "ufw":
+		action := "disable"
+		if enable {
+			action = "enable"
+		}
+
+		// --force prevents ufw from prompting about disrupting SSH connections
+		out, err := a.runner.Combined("ufw", "--force", action)
+		if err != nil {
+			return fmt.Errorf("ufw %s failed: %s", action, cmdOutput(out, err))
+		}
+
+	case "firewalld":
+		// firewalld is controlled through its systemd unit
+		var args []string
+		if enable {
+			args = []string{"systemctl", "enable", "--now", "firewalld"}
+		} else {
+			args = []string{"systemctl", "disable", "--now", "firewalld"}
+		}
+
+		out, err := a.runner.Combined(args...)
+		if err != nil {
+			return fmt.Errorf("%s failed: %s", strings.Join(args, " "), cmdOutput(out, err))
+		}
+	}
+	return nil
+}
+
+// getFirewall returns the state of ufw or firewalld, whichever is installed
+func (a *Actions) getFirewall() (FirewallState, error) {
+	state := FirewallState{Profiles: make(map[string]string)}
+
+	backend, err := linuxFirewallBackend()
+	if err != nil {
+		return state, err
+	}
+	state.Backend = backend
+
+	switch backend {
+	case "ufw":
+		out, err := a.runner.Combined("ufw", "status", "verbose")
+		if err != nil {
+			return state, fmt.Errorf("ufw status failed: %s", cmdOutput(out, err))
+		}
+
+		// Lines are in the form "Status: active", "Logging: on (low)", "Default: deny (incoming), allow (outgoing)..."
+		for _, line := range strings.Split(out, "\n") {
+			key, value, found := strings.Cut(line, ":")
+			if !found || strings.TrimSpace(value) == "" {
+				continue
+			}
+			key = strings.ToLower(strings.TrimSpace(key))
+			switch key {
+			case "status", "logging", "default", "new profiles":
+				state.Profiles[key] = strings.TrimSpace(value)
+			}
+		}
+		state.Enabled = state.Profiles["status"] == "active"
+
+	case "firewalld":
+		// firewall-cmd --state exits non-zero when firewalld is not running
+		out, _ := a.runner.Combined("firewall-cmd", "--state")
+		out = strings.TrimSpace(out)
+		state.Profiles["state"] = out
+		state.Enabled = out == "running"
+
+		if state.Enabled {
+			if zone, err := a.runner.Stdout("firewall-cmd", "--get-default-zone"); err == nil {
+				state.Profiles["default_zone"] = strings.TrimSpace(zone)
+			}
+			if zones, err := a.runner.Stdout("firewall-cmd", "--get-active-zones"); err == nil {
+				state.Profiles["active_zones"] = strings.Join(strings.Fields(zones), " ")
+			}
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,63 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"fmt"
+	"strings"
+)
+
+// setFirewall enables or disables Windows Defender Firewall for all profiles
+func (a *Actions) setFirewall(enable bool) error {
+	out, err := a.runner.Combined("netsh", "advfirewall", "set", "allprofiles", "state", onOff(enable))
+	if err != nil {
+		return fmt.Errorf("netsh failed to set firewall state to %s: %s", onOff(enable), cmdOutput(out, err))
+	}
+	return nil
+}
+
+// getFirewall returns the state of each Windows Defender Firewall profile
+func (a *Actions) getFirewall() (FirewallState, error) {
+	state := FirewallState{Backend: "netsh", Profiles: make(map[string]string)}
+
+	out, err := a.runner.Combined("netsh", "advfirewall", "show", "allprofiles", "state")
+	if err != nil {
+		return state, fmt.Errorf("netsh failed to report firewall state: %s", cmdOutput(out, err))
+	}
+
+	// Output consists of sections such as "Domain Profile Settings:" followed by "State    ON"
+	profile := ""
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		lower := strings.ToLower(line)
+
+		if strings.HasSuffix(lower, "profile settings:") {
+			profile = strings.Fields(lower)[0]
+			continue
+		}
+
+		if profile != "" && strings.HasPrefix(lower, "state") {
+			f := strings.Fields(lower)
+			if len(f) > 1 {
+				state.Profiles[profile] = f[1]
+			}
+		}
+	}
+
+	if len(state.Profiles) == 0 {
+		return state, fmt.Errorf("unable to parse netsh firewall output")
+	}
+
+	state.Enabled = true
+	for _, s := range state.Profiles {
+		if s != "on" {
+			state.Enabled = false
+		}
+	}
+	return state, nil
+}
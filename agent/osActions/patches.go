@@ -0,0 +1,111 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// PatchStatus returns the operating system updates that are pending installation
+func (a *Actions) PatchStatus() (schema.PatchStatus, error) {
+	status, err := a.patchStatus()
+	if err != nil {
+		return status, err
+	}
+
+	if status.Updates == nil {
+		status.Updates = []schema.PatchUpdate{}
+	}
+	status.Collected = time.Now()
+	status.Count = len(status.Updates)
+	return status, nil
+}
+
+// InstallPatches installs the named updates, or all pending updates if names is empty, and
+// returns the installer output and whether a restart is required to complete the installation.
+// The installer is killed if the context expires.
+func (a *Actions) InstallPatches(ctx context.Context, names []string) (string, bool, error) {
+	return a.installPatches(ctx, names)
+}
+
+// parseSoftwareUpdate parses the output of softwareupdate -l, which lists each update as a
+// "* Label: <label>" line followed by a "Title: <title>, Version: <version>, ..." line
+func parseSoftwareUpdate(out string) []schema.PatchUpdate {
+	var updates []schema.PatchUpdate
+	for _, line := range strings.Split(out, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if label, ok := strings.CutPrefix(trimmed, "* "); ok {
+			updates = append(updates, schema.PatchUpdate{Name: strings.TrimPrefix(label, "Label: ")})
+			continue
+		}
+
+		// Details follow the label on an indented line
+		if len(updates) == 0 || !strings.HasPrefix(line, "\t") {
+			continue
+		}
+		update := &updates[len(updates)-1]
+		for _, field := range strings.Split(trimmed, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(field), ":")
+			value = strings.TrimSpace(value)
+			switch name {
+			case "Title":
+				update.Title = value
+			case "Version":
+				update.Version = value
+			case "Action":
+				update.Restart = strings.EqualFold(value, "restart")
+			}
+		}
+
+		// Older versions of macOS show "[restart]" instead of an action
+		if strings.Contains(trimmed, "[restart]") {
+			update.Restart = true
+		}
+	}
+	return updates
+}
+
+// parseAptUpgradable parses the output of apt list --upgradable, which lists each package as:
+//
+//	openssl/jammy-updates 3.0.2-0ubuntu1.15 amd64 [upgradable from: 3.0.2-0ubuntu1.14]
+func parseAptUpgradable(out string) []schema.PatchUpdate {
+	var updates []schema.PatchUpdate
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Fields(line)
+		if len(f) < 2 || !strings.Contains(f[0], "/") {
+			continue
+		}
+		name, _, _ := strings.Cut(f[0], "/")
+		updates = append(updates, schema.PatchUpdate{Name: name, Version: f[1]})
+	}
+	return updates
+}
+
+// parseDnfCheckUpdate parses the output of dnf check-update, which lists each package as:
+//
+//	openssl.x86_64    1:3.0.7-27.el9    baseos
+//
+// Packages listed after "Obsoleting Packages" are replaced by the packages above and are skipped.
+func parseDnfCheckUpdate(out string) []schema.PatchUpdate {
+	var updates []schema.PatchUpdate
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "Obsoleting Packages") {
+			break
+		}
+
+		f := strings.Fields(line)
+		if len(f) != 3 || !strings.Contains(f[0], ".") || strings.HasSuffix(f[0], ":") {
+			continue
+		}
+		updates = append(updates, schema.PatchUpdate{Name: f[0], Version: f[1]})
+	}
+	return updates
+}
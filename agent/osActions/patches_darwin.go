@@ -0,0 +1,49 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// patchStatus lists the pending updates reported by softwareupdate
+func (a *Actions) patchStatus() (schema.PatchStatus, error) {
+	status := schema.PatchStatus{Source: "softwareupdate"}
+
+	// "No new software available." is not an error
+	out, err := a.runner.Combined("softwareupdate", "-l")
+	if err != nil {
+		return status, fmt.Errorf("softwareupdate failed to list updates: %s", cmdOutput(out, err))
+	}
+
+	status.Updates = parseSoftwareUpdate(out)
+	return status, nil
+}
+
+// installPatches installs the named updates, or all recommended updates, using softwareupdate.
+// The system is not restarted by softwareupdate; the caller decides whether to restart.
+func (a *Actions) installPatches(ctx context.Context, names []string) (string, bool, error) {
+	args := []string{"softwareupdate", "--install"}
+	if len(names) == 0 {
+		args = append(args, "--all")
+	} else {
+		args = append(args, names...)
+	}
+
+	out, err := a.runner.CombinedCtx(ctx, args...)
+	if err != nil {
+		return out, false, fmt.Errorf("softwareupdate failed to install updates: %s", cmdOutput(out, err))
+	}
+
+	// softwareupdate asks for a restart when one is required to complete the installation
+	return out, strings.Contains(strings.ToLower(out), "restart"), nil
+}
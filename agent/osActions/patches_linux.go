@@ -0,0 +1,114 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// linuxPackageManager returns the name of the installed package manager, preferring
+// apt (Debian/Ubuntu) over dnf (RHEL/Fedora)
+func linuxPackageManager() (string, error) {
+	if _, err := exec.LookPath("apt-get"); err == nil {
+		return "apt", nil
+	}
+	if _, err := exec.LookPath("dnf"); err == nil {
+		return "dnf", nil
+	}
+	return "", errors.New("no supported package manager found: neither apt nor dnf is installed")
+}
+
+// patchStatus lists the packages that can be upgraded using apt or dnf, whichever is installed
+func (a *Actions) patchStatus() (schema.PatchStatus, error) {
+	var status schema.PatchStatus
+
+	manager, err := linuxPackageManager()
+	if err != nil {
+		return status, err
+	}
+	status.Source = manager
+
+	switch manager {
+	case "apt":
+		// Refresh the package lists so that new updates are included
+		out, err := a.runner.Combined("apt-get", "update", "-qq")
+		if err != nil {
+			return status, fmt.Errorf("apt-get update failed: %s", cmdOutput(out, err))
+		}
+
+		out, err = a.runner.Stdout("apt", "list", "--upgradable")
+		if err != nil {
+			return status, fmt.Errorf("apt failed to list updates: %s", cmdOutput(out, err))
+		}
+		status.Updates = parseAptUpgradable(out)
+
+	case "dnf":
+		// dnf exits with 100 if updates are available
+		out, err := a.runner.Stdout("dnf", "-q", "check-update")
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 100) {
+			return status, fmt.Errorf("dnf failed to list updates: %s", cmdOutput(out, err))
+		}
+		status.Updates = parseDnfCheckUpdate(out)
+	}
+
+	status.RebootRequired = a.linuxRebootRequired()
+	return status, nil
+}
+
+// installPatches upgrades the named packages, or all packages, using apt or dnf
+func (a *Actions) installPatches(ctx context.Context, names []string) (string, bool, error) {
+	manager, err := linuxPackageManager()
+	if err != nil {
+		return "", false, err
+	}
+
+	var args []string
+	switch manager {
+	case "apt":
+		// Keep existing configuration files rather than prompting
+		args = []string{"env", "DEBIAN_FRONTEND=noninteractive", "apt-get", "-y",
+			"-o", "Dpkg::Options::=--force-confdef", "-o", "Dpkg::Options::=--force-confold"}
+		if len(names) == 0 {
+			args = append(args, "upgrade")
+		} else {
+			args = append(append(args, "install", "--only-upgrade"), names...)
+		}
+
+	case "dnf":
+		args = append([]string{"dnf", "-y", "upgrade"}, names...)
+	}
+
+	out, err := a.runner.CombinedCtx(ctx, args...)
+	if err != nil {
+		return out, false, fmt.Errorf("%s failed to install updates: %s", manager, cmdOutput(out, err))
+	}
+	return out, a.linuxRebootRequired(), nil
+}
+
+// linuxRebootRequired returns true if a restart is required to complete an upgrade. Debian
+// and Ubuntu create /var/run/reboot-required, and on RHEL and Fedora needs-restarting -r
+// exits with 1.
+func (a *Actions) linuxRebootRequired() bool {
+	if _, err := os.Stat("/var/run/reboot-required"); err == nil {
+		return true
+	}
+
+	if _, err := exec.LookPath("needs-restarting"); err == nil {
+		_, err = a.runner.Combined("needs-restarting", "-r")
+		var exitErr *exec.ExitError
+		return errors.As(err, &exitErr) && exitErr.ExitCode() == 1
+	}
+	return false
+}
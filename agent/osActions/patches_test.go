@@ -0,0 +1,64 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func TestParseSoftwareUpdate(t *testing.T) {
+	out := "Software Update Tool\n\nFinding available software\n" +
+		"Software Update found the following new or updated software:\n" +
+		"* Label: macOS Sonoma 14.5-23F79\n" +
+		"\tTitle: macOS Sonoma 14.5, Version: 14.5, Size: 6789123KiB, Recommended: YES, Action: restart,\n" +
+		"* Label: Safari17.5VenturaAuto-17.5\n" +
+		"\tTitle: Safari, Version: 17.5, Size: 154421KiB, Recommended: YES, \n"
+
+	expected := []schema.PatchUpdate{
+		{Name: "macOS Sonoma 14.5-23F79", Title: "macOS Sonoma 14.5", Version: "14.5", Restart: true},
+		{Name: "Safari17.5VenturaAuto-17.5", Title: "Safari", Version: "17.5"},
+	}
+	if updates := parseSoftwareUpdate(out); !reflect.DeepEqual(updates, expected) {
+		t.Errorf("expected %+v, got %+v", expected, updates)
+	}
+
+	if updates := parseSoftwareUpdate("Software Update Tool\n\nFinding available software\n"); len(updates) != 0 {
+		t.Errorf("expected no updates, got %+v", updates)
+	}
+}
+
+func TestParseAptUpgradable(t *testing.T) {
+	out := "Listing... Done\n" +
+		"openssl/jammy-updates,jammy-security 3.0.2-0ubuntu1.15 amd64 [upgradable from: 3.0.2-0ubuntu1.14]\n" +
+		"tzdata/jammy-updates 2024a-0ubuntu0.22.04 all [upgradable from: 2023c-0ubuntu0.22.04.2]\n"
+
+	expected := []schema.PatchUpdate{
+		{Name: "openssl", Version: "3.0.2-0ubuntu1.15"},
+		{Name: "tzdata", Version: "2024a-0ubuntu0.22.04"},
+	}
+	if updates := parseAptUpgradable(out); !reflect.DeepEqual(updates, expected) {
+		t.Errorf("expected %+v, got %+v", expected, updates)
+	}
+}
+
+func TestParseDnfCheckUpdate(t *testing.T) {
+	out := "\n" +
+		"kernel.x86_64                 5.14.0-427.el9          baseos\n" +
+		"openssl.x86_64                1:3.0.7-27.el9          baseos\n" +
+		"Obsoleting Packages\n" +
+		"grub2-tools.x86_64            1:2.06-77.el9           baseos\n"
+
+	expected := []schema.PatchUpdate{
+		{Name: "kernel.x86_64", Version: "5.14.0-427.el9"},
+		{Name: "openssl.x86_64", Version: "1:3.0.7-27.el9"},
+	}
+	if updates := parseDnfCheckUpdate(out); !reflect.DeepEqual(updates, expected) {
+		t.Errorf("expected %+v, got %+v", expected, updates)
+	}
+}
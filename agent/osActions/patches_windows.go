@@ -0,0 +1,125 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/runCmd"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Both scripts use the Windows Update Agent API, which is available on all supported versions
+// of Windows and, unlike PSWindowsUpdate, does not require a module to be installed.
+const (
+	windowsUpdateSearch = `$ErrorActionPreference = 'Stop'
+$session = New-Object -ComObject Microsoft.Update.Session
+$result = $session.CreateUpdateSearcher().Search("IsInstalled=0 and IsHidden=0 and Type='Software'")
+`
+
+	windowsUpdateStatus = windowsUpdateSearch + `$updates = @(foreach ($u in $result.Updates) {
+    $name = $u.Identity.UpdateID
+    if ($u.KBArticleIDs.Count -gt 0) { $name = 'KB' + $u.KBArticleIDs.Item(0) }
+    @{ name = $name; title = $u.Title; restart = ($u.InstallationBehavior.RebootBehavior -ne 0) }
+})
+$reboot = Test-Path 'HKLM:\SOFTWARE\Microsoft\Windows\CurrentVersion\WindowsUpdate\Auto Update\RebootRequired'
+@{ updates = $updates; reboot = $reboot } | ConvertTo-Json -Depth 3 -Compress
+`
+
+	// The list of names is inserted before this script
+	windowsUpdateInstall = windowsUpdateSearch + `$selected = New-Object -ComObject Microsoft.Update.UpdateColl
+foreach ($u in $result.Updates) {
+    $kb = @($u.KBArticleIDs | ForEach-Object { 'KB' + $_ })
+    $match = @($names | Where-Object { $kb -contains $_ -or $u.Title -eq $_ -or $u.Identity.UpdateID -eq $_ })
+    if ($names.Count -eq 0 -or $match.Count -gt 0) {
+        if (-not $u.EulaAccepted) { $u.AcceptEula() }
+        [void]$selected.Add($u)
+    }
+}
+if ($selected.Count -eq 0) {
+    @{ count = 0; result = 2; reboot = $false } | ConvertTo-Json -Compress
+    exit 0
+}
+$downloader = $session.CreateUpdateDownloader()
+$downloader.Updates = $selected
+[void]$downloader.Download()
+$installer = $session.CreateUpdateInstaller()
+$installer.Updates = $selected
+$r = $installer.Install()
+@{ count = $selected.Count; result = $r.ResultCode; reboot = $r.RebootRequired } | ConvertTo-Json -Compress
+`
+)
+
+// Windows Update OperationResultCode values
+const (
+	wuSucceeded           = 2
+	wuSucceededWithErrors = 3
+)
+
+// patchStatus lists the updates offered by Windows Update
+func (a *Actions) patchStatus() (schema.PatchStatus, error) {
+	status := schema.PatchStatus{Source: "windows_update"}
+
+	out, err := a.runner.Stdout("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsUpdateStatus)
+	if err != nil {
+		return status, fmt.Errorf("windows update search failed: %s", cmdOutput(out, err))
+	}
+
+	var result struct {
+		Updates []schema.PatchUpdate `json:"updates"`
+		Reboot  bool                 `json:"reboot"`
+	}
+	err = json.Unmarshal([]byte(strings.TrimSpace(out)), &result)
+	if err != nil {
+		return status, fmt.Errorf("unexpected windows update search result: %w", err)
+	}
+
+	status.Updates = result.Updates
+	status.RebootRequired = result.Reboot
+	return status, nil
+}
+
+// installPatches downloads and installs the named updates (KB article, title, or update ID),
+// or all pending updates, using Windows Update. Windows is not restarted; the caller decides
+// whether to restart.
+func (a *Actions) installPatches(ctx context.Context, names []string) (string, bool, error) {
+
+	// Update names are validated, and are also quoted in case validation is bypassed
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = runCmd.PowerShellQuote(name)
+	}
+	script := fmt.Sprintf("$names = @(%s)\n%s", strings.Join(quoted, ","), windowsUpdateInstall)
+
+	out, err := a.runner.CombinedCtx(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	if err != nil {
+		return out, false, fmt.Errorf("windows update installation failed: %s", cmdOutput(out, err))
+	}
+
+	var result struct {
+		Count  int  `json:"count"`
+		Result int  `json:"result"`
+		Reboot bool `json:"reboot"`
+	}
+	err = json.Unmarshal([]byte(strings.TrimSpace(out)), &result)
+	if err != nil {
+		return out, false, fmt.Errorf("unexpected windows update installation result: %s", strings.TrimSpace(out))
+	}
+
+	summary := fmt.Sprintf("%d updates installed", result.Count)
+	if result.Result != wuSucceeded && result.Result != wuSucceededWithErrors {
+		return summary, result.Reboot, fmt.Errorf("windows update installation failed with result code %d", result.Result)
+	}
+	if result.Result == wuSucceededWithErrors {
+		summary = fmt.Sprintf("%d updates processed, some failed to install", result.Count)
+	}
+	return summary, result.Reboot, nil
+}
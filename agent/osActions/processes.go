@@ -0,0 +1,88 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Process describes a running process
+type Process struct {
+	PID     int     `json:"pid"`
+	Name    string  `json:"name"`
+	User    string  `json:"user"`
+	Command string  `json:"command"` // command line, if available
+	CPU     float64 `json:"cpu"`     // percent of one CPU
+	RSS     int64   `json:"rss"`     // resident memory in KB
+}
+
+// ListProcesses returns the running processes
+func (a *Actions) ListProcesses() ([]Process, error) {
+	return a.listProcesses()
+}
+
+// KillProcesses terminates the processes that match the PID and/or the exact name and returns
+// the processes that were killed. If force is false, processes are asked to exit. Nothing is
+// killed if any matching process is protected.
+func (a *Actions) KillProcesses(pid int, name string, force bool) ([]Process, error) {
+	list, err := a.listProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := selectProcesses(list, pid, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var killed []Process
+	var failed []string
+	for _, p := range matches {
+		err = a.killProcess(p.PID, force)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%d (%s): %s", p.PID, p.Name, err.Error()))
+			continue
+		}
+		killed = append(killed, p)
+	}
+
+	if len(failed) > 0 {
+		return killed, fmt.Errorf("failed to kill %s", strings.Join(failed, "; "))
+	}
+	return killed, nil
+}
+
+// selectProcesses returns the processes that match the PID and/or name. An error is returned
+// if there are no matches or if a match is this agent, PID 0 or 1, or a critical system process.
+func selectProcesses(list []Process, pid int, name string) ([]Process, error) {
+	if pid == 0 && name == "" {
+		return nil, errors.New("pid or name is required")
+	}
+
+	self := os.Getpid()
+	var matches []Process
+	for _, p := range list {
+		if pid != 0 && p.PID != pid {
+			continue
+		}
+		if name != "" && !sameProcessName(p.Name, name) {
+			continue
+		}
+
+		if p.PID == self || p.PID <= 1 || protectedProcess(p.Name) {
+			return nil, fmt.Errorf("refusing to kill protected process %d (%s)", p.PID, p.Name)
+		}
+		matches = append(matches, p)
+	}
+
+	if len(matches) == 0 {
+		return nil, errors.New("no matching process found")
+	}
+	return matches, nil
+}
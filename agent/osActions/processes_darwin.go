@@ -0,0 +1,44 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"path/filepath"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+const psUserColumn = "user="
+
+// protectedProcesses are never killed by process_kill
+var protectedProcesses = map[string]bool{
+	"kernel_task":         true,
+	"launchd":             true,
+	"WindowServer":        true,
+	"loginwindow":         true,
+	"opendirectoryd":      true,
+	"securityd":           true,
+	"configd":             true,
+	"logd":                true,
+	"coreservicesd":       true,
+	global.UnixBinaryName: true,
+}
+
+// processName returns the name of a process from the ps comm column, which on macOS is the
+// path of the executable
+func processName(comm string) string {
+	return filepath.Base(comm)
+}
+
+func protectedProcess(name string) bool {
+	return protectedProcesses[name]
+}
+
+func sameProcessName(name, match string) bool {
+	return name == match
+}
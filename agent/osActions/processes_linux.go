@@ -0,0 +1,39 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import "github.com/UnifyEM/UnifyEM/agent/global"
+
+// procps truncates user names to 8 characters unless a width is specified
+const psUserColumn = "user:32="
+
+// protectedProcesses are never killed by process_kill
+var protectedProcesses = map[string]bool{
+	"init":                true,
+	"systemd":             true,
+	"systemd-journald":    true,
+	"systemd-logind":      true,
+	"systemd-udevd":       true,
+	"kthreadd":            true,
+	"dbus-daemon":         true,
+	global.UnixBinaryName: true,
+}
+
+// processName returns the name of a process from the ps comm column, which on Linux is the
+// name of the executable
+func processName(comm string) string {
+	return comm
+}
+
+func protectedProcess(name string) bool {
+	return protectedProcesses[name]
+}
+
+func sameProcessName(name, match string) bool {
+	return name == match
+}
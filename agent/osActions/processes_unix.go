@@ -0,0 +1,101 @@
+//go:build darwin || linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// listProcesses uses ps to list processes. The command line is obtained separately because
+// both it and the executable name may contain spaces.
+func (a *Actions) listProcesses() ([]Process, error) {
+	details, err := a.runner.Stdout("ps", "-axww", "-o", "pid=,"+psUserColumn+",%cpu=,rss=,comm=")
+	if err != nil {
+		return nil, fmt.Errorf("ps failed to list processes: %s", cmdOutput(details, err))
+	}
+
+	args, err := a.runner.Stdout("ps", "-axww", "-o", "pid=,args=")
+	if err != nil {
+		return nil, fmt.Errorf("ps failed to list command lines: %s", cmdOutput(args, err))
+	}
+	return parsePS(details, args), nil
+}
+
+// killProcess sends SIGKILL if force is true, otherwise SIGTERM
+func (a *Actions) killProcess(pid int, force bool) error {
+	signal := syscall.SIGTERM
+	if force {
+		signal = syscall.SIGKILL
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Signal(signal)
+}
+
+// parsePS combines the output of "ps -o pid=,user=,%cpu=,rss=,comm=" and "ps -o pid=,args=".
+// The last column of each may contain spaces.
+func parsePS(details, args string) []Process {
+	commands := make(map[int]string)
+	for _, line := range strings.Split(args, "\n") {
+		f, rest := splitColumns(line, 1)
+		if f == nil {
+			continue
+		}
+		if pid, err := strconv.Atoi(f[0]); err == nil {
+			commands[pid] = rest
+		}
+	}
+
+	var list []Process
+	for _, line := range strings.Split(details, "\n") {
+		f, rest := splitColumns(line, 4)
+		if f == nil || rest == "" {
+			continue
+		}
+
+		pid, err := strconv.Atoi(f[0])
+		if err != nil {
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(f[2], 64)
+		rss, _ := strconv.ParseInt(f[3], 10, 64)
+
+		list = append(list, Process{
+			PID:     pid,
+			Name:    processName(rest),
+			User:    f[1],
+			Command: commands[pid],
+			CPU:     cpu,
+			RSS:     rss,
+		})
+	}
+	return list
+}
+
+// splitColumns returns the first n whitespace-separated columns of the line and the rest of
+// the line, or nil if there are fewer than n columns
+func splitColumns(line string, n int) ([]string, string) {
+	var columns []string
+	rest := strings.TrimSpace(line)
+	for i := 0; i < n; i++ {
+		if rest == "" {
+			return nil, ""
+		}
+		column, remainder, _ := strings.Cut(rest, " ")
+		columns = append(columns, column)
+		rest = strings.TrimSpace(remainder)
+	}
+	return columns, rest
+}
@@ -0,0 +1,66 @@
+//go:build darwin || linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParsePS(t *testing.T) {
+	details := "    1 root              0.0 12345 launchd\n" +
+		"  812 alice            12.5  2048 Web Content\n" +
+		"  900 bob\n"
+	args := "    1 /sbin/launchd\n  812 /usr/lib/firefox/firefox -contentproc -childID 1\n"
+
+	list := parsePS(details, args)
+	if len(list) != 2 {
+		t.Fatalf("expected 2 processes, got %+v", list)
+	}
+
+	p := list[1]
+	if p.PID != 812 || p.Name != "Web Content" || p.User != "alice" || p.CPU != 12.5 || p.RSS != 2048 ||
+		p.Command != "/usr/lib/firefox/firefox -contentproc -childID 1" {
+		t.Errorf("unexpected process %+v", p)
+	}
+}
+
+func TestSelectProcesses(t *testing.T) {
+	list := []Process{
+		{PID: 1, Name: "launchd"},
+		{PID: 100, Name: "sshd"},
+		{PID: 200, Name: "python3"},
+		{PID: 201, Name: "python3"},
+		{PID: 202, Name: "python3.11"},
+		{PID: os.Getpid(), Name: "agent"},
+	}
+
+	// Names must match exactly
+	matches, err := selectProcesses(list, 0, "python3")
+	if err != nil || len(matches) != 2 {
+		t.Errorf("expected 2 matches, got %+v (%v)", matches, err)
+	}
+
+	// A PID and name must both match
+	if _, err = selectProcesses(list, 200, "sshd"); err == nil {
+		t.Errorf("expected no match for mismatched pid and name")
+	}
+	if matches, err = selectProcesses(list, 202, ""); err != nil || matches[0].Name != "python3.11" {
+		t.Errorf("unexpected match %+v (%v)", matches, err)
+	}
+
+	for _, pid := range []int{1, os.Getpid()} {
+		if _, err = selectProcesses(list, pid, ""); err == nil {
+			t.Errorf("expected PID %d to be protected", pid)
+		}
+	}
+
+	if _, err = selectProcesses(list, 0, "python"); err == nil {
+		t.Errorf("expected no match for a partial name")
+	}
+}
@@ -0,0 +1,88 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+// Win32_Process provides the executable name and command line, and Get-Process the user and
+// resource usage. CPU is averaged over the life of the process, as it is by ps on Linux.
+const windowsProcessList = `$ErrorActionPreference = 'Stop'
+$now = Get-Date
+$cim = @{}
+Get-CimInstance Win32_Process | ForEach-Object { $cim[[int]$_.ProcessId] = $_ }
+$list = @(Get-Process -IncludeUserName | ForEach-Object {
+    $c = $cim[$_.Id]
+    $cpu = 0
+    try { if ($_.CPU) { $cpu = [math]::Round($_.CPU / ($now - $_.StartTime).TotalSeconds * 100, 1) } } catch {}
+    @{ pid = $_.Id; name = [string]$c.Name; user = [string]$_.UserName; command = [string]$c.CommandLine; cpu = $cpu; rss = [int64]($_.WorkingSet64 / 1024) }
+})
+ConvertTo-Json -InputObject $list -Compress
+`
+
+// protectedProcesses are never killed by process_kill. Names are lower case.
+var protectedProcesses = map[string]bool{
+	"system":              true,
+	"system idle process": true,
+	"registry":            true,
+	"memcompression":      true,
+	"smss.exe":            true,
+	"csrss.exe":           true,
+	"wininit.exe":         true,
+	"winlogon.exe":        true,
+	"services.exe":        true,
+	"lsass.exe":           true,
+	"lsaiso.exe":          true,
+	"svchost.exe":         true,
+	"dwm.exe":             true,
+	strings.ToLower(global.WindowsBinaryName): true,
+}
+
+// listProcesses uses PowerShell to list processes
+func (a *Actions) listProcesses() ([]Process, error) {
+	out, err := a.runner.Stdout("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsProcessList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %s", cmdOutput(out, err))
+	}
+
+	var list []Process
+	err = json.Unmarshal([]byte(strings.TrimSpace(out)), &list)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected process list: %w", err)
+	}
+	return list, nil
+}
+
+// killProcess uses taskkill, which asks the process to close unless force is true
+func (a *Actions) killProcess(pid int, force bool) error {
+	args := []string{"taskkill", "/PID", strconv.Itoa(pid)}
+	if force {
+		args = append(args, "/F")
+	}
+
+	out, err := a.runner.Combined(args...)
+	if err != nil {
+		return fmt.Errorf("taskkill failed: %s", cmdOutput(out, err))
+	}
+	return nil
+}
+
+func protectedProcess(name string) bool {
+	return protectedProcesses[strings.ToLower(name)]
+}
+
+// Process names are not case-sensitive on Windows
+func sameProcessName(name, match string) bool {
+	return strings.EqualFold(name, match)
+}
@@ -0,0 +1,31 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import "fmt"
+
+// Limits for the screen lock delay, in minutes
+const (
+	ScreenLockMinDelay = 1
+	ScreenLockMaxDelay = 1440
+)
+
+// ScreenLockSettings reports the effective screen lock settings read back after they were applied
+type ScreenLockSettings struct {
+	User            string `json:"user"`             // user whose settings were changed
+	DelayMinutes    int    `json:"delay_minutes"`    // idle time before the screen locks
+	RequirePassword bool   `json:"require_password"` // true if a password is required to unlock
+}
+
+// SetScreenLock configures the screen to lock after delayMinutes of inactivity and to
+// require a password to unlock. On macOS this must be called in the console user's context.
+func (a *Actions) SetScreenLock(delayMinutes int) (ScreenLockSettings, error) {
+	if delayMinutes < ScreenLockMinDelay || delayMinutes > ScreenLockMaxDelay {
+		return ScreenLockSettings{}, fmt.Errorf("delay_minutes must be between %d and %d",
+			ScreenLockMinDelay, ScreenLockMaxDelay)
+	}
+	return a.setScreenLock(delayMinutes)
+}
@@ -0,0 +1,71 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// setScreenLock writes the ByHost screensaver preferences for the current user. The
+// preferences are per-user, so this must run as the console user (i.e., via the user-helper)
+func (a *Actions) setScreenLock(delayMinutes int) (ScreenLockSettings, error) {
+	if os.Geteuid() == 0 {
+		return ScreenLockSettings{}, errors.New("screen lock settings must be applied in the console user's context")
+	}
+
+	settings := ScreenLockSettings{User: "unknown"}
+	if u, err := user.Current(); err == nil {
+		settings.User = u.Username
+	}
+
+	writes := [][]string{
+		{"defaults", "-currentHost", "write", "com.apple.screensaver", "idleTime", "-int", strconv.Itoa(delayMinutes * 60)},
+		{"defaults", "-currentHost", "write", "com.apple.screensaver", "askForPassword", "-int", "1"},
+		{"defaults", "-currentHost", "write", "com.apple.screensaver", "askForPasswordDelay", "-int", "0"},
+	}
+	for _, args := range writes {
+		out, err := a.runner.Combined(args...)
+		if err != nil {
+			return settings, fmt.Errorf("failed to set %s: %s", args[4], cmdOutput(out, err))
+		}
+	}
+
+	// Read the values back to report what is actually in effect
+	idle, err := a.darwinScreenSaverInt("idleTime")
+	if err != nil {
+		return settings, err
+	}
+	settings.DelayMinutes = idle / 60
+
+	ask, err := a.darwinScreenSaverInt("askForPassword")
+	if err != nil {
+		return settings, err
+	}
+	settings.RequirePassword = ask == 1
+
+	return settings, nil
+}
+
+// darwinScreenSaverInt reads an integer value from the current user's ByHost screensaver preferences
+func (a *Actions) darwinScreenSaverInt(key string) (int, error) {
+	out, err := a.runner.Stdout("defaults", "-currentHost", "read", "com.apple.screensaver", key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %s", key, cmdOutput(out, err))
+	}
+
+	v, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected value for %s: %s", key, strings.TrimSpace(out))
+	}
+	return v, nil
+}
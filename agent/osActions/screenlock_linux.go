@@ -0,0 +1,105 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// setScreenLock configures GNOME's idle delay and screen lock using gsettings. The
+// settings belong to the desktop user, so gsettings is run as that user against
+// their session bus.
+func (a *Actions) setScreenLock(delayMinutes int) (ScreenLockSettings, error) {
+	settings := ScreenLockSettings{}
+
+	if _, err := exec.LookPath("gsettings"); err != nil {
+		return settings, errors.New("gsettings not found: only GNOME desktops are supported")
+	}
+
+	username, bus, err := linuxSessionUser()
+	if err != nil {
+		return settings, err
+	}
+	settings.User = username
+
+	writes := [][]string{
+		{"org.gnome.desktop.session", "idle-delay", fmt.Sprintf("uint32 %d", delayMinutes*60)},
+		{"org.gnome.desktop.screensaver", "idle-activation-enabled", "true"},
+		{"org.gnome.desktop.screensaver", "lock-enabled", "true"},
+		{"org.gnome.desktop.screensaver", "lock-delay", "uint32 0"},
+	}
+	for _, w := range writes {
+		out, err := a.gsettings(username, bus, "set", w[0], w[1], w[2])
+		if err != nil {
+			return settings, fmt.Errorf("failed to set %s: %s", w[1], cmdOutput(out, err))
+		}
+	}
+
+	// Read the values back to report what is actually in effect
+	out, err := a.gsettings(username, bus, "get", "org.gnome.desktop.session", "idle-delay")
+	if err != nil {
+		return settings, fmt.Errorf("failed to read idle-delay: %s", cmdOutput(out, err))
+	}
+
+	// gsettings reports typed values as "uint32 300"
+	f := strings.Fields(out)
+	if len(f) == 0 {
+		return settings, errors.New("failed to read idle-delay: no value returned")
+	}
+	seconds, err := strconv.Atoi(f[len(f)-1])
+	if err != nil {
+		return settings, fmt.Errorf("unexpected idle-delay value: %s", strings.TrimSpace(out))
+	}
+	settings.DelayMinutes = seconds / 60
+
+	out, err = a.gsettings(username, bus, "get", "org.gnome.desktop.screensaver", "lock-enabled")
+	if err != nil {
+		return settings, fmt.Errorf("failed to read lock-enabled: %s", cmdOutput(out, err))
+	}
+	settings.RequirePassword = strings.TrimSpace(out) == "true"
+
+	return settings, nil
+}
+
+// gsettings runs gsettings as the specified user, connected to their session bus
+func (a *Actions) gsettings(username, bus string, args ...string) (string, error) {
+	cmd := []string{"runuser", "-u", username, "--", "env", "DBUS_SESSION_BUS_ADDRESS=unix:path=" + bus, "gsettings"}
+	return a.runner.Stdout(append(cmd, args...)...)
+}
+
+// linuxSessionUser finds a logged-in desktop user by looking for a session bus
+// under /run/user and returns the username and the path to the bus socket
+func linuxSessionUser() (string, string, error) {
+	buses, _ := filepath.Glob("/run/user/*/bus")
+	for _, bus := range buses {
+		uid := filepath.Base(filepath.Dir(bus))
+
+		// Skip system accounts such as gdm
+		if n, err := strconv.Atoi(uid); err != nil || n < 1000 {
+			continue
+		}
+
+		if _, err := os.Stat(bus); err != nil {
+			continue
+		}
+
+		u, err := user.LookupId(uid)
+		if err != nil {
+			continue
+		}
+		return u.Username, bus, nil
+	}
+	return "", "", errors.New("no logged-in desktop user found")
+}
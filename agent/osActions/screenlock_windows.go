@@ -0,0 +1,90 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// setScreenLock sets the screen saver timeout and password requirement for the last
+// logged-on user. The agent runs as a service, so HKEY_CURRENT_USER would refer to the
+// service account; the user's hive is opened via HKEY_USERS and their SID instead.
+func (a *Actions) setScreenLock(delayMinutes int) (ScreenLockSettings, error) {
+	settings := ScreenLockSettings{}
+
+	logonUI, err := registry.OpenKey(registry.LOCAL_MACHINE,
+		`SOFTWARE\Microsoft\Windows\CurrentVersion\Authentication\LogonUI`, registry.QUERY_VALUE)
+	if err != nil {
+		return settings, fmt.Errorf("error opening LogonUI key: %w", err)
+	}
+	defer func(k registry.Key) {
+		_ = k.Close()
+	}(logonUI)
+
+	sid, _, err := logonUI.GetStringValue("LastLoggedOnUserSID")
+	if err != nil {
+		return settings, fmt.Errorf("error getting LastLoggedOnUserSID: %w", err)
+	}
+	settings.User, _, _ = logonUI.GetStringValue("LastLoggedOnUser")
+
+	desktop, err := registry.OpenKey(registry.USERS, sid+`\Control Panel\Desktop`,
+		registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		return settings, fmt.Errorf("error opening desktop settings for %s (is the user's profile loaded?): %w", sid, err)
+	}
+	defer func(k registry.Key) {
+		_ = k.Close()
+	}(desktop)
+
+	values := map[string]string{
+		"ScreenSaveActive":    "1",
+		"ScreenSaverIsSecure": "1",
+		"ScreenSaveTimeOut":   strconv.Itoa(delayMinutes * 60),
+	}
+
+	// A screen saver must be selected for the timeout to take effect. Use the blank
+	// screen saver if none is configured, but leave an existing selection alone.
+	scr, _, err := desktop.GetStringValue("SCRNSAVE.EXE")
+	if err != nil || scr == "" || scr == "(none)" {
+		root := os.Getenv("SystemRoot")
+		if root == "" {
+			root = `C:\Windows`
+		}
+		values["SCRNSAVE.EXE"] = filepath.Join(root, "System32", "scrnsave.scr")
+	}
+
+	for name, value := range values {
+		if err = desktop.SetStringValue(name, value); err != nil {
+			return settings, fmt.Errorf("error setting %s: %w", name, err)
+		}
+	}
+
+	// Read the values back to report what is actually in effect
+	timeout, _, err := desktop.GetStringValue("ScreenSaveTimeOut")
+	if err != nil {
+		return settings, fmt.Errorf("error reading ScreenSaveTimeOut: %w", err)
+	}
+	seconds, err := strconv.Atoi(timeout)
+	if err != nil {
+		return settings, fmt.Errorf("unexpected ScreenSaveTimeOut value: %s", timeout)
+	}
+	settings.DelayMinutes = seconds / 60
+
+	secure, _, err := desktop.GetStringValue("ScreenSaverIsSecure")
+	if err != nil {
+		return settings, fmt.Errorf("error reading ScreenSaverIsSecure: %w", err)
+	}
+	settings.RequirePassword = secure == "1"
+
+	return settings, nil
+}
@@ -0,0 +1,24 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import "time"
+
+// serviceTimeout is the time allowed for a service to start or stop
+const serviceTimeout = 60 * time.Second
+
+// ServiceState describes a service after a service_control action
+type ServiceState struct {
+	Name   string `json:"name"`
+	State  string `json:"state"`            // as reported by the service manager, such as running or stopped
+	Output string `json:"output,omitempty"` // output from the service manager, if any
+}
+
+// ServiceControl starts, stops, restarts, or queries a service and returns its resulting state.
+// The action and name are validated by the command definition.
+func (a *Actions) ServiceControl(name, action string) (ServiceState, error) {
+	return a.serviceControl(name, action)
+}
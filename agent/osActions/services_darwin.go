@@ -0,0 +1,63 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	launchdState = regexp.MustCompile(`(?m)^\s*state = (.+)$`)
+	launchdPID   = regexp.MustCompile(`(?m)^\s*pid = (\d+)$`)
+)
+
+// serviceControl uses launchctl. The name is a launchd label in the system domain, or a
+// service target that includes the domain, such as gui/501/com.example.agent.
+func (a *Actions) serviceControl(name, action string) (ServiceState, error) {
+	state := ServiceState{Name: name}
+
+	target := name
+	if !strings.Contains(name, "/") {
+		target = "system/" + name
+	}
+
+	var args []string
+	switch action {
+	case "start":
+		args = []string{"launchctl", "kickstart", target}
+	case "stop":
+		// Services with KeepAlive set are restarted by launchd
+		args = []string{"launchctl", "kill", "SIGTERM", target}
+	case "restart":
+		args = []string{"launchctl", "kickstart", "-k", target}
+	}
+
+	if args != nil {
+		out, err := a.runner.Combined(args...)
+		state.Output = strings.TrimSpace(out)
+		if err != nil {
+			return state, fmt.Errorf("launchctl %s %s failed: %s", args[1], target, cmdOutput(out, err))
+		}
+	}
+
+	out, err := a.runner.Combined("launchctl", "print", target)
+	if err != nil {
+		return state, fmt.Errorf("launchctl print %s failed: %s", target, cmdOutput(out, err))
+	}
+
+	state.State = "unknown"
+	if m := launchdState.FindStringSubmatch(out); m != nil {
+		state.State = strings.TrimSpace(m[1])
+	}
+	if m := launchdPID.FindStringSubmatch(out); m != nil {
+		state.State += fmt.Sprintf(" (pid %s)", m[1])
+	}
+	return state, nil
+}
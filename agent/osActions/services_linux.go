@@ -0,0 +1,41 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// serviceControl uses systemctl, which waits for start, stop, and restart to complete
+func (a *Actions) serviceControl(name, action string) (ServiceState, error) {
+	state := ServiceState{Name: name}
+
+	if action == "status" {
+		// systemctl status exits with 3 if the unit is not running and 4 if it does not exist
+		out, err := a.runner.Combined("systemctl", "status", "--no-pager", "--lines=10", name)
+		var exitErr *exec.ExitError
+		if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 3) {
+			return state, fmt.Errorf("systemctl status %s failed: %s", name, cmdOutput(out, err))
+		}
+		state.Output = strings.TrimSpace(out)
+	} else {
+		out, err := a.runner.Combined("systemctl", action, name)
+		state.Output = strings.TrimSpace(out)
+		if err != nil {
+			return state, fmt.Errorf("systemctl %s %s failed: %s", action, name, cmdOutput(out, err))
+		}
+	}
+
+	// is-active exits with a non-zero code unless the unit is active, but still reports the state
+	out, _ := a.runner.Stdout("systemctl", "is-active", name)
+	state.State = strings.TrimSpace(out)
+	return state, nil
+}
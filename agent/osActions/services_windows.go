@@ -0,0 +1,81 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+
+	"github.com/UnifyEM/UnifyEM/common/uemservice/winsvcutil"
+)
+
+// serviceControl uses the Service Control Manager and waits for the service to start or stop
+func (a *Actions) serviceControl(name, action string) (ServiceState, error) {
+	state := ServiceState{Name: name}
+
+	// Connect to the service manager
+	m, err := mgr.Connect()
+	if err != nil {
+		return state, fmt.Errorf("error connecting to service manager: %w", err)
+	}
+	defer func(m *mgr.Mgr) {
+		_ = m.Disconnect()
+	}(m)
+
+	// Open the service
+	service, err := m.OpenService(name)
+	if err != nil {
+		return state, fmt.Errorf("error opening service %s: %w", name, err)
+	}
+	defer func(service *mgr.Service) {
+		_ = service.Close()
+	}(service)
+
+	status, err := service.Query()
+	if err != nil {
+		return state, fmt.Errorf("error querying service status: %w", err)
+	}
+
+	// Stop the service if required
+	if (action == "stop" || action == "restart") && status.State != svc.Stopped {
+		_, err = service.Control(svc.Stop)
+		if err != nil {
+			return state, fmt.Errorf("error stopping service: %w", err)
+		}
+
+		status, err = winsvcutil.WaitForState(service, svc.Stopped, serviceTimeout)
+		if err != nil {
+			state.State = winsvcutil.StateName(status.State)
+			return state, err
+		}
+		state.Output = "service stopped"
+	}
+
+	// Start the service if required
+	if (action == "start" || action == "restart") && status.State != svc.Running {
+		err = service.Start()
+		if err != nil {
+			return state, fmt.Errorf("error starting service: %w", err)
+		}
+
+		status, err = winsvcutil.WaitForState(service, svc.Running, serviceTimeout)
+		if err != nil {
+			state.State = winsvcutil.StateName(status.State)
+			return state, err
+		}
+		state.Output = "service started"
+		if action == "restart" {
+			state.Output = "service restarted"
+		}
+	}
+
+	state.State = winsvcutil.StateName(status.State)
+	return state, nil
+}
@@ -0,0 +1,11 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+// shells are tried in order for remote shell sessions, /bin/sh is used if none exist
+var shells = []string{"/bin/bash"}
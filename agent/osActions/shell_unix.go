@@ -0,0 +1,71 @@
+//go:build darwin || linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/creack/pty"
+)
+
+// Shell is an interactive shell running in a pseudo-terminal
+type Shell struct {
+	cmd *exec.Cmd
+	pty *os.File
+}
+
+// StartShell starts an interactive login shell as the agent's user in a pseudo-terminal
+func (a *Actions) StartShell() (*Shell, error) {
+	path := "/bin/sh"
+	for _, s := range shells {
+		if _, err := os.Stat(s); err == nil {
+			path = s
+			break
+		}
+	}
+
+	cmd := exec.Command(path, "-l")
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+	cmd.Dir = "/"
+	if home, err := os.UserHomeDir(); err == nil {
+		cmd.Dir = home
+	}
+
+	ptmx, err := pty.Start(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", path, err)
+	}
+	return &Shell{cmd: cmd, pty: ptmx}, nil
+}
+
+// Read reads output from the shell
+func (s *Shell) Read(p []byte) (int, error) {
+	return s.pty.Read(p)
+}
+
+// Write sends input to the shell
+func (s *Shell) Write(p []byte) (int, error) {
+	return s.pty.Write(p)
+}
+
+// Resize sets the size of the terminal
+func (s *Shell) Resize(rows, cols int) error {
+	return pty.Setsize(s.pty, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)})
+}
+
+// Close terminates the shell and any processes that it started in the foreground
+func (s *Shell) Close() error {
+	if s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+	}
+	err := s.pty.Close()
+	_ = s.cmd.Wait()
+	return err
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import "errors"
+
+// Shell is not yet implemented on Windows, where a pseudo-terminal requires the ConPTY API
+type Shell struct{}
+
+// StartShell is not supported on Windows
+func (a *Actions) StartShell() (*Shell, error) {
+	return nil, errors.New("remote shell is not supported on Windows")
+}
+
+func (s *Shell) Read(_ []byte) (int, error) {
+	return 0, errors.New("remote shell is not supported on Windows")
+}
+
+func (s *Shell) Write(_ []byte) (int, error) {
+	return 0, errors.New("remote shell is not supported on Windows")
+}
+
+func (s *Shell) Resize(_, _ int) error {
+	return nil
+}
+
+func (s *Shell) Close() error {
+	return nil
+}
@@ -338,31 +338,6 @@ func (a *Actions) userExists(username string) (bool, error) {
 	return true, nil
 }
 
-func (a *Actions) testCredentials(user string, pass string) error {
-	if user == "" || pass == "" {
-		return fmt.Errorf("username and password are required")
-	}
-
-	// Use su to validate credentials
-	// Create a command that attempts to switch to the user with their password
-	cmd := exec.Command("su", "-c", "exit 0", user)
-	cmd.Stdin = strings.NewReader(pass + "\n")
-
-	// Capture output to check for specific error messages
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		// Check for authentication failure vs other errors
-		outputStr := string(output)
-		if strings.Contains(outputStr, "Authentication failure") ||
-			strings.Contains(outputStr, "incorrect password") {
-			return fmt.Errorf("authentication failed for user %s: invalid credentials", user)
-		}
-		return fmt.Errorf("failed to validate credentials for user %s: %w", user, err)
-	}
-
-	return nil
-}
-
 // refreshServiceAccount generates a new password for the service account and ensures it's an administrator
 // Returns the new password on success
 func (a *Actions) refreshServiceAccount(userInfo UserInfo) (string, error) {
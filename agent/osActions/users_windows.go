@@ -17,6 +17,7 @@ import (
 	"github.com/StackExchange/wmi"
 
 	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/runCmd"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
@@ -157,12 +158,12 @@ func (a *Actions) setPassword(userInfo UserInfo) error {
 		return fmt.Errorf("username and password are required")
 	}
 
-	escapedPW := escapePowerShellString(userInfo.Password)
+	quotedPW := runCmd.PowerShellQuote(userInfo.Password)
 	_, err := a.runner.Combined(
 		"powershell", "-Command",
 		fmt.Sprintf(
-			"Set-LocalUser -Name '%s' -Password (ConvertTo-SecureString '%s' -AsPlainText -Force)",
-			userInfo.Username, escapedPW,
+			"Set-LocalUser -Name %s -Password (ConvertTo-SecureString %s -AsPlainText -Force)",
+			runCmd.PowerShellQuote(userInfo.Username), quotedPW,
 		),
 	)
 	if err != nil {
@@ -179,12 +180,12 @@ func (a *Actions) addUser(userInfo UserInfo) error {
 	}
 
 	// Create the user and set the password
-	escapedPW := escapePowerShellString(userInfo.Password)
+	quotedPW := runCmd.PowerShellQuote(userInfo.Password)
 	_, err := a.runner.Combined(
 		"powershell", "-Command",
 		fmt.Sprintf(
-			"New-LocalUser -Name '%s' -Password (ConvertTo-SecureString '%s' -AsPlainText -Force) -PasswordNeverExpires -AccountNeverExpires",
-			userInfo.Username, escapedPW,
+			"New-LocalUser -Name %s -Password (ConvertTo-SecureString %s -AsPlainText -Force) -PasswordNeverExpires -AccountNeverExpires",
+			runCmd.PowerShellQuote(userInfo.Username), quotedPW,
 		),
 	)
 	if err != nil {
@@ -192,13 +193,13 @@ func (a *Actions) addUser(userInfo UserInfo) error {
 	}
 
 	// Add the user's password to allow boot drive bitlocker access
-	escapedPWBL := escapePowerShellString(userInfo.Password)
+	quotedPWBL := runCmd.PowerShellQuote(userInfo.Password)
 	_, err = a.runner.Combined(
 		"powershell",
 		"-Command",
 		fmt.Sprintf(
-			"Add-BitLockerKeyProtector -MountPoint 'C:' -PasswordProtector -Password (ConvertTo-SecureString '%s' -AsPlainText -Force)",
-			escapedPWBL,
+			"Add-BitLockerKeyProtector -MountPoint 'C:' -PasswordProtector -Password (ConvertTo-SecureString %s -AsPlainText -Force)",
+			quotedPWBL,
 		),
 	)
 	if err != nil {
@@ -374,12 +375,12 @@ func (a *Actions) refreshServiceAccount(userInfo UserInfo) (string, error) {
 	newPassword := crypto.RandomPassword()
 
 	// Set the new password using PowerShell (no interactive prompt, no 14-char limit)
-	escapedPW := escapePowerShellString(newPassword)
+	quotedPW := runCmd.PowerShellQuote(newPassword)
 	_, err = a.runner.Combined(
 		"powershell", "-Command",
 		fmt.Sprintf(
-			"Set-LocalUser -Name '%s' -Password (ConvertTo-SecureString '%s' -AsPlainText -Force)",
-			userInfo.Username, escapedPW,
+			"Set-LocalUser -Name %s -Password (ConvertTo-SecureString %s -AsPlainText -Force)",
+			runCmd.PowerShellQuote(userInfo.Username), quotedPW,
 		),
 	)
 	if err != nil {
@@ -394,12 +395,12 @@ func (a *Actions) refreshServiceAccount(userInfo UserInfo) (string, error) {
 	}
 
 	// Add new password protector
-	escapedPWBL := escapePowerShellString(newPassword)
+	quotedPWBL := runCmd.PowerShellQuote(newPassword)
 	_, addErr := a.runner.Combined(
 		"powershell", "-Command",
 		fmt.Sprintf(
-			"Add-BitLockerKeyProtector -MountPoint 'C:' -PasswordProtector -Password (ConvertTo-SecureString '%s' -AsPlainText -Force)",
-			escapedPWBL,
+			"Add-BitLockerKeyProtector -MountPoint 'C:' -PasswordProtector -Password (ConvertTo-SecureString %s -AsPlainText -Force)",
+			quotedPWBL,
 		),
 	)
 	if addErr != nil {
@@ -413,10 +414,3 @@ func (a *Actions) refreshServiceAccount(userInfo UserInfo) (string, error) {
 
 	return newPassword, nil
 }
-
-// escapePowerShellString escapes special characters for use in PowerShell single-quoted strings
-func escapePowerShellString(s string) string {
-	// In PowerShell single-quoted strings, only single quotes need escaping (doubled)
-	// Backticks, dollar signs, etc. are literal in single quotes
-	return strings.ReplaceAll(s, "'", "''")
-}
@@ -23,6 +23,11 @@ func safeUsername(s string) (string, error) {
 		return "", nil
 	}
 
+	// Commands would treat a leading '-' as an option
+	if strings.HasPrefix(s, "-") {
+		return "", fmt.Errorf("username can not start with '-'")
+	}
+
 	for _, char := range s {
 		if strings.ContainsRune(schema.ValidUsernameChars, char) {
 			filtered.WriteRune(char)
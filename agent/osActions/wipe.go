@@ -0,0 +1,110 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Wipe strategies recorded in the final wipe event
+const (
+	wipeStrategyDelete = "delete"
+)
+
+// Wipe destroys data on the device. A corporate wipe deletes paths, which may contain
+// wildcards such as /Users/*/Library/Mail. A full wipe destroys all data using the best
+// strategy available and then shuts down the system. report is called before each step, so
+// that the server can see that the wipe began, and at the end with the strategy used.
+func (a *Actions) Wipe(mode string, paths []string, report func(string)) error {
+	if mode == "" {
+		mode = schema.WipeModeFull
+	}
+	if !schema.ValidWipeMode(mode) {
+		return fmt.Errorf("unknown wipe mode: %s", mode)
+	}
+
+	report(fmt.Sprintf("wipe started: mode=%s", mode))
+
+	var strategy string
+	var err error
+	if mode == schema.WipeModeCorporate {
+		strategy, err = a.wipePaths(paths, report)
+	} else {
+		strategy, err = a.wipeFull(report)
+	}
+
+	if err != nil {
+		report(fmt.Sprintf("wipe incomplete: mode=%s strategy=%s: %s", mode, strategy, err.Error()))
+	} else {
+		report(fmt.Sprintf("wipe complete: mode=%s strategy=%s", mode, strategy))
+	}
+
+	// Shut down after a full wipe, even if part of it failed, so that encrypted volumes are locked
+	if mode == schema.WipeModeFull {
+		return errors.Join(err, a.shutdownOrReboot(false))
+	}
+	return err
+}
+
+// wipePaths deletes the paths configured for a corporate wipe
+func (a *Actions) wipePaths(paths []string, report func(string)) (string, error) {
+	var targets []string
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		matches, err := filepath.Glob(os.ExpandEnv(path))
+		if err != nil {
+			return wipeStrategyDelete, fmt.Errorf("invalid wipe path %s: %w", path, err)
+		}
+		targets = append(targets, matches...)
+	}
+
+	if len(targets) == 0 {
+		return wipeStrategyDelete, errors.New("no paths in " + schema.ConfigAgentWipePaths + " exist")
+	}
+	return wipeStrategyDelete, a.deleteTargets(targets, nil, report)
+}
+
+// deleteTargets deletes each target, except those whose base name is in skip. Root directories
+// are never deleted, in case a path is misconfigured.
+func (a *Actions) deleteTargets(targets []string, skip []string, report func(string)) error {
+	var failed []string
+	for _, target := range targets {
+		if filepath.Dir(target) == target || slices.Contains(skip, filepath.Base(target)) {
+			continue
+		}
+
+		report("wiping " + target)
+		if err := os.RemoveAll(target); err != nil {
+			a.logger.Errorf(8700, "error deleting %s: %s", target, err.Error())
+			failed = append(failed, target)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("unable to delete %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// deleteProfiles deletes the users' home directories matching pattern
+func (a *Actions) deleteProfiles(pattern string, skip []string, report func(string)) error {
+	profiles, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	return a.deleteTargets(profiles, skip, report)
+}
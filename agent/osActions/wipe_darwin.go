@@ -0,0 +1,59 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// wipeFull crypto-erases the data volume if FileVault is enabled. The recovery keys are removed
+// first, and erasing an encrypted APFS volume destroys its volume key. If FileVault is off or
+// the volume can not be erased, users' home directories are deleted.
+func (a *Actions) wipeFull(report func(string)) (string, error) {
+	var errs []error
+
+	out, err := a.runner.Combined("fdesetup", "status")
+	if err == nil && strings.Contains(out, "FileVault is On") {
+		report("removing FileVault recovery keys")
+		for _, key := range []string{"-personal", "-institutional"} {
+			if out, err = a.runner.Combined("fdesetup", "removerecovery", key); err != nil {
+				a.logger.Warningf(8701, "fdesetup removerecovery %s failed: %s", key, cmdOutput(out, err))
+			}
+		}
+
+		device, err := a.dataVolume()
+		if err == nil {
+			report(fmt.Sprintf("volume %s being wiped: erasing FileVault volume", device))
+			out, err = a.runner.Combined("diskutil", "apfs", "eraseVolume", device, "-name", "Data")
+		}
+		if err == nil {
+			return "filevault-crypto-erase", nil
+		}
+		errs = append(errs, fmt.Errorf("unable to erase data volume: %s", cmdOutput(out, err)))
+	}
+
+	errs = append(errs, a.deleteProfiles("/Users/*", []string{"Shared"}, report))
+	return wipeStrategyDelete, errors.Join(errs...)
+}
+
+// dataVolume returns the device identifier of the volume mounted at /System/Volumes/Data
+func (a *Actions) dataVolume() (string, error) {
+	out, err := a.runner.Stdout("diskutil", "info", "/System/Volumes/Data")
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if device, ok := strings.CutPrefix(strings.TrimSpace(line), "Device Identifier:"); ok {
+			return strings.TrimSpace(device), nil
+		}
+	}
+	return "", errors.New("data volume not found")
+}
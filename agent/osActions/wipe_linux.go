@@ -0,0 +1,69 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// wipeFull destroys the LUKS key slots of encrypted volumes, which makes their data
+// unrecoverable once the system shuts down. Without LUKS, users' home directories are deleted.
+func (a *Actions) wipeFull(report func(string)) (string, error) {
+	var errs []error
+	erased := false
+	for _, device := range a.luksDevices() {
+		report(fmt.Sprintf("volume %s being wiped: erasing LUKS key slots", device))
+		out, err := a.runner.Combined("cryptsetup", "luksErase", "--batch-mode", device)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cryptsetup luksErase %s failed: %s", device, cmdOutput(out, err)))
+			continue
+		}
+		erased = true
+	}
+
+	if erased {
+		return "luks-erase", errors.Join(errs...)
+	}
+
+	errs = append(errs, a.deleteProfiles("/home/*", nil, report))
+	errs = append(errs, a.deleteTargets([]string{"/root"}, nil, report))
+	return wipeStrategyDelete, errors.Join(errs...)
+}
+
+// luksDevices returns the devices underlying open dm-crypt mappings
+func (a *Actions) luksDevices() []string {
+	out, err := a.runner.Stdout("lsblk", "--raw", "--noheadings", "--output", "NAME,TYPE")
+	if err != nil {
+		return nil
+	}
+
+	var devices []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[1] != "crypt" {
+			continue
+		}
+
+		status, err := a.runner.Stdout("cryptsetup", "status", fields[0])
+		if err != nil {
+			continue
+		}
+		for _, statusLine := range strings.Split(status, "\n") {
+			if device, ok := strings.CutPrefix(strings.TrimSpace(statusLine), "device:"); ok {
+				device = strings.TrimSpace(device)
+				if device != "" && !slices.Contains(devices, device) {
+					devices = append(devices, device)
+				}
+			}
+		}
+	}
+	return devices
+}
@@ -0,0 +1,56 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func TestCorporateWipe(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"alice/Mail", "bob/Mail", "bob/Documents"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var events []string
+	report := func(status string) { events = append(events, status) }
+
+	err := New(null.Logger()).Wipe(schema.WipeModeCorporate, []string{filepath.Join(dir, "*", "Mail"), " "}, report)
+	if err != nil {
+		t.Fatalf("corporate wipe failed: %v", err)
+	}
+
+	for _, name := range []string{"alice/Mail", "bob/Mail"} {
+		if _, err = os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was not deleted", name)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(dir, "bob/Documents")); err != nil {
+		t.Errorf("path not configured for wipe was deleted: %v", err)
+	}
+
+	if len(events) != 4 || !strings.HasPrefix(events[0], "wipe started: mode=corporate") ||
+		!strings.HasPrefix(events[3], "wipe complete: mode=corporate strategy=delete") {
+		t.Errorf("unexpected progress events: %q", events)
+	}
+
+	// Nothing is deleted if no paths are configured
+	events = nil
+	if err = New(null.Logger()).Wipe(schema.WipeModeCorporate, []string{""}, report); err == nil {
+		t.Errorf("expected an error with no paths configured")
+	}
+	if len(events) != 2 || !strings.HasPrefix(events[1], "wipe incomplete") {
+		t.Errorf("unexpected progress events: %q", events)
+	}
+}
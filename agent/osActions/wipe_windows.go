@@ -0,0 +1,110 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package osActions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// wipeFull forces BitLocker recovery on the system drive, removing the TPM protectors so that
+// the drive can only be unlocked with the recovery password. Without BitLocker, users' profiles
+// are deleted. Other fixed disks are cleaned with diskpart.
+func (a *Actions) wipeFull(report func(string)) (string, error) {
+	var strategies []string
+	var errs []error
+
+	drive := os.Getenv("SystemDrive")
+	if drive == "" {
+		drive = "C:"
+	}
+
+	out, err := a.runner.Combined("manage-bde", "-status", drive)
+	if err == nil && strings.Contains(out, "Protection On") {
+		report(fmt.Sprintf("volume %s being wiped: forcing BitLocker recovery", drive))
+
+		// Other volumes must not unlock automatically once the system drive is locked
+		if out, err = a.runner.Combined("powershell", "-NoProfile", "-NonInteractive", "-Command", "Clear-BitLockerAutoUnlock"); err != nil {
+			a.logger.Warningf(8701, "Clear-BitLockerAutoUnlock failed: %s", cmdOutput(out, err))
+		}
+
+		out, err = a.runner.Combined("manage-bde", "-forcerecovery", drive)
+		if err == nil {
+			strategies = append(strategies, "bitlocker-forcerecovery")
+		} else {
+			errs = append(errs, fmt.Errorf("manage-bde -forcerecovery failed: %s", cmdOutput(out, err)))
+		}
+	}
+
+	if len(strategies) == 0 {
+		strategies = append(strategies, wipeStrategyDelete)
+		errs = append(errs, a.deleteProfiles(filepath.Join(drive+`\`, "Users", "*"),
+			[]string{"Public", "Default", "Default User", "All Users"}, report))
+	}
+
+	disks, err := a.dataDisks()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, disk := range disks {
+		report(fmt.Sprintf("disk %d being wiped: diskpart clean", disk))
+		if err = a.diskpartClean(disk); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(strategies) == 1 {
+			strategies = append(strategies, "diskpart-clean")
+		}
+	}
+
+	return strings.Join(strategies, ", "), errors.Join(errs...)
+}
+
+// dataDisks returns the numbers of the fixed disks that do not contain the system or boot volume
+func (a *Actions) dataDisks() ([]int, error) {
+	out, err := a.runner.Stdout("powershell", "-NoProfile", "-NonInteractive", "-Command",
+		"Get-Disk | Where-Object { -not $_.IsBoot -and -not $_.IsSystem -and $_.BusType -ne 'USB' } | ForEach-Object { $_.Number }")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list disks: %s", cmdOutput(out, err))
+	}
+
+	var disks []int
+	for _, field := range strings.Fields(out) {
+		if disk, err := strconv.Atoi(field); err == nil {
+			disks = append(disks, disk)
+		}
+	}
+	return disks, nil
+}
+
+// diskpartClean removes all partitions from a disk
+func (a *Actions) diskpartClean(disk int) error {
+	script, err := os.CreateTemp("", "uem-wipe-*.txt")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(script.Name()) }()
+
+	_, err = fmt.Fprintf(script, "select disk %d\r\nclean\r\n", disk)
+	if closeErr := script.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	out, err := a.runner.Combined("diskpart", "/s", script.Name())
+	if err != nil {
+		return fmt.Errorf("diskpart clean of disk %d failed: %s", disk, cmdOutput(out, err))
+	}
+	return nil
+}
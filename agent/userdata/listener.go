@@ -8,10 +8,12 @@ package userdata
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
-	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/UnifyEM/UnifyEM/agent/functions/status"
@@ -19,43 +21,39 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 )
 
-// UserDataListener manages the Unix socket server for receiving user-context data
+// UserDataListener manages the socket server for receiving user-context data
 //
 //goland:noinspection GoNameStartsWithPackageName
 type UserDataListener struct {
-	logger          interfaces.Logger
-	listener        net.Listener
-	mu              sync.RWMutex
-	consoleUserData status.UserContextData // Only store console user data
-	hasData         bool
-	running         bool
+	logger      interfaces.Logger
+	listener    net.Listener
+	mu          sync.RWMutex
+	userData    map[string]status.UserContextData // keyed by the verified username
+	running     atomic.Bool
+	pending     []status.UserRequest                     // requests awaiting delivery to the user-helper
+	waiters     map[string]chan status.UserRequestResult // keyed by request ID
+	consoleUser func() (string, error)
 }
 
 // New creates a new UserDataListener instance
 func New(logger interfaces.Logger) *UserDataListener {
 	return &UserDataListener{
-		logger: logger,
+		logger:      logger,
+		userData:    make(map[string]status.UserContextData),
+		waiters:     make(map[string]chan status.UserRequestResult),
+		consoleUser: consoleUser,
 	}
 }
 
-// Start begins listening on the Unix socket
+// Start begins listening on the socket, or named pipe on Windows
 func (l *UserDataListener) Start() error {
-	// Remove stale socket if it exists
-	_ = os.Remove(global.SocketPath)
-
-	listener, err := net.Listen("unix", global.SocketPath)
+	listener, err := listen()
 	if err != nil {
-		return fmt.Errorf("failed to create socket listener: %w", err)
-	}
-
-	// Set permissions so user processes can connect
-	if err := os.Chmod(global.SocketPath, global.SocketPerms); err != nil {
-		_ = listener.Close()
-		return fmt.Errorf("failed to set socket permissions: %w", err)
+		return err
 	}
 
 	l.listener = listener
-	l.running = true
+	l.running.Store(true)
 	l.logger.Infof(3100, "User data listener started on %s", global.SocketPath)
 
 	// Start accepting connections in background
@@ -66,10 +64,10 @@ func (l *UserDataListener) Start() error {
 
 // acceptLoop handles incoming connections
 func (l *UserDataListener) acceptLoop() {
-	for l.running {
+	for l.running.Load() {
 		conn, err := l.listener.Accept()
 		if err != nil {
-			if l.running {
+			if l.running.Load() {
 				l.logger.Errorf(3101, "Error accepting connection: %v", err)
 			}
 			continue
@@ -89,29 +87,168 @@ func (l *UserDataListener) handleConnection(conn net.Conn) {
 	// Set read deadline
 	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
 
+	// Identify the user-helper before reading anything it sends
+	peer, err := peerUser(conn)
+	if err != nil {
+		l.logger.Warningf(3110, "Rejected user data: %v", err)
+		return
+	}
+
+	// Read one more byte than the limit to detect oversized messages
+	reader := &io.LimitedReader{R: conn, N: global.MaxUserDataSize + 1}
 	var data status.UserContextData
-	decoder := json.NewDecoder(conn)
-	if err := decoder.Decode(&data); err != nil {
+	decoder := json.NewDecoder(reader)
+	if err = decoder.Decode(&data); err != nil {
+		if reader.N <= 0 {
+			l.logger.Warningf(3112, "Rejected user data from %s: message exceeds %d bytes", peer, global.MaxUserDataSize)
+			return
+		}
 		l.logger.Errorf(3102, "Error decoding user data: %v", err)
 		return
 	}
 
-	// Store the received data (console user only)
+	// Only accept data from a helper running as the user it reports
+	if peer != data.Username {
+		l.logger.Warningf(3111, "Rejected user data for %s from %s", data.Username, peer)
+		return
+	}
+
+	// Deliver any results to the goroutines waiting for them
+	for _, result := range data.Results {
+		l.deliverResult(result)
+	}
+
+	// Reply with any pending requests, which are only performed by the console user's helper
+	l.sendPending(conn, l.isConsoleUser(peer))
+
+	// Poll messages only exchange requests and results
+	if data.Type == status.UserMessagePoll {
+		return
+	}
+
+	// Store the received data under the verified username
+	l.mu.Lock()
+	l.userData[peer] = data
+	l.mu.Unlock()
+
+	l.logger.Debugf(3103, "Received user data from %s: screen_lock=%s, delay=%s",
+		peer, data.ScreenLock, data.ScreenLockDelay)
+}
+
+// isConsoleUser returns true if username is the user logged in at the console
+func (l *UserDataListener) isConsoleUser(username string) bool {
+	console, err := l.consoleUser()
+	if err != nil {
+		l.logger.Debugf(3113, "Unable to determine console user: %v", err)
+		return false
+	}
+	return console == username
+}
+
+// deliverResult passes a result from the user-helper to the waiting requester
+func (l *UserDataListener) deliverResult(result status.UserRequestResult) {
+	l.mu.Lock()
+	ch, ok := l.waiters[result.ID]
+	delete(l.waiters, result.ID)
+	l.mu.Unlock()
+
+	if !ok {
+		l.logger.Warningf(3106, "Received result for unknown or expired user request %s", result.ID)
+		return
+	}
+
+	// The channel is buffered, so this never blocks
+	ch <- result
+}
+
+// sendPending writes pending requests to the user-helper, or an empty list if it is not the
+// console user's. The requests are removed from the pending list once written; the waiters
+// remain until a result arrives or the request times out.
+func (l *UserDataListener) sendPending(conn net.Conn, console bool) {
+	var requests []status.UserRequest
+	if console {
+		l.mu.Lock()
+		requests = l.pending
+		l.pending = nil
+		l.mu.Unlock()
+	}
+
+	// Always reply so that the user-helper does not wait for its read deadline
+	if requests == nil {
+		requests = []status.UserRequest{}
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewEncoder(conn).Encode(requests); err != nil {
+		l.logger.Errorf(3107, "Error sending user requests: %v", err)
+		return
+	}
+
+	for _, r := range requests {
+		l.logger.Debugf(3108, "Sent user request %s (%s) to user-helper", r.ID, r.Type)
+	}
+}
+
+// UserRequest queues a request for the console user's helper and waits for the result.
+// The helper picks up requests when it next polls the listener.
+func (l *UserDataListener) UserRequest(request status.UserRequest, timeout time.Duration) (status.UserRequestResult, error) {
+	if l == nil || !l.running.Load() {
+		return status.UserRequestResult{}, errors.New("user data listener is not running")
+	}
+
+	ch := make(chan status.UserRequestResult, 1)
+
 	l.mu.Lock()
-	l.consoleUserData = data
-	l.hasData = true
+	l.pending = append(l.pending, request)
+	l.waiters[request.ID] = ch
 	l.mu.Unlock()
 
-	l.logger.Debugf(3103, "Received console user data from %s: screen_lock=%s, delay=%s",
-		data.Username, data.ScreenLock, data.ScreenLockDelay)
+	select {
+	case result := <-ch:
+		return result, nil
+	case <-time.After(timeout):
+	}
+
+	// Timed out, so make sure the request is not delivered later
+	l.mu.Lock()
+	delete(l.waiters, request.ID)
+	for i, r := range l.pending {
+		if r.ID == request.ID {
+			l.pending = append(l.pending[:i], l.pending[i+1:]...)
+			break
+		}
+	}
+	l.mu.Unlock()
+
+	l.logger.Warningf(3109, "Timed out waiting for user request %s (%s)", request.ID, request.Type)
+	return status.UserRequestResult{}, fmt.Errorf("timed out after %v waiting for the console user's helper (is a user logged in?)", timeout)
 }
 
-// GetConsoleUserData retrieves stored user-context data for the console user
+// GetConsoleUserData retrieves stored user-context data for the user logged in at the console
 func (l *UserDataListener) GetConsoleUserData() (status.UserContextData, bool) {
+	if l == nil {
+		return status.UserContextData{}, false
+	}
+
+	username, err := l.consoleUser()
+	if err != nil {
+		l.logger.Debugf(3113, "Unable to determine console user: %v", err)
+		return status.UserContextData{}, false
+	}
+	return l.GetUserData(username)
+}
+
+// GetUserData retrieves stored user-context data for the specified user
+func (l *UserDataListener) GetUserData(username string) (status.UserContextData, bool) {
+	if l == nil {
+		return status.UserContextData{}, false
+	}
+
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
-	return l.consoleUserData, l.hasData
+	data, ok := l.userData[username]
+	return data, ok
 }
 
 // CleanStaleData removes user data older than the specified duration
@@ -119,21 +256,18 @@ func (l *UserDataListener) CleanStaleData(maxAge time.Duration) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if !l.hasData {
-		return
-	}
-
 	cutoff := time.Now().Add(-maxAge)
-	if l.consoleUserData.Timestamp.Before(cutoff) {
-		l.logger.Debugf(3104, "Removed stale user data for %s", l.consoleUserData.Username)
-		l.hasData = false
-		l.consoleUserData = status.UserContextData{}
+	for username, data := range l.userData {
+		if data.Timestamp.Before(cutoff) {
+			l.logger.Debugf(3104, "Removed stale user data for %s", username)
+			delete(l.userData, username)
+		}
 	}
 }
 
 // Stop closes the listener and cleans up
 func (l *UserDataListener) Stop() error {
-	l.running = false
+	l.running.Store(false)
 
 	if l.listener != nil {
 		if err := l.listener.Close(); err != nil {
@@ -142,7 +276,7 @@ func (l *UserDataListener) Stop() error {
 	}
 
 	// Clean up socket file
-	_ = os.Remove(global.SocketPath)
+	removeSocket()
 
 	l.logger.Infof(3105, "User data listener stopped")
 	return nil
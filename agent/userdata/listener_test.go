@@ -0,0 +1,88 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userdata
+
+import (
+	"encoding/json"
+	"io"
+	"os/user"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/functions/status"
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/common/null"
+)
+
+// send sends data to the listener and waits for it to close the connection
+func send(t *testing.T, data status.UserContextData) {
+	conn, err := Dial(time.Second)
+	if err != nil {
+		t.Fatalf("failed to connect to listener: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	// The listener closes the connection without reading all of a message that is too large
+	_ = json.NewEncoder(conn).Encode(data)
+	_, _ = io.Copy(io.Discard, conn)
+}
+
+func TestListenerPeerUser(t *testing.T) {
+	current, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := New(null.Logger())
+	l.consoleUser = func() (string, error) { return current.Username, nil }
+	if err = l.Start(); err != nil {
+		t.Skipf("unable to start listener: %v", err)
+	}
+	defer func() { _ = l.Stop() }()
+
+	// Data claiming to be from another user is rejected
+	send(t, status.UserContextData{Username: current.Username + "-other", Timestamp: time.Now(), ScreenLock: "no"})
+	if _, ok := l.GetUserData(current.Username + "-other"); ok {
+		t.Fatalf("accepted data for a user other than the peer")
+	}
+
+	send(t, status.UserContextData{Username: current.Username, Timestamp: time.Now(), ScreenLock: "yes"})
+	data, ok := l.GetConsoleUserData()
+	if !ok || data.ScreenLock != "yes" {
+		t.Errorf("expected data from %s to be stored, got %+v", current.Username, data)
+	}
+
+	// The data is only reported as the console user's while they are at the console
+	l.consoleUser = func() (string, error) { return "someone-else", nil }
+	if _, ok = l.GetConsoleUserData(); ok {
+		t.Errorf("reported data for %s as the console user's", current.Username)
+	}
+}
+
+func TestListenerSizeLimit(t *testing.T) {
+	current, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := New(null.Logger())
+	if err = l.Start(); err != nil {
+		t.Skipf("unable to start listener: %v", err)
+	}
+	defer func() { _ = l.Stop() }()
+
+	send(t, status.UserContextData{
+		Username:  current.Username,
+		Timestamp: time.Now(),
+		RawData:   map[string]string{"padding": strings.Repeat("x", global.MaxUserDataSize)},
+	})
+	if _, ok := l.GetUserData(current.Username); ok {
+		t.Errorf("accepted a message larger than %d bytes", global.MaxUserDataSize)
+	}
+}
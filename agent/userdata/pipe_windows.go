@@ -0,0 +1,97 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userdata
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/npipe"
+)
+
+// On Windows the listener is a named pipe, see the npipe package
+
+// pipeSDDL allows SYSTEM and administrators full access, and interactive users to read and write
+const pipeSDDL = "D:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;GRGW;;;IU)"
+
+// listen creates the named pipe that user-helpers connect to
+func listen() (net.Listener, error) {
+	return npipe.Listen(global.SocketPath, pipeSDDL, false)
+}
+
+// Dial connects to the agent's named pipe, waiting up to timeout if all instances are busy
+func Dial(timeout time.Duration) (net.Conn, error) {
+	return npipe.Dial(global.SocketPath, windows.GENERIC_READ|windows.GENERIC_WRITE, timeout)
+}
+
+// removeSocket does nothing because the pipe is removed when its last instance is closed
+func removeSocket() {}
+
+// peerUser returns the name of the user running the process at the other end of the pipe, in
+// the form DOMAIN\user used by os/user
+func peerUser(conn net.Conn) (string, error) {
+	pc, ok := conn.(*npipe.Conn)
+	if !ok {
+		return "", errors.New("not a named pipe")
+	}
+
+	var pid uint32
+	if err := windows.GetNamedPipeClientProcessId(pc.Handle(), &pid); err != nil {
+		return "", fmt.Errorf("unable to get pipe client: %w", err)
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return "", fmt.Errorf("unable to open pipe client process %d: %w", pid, err)
+	}
+	defer func() { _ = windows.CloseHandle(process) }()
+
+	var token windows.Token
+	if err = windows.OpenProcessToken(process, windows.TOKEN_QUERY, &token); err != nil {
+		return "", fmt.Errorf("unable to open pipe client token: %w", err)
+	}
+	defer func() { _ = token.Close() }()
+
+	return tokenUsername(token)
+}
+
+// consoleUser returns the name of the user logged in to the console session
+func consoleUser() (string, error) {
+	session := windows.WTSGetActiveConsoleSessionId()
+	if session == 0xFFFFFFFF {
+		return "", errors.New("there is no console session")
+	}
+
+	// Only available to services running as LocalSystem, as the agent does
+	var token windows.Token
+	if err := windows.WTSQueryUserToken(session, &token); err != nil {
+		return "", fmt.Errorf("no user is logged in at the console: %w", err)
+	}
+	defer func() { _ = token.Close() }()
+
+	return tokenUsername(token)
+}
+
+// tokenUsername returns the name of a token's user in the form DOMAIN\user
+func tokenUsername(token windows.Token) (string, error) {
+	tokenUser, err := token.GetTokenUser()
+	if err != nil {
+		return "", fmt.Errorf("unable to get token user: %w", err)
+	}
+
+	account, domain, _, err := tokenUser.User.Sid.LookupAccount("")
+	if err != nil {
+		return "", fmt.Errorf("unable to look up token user: %w", err)
+	}
+	return domain + `\` + account, nil
+}
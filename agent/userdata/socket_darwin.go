@@ -0,0 +1,99 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userdata
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+// listen creates the Unix socket that user-helpers connect to
+func listen() (net.Listener, error) {
+	// Remove stale socket if it exists
+	_ = os.Remove(global.SocketPath)
+
+	listener, err := net.Listen("unix", global.SocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket listener: %w", err)
+	}
+
+	// Allow local users, but not system accounts, to connect
+	if err = setSocketOwner(); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// setSocketOwner sets the socket's group and permissions so that members of the group can connect
+func setSocketOwner() error {
+	group, err := user.LookupGroup(global.SocketGroup)
+	if err != nil {
+		return fmt.Errorf("failed to look up socket group %s: %w", global.SocketGroup, err)
+	}
+
+	gid, err := strconv.Atoi(group.Gid)
+	if err != nil {
+		return fmt.Errorf("invalid group ID %s for %s", group.Gid, global.SocketGroup)
+	}
+
+	if err = os.Lchown(global.SocketPath, 0, gid); err != nil {
+		return fmt.Errorf("failed to set socket owner: %w", err)
+	}
+
+	if err = os.Chmod(global.SocketPath, global.SocketPerms); err != nil {
+		return fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return nil
+}
+
+// removeSocket removes the socket file
+func removeSocket() {
+	_ = os.Remove(global.SocketPath)
+}
+
+// peerUID returns the user ID of the process at the other end of a Unix socket
+func peerUID(fd int) (uint32, error) {
+	cred, err := unix.GetsockoptXucred(fd, unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	if err != nil {
+		return 0, err
+	}
+	return cred.Uid, nil
+}
+
+// consoleUser returns the name of the user logged in at the console, who owns /dev/console
+func consoleUser() (string, error) {
+	info, err := os.Stat("/dev/console")
+	if err != nil {
+		return "", err
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", fmt.Errorf("unable to get owner of /dev/console")
+	}
+
+	// root owns the console at the login window
+	if stat.Uid == 0 {
+		return "", fmt.Errorf("no user is logged in at the console")
+	}
+
+	u, err := user.LookupId(strconv.FormatUint(uint64(stat.Uid), 10))
+	if err != nil {
+		return "", fmt.Errorf("unable to look up console user %d: %w", stat.Uid, err)
+	}
+	return u.Username, nil
+}
@@ -0,0 +1,61 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userdata
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+// listen creates the abstract Unix socket that user-helpers connect to. Abstract sockets have
+// no file permissions, so any local user can connect, and peerUser identifies them.
+func listen() (net.Listener, error) {
+	listener, err := net.Listen("unix", global.SocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket listener: %w", err)
+	}
+	return listener, nil
+}
+
+// removeSocket does nothing because abstract sockets are removed when they are closed
+func removeSocket() {}
+
+// peerUID returns the user ID of the process at the other end of a Unix socket
+func peerUID(fd int) (uint32, error) {
+	cred, err := unix.GetsockoptUcred(fd, unix.SOL_SOCKET, unix.SO_PEERCRED)
+	if err != nil {
+		return 0, err
+	}
+	return cred.Uid, nil
+}
+
+// consoleUser returns the name of the user with the active session on the main seat
+func consoleUser() (string, error) {
+	out, err := exec.Command("loginctl", "show-seat", "seat0", "--property=ActiveSession", "--value").Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to get active session: %w", err)
+	}
+
+	session := strings.TrimSpace(string(out))
+	if session == "" {
+		return "", errors.New("no user is logged in at the console")
+	}
+
+	out, err = exec.Command("loginctl", "show-session", session, "--property=Name", "--value").Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to get user of session %s: %w", session, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
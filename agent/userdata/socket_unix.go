@@ -0,0 +1,55 @@
+//go:build linux || darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userdata
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/agent/global"
+)
+
+// Dial connects to the agent's user data listener
+func Dial(timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", global.SocketPath, timeout)
+}
+
+// peerUser returns the name of the user running the process at the other end of the connection
+func peerUser(conn net.Conn) (string, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return "", errors.New("not a unix socket")
+	}
+
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return "", err
+	}
+
+	var uid uint32
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		uid, credErr = peerUID(int(fd))
+	})
+	if err == nil {
+		err = credErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to get peer credentials: %w", err)
+	}
+
+	u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10))
+	if err != nil {
+		return "", fmt.Errorf("unable to look up peer user %d: %w", uid, err)
+	}
+	return u.Username, nil
+}
@@ -1,33 +1,33 @@
-//go:build darwin
-
 /******************************************************************************
  * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
  * Please see the LICENSE file for details                                    *
  ******************************************************************************/
 
-// Package userhelper provides user-context data collection for macOS
+// Package userhelper provides user-context data collection in the console user's session
 package userhelper
 
 import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"os/exec"
 	"os/user"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/UnifyEM/UnifyEM/agent/functions/status"
 	"github.com/UnifyEM/UnifyEM/agent/global"
+	"github.com/UnifyEM/UnifyEM/agent/osActions"
+	"github.com/UnifyEM/UnifyEM/agent/userdata"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
 )
 
 // UserHelper manages user-context data collection and transmission
 type UserHelper struct {
-	logger               interfaces.Logger
-	config               *global.AgentConfig
-	collectionInterval   time.Duration
-	tccNotificationShown bool // Track if we've shown TCC notification in this run
+	logger             interfaces.Logger
+	config             *global.AgentConfig
+	collectionInterval time.Duration
+	screenLockReported bool // Track if a screen lock error was reported in this run
 }
 
 // UserContextData represents user-specific context information
@@ -57,20 +57,30 @@ func (h *UserHelper) Run() error {
 	ticker := time.NewTicker(h.collectionInterval)
 	defer ticker.Stop()
 
+	// Poll for requests more frequently than data is collected so that
+	// commands requiring user context are not delayed
+	pollTicker := time.NewTicker(global.UserRequestPollInterval * time.Second)
+	defer pollTicker.Stop()
+
 	// Send initial data immediately
 	if err := h.collectAndSend(); err != nil {
 		h.logger.Errorf(3001, "Error collecting initial data: %v", err)
 	}
 
-	// Periodic collection
-	for range ticker.C {
-		if err := h.collectAndSend(); err != nil {
-			h.logger.Errorf(3002, "Error collecting periodic data: %v", err)
-			// Continue running despite errors
+	for {
+		select {
+		case <-ticker.C:
+			// Periodic collection
+			if err := h.collectAndSend(); err != nil {
+				h.logger.Errorf(3002, "Error collecting periodic data: %v", err)
+				// Continue running despite errors
+			}
+		case <-pollTicker.C:
+			if err := h.poll(); err != nil {
+				h.logger.Debugf(3004, "Error polling for requests: %v", err)
+			}
 		}
 	}
-
-	return nil
 }
 
 // collectAndSend gathers user-context data and sends to daemon
@@ -82,26 +92,92 @@ func (h *UserHelper) collectAndSend() error {
 	}
 
 	data := h.collectUserData()
-	return h.sendToDaemon(data)
+	requests, err := h.sendToDaemon(data)
+	if err != nil {
+		return err
+	}
+	return h.processRequests(requests)
 }
 
-// isConsoleUser checks if the current user is the active console user
-func (h *UserHelper) isConsoleUser() bool {
-	cmd := exec.Command("/usr/bin/stat", "-f", "%Su", "/dev/console")
-	output, err := cmd.Output()
+// poll checks the daemon for pending requests without collecting data
+func (h *UserHelper) poll() error {
+	if !h.isConsoleUser() {
+		return nil
+	}
+
+	requests, err := h.sendToDaemon(status.UserContextData{
+		Type:      status.UserMessagePoll,
+		Username:  getCurrentUsername(),
+		Timestamp: time.Now(),
+	})
 	if err != nil {
-		h.logger.Errorf(3011, "Error checking console user: %v", err)
-		return false
+		return err
 	}
+	return h.processRequests(requests)
+}
 
-	consoleUser := strings.TrimSpace(string(output))
-	currentUser := getCurrentUsername()
+// processRequests performs requests received from the daemon and immediately
+// returns the results, along with fresh data reflecting any changes made
+func (h *UserHelper) processRequests(requests []status.UserRequest) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	var results []status.UserRequestResult
+	for _, request := range requests {
+		results = append(results, h.performRequest(request))
+	}
 
-	isConsole := consoleUser == currentUser
-	h.logger.Debugf(3012, "Console user: %s, Current user: %s, Is console: %v",
-		consoleUser, currentUser, isConsole)
+	data := h.collectUserData()
+	data.Results = results
 
-	return isConsole
+	// Any requests queued in the meantime are handled on the next poll
+	more, err := h.sendToDaemon(data)
+	if err != nil {
+		return fmt.Errorf("failed to send request results: %w", err)
+	}
+	if len(more) > 0 {
+		return h.processRequests(more)
+	}
+	return nil
+}
+
+// performRequest carries out a single request in the user's context
+func (h *UserHelper) performRequest(request status.UserRequest) status.UserRequestResult {
+	result := status.UserRequestResult{
+		ID:   request.ID,
+		Type: request.Type,
+	}
+
+	switch request.Type {
+	case commands.ScreenLockSet:
+		delay, err := strconv.Atoi(request.Parameters["delay_minutes"])
+		if err != nil {
+			result.Response = "delay_minutes must be a number"
+			break
+		}
+
+		settings, err := osActions.New(h.logger).SetScreenLock(delay)
+		if err != nil {
+			result.Response = err.Error()
+			break
+		}
+
+		result.Data, err = json.Marshal(settings)
+		if err != nil {
+			result.Response = fmt.Sprintf("failed to encode settings: %v", err)
+			break
+		}
+		result.Success = true
+		result.Response = "screen lock set"
+
+	default:
+		result.Response = fmt.Sprintf("unsupported request type: %s", request.Type)
+	}
+
+	h.logger.Infof(3005, "Performed request %s (%s): success=%t %s",
+		request.ID, request.Type, result.Success, result.Response)
+	return result
 }
 
 // collectUserData gathers user-specific information
@@ -120,11 +196,10 @@ func (h *UserHelper) collectUserData() status.UserContextData {
 	data.ScreenLock = screenLockValue
 	data.ScreenLockDelay = statusHandler.ScreenLockDelay()
 
-	// Detect TCC permission denial
-	// If ScreenLock() returned an error and the value is "unknown", it's likely a TCC issue
-	if screenLockErr != nil && screenLockValue == "unknown" && !h.tccNotificationShown {
-		h.handleTCCDenial(screenLockErr)
-		h.tccNotificationShown = true
+	// Report a failure to read the screen lock once per run, as it may require the user's action
+	if screenLockErr != nil && screenLockValue == "unknown" && !h.screenLockReported {
+		h.handleScreenLockError(screenLockErr)
+		h.screenLockReported = true
 	}
 
 	// Collect additional user-context data
@@ -133,48 +208,11 @@ func (h *UserHelper) collectUserData() status.UserContextData {
 	return data
 }
 
-// handleTCCDenial detects TCC permission denial and shows a blocking dialog to the user
-func (h *UserHelper) handleTCCDenial(err error) {
-	// Check if this is specifically a TCC error (-1743)
-	errMsg := err.Error()
-	isTCCError := strings.Contains(errMsg, "-1743") ||
-		strings.Contains(errMsg, "Not authorized to send Apple events")
-
-	if !isTCCError {
-		// Not a TCC error, just log and return
-		h.logger.Warningf(3020, "Screen lock detection failed (non-TCC): %v", err)
-		return
-	}
-
-	// Log the TCC denial
-	h.logger.Warningf(3021, "TCC permission denied for System Events - showing user notification")
-
-	// Show blocking dialog to user
-	dialogScript := `display dialog "UEM Agent needs your permission to monitor security settings.
-
-To enable full security monitoring:
-
-1. Go to System Settings
-2. Navigate to Privacy & Security > Automation
-3. Find 'uem-agent' in the list
-4. Enable 'System Events'
-
-Without this permission, some security settings cannot be monitored." buttons {"OK"} default button "OK" with title "UEM Agent - Permission Required" with icon caution`
-
-	cmd := exec.Command("/usr/bin/osascript", "-e", dialogScript)
-	err = cmd.Run()
-	if err != nil {
-		h.logger.Errorf(3022, "Failed to show TCC notification dialog: %v", err)
-	} else {
-		h.logger.Infof(3023, "TCC notification dialog shown to user")
-	}
-}
-
-// sendToDaemon sends data to daemon via Unix socket
-func (h *UserHelper) sendToDaemon(data status.UserContextData) error {
-	conn, err := net.DialTimeout("unix", global.SocketPath, 5*time.Second)
+// sendToDaemon sends data to the daemon's listener and returns any requests it replies with
+func (h *UserHelper) sendToDaemon(data status.UserContextData) ([]status.UserRequest, error) {
+	conn, err := userdata.Dial(5 * time.Second)
 	if err != nil {
-		return fmt.Errorf("failed to connect to daemon socket: %w", err)
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
 	}
 	defer func(conn net.Conn) {
 		_ = conn.Close()
@@ -186,13 +224,22 @@ func (h *UserHelper) sendToDaemon(data status.UserContextData) error {
 	// Send JSON payload
 	encoder := json.NewEncoder(conn)
 	if err := encoder.Encode(data); err != nil {
-		return fmt.Errorf("failed to send data: %w", err)
+		return nil, fmt.Errorf("failed to send data: %w", err)
 	}
 
-	h.logger.Debugf(3003, "Sent user data to daemon: screen_lock=%s, delay=%s",
-		data.ScreenLock, data.ScreenLockDelay)
+	if data.Type != status.UserMessagePoll {
+		h.logger.Debugf(3003, "Sent user data to daemon: screen_lock=%s, delay=%s",
+			data.ScreenLock, data.ScreenLockDelay)
+	}
 
-	return nil
+	// Read pending requests. An older daemon closes the connection without
+	// replying, which is treated as having no requests.
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var requests []status.UserRequest
+	if err := json.NewDecoder(conn).Decode(&requests); err != nil {
+		return nil, nil
+	}
+	return requests, nil
 }
 
 // getCurrentUsername returns the current user's username
@@ -0,0 +1,69 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userhelper
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// isConsoleUser checks if the current user is the active console user
+func (h *UserHelper) isConsoleUser() bool {
+	cmd := exec.Command("/usr/bin/stat", "-f", "%Su", "/dev/console")
+	output, err := cmd.Output()
+	if err != nil {
+		h.logger.Errorf(3011, "Error checking console user: %v", err)
+		return false
+	}
+
+	consoleUser := strings.TrimSpace(string(output))
+	currentUser := getCurrentUsername()
+
+	isConsole := consoleUser == currentUser
+	h.logger.Debugf(3012, "Console user: %s, Current user: %s, Is console: %v",
+		consoleUser, currentUser, isConsole)
+
+	return isConsole
+}
+
+// handleScreenLockError detects TCC permission denial and shows a blocking dialog to the user
+func (h *UserHelper) handleScreenLockError(err error) {
+	// Check if this is specifically a TCC error (-1743)
+	errMsg := err.Error()
+	isTCCError := strings.Contains(errMsg, "-1743") ||
+		strings.Contains(errMsg, "Not authorized to send Apple events")
+
+	if !isTCCError {
+		// Not a TCC error, just log and return
+		h.logger.Warningf(3020, "Screen lock detection failed (non-TCC): %v", err)
+		return
+	}
+
+	// Log the TCC denial
+	h.logger.Warningf(3021, "TCC permission denied for System Events - showing user notification")
+
+	// Show blocking dialog to user
+	dialogScript := `display dialog "UEM Agent needs your permission to monitor security settings.
+
+To enable full security monitoring:
+
+1. Go to System Settings
+2. Navigate to Privacy & Security > Automation
+3. Find 'uem-agent' in the list
+4. Enable 'System Events'
+
+Without this permission, some security settings cannot be monitored." buttons {"OK"} default button "OK" with title "UEM Agent - Permission Required" with icon caution`
+
+	cmd := exec.Command("/usr/bin/osascript", "-e", dialogScript)
+	err = cmd.Run()
+	if err != nil {
+		h.logger.Errorf(3022, "Failed to show TCC notification dialog: %v", err)
+	} else {
+		h.logger.Infof(3023, "TCC notification dialog shown to user")
+	}
+}
@@ -0,0 +1,38 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userhelper
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// isConsoleUser checks if the current user has the active session on a seat. The user's
+// systemd instance runs for every login, including SSH, so inactive users are skipped.
+func (h *UserHelper) isConsoleUser() bool {
+	uid := strconv.Itoa(os.Getuid())
+	output, err := exec.Command("loginctl", "show-user", uid, "--property=State", "--value").Output()
+	if err != nil {
+		h.logger.Errorf(3011, "Error checking console user: %v", err)
+		return false
+	}
+
+	state := strings.TrimSpace(string(output))
+	isConsole := state == "active"
+	h.logger.Debugf(3012, "User %s session state: %s, Is console: %v",
+		getCurrentUsername(), state, isConsole)
+
+	return isConsole
+}
+
+// handleScreenLockError logs a failure to read the screen lock settings
+func (h *UserHelper) handleScreenLockError(err error) {
+	h.logger.Warningf(3020, "Screen lock detection failed: %v", err)
+}
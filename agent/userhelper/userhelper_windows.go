@@ -0,0 +1,35 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userhelper
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// isConsoleUser checks if the current process is running in the console session. The Run
+// key starts the user-helper for every interactive logon, including remote desktop sessions.
+func (h *UserHelper) isConsoleUser() bool {
+	var session uint32
+	err := windows.ProcessIdToSessionId(windows.GetCurrentProcessId(), &session)
+	if err != nil {
+		h.logger.Errorf(3011, "Error checking console user: %v", err)
+		return false
+	}
+
+	consoleSession := windows.WTSGetActiveConsoleSessionId()
+	isConsole := session == consoleSession
+	h.logger.Debugf(3012, "Console session: %d, Current session: %d, Is console: %v",
+		consoleSession, session, isConsole)
+
+	return isConsole
+}
+
+// handleScreenLockError logs a failure to read the screen lock settings
+func (h *UserHelper) handleScreenLockError(err error) {
+	h.logger.Warningf(3020, "Screen lock detection failed: %v", err)
+}
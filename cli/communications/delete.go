@@ -7,5 +7,5 @@ package communications
 
 // Delete sends a DELETE request to the specified endpoint and returns the response body.
 func (c *Communications) Delete(endpoint string) (int, []byte, error) {
-	return c.sendRequest("DELETE", endpoint, nil)
+	return c.sendRequest("DELETE", endpoint, nil, nil)
 }
@@ -0,0 +1,14 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import "io"
+
+// Download sends a GET request to the specified endpoint and copies a successful response body
+// to dst. For other responses, the body is returned.
+func (c *Communications) Download(endpoint string, dst io.Writer) (int, []byte, error) {
+	return c.sendRequest("GET", endpoint, nil, dst)
+}
@@ -9,7 +9,7 @@ import "github.com/UnifyEM/UnifyEM/cli/util"
 
 // Get sends a GET request to the specified endpoint and returns the response body.
 func (c *Communications) Get(endpoint string) (int, []byte, error) {
-	return c.sendRequest("GET", endpoint, nil)
+	return c.sendRequest("GET", endpoint, nil, nil)
 }
 
 // GetQuery accepts pairs and turns them into query parameters for a GET request to the specified endpoint
@@ -27,5 +27,5 @@ func (c *Communications) GetQuery(endpoint string, pairs *util.NVPairs) (int, []
 			query += n + "=" + v
 		}
 	}
-	return c.sendRequest("GET", endpoint+query, nil)
+	return c.sendRequest("GET", endpoint+query, nil, nil)
 }
@@ -5,19 +5,25 @@
 
 package communications
 
-import "github.com/UnifyEM/UnifyEM/cli/global"
+import (
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/cli/global"
+)
 
 // Ensure that Communications implements the global.Comms interface
 var _ global.Comms = &Communications{}
 
 type Communications struct {
-	token string
+	serverURL string
+	token     string
 }
 
-// New returns a new Communications object and optionally accepts a token
-func New(token ...string) global.Comms {
+// New returns a new Communications object for the server at serverURL and optionally accepts a token
+func New(serverURL string, token ...string) global.Comms {
 	comms := &Communications{
-		token: "",
+		serverURL: strings.TrimRight(serverURL, "/"),
+		token:     "",
 	}
 	if len(token) > 0 {
 		comms.token = token[0]
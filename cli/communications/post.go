@@ -24,5 +24,5 @@ func (c *Communications) Post(endpoint string, payload interface{}) (int, []byte
 	}
 
 	// Use the common sendRequest function to send the POST request
-	return c.sendRequest("POST", endpoint, jsonData)
+	return c.sendRequest("POST", endpoint, jsonData, nil)
 }
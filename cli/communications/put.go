@@ -24,5 +24,5 @@ func (c *Communications) Put(endpoint string, payload interface{}) (int, []byte,
 	}
 
 	// Use the common sendRequest function to send the PUT request
-	return c.sendRequest("PUT", endpoint, jsonData)
+	return c.sendRequest("PUT", endpoint, jsonData, nil)
 }
@@ -19,7 +19,6 @@ import (
 	"strings"
 
 	"github.com/UnifyEM/UnifyEM/cli/certstore"
-	"github.com/UnifyEM/UnifyEM/cli/global"
 )
 
 // UntrustedCertError is returned when a server presents a certificate
@@ -33,11 +32,24 @@ func (e *UntrustedCertError) Error() string {
 	return fmt.Sprintf("untrusted certificate from %s", e.Host)
 }
 
-// sendRequest is a lower level function that sends HTTP requests
-func (c *Communications) sendRequest(method, endpoint string, payload []byte) (int, []byte, error) {
+// sendRequest is a lower level function that sends HTTP requests. If dst is not nil, a
+// successful response body is copied to it instead of being returned.
+func (c *Communications) sendRequest(method, endpoint string, payload []byte, dst io.Writer) (int, []byte, error) {
+	contentType := ""
+	if method == "POST" || method == "PUT" {
+		contentType = "application/json"
+	}
+	return c.sendBody(method, endpoint, func() (io.Reader, string, error) {
+		return bytes.NewReader(payload), contentType, nil
+	}, dst)
+}
+
+// sendBody sends an HTTP request with the body and content type returned by body. It is called
+// again if the request must be retried, so it must return a new reader each time.
+func (c *Communications) sendBody(method, endpoint string, body func() (io.Reader, string, error), dst io.Writer) (int, []byte, error) {
 
 	// Build the request URL
-	reqURL := fmt.Sprintf("%s%s", global.ServerURL, endpoint)
+	reqURL := fmt.Sprintf("%s%s", c.serverURL, endpoint)
 
 	// Extract host:port for certificate operations
 	host := hostFromURL(reqURL)
@@ -45,7 +57,7 @@ func (c *Communications) sendRequest(method, endpoint string, payload []byte) (i
 	// Build the HTTP client with TLS certificate verification
 	client := c.buildHTTPClient(host)
 
-	code, body, err := c.doRequest(client, method, reqURL, payload)
+	code, respBody, err := c.doRequest(client, method, reqURL, body, dst)
 	if err != nil {
 		// Check if this is an untrusted certificate error
 		var certErr *UntrustedCertError
@@ -67,17 +79,22 @@ func (c *Communications) sendRequest(method, endpoint string, payload []byte) (i
 
 			// Retry with the now-trusted certificate
 			client = c.buildHTTPClient(host)
-			return c.doRequest(client, method, reqURL, payload)
+			return c.doRequest(client, method, reqURL, body, dst)
 		}
 		return 0, nil, err
 	}
-	return code, body, nil
+	return code, respBody, nil
 }
 
 // doRequest executes an HTTP request and returns status code, body, and error.
-func (c *Communications) doRequest(client *http.Client, method, reqURL string, payload []byte) (int, []byte, error) {
+func (c *Communications) doRequest(client *http.Client, method, reqURL string, body func() (io.Reader, string, error), dst io.Writer) (int, []byte, error) {
 
-	httpReq, err := http.NewRequest(method, reqURL, bytes.NewBuffer(payload))
+	payload, contentType, err := body()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request body: %w", err)
+	}
+
+	httpReq, err := http.NewRequest(method, reqURL, payload)
 	if err != nil {
 		return 0, nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -88,8 +105,8 @@ func (c *Communications) doRequest(client *http.Client, method, reqURL string, p
 	}
 
 	// Set the appropriate headers
-	if method == "POST" || method == "PUT" {
-		httpReq.Header.Set("Content-Type", "application/json")
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
 	}
 
 	resp, err := client.Do(httpReq)
@@ -100,6 +117,15 @@ func (c *Communications) doRequest(client *http.Client, method, reqURL string, p
 		_ = Body.Close()
 	}(resp.Body)
 
+	// Stream a successful response to the destination. A body shorter than its Content-Length
+	// results in an error.
+	if dst != nil && resp.StatusCode == http.StatusOK {
+		if _, err = io.Copy(dst, resp.Body); err != nil {
+			return resp.StatusCode, nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		return resp.StatusCode, nil, nil
+	}
+
 	// Read the response body
 	var responseBody bytes.Buffer
 	_, err = responseBody.ReadFrom(resp.Body)
@@ -0,0 +1,44 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package communications
+
+import (
+	"io"
+	"mime/multipart"
+)
+
+// Upload sends src to the specified endpoint as the "file" field of a multipart/form-data POST
+// request. The file is streamed rather than read into memory.
+func (c *Communications) Upload(endpoint, name string, src io.ReadSeeker) (int, []byte, error) {
+	// done is closed when the writer of the previous attempt has stopped using src
+	var done chan struct{}
+
+	return c.sendBody("POST", endpoint, func() (io.Reader, string, error) {
+		if done != nil {
+			<-done
+		}
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return nil, "", err
+		}
+
+		// The pipe is closed by the HTTP client when the request ends, which stops the writer
+		pr, pw := io.Pipe()
+		mw := multipart.NewWriter(pw)
+		done = make(chan struct{})
+		go func(done chan struct{}) {
+			defer close(done)
+			part, err := mw.CreateFormFile("file", name)
+			if err == nil {
+				_, err = io.Copy(part, src)
+			}
+			if err == nil {
+				err = mw.Close()
+			}
+			_ = pw.CloseWithError(err)
+		}(done)
+		return pr, mw.FormDataContentType(), nil
+	}, nil)
+}
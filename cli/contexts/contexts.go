@@ -0,0 +1,192 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+// Package contexts manages named server contexts, each with its own URL and optional
+// credentials, stored in ~/.uemcontexts. The tokens for each context are stored by the
+// credentials package.
+package contexts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const contextFile = ".uemcontexts"
+
+// ErrNotFound is returned when a context does not exist
+var ErrNotFound = errors.New("context does not exist")
+
+// Context is a server and the credentials used to access it
+type Context struct {
+	URL      string `json:"url"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// Config is the set of contexts and the name of the current one
+type Config struct {
+	Current  string              `json:"current,omitempty"`
+	Contexts map[string]*Context `json:"contexts"`
+}
+
+// path returns the full path to ~/.uemcontexts
+func path() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine home directory: %w", err)
+	}
+	return filepath.Join(homeDir, contextFile), nil
+}
+
+// Load reads the contexts. If the file does not exist, an empty configuration is returned.
+func Load() (*Config, error) {
+	cfg := &Config{Contexts: make(map[string]*Context)}
+
+	p, err := path()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("unable to read %s: %w", p, err)
+	}
+
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("unable to parse %s: %w", p, err)
+	}
+	if cfg.Contexts == nil {
+		cfg.Contexts = make(map[string]*Context)
+	}
+	return cfg, nil
+}
+
+// Save writes the contexts. The file may contain credentials, so it is only
+// readable by the user, and it is replaced atomically so that it is never left partially written.
+func (c *Config) Save() error {
+	p, err := path()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to serialize contexts: %w", err)
+	}
+
+	tmp := p + ".tmp"
+	err = os.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to write %s: %w", tmp, err)
+	}
+	err = os.Rename(tmp, p)
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("unable to replace %s: %w", p, err)
+	}
+	return nil
+}
+
+// Get returns the named context
+func (c *Config) Get(name string) (*Context, error) {
+	ctx, ok := c.Contexts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return ctx, nil
+}
+
+// Set creates or updates a context. Empty values leave the existing values unchanged.
+// The first context created becomes the current context.
+func (c *Config) Set(name, url, user, password string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("context name is required")
+	}
+
+	ctx, ok := c.Contexts[name]
+	if !ok {
+		if url == "" {
+			return errors.New("url is required for a new context")
+		}
+		ctx = &Context{}
+		c.Contexts[name] = ctx
+	}
+
+	url = strings.TrimRight(url, "/")
+	if url != "" {
+		ctx.URL = url
+	}
+	if user != "" {
+		ctx.User = user
+	}
+	if password != "" {
+		ctx.Password = password
+	}
+
+	if c.Current == "" {
+		c.Current = name
+	}
+	return nil
+}
+
+// Selected returns the name of the context to use, which is override if it is not empty,
+// and otherwise the current context. If there is no context to use, the name is empty and
+// the context is nil.
+func (c *Config) Selected(override string) (string, *Context, error) {
+	name := override
+	if name == "" {
+		name = c.Current
+	}
+	if name == "" {
+		return "", nil, nil
+	}
+
+	ctx, err := c.Get(name)
+	if err != nil {
+		return name, nil, err
+	}
+	return name, ctx, nil
+}
+
+// Use makes the named context the current context
+func (c *Config) Use(name string) error {
+	if _, err := c.Get(name); err != nil {
+		return err
+	}
+	c.Current = name
+	return nil
+}
+
+// Delete removes a context. If it is the current context, there is no current context.
+func (c *Config) Delete(name string) error {
+	if _, err := c.Get(name); err != nil {
+		return err
+	}
+	delete(c.Contexts, name)
+	if c.Current == name {
+		c.Current = ""
+	}
+	return nil
+}
+
+// Names returns the names of the contexts in order
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Contexts))
+	for name := range c.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
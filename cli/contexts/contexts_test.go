@@ -0,0 +1,71 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package contexts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContexts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	cfg, err := Load()
+	if err != nil || len(cfg.Contexts) != 0 {
+		t.Fatalf("expected no contexts, got %v (%v)", cfg.Contexts, err)
+	}
+
+	if err = cfg.Set("prod", "", "admin", ""); err == nil {
+		t.Errorf("expected an error for a new context without a URL")
+	}
+	if err = cfg.Set("prod", "https://uem.example.com/", "admin", "secret"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err = cfg.Set("staging", "https://staging.example.com", "", ""); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// The first context becomes the current context
+	name, ctx, err := cfg.Selected("")
+	if err != nil || name != "prod" || ctx.URL != "https://uem.example.com" {
+		t.Fatalf("unexpected selected context: %s %+v (%v)", name, ctx, err)
+	}
+	if name, _, _ = cfg.Selected("staging"); name != "staging" {
+		t.Errorf("override not selected: %s", name)
+	}
+	if _, _, err = cfg.Selected("dev"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	// Empty values leave the context unchanged
+	if err = cfg.Set("prod", "", "", "changed"); err != nil || ctx.User != "admin" || ctx.Password != "changed" {
+		t.Errorf("unexpected context after update: %+v (%v)", ctx, err)
+	}
+
+	if err = cfg.Use("staging"); err != nil {
+		t.Fatalf("Use failed: %v", err)
+	}
+	if err = cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(home, contextFile))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected file mode: %v (%v)", info, err)
+	}
+
+	cfg, err = Load()
+	if err != nil || cfg.Current != "staging" || len(cfg.Names()) != 2 {
+		t.Fatalf("contexts not reloaded: %+v (%v)", cfg, err)
+	}
+
+	if err = cfg.Delete("staging"); err != nil || cfg.Current != "" {
+		t.Errorf("deleting the current context did not clear it (%v)", err)
+	}
+}
@@ -3,20 +3,85 @@
  * Please see the LICENSE file for details                                    *
  ******************************************************************************/
 
-// Package credentials manages the access and refresh tokens. At some point in the future, they may be persisted.
+// Package credentials manages the access and refresh tokens. Tokens are persisted in the
+// user's configuration directory, separately for each context, so that later invocations
+// can reuse them without logging in again.
 package credentials
 
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	tokenDir  = "uem"
+	tokenFile = "tokens.json"
+)
+
+// tokens are the tokens issued by a server to a user. The key in the store is the name of
+// the context, or empty if the server is specified by the environment.
+type tokens struct {
+	Server       string `json:"server"`
+	User         string `json:"user,omitempty"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
 var (
 	accessToken  string
 	refreshToken string
+
+	// The store entry that the tokens belong to
+	active    bool
+	storeKey  string
+	serverURL string
+	userName  string
 )
 
-func SetAccessToken(token string) {
-	accessToken = token
+// Use selects the store entry for a context, or the environment if the context is empty,
+// and loads its tokens if they were issued by the same server to the same user. Tokens set
+// or expired afterward are saved to it. An empty user matches any user.
+func Use(context, server, user string) {
+	active = true
+	storeKey = context
+	serverURL = server
+	userName = user
+	accessToken = ""
+	refreshToken = ""
+
+	store, err := load()
+	if err != nil {
+		warn(err)
+		return
+	}
+
+	entry, ok := store[storeKey]
+	if !ok || entry.Server != server || (user != "" && entry.User != user) {
+		return
+	}
+	userName = entry.User
+	accessToken = entry.AccessToken
+	refreshToken = entry.RefreshToken
+}
+
+// User returns the user the tokens were issued to, if known
+func User() string {
+	return userName
 }
 
-func SetRefreshToken(token string) {
-	refreshToken = token
+// SetTokens stores the tokens issued to a user when logging in
+func SetTokens(user, access, refresh string) {
+	userName = user
+	accessToken = access
+	refreshToken = refresh
+	save()
+}
+
+func SetAccessToken(token string) {
+	accessToken = token
+	save()
 }
 
 func GetAccessToken() string {
@@ -29,8 +94,120 @@ func GetRefreshToken() string {
 
 func AccessExpired() {
 	accessToken = ""
+	save()
 }
 
 func RefreshExpired() {
 	refreshToken = ""
+	save()
+}
+
+// Delete removes the stored tokens for a context, or the environment if the context is empty
+func Delete(context string) error {
+	store, err := load()
+	if err != nil {
+		return err
+	}
+	if _, ok := store[context]; !ok {
+		return nil
+	}
+
+	delete(store, context)
+	if active && storeKey == context {
+		accessToken = ""
+		refreshToken = ""
+	}
+	return write(store)
+}
+
+// save stores the tokens in the selected entry, if any. Failing to save them is not fatal
+// because they remain valid for this invocation.
+func save() {
+	if !active {
+		return
+	}
+
+	store, err := load()
+	if err == nil {
+		if accessToken == "" && refreshToken == "" {
+			delete(store, storeKey)
+		} else {
+			store[storeKey] = tokens{
+				Server:       serverURL,
+				User:         userName,
+				AccessToken:  accessToken,
+				RefreshToken: refreshToken}
+		}
+		err = write(store)
+	}
+	if err != nil {
+		warn(err)
+	}
+}
+
+func warn(err error) {
+	_, _ = fmt.Fprintf(os.Stderr, "Warning: unable to access stored tokens: %s\n", err.Error())
+}
+
+// path returns the full path to the token store
+func path() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine configuration directory: %w", err)
+	}
+	return filepath.Join(configDir, tokenDir, tokenFile), nil
+}
+
+// load reads the token store. If it does not exist, an empty store is returned.
+func load() (map[string]tokens, error) {
+	store := make(map[string]tokens)
+
+	p, err := path()
+	if err != nil {
+		return store, err
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return store, fmt.Errorf("unable to read %s: %w", p, err)
+	}
+
+	err = json.Unmarshal(data, &store)
+	if err != nil {
+		return make(map[string]tokens), fmt.Errorf("unable to parse %s: %w", p, err)
+	}
+	return store, nil
+}
+
+// write replaces the token store. The directory and file are only accessible by the user.
+func write(store map[string]tokens) error {
+	p, err := path()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(p), 0700)
+	if err != nil {
+		return fmt.Errorf("unable to create %s: %w", filepath.Dir(p), err)
+	}
+
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to serialize tokens: %w", err)
+	}
+
+	tmp := p + ".tmp"
+	err = os.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to write %s: %w", tmp, err)
+	}
+	err = os.Rename(tmp, p)
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("unable to replace %s: %w", p, err)
+	}
+	return nil
 }
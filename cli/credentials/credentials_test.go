@@ -0,0 +1,64 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package credentials
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTokenStore(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+
+	Use("prod", "https://uem.example.com", "")
+	SetTokens("admin", "access", "refresh")
+
+	p, err := path()
+	if err != nil {
+		t.Fatalf("path failed: %v", err)
+	}
+	info, err := os.Stat(p)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected token file mode: %v (%v)", info, err)
+	}
+
+	// Tokens are reloaded for the same server and user
+	Use("prod", "https://uem.example.com", "admin")
+	if GetAccessToken() != "access" || GetRefreshToken() != "refresh" || User() != "admin" {
+		t.Errorf("tokens not reloaded")
+	}
+
+	// Tokens are never used for a different server or user, or another context
+	for _, test := range []struct{ context, server, user string }{
+		{"prod", "https://other.example.com", "admin"},
+		{"prod", "https://uem.example.com", "someone"},
+		{"staging", "https://uem.example.com", "admin"},
+		{"", "https://uem.example.com", ""},
+	} {
+		Use(test.context, test.server, test.user)
+		if GetRefreshToken() != "" {
+			t.Errorf("tokens loaded for %+v", test)
+		}
+	}
+
+	// Expiring the access token keeps the refresh token
+	Use("prod", "https://uem.example.com", "")
+	AccessExpired()
+	Use("prod", "https://uem.example.com", "")
+	if GetAccessToken() != "" || GetRefreshToken() != "refresh" {
+		t.Errorf("unexpected tokens after expiry")
+	}
+
+	if err = Delete("prod"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	Use("prod", "https://uem.example.com", "")
+	if GetRefreshToken() != "" {
+		t.Errorf("tokens remain after Delete")
+	}
+}
@@ -10,14 +10,16 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/global"
 	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
 	"github.com/UnifyEM/UnifyEM/cli/util"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
@@ -27,7 +29,8 @@ func Register() *cobra.Command {
 		Use:     "agent",
 		Aliases: []string{"agents"},
 		Short:   "agent functions",
-		Long:    "agent-related functions",
+		Long: "agent-related functions. Agents can be specified by agent ID or by a hostname, friendly\n" +
+			"name, serial number, or IP address that identifies a single agent.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return fmt.Errorf("a subcommand is required\n")
@@ -36,14 +39,19 @@ func Register() *cobra.Command {
 		},
 	}
 
-	cmd.AddCommand(&cobra.Command{
+	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "list agents",
-		Long:  "request a list of agents",
+		Long:  "list agents with the time since each was last seen and whether it is offline",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return agentList(args, util.NewNVPairs(args))
+			asJSON, _ := cmd.Flags().GetBool("json")
+			showIP, _ := cmd.Flags().GetBool("ip")
+			return agentList(asJSON, showIP)
 		},
-	})
+	}
+	listCmd.Flags().Bool("json", false, "print the server's full response")
+	listCmd.Flags().Bool("ip", false, "include the public IP address and country each agent last synced from")
+	cmd.AddCommand(listCmd)
 
 	cmd.AddCommand(&cobra.Command{
 		Use:   "status",
@@ -54,6 +62,17 @@ func Register() *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "search <term>",
+		Short: "search agents",
+		Long: "find agents with a hostname, friendly name, IP address, serial number, agent ID, or user\n" +
+			"that contains the term (case-insensitive)",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return agentSearch(args)
+		},
+	})
+
 	cmd.AddCommand(&cobra.Command{
 		Use:   "get <agent_id>|tag=<tag>",
 		Short: "get agent",
@@ -72,6 +91,15 @@ func Register() *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "revoke-sessions <agent_id>",
+		Short: "revoke agent tokens",
+		Long:  "revoke all tokens issued to the agent; it must be registered again to reconnect",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return agentRevokeSessions(args, util.NewNVPairs(args))
+		},
+	})
+
 	cmd.AddCommand(&cobra.Command{
 		Use:   "lost <agent_id>",
 		Short: "activate lost mode",
@@ -94,15 +122,28 @@ func Register() *cobra.Command {
 		},
 	})
 
-	cmd.AddCommand(&cobra.Command{
+	wipeCmd := &cobra.Command{
 		Use:   "wipe <agent_id>",
 		Short: "wipe disk",
-		Long:  "instruct the agent to wipe all drives and set lost mode",
+		Long: "instruct the agent to wipe all drives and set lost mode. The wipe is not sent to the\n" +
+			"agent until the confirmation code returned by the server is entered, unless --force is used.\n" +
+			"With --mode corporate, only the paths in the agent's wipe_paths setting are deleted.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			triggers := schema.NewAgentTriggers()
-			triggers.Wipe = true
-			triggers.Lost = true
-			return agentSetTriggers(args, triggers)
+			force, _ := cmd.Flags().GetBool("force")
+			mode, _ := cmd.Flags().GetString("mode")
+			return agentWipe(args, force, mode)
+		},
+	}
+	wipeCmd.Flags().BoolP("force", "f", false, "set the wipe trigger without confirmation")
+	wipeCmd.Flags().StringP("mode", "m", schema.WipeModeFull, "wipe mode: full or corporate")
+	cmd.AddCommand(wipeCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "wipe-confirm <agent_id> <code>",
+		Short: "confirm wipe",
+		Long:  "confirm a pending wipe using the confirmation code returned by the server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return agentWipeConfirm(args)
 		},
 	})
 
@@ -125,9 +166,10 @@ func Register() *cobra.Command {
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   "tags <agent_id>",
-		Short: "list tags for an agent",
-		Long:  "list all tags assigned to the agent",
+		Use:   "tags [agent_id]",
+		Short: "list tags",
+		Long:  "list all tags assigned to the agent, or all tags with the number of agents for each",
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return agentListTags(args)
 		},
@@ -151,6 +193,25 @@ func Register() *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set-channel <agent_id>|tag=<tag> <channel>|none",
+		Short: "set upgrade channel",
+		Long: "assign an upgrade channel to an agent or a tag. A channel assigned to an agent takes\n" +
+			"precedence over its tags. Use 'none' to remove the assignment.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return agentSetChannel(args)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "channels",
+		Short: "list tag upgrade channels",
+		Long:  "list the upgrade channels assigned to tags",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return agentListChannels()
+		},
+	})
+
 	cmd.AddCommand(&cobra.Command{
 		Use:   "user-add <agent_id>|tag=<tag> <user1> [<user2> ...]",
 		Short: "add users to an agent",
@@ -172,14 +233,130 @@ func Register() *cobra.Command {
 	return cmd
 }
 
-func agentList(_ []string, _ *util.NVPairs) error {
-	c := communications.New(login.Login())
-	display.ErrorWrapper(display.AnyResp(c.Get(schema.EndpointAgent)))
+func agentList(asJSON, showIP bool) error {
+	c := login.Connect()
+	statusCode, data, err := c.Get(schema.EndpointAgent)
+
+	var resp schema.APIAgentInfoResponse
+	if asJSON || err != nil || json.Unmarshal(data, &resp) != nil || resp.Code != 200 {
+		display.ErrorWrapper(display.AnyResp(statusCode, data, err))
+		return nil
+	}
+
+	fmt.Printf("\nServer response: HTTP %d\n", statusCode)
+
+	if len(resp.Data.Agents) == 0 {
+		fmt.Printf("No agents found\n")
+		return nil
+	}
+
+	fmt.Println()
+	// No column headers by design — output is intended for scripting and parsing
+	for _, agent := range resp.Data.Agents {
+		state := "online"
+		if agent.Offline {
+			state = "offline"
+		}
+		fmt.Printf("%-38s %-24s %-30s %6s %-7s %s-%03d %-5s %4s", agent.AgentID, resolver.Hostname(agent), agent.FriendlyName,
+			lastSeenAge(agent.LastSeen), state, agent.Version, agent.Build, triggerFlags(agent), queuedCount(agent))
+		if showIP {
+			fmt.Printf(" %-39s %s", agent.LastIP, lastCountry(agent))
+		}
+		fmt.Println()
+	}
 	return nil
 }
 
+// triggerFlags returns a compact summary of an agent's triggers: "W!" for a wipe, "W?" for a wipe
+// awaiting confirmation, "L" for lost mode, and "U" for uninstall, or "-" if none are set
+func triggerFlags(agent schema.AgentMeta) string {
+	var flags string
+	switch {
+	case agent.Triggers.Wipe:
+		flags += "W!"
+	case agent.WipePending != nil:
+		flags += "W?"
+	}
+	if agent.Triggers.Lost {
+		flags += "L"
+	}
+	if agent.Triggers.Uninstall {
+		flags += "U"
+	}
+	if flags == "" {
+		return "-"
+	}
+	return flags
+}
+
+// queuedCount returns the number of requests that have not been delivered to an agent, for
+// example "3q", or "-" if there are none
+func queuedCount(agent schema.AgentMeta) string {
+	if agent.Queue.Count() == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dq", agent.Queue.Count())
+}
+
+// agentBreakdown displays an agent's triggers and undelivered requests
+func agentBreakdown(agent schema.AgentMeta) {
+	var triggers []string
+	if agent.Triggers.Wipe {
+		mode := agent.Triggers.WipeMode
+		if mode == "" {
+			mode = schema.WipeModeFull
+		}
+		triggers = append(triggers, "wipe ("+mode+")")
+	}
+	if agent.Triggers.Lost {
+		triggers = append(triggers, "lost")
+	}
+	if agent.Triggers.Uninstall {
+		triggers = append(triggers, "uninstall")
+	}
+	if len(triggers) == 0 {
+		triggers = append(triggers, "none")
+	}
+
+	fmt.Printf("\nTriggers: %s\n", strings.Join(triggers, ", "))
+	if agent.WipePending != nil {
+		fmt.Printf("Wipe awaiting confirmation until %s\n", agent.WipePending.Expires.Local().Format(time.RFC1123))
+	}
+
+	if agent.Queue.Count() == 0 {
+		fmt.Println("Queued requests: none")
+		return
+	}
+	fmt.Printf("Queued requests: %d (%d new, %d pending), oldest created %s ago at %s\n", agent.Queue.Count(),
+		agent.Queue.New, agent.Queue.Pending, lastSeenAge(agent.Queue.Oldest), agent.Queue.Oldest.Local().Format(time.RFC1123))
+}
+
+// lastCountry returns the country of the address an agent last synced from, or "-" if unknown
+func lastCountry(agent schema.AgentMeta) string {
+	if len(agent.IPHistory) == 0 || agent.IPHistory[0].Country == "" {
+		return "-"
+	}
+	return agent.IPHistory[0].Country
+}
+
+// lastSeenAge returns the time since an agent was last seen in the largest whole unit
+func lastSeenAge(lastSeen time.Time) string {
+	age := time.Since(lastSeen)
+	switch {
+	case lastSeen.IsZero():
+		return "never"
+	case age < time.Minute:
+		return "<1m"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
+
 func agentStatus(_ []string, _ *util.NVPairs) error {
-	c := communications.New(login.Login())
+	c := login.Connect()
 	statusCode, data, err := c.Get(schema.EndpointAgent)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve agent list: %w", err)
@@ -211,7 +388,7 @@ func agentGet(args []string, _ *util.NVPairs) error {
 		return errors.New("agent ID or tag=<tag> is required")
 	}
 
-	c := communications.New(login.Login())
+	c := login.Connect()
 
 	// Check if argument is a tag
 	if tag, hasPrefix := strings.CutPrefix(args[0], "tag="); hasPrefix {
@@ -222,17 +399,97 @@ func agentGet(args []string, _ *util.NVPairs) error {
 		return nil
 	}
 
-	display.ErrorWrapper(display.AnyResp(c.Get(schema.EndpointAgent + "/" + args[0])))
+	agentID, err := resolver.Agent(c, args[0])
+	if err != nil {
+		return err
+	}
+
+	statusCode, data, err := c.Get(schema.EndpointAgent + "/" + agentID)
+	display.ErrorWrapper(display.AnyResp(statusCode, data, err))
+	if err != nil {
+		return nil
+	}
+
+	var resp schema.APIAgentInfoResponse
+	if json.Unmarshal(data, &resp) != nil || len(resp.Data.Agents) != 1 {
+		return nil
+	}
+	agentBreakdown(resp.Data.Agents[0])
+
+	// If the agent is in lost mode, show where it has been seen
+	if resp.Data.Agents[0].Triggers.Lost {
+		agentLocations(c, agentID)
+	}
 	return nil
 }
 
+// agentLocations displays the location events recorded for a lost agent in the last week
+func agentLocations(c global.Comms, agentID string) {
+	pairs := util.NewNVPairs([]string{
+		"agent_id=" + agentID,
+		"type=" + schema.AgentEventLocation,
+		fmt.Sprintf("start_time=%d", time.Now().AddDate(0, 0, -7).Unix())})
+
+	_, data, err := c.GetQuery(schema.EndpointEvents, pairs)
+	if err != nil {
+		return
+	}
+
+	var resp schema.APIEventsResponse
+	if json.Unmarshal(data, &resp) != nil {
+		return
+	}
+
+	fmt.Printf("\nLost mode is active. Locations reported in the last 7 days:\n\n")
+	if len(resp.Data) == 0 {
+		fmt.Printf("None\n")
+		return
+	}
+
+	sort.Slice(resp.Data, func(i, j int) bool { return resp.Data[i].Time.Before(resp.Data[j].Time) })
+	for _, event := range resp.Data {
+		fmt.Printf("%s  public_ip=%s ip=%s %s=%s",
+			event.Time.Local().Format("2006-01-02 15:04:05"),
+			event.Details["public_ip"], event.Details["ip"],
+			schema.StatusGatewayMAC, event.Details[schema.StatusGatewayMAC])
+		if ssid, ok := event.Details[schema.StatusWiFiSSID]; ok {
+			fmt.Printf(" %s=%q", schema.StatusWiFiSSID, ssid)
+		}
+		if country, ok := event.Details["country"]; ok {
+			fmt.Printf(" country=%s", country)
+			if city, ok := event.Details["city"]; ok {
+				fmt.Printf(" city=%q", city)
+			}
+		}
+		fmt.Println()
+	}
+}
+
 func agentDelete(args []string, _ *util.NVPairs) error {
 	if len(args) == 0 {
 		return errors.New("agent ID is required")
 	}
 
-	c := communications.New(login.Login())
-	display.ErrorWrapper(display.GenericResp(c.Delete(schema.EndpointAgent + "/" + args[0])))
+	c := login.Connect()
+	path, err := resolver.Path(c, args[0])
+	if err != nil {
+		return err
+	}
+	display.ErrorWrapper(display.GenericResp(c.Delete(path)))
+	return nil
+}
+
+func agentRevokeSessions(args []string, _ *util.NVPairs) error {
+	if len(args) == 0 {
+		return errors.New("agent ID is required")
+	}
+
+	c := login.Connect()
+	path, err := resolver.Path(c, args[0])
+	if err != nil {
+		return err
+	}
+	display.ErrorWrapper(display.GenericResp(c.Post(path+"/revoke-sessions", nil)))
 	return nil
 }
 
@@ -241,21 +498,31 @@ func agentSetName(args []string, _ *util.NVPairs) error {
 		return errors.New("agent ID and name are required")
 	}
 
-	agentMeta := schema.NewAgentMeta(args[0])
+	c := login.Connect()
+	agentID, err := resolver.Agent(c, args[0])
+	if err != nil {
+		return err
+	}
+
+	agentMeta := schema.NewAgentMeta(agentID)
 	agentMeta.FriendlyName = args[1]
 
-	c := communications.New(login.Login())
-	display.ErrorWrapper(display.GenericResp(c.Put(schema.EndpointAgent+"/"+args[0], agentMeta)))
+	display.ErrorWrapper(display.GenericResp(c.Put(schema.EndpointAgent+"/"+agentID, agentMeta)))
 	return nil
 }
 
-// List tags for an agent
+// List tags for an agent, or all tags if no agent is specified
 func agentListTags(args []string) error {
+	c := login.Connect()
 	if len(args) < 1 {
-		return errors.New("agent ID is required")
+		display.ErrorWrapper(display.AnyResp(c.Get(schema.EndpointTags)))
+		return nil
+	}
+	path, err := resolver.Path(c, args[0])
+	if err != nil {
+		return err
 	}
-	c := communications.New(login.Login())
-	status, body, err := c.Get(schema.EndpointAgent + "/" + args[0] + "/tags")
+	status, body, err := c.Get(path + "/tags")
 	display.ErrorWrapper(display.TagsResp(status, body, err))
 	return nil
 }
@@ -266,8 +533,12 @@ func agentAddTags(args []string) error {
 		return errors.New("agent ID and at least one tag are required")
 	}
 	req := schema.AgentTagsRequest{Tags: args[1:]}
-	c := communications.New(login.Login())
-	status, body, err := c.Post(schema.EndpointAgent+"/"+args[0]+"/tags/add", req)
+	c := login.Connect()
+	path, err := resolver.Path(c, args[0])
+	if err != nil {
+		return err
+	}
+	status, body, err := c.Post(path+"/tags/add", req)
 	display.ErrorWrapper(display.GenericResp(status, body, err))
 	return nil
 }
@@ -278,18 +549,91 @@ func agentRemoveTags(args []string) error {
 		return errors.New("agent ID and at least one tag are required")
 	}
 	req := schema.AgentTagsRequest{Tags: args[1:]}
-	c := communications.New(login.Login())
-	status, body, err := c.Post(schema.EndpointAgent+"/"+args[0]+"/tags/remove", req)
+	c := login.Connect()
+	path, err := resolver.Path(c, args[0])
+	if err != nil {
+		return err
+	}
+	status, body, err := c.Post(path+"/tags/remove", req)
 	display.ErrorWrapper(display.GenericResp(status, body, err))
 	return nil
 }
 
+// Assign an upgrade channel to an agent or tag
+func agentSetChannel(args []string) error {
+	if len(args) < 2 {
+		return errors.New("agent ID or tag=<tag> and channel are required")
+	}
+
+	channel := strings.ToLower(args[1])
+	if channel == "none" {
+		channel = ""
+	}
+	if !schema.ValidChannel(channel) {
+		return fmt.Errorf("invalid channel: %s", args[1])
+	}
+	req := schema.ChannelRequest{Channel: channel}
+
+	c := login.Connect()
+	var endpoint string
+	if tag, hasPrefix := strings.CutPrefix(args[0], "tag="); hasPrefix {
+		if tag == "" {
+			return errors.New("tag value cannot be empty")
+		}
+		endpoint = schema.EndpointChannel + "/tag/" + url.PathEscape(tag)
+	} else {
+		path, err := resolver.Path(c, args[0])
+		if err != nil {
+			return err
+		}
+		endpoint = path + "/channel"
+	}
+
+	display.ErrorWrapper(display.GenericResp(c.Post(endpoint, req)))
+	return nil
+}
+
+// List the upgrade channels assigned to tags
+func agentListChannels() error {
+	c := login.Connect()
+	status, body, err := c.Get(schema.EndpointChannel)
+	if err != nil {
+		return err
+	}
+
+	var resp schema.APITagChannelsResponse
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("failed to parse response: %v", err)
+	}
+
+	fmt.Printf("\nServer response: HTTP %d\n", status)
+	if resp.Code != 200 {
+		fmt.Printf("Details: %s\n", resp.Details)
+		return nil
+	}
+
+	if len(resp.Channels) == 0 {
+		fmt.Println("No tag channels assigned")
+		return nil
+	}
+
+	tags := make([]string, 0, len(resp.Channels))
+	for tag := range resp.Channels {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		fmt.Printf("%s: %s\n", tag, resp.Channels[tag])
+	}
+	return nil
+}
+
 // Add users to an agent or all agents with a tag
 func agentAddUsers(args []string) error {
 	if len(args) < 2 {
 		return errors.New("agent ID or tag=<tag> and at least one user are required")
 	}
-	c := communications.New(login.Login())
+	c := login.Connect()
 	if tag, hasPrefix := strings.CutPrefix(args[0], "tag="); hasPrefix {
 		if tag == "" {
 			return errors.New("tag value cannot be empty")
@@ -318,8 +662,12 @@ func agentAddUsers(args []string) error {
 		return firstErr
 	}
 	// Single agent
+	path, err := resolver.Path(c, args[0])
+	if err != nil {
+		return err
+	}
 	req := schema.AgentUsersRequest{Users: args[1:]}
-	status, body, err := c.Post(schema.EndpointAgent+"/"+args[0]+"/users/add", req)
+	status, body, err := c.Post(path+"/users/add", req)
 	display.ErrorWrapper(display.GenericResp(status, body, err))
 	return nil
 }
@@ -329,7 +677,7 @@ func agentRemoveUsers(args []string) error {
 	if len(args) < 2 {
 		return errors.New("agent ID or tag=<tag> and at least one user are required")
 	}
-	c := communications.New(login.Login())
+	c := login.Connect()
 	if tag, hasPrefix := strings.CutPrefix(args[0], "tag="); hasPrefix {
 		if tag == "" {
 			return errors.New("tag value cannot be empty")
@@ -358,8 +706,12 @@ func agentRemoveUsers(args []string) error {
 		return firstErr
 	}
 	// Single agent
+	path, err := resolver.Path(c, args[0])
+	if err != nil {
+		return err
+	}
 	req := schema.AgentUsersRequest{Users: args[1:]}
-	status, body, err := c.Post(schema.EndpointAgent+"/"+args[0]+"/users/remove", req)
+	status, body, err := c.Post(path+"/users/remove", req)
 	display.ErrorWrapper(display.GenericResp(status, body, err))
 	return nil
 }
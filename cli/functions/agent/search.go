@@ -0,0 +1,42 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
+)
+
+// agentSearch lists the agents that match a hostname, friendly name, IP address, serial
+// number, agent ID, or user
+func agentSearch(args []string) error {
+	if len(args) != 1 {
+		return errors.New("a search term is required")
+	}
+
+	c := login.Connect()
+	statusCode, results, err := resolver.Search(c, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nServer response: HTTP %d\n", statusCode)
+	if len(results) == 0 {
+		fmt.Printf("No agents found\n")
+		return nil
+	}
+
+	fmt.Println()
+	// No column headers by design — output is intended for scripting and parsing
+	for _, r := range results {
+		fmt.Printf("%-38s %-24s %-30s %s\n", r.Agent.AgentID, resolver.Hostname(r.Agent), r.Agent.FriendlyName, strings.Join(r.Matched, ","))
+	}
+	return nil
+}
@@ -8,9 +8,9 @@ package agent
 import (
 	"errors"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
 	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
@@ -23,11 +23,16 @@ func agentSetTriggers(args []string, triggers schema.AgentTriggers) error {
 		return errors.New("Agent ID is required\n")
 	}
 
-	agentMeta := schema.NewAgentMeta(args[0])
+	c := login.Connect()
+	agentID, err := resolver.Agent(c, args[0])
+	if err != nil {
+		return err
+	}
+
+	agentMeta := schema.NewAgentMeta(agentID)
 	agentMeta.Triggers = triggers
 
-	c := communications.New(login.Login())
-	display.ErrorWrapper(display.GenericResp(c.Post(schema.EndpointAgent+"/"+args[0], agentMeta)))
+	display.ErrorWrapper(display.GenericResp(c.Post(schema.EndpointAgent+"/"+agentID, agentMeta)))
 	return nil
 }
 
@@ -39,7 +44,11 @@ func agentResetTriggers(args []string) error {
 		return errors.New("Agent ID is required\n")
 	}
 
-	c := communications.New(login.Login())
-	display.ErrorWrapper(display.GenericResp(c.Put(schema.EndpointReset+"/"+args[0], nil)))
+	c := login.Connect()
+	agentID, err := resolver.Agent(c, args[0])
+	if err != nil {
+		return err
+	}
+	display.ErrorWrapper(display.GenericResp(c.Put(schema.EndpointReset+"/"+agentID, nil)))
 	return nil
 }
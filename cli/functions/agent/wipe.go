@@ -0,0 +1,95 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// agentWipe requests a wipe and lost mode for the specified agent. Unless force is true,
+// the server returns a confirmation code that must be entered to set the wipe trigger.
+func agentWipe(args []string, force bool, mode string) error {
+
+	// Require one argument
+	if len(args) != 1 {
+		return errors.New("Agent ID is required\n")
+	}
+
+	if !schema.ValidWipeMode(mode) {
+		return fmt.Errorf("mode must be %s or %s\n", schema.WipeModeFull, schema.WipeModeCorporate)
+	}
+
+	c := login.Connect()
+	agentID, err := resolver.Agent(c, args[0])
+	if err != nil {
+		return err
+	}
+
+	agentMeta := schema.NewAgentMeta(agentID)
+	agentMeta.Triggers.Wipe = true
+	agentMeta.Triggers.WipeMode = mode
+	agentMeta.Triggers.Lost = true
+
+	endpoint := schema.EndpointAgent + "/" + agentID
+	if force {
+		endpoint += "?force=true"
+	}
+
+	statusCode, data, err := c.Post(endpoint, agentMeta)
+
+	var resp schema.APIWipeResponse
+	if err != nil || statusCode != http.StatusOK || json.Unmarshal(data, &resp) != nil || resp.ConfirmationCode == "" {
+		display.ErrorWrapper(display.GenericResp(statusCode, data, err))
+		return nil
+	}
+
+	fmt.Printf("\nWipe (%s) requested for agent %s.\n", mode, agentID)
+	fmt.Printf("Confirmation code: %s (expires %s)\n", resp.ConfirmationCode, resp.Expires.Local().Format(time.RFC1123))
+	fmt.Printf("\nType the confirmation code to wipe the agent, or press enter to cancel: ")
+
+	reader := bufio.NewReader(os.Stdin)
+	code, _ := reader.ReadString('\n')
+	code = strings.TrimSpace(code)
+	if code == "" {
+		fmt.Printf("\nThe wipe has not been confirmed. To confirm it before the code expires, use:\n")
+		fmt.Printf("  agent wipe-confirm %s <code>\n", agentID)
+		return nil
+	}
+
+	display.ErrorWrapper(display.GenericResp(c.Post(schema.EndpointAgent+"/"+agentID+"/wipe/confirm",
+		schema.WipeConfirmRequest{Code: code})))
+	return nil
+}
+
+// agentWipeConfirm confirms a pending wipe using the confirmation code
+func agentWipeConfirm(args []string) error {
+
+	// Require two arguments
+	if len(args) != 2 {
+		return errors.New("Agent ID and confirmation code are required\n")
+	}
+
+	c := login.Connect()
+	path, err := resolver.Path(c, args[0])
+	if err != nil {
+		return err
+	}
+	display.ErrorWrapper(display.GenericResp(c.Post(path+"/wipe/confirm",
+		schema.WipeConfirmRequest{Code: args[1]})))
+	return nil
+}
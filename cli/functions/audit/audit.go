@@ -0,0 +1,48 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package audit
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/util"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func Register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "audit functions",
+		Long:  "audit functions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("a subcommand is required\n")
+			}
+			return fmt.Errorf("unknown subcommand: %s\n", args[0])
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "logins [user=<username>] [start_time=<unix time>] [end_time=<unix time>]",
+		Short: "get login audit events",
+		Long:  "get administrator login successes, failures, and lockouts with optional user and time filters",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return auditLogins(args, util.NewNVPairs(args))
+		},
+	})
+
+	return cmd
+}
+
+func auditLogins(_ []string, pairs *util.NVPairs) error {
+	c := login.Connect()
+	display.ErrorWrapper(display.AnyResp(c.GetQuery(schema.EndpointAuditLogins, pairs)))
+	return nil
+}
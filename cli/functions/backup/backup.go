@@ -0,0 +1,149 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func Register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "database backup and migration functions",
+		Long:  "database backup and migration functions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("a subcommand is required\n")
+			}
+			return fmt.Errorf("unknown subcommand: %s\n", args[0])
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "download <path>",
+		Short: "download a database backup",
+		Long:  "download a consistent snapshot of the server database to the specified file, which must not already exist",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("a path is required\n")
+			}
+			return download(schema.EndpointBackup, args[0])
+		},
+	})
+
+	exportCmd := &cobra.Command{
+		Use:   "export <path> [--events]",
+		Short: "export server data for migration",
+		Long: "export agents, users, login accounts, groups, and configuration to the specified file for migration to\n" +
+			"another server. The export contains password hashes and the server's keys, so protect it accordingly.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("a path is required\n")
+			}
+			endpoint := schema.EndpointExport
+			if events, _ := cmd.Flags().GetBool("events"); events {
+				endpoint += "?events=true"
+			}
+			return download(endpoint, args[0])
+		},
+	}
+	exportCmd.Flags().Bool("events", false, "include events")
+	cmd.AddCommand(exportCmd)
+
+	importCmd := &cobra.Command{
+		Use:   "import <path> [--merge]",
+		Short: "import server data",
+		Long: "import a file created by export. Nothing is imported if any agent, user, login, or group in the file\n" +
+			"already exists unless --merge is specified. Access tokens are revoked, so log in again afterwards.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("a path is required\n")
+			}
+			merge, _ := cmd.Flags().GetBool("merge")
+			return importFile(args[0], merge)
+		},
+	}
+	importCmd.Flags().Bool("merge", false, "replace existing records")
+	cmd.AddCommand(importCmd)
+
+	return cmd
+}
+
+// download writes the response to a temporary file and renames it once it is complete, so that
+// an interrupted download never leaves a partial file at the requested path
+func download(endpoint, path string) error {
+
+	// Never overwrite an existing file
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".uem-backup-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	c := login.Connect()
+	statusCode, data, err := c.Download(endpoint, f)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+
+	if err != nil || statusCode != http.StatusOK {
+		_ = os.Remove(tmp)
+		display.ErrorWrapper(display.GenericResp(statusCode, data, err))
+		return nil
+	}
+
+	// Check again in case the file was created during the download
+	if _, err = os.Stat(path); err == nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d bytes written to %s\n", info.Size(), path)
+	return nil
+}
+
+// importFile sends a file created by export to the server
+func importFile(path string, merge bool) error {
+	bundle, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(bundle) {
+		return fmt.Errorf("%s is not a valid export", path)
+	}
+
+	endpoint := schema.EndpointImport
+	if merge {
+		endpoint += "?merge=true"
+	}
+
+	c := login.Connect()
+	display.ErrorWrapper(display.AnyResp(c.Post(endpoint, json.RawMessage(bundle))))
+	return nil
+}
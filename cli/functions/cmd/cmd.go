@@ -11,9 +11,10 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/functions/files"
 	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
 	"github.com/UnifyEM/UnifyEM/cli/util"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/schema/commands"
@@ -36,256 +37,373 @@ func Register() *cobra.Command {
 	// Add persistent flags for wait functionality
 	cmd.PersistentFlags().BoolP("wait", "w", false, "wait for agent response before returning")
 	cmd.PersistentFlags().IntP("timeout", "t", 300, "timeout in seconds when waiting (default: 300)")
+	cmd.PersistentFlags().Bool("fail-on-timeout", false, "exit with an error if any agent does not respond before the timeout")
+	cmd.PersistentFlags().Bool("json", false, "when waiting, print only a JSON summary of the results")
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.DownloadExecute + " agent_id=<agent ID> | tag=<tag> url=<URL> [arg1=value1] [arg2=value2] ...",
+		Use:   usage(commands.DownloadExecute),
 		Short: "download and execute a file",
-		Long:  "download a file from the specified URL and execute it on the specified agent",
+		Long: "download a file from the specified URL and execute it on the specified agent. For files hosted by the\n" +
+			"server, the hash and any signature created with 'files sign' are added by the server. For other URLs,\n" +
+			"provide the hash and/or a signature. The agent refuses to execute the file if a signature is provided\n" +
+			"and cannot be verified with its publisher_key.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.DownloadExecute, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.DownloadExecute, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.Ping + " agent_id=<agent ID> | tag=<tag>",
+		Use:   usage(commands.Ping),
 		Short: "ping an agent",
 		Long:  "instruct the server to ping the specified agent",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.Ping, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.Ping, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.Execute + " agent_id=<agent ID> | tag=<tag> cmd=<command> [arg1=value1] [arg2=value2] ...",
+		Use:   usage(commands.Execute),
 		Short: "execute a command",
 		Long:  "execute the specified command on the specified agent",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.Execute, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.FDEKeyEscrow),
+		Short: "escrow the disk encryption recovery key",
+		Long: "instruct the agent to send its BitLocker or FileVault recovery key to the server, encrypted with the server's\n" +
+			"public key. On macOS a new personal recovery key is generated, which requires admin_user and admin_password.\n" +
+			"Super admins can retrieve the key with 'recovery fde-key'.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.FDEKeyEscrow, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.FileFetch),
+		Short: "upload a file from an agent to the server",
+		Long: "instruct the agent to upload the specified file to the server. Use 'files get' to download it\n" +
+			"once the request is complete, or 'files fetch --wait' to do both in one step.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.FileFetch, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.FilePush),
+		Short: "push a file to an agent",
+		Long: "download a file from a URL or the server's file directory to the specified path on the agent without executing it.\n" +
+			"The file is verified against the hash of the server's copy and written atomically. Agents refuse to overwrite\n" +
+			"their own binary, configuration, or data directory.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.FilePush, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.FirewallGet),
+		Short: "get firewall state",
+		Long:  "get the detailed firewall state, including per-profile settings, from the specified agent",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.FirewallGet, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.FirewallSet),
+		Short: "enable or disable the firewall",
+		Long:  "enable or disable the host firewall on the specified agent",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.FirewallSet, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.LogsFetch),
+		Short: "retrieve an agent's log",
+		Long: "retrieve the agent's own log, by default the last 200 lines. With since, entries logged at or after\n" +
+			"the specified time are returned, including those in rotated log files. With --wait, the log is written\n" +
+			"to stdout once the agent has responded.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			wait, _ := cmd.Flags().GetBool("wait")
 			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.Execute, args, util.NewNVPairs(args), wait, timeout)
+			return files.LogsFetch(util.NewNVPairs(args), wait, timeout)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.PatchInstall),
+		Short: "install operating system updates",
+		Long: "install the named updates, or all pending updates, on the specified agent. Names are those listed by\n" +
+			"patch_status. Installation continues in the background and the request is complete when it ends. If\n" +
+			"reboot_allowed is true (default false), the agent restarts if required to complete the installation.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.PatchInstall, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.Reboot + " agent_id=<agent ID> | tag=<tag>",
+		Use:   usage(commands.PatchStatus),
+		Short: "list pending operating system updates",
+		Long:  "list the operating system updates pending on the specified agent. Use 'report patches' for all agents",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.PatchStatus, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.ProcessKill),
+		Short: "kill processes",
+		Long: "terminate the processes on the specified agent with the PID and/or the exact name (case-insensitive on\n" +
+			"Windows, where names include .exe). Processes are asked to exit unless force is true. The agent refuses to\n" +
+			"kill itself or critical system processes, and reports the processes that were killed.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.ProcessKill, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.ProcessList),
+		Short: "list running processes",
+		Long:  "list the processes running on the specified agent with their PID, name, user, command line, CPU, and memory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.ProcessList, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.Reboot),
 		Short: "reboot an agent",
 		Long:  "instruct the server to reboot the specified agent",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.Reboot, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.Reboot, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.RefreshServiceAccount + " agent_id=<agent ID> | tag=<tag>",
+		Use:   usage(commands.RefreshServiceAccount),
 		Short: "refresh service account",
 		Long:  "instruct the agent to generate a new service account password and send it to the server",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.RefreshServiceAccount, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.RefreshServiceAccount, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.Shutdown + " agent_id=<agent ID> | tag=<tag>",
+		Use:   usage(commands.ScreenLockSet),
+		Short: "enforce screen lock",
+		Long:  "configure the specified agent's screen to lock after delay_minutes of inactivity and require a password to unlock",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.ScreenLockSet, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.ServiceControl),
+		Short: "control a service",
+		Long: "start, stop, restart, or query a service on the specified agent using systemctl on Linux, launchctl on\n" +
+			"macOS, or the Service Control Manager on Windows. On macOS the name is a launchd label in the system domain\n" +
+			"or a full service target such as gui/501/<label>. The agent's own service cannot be controlled.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.ServiceControl, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.SetLogLevel),
+		Short: "change the agent's log level",
+		Long: "change the level of the agent's log without changing its configuration or restarting it. If duration_minutes\n" +
+			"is specified, the configured level is restored when it expires, otherwise when the agent restarts.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.SetLogLevel, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.SetServerURL),
+		Short: "move an agent to another server",
+		Long: "move the specified agent to the server at url. The agent checks the new server and performs a test sync\n" +
+			"with it, and only changes its configuration if that succeeds. If the new server does not accept the agent,\n" +
+			"it registers with migration_token, which is created on the new server with 'uem-cli regtoken migration new',\n" +
+			"and keeps its agent ID.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return execute(commands.SetServerURL, args, util.NewNVPairs(args), getWaitOptions(cmd))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   usage(commands.Shutdown),
 		Short: "shutdown an agent",
 		Long:  "instruct the server to shutdown the specified agent",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.Shutdown, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.Shutdown, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.Status + " agent_id=<agent ID> | tag=<tag>",
+		Use:   usage(commands.Status),
 		Short: "get agent status",
 		Long:  "request the status of the specified agent",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.Status, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.Status, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.Upgrade + " agent_id=<agent ID> | tag=<tag>",
+		Use:   usage(commands.Upgrade),
 		Short: "agent upgrade",
 		Long:  "instruct the agent to download and install the latest version",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.Upgrade, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.Upgrade, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.UserAdd + " agent_id=<agent ID> | tag=<tag> user=<username> password=<password> [admin=true|false]",
+		Use:   usage(commands.UserAdd),
 		Short: "add a user",
 		Long:  "add a user to the specified agent",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.UserAdd, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.UserAdd, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.UserDelete + " agent_id=<agent ID> | tag=<tag> user=<username> [shutdown=true]",
+		Use:   usage(commands.UserDelete),
 		Short: "delete a user",
 		Long:  "delete a user from the specified agent and optionally shutdown the device (default shutdown=false, specify shutdown=true to override)",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
 			pairs := util.NewNVPairs(args)
 			if _, ok := pairs.Pairs["shutdown"]; !ok {
 				pairs.Pairs["shutdown"] = "false"
 			}
-			return execute(commands.UserDelete, args, pairs, wait, timeout)
+			return execute(commands.UserDelete, args, pairs, getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.UserAdmin + " agent_id=<agent ID> | tag=<tag> user=<username> admin=true|false",
+		Use:   usage(commands.UserAdmin),
 		Short: "grant or revoke admin privileges",
 		Long:  "set or remove the specified user as an admin on the specified agent",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.UserAdmin, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.UserAdmin, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.UserPassword + " agent_id=<agent ID> | tag=<tag> user=<username> password=<password>",
+		Use:   usage(commands.UserPassword),
 		Short: "set user password",
 		Long:  "set the password for the specified user on the specified agent",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.UserPassword, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.UserPassword, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.UserList + " agent_id=<agent ID> | tag=<tag>",
+		Use:   usage(commands.UserList),
 		Short: "list users",
 		Long:  "list the users on the specified agent",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.UserList, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.UserList, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.UserLock + " agent_id=<agent ID> | tag=<tag> user=<username> [shutdown=false]",
+		Use:   usage(commands.UserLock),
 		Short: "lock user account",
 		Long:  "lock the specified user on the specified agent and shutdown the device (default shutdown=true, specify shutdown=false to override)",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
 			pairs := util.NewNVPairs(args)
 			if _, ok := pairs.Pairs["shutdown"]; !ok {
 				pairs.Pairs["shutdown"] = "true"
 			}
-			return execute(commands.UserLock, args, pairs, wait, timeout)
+			return execute(commands.UserLock, args, pairs, getWaitOptions(cmd))
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   commands.UserUnlock + " agent_id=<agent ID> | tag=<tag> user=<username> password=<new password>",
+		Use:   usage(commands.UserUnlock),
 		Short: "unlock user account",
 		Long:  "unlock the specified user account on the specified agent",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			wait, _ := cmd.Flags().GetBool("wait")
-			timeout, _ := cmd.Flags().GetInt("timeout")
-			return execute(commands.UserUnlock, args, util.NewNVPairs(args), wait, timeout)
+			return execute(commands.UserUnlock, args, util.NewNVPairs(args), getWaitOptions(cmd))
 		},
 	})
+
+	// Describe the parameters of each command
+	for _, sub := range cmd.Commands() {
+		sub.Long += parameterHelp(sub.Name())
+	}
 	return cmd
 }
 
-func execute(subCmd string, _ []string, pairs *util.NVPairs, wait bool, timeout int) error {
+// Wait sends a command and waits for the response, as 'cmd <command> --wait' does
+func Wait(subCmd string, args []string, timeout int) error {
+	return execute(subCmd, args, util.NewNVPairs(args), waitOptions{wait: true, timeout: timeout})
+}
+
+func execute(subCmd string, _ []string, pairs *util.NVPairs, opts waitOptions) error {
+
+	// Check the values before connecting so that a typo is reported without a round trip
+	if err := commands.CheckParams(subCmd, pairs.ToMap()); err != nil {
+		return fmt.Errorf("command %s validation failed: %s", subCmd, err.Error())
+	}
 
 	// Create communications object
-	c := communications.New(login.Login())
+	c := login.Connect()
+
+	// Substitute the agent ID if the agent was specified by name
+	if err := resolver.Pairs(c, pairs); err != nil {
+		return err
+	}
 
 	params := pairs.ToMap()
+
 	_, hasAgentID := params["agent_id"]
-	tag, hasTag := params["tag"]
+	_, hasTag := params["tag"]
+
+	_, hasGroup := params["group"]
 
 	if hasAgentID && hasTag {
 		return fmt.Errorf("cannot specify both agent_id and tag")
 	}
 
+	if hasGroup && (hasAgentID || hasTag) {
+		return fmt.Errorf("cannot specify group with agent_id or tag")
+	}
+
 	// Track request IDs if waiting
 	var requestIDs []string
 
-	if hasTag {
-		// Bulk action by tag
-		// Query the server for all agents with the tag
-		_, body, err := c.Get(schema.EndpointAgent + "/by-tag/" + tag)
-		if err != nil {
-			return fmt.Errorf("failed to query agents by tag: %v", err)
-		}
-		var resp schema.AgentsByTagResponse
-		if err := json.Unmarshal(body, &resp); err != nil {
-			return fmt.Errorf("failed to parse agents by tag response: %v", err)
-		}
-		if len(resp.Agents) == 0 {
-			return fmt.Errorf("no agents found with tag: %s", tag)
-		}
-		var firstErr error
-		for _, agent := range resp.Agents {
-			// Prepare parameters for this agent
-			newParams := make(map[string]string)
-			for k, v := range params {
-				if k != "tag" {
-					newParams[k] = v
-				}
-			}
-			newParams["agent_id"] = agent.AgentID
-			// Validate command for this agent
-			if err = commands.Validate(subCmd, newParams); err != nil {
-				fmt.Printf("command %s validation failed for agent %s: %s\n", subCmd, agent.AgentID, err.Error())
-				if firstErr == nil {
-					firstErr = err
-				}
-				continue
-			}
-			// Build and send command
-			cmdReq := schema.NewCmdRequest()
-			cmdReq.Cmd = subCmd
-			cmdReq.Parameters = newParams
+	if hasGroup || hasTag {
+		// The server queues the command for each agent in a group, and delivers a command
+		// for a tag to each agent with the tag when it syncs
+		cmdReq := schema.NewCmdRequest()
+		cmdReq.Cmd = subCmd
+		cmdReq.Parameters = params
 
-			// Post command and capture request ID
-			statusCode, data, err := c.Post(schema.EndpointCmd, cmdReq)
-
-			// Always display the initial response
+		statusCode, data, err := c.Post(schema.EndpointCmd, cmdReq)
+		if opts.showResponse(statusCode, err) {
 			display.ErrorWrapper(display.CmdResp(statusCode, data, err))
+		}
 
-			// Capture request ID if successful
-			if err == nil && statusCode == 200 {
-				var cmdResp schema.APICmdResponse
-				if err := json.Unmarshal(data, &cmdResp); err == nil && cmdResp.RequestID != "" {
+		if err == nil && statusCode == 200 {
+			var cmdResp schema.APICmdResponse
+			if err := json.Unmarshal(data, &cmdResp); err == nil {
+				for _, requestID := range cmdResp.Requests {
+					requestIDs = append(requestIDs, requestID)
+				}
+				if cmdResp.RequestID != "" {
 					requestIDs = append(requestIDs, cmdResp.RequestID)
 				}
 			}
 		}
 
-		// If waiting and we have request IDs, poll for responses
-		if wait && len(requestIDs) > 0 {
-			return waitForResponses(c, requestIDs, timeout)
+		if opts.wait && len(requestIDs) > 0 {
+			return waitForResponses(c, requestIDs, opts, nil)
 		}
-
-		return firstErr
+		return nil
 	}
 
 	// Single agent or normal case
@@ -302,8 +420,10 @@ func execute(subCmd string, _ []string, pairs *util.NVPairs, wait bool, timeout
 	// Post the command to the server
 	statusCode, data, err := c.Post(schema.EndpointCmd, cmd)
 
-	// Always display the initial response
-	display.ErrorWrapper(display.CmdResp(statusCode, data, err))
+	// Display the initial response
+	if opts.showResponse(statusCode, err) {
+		display.ErrorWrapper(display.CmdResp(statusCode, data, err))
+	}
 
 	// Capture request ID if successful
 	if err == nil && statusCode == 200 {
@@ -314,8 +434,8 @@ func execute(subCmd string, _ []string, pairs *util.NVPairs, wait bool, timeout
 	}
 
 	// If waiting and we have request IDs, poll for responses
-	if wait && len(requestIDs) > 0 {
-		return waitForResponses(c, requestIDs, timeout)
+	if opts.wait && len(requestIDs) > 0 {
+		return waitForResponses(c, requestIDs, opts, nil)
 	}
 
 	return nil
@@ -0,0 +1,97 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+)
+
+// The usage and parameter help of each subcommand are generated from the same parameter specs
+// that the server validates against, and that it describes at /cmd/spec
+
+// agentOnly lists the commands that can only be sent to a single agent
+var agentOnly = map[string]bool{
+	commands.FileFetch: true,
+	commands.LogsFetch: true,
+}
+
+// usage returns the cobra Use string for a command
+func usage(name string) string {
+	parts := []string{name, "agent_id=<agent ID> | tag=<tag> | group=<group>"}
+	if agentOnly[name] {
+		parts[1] = "agent_id=<agent ID>"
+	}
+
+	spec, ok := commands.Spec(name)
+	if !ok {
+		return name
+	}
+
+	for _, p := range spec.Params {
+		if p.Name == commands.AgentID || isExtraArg(p.Name) {
+			continue
+		}
+
+		text := p.Name + "=" + placeholder(p)
+		if p.Name == "arg1" {
+			text = "[arg1=value1] [arg2=value2] ..."
+		} else if !p.Required {
+			text = "[" + text + "]"
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// parameterHelp returns a description of a command's parameters to append to its Long text
+func parameterHelp(name string) string {
+	spec, ok := commands.Spec(name)
+	if !ok {
+		return ""
+	}
+
+	var lines []string
+	for _, p := range spec.Params {
+		if p.Help == "" || isExtraArg(p.Name) {
+			continue
+		}
+
+		help := p.Help
+		if p.Type == schema.ParamInt && p.Min != nil && p.Max != nil {
+			help += fmt.Sprintf(" (%d-%d)", *p.Min, *p.Max)
+		}
+		if !p.Required {
+			help += ", optional"
+		}
+		lines = append(lines, fmt.Sprintf("  %-18s %s", p.Name, help))
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n\nParameters:\n" + strings.Join(lines, "\n")
+}
+
+// placeholder returns how the value of a parameter is shown in the usage
+func placeholder(p schema.CmdParam) string {
+	switch p.Type {
+	case schema.ParamBool:
+		return "true|false"
+	case schema.ParamEnum:
+		return strings.Join(p.Values, "|")
+	default:
+		return "<" + strings.ReplaceAll(p.Name, "_", " ") + ">"
+	}
+}
+
+// isExtraArg reports whether a parameter is one of arg2 to arg12, which are shown with arg1
+func isExtraArg(name string) bool {
+	return strings.HasPrefix(name, "arg") && name != "arg1"
+}
@@ -8,139 +8,300 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
 	"time"
 
+	"github.com/spf13/cobra"
+
 	"github.com/UnifyEM/UnifyEM/cli/display"
 	"github.com/UnifyEM/UnifyEM/cli/global"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
-// waitForResponses polls the server for request status until all requests complete or a timeout occurs
-func waitForResponses(c global.Comms, requestIDs []string, timeout int) error {
+// pollInterval is the time between polls of pending requests
+const pollInterval = 5 * time.Second
+
+// Result states in addition to the request statuses reported by the server
+const (
+	resultTimedOut  = "timed out"
+	resultNotQueued = "not queued"
+	resultNotFound  = "not found"
+)
+
+// waitOptions control whether and how the CLI waits for agents to respond
+type waitOptions struct {
+	wait          bool
+	timeout       int
+	failOnTimeout bool
+	json          bool
+}
+
+// getWaitOptions returns the wait options from the persistent flags of the cmd command
+func getWaitOptions(cmd *cobra.Command) waitOptions {
+	var opts waitOptions
+	opts.wait, _ = cmd.Flags().GetBool("wait")
+	opts.timeout, _ = cmd.Flags().GetInt("timeout")
+	opts.failOnTimeout, _ = cmd.Flags().GetBool("fail-on-timeout")
+	opts.json, _ = cmd.Flags().GetBool("json")
+	return opts
+}
+
+// showResponse returns false if the server's response to queuing a command should not
+// be displayed because only the JSON summary is wanted. Errors are always displayed.
+func (o waitOptions) showResponse(statusCode int, err error) bool {
+	return !o.wait || !o.json || err != nil || statusCode != http.StatusOK
+}
+
+// waitResult is the outcome of a single request
+type waitResult struct {
+	RequestID string `json:"request_id,omitempty"`
+	AgentID   string `json:"agent_id,omitempty"`
+	Status    string `json:"status"`
+	Success   bool   `json:"success"`
+	Details   string `json:"details,omitempty"`
+
+	Latency *schema.RequestLatency `json:"latency,omitempty"`
+
+	// A tag request is not a result itself, but the requests delivered to agents are awaited
+	tag        bool
+	deliveries map[string]string
+}
+
+// waitSummary is the outcome of waiting for all requests
+type waitSummary struct {
+	Completed int          `json:"completed"`
+	Failed    int          `json:"failed"`
+	TimedOut  int          `json:"timed_out"`
+	Elapsed   int          `json:"elapsed"`
+	Results   []waitResult `json:"results"`
+}
+
+// waitForResponses polls the server for request status until all requests complete or the
+// timeout expires. Progress and responses are displayed as they arrive unless JSON output
+// was requested, in which case only the summary is printed. Agents that the request could
+// not be queued for are included in the summary as failures. An error is returned if any
+// agent failed, or if any timed out and failOnTimeout is set.
+func waitForResponses(c global.Comms, requestIDs []string, opts waitOptions, notQueued map[string]string) error {
+	summary := waitSummary{Results: make([]waitResult, 0, len(requestIDs)+len(notQueued))}
+	for agentID, reason := range notQueued {
+		summary.Results = append(summary.Results, waitResult{AgentID: agentID, Status: resultNotQueued, Details: reason})
+		summary.Failed++
+	}
+
 	if len(requestIDs) == 0 {
-		return nil
+		return finishWait(summary, opts)
 	}
 
-	// Create a map of pending requests
-	pendingRequests := make(map[string]bool)
+	// Pending requests and the agent each is for, once known
+	pending := make(map[string]string)
 	for _, id := range requestIDs {
-		pendingRequests[id] = true
+		pending[id] = ""
 	}
 
-	fmt.Printf("\nWaiting for response(s) (timeout: %ds)...\n", timeout)
+	// Tag requests still being delivered, and the deliveries already being awaited
+	tagPending := make(map[string]waitResult)
+	delivered := make(map[string]bool)
+	multiple := len(requestIDs) > 1
+
+	if !opts.json {
+		fmt.Printf("\nWaiting for %d response(s) (timeout: %ds)...\n", len(pending), opts.timeout)
+	}
 	startTime := time.Now()
+	deadline := startTime.Add(time.Duration(opts.timeout) * time.Second)
+
+	for {
+		for requestID := range pending {
+			result, complete := pollRequest(c, requestID, opts)
+			if result.tag {
+				for agentID, id := range result.deliveries {
+					if !delivered[id] {
+						delivered[id] = true
+						pending[id] = agentID
+						multiple = true
+					}
+				}
+				tagPending[requestID] = result
+				if complete {
+					delete(pending, requestID)
+					delete(tagPending, requestID)
+				}
+				continue
+			}
 
-	// Poll until all requests are complete or timeout
-	for len(pendingRequests) > 0 {
+			if result.AgentID != "" {
+				pending[requestID] = result.AgentID
+			}
+			if !complete {
+				continue
+			}
 
-		// Poll each pending request and collect completed ones
-		completedRequests := make([]string, 0)
-		for requestID := range pendingRequests {
-			if checkAndDisplayIfComplete(c, requestID) {
-				completedRequests = append(completedRequests, requestID)
+			// Deleting during iteration is safe and the request is not visited again
+			delete(pending, requestID)
+			summary.Results = append(summary.Results, result)
+			if result.Success {
+				summary.Completed++
+			} else {
+				summary.Failed++
 			}
 		}
 
-		// Remove completed requests from pending map (safe to do after iteration)
-		for _, requestID := range completedRequests {
-			delete(pendingRequests, requestID)
+		remaining := time.Until(deadline)
+		if len(pending) == 0 || remaining <= 0 {
+			break
 		}
 
-		// Check timeout
-		elapsed := int(time.Since(startTime).Seconds())
-		if elapsed >= timeout {
-			displayTimeoutMessage(c, pendingRequests, elapsed)
-			return nil
+		if !opts.json && multiple {
+			fmt.Printf("Progress: %d completed, %d failed, %d pending (%ds elapsed)\n",
+				summary.Completed, summary.Failed, len(pending), int(time.Since(startTime).Seconds()))
 		}
+		time.Sleep(min(pollInterval, remaining))
+	}
 
-		// Sleep before next poll cycle (only if there are still pending requests)
-		if len(pendingRequests) > 0 {
-			time.Sleep(5 * time.Second)
+	// Requests still pending are reported individually rather than failing the command
+	for requestID, agentID := range pending {
+		result := waitResult{RequestID: requestID, AgentID: agentID, Status: resultTimedOut}
+		if tagResult, ok := tagPending[requestID]; ok {
+			result.Details = tagResult.Details
 		}
+		summary.Results = append(summary.Results, result)
+		summary.TimedOut++
 	}
 
-	return nil
+	summary.Elapsed = int(time.Since(startTime).Seconds())
+	return finishWait(summary, opts)
 }
 
-// checkAndDisplayIfComplete polls a single request and displays it if complete
-// Returns true if the request is complete, false otherwise
-func checkAndDisplayIfComplete(c global.Comms, requestID string) bool {
+// pollRequest polls a single request and displays it if complete, unless only the JSON
+// summary is wanted. It returns the result and true if the request is complete.
+func pollRequest(c global.Comms, requestID string, opts waitOptions) (waitResult, bool) {
+	result := waitResult{RequestID: requestID}
+
 	statusCode, data, err := c.Get(schema.EndpointRequest + "/" + requestID)
 	if err != nil {
 		// Network error - keep polling
-		return false
+		return result, false
 	}
 
-	if statusCode != 200 {
+	if statusCode != http.StatusOK {
 		// Request not found or error - consider it complete to remove from pending
-		return true
+		result.Status = resultNotFound
+		result.Details = fmt.Sprintf("HTTP %d", statusCode)
+		return result, true
 	}
 
 	// Parse response
 	var resp schema.APIRequestStatusResponse
-	if err = json.Unmarshal(data, &resp); err != nil {
+	if err = json.Unmarshal(data, &resp); err != nil || len(resp.Data.Requests) == 0 {
 		// Parse error - keep polling
-		return false
+		return result, false
+	}
+
+	request := resp.Data.Requests[0]
+	if request.Tag != "" {
+		return pollTagRequest(request)
 	}
 
-	// Check if request has completed
-	if len(resp.Data.Requests) > 0 {
-		request := resp.Data.Requests[0]
-		if isRequestComplete(request.Status) {
-			// Display the completed request
-			fmt.Printf("\n")
-			display.ErrorWrapper(display.RequestList(statusCode, data, nil))
-			return true
+	result.AgentID = request.AgentID
+	result.Status = request.Status
+	result.Details = request.ResponseDetails
+	result.Latency = request.Latency
+	if !isRequestComplete(request.Status) {
+		return result, false
+	}
+	result.Success = request.Status == schema.RequestStatusComplete && (request.Success == nil || *request.Success)
+
+	if !opts.json {
+		// Display the completed request
+		fmt.Printf("\n")
+		display.ErrorWrapper(display.RequestList(statusCode, data, nil))
+
+		// The server only decrypts responses to sensitive commands for permitted roles
+		if request.ResponseEncrypted && request.ResponseDetails == schema.ResponseEncryptedDetails {
+			fmt.Printf("The response is encrypted and can only be read by roles listed in the sensitive_response_roles server setting\n")
+		}
+
+		if request.Latency != nil {
+			printLatency(request.Latency)
 		}
 	}
+	return result, true
+}
+
+// pollTagRequest returns the deliveries of a tag request. The tag request is complete when it is
+// no longer delivered, or when every agent that currently has the tag has received it.
+func pollTagRequest(request schema.AgentRequestRecord) (waitResult, bool) {
+	result := waitResult{RequestID: request.RequestID, Status: request.Status, tag: true, deliveries: request.Deliveries}
+	if request.Status != schema.RequestStatusActive {
+		return result, true
+	}
+	if request.Outcomes == nil {
+		return result, false
+	}
 
-	return false
+	remaining := request.Outcomes.Agents - request.Outcomes.Delivered
+	result.Details = fmt.Sprintf("%d of %d agents with tag %s have not synced", max(remaining, 0), request.Outcomes.Agents, request.Tag)
+	return result, remaining <= 0
 }
 
-// displayTimeoutMessage shows timeout information and lists non-responsive agents
-func displayTimeoutMessage(c global.Comms, pendingRequests map[string]bool, elapsed int) {
-
-	// Collect agent IDs from pending requests
-	var nonResponsiveAgents []string
-	for requestID := range pendingRequests {
-		statusCode, data, err := c.Get(schema.EndpointRequest + "/" + requestID)
-		if err == nil && statusCode == 200 {
-			var resp schema.APIRequestStatusResponse
-			if err := json.Unmarshal(data, &resp); err == nil {
-				if len(resp.Data.Requests) > 0 {
-					nonResponsiveAgents = append(nonResponsiveAgents, resp.Data.Requests[0].AgentID)
-				}
+// finishWait displays the summary and returns an error if the command should exit non-zero
+func finishWait(summary waitSummary, opts waitOptions) error {
+	sort.Slice(summary.Results, func(i, j int) bool {
+		if summary.Results[i].AgentID != summary.Results[j].AgentID {
+			return summary.Results[i].AgentID < summary.Results[j].AgentID
+		}
+		return summary.Results[i].RequestID < summary.Results[j].RequestID
+	})
+
+	if opts.json {
+		global.Pretty(summary)
+	} else {
+		fmt.Printf("\nCompleted %d, failed %d, timed out %d\n", summary.Completed, summary.Failed, summary.TimedOut)
+		for _, result := range summary.Results {
+			if result.Success {
+				continue
+			}
+			agentID := result.AgentID
+			if agentID == "" {
+				agentID = result.RequestID
+			}
+			if result.Details != "" {
+				fmt.Printf("  %s: %s (%s)\n", agentID, result.Status, result.Details)
+			} else {
+				fmt.Printf("  %s: %s\n", agentID, result.Status)
 			}
 		}
 	}
 
-	// Display timeout message with non-responsive agents
-	fmt.Printf("\n")
-	fmt.Printf("Wait timed out after %ds\n", elapsed)
-	if len(nonResponsiveAgents) > 0 {
-		fmt.Printf("The following agent(s) have not responded yet:\n")
-		for _, agentID := range nonResponsiveAgents {
-			fmt.Printf("  - %s\n", agentID)
-		}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d agent(s) failed", summary.Failed)
 	}
+	if opts.failOnTimeout && summary.TimedOut > 0 {
+		return fmt.Errorf("%d agent(s) did not respond before the timeout", summary.TimedOut)
+	}
+	return nil
 }
 
-/*
-// displayRequestStatus shows the current status of a request
-func displayRequestStatus(c global.Comms, requestID string) {
-	statusCode, data, err := c.Get(schema.EndpointRequest + "/" + requestID)
-	if err == nil {
-		fmt.Printf("\n")
-		display.ErrorWrapper(display.RequestList(statusCode, data, nil))
-	} else {
-		fmt.Printf("\nUnable to retrieve status for request %s: %v\n", requestID, err)
+// printLatency displays the latency breakdown of a ping
+func printLatency(latency *schema.RequestLatency) {
+	ms := func(n int64) time.Duration { return time.Duration(n) * time.Millisecond }
+	fmt.Printf("\nLatency:\n")
+	fmt.Printf("  queued (until sent to agent): %s\n", ms(latency.QueueMs))
+	fmt.Printf("  agent (received to response): %s\n", ms(latency.AgentMs))
+	fmt.Printf("  round trip:                   %s\n", ms(latency.RoundTripMs))
+	if !latency.AgentReceived.IsZero() {
+		fmt.Printf("  agent received:  %s\n", latency.AgentReceived.Local().Format(time.RFC3339Nano))
+		fmt.Printf("  agent responded: %s\n", latency.AgentResponded.Local().Format(time.RFC3339Nano))
 	}
 }
-*/
 
 // isRequestComplete checks if a request status indicates completion
 func isRequestComplete(status string) bool {
 	return status == schema.RequestStatusComplete ||
 		status == schema.RequestStatusFailed ||
-		status == schema.RequestStatusInvalid
+		status == schema.RequestStatusRefused ||
+		status == schema.RequestStatusDenied ||
+		status == schema.RequestStatusInvalid ||
+		status == schema.RequestStatusCancelled ||
+		status == schema.RequestStatusExpired
 }
@@ -0,0 +1,31 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package cmd
+
+import (
+	"testing"
+)
+
+func TestFinishWait(t *testing.T) {
+	tests := []struct {
+		name          string
+		summary       waitSummary
+		failOnTimeout bool
+		expectError   bool
+	}{
+		{"all completed", waitSummary{Completed: 3}, false, false},
+		{"timed out", waitSummary{Completed: 2, TimedOut: 1}, false, false},
+		{"timed out with fail-on-timeout", waitSummary{Completed: 2, TimedOut: 1}, true, true},
+		{"failed", waitSummary{Completed: 2, Failed: 1}, false, true},
+	}
+
+	for _, test := range tests {
+		err := finishWait(test.summary, waitOptions{wait: true, json: true, failOnTimeout: test.failOnTimeout})
+		if (err != nil) != test.expectError {
+			t.Errorf("%s: expected error %t, got %v", test.name, test.expectError, err)
+		}
+	}
+}
@@ -11,7 +11,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
 	"github.com/UnifyEM/UnifyEM/cli/login"
 	"github.com/UnifyEM/UnifyEM/cli/util"
@@ -22,7 +21,7 @@ func Register() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "configuration commands",
-		Long:  "get or set configuration information",
+		Long:  "get or set configuration information, and manage the server contexts used by the CLI",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return fmt.Errorf("a subcommand is required\n")
@@ -96,6 +95,7 @@ func Register() *cobra.Command {
 	// add the agent commands
 	cmd.AddCommand(agents)
 	cmd.AddCommand(server)
+	cmd.AddCommand(contextCommands()...)
 	return cmd
 }
 
@@ -113,7 +113,7 @@ func get(cfg string) error {
 	}
 
 	// Create communications object
-	c := communications.New(login.Login())
+	c := login.Connect()
 
 	// Post the command to the server and display the result
 	display.ErrorWrapper(display.AnyResp(c.Get(endpoint)))
@@ -134,7 +134,7 @@ func set(cfg string, pairs *util.NVPairs) error {
 	}
 
 	// Create communications object
-	c := communications.New(login.Login())
+	c := login.Connect()
 
 	// Initialize a new command object
 	req := schema.NewConfigRequest()
@@ -0,0 +1,131 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package configCmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/UnifyEM/UnifyEM/cli/contexts"
+	"github.com/UnifyEM/UnifyEM/cli/credentials"
+	"github.com/UnifyEM/UnifyEM/cli/global"
+)
+
+// contextCommands returns the commands that manage server contexts
+func contextCommands() []*cobra.Command {
+	setContext := &cobra.Command{
+		Use:   "set-context <name> [--url <URL>] [--user <user>] [--password <password>]",
+		Short: "create or update a server context",
+		Long: "create or update a named server context. A new context requires --url. The user and password are\n" +
+			"optional; if they are not set, the environment is used or 'uem-cli login' prompts for them. The first\n" +
+			"context created becomes the current context.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url, _ := cmd.Flags().GetString("url")
+			user, _ := cmd.Flags().GetString("user")
+			password, _ := cmd.Flags().GetString("password")
+			return updateContexts(func(cfg *contexts.Config) error {
+				return cfg.Set(args[0], url, user, password)
+			}, fmt.Sprintf("context %s saved", args[0]))
+		},
+	}
+	setContext.Flags().String("url", "", "protocol, FQDN, and port of the server (i.e. https://uem.example.com:443)")
+	setContext.Flags().String("user", "", "user to log in as")
+	setContext.Flags().String("password", "", "password to log in with")
+
+	return []*cobra.Command{
+		setContext,
+		{
+			Use:   "use-context <name>",
+			Short: "select the current server context",
+			Long:  "select the server context used when --context is not specified",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return updateContexts(func(cfg *contexts.Config) error {
+					return cfg.Use(args[0])
+				}, fmt.Sprintf("switched to context %s", args[0]))
+			},
+		},
+		{
+			Use:   "delete-context <name>",
+			Short: "delete a server context",
+			Long:  "delete a server context and the tokens stored for it. Use logout first to revoke the tokens.",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return updateContexts(func(cfg *contexts.Config) error {
+					if err := cfg.Delete(args[0]); err != nil {
+						return err
+					}
+					return credentials.Delete(args[0])
+				}, fmt.Sprintf("context %s deleted", args[0]))
+			},
+		},
+		{
+			Use:   "contexts",
+			Short: "list server contexts",
+			Long:  "list the server contexts, marking the current context with *",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cfg, err := contexts.Load()
+				if err != nil {
+					return err
+				}
+				for _, name := range cfg.Names() {
+					marker := " "
+					if name == cfg.Current {
+						marker = "*"
+					}
+					fmt.Printf("%s %-20s %s\n", marker, name, cfg.Contexts[name].URL)
+				}
+				return nil
+			},
+		},
+		{
+			Use:   "current",
+			Short: "show the server context in use",
+			Long:  "show the server context in use, which is the one specified with --context or the current context",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cfg, err := contexts.Load()
+				if err != nil {
+					return err
+				}
+				name, ctx, err := cfg.Selected(global.Context)
+				if err != nil {
+					return err
+				}
+				if ctx == nil {
+					fmt.Printf("No context is selected; UEM_SERVER, UEM_USER, and UEM_PASS are used\n")
+					return nil
+				}
+
+				fmt.Printf("Context:   %s\n", name)
+				fmt.Printf("Server:    %s\n", ctx.URL)
+				if ctx.User != "" {
+					fmt.Printf("User:      %s\n", ctx.User)
+				}
+				credentials.Use(name, ctx.URL, ctx.User)
+				fmt.Printf("Logged in: %t\n", credentials.GetRefreshToken() != "")
+				return nil
+			},
+		},
+	}
+}
+
+// updateContexts loads the contexts, applies fn, and saves them
+func updateContexts(fn func(cfg *contexts.Config) error, message string) error {
+	cfg, err := contexts.Load()
+	if err != nil {
+		return err
+	}
+	if err = fn(cfg); err != nil {
+		return err
+	}
+	if err = cfg.Save(); err != nil {
+		return err
+	}
+	fmt.Println(message)
+	return nil
+}
@@ -6,17 +6,27 @@
 package events
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/global"
 	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
 	"github.com/UnifyEM/UnifyEM/cli/util"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
+// tailWindow is how far back --tail starts if no start time or cursor is specified
+const tailWindow = time.Hour
+
 func Register() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "events",
@@ -31,20 +41,116 @@ func Register() *cobra.Command {
 		},
 	}
 
-	cmd.AddCommand(&cobra.Command{
-		Use:   "get agent_id=<agent_id [start=<YYYYMMDD>] [end=<YYYYMMDD>] [start_time=<unix time>] [end_time=<unix time>] [type=<message|alert|status>]",
+	getCmd := &cobra.Command{
+		Use:   "get [agent_id=<agent_id>] [start=<YYYYMMDD>] [end=<YYYYMMDD>] [start_time=<unix time>] [end_time=<unix time>] [type=<message|alert|status|location>] [limit=<n>] [cursor=<cursor>]",
 		Short: "get events",
-		Long:  "get events for the specified agent with optional start and end times",
+		Long: "get events for the specified agent, agent_id=server for events recorded by the server, or all agents if no agent is specified.\n" +
+			"Events are returned in pages of up to 1000 events by default. If there are more, the response has \"more\": true\n" +
+			"and the cursor to pass with --cursor to get the next page. --tail displays the events as they are recorded,\n" +
+			"starting an hour ago unless a start time or cursor is specified, until interrupted.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return eventsGet(args, util.NewNVPairs(args))
+			pairs := util.NewNVPairs(args)
+			for flag, name := range map[string]string{
+				"agent": "agent_id", "type": "type", "start": "start", "end": "end", "limit": "limit", "cursor": "cursor"} {
+				if value, _ := cmd.Flags().GetString(flag); value != "" {
+					pairs.Pairs[name] = value
+				}
+			}
+
+			if tail, _ := cmd.Flags().GetBool("tail"); tail {
+				interval, _ := cmd.Flags().GetDuration("interval")
+				return eventsTail(pairs, interval)
+			}
+			return eventsGet(args, pairs)
 		},
-	})
+	}
+	getCmd.Flags().StringP("agent", "a", "", "agent ID or hostname, or server for events recorded by the server")
+	getCmd.Flags().StringP("type", "t", "", "only events of this type, for example alert")
+	getCmd.Flags().String("start", "", "start date in YYYYMMDD format")
+	getCmd.Flags().String("end", "", "end date in YYYYMMDD format")
+	getCmd.Flags().StringP("limit", "n", "", "maximum number of events per page, up to 10000")
+	getCmd.Flags().String("cursor", "", "continue after the last event of a previous response")
+	getCmd.Flags().BoolP("tail", "f", false, "display new events as they are recorded")
+	getCmd.Flags().Duration("interval", 5*time.Second, "time between polls with --tail")
+	cmd.AddCommand(getCmd)
 
 	return cmd
 }
 
+// resolveAgent replaces a hostname in agent_id with the agent ID. Events recorded by the server
+// itself are requested with agent_id=server.
+func resolveAgent(c global.Comms, pairs *util.NVPairs) error {
+	if pairs.Pairs["agent_id"] == schema.ServerEventsID {
+		return nil
+	}
+	return resolver.Pairs(c, pairs)
+}
+
 func eventsGet(_ []string, pairs *util.NVPairs) error {
-	c := communications.New(login.Login())
+	c := login.Connect()
+	if err := resolveAgent(c, pairs); err != nil {
+		return err
+	}
 	display.ErrorWrapper(display.AnyResp(c.GetQuery(schema.EndpointEvents, pairs)))
 	return nil
 }
+
+// eventsTail displays events one per line, polling for new events after the last one displayed
+func eventsTail(pairs *util.NVPairs, interval time.Duration) error {
+	if interval < time.Second {
+		return fmt.Errorf("interval must be at least 1s")
+	}
+
+	c := login.Connect()
+	if err := resolveAgent(c, pairs); err != nil {
+		return err
+	}
+
+	_, hasStart := pairs.Pairs["start"]
+	_, hasStartTime := pairs.Pairs["start_time"]
+	if !hasStart && !hasStartTime && pairs.Pairs["cursor"] == "" {
+		pairs.Pairs["start_time"] = strconv.FormatInt(time.Now().Add(-tailWindow).Unix(), 10)
+	}
+
+	for {
+		statusCode, data, err := c.GetQuery(schema.EndpointEvents, pairs)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve events: %w", err)
+		}
+
+		var resp schema.APIEventsResponse
+		if err = json.Unmarshal(data, &resp); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		if statusCode != http.StatusOK {
+			return fmt.Errorf("server response: HTTP %d %s", statusCode, resp.Details)
+		}
+
+		for _, event := range resp.Data {
+			printEvent(event)
+		}
+		if resp.Cursor != "" {
+			pairs.Pairs["cursor"] = resp.Cursor
+		}
+
+		// Read the remaining pages without waiting
+		if !resp.More {
+			time.Sleep(interval)
+		}
+	}
+}
+
+// printEvent displays an event on a single line
+func printEvent(event schema.AgentEvent) {
+	var details []string
+	for name, value := range event.Details {
+		details = append(details, fmt.Sprintf("%s=%q", name, value))
+	}
+	sort.Strings(details)
+
+	fmt.Printf("%s %-38s %-12s %s", event.Time.Local().Format("2006-01-02 15:04:05"), event.AgentID, event.EventType, event.Event)
+	if len(details) > 0 {
+		fmt.Printf(" %s", strings.Join(details, " "))
+	}
+	fmt.Println()
+}
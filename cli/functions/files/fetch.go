@@ -0,0 +1,207 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package files
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/global"
+	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
+	"github.com/UnifyEM/UnifyEM/cli/util"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+)
+
+// fetch sends a file_fetch command to an agent and, if wait is true, waits
+// for the agent to upload the file and then downloads it from the server
+func fetch(pairs *util.NVPairs, wait bool, timeout int, output string) error {
+	c := login.Connect()
+
+	if err := resolver.Pairs(c, pairs); err != nil {
+		return err
+	}
+
+	params := pairs.ToMap()
+	err := commands.Validate(commands.FileFetch, params)
+	if err != nil {
+		return fmt.Errorf("command %s validation failed: %s", commands.FileFetch, err.Error())
+	}
+
+	cmd := schema.NewCmdRequest()
+	cmd.Cmd = commands.FileFetch
+	cmd.Parameters = params
+
+	statusCode, data, err := c.Post(schema.EndpointCmd, cmd)
+	display.ErrorWrapper(display.CmdResp(statusCode, data, err))
+	if err != nil || statusCode != 200 {
+		return nil
+	}
+
+	var cmdResp schema.APICmdResponse
+	if err = json.Unmarshal(data, &cmdResp); err != nil || cmdResp.RequestID == "" {
+		return errors.New("unable to obtain request ID from server response")
+	}
+
+	if !wait {
+		fmt.Printf("\nOnce the agent has responded, retrieve the file with:\n  files get request_id=%s\n", cmdResp.RequestID)
+		return nil
+	}
+
+	fmt.Printf("\nWaiting for the agent to upload the file (timeout: %ds)...\n", timeout)
+	request, err := waitForRequest(c, cmdResp.RequestID, timeout,
+		"retrieve the file later with: files get request_id="+cmdResp.RequestID)
+	if err != nil {
+		return err
+	}
+
+	if request.Status != schema.RequestStatusComplete {
+		return fmt.Errorf("file fetch %s: %s", request.Status, request.ResponseDetails)
+	}
+
+	return download(c, request, output)
+}
+
+// get downloads a file previously uploaded by an agent in response to a file_fetch command
+func get(pairs *util.NVPairs, output string) error {
+	c := login.Connect()
+
+	requestID := pairs.ToMap()["request_id"]
+	if requestID == "" {
+		return errors.New("request_id is required")
+	}
+
+	request, err := getRequest(c, requestID)
+	if err != nil {
+		return err
+	}
+
+	if request.Request != commands.FileFetch && request.Request != commands.LogsFetch {
+		return fmt.Errorf("request %s is not a %s or %s request", requestID, commands.FileFetch, commands.LogsFetch)
+	}
+
+	if request.Status != schema.RequestStatusComplete {
+		return fmt.Errorf("request %s status is %s", requestID, request.Status)
+	}
+
+	return download(c, request, output)
+}
+
+// waitForRequest polls the server until the request is complete or the timeout expires.
+// The hint is included in the error if the timeout expires.
+func waitForRequest(c global.Comms, requestID string, timeout int, hint string) (schema.AgentRequestRecord, error) {
+	startTime := time.Now()
+	for {
+		request, err := getRequest(c, requestID)
+		if err == nil {
+			switch request.Status {
+			case schema.RequestStatusComplete, schema.RequestStatusFailed, schema.RequestStatusRefused,
+				schema.RequestStatusInvalid, schema.RequestStatusCancelled, schema.RequestStatusExpired, schema.RequestStatusDenied:
+				return request, nil
+			}
+		}
+
+		if int(time.Since(startTime).Seconds()) >= timeout {
+			return schema.AgentRequestRecord{}, fmt.Errorf("wait timed out, %s", hint)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// getRequest retrieves a request record from the server
+func getRequest(c global.Comms, requestID string) (schema.AgentRequestRecord, error) {
+	statusCode, data, err := c.Get(schema.EndpointRequest + "/" + requestID)
+	if err != nil {
+		return schema.AgentRequestRecord{}, err
+	}
+
+	if statusCode != 200 {
+		return schema.AgentRequestRecord{}, fmt.Errorf("unable to retrieve request %s: HTTP %d", requestID, statusCode)
+	}
+
+	var resp schema.APIRequestStatusResponse
+	if err = json.Unmarshal(data, &resp); err != nil {
+		return schema.AgentRequestRecord{}, fmt.Errorf("error parsing request response: %w", err)
+	}
+
+	if len(resp.Data.Requests) == 0 {
+		return schema.AgentRequestRecord{}, fmt.Errorf("request %s not found", requestID)
+	}
+	return resp.Data.Requests[0], nil
+}
+
+// download retrieves the uploaded file and writes it to output or, if output is
+// empty, to the file's original name in the current directory
+func download(c global.Comms, request schema.AgentRequestRecord, output string) error {
+	data, err := getUpload(c, request.RequestID)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		output = uploadName(request)
+	}
+
+	// Never overwrite an existing file
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", output, err)
+	}
+
+	fmt.Printf("\n%d bytes written to %s\n", len(data), output)
+	if sha256 := responseValue(request, "sha256"); sha256 != "" {
+		fmt.Printf("sha256: %s\n", sha256)
+	}
+	return nil
+}
+
+// getUpload retrieves the file uploaded by an agent in response to the request
+func getUpload(c global.Comms, requestID string) ([]byte, error) {
+	statusCode, data, err := c.Get(schema.EndpointAgentUpload + "/" + requestID)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading file: %w", err)
+	}
+
+	if statusCode != 200 {
+		var resp schema.APIGenericResponse
+		_ = json.Unmarshal(data, &resp)
+		return nil, fmt.Errorf("error downloading file: HTTP %d %s", statusCode, resp.Details)
+	}
+	return data, nil
+}
+
+// uploadName returns a safe local filename for the uploaded file
+func uploadName(request schema.AgentRequestRecord) string {
+	name := filepath.Base(responseValue(request, "name"))
+	if name == "" || name == "." || name == ".." || name == string(filepath.Separator) {
+		name = request.RequestID
+	}
+	return name
+}
+
+// responseValue returns a string value from the request's response data
+func responseValue(request schema.AgentRequestRecord, key string) string {
+	data, ok := request.ResponseData.(map[string]any)
+	if !ok {
+		return ""
+	}
+	value, _ := data[key].(string)
+	return value
+}
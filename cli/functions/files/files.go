@@ -7,12 +7,14 @@ package files
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
 	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/util"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
@@ -31,19 +33,112 @@ func Register() *cobra.Command {
 	}
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   "deploy",
+		Use:   "deploy [channel=<channel>] [version=<version>] [build=<build>] [min_version=<version>]",
 		Short: "create deploy.json",
-		Long:  "create deploy.json containing file hashes for agent upgrades",
+		Long: "create deploy.json, a manifest of the uem-agent-<os>-<arch> binaries for agent upgrades. The binaries are\n" +
+			"given the version and build specified, or the server's version and build, and agents older than\n" +
+			"min_version refuse to upgrade to them. If a channel is specified, deploy-<channel>.json is created\n" +
+			"from the agent files in the <channel> subdirectory.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return createDeploy()
+			return createDeploy(util.NewNVPairs(args))
+		},
+	})
+
+	fetchCmd := &cobra.Command{
+		Use:   "fetch agent_id=<agent ID> path=<path>",
+		Short: "retrieve a file from an agent",
+		Long: "instruct the agent to upload the specified file to the server. With --wait, the file is\n" +
+			"downloaded once the agent has uploaded it, otherwise use 'files get' to retrieve it later.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wait, _ := cmd.Flags().GetBool("wait")
+			timeout, _ := cmd.Flags().GetInt("timeout")
+			output, _ := cmd.Flags().GetString("output")
+			return fetch(util.NewNVPairs(args), wait, timeout, output)
+		},
+	}
+	fetchCmd.Flags().BoolP("wait", "w", false, "wait for the agent to upload the file and download it")
+	fetchCmd.Flags().IntP("timeout", "t", 300, "timeout in seconds when waiting (default: 300)")
+	fetchCmd.Flags().StringP("output", "o", "", "local filename (default: the file's original name)")
+	cmd.AddCommand(fetchCmd)
+
+	getCmd := &cobra.Command{
+		Use:   "get request_id=<request ID>",
+		Short: "download a file fetched from an agent",
+		Long:  "download a file uploaded by an agent in response to a file_fetch request",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, _ := cmd.Flags().GetString("output")
+			return get(util.NewNVPairs(args), output)
+		},
+	}
+	getCmd.Flags().StringP("output", "o", "", "local filename (default: the file's original name)")
+	cmd.AddCommand(getCmd)
+
+	uploadCmd := &cobra.Command{
+		Use:   "upload <path>",
+		Short: "upload a file to the server",
+		Long: "upload a local file to the server's file directory, replacing any file with the same name, for use with\n" +
+			"download_execute and file_push",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, _ := cmd.Flags().GetString("name")
+			return upload(args, name)
+		},
+	}
+	uploadCmd.Flags().StringP("name", "n", "", "name to store the file as (default: the file's name)")
+	cmd.AddCommand(uploadCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list files on the server",
+		Long:  "list the name, size in bytes, and base64 SHA256 hash of each file in the server's file directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return list()
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "keygen [output_path]",
+		Short: "generate publisher keypair",
+		Long: "generate a keypair for signing download_execute payloads, save the private key to a file, and\n" +
+			"display the public key to set as the agents' publisher_key",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return publisherKeygen(args)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "sign file=<file> [key=<private key file>] [hash_alg=sha256|sha512]",
+		Short: "sign a download_execute payload",
+		Long: "create a detached signature of a file and save it as <file>.sig. If both are uploaded to the server's\n" +
+			"file directory, the server sends the signature to agents with download_execute requests for the file.\n" +
+			"Requests must use the same hash_alg as the signature.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sign(util.NewNVPairs(args))
 		},
 	})
 
 	return cmd
 }
 
-func createDeploy() error {
-	c := communications.New(login.Login())
-	display.ErrorWrapper(display.GenericResp(c.Post(schema.EndpointCreateDeployFile, nil)))
+func createDeploy(pairs *util.NVPairs) error {
+	args := pairs.ToMap()
+	endpoint := schema.EndpointCreateDeployFile
+	if channel := args["channel"]; channel != "" {
+		endpoint += "?channel=" + url.QueryEscape(channel)
+	}
+
+	deployReq := schema.DeployFileRequest{
+		Version:    args["version"],
+		MinVersion: args["min_version"],
+	}
+	if b := args["build"]; b != "" {
+		build, err := strconv.Atoi(b)
+		if err != nil || build < 0 {
+			return fmt.Errorf("invalid build: %s", b)
+		}
+		deployReq.Build = build
+	}
+
+	c := login.Connect()
+	display.ErrorWrapper(display.GenericResp(c.Post(endpoint, deployReq)))
 	return nil
 }
@@ -0,0 +1,87 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package files
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
+	"github.com/UnifyEM/UnifyEM/cli/util"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+)
+
+// LogsFetch sends a logs_fetch command to an agent and, if wait is true, waits for the
+// agent to respond and writes the log to stdout. Small logs are returned in the response
+// and larger ones are uploaded by the agent and downloaded from the server.
+func LogsFetch(pairs *util.NVPairs, wait bool, timeout int) error {
+	c := login.Connect()
+
+	if err := resolver.Pairs(c, pairs); err != nil {
+		return err
+	}
+
+	params := pairs.ToMap()
+	err := commands.Validate(commands.LogsFetch, params)
+	if err != nil {
+		return fmt.Errorf("command %s validation failed: %s", commands.LogsFetch, err.Error())
+	}
+
+	cmd := schema.NewCmdRequest()
+	cmd.Cmd = commands.LogsFetch
+	cmd.Parameters = params
+
+	statusCode, data, err := c.Post(schema.EndpointCmd, cmd)
+	display.ErrorWrapper(display.CmdResp(statusCode, data, err))
+	if err != nil || statusCode != 200 {
+		return nil
+	}
+
+	var cmdResp schema.APICmdResponse
+	if err = json.Unmarshal(data, &cmdResp); err != nil || cmdResp.RequestID == "" {
+		return errors.New("unable to obtain request ID from server response")
+	}
+
+	hint := fmt.Sprintf("view the response later with: request get %s", cmdResp.RequestID)
+	if !wait {
+		fmt.Printf("\nOnce the agent has responded, %s\n", hint)
+		return nil
+	}
+
+	fmt.Printf("\nWaiting for the agent to respond (timeout: %ds)...\n", timeout)
+	request, err := waitForRequest(c, cmdResp.RequestID, timeout, hint)
+	if err != nil {
+		return err
+	}
+
+	if request.Status != schema.RequestStatusComplete {
+		return fmt.Errorf("logs fetch %s: %s", request.Status, request.ResponseDetails)
+	}
+
+	if responseValue(request, "truncated") == "true" {
+		fmt.Printf("\nThe log exceeds the maximum size, only the most recent entries are shown.\n")
+	}
+	fmt.Printf("\n")
+
+	// Small logs are included in the response
+	responseData, _ := request.ResponseData.(map[string]any)
+	if _, ok := responseData["log"]; ok {
+		_, err = os.Stdout.WriteString(responseValue(request, "log"))
+		return err
+	}
+
+	data, err = getUpload(c, request.RequestID)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
@@ -0,0 +1,125 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package files
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+
+	"github.com/UnifyEM/UnifyEM/cli/util"
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/hasher"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+const defaultPublisherKeyFile = "publisher_key.pem"
+
+// publisherKeygen generates a publisher keypair, saves the private key, and prints the public
+// key to be set as the publisher_key agent configuration
+func publisherKeygen(args []string) error {
+	outputPath := defaultPublisherKeyFile
+	if len(args) > 0 {
+		outputPath = args[0]
+	}
+
+	privateKey, publicKey, err := crypto.GenerateSingleKeyPair()
+	if err != nil {
+		return fmt.Errorf("failed to generate keypair: %w", err)
+	}
+
+	passphrase, err := promptPassphrase("Enter passphrase (leave empty for no encryption): ")
+	if err != nil {
+		return fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	if passphrase != "" {
+		confirm, err := promptPassphrase("Confirm passphrase: ")
+		if err != nil {
+			return fmt.Errorf("failed to read passphrase confirmation: %w", err)
+		}
+		if passphrase != confirm {
+			return errors.New("passphrases do not match")
+		}
+	}
+
+	if err = crypto.SavePrivateKeyPEM(privateKey, outputPath, passphrase); err != nil {
+		return fmt.Errorf("failed to save private key: %w", err)
+	}
+	fmt.Printf("Private key saved to: %s\n", outputPath)
+	fmt.Printf("Configure agents to verify signatures with:\n  config agents set %s=%s\n", schema.ConfigAgentPublisherKey, publicKey)
+	return nil
+}
+
+// sign creates a detached signature of a file for download_execute and saves it alongside the
+// file with a .sig suffix. The server sends the signature with the request if the file is
+// hosted in its file directory.
+func sign(pairs *util.NVPairs) error {
+	params := pairs.ToMap()
+	file := params["file"]
+	if file == "" {
+		return errors.New("file is required")
+	}
+
+	keyPath := params["key"]
+	if keyPath == "" {
+		keyPath = defaultPublisherKeyFile
+	}
+
+	alg := params["hash_alg"]
+	if alg == "" {
+		alg = hasher.SHA256
+	}
+	if !hasher.ValidAlg(alg) {
+		return fmt.Errorf("hash_alg must be %s or %s", hasher.SHA256, hasher.SHA512)
+	}
+
+	privateKey, err := crypto.LoadPrivateKeyPEM(keyPath, "")
+	if err != nil {
+		if !errors.Is(err, crypto.ErrKeyEncrypted) {
+			return fmt.Errorf("failed to load private key: %w", err)
+		}
+		passphrase, pErr := promptPassphrase("Enter passphrase for private key: ")
+		if pErr != nil {
+			return fmt.Errorf("failed to read passphrase: %w", pErr)
+		}
+		privateKey, err = crypto.LoadPrivateKeyPEM(keyPath, passphrase)
+		if err != nil {
+			return fmt.Errorf("failed to load private key: %w", err)
+		}
+	}
+
+	sum := hasher.New().File(alg, file)
+	if len(sum.Bytes()) == 0 {
+		return fmt.Errorf("failed to hash %s", file)
+	}
+
+	signature, err := crypto.Sign(schema.PayloadSignatureData(alg, sum.Base64()), privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign %s: %w", file, err)
+	}
+
+	sigFile := file + ".sig"
+	if err = os.WriteFile(sigFile, []byte(signature+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", sigFile, err)
+	}
+
+	fmt.Printf("Signature saved to: %s\n", sigFile)
+	fmt.Printf("hash_alg=%s\nhash=%s\nsignature=%s\n", alg, sum.Base64(), signature)
+	return nil
+}
+
+func promptPassphrase(prompt string) (string, error) {
+	fmt.Print(prompt)
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,83 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package files
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/UnifyEM/UnifyEM/cli/credentials"
+	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/global"
+	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// upload sends a local file to the server's file directory
+func upload(args []string, name string) error {
+	if len(args) != 1 {
+		return errors.New("the path of the file to upload is required")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", args[0], err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	endpoint := schema.EndpointFiles
+	if name == "" {
+		name = filepath.Base(args[0])
+	} else {
+		endpoint += "?name=" + url.QueryEscape(name)
+	}
+
+	c := login.Connect()
+	display.ErrorWrapper(display.AnyResp(c.Upload(endpoint, name, f)))
+	return nil
+}
+
+// list displays the files in the server's file directory
+func list() error {
+	c := login.Connect()
+	statusCode, data, err := c.Get(schema.EndpointFiles)
+	if err != nil {
+		return fmt.Errorf("HTTP get failed: %w", err)
+	}
+
+	fmt.Printf("\nServer response: HTTP %d\n", statusCode)
+
+	var resp schema.APIFilesResponse
+	if err = json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if resp.Status == schema.APIStatusExpired {
+		credentials.AccessExpired()
+	}
+
+	if statusCode != 200 {
+		global.Pretty(resp)
+		return nil
+	}
+
+	if len(resp.Data) == 0 {
+		fmt.Printf("No files found\n")
+		return nil
+	}
+
+	fmt.Println()
+	for _, file := range resp.Data {
+		fmt.Printf("%-40s %12d %s\n", file.Name, file.Size, file.SHA256)
+	}
+	return nil
+}
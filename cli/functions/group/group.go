@@ -0,0 +1,158 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package group
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func Register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "group",
+		Aliases: []string{"groups"},
+		Short:   "group functions",
+		Long:    "create, list, and delete agent groups and manage their members",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("a subcommand is required")
+			}
+			return fmt.Errorf("unknown subcommand: %s", args[0])
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list groups",
+		Long:  "list all groups",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return groupList()
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get <group>",
+		Short: "get group",
+		Long:  "get the group and all agents in it, including those in the groups it contains",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return groupGet(args)
+		},
+	})
+
+	createCmd := &cobra.Command{
+		Use:   "create <group>",
+		Short: "create group",
+		Long:  "create an empty group",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			description, _ := cmd.Flags().GetString("description")
+			return groupCreate(args, description)
+		},
+	}
+	createCmd.Flags().StringP("description", "d", "", "group description")
+	cmd.AddCommand(createCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "delete <group>",
+		Short: "delete group",
+		Long:  "delete the group and remove it from any groups that contain it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return groupDelete(args)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "add-member <group> <agent_id>|group=<group> ...",
+		Short: "add members to a group",
+		Long:  "add one or more agents or groups to the group",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return groupMembers(args, "/add")
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove-member <group> <agent_id>|group=<group> ...",
+		Short: "remove members from a group",
+		Long:  "remove one or more agents or groups from the group",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return groupMembers(args, "/remove")
+		},
+	})
+
+	return cmd
+}
+
+func groupList() error {
+	c := login.Connect()
+	display.ErrorWrapper(display.AnyResp(c.Get(schema.EndpointGroup)))
+	return nil
+}
+
+func groupGet(args []string) error {
+	if len(args) == 0 {
+		return errors.New("group name is required")
+	}
+
+	c := login.Connect()
+	display.ErrorWrapper(display.AnyResp(c.Get(schema.EndpointGroup + "/" + url.PathEscape(args[0]))))
+	return nil
+}
+
+func groupCreate(args []string, description string) error {
+	if len(args) == 0 {
+		return errors.New("group name is required")
+	}
+
+	req := schema.GroupRequest{Name: args[0], Description: description}
+	c := login.Connect()
+	display.ErrorWrapper(display.AnyResp(c.Post(schema.EndpointGroup, req)))
+	return nil
+}
+
+func groupDelete(args []string) error {
+	if len(args) == 0 {
+		return errors.New("group name is required")
+	}
+
+	c := login.Connect()
+	display.ErrorWrapper(display.GenericResp(c.Delete(schema.EndpointGroup + "/" + url.PathEscape(args[0]))))
+	return nil
+}
+
+// groupMembers adds or removes members. Arguments in the form group=<name> are groups,
+// and all other arguments are agent IDs or identify an agent by name.
+func groupMembers(args []string, action string) error {
+	if len(args) < 2 {
+		return errors.New("group name and at least one member are required")
+	}
+
+	c := login.Connect()
+
+	var req schema.GroupMembersRequest
+	for _, arg := range args[1:] {
+		if group, ok := strings.CutPrefix(arg, "group="); ok {
+			req.Groups = append(req.Groups, group)
+			continue
+		}
+		agentID, err := resolver.Agent(c, arg)
+		if err != nil {
+			return err
+		}
+		req.Agents = append(req.Agents, agentID)
+	}
+
+	display.ErrorWrapper(display.AnyResp(c.Post(schema.EndpointGroup+"/"+url.PathEscape(args[0])+action, req)))
+	return nil
+}
@@ -8,27 +8,37 @@ package ping
 import (
 	"github.com/spf13/cobra"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/functions/cmd"
 	"github.com/UnifyEM/UnifyEM/cli/login"
 	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
 )
 
 func Register() *cobra.Command {
-	return &cobra.Command{
-		Use:   "ping",
-		Short: "ping the server",
-		Long:  "ping the server, which also requires login",
-		RunE: func(cmd *cobra.Command, args []string) error {
+	c := &cobra.Command{
+		Use:   "ping [agent_id]",
+		Short: "ping the server or an agent",
+		Long: "ping the server, which also requires login. If an agent ID or name is specified, ping the agent\n" +
+			"and wait for its response, displaying the time the request was queued, the time the agent took to\n" +
+			"respond, and the round trip time.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				timeout, _ := c.Flags().GetInt("timeout")
+				return cmd.Wait(commands.Ping, []string{"agent_id=" + args[0]}, timeout)
+			}
 			return execute()
 		},
 	}
+	c.Flags().IntP("timeout", "t", 300, "timeout in seconds when waiting for an agent (default: 300)")
+	return c
 }
 
 func execute() error {
 
 	// Create communications object
-	c := communications.New(login.Login())
+	c := login.Connect()
 
 	// Post the command to the server and display the result
 	display.ErrorWrapper(display.GenericResp(c.Get(schema.EndpointPing)))
@@ -14,10 +14,10 @@ import (
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
 	"github.com/UnifyEM/UnifyEM/cli/global"
 	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
 	"github.com/UnifyEM/UnifyEM/cli/util"
 	"github.com/UnifyEM/UnifyEM/common/crypto"
 	"github.com/UnifyEM/UnifyEM/common/schema"
@@ -56,6 +56,15 @@ func Register() *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "fde-key <agent_id>",
+		Short: "get agent disk encryption recovery key",
+		Long:  "retrieve the BitLocker or FileVault recovery key escrowed by the specified agent (super admins only)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return recoveryFDEKey(args, util.NewNVPairs(args))
+		},
+	})
+
 	cmd.AddCommand(&cobra.Command{
 		Use:   "check <agent_id>",
 		Short: "check if recovery info exists for agent",
@@ -113,15 +122,19 @@ func recoveryKeygen(args []string, _ *util.NVPairs) error {
 	fmt.Printf("Private key saved to: %s\n", outputPath)
 
 	// Upload public key to server
-	c := communications.New(login.Login())
+	c := login.Connect()
 	keyReq := schema.RecoveryKeyRequest{PublicKey: publicKey}
 	display.ErrorWrapper(display.GenericResp(c.Post(schema.EndpointRecovery+"/key", keyReq)))
 	return nil
 }
 
-func fetchRecoveryInfo(agentID string) (schema.APIRecoveryResponse, int, error) {
-	c := communications.New(login.Login())
-	statusCode, data, err := c.Get(schema.EndpointAgent + "/" + agentID + "/recovery")
+func fetchRecoveryInfo(arg string) (schema.APIRecoveryResponse, int, error) {
+	c := login.Connect()
+	path, err := resolver.Path(c, arg)
+	if err != nil {
+		return schema.APIRecoveryResponse{}, 0, err
+	}
+	statusCode, data, err := c.Get(path + "/recovery")
 	if err != nil {
 		return schema.APIRecoveryResponse{}, statusCode, fmt.Errorf("failed to retrieve recovery info: %w", err)
 	}
@@ -190,6 +203,20 @@ func recoveryGet(args []string, _ *util.NVPairs) error {
 	return nil
 }
 
+func recoveryFDEKey(args []string, _ *util.NVPairs) error {
+	if len(args) == 0 {
+		return errors.New("agent ID is required")
+	}
+
+	c := login.Connect()
+	path, err := resolver.Path(c, args[0])
+	if err != nil {
+		return err
+	}
+	display.ErrorWrapper(display.GenericResp(c.Get(path + "/recovery-key")))
+	return nil
+}
+
 func recoveryCheck(args []string, _ *util.NVPairs) error {
 	if len(args) == 0 {
 		return errors.New("agent ID is required")
@@ -214,7 +241,7 @@ func recoveryCheck(args []string, _ *util.NVPairs) error {
 }
 
 func recoveryList(_ []string, _ *util.NVPairs) error {
-	c := communications.New(login.Login())
+	c := login.Connect()
 	statusCode, data, err := c.Get(schema.EndpointAgent)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve agent list: %w", err)
@@ -8,7 +8,6 @@ package regToken
 import (
 	"fmt"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
 	"github.com/UnifyEM/UnifyEM/cli/login"
 	"github.com/UnifyEM/UnifyEM/common/schema"
@@ -47,17 +46,52 @@ func Register() *cobra.Command {
 		},
 	})
 
+	migration := &cobra.Command{
+		Use:   "migration [new]",
+		Short: "migration token functions",
+		Long: "view the current migration token or generate a new one. Agents moving from another server with\n" +
+			"set_server_url that register with it keep their agent ID.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return getMigrationToken()
+			}
+			return fmt.Errorf("Unknown subcommand: %s\n", args[0])
+		},
+	}
+
+	migration.AddCommand(&cobra.Command{
+		Use:   "new",
+		Short: "generate new migration token",
+		Long:  "generate a new migration token, valid for migration_token_days",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return newMigrationToken()
+		},
+	})
+
+	cmd.AddCommand(migration)
 	return cmd
 }
 
 func getRegToken() error {
-	c := communications.New(login.Login())
+	c := login.Connect()
 	display.ErrorWrapper(display.AnyResp(c.Get(schema.EndpointRegToken)))
 	return nil
 }
 
 func newRegToken() error {
-	c := communications.New(login.Login())
+	c := login.Connect()
 	display.ErrorWrapper(display.AnyResp(c.Post(schema.EndpointRegToken, nil)))
 	return nil
 }
+
+func getMigrationToken() error {
+	c := login.Connect()
+	display.ErrorWrapper(display.AnyResp(c.Get(schema.EndpointMigrationToken)))
+	return nil
+}
+
+func newMigrationToken() error {
+	c := login.Connect()
+	display.ErrorWrapper(display.AnyResp(c.Post(schema.EndpointMigrationToken, nil)))
+	return nil
+}
@@ -6,11 +6,13 @@
 package report
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/spf13/cobra"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
 	"github.com/UnifyEM/UnifyEM/cli/login"
 	"github.com/UnifyEM/UnifyEM/cli/util"
@@ -18,10 +20,11 @@ import (
 )
 
 func Register() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "report <report name>",
 		Short: "request report",
-		Long:  "request the specified report",
+		Long: "request the specified report: agents, antivirus, compliance, offline, or patches. Add format=json for JSON output.\n" +
+			"Saved reports are managed with the save, saved, delete-saved, and run-saved subcommands",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return fmt.Errorf("A report name is required\n")
@@ -30,12 +33,54 @@ func Register() *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "saved [name]",
+		Short: "list saved reports",
+		Long:  "list all saved reports, or get the specified saved report",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return savedGet(args)
+		},
+	})
+
+	saveCmd := &cobra.Command{
+		Use:   "save <name> report=<report name> [format=csv|html] [schedule=\"<cron>\"] [email=<address>,...] [<report arg>=<value> ...]",
+		Short: "save report",
+		Long: "save a report definition. The schedule is a cron expression such as \"0 8 * * mon\" or one of @hourly, @daily, @weekly, or @monthly,\n" +
+			"in the server's time zone. Without a schedule, the report only runs with run-saved. Other arguments are passed to the report",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			replace, _ := cmd.Flags().GetBool("replace")
+			return savedSave(args, replace)
+		},
+	}
+	saveCmd.Flags().BoolP("replace", "r", false, "replace an existing saved report")
+	cmd.AddCommand(saveCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "delete-saved <name>",
+		Short: "delete saved report",
+		Long:  "delete the saved report. Copies in the reports directory are not deleted",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return savedDelete(args)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "run-saved <name>",
+		Short: "run saved report",
+		Long:  "run the saved report now, writing a copy to the reports directory and sending it to its email recipients",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return savedRun(args)
+		},
+	})
+
+	return cmd
 }
 
 func execute(args []string, pairs *util.NVPairs) {
 
 	// Create communications object
-	c := communications.New(login.Login())
+	c := login.Connect()
 
 	// Initialize a new common command object
 	cmd := schema.NewReportRequest()
@@ -45,3 +90,76 @@ func execute(args []string, pairs *util.NVPairs) {
 	// Post the command to the server and display the result
 	display.ErrorWrapper(display.ReportResp(c.Post(schema.EndpointReport, cmd)))
 }
+
+func savedGet(args []string) error {
+	c := login.Connect()
+	if len(args) == 0 {
+		display.ErrorWrapper(display.AnyResp(c.Get(schema.EndpointSavedReport)))
+		return nil
+	}
+	display.ErrorWrapper(display.AnyResp(c.Get(schema.EndpointSavedReport + "/" + url.PathEscape(args[0]))))
+	return nil
+}
+
+// savedSave creates or replaces a saved report. The report, format, schedule, and email
+// arguments define the saved report and all others are passed to the report.
+func savedSave(args []string, replace bool) error {
+	if len(args) < 2 {
+		return errors.New("saved report name and report=<report name> are required")
+	}
+
+	pairs := util.NewNVPairs(args[1:]).ToMap()
+	req := schema.SavedReportRequest{
+		Name:       args[0],
+		Report:     pairs["report"],
+		Format:     pairs["format"],
+		Schedule:   pairs["schedule"],
+		Parameters: make(map[string]string),
+	}
+	if req.Report == "" {
+		return errors.New("report=<report name> is required")
+	}
+	if email := pairs["email"]; email != "" {
+		for _, address := range strings.Split(email, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				req.Email = append(req.Email, address)
+			}
+		}
+	}
+
+	for k, v := range pairs {
+		switch k {
+		case "report", "format", "schedule", "email":
+		default:
+			req.Parameters[k] = v
+		}
+	}
+
+	c := login.Connect()
+	if replace {
+		display.ErrorWrapper(display.AnyResp(c.Put(schema.EndpointSavedReport+"/"+url.PathEscape(args[0]), req)))
+		return nil
+	}
+	display.ErrorWrapper(display.AnyResp(c.Post(schema.EndpointSavedReport, req)))
+	return nil
+}
+
+func savedDelete(args []string) error {
+	if len(args) == 0 {
+		return errors.New("saved report name is required")
+	}
+
+	c := login.Connect()
+	display.ErrorWrapper(display.GenericResp(c.Delete(schema.EndpointSavedReport + "/" + url.PathEscape(args[0]))))
+	return nil
+}
+
+func savedRun(args []string) error {
+	if len(args) == 0 {
+		return errors.New("saved report name is required")
+	}
+
+	c := login.Connect()
+	display.ErrorWrapper(display.AnyResp(c.Post(schema.EndpointSavedReport+"/"+url.PathEscape(args[0])+"/run", nil)))
+	return nil
+}
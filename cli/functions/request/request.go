@@ -8,10 +8,11 @@ package request
 import (
 	"errors"
 	"fmt"
+	"net/url"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
 	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
 	"github.com/UnifyEM/UnifyEM/cli/util"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 
@@ -23,7 +24,7 @@ func Register() *cobra.Command {
 		Use:     "request",
 		Aliases: []string{"requests"},
 		Short:   "request functions",
-		Long:    "query, delete, and cancel agent requests",
+		Long:    "query, delete, cancel, requeue, approve, and deny agent requests",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return fmt.Errorf("a subcommand is required")
@@ -32,15 +33,25 @@ func Register() *cobra.Command {
 		},
 	}
 
-	cmd.AddCommand(&cobra.Command{
+	listCmd := &cobra.Command{
 		Use:   "list [agent_id]",
 		Short: "list requests",
 		Long:  "list all requests, or all requests for a specified agent",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return requestList(args, util.NewNVPairs(args))
+			status, _ := cmd.Flags().GetString("status")
+			if awaiting, _ := cmd.Flags().GetBool("awaiting-approval"); awaiting {
+				if status != "" && status != schema.RequestStatusAwaitingApproval {
+					return errors.New("--awaiting-approval cannot be used with a different --status")
+				}
+				status = schema.RequestStatusAwaitingApproval
+			}
+			return requestList(args, status)
 		},
-	})
+	}
+	listCmd.Flags().StringP("status", "s", "", "only list requests with this status, for example expired")
+	listCmd.Flags().Bool("awaiting-approval", false, "only list requests awaiting approval by a second administrator")
+	cmd.AddCommand(listCmd)
 
 	cmd.AddCommand(&cobra.Command{
 		Use:   "get <request_id>",
@@ -69,6 +80,33 @@ func Register() *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "requeue <request_id>",
+		Short: "requeue request",
+		Long:  "requeue the specified expired request so that it is sent to the agent again",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return requestRequeue(args, util.NewNVPairs(args))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "approve <request_id>",
+		Short: "approve request",
+		Long:  "approve the specified request so that it is sent to the agent. Requests must be approved by a different administrator than the one who created them.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return requestReview(args, "approve")
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "deny <request_id>",
+		Short: "deny request",
+		Long:  "deny the specified request so that it is never sent to the agent. Requests must be denied by a different administrator than the one who created them.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return requestReview(args, "deny")
+		},
+	})
+
 	cmd.AddCommand(&cobra.Command{
 		Use:   "cancel-agent <agent_id>",
 		Short: "cancel all requests for agent",
@@ -81,13 +119,22 @@ func Register() *cobra.Command {
 	return cmd
 }
 
-func requestList(args []string, _ *util.NVPairs) error {
-	c := communications.New(login.Login())
+func requestList(args []string, status string) error {
+	c := login.Connect()
+
+	endpoint := schema.EndpointRequest
 	if len(args) > 0 {
-		display.ErrorWrapper(display.RequestList(c.Get(schema.EndpointAgent + "/" + args[0] + "/requests")))
-	} else {
-		display.ErrorWrapper(display.RequestList(c.Get(schema.EndpointRequest)))
+		path, err := resolver.Path(c, args[0])
+		if err != nil {
+			return err
+		}
+		endpoint = path + "/requests"
+	}
+	if status != "" {
+		endpoint += "?status=" + url.QueryEscape(status)
 	}
+
+	display.ErrorWrapper(display.RequestList(c.Get(endpoint)))
 	return nil
 }
 
@@ -96,7 +143,7 @@ func requestGet(args []string, _ *util.NVPairs) error {
 		return errors.New("request ID is required")
 	}
 
-	c := communications.New(login.Login())
+	c := login.Connect()
 	display.ErrorWrapper(display.RequestList(c.Get(schema.EndpointRequest + "/" + args[0])))
 	return nil
 }
@@ -106,7 +153,7 @@ func requestDelete(args []string, _ *util.NVPairs) error {
 		return errors.New("request ID is required")
 	}
 
-	c := communications.New(login.Login())
+	c := login.Connect()
 	display.ErrorWrapper(display.GenericResp(c.Delete(schema.EndpointRequest + "/" + args[0])))
 	return nil
 }
@@ -116,17 +163,42 @@ func requestCancel(args []string, _ *util.NVPairs) error {
 		return errors.New("request ID is required")
 	}
 
-	c := communications.New(login.Login())
+	c := login.Connect()
 	display.ErrorWrapper(display.GenericResp(c.Post(schema.EndpointRequest+"/"+args[0]+"/cancel", nil)))
 	return nil
 }
 
+func requestRequeue(args []string, _ *util.NVPairs) error {
+	if len(args) == 0 {
+		return errors.New("request ID is required")
+	}
+
+	c := login.Connect()
+	display.ErrorWrapper(display.GenericResp(c.Post(schema.EndpointRequest+"/"+args[0]+"/requeue", nil)))
+	return nil
+}
+
+// requestReview approves or denies a request awaiting approval
+func requestReview(args []string, action string) error {
+	if len(args) == 0 {
+		return errors.New("request ID is required")
+	}
+
+	c := login.Connect()
+	display.ErrorWrapper(display.GenericResp(c.Post(schema.EndpointRequest+"/"+args[0]+"/"+action, nil)))
+	return nil
+}
+
 func requestCancelAgent(args []string, _ *util.NVPairs) error {
 	if len(args) == 0 {
 		return errors.New("agent ID is required")
 	}
 
-	c := communications.New(login.Login())
-	display.ErrorWrapper(display.GenericResp(c.Post(schema.EndpointAgent+"/"+args[0]+"/cancel-requests", nil)))
+	c := login.Connect()
+	path, err := resolver.Path(c, args[0])
+	if err != nil {
+		return err
+	}
+	display.ErrorWrapper(display.GenericResp(c.Post(path+"/cancel-requests", nil)))
 	return nil
 }
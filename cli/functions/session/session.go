@@ -0,0 +1,38 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package session
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/UnifyEM/UnifyEM/cli/login"
+)
+
+// Register returns the login and logout commands
+func Register() []*cobra.Command {
+	return []*cobra.Command{
+		{
+			Use:   "login",
+			Short: "log in to the server",
+			Long: "log in to the server of the selected context and store the access and refresh tokens so that\n" +
+				"later commands do not need credentials. The user and password are read from the context or\n" +
+				"UEM_USER and UEM_PASSWORD if set, and otherwise prompted for.",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				login.Login()
+				return nil
+			},
+		},
+		{
+			Use:   "logout",
+			Short: "log out of the server",
+			Long:  "revoke the stored refresh token for the server of the selected context and delete the stored tokens",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				login.Logout()
+				return nil
+			},
+		},
+	}
+}
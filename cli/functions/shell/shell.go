@@ -0,0 +1,165 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/global"
+	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/cli/resolver"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+const (
+	escapeKey    = 0x1d // Ctrl-], ends the session
+	pollInterval = 250 * time.Millisecond
+	maxFailures  = 20 // consecutive relay failures before giving up
+)
+
+func Register() *cobra.Command {
+	return &cobra.Command{
+		Use:   "shell <agent_id>",
+		Short: "open a remote shell",
+		Long: "open an interactive shell on the specified agent. The agent starts the shell the next time it syncs with\n" +
+			"the server, so it may take some time to connect. Press Ctrl-] to end the session. Remote shells must be\n" +
+			"enabled with the shell_enabled agent setting, and all input and output is recorded as shell events.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("agent ID is required\n")
+			}
+			return execute(args[0])
+		},
+	}
+}
+
+func execute(arg string) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("a terminal is required for a remote shell")
+	}
+
+	c := login.Connect()
+
+	agentID, err := resolver.Agent(c, arg)
+	if err != nil {
+		return err
+	}
+
+	// Open the session
+	statusCode, data, err := c.Post(schema.EndpointShell+"/"+url.PathEscape(agentID), nil)
+	if err != nil || statusCode != http.StatusOK {
+		display.ErrorWrapper(display.GenericResp(statusCode, data, err))
+		return nil
+	}
+
+	var resp schema.APIShellResponse
+	if err = json.Unmarshal(data, &resp); err != nil || resp.SessionID == "" {
+		return errors.New("unable to obtain session ID from server response")
+	}
+
+	fmt.Printf("Session %s opened, waiting for the agent to connect. Press Ctrl-] to end the session.\n", resp.SessionID)
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("unable to set terminal to raw mode: %w", err)
+	}
+
+	reason, err := relay(c, resp.SessionID)
+	_ = term.Restore(fd, oldState)
+
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nSession closed: %s\n", reason)
+	return nil
+}
+
+// relay sends keyboard input to the session and writes its output to the terminal until the
+// session closes or the escape key is pressed
+func relay(c global.Comms, sessionID string) (string, error) {
+	endpoint := schema.EndpointShell + "/session/" + url.PathEscape(sessionID)
+
+	// Read the keyboard in the background
+	input := make(chan []byte, 16)
+	go func() {
+		for {
+			buf := make([]byte, 1024)
+			n, err := os.Stdin.Read(buf)
+			if n > 0 {
+				input <- buf[:n]
+			}
+			if err != nil {
+				close(input)
+				return
+			}
+		}
+	}()
+
+	connected := false
+	failures := 0
+	var pending []byte
+
+	for {
+		// Wait for input or the poll interval
+		select {
+		case data, ok := <-input:
+			if ok {
+				pending = append(pending, data...)
+			} else {
+				input = nil
+			}
+		case <-time.After(pollInterval):
+		}
+
+		// End the session if the escape key was pressed, without sending it
+		if bytes.IndexByte(pending, escapeKey) >= 0 {
+			_, _, _ = c.Delete(endpoint)
+			return "ended by user", nil
+		}
+
+		cols, rows, _ := term.GetSize(int(os.Stdout.Fd()))
+		statusCode, data, err := c.Post(endpoint, schema.ShellAdminIO{Input: pending, Rows: rows, Cols: cols})
+		if err != nil || statusCode != http.StatusOK {
+			failures++
+			if failures >= maxFailures || statusCode == http.StatusNotFound || statusCode == http.StatusForbidden {
+				return "", fmt.Errorf("shell relay failed: HTTP %d", statusCode)
+			}
+			continue
+		}
+		failures = 0
+		pending = nil
+
+		var resp schema.APIShellResponse
+		if err = json.Unmarshal(data, &resp); err != nil {
+			return "", fmt.Errorf("error unmarshalling response: %w", err)
+		}
+
+		if resp.Connected && !connected {
+			connected = true
+			_, _ = os.Stdout.WriteString("Connected.\r\n")
+		}
+
+		if len(resp.Output) > 0 {
+			_, _ = os.Stdout.Write(resp.Output)
+		}
+
+		if resp.Closed {
+			return resp.Reason, nil
+		}
+	}
+}
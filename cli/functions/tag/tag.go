@@ -0,0 +1,70 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package tag
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+
+	"github.com/UnifyEM/UnifyEM/cli/display"
+	"github.com/UnifyEM/UnifyEM/cli/login"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func Register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tag",
+		Aliases: []string{"tags"},
+		Short:   "tag functions",
+		Long:    "list and delete tags across all agents",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("a subcommand is required")
+			}
+			return fmt.Errorf("unknown subcommand: %s", args[0])
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list tags",
+		Long:  "list all tags in use with the number of agents for each",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tagList()
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "delete <tag>",
+		Short: "delete tag",
+		Long:  "remove the tag from every agent",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tagDelete(args)
+		},
+	})
+
+	return cmd
+}
+
+func tagList() error {
+	c := login.Connect()
+	display.ErrorWrapper(display.AnyResp(c.Get(schema.EndpointTags)))
+	return nil
+}
+
+func tagDelete(args []string) error {
+	if len(args) == 0 {
+		return errors.New("tag is required")
+	}
+
+	c := login.Connect()
+	display.ErrorWrapper(display.AnyResp(c.Delete(schema.EndpointTags + "/" + url.PathEscape(args[0]))))
+	return nil
+}
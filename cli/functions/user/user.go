@@ -10,7 +10,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/UnifyEM/UnifyEM/cli/communications"
 	"github.com/UnifyEM/UnifyEM/cli/display"
 	"github.com/UnifyEM/UnifyEM/cli/login"
 	"github.com/UnifyEM/UnifyEM/common/schema"
@@ -22,12 +21,13 @@ func Register() *cobra.Command {
 		Use:     "user",
 		Aliases: []string{"users"},
 		Short:   "Manage users",
-		Long:    "User management commands: list, add, delete",
+		Long:    "User management commands: list, add, delete, revoke-sessions",
 	}
 
 	userCmd.AddCommand(listCmd())
 	userCmd.AddCommand(addCmd())
 	userCmd.AddCommand(deleteCmd())
+	userCmd.AddCommand(revokeSessionsCmd())
 
 	return userCmd
 }
@@ -44,26 +44,29 @@ func listCmd() *cobra.Command {
 
 // userList calls GET /api/v1/user and displays the result.
 func userList() error {
-	c := communications.New(login.Login())
+	c := login.Connect()
 	status, body, err := c.Get(schema.EndpointUser)
 	return display.UserResp(status, body, err)
 }
 
 // addCmd returns the 'user add' command.
 func addCmd() *cobra.Command {
-	var user, displayName, email string
+	var user, displayName, email, role, password string
 
 	cmd := &cobra.Command{
 		Use:   "add",
 		Short: "Add a new user",
+		Long:  "Add a new user. Specify --password and --role to also create a login account for the user.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return userAdd(user, displayName, email)
+			return userAdd(user, displayName, email, role, password)
 		},
 	}
 
 	cmd.Flags().StringVarP(&user, "user", "u", "", "User (required)")
 	cmd.Flags().StringVarP(&displayName, "display-name", "d", "", "Display name (optional)")
 	cmd.Flags().StringVarP(&email, "email", "e", "", "Email (required)")
+	cmd.Flags().StringVarP(&role, "role", "r", "readonly", "Login role: readonly or admin (optional)")
+	cmd.Flags().StringVarP(&password, "password", "p", "", "Login password (optional)")
 	_ = cmd.MarkFlagRequired("user")
 	_ = cmd.MarkFlagRequired("email")
 
@@ -71,7 +74,7 @@ func addCmd() *cobra.Command {
 }
 
 // userAdd calls POST /api/v1/user to add a new user.
-func userAdd(user, displayName, email string) error {
+func userAdd(user, displayName, email, role, password string) error {
 	if user == "" || email == "" {
 		return errors.New("user and email are required")
 	}
@@ -80,7 +83,11 @@ func userAdd(user, displayName, email string) error {
 		DisplayName: displayName,
 		Email:       email,
 	}
-	c := communications.New(login.Login())
+	if password != "" {
+		req.Role = role
+		req.Password = password
+	}
+	c := login.Connect()
 	display.ErrorWrapper(display.GenericResp(c.Post(schema.EndpointUser, req)))
 	return nil
 }
@@ -102,7 +109,29 @@ func userDelete(userID string) error {
 	if userID == "" {
 		return errors.New("user ID is required")
 	}
-	c := communications.New(login.Login())
+	c := login.Connect()
 	display.ErrorWrapper(display.GenericResp(c.Delete(schema.EndpointUser + "/" + userID)))
 	return nil
 }
+
+// revokeSessionsCmd returns the 'user revoke-sessions' command.
+func revokeSessionsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke-sessions <user_id>",
+		Short: "Revoke all tokens issued to a user, forcing them to log in again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return userRevokeSessions(args[0])
+		},
+	}
+}
+
+// userRevokeSessions calls POST /api/v1/user/{id}/revoke-sessions.
+func userRevokeSessions(userID string) error {
+	if userID == "" {
+		return errors.New("user ID is required")
+	}
+	c := login.Connect()
+	display.ErrorWrapper(display.GenericResp(c.Post(schema.EndpointUser+"/"+userID+"/revoke-sessions", nil)))
+	return nil
+}
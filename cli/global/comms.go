@@ -5,7 +5,11 @@
 
 package global
 
-import "github.com/UnifyEM/UnifyEM/cli/util"
+import (
+	"io"
+
+	"github.com/UnifyEM/UnifyEM/cli/util"
+)
 
 type Comms interface {
 	SetToken(token string)
@@ -14,4 +18,6 @@ type Comms interface {
 	Get(endpoint string) (int, []byte, error)
 	GetQuery(endpoint string, pairs *util.NVPairs) (int, []byte, error)
 	Delete(endpoint string) (int, []byte, error)
+	Download(endpoint string, dst io.Writer) (int, []byte, error)
+	Upload(endpoint, name string, src io.ReadSeeker) (int, []byte, error)
 }
@@ -17,4 +17,5 @@ const (
 	Copyright       = "Copyright (c) 2024-2026 Tenebris Technologies Inc."
 )
 
-var ServerURL string
+// Context is the name of the context selected with --context, overriding the current context
+var Context string
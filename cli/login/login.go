@@ -6,81 +6,176 @@
 package login
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/joho/godotenv"
+	"golang.org/x/term"
 
 	"github.com/UnifyEM/UnifyEM/cli/communications"
+	"github.com/UnifyEM/UnifyEM/cli/contexts"
 	"github.com/UnifyEM/UnifyEM/cli/credentials"
 	"github.com/UnifyEM/UnifyEM/cli/global"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
-// Login does its own error handling to avoid a lot of duplication
-func Login() string {
+// expiryMargin is how long before it expires a stored access token is refreshed
+const expiryMargin = 30 * time.Second
 
-	// If we already have an access token, return it
+// server is the server to use and the credentials for it, if known
+type server struct {
+	context string
+	url     string
+	user    string
+	pass    string
+}
+
+// Connect returns a communications object for the server of the selected context, using
+// the stored tokens if possible and logging in if necessary. It does its own error
+// handling to avoid a lot of duplication.
+func Connect() global.Comms {
+	s := resolve()
+
+	// If we already have an access token that has not expired, use it
 	accessToken := credentials.GetAccessToken()
-	if accessToken != "" {
-		return accessToken
+	if accessToken != "" && !tokenExpired(accessToken) {
+		return communications.New(s.url, accessToken)
 	}
 
 	// If we have a refresh token, try to refresh the access token
 	refreshToken := credentials.GetRefreshToken()
 	if refreshToken != "" {
-		token := RefreshToken(refreshToken)
+		token := RefreshToken(s.url, refreshToken)
 		if token != "" {
 			credentials.SetAccessToken(token)
-			return token
+			return communications.New(s.url, token)
 		}
 		// Refresh failed, so we need to log in again
 		credentials.RefreshExpired()
 	}
 
+	return communications.New(s.url, s.login())
+}
+
+// Login logs in to the server of the selected context and stores the tokens. Credentials
+// that are not set in the context or the environment are prompted for.
+func Login() {
+	s := resolve()
+	s.login()
+	fmt.Printf("Logged in to %s as %s\n", s.url, credentials.User())
+}
+
+// Logout revokes the stored refresh token for the server of the selected context and
+// deletes the stored tokens
+func Logout() {
+	s := resolve()
+
+	refreshToken := credentials.GetRefreshToken()
+	if refreshToken != "" {
+		c := communications.New(s.url)
+		code, _, err := c.Post(schema.EndpointLogout, schema.RefreshRequest{RefreshToken: refreshToken})
+		if err != nil {
+			fmt.Printf("Unable to revoke the refresh token: %s\n", err.Error())
+		} else if code != 200 {
+			// The token has already expired or been revoked
+			fmt.Printf("The server did not revoke the refresh token (HTTP status %d)\n", code)
+		}
+	}
+
+	err := credentials.Delete(s.context)
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Logged out of %s\n", s.url)
+}
+
+// resolve returns the server to use and selects its stored tokens. The server and
+// credentials are taken from the context selected with --context or the current context,
+// with the environment used for any that the context does not specify. Without a
+// context, only the environment is used.
+func resolve() server {
+
 	// Get the user's home directory
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		fatal(err)
 	}
 
-	// Construct the full path to the .openuem file
-	envPath := filepath.Join(homeDir, ".uem")
-
-	// Load environment variables from .openuem file if it exists
-	_ = godotenv.Load(envPath)
+	// Load environment variables from the .uem file if it exists
+	_ = godotenv.Load(filepath.Join(homeDir, ".uem"))
 
 	// Read from environment variables
-	user := os.Getenv("UEM_USER")
-	pass := os.Getenv("UEM_PASS")
-	global.ServerURL = os.Getenv("UEM_SERVER")
+	s := server{
+		url:  os.Getenv("UEM_SERVER"),
+		user: os.Getenv("UEM_USER"),
+		pass: os.Getenv("UEM_PASSWORD"),
+	}
+	if s.pass == "" {
+		s.pass = os.Getenv("UEM_PASS")
+	}
+
+	cfg, err := contexts.Load()
+	if err != nil {
+		fatal(err)
+	}
 
-	if user == "" {
-		fatal(errors.New("UEM_USER is not set"))
+	name, ctx, err := cfg.Selected(global.Context)
+	if err != nil {
+		fatal(err)
 	}
 
-	if pass == "" {
-		fatal(errors.New("UEM_PASS is not set"))
+	if ctx != nil {
+		s.context = name
+		s.url = ctx.URL
+		if ctx.User != "" {
+			s.user = ctx.User
+		}
+		if ctx.Password != "" {
+			s.pass = ctx.Password
+		}
 	}
 
-	if global.ServerURL == "" {
-		fatal(errors.New("UEM_SERVER is not set"))
+	if s.url == "" {
+		fatal(errors.New("UEM_SERVER is not set and no context is selected"))
+	}
+
+	credentials.Use(s.context, s.url, s.user)
+	return s
+}
+
+// login obtains and stores a new access and refresh token, prompting for any credentials
+// that are not known, and returns the access token
+func (s server) login() string {
+	if s.user == "" || s.pass == "" {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			fatal(errors.New("not logged in: run 'uem-cli login' or set UEM_USER and UEM_PASSWORD"))
+		}
+		s.prompt()
 	}
 
 	// Create a login request
-	req := schema.NewLoginRequest(user, pass)
+	req := schema.NewLoginRequest(s.user, s.pass)
 
 	// Post the login request to the server
-	c := communications.New()
+	c := communications.New(s.url)
 	code, data, err := c.Post(schema.EndpointLogin, req)
 	if err != nil {
 		fatal(err)
 	}
 
 	if code != 200 {
+		// Surface the server's explanation, such as an account lockout, if there is one
+		var errResp schema.APIAnyResponse
+		if json.Unmarshal(data, &errResp) == nil && errResp.Details != "" {
+			fatal(fmt.Errorf("login failed with HTTP status %d: %s", code, errResp.Details))
+		}
 		fatal(fmt.Errorf("login failed with HTTP status %d", code))
 	}
 
@@ -96,23 +191,66 @@ func Login() string {
 	}
 
 	// Save the tokens
-	credentials.SetAccessToken(loginResp.AccessToken)
-	credentials.SetRefreshToken(loginResp.RefreshToken)
+	credentials.SetTokens(s.user, loginResp.AccessToken, loginResp.RefreshToken)
 	return loginResp.AccessToken
 }
 
+// prompt reads the user and password that are not already known from the terminal
+func (s *server) prompt() {
+	fmt.Printf("Logging in to %s\n", s.url)
+
+	if s.user == "" {
+		fmt.Print("User: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			fatal(fmt.Errorf("failed to read user: %w", err))
+		}
+		s.user = strings.TrimSpace(line)
+	}
+
+	if s.pass == "" {
+		fmt.Print("Password: ")
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			fatal(fmt.Errorf("failed to read password: %w", err))
+		}
+		s.pass = string(b)
+	}
+
+	if s.user == "" || s.pass == "" {
+		fatal(errors.New("user and password are required"))
+	}
+}
+
+// tokenExpired returns true if a stored access token has expired or is about to. The
+// signature is not verified because only the server can do that.
+func tokenExpired(token string) bool {
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	if err != nil {
+		return true
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return false
+	}
+	return time.Until(exp.Time) < expiryMargin
+}
+
 func fatal(err error) {
 	fmt.Printf("Error: %s\n\n", err.Error())
 	os.Exit(1)
 }
 
-func RefreshToken(rToken string) string {
+func RefreshToken(serverURL, rToken string) string {
 
 	// Send a refresh request to the server
 	req := schema.RefreshRequest{RefreshToken: rToken}
 
 	// Post the refresh request to the server
-	c := communications.New()
+	c := communications.New(serverURL)
 	code, data, err := c.Post(schema.EndpointRefresh, req)
 	if err != nil {
 		fmt.Printf("Token refresh failed: %s\n", err.Error())
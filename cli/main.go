@@ -15,14 +15,20 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/UnifyEM/UnifyEM/cli/functions/agent"
+	"github.com/UnifyEM/UnifyEM/cli/functions/audit"
+	"github.com/UnifyEM/UnifyEM/cli/functions/backup"
 	"github.com/UnifyEM/UnifyEM/cli/functions/cmd"
 	"github.com/UnifyEM/UnifyEM/cli/functions/events"
 	"github.com/UnifyEM/UnifyEM/cli/functions/files"
+	"github.com/UnifyEM/UnifyEM/cli/functions/group"
 	"github.com/UnifyEM/UnifyEM/cli/functions/ping"
 	"github.com/UnifyEM/UnifyEM/cli/functions/recovery"
 	"github.com/UnifyEM/UnifyEM/cli/functions/regToken"
 	"github.com/UnifyEM/UnifyEM/cli/functions/report"
 	"github.com/UnifyEM/UnifyEM/cli/functions/request"
+	"github.com/UnifyEM/UnifyEM/cli/functions/session"
+	"github.com/UnifyEM/UnifyEM/cli/functions/shell"
+	"github.com/UnifyEM/UnifyEM/cli/functions/tag"
 	"github.com/UnifyEM/UnifyEM/cli/functions/user"
 	"github.com/UnifyEM/UnifyEM/cli/functions/version"
 	"github.com/UnifyEM/UnifyEM/cli/global"
@@ -49,16 +55,25 @@ func main() {
 	// Disable completion command
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
+	// Select a server context for this invocation
+	rootCmd.PersistentFlags().StringVar(&global.Context, "context", "", "use the named server context instead of the current context")
+
 	// Add the functions
 	rootCmd.AddCommand(agent.Register())
+	rootCmd.AddCommand(audit.Register())
+	rootCmd.AddCommand(backup.Register())
 	rootCmd.AddCommand(cmd.Register())
 	rootCmd.AddCommand(configCmd.Register())
 	rootCmd.AddCommand(events.Register())
 	rootCmd.AddCommand(files.Register())
+	rootCmd.AddCommand(group.Register())
 	rootCmd.AddCommand(ping.Register())
 	rootCmd.AddCommand(recovery.Register())
 	rootCmd.AddCommand(report.Register())
 	rootCmd.AddCommand(request.Register())
+	rootCmd.AddCommand(session.Register()...)
+	rootCmd.AddCommand(shell.Register())
+	rootCmd.AddCommand(tag.Register())
 	rootCmd.AddCommand(version.Register())
 	rootCmd.AddCommand(regToken.Register())
 	rootCmd.AddCommand(user.Register())
@@ -0,0 +1,115 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+// Package resolver allows commands that take an agent ID to accept a hostname, friendly
+// name, serial number, or IP address instead. Values that are not agent IDs are looked up
+// with the server's agent search.
+package resolver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/cli/global"
+	"github.com/UnifyEM/UnifyEM/cli/util"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Search returns the HTTP status and the agents that match the term
+func Search(c global.Comms, term string) (int, []schema.AgentSearchResult, error) {
+	statusCode, data, err := c.Get(schema.EndpointAgent + "/search?q=" + url.QueryEscape(term))
+	if err != nil {
+		return statusCode, nil, fmt.Errorf("failed to search agents: %w", err)
+	}
+
+	var resp schema.APIAgentSearchResponse
+	if err = json.Unmarshal(data, &resp); err != nil {
+		return statusCode, nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if resp.Code != 200 {
+		return statusCode, nil, fmt.Errorf("search failed with HTTP status %d: %s", statusCode, resp.Details)
+	}
+	return statusCode, resp.Data, nil
+}
+
+// Agent returns the agent ID for an argument that is either an agent ID or identifies a
+// single agent by hostname, friendly name, serial number, or IP address. If several agents
+// match, one whose hostname, friendly name, or serial number is exactly the argument is used.
+// If the match is still ambiguous, the error lists the candidates.
+func Agent(c global.Comms, arg string) (string, error) {
+	if arg == "" {
+		return "", errors.New("agent ID is required")
+	}
+	if schema.IsAgentID(arg) {
+		return arg, nil
+	}
+
+	_, results, err := Search(c, arg)
+	if err != nil {
+		return "", err
+	}
+
+	if len(results) > 1 {
+		var exact []schema.AgentSearchResult
+		for _, r := range results {
+			if strings.EqualFold(Hostname(r.Agent), arg) || strings.EqualFold(r.Agent.FriendlyName, arg) ||
+				(r.Agent.Status != nil && strings.EqualFold(r.Agent.Status.Details.SerialNumber, arg)) {
+				exact = append(exact, r)
+			}
+		}
+		if len(exact) > 0 {
+			results = exact
+		}
+	}
+
+	switch len(results) {
+	case 0:
+		return "", fmt.Errorf("no agent found matching %q", arg)
+	case 1:
+		return results[0].Agent.AgentID, nil
+	}
+
+	msg := fmt.Sprintf("%d agents match %q, use the agent ID:", len(results), arg)
+	for _, r := range results {
+		msg += fmt.Sprintf("\n  %s  %s  %s", r.Agent.AgentID, Hostname(r.Agent), r.Agent.FriendlyName)
+	}
+	return "", errors.New(msg)
+}
+
+// Path returns the agent endpoint for an agent ID or a term that identifies one agent
+func Path(c global.Comms, arg string) (string, error) {
+	agentID, err := Agent(c, arg)
+	if err != nil {
+		return "", err
+	}
+	return schema.EndpointAgent + "/" + url.PathEscape(agentID), nil
+}
+
+// Pairs replaces the value of an agent_id pair with the agent ID it identifies. Pairs
+// without an agent_id are left unchanged.
+func Pairs(c global.Comms, pairs *util.NVPairs) error {
+	arg, ok := pairs.Pairs["agent_id"]
+	if !ok {
+		return nil
+	}
+
+	agentID, err := Agent(c, arg)
+	if err != nil {
+		return err
+	}
+	pairs.Pairs["agent_id"] = agentID
+	return nil
+}
+
+// Hostname returns the hostname last reported by the agent
+func Hostname(agent schema.AgentMeta) string {
+	if agent.Status == nil {
+		return ""
+	}
+	return agent.Status.Details.Hostname
+}
@@ -0,0 +1,95 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package resolver
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/cli/util"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// searchComms answers agent searches with a fixed set of agents
+type searchComms struct {
+	agents   []schema.AgentMeta
+	searches int
+}
+
+func (s *searchComms) Get(endpoint string) (int, []byte, error) {
+	s.searches++
+	resp := schema.APIAgentSearchResponse{Status: schema.APIStatusOK, Code: 200}
+	for _, agent := range s.agents {
+		resp.Data = append(resp.Data, schema.AgentSearchResult{Matched: []string{schema.AgentSearchHostname}, Agent: agent})
+	}
+	data, _ := json.Marshal(resp)
+	return 200, data, nil
+}
+
+func (s *searchComms) SetToken(string)                                 {}
+func (s *searchComms) Post(string, interface{}) (int, []byte, error)   { return 0, nil, nil }
+func (s *searchComms) Put(string, interface{}) (int, []byte, error)    { return 0, nil, nil }
+func (s *searchComms) Delete(string) (int, []byte, error)              { return 0, nil, nil }
+func (s *searchComms) Download(string, io.Writer) (int, []byte, error) { return 0, nil, nil }
+func (s *searchComms) Upload(string, string, io.ReadSeeker) (int, []byte, error) {
+	return 0, nil, nil
+}
+func (s *searchComms) GetQuery(string, *util.NVPairs) (int, []byte, error) {
+	return 0, nil, nil
+}
+
+func withHostname(agentID, hostname string) schema.AgentMeta {
+	return schema.AgentMeta{AgentID: agentID, Status: &schema.AgentStatus{Details: schema.StatusDetails{Hostname: hostname}}}
+}
+
+func TestAgent(t *testing.T) {
+	const id = "A-0f8fad5b-d9cb-469f-a165-70867728950e"
+
+	// Agent IDs are used without searching
+	c := &searchComms{}
+	if agentID, err := Agent(c, id); err != nil || agentID != id || c.searches != 0 {
+		t.Errorf("expected %s without a search, got %q (%v) after %d searches", id, agentID, err, c.searches)
+	}
+
+	// A single match is used
+	c = &searchComms{agents: []schema.AgentMeta{withHostname("A-1", "LAPTOP-7GQ2")}}
+	if agentID, err := Agent(c, "laptop-7gq2"); err != nil || agentID != "A-1" {
+		t.Errorf("expected A-1, got %q (%v)", agentID, err)
+	}
+
+	// An exact match is preferred over partial matches
+	c = &searchComms{agents: []schema.AgentMeta{withHostname("A-1", "lab-01"), withHostname("A-2", "lab-010")}}
+	if agentID, err := Agent(c, "LAB-01"); err != nil || agentID != "A-1" {
+		t.Errorf("expected A-1, got %q (%v)", agentID, err)
+	}
+
+	// Ambiguous and missing agents are errors
+	if _, err := Agent(c, "lab"); err == nil || !strings.Contains(err.Error(), "2 agents match") {
+		t.Errorf("expected an ambiguous match error, got %v", err)
+	}
+	c = &searchComms{}
+	if _, err := Agent(c, "nothing"); err == nil {
+		t.Errorf("expected an error when no agent matches")
+	}
+}
+
+func TestPairs(t *testing.T) {
+	c := &searchComms{agents: []schema.AgentMeta{withHostname("A-1", "LAPTOP-7GQ2")}}
+
+	pairs := util.NewNVPairs([]string{"agent_id=laptop-7gq2", "path=/tmp/x"})
+	if err := Pairs(c, pairs); err != nil || pairs.Pairs["agent_id"] != "A-1" || pairs.Pairs["path"] != "/tmp/x" {
+		t.Errorf("expected agent_id A-1, got %v (%v)", pairs.Pairs, err)
+	}
+
+	// Without an agent_id nothing is searched
+	c.searches = 0
+	pairs = util.NewNVPairs([]string{"tag=lab"})
+	if err := Pairs(c, pairs); err != nil || c.searches != 0 {
+		t.Errorf("expected no search, got %d (%v)", c.searches, err)
+	}
+}
@@ -64,3 +64,8 @@ func (h *Hasher) Compare(s string) bool {
 	}
 	return h.Base64() == s
 }
+
+// ClearCache discards cached hashes, for example when a file has been replaced
+func (h *Hasher) ClearCache() {
+	h.cache.Clear()
+}
@@ -7,18 +7,54 @@ package hasher
 
 import (
 	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
 	"io"
 	"os"
 )
 
+// Supported hash algorithms
+const (
+	SHA256 = "sha256"
+	SHA512 = "sha512"
+)
+
 func (h *Hasher) SHA256File(f string) *Hasher {
+	return h.hashFile(f, "", sha256.New)
+}
+
+func (h *Hasher) SHA512File(f string) *Hasher {
+	return h.hashFile(f, SHA512+":", sha512.New)
+}
+
+// File hashes a file using the named algorithm. An empty name selects SHA256.
+// An unsupported algorithm returns an empty Hasher.
+func (h *Hasher) File(alg, f string) *Hasher {
+	switch alg {
+	case "", SHA256:
+		return h.SHA256File(f)
+	case SHA512:
+		return h.SHA512File(f)
+	default:
+		return &Hasher{}
+	}
+}
+
+// ValidAlg returns true if the algorithm is supported. An empty name selects SHA256.
+func ValidAlg(alg string) bool {
+	return alg == "" || alg == SHA256 || alg == SHA512
+}
+
+// hashFile hashes a file, using the cache if enabled. The prefix keeps cache entries for
+// different algorithms apart.
+func (h *Hasher) hashFile(f, prefix string, newHash func() hash.Hash) *Hasher {
 	if f == "" {
 		return &Hasher{}
 	}
 
 	// If ttl is set, the cache is in use
 	if h.useCache {
-		b := h.cache.Get(f)
+		b := h.cache.Get(prefix + f)
 		if b != nil {
 			// cache hit
 			return &Hasher{bytes: b}
@@ -34,7 +70,7 @@ func (h *Hasher) SHA256File(f string) *Hasher {
 		_ = file.Close()
 	}(file)
 
-	hasher := sha256.New()
+	hasher := newHash()
 	if _, err = io.Copy(hasher, file); err != nil {
 		return &Hasher{}
 	}
@@ -42,7 +78,7 @@ func (h *Hasher) SHA256File(f string) *Hasher {
 
 	// If ttl is set, the cache is in use
 	if h.useCache {
-		h.cache.Set(f, sum)
+		h.cache.Set(prefix+f, sum)
 	}
 
 	return &Hasher{bytes: sum}
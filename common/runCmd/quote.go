@@ -0,0 +1,81 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package runCmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Commands are run with argument arrays wherever possible, so that request parameters never
+// reach a shell. A shell is only required where the command line is sent as text, such as a
+// command sent over SSH or typed into a login session. In those cases every argument must be
+// quoted with ShellJoin, or with PowerShellQuote when building a PowerShell script.
+
+// ErrUnsafeArgument is returned for arguments that can not be passed through a shell or terminal
+var ErrUnsafeArgument = errors.New("argument contains a control character")
+
+// CheckArg rejects NUL, which truncates arguments, and other control characters such as
+// newlines, which a terminal treats as input even when they are quoted. Tabs are permitted.
+func CheckArg(arg string) error {
+	for _, c := range arg {
+		if (c < 0x20 && c != '\t') || c == 0x7f {
+			return fmt.Errorf("%w: %q", ErrUnsafeArgument, c)
+		}
+	}
+	return nil
+}
+
+// ShellQuote quotes a string for a POSIX shell. Arguments containing only safe characters are
+// returned unchanged, and all others are single-quoted, which preserves every character except
+// the single quote itself. A single quote closes the quoted string, is escaped with a
+// backslash, and the string is reopened.
+func ShellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+
+	safe := true
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_-./:@%+,", c)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ShellJoin quotes each argument with ShellQuote and joins them into a command line
+func ShellJoin(cmdAndArgs []string) (string, error) {
+	quoted := make([]string, len(cmdAndArgs))
+	for i, arg := range cmdAndArgs {
+		if err := CheckArg(arg); err != nil {
+			return "", err
+		}
+		quoted[i] = ShellQuote(arg)
+	}
+	return strings.Join(quoted, " "), nil
+}
+
+// PowerShellQuote returns s as a PowerShell single-quoted string, in which nothing is expanded.
+// PowerShell also accepts the typographic single quotes as quotes, so all four are doubled.
+func PowerShellQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, c := range s {
+		switch c {
+		case '\'', '‘', '’', '‚', '‛':
+			b.WriteRune(c)
+		}
+		b.WriteRune(c)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
@@ -0,0 +1,84 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package runCmd
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+// hostileArgs are used to seed the fuzz tests
+var hostileArgs = []string{
+	"", "plain", "two words", "it's", `"double"`, "$(id)", "`id`", "${HOME}", "a;id", "a&&id", "a|id",
+	"a>/tmp/x", "*", "~root", "-rf", "FOO=bar", `back\slash`, "'; id; '", "tab\there", "’typographic’",
+}
+
+func TestShellJoin(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"sysadminctl", "-deleteUser", "jo-smith"}, "sysadminctl -deleteUser jo-smith"},
+		{[]string{"echo", "it's $HOME"}, `echo 'it'\''s $HOME'`},
+		{[]string{"FOO=bar", ""}, "'FOO=bar' ''"},
+	}
+	for _, tt := range tests {
+		got, err := ShellJoin(tt.args)
+		if err != nil || got != tt.want {
+			t.Errorf("%q: expected %s, got %s (%v)", tt.args, tt.want, got, err)
+		}
+	}
+
+	for _, arg := range []string{"a\nid", "a\rid", "a\x00b", "\x1b[2J"} {
+		if _, err := ShellJoin([]string{"echo", arg}); !errors.Is(err, ErrUnsafeArgument) {
+			t.Errorf("%q: expected ErrUnsafeArgument, got %v", arg, err)
+		}
+	}
+}
+
+func TestPowerShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"":            "''",
+		"P@ss$word":   "'P@ss$word'",
+		"it's":        "'it''s'",
+		"a’; calc; ’": "'a’’; calc; ’’'",
+	}
+	for s, want := range tests {
+		if got := PowerShellQuote(s); got != want {
+			t.Errorf("%q: expected %s, got %s", s, want, got)
+		}
+	}
+}
+
+// FuzzShellJoin checks that a POSIX shell receives exactly the arguments that were quoted
+func FuzzShellJoin(f *testing.F) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		f.Skip("requires a POSIX shell")
+	}
+	for _, arg := range hostileArgs {
+		f.Add(arg)
+	}
+
+	f.Fuzz(func(t *testing.T, arg string) {
+		args := []string{"printf", "[%s]", arg, "last"}
+		line, err := ShellJoin(args)
+		if err != nil {
+			if CheckArg(arg) == nil {
+				t.Fatalf("%q: unexpected error: %v", arg, err)
+			}
+			return
+		}
+
+		out, err := exec.Command("sh", "-c", line).Output()
+		if err != nil {
+			t.Fatalf("%q: %s failed: %v", arg, line, err)
+		}
+		if want := "[" + arg + "][last]"; string(out) != want {
+			t.Errorf("%q: shell received %q", arg, out)
+		}
+	})
+}
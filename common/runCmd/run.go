@@ -7,9 +7,13 @@ package runCmd
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"time"
 
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 )
@@ -21,9 +25,18 @@ const (
 	RunStderr
 )
 
+// truncatedMarker is appended to output that exceeds the maximum captured size
+const truncatedMarker = "\n[output truncated after %d bytes]"
+
+// waitDelay is how long to wait for a cancelled command's output to be closed, in case it
+// was inherited by a process that did not exit
+const waitDelay = 5 * time.Second
+
 // Runner provides command execution with optional logging
 type Runner struct {
-	logger interfaces.Logger
+	logger    interfaces.Logger
+	env       map[string]string
+	maxOutput int
 }
 
 // Option configures a Runner
@@ -45,36 +58,71 @@ func WithLogger(logger interfaces.Logger) Option {
 	}
 }
 
+// WithMaxOutput limits the output captured from each stream of a command to maxBytes. Output
+// beyond the limit is discarded and a marker is appended. Zero, the default, means no limit.
+func WithMaxOutput(maxBytes int) Option {
+	return func(r *Runner) {
+		r.maxOutput = maxBytes
+	}
+}
+
+// Env returns a copy of the Runner that adds the variables to the environment of the commands
+// it runs. This is used instead of os.Setenv so that the process's own environment is not
+// changed for other goroutines.
+func (r *Runner) Env(env map[string]string) *Runner {
+	c := *r
+	c.env = make(map[string]string, len(r.env)+len(env))
+	for k, v := range r.env {
+		c.env[k] = v
+	}
+	for k, v := range env {
+		c.env[k] = v
+	}
+	return &c
+}
+
 // Combined runs a command given as a slice of strings and return a combined stdout and stderr string
 func (r *Runner) Combined(cmdAndArgs ...string) (string, error) {
-	return r.run(RunCombined, cmdAndArgs...)
+	return r.run(context.Background(), RunCombined, cmdAndArgs...)
 }
 
 // Separate runs a command given as a slice of strings and returns stdout and stderr separately in the same string
 //
 //goland:noinspection GoUnusedExportedFunction
 func (r *Runner) Separate(cmdAndArgs ...string) (string, error) {
-	return r.run(RunSeparate, cmdAndArgs...)
+	return r.run(context.Background(), RunSeparate, cmdAndArgs...)
 }
 
 // Stdout runs a command given as a slice of strings and return stdout only
 func (r *Runner) Stdout(cmdAndArgs ...string) (string, error) {
-	return r.run(RunStdout, cmdAndArgs...)
+	return r.run(context.Background(), RunStdout, cmdAndArgs...)
 }
 
 // Stderr runs a command given as a slice of strings and return stderr only
 //
 //goland:noinspection GoUnusedExportedFunction
 func (r *Runner) Stderr(cmdAndArgs ...string) (string, error) {
-	return r.run(RunStderr, cmdAndArgs...)
+	return r.run(context.Background(), RunStderr, cmdAndArgs...)
+}
+
+// CombinedCtx is like Combined, but the command and any processes it started are killed if
+// the context is cancelled or its deadline expires
+func (r *Runner) CombinedCtx(ctx context.Context, cmdAndArgs ...string) (string, error) {
+	return r.run(ctx, RunCombined, cmdAndArgs...)
+}
+
+// StdoutCtx is like Stdout, but the command and any processes it started are killed if the
+// context is cancelled or its deadline expires
+func (r *Runner) StdoutCtx(ctx context.Context, cmdAndArgs ...string) (string, error) {
+	return r.run(ctx, RunStdout, cmdAndArgs...)
 }
 
 // run a command given as a slice of strings and return output based on runType
-func (r *Runner) run(runType int, cmdAndArgs ...string) (string, error) {
+func (r *Runner) run(ctx context.Context, runType int, cmdAndArgs ...string) (string, error) {
 	var err error
-	var out []byte
 	var outStr string
-	var stdout, stderr bytes.Buffer
+	stdout := &limitedBuffer{max: r.maxOutput}
+	stderr := &limitedBuffer{max: r.maxOutput}
 
 	if len(cmdAndArgs) == 0 {
 		return "", fmt.Errorf("no command provided")
@@ -86,24 +134,39 @@ func (r *Runner) run(runType int, cmdAndArgs ...string) (string, error) {
 	}
 
 	// Set up the command
-	cmd := exec.Command(cmdAndArgs[0], cmdAndArgs[1:]...)
+	cmd := exec.CommandContext(ctx, cmdAndArgs[0], cmdAndArgs[1:]...)
+	if len(r.env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range r.env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+
+	// If the command can be cancelled, kill any processes it started as well
+	if ctx.Done() != nil {
+		killProcessGroup(cmd)
+		cmd.WaitDelay = waitDelay
+	}
 
 	// Run it using the correct variant
 	switch runType {
 	case RunCombined:
-		out, err = cmd.CombinedOutput()
-		outStr = string(out)
+		cmd.Stdout = stdout
+		cmd.Stderr = stdout
+		err = cmd.Run()
+		outStr = stdout.String()
 	case RunStdout:
-		out, err = cmd.Output()
-		outStr = string(out)
+		cmd.Stdout = stdout
+		err = cmd.Run()
+		outStr = stdout.String()
 	case RunStderr:
 		cmd.Stdout = io.Discard
-		cmd.Stderr = &stderr
+		cmd.Stderr = stderr
 		err = cmd.Run()
 		outStr = stderr.String()
 	case RunSeparate:
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
 		err = cmd.Run()
 		outStr = fmt.Sprintf("--- stdout ---\n%s\n\n--- stderr ---\n%s", stdout.String(), stderr.String())
 	}
@@ -125,6 +188,9 @@ func (r *Runner) run(runType int, cmdAndArgs ...string) (string, error) {
 
 	// Check for an error
 	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return outStr, fmt.Errorf("command %s timed out: %w", cmdAndArgs[0], ctx.Err())
+		}
 		return outStr, fmt.Errorf("command %s failed with exit code %d: %s: %w",
 			cmdAndArgs[0], exitCode, outStr, err)
 	}
@@ -137,3 +203,34 @@ func (r *Runner) run(runType int, cmdAndArgs ...string) (string, error) {
 
 	return outStr, nil
 }
+
+// limitedBuffer captures up to max bytes of output, or all of it if max is zero. Writes beyond
+// the limit are discarded but reported as successful so that the command is not interrupted.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.max <= 0 {
+		return b.buf.Write(p)
+	}
+
+	room := b.max - b.buf.Len()
+	if room >= len(p) {
+		return b.buf.Write(p)
+	}
+	if room > 0 {
+		b.buf.Write(p[:room])
+	}
+	b.truncated = true
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + fmt.Sprintf(truncatedMarker, b.max)
+	}
+	return b.buf.String()
+}
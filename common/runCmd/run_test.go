@@ -0,0 +1,89 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package runCmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func skipWindows(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+}
+
+func TestMaxOutput(t *testing.T) {
+	skipWindows(t)
+
+	out, err := New(WithMaxOutput(10)).Stdout("sh", "-c", "printf 0123456789abcdef")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "0123456789" + fmt.Sprintf(truncatedMarker, 10); out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+
+	out, err = New(WithMaxOutput(10)).Combined("sh", "-c", "printf 01234; printf 56789 >&2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "0123456789" {
+		t.Errorf("expected output at the limit to be complete, got %q", out)
+	}
+
+	out, err = New().Stdout("sh", "-c", "printf 0123456789abcdef")
+	if err != nil || out != "0123456789abcdef" {
+		t.Errorf("expected output without a limit to be complete, got %q, %v", out, err)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	skipWindows(t)
+
+	r := New()
+	withEnv := r.Env(map[string]string{"UEM_RUNCMD_TEST": "set"})
+
+	out, err := withEnv.Stdout("sh", "-c", "printf %s \"$UEM_RUNCMD_TEST\"")
+	if err != nil || out != "set" {
+		t.Errorf("expected the variable to be set for the command, got %q, %v", out, err)
+	}
+
+	if _, ok := os.LookupEnv("UEM_RUNCMD_TEST"); ok {
+		t.Error("the variable should not be set in the process environment")
+	}
+
+	out, _ = r.Stdout("sh", "-c", "printf %s \"$UEM_RUNCMD_TEST\"")
+	if out != "" {
+		t.Errorf("the original runner should not be changed, got %q", out)
+	}
+}
+
+func TestStdoutCtxKillsProcessGroup(t *testing.T) {
+	skipWindows(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// The background sleep keeps stdout open, so the command only returns early if it is killed
+	start := time.Now()
+	_, err := New().StdoutCtx(ctx, "sh", "-c", "sleep 30 & sleep 30")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("command took %s, expected it to be killed", elapsed)
+	}
+}
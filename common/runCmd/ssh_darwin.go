@@ -33,6 +33,12 @@ func (r *Runner) SSH(user *UserLogin, cmdAndArgs ...string) (string, error) {
 		return "", fmt.Errorf("no command specified")
 	}
 
+	// The command is run by the remote shell, so each argument is quoted
+	cmdString, err := ShellJoin(cmdAndArgs)
+	if err != nil {
+		return "", err
+	}
+
 	if r.logger != nil {
 		r.logger.Debugf(8300, "SSH command requested: %s (arguments redacted) (user: %s, runAsRoot: %v)", cmdAndArgs[0], user.Username, user.RunAsRoot)
 	}
@@ -73,9 +79,6 @@ func (r *Runner) SSH(user *UserLogin, cmdAndArgs ...string) (string, error) {
 		time.Sleep(500 * time.Millisecond)
 	}
 
-	// Build command string
-	cmdString := strings.Join(cmdAndArgs, " ")
-
 	// Execute via SSH
 	if r.logger != nil {
 		r.logger.Debugf(8306, "attempting SSH connection to %s as user %s", sshHost, user.Username)
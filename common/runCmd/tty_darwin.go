@@ -51,8 +51,11 @@ func (r *Runner) osTTY(def Interactive) (string, error) {
 		// Start with login command
 		cmd = exec.CommandContext(ctx, "login", def.AsUser.Username)
 
-		// Build the command string
-		cmdString := strings.Join(def.Command, " ")
+		// The command is typed into the login shell, so each argument is quoted
+		cmdString, err := ShellJoin(def.Command)
+		if err != nil {
+			return "", err
+		}
 
 		// Prepend login actions to the action list
 		var loginActions []Action
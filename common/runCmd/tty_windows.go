@@ -57,16 +57,17 @@ func runAsUserWindows(def Interactive) (string, error) {
 // runWithPowerShellCredentials uses PowerShell's Start-Process with credentials
 func runWithPowerShellCredentials(def Interactive) (string, error) {
 	// Create a PowerShell script that runs the command with credentials
+	// Every value is quoted, and the arguments are escaped as a Windows command line
 	psScript := fmt.Sprintf(`
-$secpasswd = ConvertTo-SecureString '%s' -AsPlainText -Force
-$creds = New-Object System.Management.Automation.PSCredential ('%s', $secpasswd)
+$secpasswd = ConvertTo-SecureString %s -AsPlainText -Force
+$creds = New-Object System.Management.Automation.PSCredential (%s, $secpasswd)
 $pinfo = New-Object System.Diagnostics.ProcessStartInfo
-$pinfo.FileName = '%s'
-$pinfo.Arguments = '%s'
+$pinfo.FileName = %s
+$pinfo.Arguments = %s
 $pinfo.RedirectStandardOutput = $true
 $pinfo.RedirectStandardError = $true
 $pinfo.UseShellExecute = $false
-$pinfo.UserName = '%s'
+$pinfo.UserName = %s
 $pinfo.Password = $secpasswd
 $pinfo.Domain = $env:COMPUTERNAME
 $p = New-Object System.Diagnostics.Process
@@ -78,10 +79,10 @@ $p.WaitForExit()
 Write-Output $stdout
 if ($stderr) { Write-Error $stderr }
 exit $p.ExitCode
-`, def.AsUser.Password, def.AsUser.Username,
-		def.Command[0],
-		strings.Join(def.Command[1:], " "),
-		def.AsUser.Username)
+`, PowerShellQuote(def.AsUser.Password), PowerShellQuote(def.AsUser.Username),
+		PowerShellQuote(def.Command[0]),
+		PowerShellQuote(commandLine(def.Command[1:])),
+		PowerShellQuote(def.AsUser.Username))
 
 	// Run the PowerShell script
 	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", psScript)
@@ -92,11 +93,9 @@ exit $p.ExitCode
 
 // runWithRunas uses the runas command for interactive scenarios
 func runWithRunas(def Interactive) (string, error) {
-	// Build command for runas
-	cmdStr := strings.Join(def.Command, " ")
-	runasCmd := exec.Command("cmd", "/C",
-		fmt.Sprintf(`echo %s | runas /user:%s "%s"`,
-			def.AsUser.Password, def.AsUser.Username, cmdStr))
+	// runas is started directly rather than through cmd, which would interpret the password
+	// and command, and the password is written to its input
+	runasCmd := exec.Command("runas", "/user:"+def.AsUser.Username, commandLine(def.Command))
 
 	// Set up pipes for stdin/stdout/stderr
 	stdin, err := runasCmd.StdinPipe()
@@ -113,6 +112,7 @@ func runWithRunas(def Interactive) (string, error) {
 	if err := runasCmd.Start(); err != nil {
 		return "", fmt.Errorf("failed to start runas: %w", err)
 	}
+	_, _ = stdin.Write([]byte(def.AsUser.Password + "\r\n"))
 
 	// Handle any additional interactions
 	for _, action := range def.Actions {
@@ -200,3 +200,12 @@ func runAsUserWindowsAPI(username, password, domain string, command []string) (s
 
 	return "", fmt.Errorf("direct API not implemented - use PowerShell method")
 }
+
+// commandLine escapes each argument as Windows programs expect and joins them
+func commandLine(args []string) string {
+	escaped := make([]string, len(args))
+	for i, arg := range args {
+		escaped[i] = syscall.EscapeArg(arg)
+	}
+	return strings.Join(escaped, " ")
+}
@@ -17,18 +17,31 @@ const (
 	ConfigAgentSyncPending      = "sync_pending"
 	ConfigAgentSyncRetry        = "sync_retry"
 	ConfigAgentSyncLost         = "sync_lost"
+	ConfigAgentSyncJitter       = "sync_jitter"
 	ConfigAgentStatusInterval   = "status_interval"
+	ConfigAgentStatusTimeout    = "status_timeout"
 	ConfigAgentLogRetention     = "log_retention"
 	ConfigAgentLogStdout        = "log_stdout"
 	ConfigAgentLogWindowsDisk   = "log_windows_disk"
 	ConfigAgentLogWindowsEvents = "log_windows_events"
 	ConfigAgentLogMacOSDisk     = "log_macos_disk"
 	ConfigAgentLogLinuxDisk     = "log_linux_disk"
+	ConfigAgentLogMaxSize       = "log_max_size_mb"
+	ConfigAgentLogMaxFiles      = "log_max_files"
 	ConfigAgentDebug            = "log_debug"
 	ConfigAgentPinCA            = "pin_ca"
 	ConfigAgentVerification     = "verification"
 	configAgentVerificationKey  = "verification_key"
 	ConfigAgentRecoveryInfo     = "recovery_info"
+	ConfigAgentFileFetchMax     = "file_fetch_max_mb"
+	ConfigAgentLostWiFi         = "lost_wifi"
+	ConfigAgentShell            = "shell_enabled"
+	ConfigAgentCompression      = "compression"
+	ConfigAgentPublisherKey     = "publisher_key"
+	ConfigAgentWipePaths        = "wipe_paths"
+	ConfigAgentLocalStatus      = "local_status"
+	ConfigAgentDisabledCommands = "disabled_commands"
+	ConfigAgentExecAllowedPaths = "exec_allowed_paths"
 )
 
 func SetAgentDefaults(c interfaces.Config) interfaces.Parameters {
@@ -37,17 +50,30 @@ func SetAgentDefaults(c interfaces.Config) interfaces.Parameters {
 	s.SetConstraint(ConfigAgentSyncPending, 5, 86400, 60)
 	s.SetConstraint(ConfigAgentSyncRetry, 5, 86400, 10)
 	s.SetConstraint(ConfigAgentSyncLost, 5, 86400, 60)
+	s.SetConstraint(ConfigAgentSyncJitter, 0, 50, 10) // percent that the sync interval is randomly varied so that agents don't sync together
 	s.SetConstraint(ConfigAgentStatusInterval, 5, 86400, 21600)
+	s.SetConstraint(ConfigAgentStatusTimeout, 5, 600, 60) // seconds before status collection stops waiting for slow items
 	s.SetConstraint(ConfigAgentLogRetention, 1, 365, 30)
 	s.SetConstraint(ConfigAgentLogStdout, 0, 0, true)
 	s.SetConstraint(ConfigAgentLogWindowsDisk, 0, 0, true)
 	s.SetConstraint(ConfigAgentLogWindowsEvents, 0, 0, true)
 	s.SetConstraint(ConfigAgentLogMacOSDisk, 0, 0, true)
 	s.SetConstraint(ConfigAgentLogLinuxDisk, 0, 0, true)
+	s.SetConstraint(ConfigAgentLogMaxSize, 0, 1024, 50) // MB before the log is rotated and compressed (0 to rotate daily only)
+	s.SetConstraint(ConfigAgentLogMaxFiles, 1, 100, 5)  // logs rotated by size to keep
 	s.SetConstraint(ConfigAgentDebug, 0, 0, false)
 	s.SetConstraint(ConfigAgentPinCA, 0, 0, false)
 	s.SetConstraint(ConfigAgentVerification, 0, 0, false)
 	s.SetConstraint(configAgentVerificationKey, 0, 0, "")
 	s.SetConstraint(ConfigAgentRecoveryInfo, 0, 0, false)
+	s.SetConstraint(ConfigAgentFileFetchMax, 1, 1024, 25)  // maximum file_fetch size in MB, enforced by agent and server
+	s.SetConstraint(ConfigAgentLostWiFi, 0, 0, false)      // report the Wi-Fi network while in lost mode
+	s.SetConstraint(ConfigAgentShell, 0, 0, false)         // allow remote shell sessions
+	s.SetConstraint(ConfigAgentCompression, 0, 0, true)    // compress large requests and accept compressed responses
+	s.SetConstraint(ConfigAgentPublisherKey, 0, 0, "")     // public key that verifies download_execute signatures
+	s.SetConstraint(ConfigAgentWipePaths, 0, 0, "")        // comma-separated paths deleted by a corporate wipe
+	s.SetConstraint(ConfigAgentLocalStatus, 0, 0, true)    // provide read-only status to local tools, see agent/localstatus
+	s.SetConstraint(ConfigAgentDisabledCommands, 0, 0, "") // comma-separated commands that the agent refuses
+	s.SetConstraint(ConfigAgentExecAllowedPaths, 0, 0, "") // comma-separated paths that execute and download_execute may run programs from
 	return s
 }
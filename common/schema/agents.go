@@ -5,7 +5,13 @@
 
 package schema
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type AgentMeta struct {
 	AgentID            string        `json:"agent_id"`
@@ -17,15 +23,68 @@ type AgentMeta struct {
 	Version            string        `json:"version"`
 	Build              int           `json:"build"`
 	Triggers           AgentTriggers `json:"triggers"`
+	WipePending        *WipePending  `json:"wipe_pending,omitempty"`
 	Status             *AgentStatus  `json:"status,omitempty"`
+	Patches            *PatchStatus  `json:"patches,omitempty"` // Most recent patch_status or patch_install result
 	Tags               []string      `json:"tags"`
 	Users              []string      `json:"users"`
+	Channel            string        `json:"channel,omitempty"`
+	UpgradePending     bool          `json:"upgrade_pending"`
+	UpgradeRequested   time.Time     `json:"upgrade_requested"` // Last automatic upgrade request
 	ClientPublicSig    string        `json:"client_public_sig,omitempty"`
 	ClientPublicEnc    string        `json:"client_public_enc,omitempty"`
 	ServiceCredentials string        `json:"service_credentials,omitempty"` // Encrypted "username:password" with agent's public key
 	RecoveryInfo       string        `json:"recovery_info,omitempty"`       // Encrypted recovery info blob
+	State              string        `json:"state,omitempty"`               // Lifecycle state, see AgentState constants
+	Offline            bool          `json:"offline"`                       // Not seen within the server's agent_offline_threshold
+	IPHistory          []AgentIP     `json:"ip_history,omitempty"`          // Most recent first, limited by the server's ip_history_size
+	Queue              *AgentQueue   `json:"queue,omitempty"`               // Undelivered requests, added when agents are retrieved
+}
+
+// AgentQueue summarizes the requests that have not yet been delivered to an agent. It is computed
+// from the agent's requests when the agent is retrieved and is not stored.
+type AgentQueue struct {
+	New     int       `json:"new"`             // Requests that have not been sent
+	Pending int       `json:"pending"`         // Requests that have been sent without a response
+	Oldest  time.Time `json:"oldest,omitzero"` // Creation time of the oldest new or pending request
+}
+
+// Count returns the number of new and pending requests
+func (q *AgentQueue) Count() int {
+	if q == nil {
+		return 0
+	}
+	return q.New + q.Pending
+}
+
+// AgentIP is a public IP address that an agent has synced from
+type AgentIP struct {
+	IP        string    `json:"ip"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Country   string    `json:"country,omitempty"` // ISO country code, if the server has a GeoIP database
+	City      string    `json:"city,omitempty"`
 }
 
+// AgentState values for AgentMeta.State. An empty state is a normal, installed agent.
+const (
+	AgentStateUninstalling = "uninstalling" // Uninstall trigger acknowledged by the agent
+	AgentStateUninstalled  = "uninstalled"  // Agent reported that it removed itself
+)
+
+// Events recorded by the server with EventType AgentEventConnectivity
+const (
+	AgentWentOffline    = "agent offline"
+	AgentBackOnline     = "agent back online"
+	AgentCountryChanged = "agent country changed"
+)
+
+// Messages sent by the agent with MessageType AgentEventUninstall
+const (
+	UninstallStarting = "uninstall starting"
+	UninstallComplete = "uninstall complete"
+)
+
 func NewAgentMeta(agentID string) AgentMeta {
 	now := time.Now()
 	return AgentMeta{
@@ -41,17 +100,157 @@ func NewAgentMeta(agentID string) AgentMeta {
 	}
 }
 
+// RecordIP adds an address that the agent synced from now to the front of its IP history,
+// keeping at most size distinct addresses, and returns the previous most recent address
+func (m *AgentMeta) RecordIP(ip AgentIP, size int) AgentIP {
+	var previous AgentIP
+	if len(m.IPHistory) > 0 {
+		previous = m.IPHistory[0]
+	}
+
+	now := time.Now()
+	entry := AgentIP{IP: ip.IP, FirstSeen: now, Country: ip.Country, City: ip.City}
+	for x, existing := range m.IPHistory {
+		if existing.IP == ip.IP {
+			entry.FirstSeen = existing.FirstSeen
+			if entry.Country == "" {
+				entry.Country, entry.City = existing.Country, existing.City
+			}
+			m.IPHistory = append(m.IPHistory[:x], m.IPHistory[x+1:]...)
+			break
+		}
+	}
+	entry.LastSeen = now
+
+	m.IPHistory = append([]AgentIP{entry}, m.IPHistory...)
+	if size < 1 {
+		size = 1
+	}
+	if len(m.IPHistory) > size {
+		m.IPHistory = m.IPHistory[:size]
+	}
+	return previous
+}
+
+// WipePending records a wipe that has been requested but not confirmed. The wipe
+// trigger is not sent to the agent until the confirmation code is provided.
+type WipePending struct {
+	Requester string    `json:"requester"`
+	Requested time.Time `json:"requested"`
+	Expires   time.Time `json:"expires"`
+	CodeHash  string    `json:"code_hash"` // Keyed hash of the confirmation code
+	Attempts  int       `json:"attempts"`  // Incorrect confirmation codes received
+	Mode      string    `json:"mode,omitempty"`
+}
+
 type AgentStatus struct {
-	LastUpdated time.Time         `json:"last_updated"`
-	Details     map[string]string `json:"details"`
-	Info        []string          `json:"info,omitempty"`
+	LastUpdated       time.Time          `json:"last_updated"`
+	Details           StatusDetails      `json:"details"`
+	Info              []string           `json:"info,omitempty"`
+	AntivirusProducts []AntivirusProduct `json:"antivirus_products,omitempty"`
 }
 
 // AgentStatusData is the structure sent by the agent for status updates.
 // This is converted to AgentStatus on the server side.
 type AgentStatusData struct {
-	Details map[string]string `json:"details"`
-	Info    []string          `json:"info,omitempty"`
+	Details           StatusDetails      `json:"details"`
+	Info              []string           `json:"info,omitempty"`
+	AntivirusProducts []AntivirusProduct `json:"antivirus_products,omitempty"`
+}
+
+// AntivirusProduct is an antivirus or EDR product detected by the agent. The "antivirus" key in
+// the status details remains "yes" if any product is detected. Enabled and Updated are "yes",
+// "no", or "unknown", because not every platform reports them.
+type AntivirusProduct struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Enabled string `json:"enabled"`
+	Updated string `json:"updated"`
+}
+
+// Hardware inventory keys in the status details. Disk and memory sizes are in bytes.
+// Values that could not be collected are reported as "unknown".
+const (
+	StatusDiskTotal    = "disk_total"
+	StatusDiskFree     = "disk_free"
+	StatusMemory       = "memory"
+	StatusCPUModel     = "cpu_model"
+	StatusSerialNumber = "serial_number"
+	StatusHardwareUUID = "hardware_uuid"
+	StatusChassisType  = "chassis_type"
+)
+
+// Network context keys in the status details, reported only while lost mode is active.
+// The Wi-Fi network is only reported if the lost_wifi agent setting is enabled.
+const (
+	StatusLost       = "lost"
+	StatusWiFiSSID   = "wifi_ssid"
+	StatusGatewayMAC = "gateway_mac"
+)
+
+// macOS security keys in the status details, reported as "yes", "no", or "unknown".
+// They are not reported by other platforms.
+const (
+	StatusMDMEnrolled     = "mdm_enrolled"     // enrolled in a mobile device management service
+	StatusActivationLock  = "activation_lock"  // Activation Lock is enabled
+	StatusSystemIntegrity = "system_integrity" // System Integrity Protection is enabled
+)
+
+// Linux security keys in the status details. They are not reported by other platforms.
+// StatusAccessControl is "selinux enforcing", "selinux permissive", "apparmor enforcing",
+// "apparmor complain", "disabled", or "unknown". The others are "yes", "no", or "unknown",
+// and StatusSSHPasswordAuth is "n/a" if the SSH server is not installed.
+const (
+	StatusAccessControl   = "access_control"    // SELinux or AppArmor enforcement mode
+	StatusSSHPasswordAuth = "ssh_password_auth" // the SSH server accepts password authentication
+	StatusUpdatesRecent   = "updates_recent"    // automatic updates ran within the last week
+	StatusRebootRequired  = "reboot_required"   // installed updates require a reboot
+)
+
+// StatusStartReason is reported only in the first status after the agent service starts. It is
+// "boot", "manual", "restart" (by the service manager after a failure), or "unknown", followed
+// by the service manager that started the agent, if any, such as "restart (systemd, 2 restarts)".
+const StatusStartReason = "start_reason"
+
+// Chassis types reported in StatusDetails.ChassisType
+const (
+	ChassisLaptop  = "laptop"
+	ChassisDesktop = "desktop"
+	ChassisServer  = "server"
+	ChassisVM      = "vm"
+	ChassisUnknown = "unknown"
+)
+
+// AgentUpload describes a file uploaded by an agent in response to a file_fetch request
+type AgentUpload struct {
+	AgentID   string `json:"agent_id"`
+	RequestID string `json:"request_id"`
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"` // base64 encoded
+}
+
+// APIAgentUploadResponse is returned to the agent after a successful upload
+type APIAgentUploadResponse struct {
+	Status  string      `json:"status" example:"ok"`
+	Code    int         `json:"code" example:"200"`
+	Details string      `json:"details,omitempty"`
+	Data    AgentUpload `json:"data"`
+}
+
+// WipeConfirmRequest Request for confirming a pending wipe
+type WipeConfirmRequest struct {
+	Code string `json:"code"`
+}
+
+// APIWipeResponse Response when a wipe is requested without force. The wipe must be
+// confirmed with the confirmation code before it expires.
+type APIWipeResponse struct {
+	Status           string    `json:"status" example:"ok"`
+	Code             int       `json:"code" example:"200"`
+	Details          string    `json:"details,omitempty"`
+	ConfirmationCode string    `json:"confirmation_code"`
+	Expires          time.Time `json:"expires"`
 }
 
 // AgentTagsRequest Request for adding/removing tags
@@ -78,6 +277,21 @@ type AgentTagsResponse struct {
 	Code   int      `json:"code"`
 }
 
+// TagsResponse Response listing all tags with the number of agents for each
+type TagsResponse struct {
+	Tags   []TagCount `json:"tags"`
+	Status string     `json:"status"`
+	Code   int        `json:"code"`
+}
+
+// TagDeleteResponse Response for deleting a tag from all agents
+type TagDeleteResponse struct {
+	Tag    string `json:"tag"`
+	Agents int    `json:"agents"`
+	Status string `json:"status"`
+	Code   int    `json:"code"`
+}
+
 // AgentsByTagResponse Response for agents by tag
 type AgentsByTagResponse struct {
 	Agents []AgentMeta `json:"agents"`
@@ -89,20 +303,80 @@ type AgentsByTagResponse struct {
 // If a new trigger is added, it should be added to the NewAgentTriggers function
 // to ensure a proper reset is possible
 type AgentTriggers struct {
-	Lost      bool `json:"lost" example:"false"`
-	Uninstall bool `json:"uninstall" example:"false"`
-	Wipe      bool `json:"wipe" example:"false"`
+	Lost      bool   `json:"lost" example:"false"`
+	Uninstall bool   `json:"uninstall" example:"false"`
+	Wipe      bool   `json:"wipe" example:"false"`
+	WipeMode  string `json:"wipe_mode,omitempty" example:"full"` // WipeModeFull if empty
 }
 
 // NewAgentTriggers creates a new AgentTriggers struct with all values set to false
 func NewAgentTriggers() AgentTriggers {
-	return AgentTriggers{Lost: false, Uninstall: false, Wipe: false}
+	return AgentTriggers{Lost: false, Uninstall: false, Wipe: false, WipeMode: ""}
+}
+
+// TriggersData returns the data the server signs when it sends triggers to an agent. Including
+// the agent ID and the server time ties the signature to the agent and the sync.
+func TriggersData(agentID string, serverTime int64, t AgentTriggers) []byte {
+	return []byte(fmt.Sprintf("uem-triggers\n%s\n%d\nlost=%t\nuninstall=%t\nwipe=%t\nwipe_mode=%s",
+		agentID, serverTime, t.Lost, t.Uninstall, t.Wipe, t.WipeMode))
+}
+
+// ServerMoveData returns the data the server signs when it sends set_server_url to an agent.
+// Including the agent ID and request ID ties the signature to the request.
+func ServerMoveData(agentID, requestID, serverURL, migrationToken string) []byte {
+	return []byte(fmt.Sprintf("uem-server-move\n%s\n%s\n%s\n%s", agentID, requestID, serverURL, migrationToken))
+}
+
+// Wipe modes. A full wipe destroys all data on the device, while a corporate wipe only
+// deletes the paths in the agent's wipe_paths setting.
+const (
+	WipeModeFull      = "full"
+	WipeModeCorporate = "corporate"
+)
+
+// ValidWipeMode returns true if mode is a wipe mode, or empty for the default
+func ValidWipeMode(mode string) bool {
+	return mode == "" || mode == WipeModeFull || mode == WipeModeCorporate
 }
 
 type AgentList struct {
 	Agents []AgentMeta `json:"agents"`
 }
 
+// IsAgentID returns true if s has the form of an agent ID, A-<uuid>
+func IsAgentID(s string) bool {
+	id, found := strings.CutPrefix(s, "A-")
+	if !found {
+		return false
+	}
+	_, err := uuid.Parse(id)
+	return err == nil && len(id) == 36
+}
+
+// Fields reported in AgentSearchResult.Matched
+const (
+	AgentSearchHostname     = "hostname"
+	AgentSearchFriendlyName = "friendly_name"
+	AgentSearchIP           = "ip"
+	AgentSearchSerialNumber = "serial_number"
+	AgentSearchAgentID      = "agent_id"
+	AgentSearchUser         = "user"
+)
+
+// AgentSearchResult is an agent that matched a search and the fields that contain the term
+type AgentSearchResult struct {
+	Matched []string  `json:"matched"`
+	Agent   AgentMeta `json:"agent"`
+}
+
+// APIAgentSearchResponse Response for an agent search
+type APIAgentSearchResponse struct {
+	Status  string              `json:"status" example:"ok"`
+	Code    int                 `json:"code" example:"200"`
+	Details string              `json:"details,omitempty"`
+	Data    []AgentSearchResult `json:"data"`
+}
+
 // DeviceUserList is used to return a list of pc/device users
 type DeviceUserList struct {
 	Users []DeviceUser
@@ -12,8 +12,12 @@ const (
 	EndpointRegister         = "/api/v1/register"
 	EndpointRefresh          = "/api/v1/refresh"
 	EndpointLogin            = "/api/v1/login"
+	EndpointLogout           = "/api/v1/logout"
 	EndpointCmd              = "/api/v1/cmd"
+	EndpointCmdBulk          = "/api/v1/cmd/bulk"
+	EndpointCmdSpec          = "/api/v1/cmd/spec"
 	EndpointReport           = "/api/v1/report"
+	EndpointSavedReport      = "/api/v1/report/saved"
 	EndpointAgent            = "/api/v1/agent"
 	EndpointUser             = "/api/v1/user"
 	EndpointConfigAgents     = "/api/v1/config/agent"
@@ -21,10 +25,24 @@ const (
 	EndpointReset            = "/api/v1/reset"
 	EndpointRequest          = "/api/v1/request"
 	EndpointRegToken         = "/api/v1/regtoken"
+	EndpointMigrationToken   = "/api/v1/regtoken/migration"
 	EndpointEvents           = "/api/v1/events"
 	EndpointCreateDeployFile = "/api/v1/deployfile"
 	EndpointFiles            = "/files"
 	EndpointRecovery         = "/api/v1/recovery"
+	EndpointAuditLogins      = "/api/v1/audit/logins"
+	EndpointAgentUpload      = "/api/v1/agent-upload"
+	EndpointChannel          = "/api/v1/channel"
+	EndpointNotifyTest       = "/api/v1/notify/test"
+	EndpointTags             = "/api/v1/tags"
+	EndpointGroup            = "/api/v1/group"
+	EndpointShell            = "/api/v1/shell"
+	EndpointAgentShell       = "/api/v1/agent-shell"
+	EndpointBackup           = "/api/v1/admin/backup"
+	EndpointExport           = "/api/v1/admin/export"
+	EndpointImport           = "/api/v1/admin/import"
+	EndpointPrune            = "/api/v1/admin/prune"
+	EndpointDashboardSummary = "/api/v1/dashboard/summary"
 	DeployInfoFile           = "deploy.json"
 )
 
@@ -37,11 +55,22 @@ const (
 
 //goland:noinspection ALL
 const (
-	AgentEventMessage = "message" // Notifications of events
-	AgentEventAlert   = "alert"   // Alerts and warnings
-	AgentEventStatus  = "status"
+	AgentEventMessage      = "message" // Notifications of events
+	AgentEventAlert        = "alert"   // Alerts and warnings
+	AgentEventStatus       = "status"
+	AgentEventLocation     = "location"     // Network context reported while lost mode is active
+	AgentEventShell        = "shell"        // Remote shell input and output
+	AgentEventUninstall    = "uninstall"    // Progress of an uninstall trigger
+	AgentEventWipe         = "wipe"         // Progress of a wipe trigger
+	AgentEventConnectivity = "connectivity" // Agent went offline, came back online, or changed country
+	AgentEventTamper       = "tamper"       // Protected agent settings changed outside the agent, or a server instruction failed verification
+	AgentEventApproval     = "approval"     // A request awaiting approval by a second administrator, approved, or denied
 )
 
+// ServerEventsID is used in place of an agent ID for events recorded by the server itself,
+// such as failures to deliver a scheduled report
+const ServerEventsID = "server"
+
 type AgentInfo struct {
 	Meta   AgentMeta   `json:"meta"`
 	Status AgentStatus `json:"status"`
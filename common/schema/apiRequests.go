@@ -15,6 +15,7 @@ type AgentRegisterRequest struct {
 	ClientPublicSig string `json:"client_public_sig,omitempty"`
 	ClientPublicEnc string `json:"client_public_enc,omitempty"`
 	FriendlyName    string `json:"friendly_name,omitempty"`
+	AgentID         string `json:"agent_id,omitempty"` // ID kept by an agent moving from another server, requires a migration token
 }
 
 // LoginRequest is sent to the server by a user (administrator) to obtain a token
@@ -40,7 +41,7 @@ type AgentSyncRequest struct {
 	Version      string          `json:"version"`
 	Build        int             `json:"build"`
 	Messages     []AgentMessage  `json:"messages"`
-	Responses    []AgentResponse `json:"responses"`              // List of responses to previous requests
+	Responses    []AgentResponse `json:"responses"`               // List of responses to previous requests
 	RecoveryInfo string          `json:"recovery_info,omitempty"` // Encrypted recovery info blob
 }
 
@@ -59,10 +60,15 @@ type AgentResponse struct {
 	Cmd                string `json:"cmd"`
 	Response           string `json:"response"`
 	Success            bool   `json:"success"`
+	InProgress         bool   `json:"in_progress,omitempty"` // command continues, a final response will follow
+	Refused            bool   `json:"refused,omitempty"`     // command not run because agent policy does not allow it
 	Data               any    `json:"data,omitempty"`
 	ServiceCredentials string `json:"service_credentials,omitempty"` // Double-encrypted "username:password" for server
+	RecoveryKey        string `json:"recovery_key,omitempty"`        // FDERecoveryKey encrypted with the server's public key
+	EncryptedResponse  string `json:"encrypted_response,omitempty"`  // ResponseBody of a sensitive command encrypted with the server's public key
 	PreShutdown        bool   `json:"-"`                             // trigger sync before OS action
 	ShutdownType       string `json:"-"`                             // "shutdown" or "reboot"
+	Sent               bool   `json:"-"`                             // already sent to the server, not queued
 }
 
 // NewAgentResponse creates a new AgentResponse and initialized the map to avoid errors
@@ -83,6 +89,15 @@ func NewCmdRequest() CmdRequest {
 	}
 }
 
+// CmdBulkRequest is a command to the server that will be queued for each of the listed
+// agents, or delivered to each agent with the tag when it syncs
+type CmdBulkRequest struct {
+	Cmd        string            `json:"cmd"`
+	AgentIDs   []string          `json:"agent_ids,omitempty"`
+	Tag        string            `json:"tag,omitempty"`
+	Parameters map[string]string `json:"args"`
+}
+
 // RecoveryKeyRequest is used to upload the recovery public key to the server
 type RecoveryKeyRequest struct {
 	PublicKey string `json:"public_key"`
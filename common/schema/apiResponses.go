@@ -39,9 +39,16 @@ type API400 struct {
 }
 
 type API401 struct {
+	Status     string `json:"status" example:"error"`
+	Code       int    `json:"code" example:"401"`
+	Details    string `json:"details" example:"authentication failed"`
+	ServerTime int64  `json:"server_time,omitempty" example:"1767225600"` // Server time (Unix seconds) for clock skew detection
+}
+
+type API403 struct {
 	Status  string `json:"status" example:"error"`
-	Code    int    `json:"code" example:"401"`
-	Details string `json:"details" example:"authentication failed"`
+	Code    int    `json:"code" example:"403"`
+	Details string `json:"details" example:"not authorized for this operation"`
 }
 
 type API404 struct {
@@ -76,13 +83,15 @@ type APITokenRefreshResponse struct {
 	AccessToken     string `json:"access_token,omitempty" example:"jwt"` // JWT access token
 	ServerPublicSig string `json:"server_public_sig,omitempty"`          // Server's EC public signature key
 	ServerPublicEnc string `json:"server_public_enc,omitempty"`          // Server's EC public encryption key
+	ServerTime      int64  `json:"server_time,omitempty"`                // Server time (Unix seconds) for clock skew detection
 }
 
 type APIAgentInfoResponse struct {
-	Status  string    `json:"status" example:"ok"`
-	Code    int       `json:"code" example:"200"`
-	Details string    `json:"details,omitempty" example:"agent info"`
-	Data    AgentList `json:"data"`
+	Status         string    `json:"status" example:"ok"`
+	Code           int       `json:"code" example:"200"`
+	Details        string    `json:"details,omitempty" example:"agent info"`
+	MinimumVersion string    `json:"minimum_version,omitempty" example:"0.0.60"`
+	Data           AgentList `json:"data"`
 }
 
 type APIEventsResponse struct {
@@ -90,6 +99,8 @@ type APIEventsResponse struct {
 	Code    int          `json:"code" example:"200"`
 	Details string       `json:"details,omitempty" example:"events"`
 	Data    []AgentEvent `json:"data"`
+	Cursor  string       `json:"cursor,omitempty"` // Pass as cursor to continue after the last event
+	More    bool         `json:"more"`             // More events are available with the cursor
 }
 
 type AgentEvent struct {
@@ -110,14 +121,19 @@ type APIConfigResponse struct {
 
 // APISyncResponse is sent to the agent by the server in response to an agent sync request
 type APISyncResponse struct {
-	Status              string            `json:"status"`
-	Code                int               `json:"code"`
-	Conf                map[string]string `json:"conf"`
-	Triggers            AgentTriggers     `json:"triggers"`
-	Details             string            `json:"details,omitempty"`
-	Requests            []AgentRequest    `json:"requests"`                        // Requests for the agent to process and respond to
-	ServiceCredentials  string            `json:"service_credentials,omitempty"`   // Encrypted "username:password" with agent's public key
-	RecoveryPublicKey   string            `json:"recovery_public_key,omitempty"`   // Recovery public key to distribute to agents
+	Status             string            `json:"status"`
+	Code               int               `json:"code"`
+	Conf               map[string]string `json:"conf"`
+	Triggers           AgentTriggers     `json:"triggers"`
+	TriggersSig        string            `json:"triggers_sig,omitempty"` // Server signature of TriggersData(agent ID, ServerTime, Triggers)
+	Details            string            `json:"details,omitempty"`
+	Requests           []AgentRequest    `json:"requests"`                      // Requests for the agent to process and respond to
+	ServiceCredentials string            `json:"service_credentials,omitempty"` // Encrypted "username:password" with agent's public key
+	RecoveryPublicKey  string            `json:"recovery_public_key,omitempty"` // Recovery public key to distribute to agents
+	ServerTime         int64             `json:"server_time,omitempty"`         // Server time (Unix seconds) for clock skew detection
+	TLSPinNext         string            `json:"tls_pin_next,omitempty"`        // Pin for the next server certificate
+	TLSPinNextSig      string            `json:"tls_pin_next_sig,omitempty"`    // Server signature of TLSPinData(current, next)
+	RetryAfter         int               `json:"retry_after,omitempty"`         // Seconds the agent should wait before syncing again, with code 429
 }
 
 // AgentRequest contains a single command (request) from the server to the agent
@@ -129,6 +145,7 @@ type AgentRequest struct {
 	RequestID   string            `json:"request_id"`
 	AgentID     string            `json:"agent_id"`
 	Parameters  map[string]string `json:"parameters"`
+	Received    time.Time         `json:"-"` // set by the agent when the request is queued
 }
 
 // NewAgentRequest creates a new AgentRequest and initializes the map to avoid errors
@@ -176,10 +193,38 @@ type APIRecoveryResponse struct {
 
 // APICmdResponse is used by the API to respond to a command request
 type APICmdResponse struct {
-	Status            string `json:"status" example:"ok"`
-	Code              int    `json:"code" example:"200"`
-	Details           string `json:"details,omitempty" example:"request queued for agent"`
-	RequestID         string `json:"request_id,omitempty" example:"R-6f9dcb2e-2e1b-4c3a-8a67-5b3e0d740df6"`
-	AgentID           string `json:"agent_id,omitempty" example:"A-12345678-abcd-1234-5648-1234567890ab"`
-	AgentFriendlyName string `json:"agent_friendly_name,omitempty" example:"Tuxedo001 Linux Laptop"`
+	Status            string            `json:"status" example:"ok"`
+	Code              int               `json:"code" example:"200"`
+	Details           string            `json:"details,omitempty" example:"request queued for agent"`
+	RequestID         string            `json:"request_id,omitempty" example:"R-6f9dcb2e-2e1b-4c3a-8a67-5b3e0d740df6"`
+	AgentID           string            `json:"agent_id,omitempty" example:"A-12345678-abcd-1234-5648-1234567890ab"`
+	AgentFriendlyName string            `json:"agent_friendly_name,omitempty" example:"Tuxedo001 Linux Laptop"`
+	Requests          map[string]string `json:"requests,omitempty"` // Agent ID to request ID when sent to a group
+}
+
+// APICmdBulkResponse is used by the API to respond to a bulk command request
+type APICmdBulkResponse struct {
+	Status   string            `json:"status" example:"ok"`
+	Code     int               `json:"code" example:"200"`
+	Details  string            `json:"details,omitempty" example:"request queued for 2 of 3 agents"`
+	Requests map[string]string `json:"requests,omitempty"` // Agent ID to request ID
+	Failed   map[string]string `json:"failed,omitempty"`   // Agent ID to the reason the request was not queued
+
+	RequestID string `json:"request_id,omitempty"` // Tag request, delivered to each agent with the tag
+}
+
+// APICmdSpecResponse is used by the API to describe the commands that may be sent to agents
+type APICmdSpecResponse struct {
+	Status   string    `json:"status" example:"ok"`
+	Code     int       `json:"code" example:"200"`
+	Details  string    `json:"details,omitempty"`
+	Commands []CmdSpec `json:"commands"`
+}
+
+// APINotifyTestResponse reports the result of sending a test event to each notification sink
+type APINotifyTestResponse struct {
+	Status  string            `json:"status" example:"ok"`
+	Code    int               `json:"code" example:"200"`
+	Details string            `json:"details,omitempty" example:"test event sent"`
+	Data    map[string]string `json:"data" example:"webhook:ok"`
 }
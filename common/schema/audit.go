@@ -0,0 +1,33 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import "time"
+
+//goland:noinspection ALL
+const (
+	LoginResultSuccess = "success" // Successful login
+	LoginResultFailure = "failure" // Failed login
+	LoginResultLockout = "lockout" // Account locked due to repeated failures
+	LoginResultLocked  = "locked"  // Login attempted while the account is locked
+)
+
+// LoginAuditEvent records a single administrator login attempt
+type LoginAuditEvent struct {
+	Time        time.Time `json:"time" example:"2023-01-01T00:00:00Z"`
+	Username    string    `json:"username" example:"admin"`
+	SourceIP    string    `json:"src_ip" example:"127.0.0.1"`
+	Result      string    `json:"result" example:"failure"`
+	Details     string    `json:"details,omitempty" example:"invalid password"`
+	LockedUntil time.Time `json:"locked_until,omitempty" example:"2023-01-01T00:15:00Z"`
+}
+
+type APILoginAuditResponse struct {
+	Status  string            `json:"status" example:"ok"`
+	Code    int               `json:"code" example:"200"`
+	Details string            `json:"details,omitempty" example:"login audit"`
+	Data    []LoginAuditEvent `json:"data"`
+}
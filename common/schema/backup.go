@@ -30,6 +30,7 @@ type AgentBackup struct {
 	ECPublicSig     string `json:"ec_public_sig,omitempty"`
 	ECPrivateEnc    string `json:"ec_private_enc,omitempty"`
 	ECPublicEnc     string `json:"ec_public_enc,omitempty"`
+	TLSPin          string `json:"tls_pin,omitempty"`
 }
 
 // CLIBackup is reserved for future CLI recovery data.
@@ -0,0 +1,54 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Upgrade channels allow agent builds to be rolled out in stages. Agents without a
+// channel use DeployInfoFile and the agent binaries in the root of the files directory.
+// Agents assigned a channel use deploy-<channel>.json and the binaries in the <channel>
+// subdirectory of the files directory.
+
+var validChannel = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ValidChannel returns true if the channel name is acceptable. An empty
+// channel is valid and means that the default deploy information is used.
+func ValidChannel(channel string) bool {
+	return channel == "" || validChannel.MatchString(channel)
+}
+
+// DeployInfoFileName returns the name of the deploy information file for the channel
+func DeployInfoFileName(channel string) string {
+	if channel == "" {
+		return DeployInfoFile
+	}
+	return fmt.Sprintf("deploy-%s.json", channel)
+}
+
+// ChannelFilePath returns the path of a file for the channel relative to the files directory
+func ChannelFilePath(channel, file string) string {
+	if channel == "" {
+		return file
+	}
+	return channel + "/" + file
+}
+
+// ChannelRequest is used to assign an upgrade channel to an agent or tag.
+// An empty channel removes the assignment.
+type ChannelRequest struct {
+	Channel string `json:"channel"`
+}
+
+// APITagChannelsResponse lists the upgrade channels assigned to tags
+type APITagChannelsResponse struct {
+	Status   string            `json:"status"`
+	Code     int               `json:"code"`
+	Details  string            `json:"details,omitempty"`
+	Channels map[string]string `json:"channels"`
+}
@@ -0,0 +1,36 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+// Types of command parameter values
+const (
+	ParamString = "string"
+	ParamInt    = "int"
+	ParamBool   = "bool"
+	ParamEnum   = "enum"
+)
+
+// CmdParam describes a command parameter and constrains its value. Min and Max apply to
+// integers, Values lists the values of an enum, and Pattern is a regular expression that a
+// string must match.
+type CmdParam struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Min      *int     `json:"min,omitempty"`
+	Max      *int     `json:"max,omitempty"`
+	Values   []string `json:"values,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Help     string   `json:"help,omitempty"`
+}
+
+// CmdSpec describes a command that may be sent to agents and its parameters
+type CmdSpec struct {
+	Name        string     `json:"name"`
+	AckRequired bool       `json:"ack_required"`
+	Sensitive   bool       `json:"sensitive,omitempty"`
+	Params      []CmdParam `json:"params"`
+}
@@ -5,13 +5,23 @@
 
 package commands
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+
+	"github.com/UnifyEM/UnifyEM/common/hasher"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
 
 type Command struct {
-	Name         string   // Command name
-	AckRequired  bool     // Whether the agent is expected to ack the command
-	RequiredArgs []string // Required arguments
-	OptionalArgs []string // Optional arguments
+	Name         string                        // Command name
+	AckRequired  bool                          // Whether the agent is expected to ack the command
+	RequiredArgs []string                      // Required arguments
+	OptionalArgs []string                      // Optional arguments
+	Params       map[string]schema.CmdParam    // Types and constraints of the arguments, if any
+	Check        func(map[string]string) error // Optional additional validation of the arguments
+	ServerOnly   bool                          // Queued by the server and not accepted from administrators
+	Sensitive    bool                          // Response is encrypted by the agent and only readable by permitted roles
 }
 
 type Commands struct {
@@ -22,9 +32,24 @@ type Commands struct {
 const (
 	DownloadExecute       = "download_execute"
 	Execute               = "execute"
+	FDEKeyEscrow          = "fde_key_escrow"
+	FileFetch             = "file_fetch"
+	FilePush              = "file_push"
+	FirewallGet           = "firewall_get"
+	FirewallSet           = "firewall_set"
+	LogsFetch             = "logs_fetch"
+	PatchInstall          = "patch_install"
+	PatchStatus           = "patch_status"
 	Ping                  = "ping"
+	ProcessKill           = "process_kill"
+	ProcessList           = "process_list"
 	Reboot                = "reboot"
 	RefreshServiceAccount = "refresh_service_account"
+	ScreenLockSet         = "screenlock_set"
+	ServiceControl        = "service_control"
+	SetLogLevel           = "set_log_level"
+	SetServerURL          = "set_server_url"
+	ShellStart            = "shell_start"
 	Shutdown              = "shutdown"
 	Status                = "status"
 	Upgrade               = "upgrade"
@@ -44,6 +69,25 @@ const (
 	RequestID = "request_id"
 )
 
+// MaxLogLines is the maximum number of lines that can be requested with logs_fetch
+const MaxLogLines = 100000
+
+// MaxLogLevelMinutes is the longest that set_log_level can change the log level for
+const MaxLogLevelMinutes = 10080
+
+// urlPattern matches the URLs that agents download files from
+const urlPattern = `^https?://`
+
+// usernamePattern matches the local user names that agents accept, see schema.ValidUsernameChars.
+// Names may not start with '-', which commands would treat as an option.
+const usernamePattern = `^[A-Za-z0-9_][A-Za-z0-9_-]*$`
+
+// Parameters shared by several commands
+var (
+	userParam     = patternParam(usernamePattern, "a local user name of letters, digits, '_' and '-', not starting with '-'")
+	shutdownParam = boolParam("shut down the computer afterwards so that the user is logged out")
+)
+
 var cmds Commands
 
 func init() {
@@ -53,13 +97,105 @@ func init() {
 				Name:         DownloadExecute,
 				AckRequired:  false,
 				RequiredArgs: []string{"url", "agent_id"},
-				OptionalArgs: allArgN(12),
+				OptionalArgs: append(allArgN(12), "hash", "hash_alg", "signature"),
+				Params: map[string]schema.CmdParam{
+					"url":       patternParam(urlPattern, "an http or https URL"),
+					"hash":      stringParam("base64 hash of the file, added by the server for files it hosts"),
+					"hash_alg":  enumParam("hash algorithm", hasher.SHA256, hasher.SHA512),
+					"signature": stringParam("signature created with 'files sign'"),
+					"arg1":      stringParam("arguments are passed in order from arg1 to arg12"),
+				},
 			},
 			Execute: {
 				Name:         Execute,
 				AckRequired:  true,
 				RequiredArgs: []string{"cmd", "agent_id"},
-				OptionalArgs: append(allArgN(12), "ssh"),
+				OptionalArgs: append(allArgN(12), "ssh", "run_as"),
+				Params: map[string]schema.CmdParam{
+					"cmd":    stringParam("command to execute"),
+					"ssh":    boolParam("run the command over SSH with the service account"),
+					"run_as": stringParam("run the command as this user, who must be logged in"),
+					"arg1":   stringParam("arguments are passed in order from arg1 to arg12"),
+				},
+				Check: checkExecute,
+			},
+			FDEKeyEscrow: {
+				Name:         FDEKeyEscrow,
+				AckRequired:  true,
+				RequiredArgs: []string{"agent_id"},
+				OptionalArgs: []string{"admin_user", "admin_password"}, // Required on macOS
+				Params: map[string]schema.CmdParam{
+					"admin_user":     stringParam("administrator account, required on macOS"),
+					"admin_password": stringParam("administrator password, required on macOS"),
+				},
+				Sensitive: true,
+			},
+			FileFetch: {
+				Name:         FileFetch,
+				AckRequired:  true,
+				RequiredArgs: []string{"path", "agent_id"},
+				OptionalArgs: []string{},
+				Params: map[string]schema.CmdParam{
+					"path": stringParam("absolute path of the file"),
+				},
+				Check: checkFileFetch,
+			},
+			FilePush: {
+				Name:         FilePush,
+				AckRequired:  true,
+				RequiredArgs: []string{"path", "agent_id"},
+				OptionalArgs: []string{"url", "file", "mode", "owner"},
+				Params: map[string]schema.CmdParam{
+					"path":  stringParam("absolute destination path"),
+					"url":   patternParam(urlPattern, "an http or https URL"),
+					"file":  stringParam("name of a file hosted by the server"),
+					"mode":  patternParam(`^0?[0-7]{3}$`, "octal permissions such as 0644"),
+					"owner": stringParam("owner of the file as user or user:group"),
+				},
+				Check: checkFilePush,
+			},
+			FirewallGet: {
+				Name:         FirewallGet,
+				AckRequired:  true,
+				RequiredArgs: []string{"agent_id"},
+				OptionalArgs: []string{},
+			},
+			FirewallSet: {
+				Name:         FirewallSet,
+				AckRequired:  true,
+				RequiredArgs: []string{"state", "agent_id"},
+				OptionalArgs: []string{},
+				Params: map[string]schema.CmdParam{
+					"state": boolParam("on to enable the firewall, off to disable it"),
+				},
+			},
+			LogsFetch: {
+				Name:         LogsFetch,
+				AckRequired:  true,
+				RequiredArgs: []string{"agent_id"},
+				OptionalArgs: []string{"lines", "since"},
+				Params: map[string]schema.CmdParam{
+					"lines": intParam(1, MaxLogLines, "number of lines to return from the end of the log"),
+					"since": stringParam("Unix time or RFC 3339 timestamp of the first entry to return"),
+				},
+				Check: checkLogsFetch,
+			},
+			PatchInstall: {
+				Name:         PatchInstall,
+				AckRequired:  true,
+				RequiredArgs: []string{"agent_id"},
+				OptionalArgs: []string{"updates", "reboot_allowed"},
+				Params: map[string]schema.CmdParam{
+					"updates":        stringParam("comma-separated names of the updates to install, all if omitted"),
+					"reboot_allowed": boolParam("allow the agent to restart the computer if an update requires it"),
+				},
+				Check: checkPatchInstall,
+			},
+			PatchStatus: {
+				Name:         PatchStatus,
+				AckRequired:  true,
+				RequiredArgs: []string{"agent_id"},
+				OptionalArgs: []string{},
 			},
 			Status: {
 				Name:         Status,
@@ -73,6 +209,24 @@ func init() {
 				RequiredArgs: []string{"agent_id"},
 				OptionalArgs: []string{},
 			},
+			ProcessKill: {
+				Name:         ProcessKill,
+				AckRequired:  true,
+				RequiredArgs: []string{"agent_id"},
+				OptionalArgs: []string{"pid", "name", "force"},
+				Params: map[string]schema.CmdParam{
+					"pid":   intParam(1, math.MaxInt32, "process ID"),
+					"name":  stringParam("exact name of the process, without a path"),
+					"force": boolParam("kill the process without allowing it to exit cleanly"),
+				},
+				Check: checkProcessKill,
+			},
+			ProcessList: {
+				Name:         ProcessList,
+				AckRequired:  true,
+				RequiredArgs: []string{"agent_id"},
+				OptionalArgs: []string{},
+			},
 			Reboot: {
 				Name:         Reboot,
 				AckRequired:  false,
@@ -85,6 +239,58 @@ func init() {
 				RequiredArgs: []string{"agent_id"},
 				OptionalArgs: []string{},
 			},
+			ScreenLockSet: {
+				Name:         ScreenLockSet,
+				AckRequired:  true,
+				RequiredArgs: []string{"delay_minutes", "agent_id"},
+				OptionalArgs: []string{},
+				Params: map[string]schema.CmdParam{
+					"delay_minutes": intParam(1, 1440, "minutes of inactivity before the screen locks"),
+				},
+			},
+			ServiceControl: {
+				Name:         ServiceControl,
+				AckRequired:  true,
+				RequiredArgs: []string{"name", "action", "agent_id"},
+				OptionalArgs: []string{},
+				Params: map[string]schema.CmdParam{
+					"name":   stringParam("name of the service"),
+					"action": enumParam("action to take", "start", "stop", "restart", "status"),
+				},
+				Check: checkServiceControl,
+			},
+			SetLogLevel: {
+				Name:         SetLogLevel,
+				AckRequired:  true,
+				RequiredArgs: []string{"level", "agent_id"},
+				OptionalArgs: []string{"duration_minutes"},
+				Params: map[string]schema.CmdParam{
+					"level":            enumParam("lowest severity of message to log", "debug", "info", "warning"),
+					"duration_minutes": intParam(1, MaxLogLevelMinutes, "minutes until the configured level is restored"),
+				},
+			},
+			SetServerURL: {
+				Name:         SetServerURL,
+				AckRequired:  true,
+				RequiredArgs: []string{"url", "agent_id"},
+				OptionalArgs: []string{"migration_token", "signature"},
+				Params: map[string]schema.CmdParam{
+					"url":             patternParam(urlPattern, "URL of the new server"),
+					"migration_token": stringParam("migration token from the new server, used if the agent must register with it"),
+					"signature":       stringParam("authorization of the move, added by the server"),
+				},
+				Check: checkSetServerURL,
+			},
+			ShellStart: {
+				Name:         ShellStart,
+				AckRequired:  true,
+				RequiredArgs: []string{"session_id", "agent_id"},
+				OptionalArgs: []string{},
+				Params: map[string]schema.CmdParam{
+					"session_id": stringParam("shell session opened by the server"),
+				},
+				ServerOnly: true, // sessions are opened with the shell endpoint, which enforces the permitted roles
+			},
 			Shutdown: {
 				Name:         Shutdown,
 				AckRequired:  false,
@@ -95,49 +301,79 @@ func init() {
 				Name:         Upgrade,
 				AckRequired:  false,
 				RequiredArgs: []string{"agent_id"},
-				OptionalArgs: []string{},
+				OptionalArgs: []string{"channel"},
+				Params: map[string]schema.CmdParam{
+					"channel": stringParam("release channel to upgrade from"),
+				},
+				Check: checkUpgrade,
 			},
 			UserAdd: {
 				Name:         UserAdd,
 				AckRequired:  true,
 				RequiredArgs: []string{"user", "password", "agent_id"},
 				OptionalArgs: []string{"admin"},
+				Params: map[string]schema.CmdParam{
+					"user":     userParam,
+					"password": stringParam("password of the new account"),
+					"admin":    boolParam("make the user an administrator"),
+				},
 			},
 			UserDelete: {
 				Name:         UserDelete,
 				AckRequired:  true,
 				RequiredArgs: []string{"user", "agent_id"},
 				OptionalArgs: []string{"shutdown"},
+				Params: map[string]schema.CmdParam{
+					"user":     userParam,
+					"shutdown": shutdownParam,
+				},
 			},
 			UserAdmin: {
 				Name:         UserAdmin,
 				AckRequired:  true,
 				RequiredArgs: []string{"user", "admin", "agent_id"},
 				OptionalArgs: []string{},
+				Params: map[string]schema.CmdParam{
+					"user":  userParam,
+					"admin": boolParam("true to make the user an administrator, false to remove administrator rights"),
+				},
 			},
 			UserPassword: {
 				Name:         UserPassword,
 				AckRequired:  true,
 				RequiredArgs: []string{"user", "password", "agent_id"},
 				OptionalArgs: []string{},
+				Params: map[string]schema.CmdParam{
+					"user":     userParam,
+					"password": stringParam("new password"),
+				},
 			},
 			UserList: {
 				Name:         UserList,
 				AckRequired:  true,
 				RequiredArgs: []string{"agent_id"},
 				OptionalArgs: []string{},
+				Sensitive:    true,
 			},
 			UserLock: {
 				Name:         UserLock,
 				AckRequired:  true,
 				RequiredArgs: []string{"user", "agent_id"},
 				OptionalArgs: []string{"shutdown"},
+				Params: map[string]schema.CmdParam{
+					"user":     userParam,
+					"shutdown": shutdownParam,
+				},
 			},
 			UserUnlock: {
 				Name:         UserUnlock,
 				AckRequired:  true,
 				RequiredArgs: []string{"user", "password", "agent_id"},
 				OptionalArgs: []string{},
+				Params: map[string]schema.CmdParam{
+					"user":     userParam,
+					"password": stringParam("new password"),
+				},
 			},
 		},
 	}
@@ -167,3 +403,22 @@ func IsAckRequired(cmd string) bool {
 	}
 	return command.AckRequired
 }
+
+// IsServerOnly returns whether the command can only be queued by the server itself
+func IsServerOnly(cmd string) bool {
+	command, exists := cmds.Commands[cmd]
+	if !exists {
+		return false
+	}
+	return command.ServerOnly
+}
+
+// IsSensitive returns whether the response to the command is encrypted by the agent so that
+// only the server can read it
+func IsSensitive(cmd string) bool {
+	command, exists := cmds.Commands[cmd]
+	if !exists {
+		return false
+	}
+	return command.Sensitive
+}
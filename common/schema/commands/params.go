@@ -0,0 +1,196 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Parameters are validated against the typed specs in each command's Params. Parameters without
+// a spec accept any string. Boolean and enum values are normalized before a request is queued,
+// because agents do not all accept the same spellings.
+
+// boolValues maps the accepted spellings of boolean parameters to their normalized values
+var boolValues = map[string]string{
+	"true": "true", "yes": "true", "on": "true", "1": "true",
+	"false": "false", "no": "false", "off": "false", "0": "false",
+}
+
+// patterns caches the compiled regular expressions of parameters with a pattern
+var patterns = make(map[string]*regexp.Regexp)
+
+func stringParam(help string) schema.CmdParam {
+	return schema.CmdParam{Type: schema.ParamString, Help: help}
+}
+
+func patternParam(pattern, help string) schema.CmdParam {
+	patterns[pattern] = regexp.MustCompile(pattern)
+	return schema.CmdParam{Type: schema.ParamString, Pattern: pattern, Help: help}
+}
+
+func intParam(minimum, maximum int, help string) schema.CmdParam {
+	return schema.CmdParam{Type: schema.ParamInt, Min: &minimum, Max: &maximum, Help: help}
+}
+
+func boolParam(help string) schema.CmdParam {
+	return schema.CmdParam{Type: schema.ParamBool, Help: help}
+}
+
+func enumParam(help string, values ...string) schema.CmdParam {
+	return schema.CmdParam{Type: schema.ParamEnum, Values: values, Help: help}
+}
+
+// checkParam checks that the value of a parameter matches its spec
+func checkParam(name string, spec schema.CmdParam, value string) error {
+	switch spec.Type {
+	case schema.ParamInt:
+		n, err := strconv.Atoi(value)
+		if err != nil || (spec.Min != nil && n < *spec.Min) || (spec.Max != nil && n > *spec.Max) {
+			switch {
+			case spec.Min != nil && spec.Max != nil:
+				return fmt.Errorf("%s must be a number between %d and %d", name, *spec.Min, *spec.Max)
+			case spec.Min != nil:
+				return fmt.Errorf("%s must be a number of at least %d", name, *spec.Min)
+			default:
+				return fmt.Errorf("%s must be a number", name)
+			}
+		}
+
+	case schema.ParamBool:
+		if _, ok := boolValues[strings.ToLower(value)]; !ok {
+			return fmt.Errorf("%s must be true or false", name)
+		}
+
+	case schema.ParamEnum:
+		for _, v := range spec.Values {
+			if strings.EqualFold(value, v) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be one of: %s", name, strings.Join(spec.Values, ", "))
+
+	default:
+		if spec.Pattern != "" && !patterns[spec.Pattern].MatchString(value) {
+			if spec.Help != "" {
+				return fmt.Errorf("%s must be %s", name, spec.Help)
+			}
+			return fmt.Errorf("invalid value for %s", name)
+		}
+	}
+	return nil
+}
+
+// CheckParams checks the values of the parameters that have a spec without requiring the
+// others. It is used when the server adds parameters, such as the agent ID, before validating.
+func CheckParams(cmd string, parameters map[string]string) error {
+	c, ok := cmds.Commands[cmd]
+	if !ok {
+		return errors.New("invalid command")
+	}
+
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if spec, ok := c.Params[name]; ok {
+			if err := checkParam(name, spec, parameters[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Normalize rewrites boolean parameters as true or false and enum parameters in lower case,
+// so that every agent interprets them the same way. Parameters that are not valid are left
+// unchanged for Validate to report.
+func Normalize(cmd string, parameters map[string]string) {
+	c, ok := cmds.Commands[cmd]
+	if !ok {
+		return
+	}
+
+	for name, value := range parameters {
+		spec, ok := c.Params[name]
+		if !ok {
+			continue
+		}
+		switch spec.Type {
+		case schema.ParamBool:
+			if v, ok := boolValues[strings.ToLower(value)]; ok {
+				parameters[name] = v
+			}
+		case schema.ParamEnum:
+			for _, v := range spec.Values {
+				if strings.EqualFold(value, v) {
+					parameters[name] = v
+				}
+			}
+		}
+	}
+}
+
+// Spec returns the description of a command and its parameters. The request_id and hash
+// parameters that every command accepts are omitted unless the command describes them.
+func Spec(cmd string) (schema.CmdSpec, bool) {
+	c, ok := cmds.Commands[cmd]
+	if !ok {
+		return schema.CmdSpec{}, false
+	}
+
+	spec := schema.CmdSpec{Name: c.Name, AckRequired: c.AckRequired, Sensitive: c.Sensitive, Params: []schema.CmdParam{}}
+	seen := make(map[string]bool)
+	add := func(name string, required bool) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+
+		p, ok := c.Params[name]
+		if !ok {
+			if !required && (name == RequestID || name == "hash") {
+				return
+			}
+			p = stringParam("")
+		}
+		p.Name = name
+		p.Required = required
+		spec.Params = append(spec.Params, p)
+	}
+
+	for _, name := range c.RequiredArgs {
+		add(name, true)
+	}
+	for _, name := range c.OptionalArgs {
+		add(name, false)
+	}
+	return spec, true
+}
+
+// Specs returns the description of every command that administrators may send, sorted by name
+func Specs() []schema.CmdSpec {
+	var specs []schema.CmdSpec
+	for name, c := range cmds.Commands {
+		if c.ServerOnly {
+			continue
+		}
+		if spec, ok := Spec(name); ok {
+			specs = append(specs, spec)
+		}
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
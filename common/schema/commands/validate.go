@@ -8,6 +8,13 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
 // Validate checks if the command and parameters are valid
@@ -53,10 +60,207 @@ func Validate(cmd string, parameters map[string]string) error {
 		if !found {
 			return fmt.Errorf("invalid argument: %s", param)
 		}
+
+		if spec, ok := cmdTemplate.Params[param]; ok {
+			if err = checkParam(param, spec, parameters[param]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Perform any command-specific validation
+	if cmdTemplate.Check != nil {
+		return cmdTemplate.Check(parameters)
+	}
+	return nil
+}
+
+// checkUpgrade requires a valid channel name if one is specified
+func checkUpgrade(parameters map[string]string) error {
+	if channel, ok := parameters["channel"]; ok && (channel == "" || !schema.ValidChannel(channel)) {
+		return errors.New("channel must be lower case letters, digits, '-' or '_'")
+	}
+	return nil
+}
+
+// checkExecute does not allow run_as with ssh, which runs the command as the service account
+func checkExecute(parameters map[string]string) error {
+	runAs, hasRunAs := parameters["run_as"]
+	if !hasRunAs {
+		return nil
+	}
+	if strings.TrimSpace(runAs) == "" || strings.HasPrefix(runAs, "-") || strings.ContainsFunc(runAs, unicode.IsControl) {
+		return errors.New("run_as must be a user name")
+	}
+	if boolValues[strings.ToLower(parameters["ssh"])] == "true" {
+		return errors.New("run_as and ssh cannot be used together")
+	}
+	return nil
+}
+
+// checkFilePush requires exactly one source (url or file) and an absolute destination path
+func checkFilePush(parameters map[string]string) error {
+	_, hasURL := parameters["url"]
+	_, hasFile := parameters["file"]
+	if hasURL == hasFile {
+		return errors.New("exactly one of url or file is required")
+	}
+
+	// The filename must refer to a file in the server's file directory
+	if hasFile && (parameters["file"] == "" || strings.ContainsAny(parameters["file"], "/\\") || strings.HasPrefix(parameters["file"], ".")) {
+		return errors.New("file must be the name of a file hosted by the server")
+	}
+
+	// The agent's OS is not known here, so accept Unix and Windows absolute paths
+	if !isAbsPath(parameters["path"]) {
+		return errors.New("path must be an absolute path")
+	}
+
+	return nil
+}
+
+// checkFileFetch requires an absolute path to the file to be fetched
+func checkFileFetch(parameters map[string]string) error {
+	if !isAbsPath(parameters["path"]) {
+		return errors.New("path must be an absolute path")
+	}
+	return nil
+}
+
+// checkLogsFetch allows either a number of lines or a start time, but not both
+func checkLogsFetch(parameters map[string]string) error {
+	_, hasLines := parameters["lines"]
+	since, hasSince := parameters["since"]
+
+	if hasLines && hasSince {
+		return errors.New("specify lines or since, not both")
+	}
+
+	if hasSince {
+		if _, err := ParseSince(since); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// checkPatchInstall requires valid update names
+func checkPatchInstall(parameters map[string]string) error {
+	if updates, ok := parameters["updates"]; ok {
+		names := PatchNames(updates)
+		if len(names) == 0 {
+			return errors.New("updates must be a comma-separated list of update names")
+		}
+		for _, name := range names {
+			if !validPatchName(name) {
+				return fmt.Errorf("invalid update name: %s", name)
+			}
+		}
+	}
+	return nil
+}
+
+// PatchNames splits the patch_install updates parameter into a list of update names
+func PatchNames(updates string) []string {
+	var names []string
+	for _, name := range strings.Split(updates, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// validPatchName allows the characters used in macOS labels, KB articles, and package names.
+// Quotes and shell metacharacters are excluded because names are passed to installers.
+func validPatchName(name string) bool {
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune(" ._+-:()", c)) {
+			return false
+		}
+	}
+	return !strings.HasPrefix(name, "-")
+}
+
+// checkProcessKill requires a PID and/or an exact process name
+func checkProcessKill(parameters map[string]string) error {
+	_, hasPID := parameters["pid"]
+	name, hasName := parameters["name"]
+
+	if !hasPID && !hasName {
+		return errors.New("pid or name is required")
+	}
+
+	if hasName && (strings.TrimSpace(name) == "" || strings.ContainsAny(name, "/\\*?")) {
+		return errors.New("name must be the exact name of a process, without a path or wildcards")
+	}
+	return nil
+}
+
+// agentServices are the names of the agent's own service on each platform. The agent is
+// upgraded and uninstalled with the upgrade command and uninstall trigger, not service_control.
+var agentServices = map[string]bool{
+	"uem-agent":              true, // Linux
+	"uem-agent.service":      true,
+	"com.tenebris.uem-agent": true, // macOS
+	"uemagent":               true, // Windows
+}
+
+// checkServiceControl requires a service name other than the agent's
+func checkServiceControl(parameters map[string]string) error {
+	name := parameters["name"]
+	if name == "" || strings.HasPrefix(name, "-") {
+		return errors.New("name must be the name of a service")
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune(" ._-@:/", c)) {
+			return fmt.Errorf("invalid character in service name: %q", c)
+		}
+	}
+
+	// On macOS the name may include a domain such as system/ or gui/501/
+	if agentServices[strings.ToLower(name[strings.LastIndex(name, "/")+1:])] {
+		return errors.New("the agent's own service can not be controlled with service_control")
+	}
+	return nil
+}
+
+// checkSetServerURL requires the URL of a server, without a path, and a non-empty migration
+// token if one is specified
+func checkSetServerURL(parameters map[string]string) error {
+	u, err := url.Parse(parameters["url"])
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+		return errors.New("url must be the URL of a server, such as https://uem.example.com")
+	}
+	if token, ok := parameters["migration_token"]; ok && token == "" {
+		return errors.New("migration_token must not be empty")
+	}
+	return nil
+}
+
+// ParseSince parses a logs_fetch start time, which may be a Unix time or an RFC 3339 timestamp
+func ParseSince(since string) (time.Time, error) {
+	if n, err := strconv.ParseInt(since, 10, 64); err == nil && n > 0 {
+		return time.Unix(n, 0), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, errors.New("since must be a Unix time or an RFC 3339 timestamp such as 2026-01-02T15:04:05Z")
+	}
+	return t, nil
+}
+
+// isAbsPath returns true if path is an absolute Unix path or an absolute Windows path with a drive letter
+func isAbsPath(path string) bool {
+	if strings.HasPrefix(path, "/") {
+		return true
+	}
+	return len(path) > 3 && path[1] == ':' && (path[2] == '\\' || path[2] == '/') &&
+		((path[0] >= 'a' && path[0] <= 'z') || (path[0] >= 'A' && path[0] <= 'Z'))
+}
+
 // ValidateCmd checks if the command is valid
 //
 //goland:noinspection GoUnusedExportedFunction
@@ -0,0 +1,78 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+// shellMeta are characters that a shell would interpret
+const shellMeta = "'\"`$;&|<>\\*?!#~\n\r\x00"
+
+// FuzzValidateHostile feeds hostile values through Validate. Parameters that agents pass to
+// other programs must be rejected if they contain shell metacharacters or could be mistaken
+// for an option.
+func FuzzValidateHostile(f *testing.F) {
+	for _, s := range []string{"sshd", "KB5034441", "jsmith", "$(id)", "`id`", "a;id", "a|id", "-rf", "--root=/",
+		"x' ; calc ; '", "a\nid", "a\x00b", "../../etc", "name with spaces", "’quote’"} {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		if Validate(ServiceControl, map[string]string{"agent_id": "A", "action": "status", "name": value}) == nil {
+			if strings.ContainsAny(value, shellMeta) || strings.HasPrefix(value, "-") {
+				t.Errorf("service_control accepted name %q", value)
+			}
+		}
+
+		if Validate(PatchInstall, map[string]string{"agent_id": "A", "updates": value}) == nil {
+			for _, name := range PatchNames(value) {
+				if strings.ContainsAny(name, shellMeta) || strings.HasPrefix(name, "-") {
+					t.Errorf("patch_install accepted update %q", name)
+				}
+			}
+		}
+
+		for _, cmd := range []string{UserAdd, UserPassword, UserUnlock} {
+			if Validate(cmd, map[string]string{"agent_id": "A", "user": value, "password": "x"}) == nil {
+				if strings.ContainsAny(value, shellMeta) || strings.HasPrefix(value, "-") {
+					t.Errorf("%s accepted user %q", cmd, value)
+				}
+			}
+		}
+
+		if Validate(Execute, map[string]string{"agent_id": "A", "cmd": "whoami", "run_as": value}) == nil {
+			if strings.HasPrefix(value, "-") || strings.ContainsAny(value, "\n\r\x00") {
+				t.Errorf("execute accepted run_as %q", value)
+			}
+		}
+	})
+}
+
+func TestValidateExecute(t *testing.T) {
+	valid := []map[string]string{
+		{"agent_id": "A", "cmd": "whoami"},
+		{"agent_id": "A", "cmd": "whoami", "run_as": "jsmith"},
+		{"agent_id": "A", "cmd": "whoami", "run_as": `CORP\jsmith`, "ssh": "false"},
+	}
+	for _, p := range valid {
+		if err := Validate(Execute, p); err != nil {
+			t.Errorf("%v: unexpected error: %v", p, err)
+		}
+	}
+
+	invalid := []map[string]string{
+		{"agent_id": "A", "cmd": "whoami", "run_as": ""},
+		{"agent_id": "A", "cmd": "whoami", "run_as": "-u"},
+		{"agent_id": "A", "cmd": "whoami", "run_as": "jsmith", "ssh": "yes"},
+	}
+	for _, p := range invalid {
+		if err := Validate(Execute, p); err == nil {
+			t.Errorf("%v: expected an error", p)
+		}
+	}
+}
@@ -5,7 +5,10 @@
 
 package schema
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 func ConvertMapString(data any) (map[string]string, error) {
 
@@ -43,7 +46,7 @@ func ConvertAgentStatusData(data any) (AgentStatusData, error) {
 	}
 
 	result := AgentStatusData{
-		Details: make(map[string]string),
+		Details: NewStatusDetails(),
 		Info:    []string{},
 	}
 
@@ -52,7 +55,7 @@ func ConvertAgentStatusData(data any) (AgentStatusData, error) {
 		// New format: extract details map
 		if detailsMap, ok := details.(map[string]interface{}); ok {
 			for key, value := range detailsMap {
-				result.Details[key] = fmt.Sprintf("%v", value)
+				result.Details.Set(key, statusString(value))
 			}
 		}
 
@@ -66,21 +69,21 @@ func ConvertAgentStatusData(data any) (AgentStatusData, error) {
 				}
 			}
 		}
+
+		// Extract the antivirus products if present
+		if products, hasProducts := dataMap["antivirus_products"]; hasProducts {
+			b, err := json.Marshal(products)
+			if err == nil {
+				err = json.Unmarshal(b, &result.AntivirusProducts)
+			}
+			if err != nil {
+				return result, fmt.Errorf("invalid antivirus_products: %w", err)
+			}
+		}
 	} else {
 		// Legacy format: treat entire map as details
 		for key, value := range dataMap {
-			switch v := value.(type) {
-			case string:
-				result.Details[key] = v
-			case int:
-				result.Details[key] = fmt.Sprintf("%d", v)
-			case float64:
-				result.Details[key] = fmt.Sprintf("%f", v)
-			case bool:
-				result.Details[key] = fmt.Sprintf("%t", v)
-			default:
-				result.Details[key] = fmt.Sprintf("%v", v)
-			}
+			result.Details.Set(key, statusString(value))
 		}
 	}
 
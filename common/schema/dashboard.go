@@ -0,0 +1,42 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import "time"
+
+// DashboardChecks are the status checks that an agent must not report as failing to be counted
+// as compliant in the dashboard summary
+var DashboardChecks = []string{"firewall", "antivirus", "full_disk_encryption", "password", "screen_lock"}
+
+// DashboardSummary is an overview of the agents, their compliance, and outstanding requests.
+// It is computed when requested and is not stored.
+type DashboardSummary struct {
+	Generated    time.Time         `json:"generated"`
+	Agents       int               `json:"agents" example:"120"`       // Registered agents
+	Online       int               `json:"online" example:"112"`       // Agents that are not offline
+	Offline      int               `json:"offline" example:"8"`        // Agents not seen within agent_offline_threshold
+	NotReported  int               `json:"not_reported" example:"3"`   // Agents that have not reported their status
+	NonCompliant int               `json:"non_compliant" example:"14"` // Agents reporting at least one of DashboardChecks as failing
+	Failing      map[string]int    `json:"failing"`                    // Agents reporting each of DashboardChecks as failing
+	Triggers     DashboardTriggers `json:"triggers"`
+	Requests     AgentQueue        `json:"requests"`      // Undelivered requests for all agents
+	RecentEvents []AgentEvent      `json:"recent_events"` // Most recent first
+}
+
+// DashboardTriggers counts the agents with each trigger set
+type DashboardTriggers struct {
+	Lost        int `json:"lost"`
+	Wipe        int `json:"wipe"`
+	WipePending int `json:"wipe_pending"` // Wipes awaiting confirmation
+	Uninstall   int `json:"uninstall"`
+}
+
+type APIDashboardResponse struct {
+	Status  string           `json:"status" example:"ok"`
+	Code    int              `json:"code" example:"200"`
+	Details string           `json:"details,omitempty" example:"dashboard summary"`
+	Data    DashboardSummary `json:"data"`
+}
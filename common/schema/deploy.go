@@ -0,0 +1,130 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The deploy information file lists the agent binaries available for upgrades. It is a
+// manifest with an entry for each OS and architecture. Earlier versions of the server wrote a
+// map of file names to hashes, which ParseDeployInfo still accepts.
+
+// DeployManifestVersion is the format version written to the manifest
+const DeployManifestVersion = 2
+
+// DeployManifest is the content of deploy.json or deploy-<channel>.json
+type DeployManifest struct {
+	ManifestVersion int           `json:"manifest_version" example:"2"`
+	Created         time.Time     `json:"created"`
+	Agents          []DeployEntry `json:"agents"`
+}
+
+// DeployEntry describes the agent binary for an OS and architecture
+type DeployEntry struct {
+	OS         string `json:"os" example:"windows"`
+	Arch       string `json:"arch" example:"amd64"`
+	File       string `json:"file" example:"uem-agent-windows-amd64.exe"`
+	Version    string `json:"version,omitempty" example:"0.0.60"`
+	Build      int    `json:"build,omitempty" example:"108"`
+	SHA256     string `json:"sha256"`                                 // base64
+	MinVersion string `json:"min_version,omitempty" example:"0.0.50"` // oldest agent version that can upgrade to this one
+}
+
+// DeployFileRequest is the optional body of a request to create a deploy information file.
+// If Agents is empty, the files directory is scanned for agent binaries and they are given
+// Version, Build, and MinVersion, which default to the server's version and build.
+type DeployFileRequest struct {
+	Version    string        `json:"version,omitempty"`
+	Build      int           `json:"build,omitempty"`
+	MinVersion string        `json:"min_version,omitempty"`
+	Agents     []DeployEntry `json:"agents,omitempty"`
+}
+
+// Agent platforms recognized in the files directory
+var (
+	deployOS   = []string{"darwin", "linux", "windows"}
+	deployArch = []string{"386", "amd64", "arm", "arm64"}
+)
+
+// AgentFileName returns the name of the agent binary for an OS and architecture
+func AgentFileName(goos, goarch string) string {
+	name := fmt.Sprintf("uem-agent-%s-%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// ParseAgentFileName returns the OS and architecture of an agent binary, or false if the
+// name is not that of an agent binary
+func ParseAgentFileName(name string) (string, string, bool) {
+	for _, goos := range deployOS {
+		for _, goarch := range deployArch {
+			if name == AgentFileName(goos, goarch) {
+				return goos, goarch, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// ParseDeployInfo reads a deploy information file in either format
+func ParseDeployInfo(data []byte) (DeployManifest, error) {
+	var m DeployManifest
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return m, fmt.Errorf("error deserializing deploy information: %w", err)
+	}
+
+	if _, ok := probe["manifest_version"]; ok {
+		if err := json.Unmarshal(data, &m); err != nil {
+			return m, fmt.Errorf("error deserializing deploy manifest: %w", err)
+		}
+		if m.ManifestVersion > DeployManifestVersion {
+			return m, fmt.Errorf("unsupported deploy manifest version %d", m.ManifestVersion)
+		}
+		return m, nil
+	}
+
+	// The original format maps file names to hashes
+	var legacy map[string]string
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return m, fmt.Errorf("error deserializing deploy information: %w", err)
+	}
+	for name, hash := range legacy {
+		if goos, goarch, ok := ParseAgentFileName(name); ok {
+			m.Agents = append(m.Agents, DeployEntry{OS: goos, Arch: goarch, File: name, SHA256: hash})
+		}
+	}
+	return m, nil
+}
+
+// Entry returns the entry for an OS and architecture
+func (m DeployManifest) Entry(goos, goarch string) (DeployEntry, bool) {
+	for _, e := range m.Agents {
+		if e.OS == goos && e.Arch == goarch {
+			return e, true
+		}
+	}
+	return DeployEntry{}, false
+}
+
+// Validate checks that an entry has a platform and the name of a file in the files directory
+func (e DeployEntry) Validate() error {
+	if e.OS == "" || e.Arch == "" {
+		return errors.New("os and arch are required")
+	}
+	if e.File == "" || strings.ContainsAny(e.File, `/\`) || strings.HasPrefix(e.File, ".") {
+		return fmt.Errorf("invalid file name for %s/%s", e.OS, e.Arch)
+	}
+	return nil
+}
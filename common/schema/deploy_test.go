@@ -0,0 +1,70 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import (
+	"testing"
+)
+
+func TestParseDeployInfo(t *testing.T) {
+	// Deploy files written by earlier servers map file names to hashes
+	legacy := []byte(`{"uem-agent-windows-amd64.exe":"aGFzaDE=","uem-agent-darwin-arm64":"aGFzaDI=","uem-cli-linux-amd64":"aGFzaDM="}`)
+	m, err := ParseDeployInfo(legacy)
+	if err != nil {
+		t.Fatalf("legacy deploy file rejected: %v", err)
+	}
+	if len(m.Agents) != 2 {
+		t.Errorf("expected 2 agents, got %+v", m.Agents)
+	}
+	e, ok := m.Entry("windows", "amd64")
+	if !ok || e.File != "uem-agent-windows-amd64.exe" || e.SHA256 != "aGFzaDE=" {
+		t.Errorf("unexpected windows entry %+v", e)
+	}
+	if _, ok = m.Entry("linux", "amd64"); ok {
+		t.Errorf("uem-cli recognized as an agent")
+	}
+
+	manifest := []byte(`{"manifest_version":2,"agents":[{"os":"linux","arch":"amd64","file":"uem-agent-linux-amd64",
+		"version":"0.0.61","build":110,"sha256":"aGFzaDQ=","min_version":"0.0.50"}]}`)
+	m, err = ParseDeployInfo(manifest)
+	if err != nil {
+		t.Fatalf("manifest rejected: %v", err)
+	}
+	e, ok = m.Entry("linux", "amd64")
+	if !ok || e.Version != "0.0.61" || e.Build != 110 || e.MinVersion != "0.0.50" || e.SHA256 != "aGFzaDQ=" {
+		t.Errorf("unexpected linux entry %+v", e)
+	}
+	if _, ok = m.Entry("darwin", "amd64"); ok {
+		t.Errorf("unexpected darwin entry")
+	}
+
+	if _, err = ParseDeployInfo([]byte(`{"manifest_version":99,"agents":[]}`)); err == nil {
+		t.Errorf("unsupported manifest version accepted")
+	}
+	if _, err = ParseDeployInfo([]byte(`[]`)); err == nil {
+		t.Errorf("invalid deploy file accepted")
+	}
+}
+
+func TestParseAgentFileName(t *testing.T) {
+	for name, want := range map[string]string{
+		"uem-agent-windows-amd64.exe": "windows/amd64",
+		"uem-agent-darwin-arm64":      "darwin/arm64",
+		"uem-agent-linux-amd64":       "linux/amd64",
+		"uem-agent-windows-amd64":     "",
+		"uem-agent-linux-amd64.exe":   "",
+		"uem-cli-linux-amd64":         "",
+	} {
+		goos, goarch, ok := ParseAgentFileName(name)
+		got := ""
+		if ok {
+			got = goos + "/" + goarch
+		}
+		if got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+}
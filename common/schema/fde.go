@@ -0,0 +1,44 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import "time"
+
+// Full disk encryption types
+const (
+	FDETypeBitLocker = "bitlocker"
+	FDETypeFileVault = "filevault"
+)
+
+// FDERecoveryKey is a full disk encryption recovery key escrowed by an agent. The agent
+// encrypts it with the server's public key before including it in its response.
+type FDERecoveryKey struct {
+	Type string `json:"type"`         // bitlocker or filevault
+	ID   string `json:"id,omitempty"` // BitLocker key protector ID
+	Key  string `json:"key"`
+}
+
+// FDERecoveryKeyRecord is the most recent recovery key escrowed by an agent. Key contains the
+// FDERecoveryKey encrypted with the server's public key.
+type FDERecoveryKeyRecord struct {
+	AgentID   string    `json:"agent_id"`
+	RequestID string    `json:"request_id"`
+	Type      string    `json:"type"`
+	ID        string    `json:"id,omitempty"`
+	Escrowed  time.Time `json:"escrowed"`
+	Key       string    `json:"key"`
+}
+
+// APIFDERecoveryKeyResponse Response containing an agent's decrypted recovery key
+type APIFDERecoveryKeyResponse struct {
+	Status    string         `json:"status" example:"ok"`
+	Code      int            `json:"code" example:"200"`
+	Details   string         `json:"details,omitempty"`
+	AgentID   string         `json:"agent_id"`
+	RequestID string         `json:"request_id"`
+	Escrowed  time.Time      `json:"escrowed"`
+	Data      FDERecoveryKey `json:"data"`
+}
@@ -0,0 +1,32 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import "time"
+
+// HostedFile describes a file in the server's file directory
+type HostedFile struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	SHA256   string    `json:"sha256"` // base64 encoded
+}
+
+// APIFilesResponse lists the files in the server's file directory
+type APIFilesResponse struct {
+	Status  string       `json:"status" example:"ok"`
+	Code    int          `json:"code" example:"200"`
+	Details string       `json:"details,omitempty"`
+	Data    []HostedFile `json:"data"`
+}
+
+// APIFileUploadResponse is returned after a file is uploaded to the server's file directory
+type APIFileUploadResponse struct {
+	Status  string     `json:"status" example:"ok"`
+	Code    int        `json:"code" example:"200"`
+	Details string     `json:"details,omitempty"`
+	Data    HostedFile `json:"data"`
+}
@@ -0,0 +1,62 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Groups are named sets of agents that may also contain other groups, so that a command
+// sent to a parent such as "Laptops" reaches the agents in "Mac Laptops" and "Windows
+// Laptops". Group names are not case-sensitive.
+
+const MaxGroupNameLength = 64
+
+var validGroupName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.-]*$`)
+
+// ValidGroupName returns true if the group name is acceptable
+func ValidGroupName(name string) bool {
+	return len(name) <= MaxGroupNameLength && validGroupName.MatchString(name)
+}
+
+// GroupKey returns the key used to store and look up a group
+func GroupKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Group contains agents and other groups, referred to by name
+type Group struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Agents      []string  `json:"agents"`
+	Groups      []string  `json:"groups"`
+	Created     time.Time `json:"created"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// GroupRequest is used to create a group
+type GroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// GroupMembersRequest is used to add agents and groups to a group or remove them
+type GroupMembersRequest struct {
+	Agents []string `json:"agents"`
+	Groups []string `json:"groups"`
+}
+
+// APIGroupsResponse lists groups. When a single group is requested, Agents contains
+// the agents in the group and all the groups it contains.
+type APIGroupsResponse struct {
+	Status  string   `json:"status"`
+	Code    int      `json:"code"`
+	Details string   `json:"details,omitempty"`
+	Groups  []Group  `json:"groups"`
+	Agents  []string `json:"agents,omitempty"`
+}
@@ -0,0 +1,23 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+// ImportSummary reports the records imported from an export bundle
+type ImportSummary struct {
+	Agents       int  `json:"agents" example:"25"`
+	Users        int  `json:"users" example:"3"`
+	Logins       int  `json:"logins" example:"2"`
+	Groups       int  `json:"groups" example:"4"`
+	Events       int  `json:"events" example:"1200"`
+	KeysImported bool `json:"keys_imported" example:"true"` // False if the server already had agents and kept its own keys
+}
+
+type APIImportResponse struct {
+	Status  string        `json:"status" example:"ok"`
+	Code    int           `json:"code" example:"200"`
+	Details string        `json:"details,omitempty" example:"import complete"`
+	Data    ImportSummary `json:"data"`
+}
@@ -0,0 +1,26 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import "time"
+
+// PatchUpdate is a pending operating system update
+type PatchUpdate struct {
+	Name    string `json:"name"`              // label, KB article, or package name used to install the update
+	Title   string `json:"title,omitempty"`   // description, if different from the name
+	Version string `json:"version,omitempty"` // version that will be installed, if known
+	Restart bool   `json:"restart,omitempty"` // true if installing the update requires a restart
+}
+
+// PatchStatus lists the operating system updates pending on an agent. It is returned by the
+// patch_status and patch_install commands, and the server keeps the most recent one for each agent.
+type PatchStatus struct {
+	Source         string        `json:"source"` // softwareupdate, windows_update, apt, or dnf
+	Collected      time.Time     `json:"collected"`
+	Count          int           `json:"count"`
+	RebootRequired bool          `json:"reboot_required"` // true if a restart is required to complete an installation
+	Updates        []PatchUpdate `json:"updates"`
+}
@@ -0,0 +1,25 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+// A payload downloaded by download_execute may carry a detached signature made with the
+// publisher's private key. The signature covers the hash of the file rather than the file
+// itself so that the agent only reads the file once.
+
+// Payload verification results reported in the download_execute response
+const (
+	PayloadVerified    = "verified"
+	PayloadFailed      = "failed"
+	PayloadNotProvided = "not provided"
+	PayloadSkipped     = "skipped" // hash verification is disabled on the agent
+)
+
+// PayloadSignatureData returns the data the publisher signs for a payload. The hash is the
+// base64 hash of the file using the named algorithm. Including the algorithm prevents a
+// signature for one algorithm being presented with another.
+func PayloadSignatureData(alg, hash string) []byte {
+	return []byte("uem-payload\n" + alg + "\n" + hash)
+}
@@ -0,0 +1,56 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// A TLS pin identifies the server certificate, or any certificate in its chain, and is
+// either the base64 SHA-256 hash of the public key (SPKI), optionally prefixed with
+// "sha256/", or the hex SHA-256 fingerprint of the certificate with optional colons.
+
+// normalizePin removes the optional prefix and separators from a pin
+func normalizePin(pin string) string {
+	pin = strings.TrimPrefix(strings.TrimSpace(pin), "sha256/")
+	return strings.ReplaceAll(pin, ":", "")
+}
+
+// ValidTLSPin returns true if the pin is in one of the supported formats
+func ValidTLSPin(pin string) bool {
+	pin = normalizePin(pin)
+	if b, err := hex.DecodeString(pin); err == nil && len(b) == sha256.Size {
+		return true
+	}
+	b, err := base64.StdEncoding.DecodeString(pin)
+	return err == nil && len(b) == sha256.Size
+}
+
+// TLSPinMatches returns true if the pin identifies the certificate
+func TLSPinMatches(cert *x509.Certificate, pin string) bool {
+	pin = normalizePin(pin)
+	if pin == "" || cert == nil {
+		return false
+	}
+
+	spki := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	if pin == base64.StdEncoding.EncodeToString(spki[:]) {
+		return true
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+	return strings.EqualFold(pin, hex.EncodeToString(fingerprint[:]))
+}
+
+// TLSPinData returns the data the server signs when it offers the next pin. Including the
+// current pin ties the signature to a rotation from that pin.
+func TLSPinData(current, next string) []byte {
+	return []byte("uem-tls-pin\n" + normalizePin(current) + "\n" + normalizePin(next))
+}
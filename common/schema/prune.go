@@ -0,0 +1,26 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+// PruneSummary reports the results of pruning the database
+type PruneSummary struct {
+	Agents            int     `json:"agents" example:"2"`               // Inactive agents deleted
+	Events            int     `json:"events" example:"3400"`            // Events deleted
+	LoginAudit        int     `json:"login_audit" example:"45"`         // Login audit records deleted
+	RequestsCompleted int     `json:"requests_completed" example:"120"` // Completed and cancelled requests deleted
+	RequestsFailed    int     `json:"requests_failed" example:"8"`      // Failed, invalid, and expired requests deleted
+	Compacted         bool    `json:"compacted" example:"false"`        // True if the database was compacted to return free space
+	SizeBefore        int64   `json:"size_before" example:"4194304"`    // Database size in bytes before pruning
+	SizeAfter         int64   `json:"size_after" example:"4194304"`     // Database size in bytes after pruning
+	Seconds           float64 `json:"seconds" example:"0.42"`
+}
+
+type APIPruneResponse struct {
+	Status  string       `json:"status" example:"ok"`
+	Code    int          `json:"code" example:"200"`
+	Details string       `json:"details,omitempty" example:"database pruned"`
+	Data    PruneSummary `json:"data"`
+}
@@ -5,31 +5,106 @@
 
 package schema
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 const (
 	RequestStatusNew       = "new"
 	RequestStatusPending   = "pending"
 	RequestStatusComplete  = "complete"
 	RequestStatusFailed    = "failed"
+	RequestStatusRefused   = "refused" // the agent's command policy does not allow the request
 	RequestStatusInvalid   = "invalid"
 	RequestStatusCancelled = "cancelled"
+	RequestStatusExpired   = "expired"
+	RequestStatusActive    = "active" // a tag request that is still being delivered to agents
+)
+
+// Requests for the commands in the server's approval_commands setting are not sent until a
+// second administrator approves them
+const (
+	RequestStatusAwaitingApproval = "awaiting_approval"
+	RequestStatusDenied           = "denied"
 )
 
+// AgentRequestRecord tracks a request through its lifecycle. TimeSent is when the request was
+// first sent to the agent, TimeAcknowledged is when the agent's response was received, and
+// TimeCompleted is when the request reached a final status. Success and ResponseDetails are
+// copied from the agent's response. Responses to sensitive commands are stored encrypted in
+// EncryptedResponse, which is never returned by the API, and are decrypted when the record is read.
+// Reviewer is the administrator who approved or denied a request that required approval.
+//
+// A tag request has a Tag instead of an AgentID. Until it Expires, it is delivered to each agent
+// that carries the tag when the agent syncs, by creating a record for the agent with the tag
+// request as its ParentID. Deliveries maps each agent to its record, so that each agent receives
+// the request once. Outcomes is not stored, and is added when a tag request is read.
 type AgentRequestRecord struct {
-	AgentID         string            `json:"agent_id"`
-	RequestID       string            `json:"request_id"`
-	Request         string            `json:"agent"`
-	Requester       string            `json:"requester"`
-	AckRequired     bool              `json:"ack_required"`
-	Parameters      map[string]string `json:"parameters"`
-	Status          string            `json:"status"`
-	TimeCreated     time.Time         `json:"time_created"`
-	LastUpdated     time.Time         `json:"last_updated"`
-	SendCount       int               `json:"send_count"`
-	ResponseDetails string            `json:"response_details"`
-	ResponseData    any               `json:"response_data,omitempty"`
-	Cancelled       bool              `json:"cancelled"`
+	AgentID           string            `json:"agent_id"`
+	RequestID         string            `json:"request_id"`
+	Request           string            `json:"agent"`
+	Requester         string            `json:"requester"`
+	AckRequired       bool              `json:"ack_required"`
+	Parameters        map[string]string `json:"parameters"`
+	Status            string            `json:"status"`
+	TimeCreated       time.Time         `json:"time_created"`
+	TimeSent          time.Time         `json:"time_sent,omitzero"`
+	TimeAcknowledged  time.Time         `json:"time_acknowledged,omitzero"`
+	TimeCompleted     time.Time         `json:"time_completed,omitzero"`
+	LastUpdated       time.Time         `json:"last_updated"`
+	Reviewer          string            `json:"reviewer,omitempty"`
+	TimeReviewed      time.Time         `json:"time_reviewed,omitzero"`
+	SendCount         int               `json:"send_count"`
+	Success           *bool             `json:"success,omitempty"`
+	ResponseDetails   string            `json:"response_details"`
+	ResponseData      any               `json:"response_data,omitempty"`
+	ResponseEncrypted bool              `json:"response_encrypted,omitempty"`
+	EncryptedResponse string            `json:"encrypted_response,omitempty"`
+	Cancelled         bool              `json:"cancelled"`
+	Latency           *RequestLatency   `json:"latency,omitempty"`
+	Tag               string            `json:"tag,omitempty"`
+	ParentID          string            `json:"parent_id,omitempty"`
+	Deliveries        map[string]string `json:"deliveries,omitempty"`
+	Expires           time.Time         `json:"expires,omitzero"`
+	Outcomes          *TagOutcomes      `json:"outcomes,omitempty"`
+}
+
+// TagOutcomes summarizes the deliveries of a tag request. Agents is the number of agents that
+// currently carry the tag, and Statuses counts the deliveries by status.
+type TagOutcomes struct {
+	Agents    int            `json:"agents"`
+	Delivered int            `json:"delivered"`
+	Statuses  map[string]int `json:"statuses"`
+}
+
+// RequestLatency is the time taken by a ping request. QueueMs is from the request being created
+// until it was sent to the agent, AgentMs is from the agent receiving the request until it
+// responded, and RoundTripMs is from the request being created until the response arrived.
+// QueueMs and RoundTripMs use the server's clock and AgentMs the agent's monotonic clock, so
+// none are affected by clock skew. AgentReceived and AgentResponded are the agent's timestamps.
+type RequestLatency struct {
+	QueueMs        int64     `json:"queue_ms"`
+	AgentMs        int64     `json:"agent_ms"`
+	RoundTripMs    int64     `json:"round_trip_ms"`
+	AgentReceived  time.Time `json:"agent_received,omitzero"`
+	AgentResponded time.Time `json:"agent_responded,omitzero"`
+}
+
+// PingData is the data included in an agent's response to a ping
+type PingData struct {
+	Received  time.Time `json:"received"`
+	Responded time.Time `json:"responded"`
+	ElapsedMs int64     `json:"elapsed_ms"`
+}
+
+// ResponseEncryptedDetails is the response details of a sensitive command until it is decrypted
+const ResponseEncryptedDetails = "response encrypted"
+
+// ResponseBody is the part of a response to a sensitive command that is encrypted
+type ResponseBody struct {
+	Response string `json:"response"`
+	Data     any    `json:"data,omitempty"`
 }
 
 type AgentRequestRecordList struct {
@@ -42,3 +117,13 @@ func NewDBAgentRequest() AgentRequestRecord {
 		ResponseData: make(map[string]string),
 	}
 }
+
+// RedactParameters masks passwords and tokens, such as password, admin_password, and
+// migration_token, in request parameters
+func RedactParameters(parameters map[string]string) {
+	for name := range parameters {
+		if name == "password" || strings.HasSuffix(name, "_password") || strings.HasSuffix(name, "_token") {
+			parameters[name] = "********"
+		}
+	}
+}
@@ -5,6 +5,11 @@
 
 package schema
 
+import (
+	"strconv"
+	"strings"
+)
+
 //goland:noinspection GoUnusedConst
 const (
 	RoleNone = iota
@@ -14,8 +19,36 @@ const (
 	RoleAuditor
 	RoleAdmin
 	RoleSuperAdmin
+	RoleReadOnly // Read-only operator (e.g., helpdesk) that may view but not change anything
 )
 
 var (
-	RolesAll = []int{RoleTest, RoleAgent, RoleUser, RoleAuditor, RoleAdmin, RoleSuperAdmin}
+	RolesAll = []int{RoleTest, RoleAgent, RoleUser, RoleAuditor, RoleAdmin, RoleSuperAdmin, RoleReadOnly}
 )
+
+// roleNames maps the names accepted by the API to roles that may be assigned to login accounts
+var roleNames = map[string]int{
+	"readonly":   RoleReadOnly,
+	"admin":      RoleAdmin,
+	"superadmin": RoleSuperAdmin,
+}
+
+// RoleByName returns the role corresponding to name or RoleNone if it is not recognized
+func RoleByName(name string) int {
+	role, ok := roleNames[strings.ToLower(name)]
+	if !ok {
+		return RoleNone
+	}
+	return role
+}
+
+// RoleName returns the name of a role that may be assigned to login accounts, or the role
+// number for other roles
+func RoleName(role int) string {
+	for name, r := range roleNames {
+		if r == role {
+			return name
+		}
+	}
+	return strconv.Itoa(role)
+}
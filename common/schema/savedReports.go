@@ -0,0 +1,82 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Saved report output formats
+const (
+	SavedReportCSV  = "csv"  // emailed as an attachment
+	SavedReportHTML = "html" // emailed as a table in the message
+)
+
+// MaxSavedReportNameLength is the longest saved report name accepted
+const MaxSavedReportNameLength = 64
+
+// Saved report names are used in file names, so spaces are not permitted
+var validSavedReportName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidSavedReportName returns true if the saved report name is acceptable
+func ValidSavedReportName(name string) bool {
+	return len(name) <= MaxSavedReportNameLength && validSavedReportName.MatchString(name)
+}
+
+// SavedReportKey returns the key used to store and look up a saved report
+func SavedReportKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// SavedReport is a report definition that is run on demand or on a schedule. Each run writes
+// a copy to the reports directory and emails it to the recipients, if any.
+type SavedReport struct {
+	Name       string            `json:"name" example:"weekly-compliance"`
+	Report     string            `json:"report" example:"compliance"` // report type
+	Parameters map[string]string `json:"args,omitempty"`              // passed to the report
+	Format     string            `json:"format" example:"csv"`        // csv or html
+	Schedule   string            `json:"schedule,omitempty" example:"0 8 * * mon"`
+	Email      []string          `json:"email,omitempty"`
+	Creator    string            `json:"creator"`
+	Created    time.Time         `json:"created"`
+	Updated    time.Time         `json:"updated"`
+	NextRun    time.Time         `json:"next_run,omitzero"`
+	LastRun    time.Time         `json:"last_run,omitzero"`
+	LastResult string            `json:"last_result,omitempty"` // "ok" or the reason the last run failed
+	LastFile   string            `json:"last_file,omitempty"`   // copy written by the last run
+}
+
+// SavedReportRequest creates or replaces a saved report. Schedule is a cron expression with
+// minute, hour, day of month, month, and day of week fields, evaluated in the server's time
+// zone, or one of @hourly, @daily, @weekly, or @monthly. If it is empty, the report is only
+// run on demand.
+type SavedReportRequest struct {
+	Name       string            `json:"name"`
+	Report     string            `json:"report"`
+	Parameters map[string]string `json:"args,omitempty"`
+	Format     string            `json:"format"`
+	Schedule   string            `json:"schedule,omitempty"`
+	Email      []string          `json:"email,omitempty"`
+}
+
+// APISavedReportsResponse lists saved reports
+type APISavedReportsResponse struct {
+	Status  string        `json:"status"`
+	Code    int           `json:"code"`
+	Details string        `json:"details,omitempty"`
+	Reports []SavedReport `json:"reports"`
+}
+
+// APISavedReportRunResponse is returned when a saved report is run on demand. Email is
+// delivered in the background, so failures are reported as server events.
+type APISavedReportRunResponse struct {
+	Status  string `json:"status"`
+	Code    int    `json:"code"`
+	Details string `json:"details,omitempty"`
+	File    string `json:"file,omitempty"` // name of the copy in the reports directory
+}
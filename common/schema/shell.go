@@ -0,0 +1,48 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+// Remote shell sessions are relayed by the server. The administrator's client and the agent
+// each poll the server, sending any data they have and receiving any data waiting for them.
+
+// ShellAdminIO is sent by the administrator's client with keyboard input and the terminal size
+type ShellAdminIO struct {
+	Input []byte `json:"input,omitempty"`
+	Rows  int    `json:"rows,omitempty"`
+	Cols  int    `json:"cols,omitempty"`
+}
+
+// APIShellResponse is returned to the administrator's client when a session is opened and
+// in response to each ShellAdminIO
+type APIShellResponse struct {
+	Status    string `json:"status" example:"ok"`
+	Code      int    `json:"code" example:"200"`
+	Details   string `json:"details,omitempty"`
+	SessionID string `json:"session_id" example:"S-6f9dcb2e-2e1b-4c3a-8a67-5b3e0d740df6"`
+	RequestID string `json:"request_id,omitempty"`
+	Output    []byte `json:"output,omitempty"`
+	Connected bool   `json:"connected"`        // the agent has started the shell
+	Closed    bool   `json:"closed"`           // the session has ended and all output has been delivered
+	Reason    string `json:"reason,omitempty"` // why the session ended
+}
+
+// ShellAgentIO is sent by the agent with output from the shell
+type ShellAgentIO struct {
+	Output []byte `json:"output,omitempty"`
+	Closed bool   `json:"closed,omitempty"` // the shell has exited
+	Reason string `json:"reason,omitempty"`
+}
+
+// APIShellAgentResponse is returned to the agent in response to each ShellAgentIO
+type APIShellAgentResponse struct {
+	Status  string `json:"status" example:"ok"`
+	Code    int    `json:"code" example:"200"`
+	Details string `json:"details,omitempty"`
+	Input   []byte `json:"input,omitempty"`
+	Rows    int    `json:"rows,omitempty"` // the terminal size, if it has changed
+	Cols    int    `json:"cols,omitempty"`
+	Close   bool   `json:"close,omitempty"` // the agent should end the session
+}
@@ -0,0 +1,357 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusTimeFormat is the format of times in the status details
+const StatusTimeFormat = "2006-01-02T15:04:05-07:00"
+
+// StatusDetails is the status reported by an agent. Items that were not reported, or that could
+// not be determined, are nil or empty. Sizes are in bytes and delays are in seconds.
+//
+// The details are sent by the agent and stored by the server as a map of strings, with the keys
+// used before they were typed, so that agents and servers of different versions can exchange
+// them. Older agents send "yes", "no", and "unknown", and may send numbers in different formats.
+type StatusDetails struct {
+	UEMAgent           string
+	Collected          time.Time
+	OS                 string
+	OSVersion          string
+	Hostname           string
+	IP                 string // comma separated
+	Firewall           *bool
+	Antivirus          *bool
+	AutoUpdates        *bool
+	FullDiskEncryption *bool
+	Password           *bool
+	ScreenLock         *bool
+	ScreenLockDelay    *int
+	LastUser           string
+	BootTime           time.Time
+	ServiceAccount     string // "yes", or "no" followed by the reason
+	StartReason        string // only in the first status after the agent starts
+
+	// Hardware inventory
+	DiskTotal    *int64
+	DiskFree     *int64
+	Memory       *int64
+	CPUModel     string
+	SerialNumber string
+	HardwareUUID string
+	ChassisType  string
+
+	// Network context, reported only while lost mode is active
+	Lost       bool
+	WiFiSSID   string
+	GatewayMAC string
+
+	// macOS security
+	MDMEnrolled     *bool
+	ActivationLock  *bool
+	SystemIntegrity *bool
+
+	// Linux security
+	AccessControl   string
+	SSHPasswordAuth *bool
+	UpdatesRecent   *bool
+	RebootRequired  *bool
+
+	// Other holds items that do not have a field, such as those added by newer agents, and the
+	// value of items that could not be parsed, such as "unknown" or "n/a"
+	Other map[string]string
+}
+
+// statusField maps a key in the status details to a field of StatusDetails
+type statusField struct {
+	key    string
+	format func(d *StatusDetails) string             // "" if the field is not set
+	parse  func(d *StatusDetails, value string) bool // false if the value is not valid
+}
+
+var statusFields = []statusField{
+	stringField("uem_agent", func(d *StatusDetails) *string { return &d.UEMAgent }),
+	timeField("collected", func(d *StatusDetails) *time.Time { return &d.Collected }),
+	stringField("os", func(d *StatusDetails) *string { return &d.OS }),
+	stringField("os_version", func(d *StatusDetails) *string { return &d.OSVersion }),
+	stringField("hostname", func(d *StatusDetails) *string { return &d.Hostname }),
+	stringField("ip", func(d *StatusDetails) *string { return &d.IP }),
+	boolField("firewall", func(d *StatusDetails) **bool { return &d.Firewall }),
+	boolField("antivirus", func(d *StatusDetails) **bool { return &d.Antivirus }),
+	boolField("auto_updates", func(d *StatusDetails) **bool { return &d.AutoUpdates }),
+	boolField("full_disk_encryption", func(d *StatusDetails) **bool { return &d.FullDiskEncryption }),
+	boolField("password", func(d *StatusDetails) **bool { return &d.Password }),
+	boolField("screen_lock", func(d *StatusDetails) **bool { return &d.ScreenLock }),
+	intField("screen_lock_delay", func(d *StatusDetails) **int { return &d.ScreenLockDelay }),
+	stringField("last_user", func(d *StatusDetails) *string { return &d.LastUser }),
+	timeField("boot_time", func(d *StatusDetails) *time.Time { return &d.BootTime }),
+	stringField("service_account", func(d *StatusDetails) *string { return &d.ServiceAccount }),
+	stringField(StatusStartReason, func(d *StatusDetails) *string { return &d.StartReason }),
+	sizeField(StatusDiskTotal, func(d *StatusDetails) **int64 { return &d.DiskTotal }),
+	sizeField(StatusDiskFree, func(d *StatusDetails) **int64 { return &d.DiskFree }),
+	sizeField(StatusMemory, func(d *StatusDetails) **int64 { return &d.Memory }),
+	stringField(StatusCPUModel, func(d *StatusDetails) *string { return &d.CPUModel }),
+	stringField(StatusSerialNumber, func(d *StatusDetails) *string { return &d.SerialNumber }),
+	stringField(StatusHardwareUUID, func(d *StatusDetails) *string { return &d.HardwareUUID }),
+	stringField(StatusChassisType, func(d *StatusDetails) *string { return &d.ChassisType }),
+	{
+		key: StatusLost,
+		format: func(d *StatusDetails) string {
+			if d.Lost {
+				return "true"
+			}
+			return ""
+		},
+		parse: func(d *StatusDetails, value string) bool {
+			lost, err := strconv.ParseBool(value)
+			d.Lost = lost
+			return err == nil
+		},
+	},
+	stringField(StatusWiFiSSID, func(d *StatusDetails) *string { return &d.WiFiSSID }),
+	stringField(StatusGatewayMAC, func(d *StatusDetails) *string { return &d.GatewayMAC }),
+	boolField(StatusMDMEnrolled, func(d *StatusDetails) **bool { return &d.MDMEnrolled }),
+	boolField(StatusActivationLock, func(d *StatusDetails) **bool { return &d.ActivationLock }),
+	boolField(StatusSystemIntegrity, func(d *StatusDetails) **bool { return &d.SystemIntegrity }),
+	stringField(StatusAccessControl, func(d *StatusDetails) *string { return &d.AccessControl }),
+	boolField(StatusSSHPasswordAuth, func(d *StatusDetails) **bool { return &d.SSHPasswordAuth }),
+	boolField(StatusUpdatesRecent, func(d *StatusDetails) **bool { return &d.UpdatesRecent }),
+	boolField(StatusRebootRequired, func(d *StatusDetails) **bool { return &d.RebootRequired }),
+}
+
+// statusFieldIndex finds the field for a key
+var statusFieldIndex = func() map[string]*statusField {
+	index := make(map[string]*statusField, len(statusFields))
+	for x := range statusFields {
+		index[statusFields[x].key] = &statusFields[x]
+	}
+	return index
+}()
+
+func stringField(key string, field func(d *StatusDetails) *string) statusField {
+	return statusField{
+		key:    key,
+		format: func(d *StatusDetails) string { return *field(d) },
+		parse: func(d *StatusDetails, value string) bool {
+			if value == "unknown" {
+				*field(d) = ""
+				return false
+			}
+			*field(d) = value
+			return true
+		},
+	}
+}
+
+func boolField(key string, field func(d *StatusDetails) **bool) statusField {
+	return statusField{
+		key: key,
+		format: func(d *StatusDetails) string {
+			if *field(d) == nil {
+				return ""
+			}
+			return YesNo(**field(d))
+		},
+		parse: func(d *StatusDetails, value string) bool {
+			var b bool
+			switch strings.ToLower(strings.TrimSpace(value)) {
+			case "yes", "true":
+				b = true
+			case "no", "false":
+				b = false
+			default:
+				*field(d) = nil
+				return false
+			}
+			*field(d) = &b
+			return true
+		},
+	}
+}
+
+func intField(key string, field func(d *StatusDetails) **int) statusField {
+	return statusField{
+		key: key,
+		format: func(d *StatusDetails) string {
+			if *field(d) == nil {
+				return ""
+			}
+			return strconv.Itoa(**field(d))
+		},
+		parse: func(d *StatusDetails, value string) bool {
+			n, ok := parseWhole(value)
+			if !ok || n > math.MaxInt32 || n < math.MinInt32 {
+				*field(d) = nil
+				return false
+			}
+			i := int(n)
+			*field(d) = &i
+			return true
+		},
+	}
+}
+
+func sizeField(key string, field func(d *StatusDetails) **int64) statusField {
+	return statusField{
+		key: key,
+		format: func(d *StatusDetails) string {
+			if *field(d) == nil {
+				return ""
+			}
+			return strconv.FormatInt(**field(d), 10)
+		},
+		parse: func(d *StatusDetails, value string) bool {
+			n, ok := parseWhole(value)
+			if !ok || n < 0 {
+				*field(d) = nil
+				return false
+			}
+			*field(d) = &n
+			return true
+		},
+	}
+}
+
+func timeField(key string, field func(d *StatusDetails) *time.Time) statusField {
+	return statusField{
+		key: key,
+		format: func(d *StatusDetails) string {
+			if field(d).IsZero() {
+				return ""
+			}
+			return field(d).Format(StatusTimeFormat)
+		},
+		parse: func(d *StatusDetails, value string) bool {
+			value = strings.TrimSpace(value)
+			t, err := time.Parse(StatusTimeFormat, value)
+			if err != nil {
+				t, err = time.Parse(time.RFC3339, value)
+			}
+			if err != nil {
+				// The format of 'uptime -s' on Linux, in local time
+				t, err = time.ParseInLocation(time.DateTime, value, time.Local)
+			}
+			*field(d) = t
+			return err == nil
+		},
+	}
+}
+
+// parseWhole parses a whole number. Older servers stored numbers received as JSON numbers in
+// the form "300.000000".
+func parseWhole(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, true
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f != math.Trunc(f) || math.Abs(f) > math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// YesNo returns "yes" or "no"
+func YesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// NewStatusDetails returns empty status details
+func NewStatusDetails() StatusDetails {
+	return StatusDetails{Other: make(map[string]string)}
+}
+
+// Set sets a status item from its string value. A value that is not valid for the item, such as
+// "unknown", clears the field and is kept in Other so that it is still reported.
+func (d *StatusDetails) Set(key, value string) {
+	field, ok := statusFieldIndex[key]
+	if ok && field.parse(d, value) {
+		delete(d.Other, key)
+		return
+	}
+
+	if d.Other == nil {
+		d.Other = make(map[string]string)
+	}
+	d.Other[key] = value
+}
+
+// Get returns the string value of a status item, or "" if it was not reported
+func (d *StatusDetails) Get(key string) string {
+	if field, ok := statusFieldIndex[key]; ok {
+		if value := field.format(d); value != "" {
+			return value
+		}
+	}
+	return d.Other[key]
+}
+
+// Merge sets the items reported in other
+func (d *StatusDetails) Merge(other StatusDetails) {
+	for key, value := range other.Map() {
+		d.Set(key, value)
+	}
+}
+
+// Map returns the status items as a map of strings, as sent by the agent
+func (d *StatusDetails) Map() map[string]string {
+	m := make(map[string]string, len(statusFields)+len(d.Other))
+	for key, value := range d.Other {
+		m[key] = value
+	}
+	for x := range statusFields {
+		if value := statusFields[x].format(d); value != "" {
+			m[statusFields[x].key] = value
+		}
+	}
+	return m
+}
+
+// MarshalJSON encodes the status items as a map of strings
+func (d StatusDetails) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Map())
+}
+
+// UnmarshalJSON decodes a map of status items. Values that are not strings are accepted because
+// older agents may send them.
+func (d *StatusDetails) UnmarshalJSON(b []byte) error {
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	*d = NewStatusDetails()
+	for key, value := range m {
+		d.Set(key, statusString(value))
+	}
+	return nil
+}
+
+// statusString returns the string form of a status value decoded from JSON
+func statusString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
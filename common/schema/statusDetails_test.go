@@ -0,0 +1,77 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestStatusDetailsLegacy tests status details in the formats sent by older agents and stored
+// by older servers
+func TestStatusDetailsLegacy(t *testing.T) {
+	var d StatusDetails
+	err := json.Unmarshal([]byte(`{
+		"firewall": "yes", "antivirus": "no", "password": "unknown", "screen_lock": "n/a",
+		"screen_lock_delay": "300.000000", "disk_total": 500107862016, "lost": true,
+		"boot_time": "2026-10-01T08:30:00-04:00", "hostname": "lab-01", "custom": "value"}`), &d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Firewall == nil || !*d.Firewall || d.Antivirus == nil || *d.Antivirus {
+		t.Errorf("unexpected firewall %v and antivirus %v", d.Firewall, d.Antivirus)
+	}
+	if d.Password != nil || d.ScreenLock != nil {
+		t.Errorf("expected unknown values to be nil, got %v and %v", d.Password, d.ScreenLock)
+	}
+	if d.ScreenLockDelay == nil || *d.ScreenLockDelay != 300 {
+		t.Errorf("unexpected screen lock delay %v", d.ScreenLockDelay)
+	}
+	if d.DiskTotal == nil || *d.DiskTotal != 500107862016 {
+		t.Errorf("unexpected disk total %v", d.DiskTotal)
+	}
+	if !d.Lost || d.Hostname != "lab-01" {
+		t.Errorf("unexpected lost %v and hostname %q", d.Lost, d.Hostname)
+	}
+	if !d.BootTime.Equal(time.Date(2026, 10, 1, 12, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected boot time %v", d.BootTime)
+	}
+
+	// Values that could not be parsed and unknown keys are sent as they were received
+	expected := map[string]string{
+		"firewall": "yes", "antivirus": "no", "password": "unknown", "screen_lock": "n/a",
+		"screen_lock_delay": "300", "disk_total": "500107862016", "lost": "true",
+		"boot_time": "2026-10-01T08:30:00-04:00", "hostname": "lab-01", "custom": "value"}
+	m := d.Map()
+	if len(m) != len(expected) {
+		t.Errorf("expected %d items, got %v", len(expected), m)
+	}
+	for key, want := range expected {
+		if m[key] != want {
+			t.Errorf("expected %s=%q, got %q", key, want, m[key])
+		}
+	}
+}
+
+// TestStatusDetailsSet tests that a value replaces one that could not be parsed
+func TestStatusDetailsSet(t *testing.T) {
+	d := NewStatusDetails()
+	d.Set("firewall", "unknown")
+	if d.Get("firewall") != "unknown" {
+		t.Errorf("expected unknown, got %q", d.Get("firewall"))
+	}
+
+	d.Set("firewall", "no")
+	if d.Firewall == nil || *d.Firewall || d.Get("firewall") != "no" || len(d.Other) != 0 {
+		t.Errorf("unexpected firewall %v, %q, %v", d.Firewall, d.Get("firewall"), d.Other)
+	}
+
+	if d.Get(StatusMDMEnrolled) != "" {
+		t.Errorf("expected an unreported item to be empty, got %q", d.Get(StatusMDMEnrolled))
+	}
+}
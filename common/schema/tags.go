@@ -0,0 +1,48 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Tags are stored in lower case so that they match regardless of how they are entered.
+// Because tags are used in URL paths, they are limited to characters that do not need
+// to be escaped. "all" is reserved to select all agents.
+
+const MaxTagLength = 64
+
+var validTag = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]*$`)
+
+// NormalizeTag returns the tag trimmed and in lower case, or an error if it is not acceptable
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", errors.New("tag cannot be empty")
+	}
+
+	if len(tag) > MaxTagLength {
+		return "", fmt.Errorf("tag %s exceeds %d characters", tag, MaxTagLength)
+	}
+
+	if tag == "all" {
+		return "", errors.New("tag all is reserved")
+	}
+
+	if !validTag.MatchString(tag) {
+		return "", fmt.Errorf("invalid tag %q: tags may only contain letters, numbers, and . _ : -", tag)
+	}
+	return tag, nil
+}
+
+// TagCount is the number of agents with a tag
+type TagCount struct {
+	Tag    string `json:"tag"`
+	Agents int    `json:"agents"`
+}
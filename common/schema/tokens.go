@@ -9,3 +9,17 @@ const (
 	TokenPurposeAccess  = "access"
 	TokenPurposeRefresh = "refresh"
 )
+
+// MigrationToken is a registration token for agents moving from another server. Agents that
+// register with it keep their agent ID.
+type MigrationToken struct {
+	Token   string `json:"token" example:"eyJzIjoiaHR0cHM6Ly91ZW0uZXhhbXBsZS5jb20iLCJ0IjoiLi4uIn0="` // Encoded like a registration token
+	Expires int64  `json:"expires" example:"1767225600"`                                             // Unix time
+}
+
+type APIMigrationTokenResponse struct {
+	Status  string         `json:"status" example:"ok"`
+	Code    int            `json:"code" example:"200"`
+	Details string         `json:"details,omitempty" example:"migration token created"`
+	Data    MigrationToken `json:"data"`
+}
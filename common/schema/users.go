@@ -12,6 +12,7 @@ type UserMeta struct {
 	User        string    `json:"user" example:"alice"`
 	DisplayName string    `json:"display_name,omitempty" example:"Alice Smith"`
 	Email       string    `json:"email" example:"alice@example.com"`
+	Role        string    `json:"role,omitempty" example:"readonly"` // Login role, if the user may log in
 	CreatedAt   time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
 	LastUpdated time.Time `json:"last_updated" example:"2023-01-01T00:00:00Z"`
 }
@@ -24,11 +25,14 @@ type UserList struct {
 	Code   int        `json:"code" example:"200"`
 }
 
-// UserCreateRequest is used to create a new user.
+// UserCreateRequest is used to create a new user. If a password is supplied,
+// a login account with the specified role ("readonly" or "admin") is also created.
 type UserCreateRequest struct {
 	User        string `json:"user"`
 	DisplayName string `json:"display_name,omitempty"`
 	Email       string `json:"email"`
+	Role        string `json:"role,omitempty"`
+	Password    string `json:"password,omitempty"`
 }
 
 // UserCreateResponse is returned after creating a user.
@@ -0,0 +1,158 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+// Package semver parses and compares agent versions. A version consists of up to
+// three numeric components, an optional pre-release suffix, and an optional build
+// number, for example "0.0.60", "v1.2.0-beta", or "0.0.60+108".
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string
+	Build      int // 0 if not specified
+}
+
+// Parse parses a version string
+func Parse(s string) (Version, error) {
+	var v Version
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if s == "" {
+		return v, errors.New("version is empty")
+	}
+
+	// Build number
+	if core, build, ok := strings.Cut(s, "+"); ok {
+		b, err := strconv.Atoi(build)
+		if err != nil || b < 0 {
+			return v, fmt.Errorf("invalid build number: %s", build)
+		}
+		v.Build = b
+		s = core
+	}
+
+	// Pre-release suffix
+	if core, pre, ok := strings.Cut(s, "-"); ok {
+		if pre == "" {
+			return v, errors.New("pre-release is empty")
+		}
+		v.PreRelease = pre
+		s = core
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version: %s", s)
+	}
+
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version component: %s", part)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// New returns a Version from a version string and build number, such as
+// the Version and Build values reported by agents
+func New(version string, build int) (Version, error) {
+	v, err := Parse(version)
+	if err != nil {
+		return v, err
+	}
+	if build > 0 {
+		v.Build = build
+	}
+	return v, nil
+}
+
+// Compare returns -1 if v is older than o, 0 if they are the same, and 1 if v is newer.
+// A pre-release is older than the corresponding release. Build numbers are only
+// compared if both versions specify one.
+func (v Version) Compare(o Version) int {
+	for _, c := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if c[0] != c[1] {
+			return sign(c[0] - c[1])
+		}
+	}
+
+	switch {
+	case v.PreRelease == "" && o.PreRelease != "":
+		return 1
+	case v.PreRelease != "" && o.PreRelease == "":
+		return -1
+	case v.PreRelease != o.PreRelease:
+		return comparePreRelease(v.PreRelease, o.PreRelease)
+	}
+
+	if v.Build > 0 && o.Build > 0 {
+		return sign(v.Build - o.Build)
+	}
+	return 0
+}
+
+// Less returns true if v is older than o
+func (v Version) Less(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	if v.Build > 0 {
+		s += "+" + strconv.Itoa(v.Build)
+	}
+	return s
+}
+
+// comparePreRelease compares dot-separated pre-release identifiers. Numeric
+// identifiers are compared numerically and are lower than alphanumeric ones.
+func comparePreRelease(a, b string) int {
+	ap := strings.Split(a, ".")
+	bp := strings.Split(b, ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		an, aErr := strconv.Atoi(ap[i])
+		bn, bErr := strconv.Atoi(bp[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(ap[i], bp[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(ap) - len(bp))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
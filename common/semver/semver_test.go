@@ -0,0 +1,60 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package semver
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.0.60", "0.0.60", 0},
+		{"0.0.9", "0.0.10", -1},
+		{"0.10.0", "0.9.99", 1},
+		{"1.0", "1.0.0", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.0.0-beta", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"0.0.60+107", "0.0.60+108", -1},
+		{"0.0.60+108", "0.0.60", 0},
+		{"0.0.61+1", "0.0.60+108", 1},
+	}
+
+	for _, tt := range tests {
+		a, err := Parse(tt.a)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.a, err)
+		}
+		b, err := Parse(tt.b)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.b, err)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{"", "1.2.3.4", "1.x", "1.2.3+", "1.2.3-", "-1.0"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) should fail", s)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	v, err := New("0.0.60", 108)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if v.String() != "0.0.60+108" {
+		t.Errorf("New = %s, want 0.0.60+108", v.String())
+	}
+}
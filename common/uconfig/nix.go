@@ -19,3 +19,7 @@ func (c *UConfig) saveRegistry() error {
 func (c *UConfig) loadRegistry() error {
 	return errors.New("registry not supported on this platform")
 }
+
+func (c *UConfig) deleteRegistry() error {
+	return errors.New("registry not supported on this platform")
+}
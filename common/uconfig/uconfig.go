@@ -79,10 +79,10 @@ func (c *UConfig) Save(filename string) error {
 	return c.saveFile()
 }
 
-// Delete the configuration file
+// Delete the configuration file or registry key
 func (c *UConfig) Delete(filename string) error {
 	if c.windowsRegistry {
-		return nil
+		return c.deleteRegistry()
 	}
 
 	if filename != "" {
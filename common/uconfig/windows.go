@@ -63,6 +63,23 @@ func (c *UConfig) loadRegistry() error {
 	return nil
 }
 
+// deleteRegistry removes the configuration registry key
+func (c *UConfig) deleteRegistry() error {
+
+	if c.windowsRegistryKey == "" {
+		return fmt.Errorf("windows registry key not set")
+	}
+
+	// Create path
+	rPath := regPrefix + c.windowsRegistryKey
+
+	err := registry.DeleteKey(registry.LOCAL_MACHINE, rPath)
+	if err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return fmt.Errorf("failed to delete registry key %s: %v", rPath, err)
+	}
+	return nil
+}
+
 func (c *UConfig) setRegistry(key string, value string) error {
 
 	if c.windowsRegistryKey == "" {
@@ -38,9 +38,10 @@ func (s *Service) start() error {
 	}
 
 	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	s.logger.Infof(s.SEid+1, "%s %s (build %d) service started", s.ServiceName, s.ServiceVersion, s.ServiceBuild)
+	s.startInfo = s.detectStart(nil)
+	s.logger.Infof(s.SEid+1, "%s %s (build %d) service started, reason: %s", s.ServiceName, s.ServiceVersion, s.ServiceBuild, s.startInfo)
 	s.logger.Debugf(s.SEid+1, "Debug logging enabled")
 
 	if s.BackgroundFunc != nil {
@@ -74,7 +75,11 @@ func (s *Service) start() error {
 			if s.TasksFunc != nil {
 				s.TasksFunc(s.logger)
 			}
-		case <-signalChan:
+		case sig := <-signalChan:
+			if sig == syscall.SIGHUP {
+				s.reload()
+				continue
+			}
 			s.logger.Infof(s.SEid+2, "%s %s (build %d) service stopping", s.ServiceName, s.ServiceVersion, s.ServiceBuild)
 			if s.StopFunc != nil {
 				s.StopFunc(s.logger)
@@ -21,7 +21,9 @@ type Service struct {
 	TasksFunc      func(interfaces.Logger)
 	StartFunc      func(interfaces.Logger)
 	StopFunc       func(interfaces.Logger)
+	ReloadFunc     func(interfaces.Logger)
 	SEid           uint32
+	startInfo      StartInfo
 	tickerStop     chan struct{}
 	tickerUpdate   chan time.Duration
 }
@@ -37,6 +39,7 @@ func New(options ...func(*Service) error) (*Service, error) {
 		ServiceVersion: "unknown",
 		TaskTicker:     60,
 		SEid:           0,
+		startInfo:      StartInfo{Reason: StartUnknown},
 		tickerStop:     make(chan struct{}),
 		tickerUpdate:   make(chan time.Duration),
 	}
@@ -129,6 +132,17 @@ func WithStopFunc(f func(interfaces.Logger)) func(*Service) error {
 	}
 }
 
+// WithReloadFunc sets a function that is called when the service is asked to reload its
+// configuration, by SIGHUP or, on Windows, the paramchange service control
+//
+//goland:noinspection GoUnusedExportedFunction
+func WithReloadFunc(f func(interfaces.Logger)) func(*Service) error {
+	return func(s *Service) error {
+		s.ReloadFunc = f
+		return nil
+	}
+}
+
 //goland:noinspection GoUnusedExportedFunction
 func WithSEid(seid uint32) func(*Service) error {
 	return func(s *Service) error {
@@ -142,3 +156,13 @@ func (s *Service) UpdateTaskTicker(newInterval time.Duration) {
 	s.TaskTicker = newInterval
 	s.tickerUpdate <- newInterval
 }
+
+// reload calls the ReloadFunc, if set
+func (s *Service) reload() {
+	if s.ReloadFunc == nil {
+		s.logger.Info(s.SEid+6, "configuration reload is not supported, ignored", nil)
+		return
+	}
+	s.logger.Infof(s.SEid+6, "%s %s (build %d) reloading configuration", s.ServiceName, s.ServiceVersion, s.ServiceBuild)
+	s.ReloadFunc(s.logger)
+}
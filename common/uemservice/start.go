@@ -0,0 +1,58 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package uemservice
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reasons that the service was started
+const (
+	StartBoot    = "boot"    // started by the service manager when the system started
+	StartManual  = "manual"  // started by an administrator or another program
+	StartRestart = "restart" // restarted by the service manager after the service failed
+	StartUnknown = "unknown" // the service manager does not reveal the reason
+)
+
+// bootWindow is how long after the system starts that an automatic start is attributed to the boot
+const bootWindow = 5 * time.Minute
+
+// StartInfo describes how the service was started, as far as the service manager reveals it
+type StartInfo struct {
+	Reason   string // StartBoot, StartManual, StartRestart, or StartUnknown
+	Manager  string // "systemd", "launchd", or "scm", empty if not started by a service manager
+	Restarts int    // automatic restarts counted by the service manager, if it reports them
+}
+
+// String returns a description such as "restart (systemd, 2 restarts)"
+func (i StartInfo) String() string {
+	if i.Manager == "" {
+		return i.Reason
+	}
+	if i.Restarts > 0 {
+		return fmt.Sprintf("%s (%s, %d restarts)", i.Reason, i.Manager, i.Restarts)
+	}
+	return fmt.Sprintf("%s (%s)", i.Reason, i.Manager)
+}
+
+// StartInfo returns how the service was started. It is determined before the StartFunc is
+// called, so it is available to the StartFunc, TasksFunc, and BackgroundFunc.
+func (s *Service) StartInfo() StartInfo {
+	return s.startInfo
+}
+
+// bootOrManual returns StartBoot if the system started less than bootWindow ago and
+// StartManual otherwise
+func bootOrManual(uptime time.Duration, err error) string {
+	if err != nil {
+		return StartUnknown
+	}
+	if uptime < bootWindow {
+		return StartBoot
+	}
+	return StartManual
+}
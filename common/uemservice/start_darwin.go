@@ -0,0 +1,73 @@
+//go:build darwin
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package uemservice
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// detectStart determines how the service was started. launchd sets XPC_SERVICE_NAME to the
+// label of the job, and counts the times it has run the job since it was loaded.
+func (s *Service) detectStart(_ []string) StartInfo {
+	label := os.Getenv("XPC_SERVICE_NAME")
+	if label == "" || label == "0" {
+		return StartInfo{Reason: StartManual}
+	}
+
+	info := StartInfo{Reason: StartUnknown, Manager: "launchd"}
+	runs, exitCode := launchdJob(label)
+
+	// A job that has run before and did not exit cleanly was restarted by KeepAlive
+	if runs > 1 && exitCode != "" && exitCode != "0" {
+		info.Reason = StartRestart
+		info.Restarts = runs - 1
+		return info
+	}
+	info.Reason = bootOrManual(uptime())
+	return info
+}
+
+// launchdJob returns the number of times launchd has run the job and the exit code of the
+// previous run, as reported by launchctl print
+func launchdJob(label string) (int, string) {
+	out, err := exec.Command("launchctl", "print", "system/"+label).Output()
+	if err != nil {
+		return 0, ""
+	}
+
+	runs := 0
+	exitCode := ""
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), " = ")
+		if !found {
+			continue
+		}
+		switch key {
+		case "runs":
+			runs, _ = strconv.Atoi(value)
+		case "last exit code":
+			exitCode = value
+		}
+	}
+	return runs, exitCode
+}
+
+// uptime returns the time since the system started
+func uptime() (time.Duration, error) {
+	tv, err := unix.SysctlTimeval("kern.boottime")
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(time.Unix(tv.Unix())), nil
+}
@@ -0,0 +1,80 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package uemservice
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// detectStart determines how the service was started. systemd sets INVOCATION_ID for every
+// process it starts, and counts automatic restarts of the unit in NRestarts.
+func (s *Service) detectStart(_ []string) StartInfo {
+	if os.Getenv("INVOCATION_ID") == "" {
+		return StartInfo{Reason: StartManual}
+	}
+
+	info := StartInfo{Reason: StartUnknown, Manager: "systemd"}
+	if unit := systemdUnit(); unit != "" {
+		out, err := exec.Command("systemctl", "show", "--property=NRestarts", "--value", unit).Output()
+		if err == nil {
+			info.Restarts, _ = strconv.Atoi(strings.TrimSpace(string(out)))
+		}
+	}
+
+	if info.Restarts > 0 {
+		info.Reason = StartRestart
+		return info
+	}
+	info.Reason = bootOrManual(uptime())
+	return info
+}
+
+// systemdUnit returns the name of the unit that the process belongs to, or "" if it is unknown
+func systemdUnit() string {
+	f, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+
+	// Lines are in the form "0::/system.slice/uem-agent.service"
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if x := strings.LastIndex(line, ":"); x >= 0 {
+			if unit := path.Base(line[x+1:]); strings.HasSuffix(unit, ".service") {
+				return unit
+			}
+		}
+	}
+	return ""
+}
+
+// uptime returns the time since the system started
+func uptime() (time.Duration, error) {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, err
+	}
+	f := strings.Fields(string(data))
+	if len(f) == 0 {
+		return 0, errors.New("empty /proc/uptime")
+	}
+	seconds, err := strconv.ParseFloat(f[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
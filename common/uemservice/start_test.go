@@ -0,0 +1,46 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package uemservice
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStartInfo(t *testing.T) {
+	tests := []struct {
+		info     StartInfo
+		expected string
+	}{
+		{StartInfo{Reason: StartManual}, "manual"},
+		{StartInfo{Reason: StartBoot, Manager: "scm"}, "boot (scm)"},
+		{StartInfo{Reason: StartRestart, Manager: "systemd", Restarts: 2}, "restart (systemd, 2 restarts)"},
+	}
+	for _, tt := range tests {
+		if got := tt.info.String(); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+
+	if reason := bootOrManual(time.Minute, nil); reason != StartBoot {
+		t.Errorf("expected boot shortly after the system started, got %s", reason)
+	}
+	if reason := bootOrManual(time.Hour, nil); reason != StartManual {
+		t.Errorf("expected manual long after the system started, got %s", reason)
+	}
+	if reason := bootOrManual(0, errors.New("no uptime")); reason != StartUnknown {
+		t.Errorf("expected unknown without the uptime, got %s", reason)
+	}
+
+	s, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.StartInfo().Reason != StartUnknown {
+		t.Errorf("expected unknown before the service starts, got %s", s.StartInfo())
+	}
+}
@@ -0,0 +1,67 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package uemservice
+
+import (
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// detectStart determines how the service was started from the arguments passed by the service
+// control manager, the start type of the service, and the time since the system started. The
+// service control manager does not reveal whether it restarted the service after a failure.
+func (s *Service) detectStart(args []string) StartInfo {
+	info := StartInfo{Reason: StartUnknown, Manager: "scm"}
+
+	// The first argument is the service name. Others are only passed by "sc start".
+	if len(args) > 1 {
+		info.Reason = StartManual
+		return info
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return info
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	service, err := m.OpenService(s.ServiceName)
+	if err != nil {
+		return info
+	}
+	defer func() { _ = service.Close() }()
+
+	config, err := service.Config()
+	if err != nil {
+		return info
+	}
+
+	switch config.StartType {
+	case mgr.StartManual:
+		info.Reason = StartManual
+	case mgr.StartAutomatic:
+		// An automatic service started long after the system was either started manually
+		// or restarted by the recovery actions, which can not be distinguished
+		if reason := bootOrManual(uptime()); reason == StartBoot {
+			info.Reason = reason
+		}
+	}
+	return info
+}
+
+// uptime returns the time since the system started
+func uptime() (time.Duration, error) {
+	getTickCount64 := syscall.NewLazyDLL("kernel32.dll").NewProc("GetTickCount64")
+	if err := getTickCount64.Find(); err != nil {
+		return 0, err
+	}
+	ret, _, _ := getTickCount64.Call()
+	return time.Duration(ret) * time.Millisecond, nil
+}
@@ -28,10 +28,11 @@ func (s *Service) start() error {
 }
 
 // Execute runs the Windows service
-//
-//goland:noinspection GoUnusedParameter
 func (s *Service) Execute(args []string, req <-chan svc.ChangeRequest, status chan<- svc.Status) (svcSpecificEC bool, exitCode uint32) {
-	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown
+	cmdsAccepted := svc.AcceptStop | svc.AcceptShutdown
+	if s.ReloadFunc != nil {
+		cmdsAccepted |= svc.AcceptParamChange
+	}
 
 	status <- svc.Status{State: svc.StartPending}
 
@@ -39,7 +40,8 @@ func (s *Service) Execute(args []string, req <-chan svc.ChangeRequest, status ch
 		return false, 1
 	}
 
-	s.logger.Infof(s.SEid+1, "%s %s (build %d) service started", s.ServiceName, s.ServiceVersion, s.ServiceBuild)
+	s.startInfo = s.detectStart(args)
+	s.logger.Infof(s.SEid+1, "%s %s (build %d) service started, reason: %s", s.ServiceName, s.ServiceVersion, s.ServiceBuild, s.startInfo)
 	s.logger.Debugf(s.SEid+1, "Debug logging enabled")
 
 	status <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
@@ -63,6 +65,11 @@ func (s *Service) Execute(args []string, req <-chan svc.ChangeRequest, status ch
 		}
 	}()
 
+	// Call the start function if defined
+	if s.StartFunc != nil {
+		s.StartFunc(s.logger)
+	}
+
 loop:
 	for {
 		select {
@@ -75,6 +82,8 @@ loop:
 				}
 				close(s.tickerStop)
 				break loop
+			case svc.ParamChange:
+				s.reload()
 			default:
 			}
 		case <-ticker.C:
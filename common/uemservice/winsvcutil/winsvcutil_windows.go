@@ -0,0 +1,226 @@
+//go:build windows
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+// Package winsvcutil configures Windows services created by the agent and server installers
+package winsvcutil
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// SERVICE_FAILURE_ACTIONS structure
+//
+//goland:noinspection GoSnakeCaseUsage
+type SERVICE_FAILURE_ACTIONS struct {
+	DwResetPeriod uint32
+	LpRebootMsg   *uint16
+	LpCommand     *uint16
+	CActions      uint32
+	LpActions     *SC_ACTION
+}
+
+// SC_ACTION_TYPE constants
+//
+//goland:noinspection ALL
+const (
+	SC_ACTION_NONE        = 0
+	SC_ACTION_RESTART     = 1
+	SC_ACTION_REBOOT      = 2
+	SC_ACTION_RUN_COMMAND = 3
+)
+
+// SC_ACTION structure
+//
+//goland:noinspection GoSnakeCaseUsage
+type SC_ACTION struct {
+	Type  uint32
+	Delay uint32
+}
+
+// SERVICE_DESCRIPTION structure
+//
+//goland:noinspection GoSnakeCaseUsage
+type SERVICE_DESCRIPTION struct {
+	LpDescription *uint16
+}
+
+// SERVICE_DELAYED_AUTO_START_INFO structure
+//
+//goland:noinspection GoSnakeCaseUsage
+type SERVICE_DELAYED_AUTO_START_INFO struct {
+	FDelayedAutostart uint32
+}
+
+// ChangeServiceConfig2 information levels
+//
+//goland:noinspection ALL
+const (
+	SERVICE_CONFIG_DESCRIPTION             = 1
+	SERVICE_CONFIG_FAILURE_ACTIONS         = 2
+	SERVICE_CONFIG_DELAYED_AUTO_START_INFO = 3
+)
+
+// failureResetPeriod resets the failure count after 1 day (86400 seconds)
+const failureResetPeriod = 86400
+
+var changeServiceConfig2 = syscall.NewLazyDLL("advapi32.dll").NewProc("ChangeServiceConfig2W")
+
+// Configure sets the description, delayed automatic start, and failure actions for a service
+func Configure(serviceHandle windows.Handle, description string) error {
+	err := SetDescription(serviceHandle, description)
+	if err != nil {
+		return fmt.Errorf("could not set description: %w", err)
+	}
+
+	err = SetDelayedAutoStart(serviceHandle, true)
+	if err != nil {
+		return fmt.Errorf("could not set delayed start: %w", err)
+	}
+
+	err = SetFailureActions(serviceHandle)
+	if err != nil {
+		return fmt.Errorf("could not set failure actions: %w", err)
+	}
+	return nil
+}
+
+// SetFailureActions configures the service to restart if it fails
+func SetFailureActions(serviceHandle windows.Handle) error {
+	actions := []SC_ACTION{
+		{Type: SC_ACTION_RESTART, Delay: 10000}, // Restart the service after 10 seconds
+		{Type: SC_ACTION_RESTART, Delay: 10000}, // Restart the service after 10 seconds
+		{Type: SC_ACTION_RESTART, Delay: 60000}, // Restart the service after 60 seconds
+	}
+
+	// Prepare the SERVICE_FAILURE_ACTIONS structure
+	failureActions := SERVICE_FAILURE_ACTIONS{
+		DwResetPeriod: failureResetPeriod,
+		LpCommand:     nil,
+		LpRebootMsg:   nil,
+		CActions:      uint32(len(actions)),
+		LpActions:     &actions[0],
+	}
+
+	return changeConfig2(serviceHandle, SERVICE_CONFIG_FAILURE_ACTIONS, unsafe.Pointer(&failureActions))
+}
+
+// SetDelayedAutoStart configures an automatic start service to start after other automatic start services
+func SetDelayedAutoStart(serviceHandle windows.Handle, delayed bool) error {
+	info := SERVICE_DELAYED_AUTO_START_INFO{}
+	if delayed {
+		info.FDelayedAutostart = 1
+	}
+
+	return changeConfig2(serviceHandle, SERVICE_CONFIG_DELAYED_AUTO_START_INFO, unsafe.Pointer(&info))
+}
+
+// SetDescription sets the description shown in the services console
+func SetDescription(serviceHandle windows.Handle, description string) error {
+	p, err := windows.UTF16PtrFromString(description)
+	if err != nil {
+		return err
+	}
+
+	info := SERVICE_DESCRIPTION{LpDescription: p}
+	return changeConfig2(serviceHandle, SERVICE_CONFIG_DESCRIPTION, unsafe.Pointer(&info))
+}
+
+// RecoveryActions returns a description of the recovery actions configured for a service
+func RecoveryActions(service *mgr.Service) string {
+	actions, err := service.RecoveryActions()
+	if err != nil {
+		return fmt.Sprintf("error reading recovery actions: %v", err)
+	}
+
+	if len(actions) == 0 {
+		return "none"
+	}
+
+	var list []string
+	for _, action := range actions {
+		switch action.Type {
+		case mgr.ServiceRestart:
+			list = append(list, fmt.Sprintf("restart after %v", action.Delay))
+		case mgr.ComputerReboot:
+			list = append(list, fmt.Sprintf("reboot after %v", action.Delay))
+		case mgr.RunCommand:
+			list = append(list, fmt.Sprintf("run command after %v", action.Delay))
+		default:
+			list = append(list, "none")
+		}
+	}
+
+	result := strings.Join(list, ", ")
+
+	reset, err := service.ResetPeriod()
+	if err == nil {
+		result += fmt.Sprintf(" (failure count reset after %v)", time.Duration(reset)*time.Second)
+	}
+	return result
+}
+
+// WaitForState polls the service until it reaches the specified state or the timeout expires
+func WaitForState(service *mgr.Service, state svc.State, timeout time.Duration) (svc.Status, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := service.Query()
+		if err != nil {
+			return status, fmt.Errorf("error querying service status: %w", err)
+		}
+		if status.State == state {
+			return status, nil
+		}
+		if time.Now().After(deadline) {
+			return status, fmt.Errorf("service did not reach %s within %v", StateName(state), timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// StateName returns a lower case name for a service state
+func StateName(state svc.State) string {
+	switch state {
+	case svc.Stopped:
+		return "stopped"
+	case svc.StartPending:
+		return "start pending"
+	case svc.StopPending:
+		return "stop pending"
+	case svc.Running:
+		return "running"
+	case svc.ContinuePending:
+		return "continue pending"
+	case svc.PausePending:
+		return "pause pending"
+	case svc.Paused:
+		return "paused"
+	default:
+		return fmt.Sprintf("unknown (%d)", state)
+	}
+}
+
+// changeConfig2 calls ChangeServiceConfig2W with the specified information level
+func changeConfig2(serviceHandle windows.Handle, level uint32, info unsafe.Pointer) error {
+	r1, _, e1 := changeServiceConfig2.Call(
+		uintptr(serviceHandle),
+		uintptr(level),
+		uintptr(info),
+	)
+
+	if r1 == 0 {
+		return fmt.Errorf("ChangeServiceConfig2W failed: %v", e1)
+	}
+	return nil
+}
@@ -6,6 +6,11 @@
 package ulogger
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 )
 
@@ -15,9 +20,42 @@ var _ interfaces.Logger = (*UEMLogger)(nil)
 // Option is a function that configures a UEMLogger
 type Option func(*UEMLogger) error
 
+// Level is the lowest severity of message that is logged. Errors and fatal errors are always logged.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarning
+)
+
+var levelNames = map[Level]string{
+	LevelDebug:   "debug",
+	LevelInfo:    "info",
+	LevelWarning: "warning",
+}
+
+// String returns the name of the level
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLevel returns the level with the specified name
+func ParseLevel(name string) (Level, error) {
+	for level, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("invalid log level: %s", name)
+}
+
 // New creates a new instance of UEMLogger with the provided options
 func New(options ...Option) (interfaces.Logger, error) {
-	u := &UEMLogger{retainDays: 30}
+	u := &UEMLogger{retainDays: 30, maxFiles: defaultMaxFiles}
 
 	for _, option := range options {
 		if err := option(u); err != nil {
@@ -64,7 +102,21 @@ func WithWindowsEvents(logWindowsEvents bool) Option {
 // WithDebug enables or disables debug logging
 func WithDebug(debug bool) Option {
 	return func(u *UEMLogger) error {
-		u.debug = debug
+		u.level = LevelInfo
+		if debug {
+			u.level = LevelDebug
+		}
+		return nil
+	}
+}
+
+// WithLevel sets the lowest severity of message that is logged
+func WithLevel(level Level) Option {
+	return func(u *UEMLogger) error {
+		if _, ok := levelNames[level]; !ok {
+			return fmt.Errorf("invalid log level: %d", int(level))
+		}
+		u.level = level
 		return nil
 	}
 }
@@ -76,3 +128,107 @@ func WithRetention(retainDays int) Option {
 		return nil
 	}
 }
+
+// WithMaxSizeMB rotates the log file when it reaches the specified size in megabytes, in
+// addition to daily. Zero disables rotation by size.
+func WithMaxSizeMB(maxSizeMB int) Option {
+	return func(u *UEMLogger) error {
+		if maxSizeMB < 0 {
+			return fmt.Errorf("invalid maximum log size: %d", maxSizeMB)
+		}
+		u.maxSize = int64(maxSizeMB) * 1024 * 1024
+		return nil
+	}
+}
+
+// WithMaxFiles sets the number of log files rotated by size to keep. Older files are deleted,
+// regardless of the retention period.
+func WithMaxFiles(maxFiles int) Option {
+	return func(u *UEMLogger) error {
+		if maxFiles < 1 {
+			return fmt.Errorf("invalid number of log files: %d", maxFiles)
+		}
+		u.maxFiles = maxFiles
+		return nil
+	}
+}
+
+// Reconfigure applies options to a logger that is in use, such as when its configuration
+// changes. The outputs are reopened only if the log file or destinations changed.
+func (u *UEMLogger) Reconfigure(options ...Option) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	logfile, logStdout, logWindowsEvents := u.logfile, u.logStdout, u.logWindowsEvents
+	for _, option := range options {
+		if err := option(u); err != nil {
+			return err
+		}
+	}
+
+	if u.logfile != "" {
+		u.logfile = filepath.Clean(u.logfile)
+	}
+	if u.logfile == logfile && u.logStdout == logStdout && u.logWindowsEvents == logWindowsEvents {
+		return nil
+	}
+
+	// Reopen the outputs, falling back to stdout if the log file can't be opened
+	u.close()
+	if _, err := u.osNew(); err != nil {
+		u.logStdout = true
+		return err
+	}
+	return nil
+}
+
+// SetLevelOverride logs messages at the specified level instead of the configured level until
+// the duration has elapsed, or until the override is cleared if the duration is zero. The
+// override is kept if the logger is reconfigured.
+func (u *UEMLogger) SetLevelOverride(level Level, duration time.Duration) error {
+	if _, ok := levelNames[level]; !ok {
+		return fmt.Errorf("invalid log level: %d", int(level))
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.override = &level
+	u.overrideUntil = time.Time{}
+	if duration > 0 {
+		u.overrideUntil = time.Now().Add(duration)
+	}
+	return nil
+}
+
+// ClearLevelOverride returns to the configured level
+func (u *UEMLogger) ClearLevelOverride() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.override = nil
+}
+
+// Level returns the level in use, including any override
+func (u *UEMLogger) Level() Level {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.currentLevel()
+}
+
+// currentLevel returns the level in use. The override expires by comparison with the monotonic
+// clock, so changes to the system time do not affect it. The caller must hold u.mu.
+func (u *UEMLogger) currentLevel() Level {
+	if u.override != nil {
+		if u.overrideUntil.IsZero() || time.Now().Before(u.overrideUntil) {
+			return *u.override
+		}
+		u.override = nil
+	}
+	return u.level
+}
+
+// enabled returns true if messages of the specified level are logged
+func (u *UEMLogger) enabled(level Level) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return level >= u.currentLevel()
+}
@@ -0,0 +1,44 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package ulogger
+
+import (
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+)
+
+// The event source is registered with EventCreate.exe as its message file, which defines
+// messages 1-1000 that display the first insertion string. Each package uses a block of 1000
+// event IDs, so the block is reported as the Event Log category and the rest as the event ID.
+// For example, 2904 is event 904 in category 2. The full ID is also part of the message.
+
+// eventCategory returns the Event Log category for an event ID
+func eventCategory(eid uint32) uint16 {
+	return uint16(eid / 1000)
+}
+
+// eventID returns the Event Log event ID for an event ID, from 1 to 1000
+func eventID(eid uint32) uint32 {
+	id := eid % 1000
+	if id == 0 {
+		return 1000
+	}
+	return id
+}
+
+// eventStrings returns the insertion strings for an event: the formatted message, which is
+// displayed, followed by each field as name=value so that they can be filtered and exported
+func eventStrings(message string, fields interfaces.Fields) []string {
+	strings := []string{message}
+	if fields == nil {
+		return strings
+	}
+	for _, pair := range fields.ToPairs() {
+		strings = append(strings, fmt.Sprintf("%s=%v", pair.Name(), pair.Value()))
+	}
+	return strings
+}
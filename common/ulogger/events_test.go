@@ -0,0 +1,41 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package ulogger
+
+import (
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+)
+
+func TestEventIDs(t *testing.T) {
+	tests := []struct {
+		eid      uint32
+		category uint16
+		id       uint32
+	}{
+		{2904, 2, 904},
+		{8001, 8, 1},
+		{8000, 8, 1000},
+		{999, 0, 999},
+	}
+
+	for _, tt := range tests {
+		if category, id := eventCategory(tt.eid), eventID(tt.eid); category != tt.category || id != tt.id {
+			t.Errorf("%d: expected category %d and ID %d, got %d and %d", tt.eid, tt.category, tt.id, category, id)
+		}
+	}
+}
+
+func TestEventStrings(t *testing.T) {
+	s := eventStrings("message", fields.NewFields(fields.NewField("agent", "A-1"), fields.NewField("count", 2)))
+	if len(s) != 3 || s[0] != "message" || s[1] != "agent=A-1" || s[2] != "count=2" {
+		t.Errorf("unexpected event strings: %q", s)
+	}
+	if s = eventStrings("message", nil); len(s) != 1 {
+		t.Errorf("unexpected event strings: %q", s)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
@@ -19,13 +20,19 @@ import (
 
 // UEMLogger is different for eah OS because of the different loggers used
 type UEMLogger struct {
+	mu               sync.Mutex // guards the settings and outputs, which may be reconfigured
 	fileHandle       *os.File
 	logfile          string
 	logStdout        bool
 	logWindowsEvents bool // Ignored on non-Windows systems
-	debug            bool
+	level            Level
+	override         *Level    // temporary level set by SetLevelOverride
+	overrideUntil    time.Time // zero if the override does not expire
 	prefix           string
 	retainDays       int
+	maxSize          int64 // bytes, 0 to rotate only daily
+	maxFiles         int   // log files rotated by size to keep
+	size             int64 // size of the current log file
 	currentLogDate   string
 }
 
@@ -66,6 +73,7 @@ func (u *UEMLogger) osNew() (*UEMLogger, error) {
 			u.logStdout = true
 		} else {
 			u.fileHandle = fh
+			u.size = fileSize(fh)
 
 			// Attempt to set the file mode to 0644 on a best-effort basis
 			_ = os.Chmod(u.logfile, 0644)
@@ -79,9 +87,17 @@ func (u *UEMLogger) osNew() (*UEMLogger, error) {
 
 // Close closes the logger.
 func (u *UEMLogger) Close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.close()
+}
+
+// close closes the log file, the caller must hold u.mu
+func (u *UEMLogger) close() {
 	if u.fileHandle != nil {
 		_ = u.fileHandle.Sync()
 		_ = u.fileHandle.Close()
+		u.fileHandle = nil
 	}
 }
 
@@ -100,6 +116,8 @@ func (u *UEMLogger) formatMessage(eid uint32, level string, message string, fiel
 
 // writeLog writes a log message and handles rotation if necessary.
 func (u *UEMLogger) writeLog(eid uint32, level string, message string, fields interfaces.Fields) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
 
 	// Rotate logs if necessary
 	err := u.rotateLogs()
@@ -111,7 +129,8 @@ func (u *UEMLogger) writeLog(eid uint32, level string, message string, fields in
 
 	//  Write and flush
 	if u.fileHandle != nil {
-		_, _ = u.fileHandle.WriteString(tmp)
+		n, _ := u.fileHandle.WriteString(tmp)
+		u.size += int64(n)
 		_ = u.fileHandle.Sync()
 	}
 
@@ -122,12 +141,16 @@ func (u *UEMLogger) writeLog(eid uint32, level string, message string, fields in
 
 // Debug logs a debug message.
 func (u *UEMLogger) Debug(eid uint32, message string, fields interfaces.Fields) {
-	u.writeLog(eid, "DEBUG", message, fields)
+	if u.enabled(LevelDebug) {
+		u.writeLog(eid, "DEBUG", message, fields)
+	}
 }
 
 // Info logs an informational message.
 func (u *UEMLogger) Info(eid uint32, message string, fields interfaces.Fields) {
-	u.writeLog(eid, "INFO", message, fields)
+	if u.enabled(LevelInfo) {
+		u.writeLog(eid, "INFO", message, fields)
+	}
 }
 
 // Warning logs a warning message.
@@ -147,14 +170,18 @@ func (u *UEMLogger) Fatal(eid uint32, message string, fields interfaces.Fields)
 
 // Debugf logs a formatted debug message.
 func (u *UEMLogger) Debugf(eid uint32, format string, v ...any) {
-	message := fmt.Sprintf(format, v...)
-	u.writeLog(eid, "DEBUG", message, nil)
+	if u.enabled(LevelDebug) {
+		message := fmt.Sprintf(format, v...)
+		u.writeLog(eid, "DEBUG", message, nil)
+	}
 }
 
 // Infof logs a formatted informational message.
 func (u *UEMLogger) Infof(eid uint32, format string, v ...any) {
-	message := fmt.Sprintf(format, v...)
-	u.writeLog(eid, "INFO", message, nil)
+	if u.enabled(LevelInfo) {
+		message := fmt.Sprintf(format, v...)
+		u.writeLog(eid, "INFO", message, nil)
+	}
 }
 
 // Warningf logs a formatted warning message.
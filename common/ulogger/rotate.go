@@ -6,16 +6,22 @@
 package ulogger
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
-// rotateLogs handles log rotation and deletion of old logs
-func (u *UEMLogger) rotateLogs() error {
-	var err error
+// defaultMaxFiles is the number of log files rotated by size that are kept by default
+const defaultMaxFiles = 5
 
+// rotateLogs handles log rotation and deletion of old logs. The log file is rotated daily,
+// and when it reaches maxSize if that is set.
+func (u *UEMLogger) rotateLogs() error {
 	if u.logfile == "" {
 		return nil
 	}
@@ -23,49 +29,153 @@ func (u *UEMLogger) rotateLogs() error {
 	// Get the current date
 	currentDate := time.Now().Format("20060102")
 
-	// Check if the log file needs to be rotated
+	// Rename the current log file with the date it was written
 	if u.currentLogDate != currentDate {
+		return u.rotate(fmt.Sprintf("%s-%s", u.logfile, u.currentLogDate), currentDate, false)
+	}
 
-		// Capture the date of the current log file before rotation
-		previousLogDate := u.currentLogDate
+	// Rename the current log file with the time and compress it
+	if u.maxSize > 0 && u.size >= u.maxSize {
+		return u.rotate(u.sizeRotatedName(time.Now()), currentDate, true)
+	}
+	return nil
+}
 
-		// Close the current log file
-		if u.fileHandle != nil {
-			_ = u.fileHandle.Sync()
-			_ = u.fileHandle.Close()
+// sizeRotatedName returns the name for a log file rotated by size. If a file was already rotated
+// in the same second, the time is advanced so that names stay unique and in order.
+func (u *UEMLogger) sizeRotatedName(t time.Time) string {
+	for {
+		name := fmt.Sprintf("%s-%s", u.logfile, t.Format("20060102-150405"))
+		if _, err := os.Stat(name + ".gz"); err != nil {
+			return name
 		}
+		t = t.Add(time.Second)
+	}
+}
+
+// rotate renames the current log file, opens a new one, and deletes old log files
+func (u *UEMLogger) rotate(newLogFileName string, currentDate string, bySize bool) error {
+	var err error
+
+	// Close the current log file
+	if u.fileHandle != nil {
+		_ = u.fileHandle.Sync()
+		_ = u.fileHandle.Close()
+	}
+
+	// Rename the current log file
+	err = os.Rename(u.logfile, newLogFileName)
+	if err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	// Open a new log file
+	var fh *os.File
+	fh, err = os.OpenFile(u.logfile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		u.fileHandle = nil
+		u.logStdout = true
+		return fmt.Errorf("failed to open new log file after rotating: %w", err)
+	}
+	u.fileHandle = fh
+	u.size = 0
+
+	// Attempt to set the file mode to 0644 on a best-effort basis
+	_ = os.Chmod(u.logfile, 0644)
+
+	u.currentLogDate = currentDate
 
-		// Rename the current log file
-		newLogFileName := fmt.Sprintf("%s-%s", u.logfile, previousLogDate)
-		err = os.Rename(u.logfile, newLogFileName)
+	if bySize {
+		err = compressFile(newLogFileName)
 		if err != nil {
-			return fmt.Errorf("failed to rotate log file: %w", err)
+			return fmt.Errorf("failed to compress rotated log file: %w", err)
 		}
 
-		// Open a new log file
-		var fh *os.File
-		fh, err = os.OpenFile(u.logfile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		err = u.deleteExtraLogs()
 		if err != nil {
-			u.fileHandle = nil
-			u.logStdout = true
-			return fmt.Errorf("failed to open new log file after rotating: %w", err)
+			return fmt.Errorf("failed to delete old log files: %w", err)
 		}
-		u.fileHandle = fh
+	}
+
+	// Delete old log files
+	err = u.deleteOldLogs()
+	if err != nil {
+		return fmt.Errorf("failed to delete old log files: %w", err)
+	}
+	return nil
+}
 
-		// Attempt to set the file mode to 0644 on a best-effort basis
-		_ = os.Chmod(u.logfile, 0644)
+// compressFile replaces a file with a gzip compressed copy named with a .gz extension
+func compressFile(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(name + ".gz")
+		return err
+	}
+
+	_ = in.Close()
+	return os.Remove(name)
+}
+
+// deleteExtraLogs deletes the oldest log files rotated by size, keeping maxFiles of them
+func (u *UEMLogger) deleteExtraLogs() error {
+	logDir := filepath.Dir(u.logfile)
+	files, err := os.ReadDir(logDir)
+	if err != nil {
+		return fmt.Errorf("failed to read log directory: %w", err)
+	}
 
-		u.currentLogDate = currentDate
+	// Files rotated by size are named <logfile>-YYYYMMDD-HHMMSS.gz, so they sort by age
+	prefix := filepath.Base(u.logfile) + "-"
+	var rotated []string
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".gz") {
+			continue
+		}
+		if len(name) == len(prefix)+len("20060102-150405.gz") {
+			rotated = append(rotated, name)
+		}
+	}
+	sort.Strings(rotated)
 
-		// Delete old log files
-		err = u.deleteOldLogs()
+	for len(rotated) > u.maxFiles {
+		err = os.Remove(filepath.Join(logDir, rotated[0]))
 		if err != nil {
-			return fmt.Errorf("failed to delete old log files: %w", err)
+			return fmt.Errorf("failed to delete old log file: %w", err)
 		}
+		rotated = rotated[1:]
 	}
 	return nil
 }
 
+// fileSize returns the size of an open file, or 0 if it can't be determined
+func fileSize(fh *os.File) int64 {
+	fileInfo, err := fh.Stat()
+	if err != nil {
+		return 0
+	}
+	return fileInfo.Size()
+}
+
 // deleteOldLogs deletes log files older than retainDays
 func (u *UEMLogger) deleteOldLogs() error {
 	if u.retainDays <= 1 {
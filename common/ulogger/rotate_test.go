@@ -0,0 +1,113 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package ulogger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	logfile := filepath.Join(dir, "test.log")
+
+	l, err := New(WithLogFile(logfile), WithLogStdout(false), WithMaxSizeMB(1), WithMaxFiles(2))
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	u := l.(*UEMLogger)
+	defer u.Close()
+
+	// Rotate three times by reaching the maximum size
+	for x := 0; x < 3; x++ {
+		u.Info(1, "message", nil)
+		u.mu.Lock()
+		u.size = u.maxSize
+		u.mu.Unlock()
+	}
+	u.Info(1, "message", nil)
+
+	var rotated []string
+	files, _ := os.ReadDir(dir)
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".gz") {
+			rotated = append(rotated, file.Name())
+		}
+	}
+	if len(rotated) != 2 {
+		t.Errorf("expected 2 compressed log files, got %v", rotated)
+	}
+	if _, err = os.Stat(logfile); err != nil {
+		t.Errorf("current log file is missing: %v", err)
+	}
+}
+
+func TestReconfigureDebug(t *testing.T) {
+	logfile := filepath.Join(t.TempDir(), "test.log")
+	l, err := New(WithLogFile(logfile), WithLogStdout(false), WithDebug(false))
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	u := l.(*UEMLogger)
+	defer u.Close()
+
+	u.Debug(1, "hidden", nil)
+	if err = u.Reconfigure(WithDebug(true)); err != nil {
+		t.Fatalf("failed to reconfigure logger: %v", err)
+	}
+	u.Debug(2, "shown", nil)
+
+	data, err := os.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hidden") || !strings.Contains(string(data), "shown") {
+		t.Errorf("unexpected log contents: %s", data)
+	}
+}
+
+func TestLevelOverride(t *testing.T) {
+	logfile := filepath.Join(t.TempDir(), "test.log")
+	l, err := New(WithLogFile(logfile), WithLogStdout(false), WithDebug(false))
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	u := l.(*UEMLogger)
+	defer u.Close()
+
+	if err = u.SetLevelOverride(LevelWarning, 0); err != nil {
+		t.Fatal(err)
+	}
+	u.Info(1, "suppressed", nil)
+
+	// The override is kept when the configuration is applied again
+	if err = u.Reconfigure(WithDebug(false)); err != nil {
+		t.Fatal(err)
+	}
+	u.Info(2, "also suppressed", nil)
+
+	if err = u.SetLevelOverride(LevelDebug, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	u.Debug(3, "shown", nil)
+	time.Sleep(5 * time.Millisecond)
+	u.Debug(4, "expired", nil)
+	if u.Level() != LevelInfo {
+		t.Errorf("expected the configured level after the override expired, got %s", u.Level())
+	}
+
+	data, err := os.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+	if strings.Contains(text, "suppressed") || !strings.Contains(text, "shown") || strings.Contains(text, "expired") {
+		t.Errorf("unexpected log contents: %s", text)
+	}
+}
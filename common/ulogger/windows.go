@@ -12,27 +12,31 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
 	"golang.org/x/sys/windows/svc/eventlog"
 
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 )
 
-// windowsEID is the event ID for the custom event log source
-// Using other event IDs will create messy log entries unless a DLL with
-// messages strings is created and registered with the event log source
-const windowsEID = 1
-
 type UEMLogger struct {
+	mu               sync.Mutex // guards the settings and outputs, which may be reconfigured
 	logger           *eventlog.Log
 	fileHandle       *os.File
 	logfile          string
 	logStdout        bool
 	logWindowsEvents bool
-	debug            bool
+	level            Level
+	override         *Level    // temporary level set by SetLevelOverride
+	overrideUntil    time.Time // zero if the override does not expire
 	prefix           string
 	retainDays       int
+	maxSize          int64 // bytes, 0 to rotate only daily
+	maxFiles         int   // log files rotated by size to keep
+	size             int64 // size of the current log file
 	currentLogDate   string
 }
 
@@ -41,13 +45,18 @@ func (u *UEMLogger) osNew() (*UEMLogger, error) {
 	var err error
 	var fh *os.File
 
+	// The installer registers the event source. If it is missing, try to register it, which
+	// requires administrator privileges, and otherwise log only to the file or stdout.
 	if u.logWindowsEvents {
-		_ = eventlog.InstallAsEventCreate(u.prefix, eventlog.Info|eventlog.Warning|eventlog.Error)
-	}
-
-	u.logger, err = eventlog.Open(u.prefix)
-	if err != nil {
-		u.logger = nil
+		if !EventSourceRegistered(u.prefix) {
+			_ = InstallEventSource(u.prefix)
+		}
+		if EventSourceRegistered(u.prefix) {
+			u.logger, err = eventlog.Open(u.prefix)
+			if err != nil {
+				u.logger = nil
+			}
+		}
 	}
 
 	if u.logfile != "" {
@@ -67,24 +76,68 @@ func (u *UEMLogger) osNew() (*UEMLogger, error) {
 		fh, err = os.OpenFile(u.logfile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 		if err != nil {
 			if u.logger != nil {
-				_ = u.logger.Error(windowsEID, fmt.Sprintf("failed to open log file: %s", err.Error()))
+				u.reportEvent(0, "ERROR", fmt.Sprintf("failed to open log file: %s", err.Error()), nil)
 			}
 			u.fileHandle = nil
 			u.logStdout = true
 		} else {
 			u.fileHandle = fh
+			u.size = fileSize(fh)
 		}
 	}
+
+	// Make sure messages go somewhere
+	if u.logger == nil && u.fileHandle == nil {
+		u.logStdout = true
+	}
 	return u, nil
 }
 
+// InstallEventSource registers an event source in the Application log, using EventCreate.exe as
+// its message file. It is not an error if the source is already registered.
+func InstallEventSource(source string) error {
+	err := eventlog.InstallAsEventCreate(source, eventlog.Info|eventlog.Warning|eventlog.Error)
+	if err != nil && !EventSourceRegistered(source) {
+		return fmt.Errorf("failed to register event source %s: %w", source, err)
+	}
+	return nil
+}
+
+// RemoveEventSource removes an event source registered by InstallEventSource
+func RemoveEventSource(source string) error {
+	if !EventSourceRegistered(source) {
+		return nil
+	}
+	return eventlog.Remove(source)
+}
+
+// EventSourceRegistered returns true if the event source is registered in the Application log
+func EventSourceRegistered(source string) bool {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE,
+		`SYSTEM\CurrentControlSet\Services\EventLog\Application\`+source, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	_ = k.Close()
+	return true
+}
+
 func (u *UEMLogger) Close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.close()
+}
+
+// close closes the event log and log file, the caller must hold u.mu
+func (u *UEMLogger) close() {
 	if u.logger != nil {
 		_ = u.logger.Close()
+		u.logger = nil
 	}
 	if u.fileHandle != nil {
 		_ = u.fileHandle.Sync()
 		_ = u.fileHandle.Close()
+		u.fileHandle = nil
 	}
 }
 
@@ -97,6 +150,8 @@ func (u *UEMLogger) formatMessage(eid uint32, level string, message string, fiel
 }
 
 func (u *UEMLogger) logMessage(eid uint32, level string, message string, fields interfaces.Fields) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
 
 	// Rotate logs if necessary
 	err := u.rotateLogs()
@@ -106,14 +161,7 @@ func (u *UEMLogger) logMessage(eid uint32, level string, message string, fields
 
 	formattedMessage := u.formatMessage(eid, level, message, fields)
 	if u.logger != nil {
-		switch level {
-		case "DEBUG", "INFO":
-			_ = u.logger.Info(windowsEID, formattedMessage)
-		case "WARNING":
-			_ = u.logger.Warning(windowsEID, formattedMessage)
-		case "ERROR", "FATAL":
-			_ = u.logger.Error(windowsEID, formattedMessage)
-		}
+		u.reportEvent(eid, level, formattedMessage, fields)
 	}
 
 	tmp := fmt.Sprintf("%s %s %s\r\n",
@@ -123,7 +171,8 @@ func (u *UEMLogger) logMessage(eid uint32, level string, message string, fields
 
 	//  Write and flush
 	if u.fileHandle != nil {
-		_, _ = u.fileHandle.WriteString(tmp)
+		n, _ := u.fileHandle.WriteString(tmp)
+		u.size += int64(n)
 		_ = u.fileHandle.Sync()
 	}
 
@@ -132,14 +181,46 @@ func (u *UEMLogger) logMessage(eid uint32, level string, message string, fields
 	}
 }
 
+// reportEvent writes a message to the event log, with the category and ID derived from eid and
+// the fields as additional insertion strings
+func (u *UEMLogger) reportEvent(eid uint32, level string, message string, fields interfaces.Fields) {
+	var etype uint16
+	switch level {
+	case "WARNING":
+		etype = windows.EVENTLOG_WARNING_TYPE
+	case "ERROR", "FATAL":
+		etype = windows.EVENTLOG_ERROR_TYPE
+	default:
+		etype = windows.EVENTLOG_INFORMATION_TYPE
+	}
+
+	strs := eventStrings(message, fields)
+	ptrs := make([]*uint16, 0, len(strs))
+	for _, str := range strs {
+		ptr, err := windows.UTF16PtrFromString(str)
+		if err != nil {
+			continue
+		}
+		ptrs = append(ptrs, ptr)
+	}
+	if len(ptrs) == 0 {
+		return
+	}
+
+	_ = windows.ReportEvent(u.logger.Handle, etype, eventCategory(eid), eventID(eid), 0,
+		uint16(len(ptrs)), 0, &ptrs[0], nil)
+}
+
 func (u *UEMLogger) Debug(eid uint32, message string, fields interfaces.Fields) {
-	if u.debug {
+	if u.enabled(LevelDebug) {
 		u.logMessage(eid, "DEBUG", message, fields)
 	}
 }
 
 func (u *UEMLogger) Info(eid uint32, message string, fields interfaces.Fields) {
-	u.logMessage(eid, "INFO", message, fields)
+	if u.enabled(LevelInfo) {
+		u.logMessage(eid, "INFO", message, fields)
+	}
 }
 
 func (u *UEMLogger) Warning(eid uint32, message string, fields interfaces.Fields) {
@@ -155,15 +236,17 @@ func (u *UEMLogger) Fatal(eid uint32, message string, fields interfaces.Fields)
 }
 
 func (u *UEMLogger) Debugf(eid uint32, format string, v ...any) {
-	if u.debug {
+	if u.enabled(LevelDebug) {
 		message := fmt.Sprintf(format, v...)
 		u.logMessage(eid, "DEBUG", message, nil)
 	}
 }
 
 func (u *UEMLogger) Infof(eid uint32, format string, v ...any) {
-	message := fmt.Sprintf(format, v...)
-	u.logMessage(eid, "INFO", message, nil)
+	if u.enabled(LevelInfo) {
+		message := fmt.Sprintf(format, v...)
+		u.logMessage(eid, "INFO", message, nil)
+	}
 }
 
 func (u *UEMLogger) Warningf(eid uint32, format string, v ...any) {
@@ -0,0 +1,118 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+)
+
+// DefaultMaxBodyBytes is the default limit on the size of request bodies
+const DefaultMaxBodyBytes int64 = 10 * 1024 * 1024
+
+// NoBodyLimit may be used as the MaxBodyBytes of a route that accepts bodies of any
+// size, such as one that streams an upload to disk and enforces its own limit
+const NoBodyLimit int64 = -1
+
+// limitedBody is a request body limited by http.MaxBytesReader that records whether the
+// handler tried to read past the limit, so that the response can be replaced with a 413
+type limitedBody struct {
+	io.ReadCloser
+	limit    int64
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// bodyLimit returns the body size limit for a route. Routes that do not set a limit use
+// the server's, and a limit of zero or less means no limit.
+func (s *HServer) bodyLimit(route Route) int64 {
+	limit := s.MaxBodyBytes
+	if route.MaxBodyBytes != 0 {
+		limit = route.MaxBodyBytes
+	}
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// BodyLimitWrapper wraps a http.Handler and limits the size of the request body with
+// http.MaxBytesReader. Requests that declare a larger Content-Length are rejected with
+// 413 Request Entity Too Large before the handler is called. Bodies of unknown length are
+// cut off at the limit and JWrapper replaces the handler's response with a 413.
+func (s *HServer) BodyLimitWrapper(handlerName string, h http.Handler, limit int64) http.Handler {
+
+	// If there is no limit, return the handler unchanged
+	if limit <= 0 {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.ContentLength > limit {
+			s.bodyTooLarge(w, req, handlerName, limit)
+			return
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			req.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, req.Body, limit), limit: limit}
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// bodyLimitExceeded returns the limit if the handler tried to read more of the request body
+// than the limit allows
+func bodyLimitExceeded(req *http.Request) (int64, bool) {
+	if b, ok := req.Body.(*limitedBody); ok && b.exceeded {
+		return b.limit, true
+	}
+	return 0, false
+}
+
+// bodyTooLargeResponse returns the 413 response, including the limit in the details
+func bodyTooLargeResponse(limit int64) JResponse {
+	return JResponse{
+		HTTPCode: http.StatusRequestEntityTooLarge,
+		JSONData: Response{
+			Details: fmt.Sprintf("request body exceeds the limit of %d bytes", limit),
+			Status:  "error",
+			Code:    http.StatusRequestEntityTooLarge}}
+}
+
+// bodyTooLarge logs and sends the 413 response
+func (s *HServer) bodyTooLarge(w http.ResponseWriter, req *http.Request, handlerName string, limit int64) {
+	s.logBodyTooLarge(req, handlerName, limit)
+
+	resp := bodyTooLargeResponse(limit)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(resp.HTTPCode)
+	_ = json.NewEncoder(w).Encode(resp.JSONData)
+}
+
+func (s *HServer) logBodyTooLarge(req *http.Request, handlerName string, limit int64) {
+	s.Logger.Warning(s.SEid+15,
+		"request body too large",
+		fields.NewFields(
+			fields.NewField("src_ip", s.getIP(req)),
+			fields.NewField("method", req.Method),
+			fields.NewField("uri", strings.Split(req.RequestURI, "?")[0]),
+			fields.NewField("handler", handlerName),
+			fields.NewField("limit", limit)))
+}
@@ -0,0 +1,84 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+)
+
+// readBody reads the whole body and returns 400 if that fails, as the API handlers do
+func readBody(req *http.Request) JResponse {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return JResponse{HTTPCode: http.StatusBadRequest, JSONData: Response{Status: "error", Code: http.StatusBadRequest}}
+	}
+	return JResponse{HTTPCode: http.StatusOK, JSONData: Response{Status: "ok", Code: http.StatusOK, Data: len(body)}}
+}
+
+func TestBodyLimit(t *testing.T) {
+	s, err := New(WithLogger(null.Logger()), WithMaxBodyBytes(10))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	send := func(route Route, size int, knownLength bool) (int, Response) {
+		req := httptest.NewRequest("POST", "/test", strings.NewReader(strings.Repeat("x", size)))
+		if !knownLength {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		s.routeHandler(route).ServeHTTP(w, req)
+
+		var resp Response
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		return w.Code, resp
+	}
+
+	route := Route{Name: "test", JHandler: readBody}
+	tests := []struct {
+		size        int
+		knownLength bool
+		code        int
+	}{
+		{0, true, http.StatusOK},
+		{10, true, http.StatusOK},
+		{11, true, http.StatusRequestEntityTooLarge},
+		{10, false, http.StatusOK},
+		{11, false, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		code, resp := send(route, tt.size, tt.knownLength)
+		if code != tt.code || resp.Code != tt.code {
+			t.Errorf("%d bytes (known length %v): expected %d, got %d", tt.size, tt.knownLength, tt.code, code)
+		}
+		if code == http.StatusRequestEntityTooLarge && resp.Details != "request body exceeds the limit of 10 bytes" {
+			t.Errorf("unexpected details: %s", resp.Details)
+		}
+	}
+
+	// Routes can raise the limit or remove it
+	route.MaxBodyBytes = 20
+	if code, _ := send(route, 20, false); code != http.StatusOK {
+		t.Errorf("expected 200 within the route's limit, got %d", code)
+	}
+	if code, _ := send(route, 21, true); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 above the route's limit, got %d", code)
+	}
+
+	route.MaxBodyBytes = NoBodyLimit
+	if code, _ := send(route, 1000, false); code != http.StatusOK {
+		t.Errorf("expected 200 without a limit, got %d", code)
+	}
+}
@@ -0,0 +1,178 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userver
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+)
+
+// Content types that are not worth compressing, either because they are already compressed
+// or because they are file downloads
+var uncompressedTypes = []string{
+	"application/octet-stream",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"image/",
+	"video/",
+	"audio/",
+}
+
+// gzipBody decompresses a request body and closes both the decompressor and the original body
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	_ = b.Reader.Close()
+	return b.body.Close()
+}
+
+// gzipResponseWriter compresses the response unless, when the status is written, the
+// handler has already set a Content-Encoding or a content type that should not be compressed
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+// WriteHeader decides whether to compress the response and writes the status code
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	h := w.Header()
+	if code != http.StatusNoContent && code != http.StatusNotModified && h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", "gzip")
+		h.Add("Vary", "Accept-Encoding")
+		h.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write compresses the data if the response is being compressed
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the original ResponseWriter so that http.ResponseController can reach it
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close flushes any compressed data that has not been written
+func (w *gzipResponseWriter) close() {
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
+
+// compressible returns true if a response with the content type should be compressed
+func compressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, t := range uncompressedTypes {
+		if strings.HasPrefix(contentType, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// acceptsGzip returns true if the client accepts gzip encoded responses
+func acceptsGzip(req *http.Request) bool {
+	for _, enc := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(enc, ";")
+		if !strings.EqualFold(strings.TrimSpace(params[0]), "gzip") {
+			continue
+		}
+
+		// A quality of zero means the encoding is not acceptable
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// CompressionWrapper wraps a http.Handler to accept gzip encoded request bodies and to
+// compress responses for clients that send Accept-Encoding: gzip. Request bodies are
+// decompressed before any body size limit applied by the wrapped handler. Responses
+// advertise gzip support for requests (RFC 7694) so that clients know they can use it.
+// Range requests and responses that are already compressed or are file downloads are not
+// compressed.
+func (s *HServer) CompressionWrapper(handlerName string, h http.Handler) http.Handler {
+
+	// If compression is disabled, return the handler unchanged
+	if !s.Compression {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Accept-Encoding", "gzip")
+
+		if strings.EqualFold(strings.TrimSpace(req.Header.Get("Content-Encoding")), "gzip") && req.Body != nil {
+			gz, err := gzip.NewReader(req.Body)
+			if err != nil {
+				s.Logger.Warning(s.SEid+16,
+					"invalid gzip request body",
+					fields.NewFields(
+						fields.NewField("src_ip", s.getIP(req)),
+						fields.NewField("method", req.Method),
+						fields.NewField("uri", strings.Split(req.RequestURI, "?")[0]),
+						fields.NewField("handler", handlerName),
+						fields.NewField("error", err.Error())))
+				w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(Response{
+					Details: "invalid gzip request body",
+					Status:  "error",
+					Code:    http.StatusBadRequest})
+				return
+			}
+
+			// The decompressed length is not known
+			req.Body = &gzipBody{Reader: gz, body: req.Body}
+			req.Header.Del("Content-Encoding")
+			req.Header.Del("Content-Length")
+			req.ContentLength = -1
+		}
+
+		if !acceptsGzip(req) || req.Header.Get("Range") != "" {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+		h.ServeHTTP(gw, req)
+	})
+}
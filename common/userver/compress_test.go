@@ -0,0 +1,119 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+)
+
+func gzipData(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(data)); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompression(t *testing.T) {
+	s, err := New(WithLogger(null.Logger()), WithMaxBodyBytes(100))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	handler := s.routeHandler(Route{Name: "test", JHandler: readBody})
+
+	send := func(body []byte, encoding string, acceptGzip bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/test", bytes.NewReader(body))
+		if encoding != "" {
+			req.Header.Set("Content-Encoding", encoding)
+		}
+		if acceptGzip {
+			req.Header.Set("Accept-Encoding", "gzip, deflate")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(w *httptest.ResponseRecorder) Response {
+		var r io.Reader = w.Body
+		if w.Header().Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("invalid gzip response: %v", err)
+			}
+			r = gz
+		}
+		var resp Response
+		if err := json.NewDecoder(r).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	// Compressed request bodies are decompressed before the handler reads them
+	w := send(gzipData(t, strings.Repeat("x", 100)), "gzip", false)
+	if resp := decode(w); w.Code != http.StatusOK || resp.Data != float64(100) {
+		t.Errorf("expected 100 decompressed bytes, got %d %+v", w.Code, resp)
+	}
+	if w.Header().Get("Accept-Encoding") != "gzip" {
+		t.Errorf("server did not advertise gzip request bodies")
+	}
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("response compressed for a client that did not accept it")
+	}
+
+	// The size limit applies to the decompressed body
+	if w = send(gzipData(t, strings.Repeat("x", 101)), "gzip", false); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for a body that exceeds the limit when decompressed, got %d", w.Code)
+	}
+
+	if w = send([]byte("not gzip"), "gzip", false); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid gzip body, got %d", w.Code)
+	}
+
+	// Responses are compressed for clients that accept gzip
+	w = send([]byte("abc"), "", true)
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("response was not compressed")
+	}
+	if resp := decode(w); resp.Data != float64(3) {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	// File downloads are not compressed
+	download := s.routeHandler(Route{Name: "download", Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write([]byte("file"))
+	})})
+	req := httptest.NewRequest("GET", "/download", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	download.ServeHTTP(w, req)
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != "file" {
+		t.Errorf("file download was compressed")
+	}
+
+	// Compression can be disabled
+	s.Compression = false
+	handler = s.routeHandler(Route{Name: "test", JHandler: readBody})
+	if w = send([]byte("abc"), "", true); w.Header().Get("Content-Encoding") != "" || w.Header().Get("Accept-Encoding") != "" {
+		t.Errorf("compression was not disabled")
+	}
+}
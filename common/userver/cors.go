@@ -0,0 +1,83 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userver
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Defaults used by WithCORS if the methods or headers are not specified
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE"}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", "Content-Encoding"}
+)
+
+// corsExposedHeaders are response headers that browser clients may read
+const corsExposedHeaders = "Retry-After, Content-Disposition"
+
+// CORS configures cross-origin requests from browsers. It is disabled if AllowedOrigins is empty.
+// An origin of "*" allows any origin. Credentials are not allowed because the API is authenticated
+// with bearer tokens rather than cookies.
+type CORS struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	MaxAge         int // Seconds that browsers may cache a preflight response, 0 to omit
+}
+
+// allowed returns true if CORS is enabled and the origin is allowed
+func (c *CORS) allowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	origin = strings.TrimSuffix(origin, "/")
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSWrapper adds CORS headers to the responses to requests from allowed origins and answers
+// their preflight requests itself, before routing and authentication, because browsers don't
+// send credentials with a preflight. Because the headers are set before the request is routed,
+// they are included in error responses such as 401, 404, and 405. Requests from other origins
+// are passed through unchanged, so the browser blocks the response.
+func (s *HServer) CORSWrapper(h http.Handler) http.Handler {
+	if len(s.CORS.AllowedOrigins) == 0 {
+		return h
+	}
+
+	methods := strings.Join(s.CORS.AllowedMethods, ", ")
+	headers := strings.Join(s.CORS.AllowedHeaders, ", ")
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+		if !s.CORS.allowed(origin) {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			if s.CORS.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(s.CORS.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		h.ServeHTTP(w, req)
+	})
+}
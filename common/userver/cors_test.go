@@ -0,0 +1,78 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+)
+
+func TestCORS(t *testing.T) {
+	s, err := New(WithLogger(null.Logger()), WithCORS([]string{"https://console.example.com/"}, nil, nil, 600))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	// An authenticated route, and the not found handler
+	deny := func(string, string) (bool, []byte, any) { return false, nil, nil }
+	protected := s.CORSWrapper(s.Wrapper("protected", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), deny))
+	notFound := s.CORSWrapper(s.Wrapper("Handler404", s.JWrapper("Handler404", s.Handler404), nil))
+
+	request := func(h http.Handler, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/agent", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	preflight := map[string]string{"Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "authorization"}
+
+	// Preflights from an allowed origin are answered before authentication
+	w := request(protected, "OPTIONS", "https://console.example.com", preflight)
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://console.example.com" ||
+		w.Header().Get("Access-Control-Allow-Methods") != "GET, POST, PUT, DELETE" ||
+		w.Header().Get("Access-Control-Allow-Headers") != "Authorization, Content-Type, Content-Encoding" ||
+		w.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("unexpected preflight response %d %v", w.Code, w.Header())
+	}
+
+	// Responses to allowed origins, including errors, have the headers
+	if w = request(protected, "GET", "https://console.example.com", nil); w.Code != http.StatusUnauthorized ||
+		w.Header().Get("Access-Control-Allow-Origin") != "https://console.example.com" {
+		t.Errorf("expected 401 with CORS headers, got %d %v", w.Code, w.Header())
+	}
+	if w = request(notFound, "GET", "https://CONSOLE.example.com", nil); w.Code != http.StatusNotFound ||
+		w.Header().Get("Access-Control-Allow-Origin") != "https://CONSOLE.example.com" {
+		t.Errorf("expected 404 with CORS headers, got %d %v", w.Code, w.Header())
+	}
+
+	// Other origins and requests without an origin are passed through without the headers
+	for _, origin := range []string{"https://evil.example.com", ""} {
+		if w = request(protected, "OPTIONS", origin, preflight); w.Code == http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("%q: unexpected preflight response %d %v", origin, w.Code, w.Header())
+		}
+		if w = request(notFound, "GET", origin, nil); w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("%q: unexpected CORS headers %v", origin, w.Header())
+		}
+	}
+
+	// CORS is disabled by default
+	s, _ = New(WithLogger(null.Logger()))
+	notFound = s.CORSWrapper(s.Wrapper("Handler404", s.JWrapper("Handler404", s.Handler404), nil))
+	if w = request(notFound, "OPTIONS", "https://console.example.com", preflight); w.Code == http.StatusNoContent {
+		t.Errorf("expected preflight to be passed through with CORS disabled")
+	}
+}
@@ -0,0 +1,70 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+)
+
+func TestFileServerRange(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "uem-agent"), []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "uploads"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "uploads", "secret"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(WithLogger(null.Logger()), WithCompression(true), WithFileDir("/files/", dir, nil), WithFileDirExclude("uploads"))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	handler := s.fileHandler()
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/files/uem-agent", map[string]string{"Accept-Encoding": "gzip"})
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" || w.Header().Get("Accept-Ranges") != "bytes" {
+		t.Errorf("unexpected full response %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	// A resumed download requests the remainder of the file
+	w = get("/files/uem-agent", map[string]string{"Range": "bytes=4-", "Accept-Encoding": "gzip"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "456789" || w.Header().Get("Content-Range") != "bytes 4-9/10" {
+		t.Errorf("unexpected range response %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	// If the file has changed since the download started, the whole file is sent
+	w = get("/files/uem-agent", map[string]string{"Range": "bytes=4-", "If-Range": "Mon, 02 Jan 2006 15:04:05 GMT"})
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" {
+		t.Errorf("unexpected response to a stale If-Range %d %q", w.Code, w.Body.String())
+	}
+
+	if w = get("/files/uem-agent", map[string]string{"Range": "bytes=20-"}); w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("expected 416 for a range beyond the end of the file, got %d", w.Code)
+	}
+
+	if w = get("/files/uploads/secret", map[string]string{"Range": "bytes=0-"}); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an excluded directory, got %d", w.Code)
+	}
+}
@@ -9,10 +9,17 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
 )
 
+// healthCheckTimeout is the maximum time allowed for each health check
+const healthCheckTimeout = 5 * time.Second
+
 // HandlerHealth implements a health check for load balancers, etc.
 func (s *HServer) HandlerHealth(_ *http.Request) JResponse {
 	var r Response
@@ -23,17 +30,66 @@ func (s *HServer) HandlerHealth(_ *http.Request) JResponse {
 		r.Status = "down"
 		r.Code = http.StatusServiceUnavailable
 		r.Details = "server is shutting down"
+		return JResponse{
+			HTTPCode: r.Code,
+			JSONData: r}
+	}
+
+	// Run any checks registered by the application
+	results := make(map[string]string)
+	var failed []string
+	for _, hc := range s.HealthChecks {
+		err := runHealthCheck(hc)
+		if err != nil {
+			results[hc.Name] = err.Error()
+			failed = append(failed, hc.Name)
+		} else {
+			results[hc.Name] = "ok"
+		}
+	}
+
+	for _, hi := range s.HealthInfo {
+		results[hi.Name] = hi.Info()
+	}
+
+	if len(results) > 0 {
+		r.Data = results
+	}
+
+	if len(failed) > 0 {
+		s.Logger.Warning(s.SEid+14,
+			"health check failed",
+			fields.NewFields(fields.NewField("checks", strings.Join(failed, ","))))
+
+		r.Status = "error"
+		r.Code = http.StatusServiceUnavailable
+		r.Details = "health check failed: " + strings.Join(failed, ", ")
 	} else {
-		// does not exist - send ok and 200
 		r.Status = "ok"
 		r.Code = http.StatusOK
 		r.Details = "health check ok"
 	}
+
 	return JResponse{
 		HTTPCode: r.Code,
 		JSONData: r}
 }
 
+// runHealthCheck runs a single check, treating a check that does not return in time as failed
+func runHealthCheck(hc HealthCheck) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- hc.Check()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(healthCheckTimeout):
+		return fmt.Errorf("timed out after %v", healthCheckTimeout)
+	}
+}
+
 func (s *HServer) Handler401(_ *http.Request) JResponse {
 	s.PenaltyBox()
 
@@ -24,8 +24,17 @@ func (s *HServer) JWrapper(name string, h JHandler) http.Handler {
 		// Call the actual handler to service the agent
 		respData := h(req)
 
+		// If the handler read past the body size limit, its response is replaced
+		if limit, exceeded := bodyLimitExceeded(req); exceeded {
+			s.logBodyTooLarge(req, name, limit)
+			respData = bodyTooLargeResponse(limit)
+		}
+
 		// Set reply headers
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		for k, v := range respData.Headers {
+			w.Header().Set(k, v)
+		}
 
 		// Send the response
 		w.WriteHeader(respData.HTTPCode)
@@ -5,7 +5,11 @@
 
 package userver
 
-import "github.com/UnifyEM/UnifyEM/common/interfaces"
+import (
+	"errors"
+
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+)
 
 // Functional options
 
@@ -98,6 +102,44 @@ func WithHealthHandler(h bool) func(*HServer) error {
 	}
 }
 
+// WithHealthCheck registers a check that is run by the health handler. If any check
+// fails, the health handler returns 503 so that load balancers stop sending traffic.
+//
+//goland:noinspection GoUnusedExportedFunction
+func WithHealthCheck(name string, check func() error) func(*HServer) error {
+	return func(e *HServer) error {
+		if name == "" || check == nil {
+			return errors.New("health check requires a name and a function")
+		}
+		e.HealthChecks = append(e.HealthChecks, HealthCheck{Name: name, Check: check})
+		return nil
+	}
+}
+
+// WithHealthInfo registers a function that adds information to the health response, such as
+// the state of a dependency that is working but degraded. It does not affect the status.
+//
+//goland:noinspection GoUnusedExportedFunction
+func WithHealthInfo(name string, info func() string) func(*HServer) error {
+	return func(e *HServer) error {
+		if name == "" || info == nil {
+			return errors.New("health info requires a name and a function")
+		}
+		e.HealthInfo = append(e.HealthInfo, HealthInfo{Name: name, Info: info})
+		return nil
+	}
+}
+
+// WithObserver registers a function that is called after each request, for example to collect metrics
+//
+//goland:noinspection GoUnusedExportedFunction
+func WithObserver(observer Observer) func(*HServer) error {
+	return func(e *HServer) error {
+		e.Observer = observer
+		return nil
+	}
+}
+
 //goland:noinspection GoUnusedExportedFunction
 func WithTestHandler(t bool) func(*HServer) error {
 	return func(e *HServer) error {
@@ -172,6 +214,16 @@ func WithFileDir(pattern, dir string, authFunc AuthFunc) func(*HServer) error {
 	}
 }
 
+// WithFileDirExclude prevents the file server from serving the specified subdirectories
+//
+//goland:noinspection GoUnusedExportedFunction
+func WithFileDirExclude(dirs ...string) func(*HServer) error {
+	return func(e *HServer) error {
+		e.FileSrv.Exclude = append(e.FileSrv.Exclude, dirs...)
+		return nil
+	}
+}
+
 //goland:noinspection GoUnusedExportedFunction
 func WithAuthFunc(authFunc AuthFunc) func(*HServer) error {
 	return func(e *HServer) error {
@@ -179,3 +231,70 @@ func WithAuthFunc(authFunc AuthFunc) func(*HServer) error {
 		return nil
 	}
 }
+
+// WithRateLimit sets the number of requests per minute and the burst size
+// allowed from a single source IP on routes that opt into rate limiting
+//
+//goland:noinspection GoUnusedExportedFunction
+func WithRateLimit(perMinute, burst int) func(*HServer) error {
+	return func(e *HServer) error {
+		e.RateLimit = perMinute
+		e.RateLimitBurst = burst
+		return nil
+	}
+}
+
+// WithTrustedProxies sets the addresses of reverse proxies or load balancers. The X-Forwarded-For
+// header is only used to identify the client for rate limiting if the request is from one of them.
+//
+//goland:noinspection GoUnusedExportedFunction
+func WithTrustedProxies(proxies []string) func(*HServer) error {
+	return func(e *HServer) error {
+		e.TrustedProxies = proxies
+		return nil
+	}
+}
+
+// WithMaxBodyBytes sets the default limit on the size of request bodies. Larger requests
+// are rejected with 413. Routes can override it, and 0 disables the limit.
+//
+//goland:noinspection GoUnusedExportedFunction
+func WithMaxBodyBytes(n int64) func(*HServer) error {
+	return func(e *HServer) error {
+		e.MaxBodyBytes = n
+		return nil
+	}
+}
+
+// WithCompression enables or disables gzip compression of responses and decompression of
+// gzip request bodies. It may be disabled to make traffic easier to inspect when debugging.
+//
+//goland:noinspection GoUnusedExportedFunction
+func WithCompression(c bool) func(*HServer) error {
+	return func(e *HServer) error {
+		e.Compression = c
+		return nil
+	}
+}
+
+// WithCORS allows browsers to call the server from the specified origins. The default methods
+// and headers are used if none are specified. CORS is disabled if no origins are specified.
+//
+//goland:noinspection GoUnusedExportedFunction
+func WithCORS(allowedOrigins, allowedMethods, allowedHeaders []string, maxAge int) func(*HServer) error {
+	return func(e *HServer) error {
+		if len(allowedMethods) == 0 {
+			allowedMethods = defaultCORSMethods
+		}
+		if len(allowedHeaders) == 0 {
+			allowedHeaders = defaultCORSHeaders
+		}
+		e.CORS = CORS{
+			AllowedOrigins: allowedOrigins,
+			AllowedMethods: allowedMethods,
+			AllowedHeaders: allowedHeaders,
+			MaxAge:         maxAge,
+		}
+		return nil
+	}
+}
@@ -0,0 +1,157 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userver
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+)
+
+// rateLimiterIdle is how long an idle bucket is retained before it is discarded
+const rateLimiterIdle = 10 * time.Minute
+
+// tokenBucket tracks the available tokens for a single source IP
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// rateLimiter implements a per-source IP token bucket. Tokens are refilled at
+// perMinute/60 per second up to a maximum of burst.
+type rateLimiter struct {
+	mu        sync.Mutex
+	perSecond float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastClean time.Time
+}
+
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		perSecond: float64(perMinute) / 60,
+		burst:     float64(burst),
+		buckets:   make(map[string]*tokenBucket),
+		lastClean: time.Now(),
+	}
+}
+
+// allow consumes a token for the source IP if one is available. If not, it
+// returns false and the number of seconds until a token will be available.
+func (r *rateLimiter) allow(src string) (bool, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.clean(now)
+
+	b, ok := r.buckets[src]
+	if !ok {
+		b = &tokenBucket{tokens: r.burst, lastSeen: now}
+		r.buckets[src] = b
+	}
+
+	// Refill based on the time elapsed since the last request
+	b.tokens = math.Min(r.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*r.perSecond)
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	// Calculate the time until the next token is available
+	retry := int(math.Ceil((1 - b.tokens) / r.perSecond))
+	if retry < 1 {
+		retry = 1
+	}
+	return false, retry
+}
+
+// clean removes buckets that have not been used recently to bound memory use.
+// The caller must hold the lock.
+func (r *rateLimiter) clean(now time.Time) {
+	if now.Sub(r.lastClean) < rateLimiterIdle {
+		return
+	}
+	for src, b := range r.buckets {
+		if now.Sub(b.lastSeen) > rateLimiterIdle {
+			delete(r.buckets, src)
+		}
+	}
+	r.lastClean = now
+}
+
+// RateLimitWrapper wraps a http.Handler and rejects requests that exceed the
+// per-source IP rate limit with 429 Too Many Requests and a Retry-After header
+func (s *HServer) RateLimitWrapper(handlerName string, h http.Handler) http.Handler {
+
+	// If rate limiting is not configured, return the handler unchanged
+	if s.rateLimiter == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		src := s.clientIP(req)
+
+		allowed, retry := s.rateLimiter.allow(src)
+		if !allowed {
+			s.Logger.Warning(s.SEid+13,
+				"rate limit exceeded",
+				fields.NewFields(
+					fields.NewField("src_ip", src),
+					fields.NewField("method", req.Method),
+					fields.NewField("uri", strings.Split(req.RequestURI, "?")[0]),
+					fields.NewField("handler", handlerName),
+					fields.NewField("retry_after", retry)))
+
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_ = json.NewEncoder(w).Encode(Response{
+				Details: "too many requests",
+				Status:  "error",
+				Code:    http.StatusTooManyRequests})
+			return
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
+
+// clientIP returns the address that a request is rate limited by. It is the address of the peer,
+// unless the peer is a trusted proxy, in which case it is the last address in X-Forwarded-For that
+// is not a trusted proxy. Clients can add any address to the header, so earlier addresses and
+// the header of untrusted peers are ignored.
+func (s *HServer) clientIP(req *http.Request) string {
+	peer, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		peer = req.RemoteAddr
+	}
+	if !slices.Contains(s.TrustedProxies, peer) {
+		return peer
+	}
+
+	forwarded := strings.Split(req.Header.Get("X-Forwarded-For"), ",")
+	for x := len(forwarded) - 1; x >= 0; x-- {
+		ip := strings.TrimSpace(forwarded[x])
+		if ip != "" && !slices.Contains(s.TrustedProxies, ip) {
+			return ip
+		}
+	}
+	return peer
+}
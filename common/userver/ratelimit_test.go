@@ -0,0 +1,66 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+)
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	s, err := New(WithLogger(null.Logger()), WithRateLimit(1, 2), WithTrustedProxies([]string{"10.0.0.1"}))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	s.rateLimiter = newRateLimiter(s.RateLimit, s.RateLimitBurst)
+
+	h := s.RateLimitWrapper("login", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(remote, forwarded string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/login", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// A client that is not a trusted proxy can not escape the limit by changing the header
+	for x := 0; x < 5; x++ {
+		w := request("192.0.2.10:5000", fmt.Sprintf("198.51.100.%d", x))
+		if x < 2 && w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200 within the burst, got %d", x, w.Code)
+		}
+		if x >= 2 {
+			if w.Code != http.StatusTooManyRequests {
+				t.Fatalf("request %d: expected 429 with a rotated X-Forwarded-For, got %d", x, w.Code)
+			}
+			if retry, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || retry < 1 {
+				t.Errorf("request %d: expected Retry-After, got %q", x, w.Header().Get("Retry-After"))
+			}
+		}
+	}
+
+	// Behind a trusted proxy, the address added by the proxy is used and addresses added
+	// by the client are ignored
+	for x := 0; x < 2; x++ {
+		if w := request("10.0.0.1:443", fmt.Sprintf("203.0.113.%d, 198.51.100.7", x)); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200 within the burst, got %d", x, w.Code)
+		}
+	}
+	if w := request("10.0.0.1:443", "203.0.113.99, 198.51.100.7"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 for the client behind the proxy, got %d", w.Code)
+	}
+	if w := request("10.0.0.1:443", "198.51.100.8"); w.Code != http.StatusOK {
+		t.Errorf("expected another client behind the proxy to be allowed, got %d", w.Code)
+	}
+}
@@ -7,6 +7,7 @@ package userver
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 )
@@ -24,6 +25,8 @@ type HServer struct {
 	LogFile          string // Optional, defaults to stdout
 	DownFile         string
 	HealthHandler    bool
+	HealthChecks     []HealthCheck
+	HealthInfo       []HealthInfo
 	TestHandler      bool
 	StrictSlash      bool
 	DefaultHeaders   bool
@@ -37,12 +40,39 @@ type HServer struct {
 	Logger           interfaces.Logger
 	SEid             uint32 // Starting event ID for logging
 	FileSrv          FileServer
+	RateLimit        int // Requests per minute per source IP for rate limited routes, 0 to disable
+	RateLimitBurst   int
+	TrustedProxies   []string // Addresses of proxies whose X-Forwarded-For header is used for rate limiting
+	MaxBodyBytes     int64    // Default limit on request body size for routes, 0 to disable
+	Compression      bool     // Accept gzip request bodies and compress responses
+	rateLimiter      *rateLimiter
+	Observer         Observer // Optional, called after each request
+	CORS             CORS     // Cross-origin requests from browsers, disabled by default
+}
+
+// Observer is called by the wrapper after each request with the handler name, method,
+// status code, and duration. It is intended for collecting metrics and must not block.
+type Observer func(handler string, method string, code int, duration time.Duration)
+
+// HealthCheck is a named callback used by the health handler to test a dependency.
+// Check should return quickly and return an error if the dependency is not healthy.
+type HealthCheck struct {
+	Name  string
+	Check func() error
+}
+
+// HealthInfo is a named callback that adds information to the health response without
+// affecting its status
+type HealthInfo struct {
+	Name string
+	Info func() string
 }
 
 type FileServer struct {
 	Dir      string
 	Pattern  string
 	AuthFunc AuthFunc
+	Exclude  []string // Subdirectories that are not served
 }
 
 // AuthFunc is used as a callback to authenticate requests
@@ -57,16 +87,30 @@ type AuthDetails interface {
 	IsAuthenticated() bool
 }
 
+// AuthStatus may optionally be implemented by the details returned by a
+// failed AuthFunc to override the default 401 status code, for example
+// to return 403 when the user is authenticated but not authorized
+type AuthStatus interface {
+	StatusCode() int
+}
+
 // Route defines a route for the HTTP router. It can include a
 // standard handler that returns a http.Handler or a JHandler
-// that returns a JResponse structure.
+// that returns a JResponse structure. If RateLimit is true, requests
+// are subject to the per-source IP rate limit configured on the server.
+// MaxBodyBytes overrides the server's limit on the size of the request
+// body if it is not zero; NoBodyLimit removes the limit. If Prefix is
+// true, the route matches any path that starts with Pattern.
 type Route struct {
-	Name     string
-	Methods  []string
-	Pattern  string
-	Handler  http.Handler
-	JHandler JHandler
-	AuthFunc AuthFunc
+	Name         string
+	Methods      []string
+	Pattern      string
+	Handler      http.Handler
+	JHandler     JHandler
+	AuthFunc     AuthFunc
+	RateLimit    bool
+	MaxBodyBytes int64
+	Prefix       bool
 }
 
 type Routes []Route
@@ -93,4 +137,5 @@ type JHandler func(req *http.Request) JResponse
 type JResponse struct {
 	HTTPCode int
 	JSONData any
+	Headers  map[string]string // optional headers, such as Retry-After
 }
@@ -17,6 +17,8 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"path"
+	"strings"
 	"time"
 
 	"golang.org/x/net/netutil"
@@ -49,6 +51,8 @@ func New(options ...func(*HServer) error) (*HServer, error) {
 		TLSKeyFile:       "",
 		TLSStrongCiphers: true,
 		Debug:            false,
+		MaxBodyBytes:     DefaultMaxBodyBytes,
+		Compression:      true,
 	}
 
 	// Process options (see options.go)
@@ -115,29 +119,35 @@ func (s *HServer) Start() error {
 		})
 	}
 
+	// Create the rate limiter if configured
+	if s.RateLimit > 0 {
+		s.rateLimiter = newRateLimiter(s.RateLimit, s.RateLimitBurst)
+	}
+
 	// Create a new gorilla/mux router
 	router := mux.NewRouter()
 
 	// Iterate through routes
 	for _, route := range s.Routes {
-		// Use JHandler if set otherwise use Handler
-		// Wrap either with Wrapper() for logging
-		if route.JHandler != nil {
-			handler := s.Wrapper(route.Name, s.JWrapper(route.Name, route.JHandler), route.AuthFunc)
-			router.Handle(route.Pattern, handler).Methods(route.Methods...)
-		} else if route.Handler != nil {
-			handler := s.Wrapper(route.Name, route.Handler, route.AuthFunc)
+		handler := s.routeHandler(route)
+		if handler == nil {
+			continue
+		}
+
+		// Apply rate limiting before any other processing if requested
+		if route.RateLimit {
+			handler = s.RateLimitWrapper(route.Name, handler)
+		}
+		if route.Prefix {
+			router.PathPrefix(route.Pattern).Handler(handler).Methods(route.Methods...)
+		} else {
 			router.Handle(route.Pattern, handler).Methods(route.Methods...)
 		}
 	}
 
 	// Serve files from FileDir if set
 	if s.FileSrv.Dir != "" && s.FileSrv.Pattern != "" {
-		// Create the file server
-		fileServer := http.FileServer(http.Dir(s.FileSrv.Dir))
-
-		// Wrap the file server for logging
-		router.PathPrefix(s.FileSrv.Pattern).Handler(s.Wrapper("FileServer", http.StripPrefix(s.FileSrv.Pattern, fileServer), s.FileSrv.AuthFunc))
+		router.PathPrefix(s.FileSrv.Pattern).Handler(s.fileHandler())
 
 		// Log creating the file server
 		s.Logger.Info(s.SEid+2, fmt.Sprintf("Serving files from %s with pattern %s", s.FileSrv.Dir, s.FileSrv.Pattern), nil)
@@ -150,7 +160,7 @@ func (s *HServer) Start() error {
 	// Create server
 	serv := &http.Server{
 		Addr:              s.Listen,
-		Handler:           router,
+		Handler:           s.CORSWrapper(router),
 		ReadHeaderTimeout: time.Duration(s.HTTPTimeout) * time.Second,
 		ReadTimeout:       time.Duration(s.HTTPTimeout) * time.Second,
 		WriteTimeout:      time.Duration(s.HTTPTimeout) * time.Second,
@@ -212,6 +222,22 @@ func (s *HServer) Stop() error {
 	return nil
 }
 
+// routeHandler returns the handler for a route, or nil if it has none. JHandler is used if
+// set, otherwise Handler. Either is wrapped to limit the body size after decompression, to
+// support compression, and with Wrapper() for logging.
+func (s *HServer) routeHandler(route Route) http.Handler {
+	var handler http.Handler
+	if route.JHandler != nil {
+		handler = s.JWrapper(route.Name, route.JHandler)
+	} else if route.Handler != nil {
+		handler = route.Handler
+	} else {
+		return nil
+	}
+	handler = s.BodyLimitWrapper(route.Name, handler, s.bodyLimit(route))
+	return s.Wrapper(route.Name, s.CompressionWrapper(route.Name, handler), route.AuthFunc)
+}
+
 // AddRoutes adds routes to the router
 func (s *HServer) AddRoutes(routes Routes) {
 	// Iterate over routes and add to the router
@@ -264,3 +290,37 @@ func (s *HServer) listen(server *http.Server) error {
 	}
 	return s.server.Serve(listener)
 }
+
+// fileHandler returns the handler for the file server, wrapped for logging and authentication.
+// http.FileServer supports range requests, so an interrupted download of a large file can be
+// resumed. The write timeout is removed because large files can take longer than the HTTP
+// timeout to send.
+func (s *HServer) fileHandler() http.Handler {
+	fileServer := s.excludeWrapper(http.FileServer(http.Dir(s.FileSrv.Dir)))
+	noTimeout := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		fileServer.ServeHTTP(w, req)
+	})
+	return s.Wrapper("FileServer", http.StripPrefix(s.FileSrv.Pattern, noTimeout), s.FileSrv.AuthFunc)
+}
+
+// excludeWrapper returns 404 for requests for files in the excluded subdirectories of the
+// file server. The comparison ignores case in case the file system is case-insensitive.
+func (s *HServer) excludeWrapper(h http.Handler) http.Handler {
+	if len(s.FileSrv.Exclude) == 0 {
+		return h
+	}
+
+	notFound := s.JWrapper("FileServer", s.Handler404)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		p := strings.ToLower(path.Clean("/" + req.URL.Path))
+		for _, dir := range s.FileSrv.Exclude {
+			dir = strings.ToLower(path.Clean("/" + dir))
+			if p == dir || strings.HasPrefix(p, dir+"/") {
+				notFound.ServeHTTP(w, req)
+				return
+			}
+		}
+		h.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,108 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// staticTypes are the content types of common web assets. They are set explicitly because
+// mime.TypeByExtension depends on the system's MIME database, which may be missing or wrong.
+var staticTypes = map[string]string{
+	".html":  "text/html; charset=utf-8",
+	".js":    "text/javascript; charset=utf-8",
+	".mjs":   "text/javascript; charset=utf-8",
+	".css":   "text/css; charset=utf-8",
+	".json":  "application/json",
+	".svg":   "image/svg+xml",
+	".png":   "image/png",
+	".ico":   "image/x-icon",
+	".txt":   "text/plain; charset=utf-8",
+	".woff2": "font/woff2",
+}
+
+// staticFile is a file read from the file system with its content type and ETag
+type staticFile struct {
+	data        []byte
+	contentType string
+	etag        string
+}
+
+// StaticHandler serves the files in fsys, such as assets embedded with go:embed, for requests
+// below prefix, with index.html served for directories. Because the files can change when the
+// application is upgraded, responses include an ETag based on the content and ask browsers to
+// revalidate before using a cached copy, which returns 304 if the file has not changed.
+// Directory listings are not served.
+func StaticHandler(prefix string, fsys fs.FS) http.Handler {
+	var mu sync.Mutex
+	files := make(map[string]*staticFile)
+	modTime := time.Now()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(req.URL.Path, prefix)), "/")
+		if name == "" || strings.HasSuffix(req.URL.Path, "/") {
+			name = path.Join(name, "index.html")
+		}
+
+		mu.Lock()
+		file, ok := files[name]
+		if !ok {
+			file = readStaticFile(fsys, name)
+			files[name] = file
+		}
+		mu.Unlock()
+
+		if file == nil {
+			http.NotFound(w, req)
+			return
+		}
+
+		h := w.Header()
+		h.Set("Content-Type", file.contentType)
+		h.Set("ETag", file.etag)
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, req, name, modTime, bytes.NewReader(file.data))
+	})
+}
+
+// readStaticFile returns the named file, or nil if it does not exist or is a directory
+func readStaticFile(fsys fs.FS, name string) *staticFile {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil
+	}
+
+	contentType, ok := staticTypes[strings.ToLower(path.Ext(name))]
+	if !ok {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	sum := sha256.Sum256(data)
+	return &staticFile{
+		data:        data,
+		contentType: contentType,
+		etag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}
+}
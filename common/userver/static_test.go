@@ -0,0 +1,67 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package userver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticHandler(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":   {Data: []byte("<!DOCTYPE html><p>dashboard</p>")},
+		"app.js":       {Data: []byte("console.log('app');")},
+		"style.css":    {Data: []byte("body { margin: 0; }")},
+		"img/logo.svg": {Data: []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>")},
+	}
+	handler := StaticHandler("/ui/", fsys)
+
+	get := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for path, contentType := range map[string]string{
+		"/ui/":             "text/html; charset=utf-8",
+		"/ui/index.html":   "text/html; charset=utf-8",
+		"/ui/app.js":       "text/javascript; charset=utf-8",
+		"/ui/style.css":    "text/css; charset=utf-8",
+		"/ui/img/logo.svg": "image/svg+xml",
+	} {
+		w := get("GET", path, nil)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != contentType {
+			t.Errorf("%s: unexpected response %d %q", path, w.Code, w.Header().Get("Content-Type"))
+		}
+		if w.Header().Get("Cache-Control") != "no-cache" || w.Header().Get("ETag") == "" || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("%s: unexpected headers %v", path, w.Header())
+		}
+	}
+
+	// A cached copy is revalidated with the ETag
+	etag := get("GET", "/ui/app.js", nil).Header().Get("ETag")
+	if w := get("GET", "/ui/app.js", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected 304 for a matching ETag, got %d", w.Code)
+	}
+	if w := get("GET", "/ui/app.js", map[string]string{"If-None-Match": `"stale"`}); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a stale ETag, got %d", w.Code)
+	}
+
+	for _, path := range []string{"/ui/missing.js", "/ui/img", "/ui/../static.go"} {
+		if w := get("GET", path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+	if w := get("POST", "/ui/app.js", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", w.Code)
+	}
+}
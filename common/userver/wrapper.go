@@ -29,6 +29,11 @@ func (rw *ResponseWriterWrapper) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap returns the original ResponseWriter so that http.ResponseController can reach it
+func (rw *ResponseWriterWrapper) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Wrapper wraps a http.Handler to add standard headers, logging, and optionally authentication
 func (s *HServer) Wrapper(handlerName string, h http.Handler, authFunc AuthFunc) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -52,13 +57,21 @@ func (s *HServer) Wrapper(handlerName string, h http.Handler, authFunc AuthFunc)
 				// Impose a time penalty for failed authentication
 				s.PenaltyBox()
 
-				// Return unauthorized status code
-				w.WriteHeader(http.StatusUnauthorized)
+				// Return unauthorized status code unless the details specify otherwise
+				code := http.StatusUnauthorized
+				if status, ok := details.(AuthStatus); ok && status.StatusCode() != 0 {
+					code = status.StatusCode()
+				}
+				w.WriteHeader(code)
 
 				// If a failure message is provided, send it and ignore any errors
 				if failMsg != nil {
 					_, _ = w.Write(failMsg)
 				}
+
+				if s.Observer != nil {
+					s.Observer(handlerName, req.Method, code, time.Since(startTime))
+				}
 				return
 			}
 
@@ -112,6 +125,10 @@ func (s *HServer) Wrapper(handlerName string, h http.Handler, authFunc AuthFunc)
 
 		// Log the event
 		s.Logger.Info(s.SEid+10, "HTTP", logFields)
+
+		if s.Observer != nil {
+			s.Observer(handlerName, req.Method, rw.statusCode, duration)
+		}
 	})
 }
 
@@ -7,18 +7,22 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
 )
 
 // @Summary Get agent information
-// @Description Retrieves agent information with optional ID
+// @Description Retrieves agent information with optional ID, including the triggers and a summary of the requests that have not been delivered
 // @Tags Agent management
 // @Security BearerAuth
 // @Produce json
@@ -71,23 +75,31 @@ func (a *API) getAgent(req *http.Request) userver.JResponse {
 		}
 	}
 
+	// The queue is informational, so the agents are returned without it if it can't be read
+	err = a.data.AddRequestQueues(&agents)
+	if err != nil {
+		a.logger.Error(2828, fmt.Sprintf("error summarizing agent requests: %s", err.Error()), logFields)
+	}
+
 	return userver.JResponse{
 		HTTPCode: http.StatusOK,
 		JSONData: schema.APIAgentInfoResponse{
-			Status: schema.APIStatusOK,
-			Code:   http.StatusOK,
-			Data:   agents}}
+			Status:         schema.APIStatusOK,
+			Code:           http.StatusOK,
+			MinimumVersion: a.conf.SC.Get(global.ConfigMinimumAgentVersion).String(),
+			Data:           agents}}
 }
 
 // @Summary Update agent information
-// @Description updates an agent by ID
+// @Description updates an agent by ID. Setting the wipe trigger returns a confirmation code, and the wipe is not sent to the agent until it is confirmed, unless force is true.
 // @Tags Agent management
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path string true "Agent ID"
+// @Param force query bool false "Set the wipe trigger without confirmation"
 // @Param agent body schema.AgentMeta true "Agent data"
-// @Success 200 {object} schema.APIGenericResponse
+// @Success 200 {object} schema.APIWipeResponse
 // @Failure 400 {object} schema.API400
 // @Failure 401 {object} schema.API401
 // @Failure 404 {object} schema.API404
@@ -133,55 +145,189 @@ func (a *API) postAgent(req *http.Request) userver.JResponse {
 			JSONData: schema.API400{Details: "error unmarshalling JSON", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
 	}
 
-	// Get the agent's metadata
-	agents, err := a.data.GetAgentMeta(agentID)
-	if err != nil || len(agents.Agents) != 1 {
+	if !schema.ValidWipeMode(AgentMeta.Triggers.WipeMode) {
+		a.logger.Error(2999, fmt.Sprintf("invalid wipe mode: %s", AgentMeta.Triggers.WipeMode), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "wipe_mode must be " + schema.WipeModeFull + " or " + schema.WipeModeCorporate, Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	// A wipe that requires a second administrator can't be forced
+	force := req.URL.Query().Get("force") == "true"
+	if force && AgentMeta.Triggers.Wipe && a.data.ApprovalRequired(data.ApprovalWipe) {
+		a.logger.Warning(2817, "forced wipe rejected, wipe requires approval", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusForbidden,
+			JSONData: schema.API403{Details: "wipe must be confirmed by a different administrator and can't be forced", Status: schema.APIStatusError, Code: http.StatusForbidden}}
+	}
+
+	// Update the fields that are allowed to be updated in a single transaction
+	var triggers []string
+	var wipeCode string
+	var wipeExpires time.Time
+	var wipeErr error
+	err = a.data.UpdateAgentMeta(agentID, func(currentMeta *schema.AgentMeta) error {
+		if AgentMeta.FriendlyName != "" {
+			currentMeta.FriendlyName = AgentMeta.FriendlyName
+			logFields.Append(fields.NewField("friendlyName", AgentMeta.FriendlyName))
+		}
+
+		// Triggers are set, but not reset, otherwise multiple
+		// triggers would cancel each other. TriggerReset must be used
+		// to clear them.
+		if AgentMeta.Triggers.Lost {
+			currentMeta.Triggers.Lost = true
+			logFields.Append(fields.NewField("lost", "true"))
+			triggers = append(triggers, "lost")
+		}
+
+		// Unless force is specified, a wipe is not sent to the agent until it is
+		// confirmed with the code returned here
+		if AgentMeta.Triggers.Wipe {
+			logFields.Append(fields.NewField("wipe_mode", AgentMeta.Triggers.WipeMode))
+			if force {
+				currentMeta.Triggers.Wipe = true
+				currentMeta.Triggers.WipeMode = AgentMeta.Triggers.WipeMode
+				currentMeta.WipePending = nil
+				logFields.Append(fields.NewField("wipe", "true"))
+				triggers = append(triggers, "wipe")
+			} else if !currentMeta.Triggers.Wipe {
+				currentMeta.WipePending, wipeCode, wipeErr = a.data.NewWipePending(agentID, authDetails.ID)
+				if wipeErr != nil {
+					return wipeErr
+				}
+				currentMeta.WipePending.Mode = AgentMeta.Triggers.WipeMode
+				wipeExpires = currentMeta.WipePending.Expires
+				logFields.Append(fields.NewField("wipe", "pending"))
+			}
+		}
+
+		if AgentMeta.Triggers.Uninstall {
+			currentMeta.Triggers.Uninstall = true
+			logFields.Append(fields.NewField("uninstall", "true"))
+			triggers = append(triggers, "uninstall")
+		}
+		return nil
+	})
+
+	if errors.Is(err, data.ErrAgentNotFound) {
 		a.logger.Error(2889, fmt.Sprintf("agent not found: %s", agentID), logFields)
 		return userver.JResponse{
 			HTTPCode: http.StatusNotFound,
 			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
 	}
 
-	// Get the current metadata (there is only one agent in the list_
-	currentMeta := agents.Agents[0]
+	if wipeErr != nil {
+		a.logger.Error(2953, fmt.Sprintf("error creating wipe confirmation code: %s", wipeErr.Error()), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error creating wipe confirmation code", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	if err != nil {
+		a.logger.Error(2890, fmt.Sprintf("update failed: %s", err.Error()), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error updating agent metadata", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
 
-	// Update the fields that are allowed to be updated
-	if AgentMeta.FriendlyName != "" {
-		currentMeta.FriendlyName = AgentMeta.FriendlyName
-		logFields.Append(fields.NewField("friendlyName", AgentMeta.FriendlyName))
+	// Record triggers in the event store so that they are forwarded to any notification sinks
+	for _, trigger := range triggers {
+		err = a.data.AddTriggerEvent(agentID, trigger, authDetails.ID)
+		if err != nil {
+			a.logger.Error(2935, fmt.Sprintf("failed to add trigger event: %s", err.Error()), logFields)
+		}
 	}
 
-	// Triggers are set, but not reset, otherwise multiple
-	// triggers would cancel each other. TriggerReset must be used
-	// to clear them.
-	if AgentMeta.Triggers.Lost {
-		currentMeta.Triggers.Lost = true
-		logFields.Append(fields.NewField("lost", "true"))
+	if wipeCode != "" {
+		err = a.data.AddWipeEvent(agentID, "requested", authDetails.ID)
+		if err != nil {
+			a.logger.Error(2954, fmt.Sprintf("failed to add wipe event: %s", err.Error()), logFields)
+		}
+
+		a.logger.Info(2891, "agent updated", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusOK,
+			JSONData: schema.APIWipeResponse{
+				Status:           schema.APIStatusOK,
+				Code:             http.StatusOK,
+				Details:          "wipe pending, confirm with " + schema.EndpointAgent + "/" + agentID + "/wipe/confirm",
+				ConfirmationCode: wipeCode,
+				Expires:          wipeExpires}}
 	}
 
-	if AgentMeta.Triggers.Wipe {
-		currentMeta.Triggers.Wipe = true
-		logFields.Append(fields.NewField("wipe", "true"))
+	a.logger.Info(2891, "agent updated", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGenericResponse{Status: schema.APIStatusOK, Code: http.StatusOK}}
+}
+
+// @Summary Confirm agent wipe
+// @Description Sets the wipe trigger for an agent using the confirmation code returned when the wipe was requested. The wipe trigger is sent to the agent on its next sync.
+// @Tags Agent management
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Agent ID"
+// @Param request body schema.WipeConfirmRequest true "Confirmation code"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Failure 500 {object} schema.API500
+// @Router /agent/{id}/wipe/confirm [post]
+func (a *API) postAgentWipeConfirm(req *http.Request) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+	agentID := userver.GetParam(req, "id")
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("agent_id", agentID))
+
+	var wipeReq schema.WipeConfirmRequest
+	if resp := readJSON(req, &wipeReq); resp != nil {
+		return *resp
 	}
 
-	if AgentMeta.Triggers.Uninstall {
-		currentMeta.Triggers.Uninstall = true
-		logFields.Append(fields.NewField("uninstall", "true"))
+	if wipeReq.Code == "" {
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "confirmation code required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
 	}
 
-	// Update the agent's metadata
-	err = a.data.SetAgentMeta(currentMeta)
+	if a.data.AgentExists(agentID) != nil {
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
+	}
+
+	err := a.data.ConfirmWipe(agentID, wipeReq.Code, authDetails.ID)
 	if err != nil {
-		a.logger.Error(2890, fmt.Sprintf("update failed: %s", err.Error()), logFields)
+		logFields.Append(fields.NewField("error", err.Error()))
+		if errors.Is(err, data.ErrSelfApproval) {
+			a.logger.Warning(2816, "wipe not confirmed, requested by the same administrator", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusForbidden,
+				JSONData: schema.API403{Details: "wipe must be confirmed by a different administrator", Status: schema.APIStatusError, Code: http.StatusForbidden}}
+		}
+		if errors.Is(err, data.ErrNoWipePending) || errors.Is(err, data.ErrWipeCodeExpired) || errors.Is(err, data.ErrWipeCodeInvalid) {
+			a.logger.Warning(2955, "wipe not confirmed", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusBadRequest,
+				JSONData: schema.API400{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+		}
+
+		a.logger.Error(2956, "error confirming wipe", logFields)
 		return userver.JResponse{
 			HTTPCode: http.StatusInternalServerError,
-			JSONData: schema.API500{Details: "error updating agent metadata", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+			JSONData: schema.API500{Details: "error confirming wipe", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
 	}
 
-	a.logger.Info(2891, "agent updated", logFields)
+	a.logger.Info(2957, "wipe confirmed", logFields)
 	return userver.JResponse{
 		HTTPCode: http.StatusOK,
-		JSONData: schema.APIGenericResponse{Status: schema.APIStatusOK, Code: http.StatusOK}}
+		JSONData: schema.APIGenericResponse{Status: schema.APIStatusOK, Code: http.StatusOK, Details: "wipe trigger set"}}
 }
 
 // @Summary Reset agent triggers
@@ -217,29 +363,26 @@ func (a *API) putAgentResetTriggers(req *http.Request) userver.JResponse {
 	// Add agent ID to log fields
 	logFields.Append(fields.NewField("id", agentID))
 
-	// Get the agent's metadata
-	agents, err := a.data.GetAgentMeta(agentID)
-	if err != nil || len(agents.Agents) != 1 {
+	// Reset all triggers and cancel any pending wipe
+	err := a.data.UpdateAgentMeta(agentID, func(currentMeta *schema.AgentMeta) error {
+		currentMeta.Triggers = schema.NewAgentTriggers()
+		currentMeta.WipePending = nil
+		return nil
+	})
+
+	if errors.Is(err, data.ErrAgentNotFound) {
 		a.logger.Error(2893, fmt.Sprintf("agent not found: %s", agentID), logFields)
 		return userver.JResponse{
 			HTTPCode: http.StatusNotFound,
 			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
 	}
 
-	// Get the current metadata (there is only one agent in the list_
-	currentMeta := agents.Agents[0]
-
-	// Reset all triggers
-	currentMeta.Triggers = schema.NewAgentTriggers()
-
 	logFields.Append(
 		fields.NewField("lost", "false"),
 		fields.NewField("lock", "false"),
 		fields.NewField("wipe", "false"),
 		fields.NewField("uninstall", "false"))
 
-	// Update the agent's metadata
-	err = a.data.SetAgentMeta(currentMeta)
 	if err != nil {
 		a.logger.Error(2894, fmt.Sprintf("update failed: %s", err.Error()), logFields)
 		return userver.JResponse{
@@ -253,6 +396,49 @@ func (a *API) putAgentResetTriggers(req *http.Request) userver.JResponse {
 		JSONData: schema.APIGenericResponse{Status: schema.APIStatusOK, Code: http.StatusOK}}
 }
 
+// @Summary Search agents
+// @Description Finds agents with a hostname, friendly name, IP address, serial number, agent ID, or assigned user that contains the term (case-insensitive). Each result lists the fields that matched.
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Param q query string true "Search term"
+// @Success 200 {object} schema.APIAgentSearchResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /agent/search [get]
+func (a *API) getAgentSearch(req *http.Request) userver.JResponse {
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	term := strings.TrimSpace(req.URL.Query().Get("q"))
+	if term == "" {
+		a.logger.Error(2992, "no search term specified", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "search term required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	results, err := a.data.SearchAgents(term)
+	if err != nil {
+		a.logger.Error(2993, fmt.Sprintf("error searching agents: %s", err.Error()), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error searching agents", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIAgentSearchResponse{
+			Status: schema.APIStatusOK,
+			Code:   http.StatusOK,
+			Data:   results}}
+}
+
 /*
  * TAG MANAGEMENT ENDPOINTS
  */
@@ -319,6 +505,80 @@ func (a *API) getAgentsByTag(req *http.Request) userver.JResponse {
 	}
 }
 
+// @Summary List all tags
+// @Description Retrieves all tags assigned to agents with the number of agents for each
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} schema.TagsResponse
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /tags [get]
+func (a *API) getTags(_ *http.Request) userver.JResponse {
+	tags, err := a.data.GetTags()
+	if err != nil {
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error retrieving tags", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.TagsResponse{
+			Tags:   tags,
+			Status: schema.APIStatusOK,
+			Code:   http.StatusOK,
+		},
+	}
+}
+
+// @Summary Delete tag
+// @Description Removes a tag from every agent and returns the number of agents affected
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Param tag path string true "Tag"
+// @Success 200 {object} schema.TagDeleteResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /tags/{tag} [delete]
+func (a *API) deleteTag(req *http.Request) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	tag := userver.GetParam(req, "tag")
+	if strings.TrimSpace(tag) == "" {
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "tag required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+	logFields.Append(fields.NewField("tag", tag))
+
+	count, err := a.data.DeleteTag(tag)
+	if err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Error(2943, "error deleting tag", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error deleting tag", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	logFields.Append(fields.NewField("agents", count))
+	a.logger.Info(2944, "tag deleted", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.TagDeleteResponse{
+			Tag:    strings.ToLower(strings.TrimSpace(tag)),
+			Agents: count,
+			Status: schema.APIStatusOK,
+			Code:   http.StatusOK,
+		},
+	}
+}
+
 // @Summary List agent tags
 // @Description Retrieves the list of tags for the specified agent
 // @Tags Agent management
@@ -354,7 +614,7 @@ func (a *API) getAgentTags(req *http.Request) userver.JResponse {
 }
 
 // @Summary Add tags to agent
-// @Description Adds one or more tags to the specified agent (duplicates ignored)
+// @Description Adds one or more tags to the specified agent (stored in lower case, duplicates ignored)
 // @Tags Agent management
 // @Security BearerAuth
 // @Accept json
@@ -373,13 +633,6 @@ func (a *API) postAgentTagsAdd(req *http.Request) userver.JResponse {
 			HTTPCode: http.StatusBadRequest,
 			JSONData: schema.API400{Details: "agent ID required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
 	}
-	agents, err := a.data.GetAgentMeta(agentID)
-	if err != nil || len(agents.Agents) != 1 {
-		return userver.JResponse{
-			HTTPCode: http.StatusNotFound,
-			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
-	}
-	currentMeta := agents.Agents[0]
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
@@ -394,23 +647,34 @@ func (a *API) postAgentTagsAdd(req *http.Request) userver.JResponse {
 			JSONData: schema.API400{Details: "error unmarshalling JSON", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
 	}
 
-	// Add tags, ensuring uniqueness
-	tagSet := make(map[string]struct{})
-	for _, t := range currentMeta.Tags {
-		tagSet[t] = struct{}{}
+	// Validate the new tags and store them in lower case
+	if len(tagReq.Tags) == 0 {
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "at least one tag is required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
 	}
-	for _, t := range tagReq.Tags {
-		if t != "" {
-			tagSet[t] = struct{}{}
+	for i, t := range tagReq.Tags {
+		tagReq.Tags[i], err = schema.NormalizeTag(t)
+		if err != nil {
+			return userver.JResponse{
+				HTTPCode: http.StatusBadRequest,
+				JSONData: schema.API400{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
 		}
 	}
-	newTags := make([]string, 0, len(tagSet))
-	for tag := range tagSet {
-		newTags = append(newTags, tag)
-	}
-	currentMeta.Tags = newTags
 
-	if err := a.data.SetAgentMeta(currentMeta); err != nil {
+	// Add tags, ensuring uniqueness
+	var tags []string
+	err = a.data.UpdateAgentMeta(agentID, func(currentMeta *schema.AgentMeta) error {
+		currentMeta.Tags = data.MergeTags(currentMeta.Tags, tagReq.Tags)
+		tags = currentMeta.Tags
+		return nil
+	})
+	if errors.Is(err, data.ErrAgentNotFound) {
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
+	}
+	if err != nil {
 		return userver.JResponse{
 			HTTPCode: http.StatusInternalServerError,
 			JSONData: schema.API500{Details: "error updating agent tags", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
@@ -418,7 +682,7 @@ func (a *API) postAgentTagsAdd(req *http.Request) userver.JResponse {
 	return userver.JResponse{
 		HTTPCode: http.StatusOK,
 		JSONData: schema.AgentTagsResponse{
-			Tags:   currentMeta.Tags,
+			Tags:   tags,
 			Status: schema.APIStatusOK,
 			Code:   http.StatusOK,
 		},
@@ -445,13 +709,6 @@ func (a *API) postAgentTagsRemove(req *http.Request) userver.JResponse {
 			HTTPCode: http.StatusBadRequest,
 			JSONData: schema.API400{Details: "agent ID required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
 	}
-	agents, err := a.data.GetAgentMeta(agentID)
-	if err != nil || len(agents.Agents) != 1 {
-		return userver.JResponse{
-			HTTPCode: http.StatusNotFound,
-			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
-	}
-	currentMeta := agents.Agents[0]
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
@@ -470,21 +727,29 @@ func (a *API) postAgentTagsRemove(req *http.Request) userver.JResponse {
 	// Create a set of tags to remove, forcing to lower case
 	removeSet := make(map[string]struct{})
 	for _, t := range tagReq.Tags {
-		removeSet[strings.ToLower(t)] = struct{}{}
+		removeSet[strings.ToLower(strings.TrimSpace(t))] = struct{}{}
 	}
 
 	// Copy existing tags that are not in the removeSet to a new list
 	// in a case-insensitive manner
-	newTags := make([]string, 0, len(currentMeta.Tags))
-	for _, t := range currentMeta.Tags {
-		if _, found := removeSet[strings.ToLower(t)]; !found {
-			newTags = append(newTags, t)
+	var tags []string
+	err = a.data.UpdateAgentMeta(agentID, func(currentMeta *schema.AgentMeta) error {
+		newTags := make([]string, 0, len(currentMeta.Tags))
+		for _, t := range currentMeta.Tags {
+			if _, found := removeSet[strings.ToLower(t)]; !found {
+				newTags = append(newTags, t)
+			}
 		}
+		currentMeta.Tags = newTags
+		tags = newTags
+		return nil
+	})
+	if errors.Is(err, data.ErrAgentNotFound) {
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
 	}
-
-	// Update the agent's metadata
-	currentMeta.Tags = newTags
-	if err := a.data.SetAgentMeta(currentMeta); err != nil {
+	if err != nil {
 		return userver.JResponse{
 			HTTPCode: http.StatusInternalServerError,
 			JSONData: schema.API500{Details: "error updating agent tags", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
@@ -493,7 +758,7 @@ func (a *API) postAgentTagsRemove(req *http.Request) userver.JResponse {
 	return userver.JResponse{
 		HTTPCode: http.StatusOK,
 		JSONData: schema.AgentTagsResponse{
-			Tags:   currentMeta.Tags,
+			Tags:   tags,
 			Status: schema.APIStatusOK,
 			Code:   http.StatusOK,
 		},
@@ -524,13 +789,6 @@ func (a *API) postAgentUsersAdd(req *http.Request) userver.JResponse {
 			HTTPCode: http.StatusBadRequest,
 			JSONData: schema.API400{Details: "agent ID required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
 	}
-	agents, err := a.data.GetAgentMeta(agentID)
-	if err != nil || len(agents.Agents) != 1 {
-		return userver.JResponse{
-			HTTPCode: http.StatusNotFound,
-			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
-	}
-	currentMeta := agents.Agents[0]
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
@@ -565,22 +823,31 @@ func (a *API) postAgentUsersAdd(req *http.Request) userver.JResponse {
 	}
 
 	// Add users, ensuring uniqueness
-	userSet := make(map[string]struct{})
-	for _, u := range currentMeta.Users {
-		userSet[u] = struct{}{}
-	}
-	for _, u := range validUsers {
-		if u != "" {
+	var users []string
+	err = a.data.UpdateAgentMeta(agentID, func(currentMeta *schema.AgentMeta) error {
+		userSet := make(map[string]struct{})
+		for _, u := range currentMeta.Users {
 			userSet[u] = struct{}{}
 		}
+		for _, u := range validUsers {
+			if u != "" {
+				userSet[u] = struct{}{}
+			}
+		}
+		newUsers := make([]string, 0, len(userSet))
+		for user := range userSet {
+			newUsers = append(newUsers, user)
+		}
+		currentMeta.Users = newUsers
+		users = newUsers
+		return nil
+	})
+	if errors.Is(err, data.ErrAgentNotFound) {
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
 	}
-	newUsers := make([]string, 0, len(userSet))
-	for user := range userSet {
-		newUsers = append(newUsers, user)
-	}
-	currentMeta.Users = newUsers
-
-	if err := a.data.SetAgentMeta(currentMeta); err != nil {
+	if err != nil {
 		a.logger.Error(3303, fmt.Sprintf("error updating agent users: %s", err.Error()), nil)
 		return userver.JResponse{
 			HTTPCode: http.StatusInternalServerError,
@@ -593,7 +860,7 @@ func (a *API) postAgentUsersAdd(req *http.Request) userver.JResponse {
 	return userver.JResponse{
 		HTTPCode: http.StatusOK,
 		JSONData: schema.AgentUsersResponse{
-			Users:  currentMeta.Users,
+			Users:  users,
 			Status: schema.APIStatusOK,
 			Code:   http.StatusOK,
 		},
@@ -620,13 +887,6 @@ func (a *API) postAgentUsersRemove(req *http.Request) userver.JResponse {
 			HTTPCode: http.StatusBadRequest,
 			JSONData: schema.API400{Details: "agent ID required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
 	}
-	agents, err := a.data.GetAgentMeta(agentID)
-	if err != nil || len(agents.Agents) != 1 {
-		return userver.JResponse{
-			HTTPCode: http.StatusNotFound,
-			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
-	}
-	currentMeta := agents.Agents[0]
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
@@ -646,15 +906,25 @@ func (a *API) postAgentUsersRemove(req *http.Request) userver.JResponse {
 	for _, u := range userReq.Users {
 		removeSet[u] = struct{}{}
 	}
-	newUsers := make([]string, 0, len(currentMeta.Users))
-	for _, u := range currentMeta.Users {
-		if _, found := removeSet[u]; !found {
-			newUsers = append(newUsers, u)
+
+	var users []string
+	err = a.data.UpdateAgentMeta(agentID, func(currentMeta *schema.AgentMeta) error {
+		newUsers := make([]string, 0, len(currentMeta.Users))
+		for _, u := range currentMeta.Users {
+			if _, found := removeSet[u]; !found {
+				newUsers = append(newUsers, u)
+			}
 		}
+		currentMeta.Users = newUsers
+		users = newUsers
+		return nil
+	})
+	if errors.Is(err, data.ErrAgentNotFound) {
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
 	}
-	currentMeta.Users = newUsers
-
-	if err := a.data.SetAgentMeta(currentMeta); err != nil {
+	if err != nil {
 		a.logger.Error(3305, fmt.Sprintf("error updating agent users: %s", err.Error()), nil)
 		return userver.JResponse{
 			HTTPCode: http.StatusInternalServerError,
@@ -667,7 +937,7 @@ func (a *API) postAgentUsersRemove(req *http.Request) userver.JResponse {
 	return userver.JResponse{
 		HTTPCode: http.StatusOK,
 		JSONData: schema.AgentUsersResponse{
-			Users:  currentMeta.Users,
+			Users:  users,
 			Status: schema.APIStatusOK,
 			Code:   http.StatusOK,
 		},
@@ -730,3 +1000,52 @@ func (a *API) deleteAgent(req *http.Request) userver.JResponse {
 			Code:    http.StatusOK,
 			Details: "deleted"}}
 }
+
+// @Summary Revoke an agent's sessions
+// @Description Revokes all access and refresh tokens issued to an agent so far. The agent must be registered again.
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Agent ID"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Failure 500 {object} schema.API500
+// @Router /agent/{id}/revoke-sessions [post]
+func (a *API) postAgentRevokeSessions(req *http.Request) userver.JResponse {
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	agentID := userver.GetParam(req, "id")
+	if agentID == "" {
+		a.logger.Error(2989, "no agent specified", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "agent ID required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+	logFields.Append(fields.NewField("agent_id", agentID))
+
+	err := a.data.RevokeAgentSessions(agentID)
+	if err != nil {
+		if errors.Is(err, data.ErrAgentNotFound) {
+			a.logger.Warning(2990, "agent not found", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusNotFound,
+				JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
+		}
+		a.logger.Error(2990, fmt.Sprintf("error revoking sessions: %s", err.Error()), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error revoking sessions", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	a.logger.Info(2991, "agent sessions revoked", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGenericResponse{Status: schema.APIStatusOK, Code: http.StatusOK, Details: "sessions revoked"}}
+}
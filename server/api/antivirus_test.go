@@ -0,0 +1,75 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/reports"
+)
+
+func TestAntivirusProducts(t *testing.T) {
+	a := newTestAPI(t)
+
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentB")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	// The status is sent as JSON, so decode it as the API handler would
+	var status any
+	err := json.Unmarshal([]byte(`{
+		"details": {"antivirus": "yes"},
+		"antivirus_products": [
+			{"name": "SentinelOne", "version": "24.1.2", "enabled": "yes", "updated": "unknown"},
+			{"name": "Microsoft Defender Antivirus", "enabled": "no", "updated": "yes"}
+		]}`), &status)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := schema.NewAgentResponse()
+	response.Cmd = commands.Status
+	response.RequestID = "status"
+	response.Success = true
+	response.Data = status
+	a.data.AgentSync(data.SyncData{AgentID: "agentA", Responses: []schema.AgentResponse{response}})
+
+	list, err := a.data.GetAgentMeta("agentA")
+	if err != nil || len(list.Agents) != 1 || list.Agents[0].Status == nil {
+		t.Fatalf("failed to get agent status: %v", err)
+	}
+	products := list.Agents[0].Status.AntivirusProducts
+	if len(products) != 2 {
+		t.Fatalf("expected 2 antivirus products, got %v", products)
+	}
+	expected := schema.AntivirusProduct{Name: "SentinelOne", Version: "24.1.2", Enabled: "yes", Updated: "unknown"}
+	if products[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, products[0])
+	}
+	if antivirus := list.Agents[0].Status.Details.Antivirus; antivirus == nil || !*antivirus {
+		t.Errorf("expected the antivirus detail to be kept, got %v", antivirus)
+	}
+
+	report, err := reports.Get(a.data, schema.ReportRequest{Report: "antivirus", Parameters: map[string]string{}})
+	if err != nil {
+		t.Fatalf("failed to get report: %v", err)
+	}
+	text := string(report.Data)
+	if !strings.Contains(text, "agentA, , yes, SentinelOne (24.1.2, yes, unknown), Microsoft Defender Antivirus (unknown, no, yes)") {
+		t.Errorf("unexpected report for agentA:\n%s", text)
+	}
+	if !strings.Contains(text, "agentB, , not reported") {
+		t.Errorf("unexpected report for agentB:\n%s", text)
+	}
+}
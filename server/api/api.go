@@ -8,6 +8,9 @@ package api
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
@@ -15,12 +18,20 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/userver"
 	"github.com/UnifyEM/UnifyEM/server/data"
 	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/metrics"
+	"github.com/UnifyEM/UnifyEM/server/queue"
 )
 
+// corsMaxAge is the number of seconds that browsers may cache a CORS preflight response
+const corsMaxAge = 600
+
 type API struct {
-	logger interfaces.Logger
-	conf   *global.ServerConfig
-	data   *data.Data
+	logger  interfaces.Logger
+	conf    *global.ServerConfig
+	data    *data.Data
+	ready   atomic.Bool  // set once the database is open
+	started time.Time    // when the database was opened, set before ready
+	syncs   atomic.Int64 // syncs in progress
 }
 
 func New(config *global.ServerConfig, logger interfaces.Logger) *API {
@@ -30,11 +41,38 @@ func New(config *global.ServerConfig, logger interfaces.Logger) *API {
 func (a *API) Start() {
 	var err error
 
-	// Set up data access
-	a.data, err = data.New(a.conf, a.logger)
-	if err != nil {
-		a.logger.Errorf(2004, "Data error: %s", err.Error())
-		return
+	// Set up data access, retrying in case another process has the database locked
+	for {
+		a.data, err = data.New(a.conf, a.logger)
+		if err == nil {
+			break
+		}
+
+		switch {
+		case errors.Is(err, data.ErrDatabaseLocked):
+			a.logger.Errorf(2005, "Database is locked, is another instance running? %s", err.Error())
+		case errors.Is(err, data.ErrDatabaseCorrupt):
+			a.logger.Errorf(2006, "Database is corrupted, run dbcheck or enable %s: %s", global.ConfigDBAutoSalvage, err.Error())
+		default:
+			a.logger.Errorf(2004, "Data error: %s", err.Error())
+		}
+		time.Sleep(10 * time.Second)
+	}
+	a.started = time.Now()
+	a.ready.Store(true)
+
+	// Store queued messages in the database so that they survive a restart
+	a.openQueue()
+
+	// Tags are stored in lower case, convert any that were stored before this was enforced
+	a.data.NormalizeAgentTags()
+
+	// Start collecting metrics if enabled, optionally with a separate listener
+	if a.conf.SC.Get(global.ConfigMetricsEnabled).Bool() {
+		metrics.Enable()
+		if listen := a.conf.SC.Get(global.ConfigMetricsListen).String(); listen != "" {
+			go a.startMetrics(listen)
+		}
 	}
 
 	// Loop until stopped
@@ -72,14 +110,27 @@ func (a *API) startAPI() error {
 		userver.WithHTTPIdleTimeout(a.conf.SC.Get(global.ConfigHTTPIdleTimeout).Int()),
 		userver.WithHandlerTimeout(a.conf.SC.Get(global.ConfigHandlerTimeout).Int()),
 		userver.WithMaxConcurrent(a.conf.SC.Get(global.ConfigMaxConcurrent).Int()),
+		userver.WithMaxBodyBytes(int64(a.conf.SC.Get(global.ConfigMaxBodyBytes).Int())),
+		userver.WithCompression(a.conf.SC.Get(global.ConfigCompression).Bool()),
 		userver.WithPenaltyBox(
 			a.conf.SC.Get(global.ConfigPenaltyBoxMin).Int(),
 			a.conf.SC.Get(global.ConfigPenaltyBoxMax).Int()),
+		userver.WithRateLimit(
+			a.conf.SC.Get(global.ConfigLoginRateLimit).Int(),
+			a.conf.SC.Get(global.ConfigLoginRateBurst).Int()),
+		userver.WithTrustedProxies(a.settingList(global.ConfigTrustedProxies)),
 		userver.WithAuthFunc(a.NewAuthFunc(a.AuthAnyRole())),
 		userver.WithFileDir(
 			global.FileDirPattern,
 			a.conf.SC.Get(global.ConfigFilesPath).String(),
-			a.NewAuthFunc(a.AuthAnyRole())))
+			a.NewAuthFunc(a.AuthAnyRole())),
+		userver.WithFileDirExclude(global.UploadsDir, global.ReportsDir),
+		userver.WithHealthCheck("database", a.checkDatabase),
+		userver.WithHealthInfo("database_status", a.data.DatabaseStatus),
+		userver.WithHealthCheck("files", a.checkFiles),
+		userver.WithHealthCheck("queue", a.checkQueue),
+		userver.WithObserver(metrics.ObserveRequest),
+		userver.WithCORS(a.settingList(global.ConfigCORSOrigins), nil, nil, corsMaxAge))
 
 	if err != nil {
 		return err
@@ -89,272 +140,17 @@ func (a *API) startAPI() error {
 		return errors.New("userver.New() returned nil")
 	}
 
-	s.AddRoute(userver.Route{
-		Name:     "ping",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointPing,
-		JHandler: a.getPing,
-		AuthFunc: a.NewAuthFunc(a.AuthAnyRole())})
-
-	s.AddRoute(userver.Route{
-		Name:     "login",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointLogin,
-		JHandler: a.postLogin,
-		AuthFunc: nil})
-
-	s.AddRoute(userver.Route{
-		Name:     "sync",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointSync,
-		JHandler: a.postSync,
-		AuthFunc: a.NewAuthFunc(a.AuthRoles(schema.RoleAgent))})
-
-	s.AddRoute(userver.Route{
-		Name:     "register",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointRegister,
-		JHandler: a.postRegister,
-		AuthFunc: nil})
-
-	s.AddRoute(userver.Route{
-		Name:     "refresh",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointRefresh,
-		JHandler: a.postRefresh,
-		AuthFunc: nil})
-
-	s.AddRoute(userver.Route{
-		Name:     "cmd",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointCmd,
-		JHandler: a.postCmd,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent-by-tag",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointAgent + "/by-tag/{tag}",
-		JHandler: a.getAgentsByTag,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointAgent + "/{id}", // Single agent
-		JHandler: a.getAgent,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent-tags-list",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointAgent + "/{id}/tags",
-		JHandler: a.getAgentTags,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent-tags-add",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointAgent + "/{id}/tags/add",
-		JHandler: a.postAgentTagsAdd,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent-tags-remove",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointAgent + "/{id}/tags/remove",
-		JHandler: a.postAgentTagsRemove,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent-users-add",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointAgent + "/{id}/users/add",
-		JHandler: a.postAgentUsersAdd,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent-users-remove",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointAgent + "/{id}/users/remove",
-		JHandler: a.postAgentUsersRemove,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointAgent, // All agents
-		JHandler: a.getAgent,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent",
-		Methods:  []string{"POST", "PUT"}, // Allow either
-		Pattern:  schema.EndpointAgent + "/{id}",
-		JHandler: a.postAgent,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent",
-		Methods:  []string{"DELETE"},
-		Pattern:  schema.EndpointAgent + "/{id}",
-		JHandler: a.deleteAgent,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "reset",
-		Methods:  []string{"PUT", "POST"},
-		Pattern:  schema.EndpointReset + "/{id}",
-		JHandler: a.putAgentResetTriggers,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "report",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointReport,
-		JHandler: a.postReport,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "request",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointRequest + "/{id}", // One request
-		JHandler: a.getRequest,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "request",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointRequest,
-		JHandler: a.getRequest,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())}) // All requests
-
-	s.AddRoute(userver.Route{
-		Name:     "request",
-		Methods:  []string{"DELETE"},
-		Pattern:  schema.EndpointRequest + "/{id}",
-		JHandler: a.deleteRequest,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "request-cancel",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointRequest + "/{id}/cancel",
-		JHandler: a.cancelRequest,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent-requests",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointAgent + "/{id}/requests",
-		JHandler: a.getAgentRequests,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent-cancel-requests",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointAgent + "/{id}/cancel-requests",
-		JHandler: a.cancelAgentRequests,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "recovery-key",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointRecovery + "/key",
-		JHandler: a.postRecoveryKey,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agent-recovery",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointAgent + "/{id}/recovery",
-		JHandler: a.getAgentRecovery,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "regToken",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointRegToken,
-		JHandler: a.getRegToken,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "regToken-refresh",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointRegToken,
-		JHandler: a.postRegToken,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "events",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointEvents,
-		JHandler: a.getEvents,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agentsConfig",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointConfigAgents,
-		JHandler: a.getConfigAgents,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "agentsConfig",
-		Methods:  []string{"PUT", "POST"},
-		Pattern:  schema.EndpointConfigAgents,
-		JHandler: a.putConfigAgents,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "serverConfig",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointConfigServer,
-		JHandler: a.getConfigServer,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "serverConfig",
-		Methods:  []string{"PUT", "POST"},
-		Pattern:  schema.EndpointConfigServer,
-		JHandler: a.putConfigServer,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "createDeployFile",
-		Methods:  []string{"PUT", "POST"},
-		Pattern:  schema.EndpointCreateDeployFile,
-		JHandler: a.createDeployFile,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	// --- User management endpoints ---
-	s.AddRoute(userver.Route{
-		Name:     "user-list",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointUser,
-		JHandler: a.getUsers,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "user-get",
-		Methods:  []string{"GET"},
-		Pattern:  schema.EndpointUser + "/{id}",
-		JHandler: a.getUser,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "user-add",
-		Methods:  []string{"POST"},
-		Pattern:  schema.EndpointUser,
-		JHandler: a.postUser,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
-
-	s.AddRoute(userver.Route{
-		Name:     "user-delete",
-		Methods:  []string{"DELETE"},
-		Pattern:  schema.EndpointUser + "/{id}",
-		JHandler: a.deleteUser,
-		AuthFunc: a.NewAuthFunc(a.AuthAdmins())})
+	s.AddRoutes(a.routes())
+
+	// Serve metrics to administrators unless they have their own listener
+	if metrics.Enabled() && a.conf.SC.Get(global.ConfigMetricsListen).String() == "" {
+		s.AddRoutes(a.metricsRoutes(a.NewAuthFunc(a.AuthAdmins())))
+	}
+
+	// Serve the dashboard page if enabled
+	if a.conf.SC.Get(global.ConfigDashboardEnabled).Bool() {
+		s.AddRoutes(a.dashboardRoutes())
+	}
 
 	// Start the server
 	err = s.Start()
@@ -364,12 +160,647 @@ func (a *API) startAPI() error {
 	return nil
 }
 
+// settingList returns the non-empty items of a comma separated server setting, such as the
+// origins that browsers may call the API from
+func (a *API) settingList(key string) []string {
+	var items []string
+	for _, item := range a.conf.SC.Get(key).SplitList() {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// routes returns the API routes and the roles permitted to access each of them
+func (a *API) routes() userver.Routes {
+	return userver.Routes{
+		{
+			Name:     "ping",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointPing,
+			JHandler: a.getPing,
+			AuthFunc: a.NewAuthFunc(a.AuthAnyRole())},
+
+		{
+			Name:      "login",
+			Methods:   []string{"POST"},
+			Pattern:   schema.EndpointLogin,
+			JHandler:  a.postLogin,
+			AuthFunc:  nil,
+			RateLimit: true},
+
+		{
+			Name:     "sync",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointSync,
+			JHandler: a.postSync,
+			AuthFunc: a.NewAuthFunc(a.AuthRoles(schema.RoleAgent))},
+
+		{
+			Name:     "agent-upload",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointAgentUpload + "/{request_id}",
+			JHandler: a.postAgentUpload,
+			AuthFunc: a.NewAuthFunc(a.AuthRoles(schema.RoleAgent)),
+
+			// Uploads are streamed to disk and limited by file_fetch_max_mb
+			MaxBodyBytes: userver.NoBodyLimit},
+
+		{
+			Name:     "agent-upload",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointAgentUpload + "/{request_id}",
+			Handler:  http.HandlerFunc(a.getAgentUpload),
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:      "register",
+			Methods:   []string{"POST"},
+			Pattern:   schema.EndpointRegister,
+			JHandler:  a.postRegister,
+			AuthFunc:  nil,
+			RateLimit: true},
+
+		{
+			Name:      "refresh",
+			Methods:   []string{"POST"},
+			Pattern:   schema.EndpointRefresh,
+			JHandler:  a.postRefresh,
+			AuthFunc:  nil,
+			RateLimit: true},
+
+		{
+			Name:      "logout",
+			Methods:   []string{"POST"},
+			Pattern:   schema.EndpointLogout,
+			JHandler:  a.postLogout,
+			AuthFunc:  nil,
+			RateLimit: true},
+
+		{
+			Name:     "cmd",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointCmd,
+			JHandler: a.postCmd,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "cmd-bulk",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointCmdBulk,
+			JHandler: a.postCmdBulk,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "cmd-spec",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointCmdSpec,
+			JHandler: a.getCmdSpec,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "agent-by-tag",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointAgent + "/by-tag/{tag}",
+			JHandler: a.getAgentsByTag,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "agent-search",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointAgent + "/search",
+			JHandler: a.getAgentSearch,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "agent",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointAgent + "/{id}", // Single agent
+			JHandler: a.getAgent,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "tags",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointTags,
+			JHandler: a.getTags,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "tags",
+			Methods:  []string{"DELETE"},
+			Pattern:  schema.EndpointTags + "/{tag}",
+			JHandler: a.deleteTag,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "group",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointGroup,
+			JHandler: a.getGroups,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "group",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointGroup,
+			JHandler: a.postGroup,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "group",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointGroup + "/{name}",
+			JHandler: a.getGroup,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "group",
+			Methods:  []string{"DELETE"},
+			Pattern:  schema.EndpointGroup + "/{name}",
+			JHandler: a.deleteGroup,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "group-members-add",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointGroup + "/{name}/add",
+			JHandler: a.postGroupMembersAdd,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "group-members-remove",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointGroup + "/{name}/remove",
+			JHandler: a.postGroupMembersRemove,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent-tags-list",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointAgent + "/{id}/tags",
+			JHandler: a.getAgentTags,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "agent-tags-add",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointAgent + "/{id}/tags/add",
+			JHandler: a.postAgentTagsAdd,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent-tags-remove",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointAgent + "/{id}/tags/remove",
+			JHandler: a.postAgentTagsRemove,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent-wipe-confirm",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointAgent + "/{id}/wipe/confirm",
+			JHandler: a.postAgentWipeConfirm,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent-channel",
+			Methods:  []string{"POST", "PUT"},
+			Pattern:  schema.EndpointAgent + "/{id}/channel",
+			JHandler: a.postAgentChannel,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "channels",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointChannel,
+			JHandler: a.getTagChannels,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "tag-channel",
+			Methods:  []string{"POST", "PUT"},
+			Pattern:  schema.EndpointChannel + "/tag/{tag}",
+			JHandler: a.postTagChannel,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent-users-add",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointAgent + "/{id}/users/add",
+			JHandler: a.postAgentUsersAdd,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent-users-remove",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointAgent + "/{id}/users/remove",
+			JHandler: a.postAgentUsersRemove,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointAgent, // All agents
+			JHandler: a.getAgent,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "agent",
+			Methods:  []string{"POST", "PUT"}, // Allow either
+			Pattern:  schema.EndpointAgent + "/{id}",
+			JHandler: a.postAgent,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent",
+			Methods:  []string{"DELETE"},
+			Pattern:  schema.EndpointAgent + "/{id}",
+			JHandler: a.deleteAgent,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent-revoke-sessions",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointAgent + "/{id}/revoke-sessions",
+			JHandler: a.postAgentRevokeSessions,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "reset",
+			Methods:  []string{"PUT", "POST"},
+			Pattern:  schema.EndpointReset + "/{id}",
+			JHandler: a.putAgentResetTriggers,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "report",
+			Methods:  []string{"POST"}, // Generates a report but does not change anything
+			Pattern:  schema.EndpointReport,
+			JHandler: a.postReport,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "report-saved",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointSavedReport,
+			JHandler: a.getSavedReports,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "report-saved",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointSavedReport,
+			JHandler: a.postSavedReport,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "report-saved",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointSavedReport + "/{name}",
+			JHandler: a.getSavedReport,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "report-saved",
+			Methods:  []string{"PUT"},
+			Pattern:  schema.EndpointSavedReport + "/{name}",
+			JHandler: a.putSavedReport,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "report-saved",
+			Methods:  []string{"DELETE"},
+			Pattern:  schema.EndpointSavedReport + "/{name}",
+			JHandler: a.deleteSavedReport,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "report-saved-run",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointSavedReport + "/{name}/run",
+			JHandler: a.postSavedReportRun,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "request",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointRequest + "/{id}", // One request
+			JHandler: a.getRequest,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "request",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointRequest,
+			JHandler: a.getRequest,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())}, // All requests
+
+		{
+			Name:     "request",
+			Methods:  []string{"DELETE"},
+			Pattern:  schema.EndpointRequest + "/{id}",
+			JHandler: a.deleteRequest,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "request-cancel",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointRequest + "/{id}/cancel",
+			JHandler: a.cancelRequest,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "request-requeue",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointRequest + "/{id}/requeue",
+			JHandler: a.requeueRequest,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "request-approve",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointRequest + "/{id}/approve",
+			JHandler: a.approveRequest,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "request-deny",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointRequest + "/{id}/deny",
+			JHandler: a.denyRequest,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent-requests",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointAgent + "/{id}/requests",
+			JHandler: a.getAgentRequests,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "agent-cancel-requests",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointAgent + "/{id}/cancel-requests",
+			JHandler: a.cancelAgentRequests,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "recovery-key",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointRecovery + "/key",
+			JHandler: a.postRecoveryKey,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent-recovery",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointAgent + "/{id}/recovery",
+			JHandler: a.getAgentRecovery,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent-recovery-key",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointAgent + "/{id}/recovery-key",
+			JHandler: a.getAgentFDERecoveryKey,
+			AuthFunc: a.NewAuthFunc(a.AuthRoles(schema.RoleSuperAdmin))},
+
+		{
+			Name:     "shell",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointShell + "/{agent_id}",
+			JHandler: a.postShell,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "shell-session",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointShell + "/session/{session_id}",
+			JHandler: a.postShellSession,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "shell-session",
+			Methods:  []string{"DELETE"},
+			Pattern:  schema.EndpointShell + "/session/{session_id}",
+			JHandler: a.deleteShellSession,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agent-shell",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointAgentShell + "/{session_id}",
+			JHandler: a.postAgentShell,
+			AuthFunc: a.NewAuthFunc(a.AuthRoles(schema.RoleAgent))},
+
+		{
+			Name:     "regToken",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointRegToken,
+			JHandler: a.getRegToken,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "regToken-refresh",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointRegToken,
+			JHandler: a.postRegToken,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "migrationToken",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointMigrationToken,
+			JHandler: a.getMigrationToken,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "migrationToken-new",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointMigrationToken,
+			JHandler: a.postMigrationToken,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "events",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointEvents,
+			JHandler: a.getEvents,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "dashboard",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointDashboardSummary,
+			JHandler: a.getDashboardSummary,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "audit-logins",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointAuditLogins,
+			JHandler: a.getAuditLogins,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "notify-test",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointNotifyTest,
+			JHandler: a.postNotifyTest,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agentsConfig",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointConfigAgents,
+			JHandler: a.getConfigAgents,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "agentsConfig",
+			Methods:  []string{"PUT", "POST"},
+			Pattern:  schema.EndpointConfigAgents,
+			JHandler: a.putConfigAgents,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "serverConfig",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointConfigServer,
+			JHandler: a.getConfigServer,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "serverConfig",
+			Methods:  []string{"PUT", "POST"},
+			Pattern:  schema.EndpointConfigServer,
+			JHandler: a.putConfigServer,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "createDeployFile",
+			Methods:  []string{"PUT", "POST"},
+			Pattern:  schema.EndpointCreateDeployFile,
+			JHandler: a.createDeployFile,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "files-list",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointFiles,
+			JHandler: a.getFiles,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "files-upload",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointFiles,
+			Handler:  http.HandlerFunc(a.postFile),
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins()),
+
+			// Files are streamed to disk and limited by file_upload_max_mb
+			MaxBodyBytes: userver.NoBodyLimit},
+
+		// --- User management endpoints ---
+		{
+			Name:     "user-list",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointUser,
+			JHandler: a.getUsers,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "user-get",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointUser + "/{id}",
+			JHandler: a.getUser,
+			AuthFunc: a.NewAuthFunc(a.AuthReaders())},
+
+		{
+			Name:     "user-add",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointUser,
+			JHandler: a.postUser,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "user-delete",
+			Methods:  []string{"DELETE"},
+			Pattern:  schema.EndpointUser + "/{id}",
+			JHandler: a.deleteUser,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "user-revoke-sessions",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointUser + "/{id}/revoke-sessions",
+			JHandler: a.postUserRevokeSessions,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "backup",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointBackup,
+			Handler:  http.HandlerFunc(a.getBackup),
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "prune",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointPrune,
+			JHandler: a.postPrune,
+			AuthFunc: a.NewAuthFunc(a.AuthAdmins())},
+
+		{
+			Name:     "export",
+			Methods:  []string{"GET"},
+			Pattern:  schema.EndpointExport,
+			Handler:  http.HandlerFunc(a.getExport),
+			AuthFunc: a.NewAuthFunc(a.AuthRoles(schema.RoleSuperAdmin))},
+
+		{
+			Name:     "import",
+			Methods:  []string{"POST"},
+			Pattern:  schema.EndpointImport,
+			JHandler: a.postImport,
+			AuthFunc: a.NewAuthFunc(a.AuthRoles(schema.RoleSuperAdmin)),
+
+			// Exports include every agent, event, and request and can be large
+			MaxBodyBytes: userver.NoBodyLimit},
+	}
+}
+
 // Close closes open files, etc.
 func (a *API) Close() {
-	a.data.Close()
+	if a.Ready() {
+		queue.Close()
+		a.data.Close()
+	}
+}
+
+// Ready returns true once the database is open. Until then, tasks must not be run.
+func (a *API) Ready() bool {
+	return a.ready.Load()
 }
 
 // PruneDB provides a way for the app to trigger database pruning
 func (a *API) PruneDB() {
 	a.data.PruneDB()
 }
+
+// CheckOfflineAgents provides a way for the app to trigger detection of agents that have gone offline
+func (a *API) CheckOfflineAgents() {
+	// Agents can't sync while the server is down, so give them a chance to after it starts
+	threshold := time.Duration(a.conf.SC.Get(global.ConfigAgentOfflineThreshold).Int()) * time.Minute
+	if time.Since(a.started) < threshold {
+		return
+	}
+	a.data.CheckOfflineAgents()
+}
+
+// ExpireRequests provides a way for the app to trigger expiry of requests that exhausted their retries
+func (a *API) ExpireRequests() {
+	a.data.ExpireAgentRequests()
+}
@@ -0,0 +1,120 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestRequestApproval(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigApprovalCommands, "execute, wipe")
+	if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: "agentA", Active: true}); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	add := func() string {
+		t.Helper()
+		requestID, err := a.data.AddAgentRequest(schema.AgentRequest{
+			AgentID:    "agentA",
+			Request:    commands.Execute,
+			Requester:  "alice",
+			Parameters: map[string]string{"agent_id": "agentA", "cmd": "/bin/true"}})
+		if err != nil {
+			t.Fatalf("failed to add request: %v", err)
+		}
+		return requestID
+	}
+	status := func(requestID string) schema.AgentRequestRecord {
+		t.Helper()
+		records, err := a.data.GetRequestRecord(requestID)
+		if err != nil || len(records.Requests) != 1 {
+			t.Fatalf("GetRequestRecord failed: %v", err)
+		}
+		return records.Requests[0]
+	}
+
+	approved := add()
+	if request := status(approved); request.Status != schema.RequestStatusAwaitingApproval {
+		t.Fatalf("expected the request to await approval, got %q", request.Status)
+	}
+	if sent, _ := a.data.GetAgentRequests("agentA", true); len(sent) != 0 {
+		t.Fatalf("request awaiting approval was sent")
+	}
+
+	// The requester can't approve their own request
+	if err := a.data.ApproveAgentRequest(approved, "alice"); !errors.Is(err, data.ErrSelfApproval) {
+		t.Errorf("expected ErrSelfApproval, got %v", err)
+	}
+
+	if err := a.data.ApproveAgentRequest(approved, "bob"); err != nil {
+		t.Fatalf("ApproveAgentRequest failed: %v", err)
+	}
+	request := status(approved)
+	if request.Status != schema.RequestStatusNew || request.Reviewer != "bob" || request.TimeReviewed.IsZero() {
+		t.Errorf("unexpected approved request: %+v", request)
+	}
+	if err := a.data.ApproveAgentRequest(approved, "bob"); !errors.Is(err, data.ErrNotAwaitingApproval) {
+		t.Errorf("expected ErrNotAwaitingApproval, got %v", err)
+	}
+
+	denied := add()
+	if err := a.data.DenyAgentRequest(denied, "bob"); err != nil {
+		t.Fatalf("DenyAgentRequest failed: %v", err)
+	}
+	if request = status(denied); request.Status != schema.RequestStatusDenied || request.TimeCompleted.IsZero() {
+		t.Errorf("unexpected denied request: %+v", request)
+	}
+
+	// Only the approved request is sent
+	sent, _ := a.data.GetAgentRequests("agentA", true)
+	if len(sent) != 1 || sent[0].RequestID != approved {
+		t.Errorf("expected only the approved request to be sent, got %+v", sent)
+	}
+
+	events, err := a.data.GetEvents("agentA", 0, 0, schema.AgentEventApproval)
+	if err != nil || len(events) != 4 {
+		t.Errorf("expected four approval events, got %d (%v)", len(events), err)
+	}
+
+	// Commands that are not listed are sent without approval
+	requestID, _ := a.data.AddAgentRequest(schema.AgentRequest{AgentID: "agentA", Request: commands.Ping, Requester: "alice"})
+	if request = status(requestID); request.Status != schema.RequestStatusNew {
+		t.Errorf("expected ping not to require approval, got %q", request.Status)
+	}
+}
+
+func TestWipeApproval(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigApprovalCommands, data.ApprovalWipe)
+
+	meta := schema.NewAgentMeta("agentA")
+	pending, code, err := a.data.NewWipePending("agentA", "alice")
+	if err != nil {
+		t.Fatalf("NewWipePending failed: %v", err)
+	}
+	meta.WipePending = pending
+	if err = a.data.SetAgentMeta(meta); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	if err = a.data.ConfirmWipe("agentA", code, "alice"); !errors.Is(err, data.ErrSelfApproval) {
+		t.Errorf("expected ErrSelfApproval, got %v", err)
+	}
+	if err = a.data.ConfirmWipe("agentA", code, "bob"); err != nil {
+		t.Fatalf("ConfirmWipe failed: %v", err)
+	}
+	agents, _ := a.data.GetAgentMeta("agentA")
+	if !agents.Agents[0].Triggers.Wipe {
+		t.Errorf("expected wipe trigger set")
+	}
+}
@@ -0,0 +1,92 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+)
+
+// @Summary Retrieve login audit events
+// @Description Retrieves the audit trail of administrator login successes, failures, and lockouts
+// @Tags Audit
+// @Security BearerAuth
+// @Produce json
+// @Param start_time query string false "Start time in Unix timestamp format"
+// @Param end_time query string false "End time in Unix timestamp format"
+// @Param user query string false "Username"
+// @Success 200 {object} schema.APILoginAuditResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /audit/logins [get]
+func (a *API) getAuditLogins(req *http.Request) userver.JResponse {
+	var err error
+	var startT, endT int64
+
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	// Parse the query parameters
+	query := req.URL.Query()
+	user := query.Get("user")
+	startTimeStr := query.Get("start_time")
+	endTimeStr := query.Get("end_time")
+
+	if user != "" {
+		logFields.Append(fields.NewField("user", user))
+	}
+
+	if startTimeStr != "" {
+		startT, err = strconv.ParseInt(startTimeStr, 10, 64)
+		if err != nil {
+			msg := fmt.Sprintf("invalid start_time: %s", err.Error())
+			logFields.Append(fields.NewField("error", msg))
+			a.logger.Info(2919, "login audit API error", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusBadRequest,
+				JSONData: schema.API400{Details: msg, Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+		}
+	}
+
+	if endTimeStr != "" {
+		endT, err = strconv.ParseInt(endTimeStr, 10, 64)
+		if err != nil {
+			msg := fmt.Sprintf("invalid end_time: %s", err.Error())
+			logFields.Append(fields.NewField("error", msg))
+			a.logger.Info(2919, "login audit API error", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusBadRequest,
+				JSONData: schema.API400{Details: msg, Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+		}
+	}
+
+	events, err := a.data.GetLoginAudit(startT, endT, user)
+	if err != nil {
+		a.logger.Error(2920, fmt.Sprintf("error retrieving login audit: %s", err.Error()), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error retrieving login audit", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	a.logger.Info(2921, "login audit retrieved", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APILoginAuditResponse{
+			Status: schema.APIStatusOK,
+			Code:   http.StatusOK,
+			Data:   events}}
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 
@@ -26,12 +27,22 @@ type AuthInfo struct {
 	ID            string // authenticated user/agent or ""
 	Role          int    // authenticated role or 0
 	Authenticated bool   // flag set if the user is authenticated
+	Forbidden     bool   // flag set if the user authenticated but their role is not permitted
 }
 
 func (a AuthInfo) IsAuthenticated() bool {
 	return a.Authenticated
 }
 
+// StatusCode implements userver.AuthStatus so that valid credentials
+// with an insufficient role receive 403 rather than 401
+func (a AuthInfo) StatusCode() int {
+	if a.Forbidden {
+		return http.StatusForbidden
+	}
+	return 0
+}
+
 // NewAuthFunc returns an AuthFunc with acceptable roles set
 func (a *API) NewAuthFunc(acceptableRoles []int) userver.AuthFunc {
 	return func(ip, authHeader string) (bool, []byte, any) {
@@ -76,7 +87,7 @@ func (a *API) NewAuthFunc(acceptableRoles []int) userver.AuthFunc {
 		logFields.Append(fields.NewField("id", user), fields.NewField("role", role))
 
 		// If the user is an admin, checked the list of authorized IP addresses
-		if role == schema.RoleAdmin || role == schema.RoleSuperAdmin {
+		if role == schema.RoleAdmin || role == schema.RoleSuperAdmin || role == schema.RoleReadOnly {
 			if !a.AuthorizedAdminIP(ip) {
 				a.logger.Info(2834, "authentication failure: IP not authorized", logFields)
 				return false, a.AuthFailMessage(false), authFail
@@ -92,7 +103,8 @@ func (a *API) NewAuthFunc(acceptableRoles []int) userver.AuthFunc {
 		}
 
 		a.logger.Warning(2836, "authentication failure: role not authorized", logFields)
-		return false, a.AuthFailMessage(false), authFail
+		authFail.Forbidden = true
+		return false, a.AuthForbiddenMessage(), authFail
 	}
 }
 
@@ -106,17 +118,36 @@ func (a *API) AuthAdmins() []int {
 	return []int{schema.RoleAdmin, schema.RoleSuperAdmin}
 }
 
+// AuthReaders returns the admin roles plus the read-only role. It is
+// intended for routes that view, but do not change, information.
+func (a *API) AuthReaders() []int {
+	return []int{schema.RoleAdmin, schema.RoleSuperAdmin, schema.RoleReadOnly}
+}
+
 // AuthAnyRole returns a list of all roles
 func (a *API) AuthAnyRole() []int {
 	return schema.RolesAll
 }
 
+// AuthRoleListed returns true if the role is named in a server setting containing a comma
+// separated list of roles
+func (a *API) AuthRoleListed(setting string, role int) bool {
+	for _, name := range a.conf.SC.Get(setting).SplitList() {
+		if r := schema.RoleByName(strings.TrimSpace(name)); r != schema.RoleNone && r == role {
+			return true
+		}
+	}
+	return false
+}
+
 // AuthFailMessage returns a generic response for authentication failures
 // The only variation is for expired tokens
 func (a *API) AuthFailMessage(expired bool) []byte {
 
-	// Start with a standard auth failure response
+	// Start with a standard auth failure response, including the server's time so that
+	// agents can detect clock skew
 	msg := authFailResponse
+	msg.ServerTime = time.Now().Unix()
 
 	// If expired, update the response
 	if expired {
@@ -133,6 +164,19 @@ func (a *API) AuthFailMessage(expired bool) []byte {
 	return response
 }
 
+// AuthForbiddenMessage returns the response for authenticated users whose role is not permitted
+func (a *API) AuthForbiddenMessage() []byte {
+	response, err := json.Marshal(schema.API403{
+		Status:  schema.APIStatusError,
+		Code:    http.StatusForbidden,
+		Details: "not authorized for this operation"})
+	if err != nil {
+		a.logger.Error(2839, fmt.Sprintf("error marshalling failure response: %s", err.Error()), nil)
+		return nil
+	}
+	return response
+}
+
 func GetAuthDetails(req *http.Request) AuthInfo {
 	details, ok := req.Context().Value("authDetails").(AuthInfo)
 	if !ok {
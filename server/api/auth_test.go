@@ -0,0 +1,386 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/uconfig"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// readOnlyRoutes lists the routes, by name and method, that the read-only role may access
+var readOnlyRoutes = map[string]bool{
+	"ping GET":            true,
+	"agent-by-tag GET":    true,
+	"agent-search GET":    true,
+	"agent GET":           true,
+	"agent-tags-list GET": true,
+	"tags GET":            true,
+	"group GET":           true,
+	"report POST":         true,
+	"report-saved GET":    true,
+	"request GET":         true,
+	"agent-requests GET":  true,
+	"events GET":          true,
+	"dashboard GET":       true,
+	"user-list GET":       true,
+	"user-get GET":        true,
+	"channels GET":        true,
+	"files-list GET":      true,
+	"cmd-spec GET":        true,
+}
+
+// testJWTKey is used to sign tokens in tests
+const testJWTKey = "test-jwt-key"
+
+// newTestAPI creates an API backed by a temporary database
+func newTestAPI(t testing.TB) *API {
+	t.Helper()
+
+	c := uconfig.Null()
+	conf := &global.ServerConfig{
+		C:  c,
+		SC: c.NewSet(global.ConfigServerSet),
+		SP: c.NewSet(global.ConfigPrivate),
+		AC: schema.SetAgentDefaults(c),
+	}
+	conf.SC.Set(global.ConfigDBPath, t.TempDir())
+	conf.SC.Set(global.ConfigAuthorizedAdminIPs, "127.0.0.1")
+	conf.SP.Set(global.ConfigJWTKey, testJWTKey)
+
+	d, err := data.New(conf, null.Logger())
+	if err != nil {
+		t.Fatalf("failed to create data instance: %v", err)
+	}
+	t.Cleanup(d.Close)
+
+	return &API{logger: null.Logger(), conf: conf, data: d}
+}
+
+// loginToken creates a user with the specified role and returns an access token
+func loginToken(t *testing.T, a *API, user string, role int) string {
+	t.Helper()
+
+	if err := a.data.SetAuth(user, "password", role); err != nil {
+		t.Fatalf("failed to set auth: %v", err)
+	}
+
+	token, _, err := a.data.LoginGetToken(user, "password", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+	return "Bearer " + token
+}
+
+func TestReadOnlyRoleRoutes(t *testing.T) {
+	a := newTestAPI(t)
+	token := loginToken(t, a, "helpdesk", schema.RoleReadOnly)
+
+	for _, route := range a.routes() {
+		if route.AuthFunc == nil {
+			continue
+		}
+
+		for _, method := range route.Methods {
+			name := route.Name + " " + method
+			ok, _, details := route.AuthFunc("127.0.0.1", token)
+
+			if readOnlyRoutes[name] {
+				if !ok {
+					t.Errorf("%s %s: read-only role should be permitted", method, route.Pattern)
+				}
+				continue
+			}
+
+			if ok {
+				t.Errorf("%s %s: read-only role should be rejected", method, route.Pattern)
+				continue
+			}
+
+			info, isInfo := details.(AuthInfo)
+			if !isInfo || info.StatusCode() != http.StatusForbidden {
+				t.Errorf("%s %s: expected 403 for read-only role", method, route.Pattern)
+			}
+		}
+	}
+}
+
+func TestReadOnlyRoleMutationsBlocked(t *testing.T) {
+	a := newTestAPI(t)
+	token := loginToken(t, a, "helpdesk", schema.RoleReadOnly)
+
+	for _, route := range a.routes() {
+		if route.AuthFunc == nil || route.Name == "report" {
+			continue
+		}
+
+		for _, method := range route.Methods {
+			if method == http.MethodGet {
+				continue
+			}
+
+			if ok, _, _ := route.AuthFunc("127.0.0.1", token); ok {
+				t.Errorf("%s %s: mutation permitted for read-only role", method, route.Pattern)
+			}
+		}
+	}
+}
+
+func TestAdminRoleRoutes(t *testing.T) {
+	a := newTestAPI(t)
+	token := loginToken(t, a, "admin", schema.RoleAdmin)
+
+	for _, route := range a.routes() {
+		if route.AuthFunc == nil || route.Name == "sync" || route.Name == "agent-recovery-key" || route.Name == "agent-shell" ||
+			route.Name == "export" || route.Name == "import" ||
+			(route.Name == "agent-upload" && route.Methods[0] == http.MethodPost) {
+			continue
+		}
+
+		if ok, _, _ := route.AuthFunc("127.0.0.1", token); !ok {
+			t.Errorf("%s: admin role should be permitted", route.Pattern)
+		}
+	}
+}
+
+func TestTokenLeeway(t *testing.T) {
+	a := newTestAPI(t)
+
+	// Sign an access token that expired a minute ago
+	now := time.Now()
+	claims := data.CustomClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "admin",
+			IssuedAt:  jwt.NewNumericDate(now.Add(-time.Hour)),
+			NotBefore: jwt.NewNumericDate(now.Add(-time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(-time.Minute)),
+		},
+		Role:    schema.RoleAdmin,
+		Purpose: schema.TokenPurposeAccess,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTKey))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	auth := a.NewAuthFunc(a.AuthAdmins())
+
+	a.conf.SC.Set(global.ConfigTokenLeeway, 120)
+	if ok, _, _ := auth("127.0.0.1", "Bearer "+token); !ok {
+		t.Errorf("token within the leeway should be accepted")
+	}
+
+	// Without leeway the token has expired, and the response includes the server's time
+	a.conf.SC.Set(global.ConfigTokenLeeway, 0)
+	ok, msg, _ := auth("127.0.0.1", "Bearer "+token)
+	if ok {
+		t.Fatalf("expired token should be rejected")
+	}
+
+	var resp schema.API401
+	if err = json.Unmarshal(msg, &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Status != schema.APIStatusExpired || resp.ServerTime < now.Unix() || resp.ServerTime > time.Now().Unix() {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	a := newTestAPI(t)
+
+	if err := a.data.SetAuth("admin", "password", schema.RoleAdmin); err != nil {
+		t.Fatalf("failed to set auth: %v", err)
+	}
+	_, refreshToken, err := a.data.LoginGetToken("admin", "password", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	if _, err = a.data.RefreshToken(refreshToken, "", ""); err != nil {
+		t.Fatalf("refresh failed before logout: %v", err)
+	}
+
+	logout := func() int {
+		body, _ := json.Marshal(schema.RefreshRequest{RefreshToken: refreshToken})
+		return a.postLogout(httptest.NewRequest("POST", schema.EndpointLogout, bytes.NewReader(body))).HTTPCode
+	}
+
+	if code := logout(); code != http.StatusOK {
+		t.Fatalf("expected logout to succeed, got %d", code)
+	}
+	if _, err = a.data.RefreshToken(refreshToken, "", ""); err == nil {
+		t.Errorf("revoked refresh token was accepted")
+	}
+
+	// A revoked token can't be used to log out again
+	if code := logout(); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a revoked token, got %d", code)
+	}
+
+	// Revocations are kept until the token expires
+	a.data.PruneDB()
+	if _, err = a.data.RefreshToken(refreshToken, "", ""); err == nil {
+		t.Errorf("revoked refresh token was accepted after pruning")
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	a := newTestAPI(t)
+	admin := loginToken(t, a, "admin", schema.RoleAdmin)
+	token := loginToken(t, a, "operator", schema.RoleAdmin)
+	_, refreshToken, err := a.data.LoginGetToken("operator", "password", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+	auth := a.NewAuthFunc(a.AuthAdmins())
+
+	revoke := func(user string) int {
+		req := httptest.NewRequest("POST", schema.EndpointUser+"/"+user+"/revoke-sessions", nil)
+		return a.postUserRevokeSessions(mux.SetURLVars(req, map[string]string{"id": user})).HTTPCode
+	}
+
+	if code := revoke("nobody"); code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown user, got %d", code)
+	}
+	if code := revoke("operator"); code != http.StatusOK {
+		t.Fatalf("expected revocation to succeed, got %d", code)
+	}
+
+	ok, msg, _ := auth("127.0.0.1", token)
+	if ok {
+		t.Fatalf("revoked access token was accepted")
+	}
+	var resp schema.API401
+	if err = json.Unmarshal(msg, &resp); err != nil || resp.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a revoked access token, got %s", msg)
+	}
+	if _, err = a.data.RefreshToken(refreshToken, "", ""); err == nil {
+		t.Errorf("revoked refresh token was accepted")
+	}
+
+	// Other users are not affected
+	if ok, _, _ = auth("127.0.0.1", admin); !ok {
+		t.Errorf("another user's token was rejected")
+	}
+
+	// Tokens are issued with a resolution of one second, so logging in again must wait
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	if ok, _, _ = auth("127.0.0.1", loginToken(t, a, "operator", schema.RoleAdmin)); !ok {
+		t.Errorf("token issued after the revocation was rejected")
+	}
+}
+
+func TestConcurrentLoginLockout(t *testing.T) {
+	const maxFailures = 5
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigLoginMaxFailures, maxFailures)
+	a.conf.SC.Set(global.ConfigLoginLockoutMinutes, 15)
+	if err := a.data.SetAuth("admin", "password", schema.RoleSuperAdmin); err != nil {
+		t.Fatalf("failed to set auth: %v", err)
+	}
+
+	// Every failure must be counted, so exactly maxFailures attempts are checked before the lock
+	var wg sync.WaitGroup
+	errs := make(chan error, maxFailures*4)
+	for x := 0; x < maxFailures*4; x++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := a.data.Auth("admin", "wrong")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	invalid, newLocks, refused := 0, 0, 0
+	for err := range errs {
+		var locked *data.AccountLockedError
+		switch {
+		case errors.As(err, &locked) && locked.New:
+			newLocks++
+		case errors.As(err, &locked):
+			refused++
+		case err != nil && err.Error() == "invalid password":
+			invalid++
+		default:
+			t.Errorf("unexpected result: %v", err)
+		}
+	}
+	if invalid != maxFailures-1 || newLocks != 1 || refused != maxFailures*3 {
+		t.Errorf("expected %d failures, 1 lock, and %d refusals, got %d, %d, and %d",
+			maxFailures-1, maxFailures*3, invalid, newLocks, refused)
+	}
+
+	var locked *data.AccountLockedError
+	if _, err := a.data.Auth("admin", "password"); !errors.As(err, &locked) {
+		t.Errorf("expected the account to be locked, got %v", err)
+	}
+}
+
+func TestLoginRecovery(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigLoginMaxFailures, 2)
+	a.conf.SC.Set(global.ConfigLoginLockoutMinutes, 15)
+	if err := a.data.SetAuth("admin", "password", schema.RoleSuperAdmin); err != nil {
+		t.Fatalf("failed to set auth: %v", err)
+	}
+	if err := a.data.SetAuth("A-agent", "secret", schema.RoleAgent); err != nil {
+		t.Fatalf("failed to set auth: %v", err)
+	}
+
+	for x := 0; x < 2; x++ {
+		_, _, _ = a.data.LoginGetToken("admin", "wrong", "127.0.0.1")
+	}
+	var locked *data.AccountLockedError
+	if _, _, err := a.data.LoginGetToken("admin", "password", "127.0.0.1"); !errors.As(err, &locked) {
+		t.Fatalf("expected the account to be locked, got %v", err)
+	}
+
+	// Agents are not login accounts
+	logins, err := a.data.ListLogins()
+	if err != nil || len(logins) != 1 || logins[0].User != "admin" || !logins[0].Locked() {
+		t.Fatalf("unexpected login accounts: %+v (%v)", logins, err)
+	}
+	if err = a.data.DeleteLogin("A-agent"); !errors.Is(err, data.ErrLoginNotFound) {
+		t.Errorf("expected ErrLoginNotFound for an agent, got %v", err)
+	}
+
+	if err = a.data.UnlockLogin("admin"); err != nil {
+		t.Fatalf("unlock failed: %v", err)
+	}
+	if _, _, err = a.data.LoginGetToken("admin", "password", "127.0.0.1"); err != nil {
+		t.Errorf("login failed after unlock: %v", err)
+	}
+
+	if err = a.data.SetLoginRole("admin", schema.RoleReadOnly); err != nil {
+		t.Fatalf("role change failed: %v", err)
+	}
+	if role, err := a.data.Auth("admin", "password"); err != nil || role != schema.RoleReadOnly {
+		t.Errorf("expected the readonly role, got %d (%v)", role, err)
+	}
+
+	if err = a.data.DeleteLogin("admin"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if logins, _ = a.data.ListLogins(); len(logins) != 0 {
+		t.Errorf("login account was not deleted: %+v", logins)
+	}
+}
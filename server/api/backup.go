@@ -0,0 +1,76 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// @Summary Download a database backup
+// @Description Streams a consistent snapshot of the database. Other operations continue while the snapshot is written.
+// @Tags Server management
+// @Security BearerAuth
+// @Produce octet-stream
+// @Success 200 {file} file
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /admin/backup [get]
+func (a *API) getBackup(w http.ResponseWriter, req *http.Request) {
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	// A large database may take longer to send than the usual write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	name := strings.ToLower(global.Name) + "-" + time.Now().Format("20060102-150405") + ".db"
+
+	// Headers are sent once the size of the snapshot is known. The Content-Length allows the
+	// client to detect a download that ends early.
+	started := false
+	size, err := a.data.Backup(w, func(size int64) {
+		started = true
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	logFields.Append(fields.NewField("size", size))
+	if err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Error(2972, "database backup failed", logFields)
+
+		// Once the status has been sent, the response can only be cut short
+		if !started {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(schema.API500{Details: "backup failed", Status: schema.APIStatusError, Code: http.StatusInternalServerError})
+		}
+		return
+	}
+	a.logger.Info(2973, "database backup downloaded", logFields)
+}
+
+// Snapshot provides a way for the app to trigger a scheduled database snapshot
+func (a *API) Snapshot() {
+	if _, err := a.data.Snapshot(); err != nil {
+		a.logger.Errorf(2974, "database snapshot failed: %s", err.Error())
+	}
+}
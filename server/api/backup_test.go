@@ -0,0 +1,146 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestBackup(t *testing.T) {
+	a := newTestAPI(t)
+
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	a.getBackup(w, httptest.NewRequest("GET", schema.EndpointBackup, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Length") != strconv.Itoa(w.Body.Len()) {
+		t.Errorf("Content-Length %s does not match body length %d", w.Header().Get("Content-Length"), w.Body.Len())
+	}
+
+	// The backup must be a usable database containing the agent
+	path := filepath.Join(t.TempDir(), "backup.db")
+	if err := os.WriteFile(path, w.Body.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write backup: %v", err)
+	}
+	bdb, err := bbolt.Open(path, 0600, &bbolt.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("backup is not a valid database: %v", err)
+	}
+	defer func() {
+		_ = bdb.Close()
+	}()
+
+	err = bdb.View(func(tx *bbolt.Tx) error {
+		if b := tx.Bucket([]byte("AgentMeta")); b == nil || b.Get([]byte("agentA")) == nil {
+			t.Errorf("agent missing from backup")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read backup: %v", err)
+	}
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	a := newTestAPI(t)
+	dir := t.TempDir()
+	a.conf.SC.Set(global.ConfigBackupPath, dir)
+	a.conf.SC.Set(global.ConfigBackupRetention, 2)
+
+	// Older snapshots and unrelated files
+	for _, name := range []string{"snapshot-20200101-000000.db", "snapshot-20200102-000000.db", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	name, err := a.data.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read backup path: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	expected := []string{"notes.txt", "snapshot-20200102-000000.db", filepath.Base(name)}
+	if len(names) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, names)
+		}
+	}
+}
+
+func TestPruneCompact(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigAgentRetention, 30)
+	a.conf.SC.Set(global.ConfigCompactThreshold, 10)
+
+	// Agents that haven't been seen for a year and one that is active
+	for i := 0; i < 2000; i++ {
+		meta := schema.NewAgentMeta(fmt.Sprintf("old%04d", i))
+		meta.LastSeen = time.Now().AddDate(-1, 0, 0)
+		meta.FriendlyName = strings.Repeat("x", 1000)
+		if err := a.data.SetAgentMeta(meta); err != nil {
+			t.Fatalf("failed to create agent: %v", err)
+		}
+	}
+	active := schema.NewAgentMeta("active")
+	active.LastSeen = time.Now()
+	if err := a.data.SetAgentMeta(active); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	summary := a.data.PruneDB()
+	if summary.Agents != 2000 {
+		t.Errorf("expected 2000 agents pruned, got %d", summary.Agents)
+	}
+	if !summary.Compacted || summary.SizeAfter >= summary.SizeBefore {
+		t.Errorf("expected the database to be compacted: %+v", summary)
+	}
+
+	// The database is usable after it is replaced
+	if _, err := a.data.GetAgentMeta("active"); err != nil {
+		t.Errorf("active agent missing after compaction: %v", err)
+	}
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("new")); err != nil {
+		t.Errorf("failed to write after compaction: %v", err)
+	}
+	dbFile, err := data.DatabaseFile(a.conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(dbFile + ".compact"); !os.IsNotExist(err) {
+		t.Errorf("temporary file was not removed: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// @Summary Set agent upgrade channel
+// @Description Assigns an upgrade channel to the specified agent. An empty channel removes the assignment.
+// @Tags Agent management
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Agent ID"
+// @Param channel body schema.ChannelRequest true "Upgrade channel"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Failure 500 {object} schema.API500
+// @Router /agent/{id}/channel [post]
+func (a *API) postAgentChannel(req *http.Request) userver.JResponse {
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	agentID := userver.GetParam(req, "id")
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("agent_id", agentID))
+
+	channel, errResp := readChannelRequest(req)
+	if errResp != nil {
+		return *errResp
+	}
+	logFields.Append(fields.NewField("channel", channel))
+
+	if err := a.data.AgentExists(agentID); err != nil {
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
+	}
+
+	if err := a.data.SetAgentChannel(agentID, channel); err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Error(2926, "error setting agent channel", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error setting agent channel", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	a.logger.Info(2927, "agent channel set", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGenericResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: channelDetails(channel)}}
+}
+
+// @Summary Set tag upgrade channel
+// @Description Assigns an upgrade channel to agents with the specified tag. A channel assigned directly
+// @Description to an agent takes precedence. An empty channel removes the assignment.
+// @Tags Agent management
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param tag path string true "Tag"
+// @Param channel body schema.ChannelRequest true "Upgrade channel"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /channel/tag/{tag} [post]
+func (a *API) postTagChannel(req *http.Request) userver.JResponse {
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	tag := userver.GetParam(req, "tag")
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("tag", tag))
+
+	if tag == "" {
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "tag required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	channel, errResp := readChannelRequest(req)
+	if errResp != nil {
+		return *errResp
+	}
+	logFields.Append(fields.NewField("channel", channel))
+
+	if err := a.data.SetTagChannel(tag, channel); err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Error(2928, "error setting tag channel", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error setting tag channel", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	a.logger.Info(2929, "tag channel set", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGenericResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: channelDetails(channel)}}
+}
+
+// @Summary List tag upgrade channels
+// @Description Returns the upgrade channels assigned to tags
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} schema.APITagChannelsResponse
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /channel [get]
+func (a *API) getTagChannels(_ *http.Request) userver.JResponse {
+	channels, err := a.data.GetTagChannels()
+	if err != nil {
+		a.logger.Error(2930, "error retrieving tag channels", fields.NewFields(fields.NewField("error", err.Error())))
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error retrieving tag channels", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APITagChannelsResponse{
+			Status:   schema.APIStatusOK,
+			Code:     http.StatusOK,
+			Channels: channels}}
+}
+
+// readChannelRequest reads and validates a schema.ChannelRequest from the request body
+func readChannelRequest(req *http.Request) (string, *userver.JResponse) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", &userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "error reading body", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	var channelReq schema.ChannelRequest
+	if err = json.Unmarshal(body, &channelReq); err != nil {
+		return "", &userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "error unmarshalling JSON", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	// The uploads and reports directories are reserved for files fetched from agents and saved reports
+	channel := strings.ToLower(channelReq.Channel)
+	if !schema.ValidChannel(channel) || channel == global.UploadsDir || channel == global.ReportsDir {
+		return "", &userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "invalid channel", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+	return channel, nil
+}
+
+// channelDetails returns the details message for a channel assignment
+func channelDetails(channel string) string {
+	if channel == "" {
+		return "channel removed"
+	}
+	return "channel set to " + channel
+}
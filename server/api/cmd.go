@@ -64,13 +64,47 @@ func (a *API) postCmd(req *http.Request) userver.JResponse {
 		fields.NewField("cmd", cmd.Cmd),
 		fields.NewField("parameters", cmd.Parameters))
 
+	// Some commands are only queued by the server as part of another operation
+	if commands.IsServerOnly(cmd.Cmd) {
+		a.logger.Warning(2962, "command cannot be sent directly", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "command cannot be sent directly", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	// Commands sent to a group are queued for each agent in the group
+	if group, ok := cmd.Parameters["group"]; ok {
+		return a.postGroupCmd(cmd, group, authDetails.ID, logFields)
+	}
+
+	// Commands sent to a tag are delivered to the agents with the tag when they sync
+	if tag, ok := cmd.Parameters["tag"]; ok {
+		if _, ok = cmd.Parameters[commands.AgentID]; ok {
+			return userver.JResponse{
+				HTTPCode: http.StatusBadRequest,
+				JSONData: schema.API400{Details: "cannot specify both agent_id and tag", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+		}
+
+		requestID, agents, errResp := a.queueTagCmd(cmd.Cmd, tag, cmd.Parameters, authDetails.ID, logFields)
+		if errResp != nil {
+			return *errResp
+		}
+		return userver.JResponse{
+			HTTPCode: http.StatusOK,
+			JSONData: schema.APICmdResponse{
+				Status:    schema.APIStatusOK,
+				Code:      http.StatusOK,
+				Details:   a.queuedDetails(cmd.Cmd, fmt.Sprintf("request queued for agents with tag, currently %d", agents)),
+				RequestID: requestID}}
+	}
+
 	// Validate the command
 	err = commands.Validate(cmd.Cmd, cmd.Parameters)
 	if err != nil {
 		a.logger.Error(2824, fmt.Sprintf("command validation failed: %s", err.Error()), logFields)
 		return userver.JResponse{
 			HTTPCode: http.StatusBadRequest,
-			JSONData: schema.API400{Details: "invalid command", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+			JSONData: schema.API400{Details: "invalid command: " + err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
 	}
 
 	// Queue the request
@@ -113,7 +147,273 @@ func (a *API) postCmd(req *http.Request) userver.JResponse {
 		JSONData: schema.APICmdResponse{
 			Status:    schema.APIStatusOK,
 			Code:      http.StatusOK,
-			Details:   "request queued for agent",
+			Details:   a.queuedDetails(cmd.Cmd, "request queued for agent"),
 			RequestID: requestID,
 			AgentID:   cmd.Parameters["agent_id"]}}
 }
+
+// postGroupCmd queues a command for each agent in a group, including the agents
+// in the groups it contains
+func (a *API) postGroupCmd(cmd schema.CmdRequest, group, requester string, logFields *fields.Fields) userver.JResponse {
+	if _, ok := cmd.Parameters[commands.AgentID]; ok {
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "cannot specify both agent_id and group", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	agents, err := a.data.GroupAgents(group)
+	if err != nil {
+		return groupError(err)
+	}
+	if len(agents) == 0 {
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "no agents found in group", Status: schema.APIStatusError, Code: http.StatusNotFound}}
+	}
+
+	requests := make(map[string]string)
+	for _, agentID := range agents {
+		params := make(map[string]string)
+		for k, v := range cmd.Parameters {
+			if k != "group" {
+				params[k] = v
+			}
+		}
+		params[commands.AgentID] = agentID
+
+		// The parameters are the same for every agent, so validation either passes or fails for all of them
+		err = commands.Validate(cmd.Cmd, params)
+		if err != nil {
+			a.logger.Error(2824, fmt.Sprintf("command validation failed: %s", err.Error()), logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusBadRequest,
+				JSONData: schema.API400{Details: "invalid command: " + err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+		}
+
+		requestID, err := a.data.AddAgentRequest(schema.AgentRequest{
+			Requester:   requester,
+			Request:     cmd.Cmd,
+			AckRequired: commands.IsAckRequired(cmd.Cmd),
+			Parameters:  params,
+		})
+		if err != nil {
+			a.logger.Error(2825, "unable to queue request: "+err.Error(), logFields)
+			continue
+		}
+		requests[agentID] = requestID
+	}
+
+	logFields.Append(fields.NewField("agents", len(requests)))
+	a.logger.Info(2952, "request queued for group", logFields)
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APICmdResponse{
+			Status:   schema.APIStatusOK,
+			Code:     http.StatusOK,
+			Details:  a.queuedDetails(cmd.Cmd, fmt.Sprintf("request queued for %d of %d agents in group", len(requests), len(agents))),
+			Requests: requests}}
+}
+
+// @Summary Send command to multiple agents
+// @Description Creates and queues the same command request for a list of agents, or a single request that is delivered to each agent with a tag when it syncs
+// @Tags Agent management
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param cmdBulkRequest body schema.CmdBulkRequest true "Bulk command request"
+// @Success 200 {object} schema.APICmdBulkResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Failure 500 {object} schema.API500
+// @Router /cmd/bulk [post]
+// postCmdBulk queues a command for multiple agents in a single request
+func (a *API) postCmdBulk(req *http.Request) userver.JResponse {
+
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	// Get the JSON post data
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		a.logger.Error(2980, fmt.Sprintf("failed reading body: %s", err.Error()), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "error reading body", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	// Deserialize the JSON
+	var cmd schema.CmdBulkRequest
+	err = json.Unmarshal(body, &cmd)
+	if err != nil {
+		a.logger.Error(2981, fmt.Sprintf("deserialization error: %s", err.Error()), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "error unmarshalling JSON", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	// Information to be logged as fields
+	logFields.Append(
+		fields.NewField("cmd", cmd.Cmd),
+		fields.NewField("tag", cmd.Tag),
+		fields.NewField("parameters", cmd.Parameters))
+
+	// Some commands are only queued by the server as part of another operation
+	if commands.IsServerOnly(cmd.Cmd) {
+		a.logger.Warning(2982, "command cannot be sent directly", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "command cannot be sent directly", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	// The agents are specified by the request, not the parameters
+	for _, key := range []string{commands.AgentID, "tag", "group"} {
+		if _, ok := cmd.Parameters[key]; ok {
+			return userver.JResponse{
+				HTTPCode: http.StatusBadRequest,
+				JSONData: schema.API400{Details: key + " cannot be specified as an argument", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+		}
+	}
+
+	if (len(cmd.AgentIDs) == 0) == (cmd.Tag == "") {
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "either agent_ids or tag is required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	// A tag is stored as a single request that is delivered to the agents with the tag
+	if cmd.Tag != "" {
+		requestID, count, errResp := a.queueTagCmd(cmd.Cmd, cmd.Tag, cmd.Parameters, authDetails.ID, logFields)
+		if errResp != nil {
+			return *errResp
+		}
+		return userver.JResponse{
+			HTTPCode: http.StatusOK,
+			JSONData: schema.APICmdBulkResponse{
+				Status:    schema.APIStatusOK,
+				Code:      http.StatusOK,
+				Details:   a.queuedDetails(cmd.Cmd, fmt.Sprintf("request queued for agents with tag, currently %d", count)),
+				RequestID: requestID}}
+	}
+
+	agents := cmd.AgentIDs
+
+	// The parameters are the same for every agent, so validation either passes or fails for all of them
+	params := make(map[string]string, len(cmd.Parameters)+1)
+	for k, v := range cmd.Parameters {
+		params[k] = v
+	}
+	params[commands.AgentID] = agents[0]
+	err = commands.Validate(cmd.Cmd, params)
+	if err != nil {
+		a.logger.Error(2984, fmt.Sprintf("command validation failed: %s", err.Error()), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "invalid command: " + err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	// Queue the requests
+	requests, failed, err := a.data.AddAgentRequests(schema.AgentRequest{
+		Requester:   authDetails.ID,
+		Request:     cmd.Cmd,
+		AckRequired: commands.IsAckRequired(cmd.Cmd),
+		Parameters:  cmd.Parameters,
+	}, agents)
+	if err != nil {
+		a.logger.Error(2985, "unable to queue requests: "+err.Error(), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "unable to queue requests", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	logFields.Append(
+		fields.NewField("agents", len(requests)),
+		fields.NewField("failed", len(failed)))
+	a.logger.Info(2986, "request queued for agents", logFields)
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APICmdBulkResponse{
+			Status:   schema.APIStatusOK,
+			Code:     http.StatusOK,
+			Details:  a.queuedDetails(cmd.Cmd, fmt.Sprintf("request queued for %d of %d agents", len(requests), len(requests)+len(failed))),
+			Requests: requests,
+			Failed:   failed}}
+}
+
+// queueTagCmd validates a command and queues a single request for the agents with a tag. It
+// returns the request ID and the number of agents that currently have the tag.
+func (a *API) queueTagCmd(cmd, tag string, parameters map[string]string, requester string, logFields *fields.Fields) (string, int, *userver.JResponse) {
+	logFields.Append(fields.NewField("tag", tag))
+
+	// Validate as the request will be delivered, with the agent ID in place of the tag
+	params := make(map[string]string, len(parameters)+1)
+	for k, v := range parameters {
+		if k != "tag" {
+			params[k] = v
+		}
+	}
+	params[commands.AgentID] = tag
+	err := commands.Validate(cmd, params)
+	if err != nil {
+		a.logger.Error(2824, fmt.Sprintf("command validation failed: %s", err.Error()), logFields)
+		return "", 0, &userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "invalid command: " + err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	delete(params, commands.AgentID)
+	params["tag"] = tag
+	requestID, err := a.data.AddAgentRequest(schema.AgentRequest{
+		Requester:   requester,
+		Request:     cmd,
+		AckRequired: commands.IsAckRequired(cmd),
+		Parameters:  params,
+	})
+	if err != nil {
+		a.logger.Error(2807, "unable to queue tag request: "+err.Error(), logFields)
+		return "", 0, &userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "unable to queue request", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	// The count is informational because agents may gain or lose the tag before they sync
+	agents, _ := a.data.TagAgents(tag)
+	logFields.Append(
+		fields.NewField("requestID", requestID),
+		fields.NewField("agents", len(agents)))
+	a.logger.Info(2808, "request queued for tag", logFields)
+	return requestID, len(agents), nil
+}
+
+// queuedDetails says that a request is pending approval if the command requires approval, so
+// that the response doesn't imply that it will be sent to the agent
+func (a *API) queuedDetails(cmd, details string) string {
+	if a.data.ApprovalRequired(cmd) {
+		return strings.Replace(details, "request queued", "request queued pending approval", 1)
+	}
+	return details
+}
+
+// @Summary Describe commands
+// @Description Lists the commands that may be sent to agents and the type and constraints of each parameter
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} schema.APICmdSpecResponse
+// @Failure 401 {object} schema.API401
+// @Router /cmd/spec [get]
+// getCmdSpec describes the commands so that clients can validate parameters and generate help
+func (a *API) getCmdSpec(_ *http.Request) userver.JResponse {
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APICmdSpecResponse{
+			Status:   schema.APIStatusOK,
+			Code:     http.StatusOK,
+			Commands: commands.Specs()}}
+}
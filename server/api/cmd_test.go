@@ -0,0 +1,209 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestCmdBulk(t *testing.T) {
+	a := newTestAPI(t)
+
+	for id, tags := range map[string][]string{
+		"agentA": {"kiosk"},
+		"agentB": {"kiosk", "lobby"},
+		"agentC": {"lobby"},
+	} {
+		if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: id, Tags: tags}); err != nil {
+			t.Fatalf("failed to create agent: %v", err)
+		}
+	}
+
+	post := func(cmd schema.CmdBulkRequest) (int, schema.APICmdBulkResponse) {
+		body, _ := json.Marshal(cmd)
+		r := a.postCmdBulk(httptest.NewRequest("POST", schema.EndpointCmdBulk, strings.NewReader(string(body))))
+		resp, _ := r.JSONData.(schema.APICmdBulkResponse)
+		return r.HTTPCode, resp
+	}
+
+	// Agents that don't exist are reported without affecting the others
+	code, resp := post(schema.CmdBulkRequest{Cmd: commands.Ping, AgentIDs: []string{"agentA", "agentX", "agentA"}})
+	if code != http.StatusOK || len(resp.Requests) != 1 || resp.Failed["agentX"] == "" {
+		t.Fatalf("unexpected response to agent list: %d %+v", code, resp)
+	}
+
+	code, resp = post(schema.CmdBulkRequest{Cmd: commands.Ping, AgentIDs: []string{"agentB", "agentC"}})
+	if code != http.StatusOK || len(resp.Requests) != 2 || len(resp.Failed) != 0 {
+		t.Fatalf("unexpected response to agent list: %d %+v", code, resp)
+	}
+	for agentID, requestID := range resp.Requests {
+		request, err := a.data.GetAgentRequest(requestID)
+		if err != nil || request.Parameters[commands.AgentID] != agentID {
+			t.Errorf("request %s for %s not stored correctly: %+v (%v)", requestID, agentID, request, err)
+		}
+	}
+
+	// A tag is queued as a single request
+	code, resp = post(schema.CmdBulkRequest{Cmd: commands.Ping, Tag: "KIOSK"})
+	if code != http.StatusOK || resp.RequestID == "" || len(resp.Requests) != 0 {
+		t.Fatalf("unexpected response to tag: %d %+v", code, resp)
+	}
+
+	// The agents must be specified exactly once
+	for _, bad := range []schema.CmdBulkRequest{
+		{Cmd: commands.Ping},
+		{Cmd: commands.Ping, Tag: "kiosk", AgentIDs: []string{"agentA"}},
+		{Cmd: commands.Ping, Tag: "kiosk", Parameters: map[string]string{commands.AgentID: "agentC"}},
+	} {
+		if code, _ = post(bad); code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %+v, got %d", bad, code)
+		}
+	}
+}
+
+func TestCmdTag(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigTagRequestDays, 7)
+	a.conf.SC.Set(global.ConfigRequestRetries, 3)
+	a.conf.SC.Set(global.ConfigRequestRetryDelay, 10)
+
+	for id, tags := range map[string][]string{
+		"agentA": {"kiosk"},
+		"agentB": {"kiosk"},
+		"agentC": {"lobby"},
+	} {
+		if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: id, Tags: tags}); err != nil {
+			t.Fatalf("failed to create agent: %v", err)
+		}
+	}
+
+	body, _ := json.Marshal(schema.CmdRequest{Cmd: commands.UserList, Parameters: map[string]string{"tag": "kiosk"}})
+	r := a.postCmd(httptest.NewRequest("POST", schema.EndpointCmd, strings.NewReader(string(body))))
+	resp, _ := r.JSONData.(schema.APICmdResponse)
+	if r.HTTPCode != http.StatusOK || resp.RequestID == "" {
+		t.Fatalf("unexpected response: %d %+v", r.HTTPCode, r.JSONData)
+	}
+
+	sync := func(agentID string) []schema.AgentRequest {
+		requests, err := a.data.GetAgentRequests(agentID, true)
+		if err != nil {
+			t.Fatalf("failed to get requests for %s: %v", agentID, err)
+		}
+		return requests
+	}
+
+	// The tag is resolved when each agent syncs, so changes made after queuing apply
+	if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: "agentB"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: "agentC", Tags: []string{"lobby", "kiosk"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := sync("agentA")
+	if len(requests) != 1 || requests[0].Parameters[commands.AgentID] != "agentA" || requests[0].RequestID == resp.RequestID {
+		t.Fatalf("unexpected requests for agentA: %+v", requests)
+	}
+	if len(sync("agentB")) != 0 {
+		t.Errorf("agent without the tag received the request")
+	}
+	if len(sync("agentC")) != 1 {
+		t.Errorf("agent that gained the tag did not receive the request")
+	}
+
+	// Syncing again does not create another delivery for the agent
+	if len(sync("agentA")) != 0 {
+		t.Errorf("request was delivered to agentA twice")
+	}
+
+	list, err := a.data.GetRequestRecord(resp.RequestID)
+	if err != nil || len(list.Requests) != 3 {
+		t.Fatalf("unexpected tag request status: %+v (%v)", list, err)
+	}
+	parent := list.Requests[0]
+	if parent.Status != schema.RequestStatusActive || parent.Outcomes == nil ||
+		parent.Outcomes.Agents != 2 || parent.Outcomes.Delivered != 2 || parent.Outcomes.Statuses[schema.RequestStatusPending] != 2 {
+		t.Errorf("unexpected outcomes: %+v", parent.Outcomes)
+	}
+	for _, child := range list.Requests[1:] {
+		if child.ParentID != resp.RequestID {
+			t.Errorf("delivery %s does not refer to the tag request", child.RequestID)
+		}
+	}
+
+	// Cancelling the tag request stops delivery and cancels pending deliveries
+	if err = a.data.CancelAgentRequest(resp.RequestID); err != nil {
+		t.Fatal(err)
+	}
+	if err = a.data.SetAgentMeta(schema.AgentMeta{AgentID: "agentB", Tags: []string{"kiosk"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sync("agentB")) != 0 {
+		t.Errorf("cancelled tag request was delivered")
+	}
+	list, _ = a.data.GetRequestRecord(resp.RequestID)
+	if list.Requests[0].Outcomes.Statuses[schema.RequestStatusCancelled] != 2 {
+		t.Errorf("pending deliveries were not cancelled: %+v", list.Requests[0].Outcomes)
+	}
+}
+
+func TestCmdParamTypes(t *testing.T) {
+	a := newTestAPI(t)
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	post := func(cmd string, params map[string]string) (int, schema.APICmdResponse) {
+		body, _ := json.Marshal(schema.CmdRequest{Cmd: cmd, Parameters: params})
+		r := a.postCmd(httptest.NewRequest("POST", schema.EndpointCmd, strings.NewReader(string(body))))
+		resp, _ := r.JSONData.(schema.APICmdResponse)
+		return r.HTTPCode, resp
+	}
+
+	// Values that don't match the parameter's type are rejected before they reach the agent
+	for _, bad := range []map[string]string{
+		{commands.AgentID: "agentA", "delay_minutes": "ten"},
+		{commands.AgentID: "agentA", "delay_minutes": "0"},
+	} {
+		if code, _ := post(commands.ScreenLockSet, bad); code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %v, got %d", bad, code)
+		}
+	}
+	if code, _ := post(commands.UserAdmin, map[string]string{commands.AgentID: "agentA", "user": "bob", "admin": "yess"}); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for admin=yess, got %d", code)
+	}
+
+	// Accepted spellings are normalized before the request is queued
+	code, resp := post(commands.ProcessKill, map[string]string{commands.AgentID: "agentA", "pid": "42", "force": "Yes"})
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	request, err := a.data.GetAgentRequest(resp.RequestID)
+	if err != nil || request.Parameters["force"] != "true" {
+		t.Errorf("force was not normalized: %+v (%v)", request.Parameters, err)
+	}
+
+	specs, _ := a.getCmdSpec(nil).JSONData.(schema.APICmdSpecResponse)
+	for _, spec := range specs.Commands {
+		if spec.Name == commands.ShellStart {
+			t.Errorf("server-only command %s should not be described", spec.Name)
+		}
+		if spec.Name != commands.ScreenLockSet {
+			continue
+		}
+		if len(spec.Params) != 2 || spec.Params[0].Name != "delay_minutes" || spec.Params[0].Type != schema.ParamInt || !spec.Params[0].Required {
+			t.Errorf("unexpected spec for %s: %+v", spec.Name, spec.Params)
+		}
+	}
+}
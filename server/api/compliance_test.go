@@ -0,0 +1,53 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/reports"
+)
+
+func TestComplianceReport(t *testing.T) {
+	a := newTestAPI(t)
+
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentB")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	response := schema.NewAgentResponse()
+	response.Cmd = commands.Status
+	response.RequestID = "status"
+	response.Success = true
+	response.Data = map[string]any{"details": map[string]any{
+		"os":                         "macOS 15.5",
+		"firewall":                   "yes",
+		"full_disk_encryption":       "yes",
+		schema.StatusMDMEnrolled:     "yes",
+		schema.StatusActivationLock:  "no",
+		schema.StatusSystemIntegrity: "unknown",
+	}}
+	a.data.AgentSync(data.SyncData{AgentID: "agentA", Responses: []schema.AgentResponse{response}})
+
+	report, err := reports.Get(a.data, schema.ReportRequest{Report: "compliance", Parameters: map[string]string{}})
+	if err != nil {
+		t.Fatalf("failed to get report: %v", err)
+	}
+	text := string(report.Data)
+	if !strings.Contains(text, "agentA, , macOS 15.5, yes, n/a, yes, n/a, n/a, n/a, yes, no, unknown, n/a, n/a, n/a, n/a") {
+		t.Errorf("unexpected report for agentA:\n%s", text)
+	}
+	if !strings.Contains(text, "agentB, , not reported") {
+		t.Errorf("unexpected report for agentB:\n%s", text)
+	}
+}
@@ -15,7 +15,10 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/semver"
+	"github.com/UnifyEM/UnifyEM/common/ulogger"
 	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/global"
 )
 
 // @Summary Retrieve global agent configuration
@@ -68,6 +71,11 @@ func (a *API) getConfigTarget(target string, req *http.Request) userver.JRespons
 		configMap = a.conf.AC.GetMap()
 	case "server":
 		configMap = a.conf.SC.GetMap()
+		for key, value := range configMap {
+			if global.SecretSettings[key] && value != "" {
+				configMap[key] = global.SecretMask
+			}
+		}
 	default:
 		msg = fmt.Sprintf("invalid config set '%s'", targetLC)
 	}
@@ -207,10 +215,39 @@ func (a *API) putConfigTarget(target string, req *http.Request) userver.JRespons
 		}
 	}
 
+	// Reject a minimum agent version that can not be compared with agent versions
+	if v, ok := request.Parameters[global.ConfigMinimumAgentVersion]; ok && targetLC == "server" && v != "" {
+		if _, err = semver.Parse(v); err != nil {
+			msg = fmt.Sprintf("invalid %s: %s", global.ConfigMinimumAgentVersion, err.Error())
+			logFields.Append(fields.NewField("error", msg))
+			a.logger.Warning(2931, msg, logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusBadRequest,
+				JSONData: schema.API400{
+					Details: msg,
+					Status:  schema.APIStatusError,
+					Code:    http.StatusBadRequest}}
+		}
+	}
+
+	// A masked secret sent back unchanged keeps the current value
+	if targetLC == "server" {
+		for key, value := range request.Parameters {
+			if global.SecretSettings[key] && value == global.SecretMask {
+				delete(request.Parameters, key)
+			}
+		}
+	}
+
 	// Set the new values
 	set.SetStringMap(request.Parameters)
 	_ = a.conf.Checkpoint()
 
+	// Apply logging changes to the logger in use
+	if targetLC == "server" && anyParameter(request.Parameters, logParameters) {
+		a.reconfigureLogger()
+	}
+
 	// Add the config set to the log fields
 	logFields.Append(fields.NewField("config_set", targetLC))
 
@@ -221,3 +258,33 @@ func (a *API) putConfigTarget(target string, req *http.Request) userver.JRespons
 		HTTPCode: http.StatusOK,
 		JSONData: schema.APIGenericResponse{Status: schema.APIStatusOK, Details: msg, Code: http.StatusOK}}
 }
+
+// logParameters are the server settings applied to the logger when they change
+var logParameters = []string{
+	global.ConfigLogFile,
+	global.ConfigLogStdout,
+	global.ConfigLogRetention,
+	global.ConfigLogMaxSize,
+	global.ConfigLogMaxFiles,
+}
+
+// anyParameter returns true if any of the keys are in the parameters
+func anyParameter(parameters map[string]string, keys []string) bool {
+	for _, key := range keys {
+		if _, ok := parameters[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// reconfigureLogger applies the logging settings in the server configuration to the logger
+func (a *API) reconfigureLogger() {
+	r, ok := a.logger.(interface{ Reconfigure(...ulogger.Option) error })
+	if !ok {
+		return
+	}
+	if err := r.Reconfigure(a.conf.LoggerOptions()...); err != nil {
+		a.logger.Errorf(2998, "error applying logging settings: %s", err.Error())
+	}
+}
@@ -0,0 +1,48 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestGetConfigServerSecrets(t *testing.T) {
+	a := newTestAPI(t)
+	const secret = "not-for-display"
+	a.conf.SC.Set(global.ConfigNotifyWebhookSecret, secret)
+	a.conf.SC.Set(global.ConfigSMTPPassword, secret)
+
+	resp := a.getConfigServer(httptest.NewRequest("GET", "/", nil))
+	r, ok := resp.JSONData.(schema.APIConfigResponse)
+	if !ok || resp.HTTPCode != http.StatusOK {
+		t.Fatalf("unexpected response %d: %+v", resp.HTTPCode, resp.JSONData)
+	}
+	for key := range global.SecretSettings {
+		if r.Data[key] != global.SecretMask {
+			t.Errorf("expected %s to be masked, got %q", key, r.Data[key])
+		}
+	}
+	for key, value := range r.Data {
+		if strings.Contains(value, secret) {
+			t.Errorf("the secret was returned in %s", key)
+		}
+	}
+
+	// Sending the mask back must not replace the secret
+	body := `{"parameters":{"` + global.ConfigNotifyWebhookSecret + `":"` + global.SecretMask + `"}}`
+	if resp = a.putConfigServer(httptest.NewRequest("PUT", "/", strings.NewReader(body))); resp.HTTPCode != http.StatusOK {
+		t.Fatalf("update failed with %d: %+v", resp.HTTPCode, resp.JSONData)
+	}
+	if value := a.conf.SC.Get(global.ConfigNotifyWebhookSecret).String(); value != secret {
+		t.Errorf("the secret was replaced with %q", value)
+	}
+}
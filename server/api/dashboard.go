@@ -0,0 +1,82 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// dashboardRecentEvents is the number of recent events included in the dashboard summary
+const dashboardRecentEvents = 25
+
+// The dashboard is a static page that logs in with the login endpoint and reads everything it
+// displays from the JSON API with the access token, so the page itself contains no data
+//
+//go:embed ui
+var dashboardFiles embed.FS
+
+// dashboardRoutes returns the routes for the dashboard page, which is only served when the
+// dashboard is enabled. The page and its assets are served without authentication.
+func (a *API) dashboardRoutes() userver.Routes {
+	assets, _ := fs.Sub(dashboardFiles, "ui")
+	return userver.Routes{
+		{
+			Name:    "ui",
+			Methods: []string{"GET", "HEAD"},
+			Pattern: global.DashboardPath,
+			Handler: userver.StaticHandler(global.DashboardPath, assets),
+			Prefix:  true},
+
+		{
+			Name:    "ui",
+			Methods: []string{"GET", "HEAD"},
+			Pattern: strings.TrimSuffix(global.DashboardPath, "/"),
+			Handler: http.RedirectHandler(global.DashboardPath, http.StatusMovedPermanently)},
+	}
+}
+
+// @Summary Dashboard summary
+// @Description Counts agents by connectivity, compliance, and triggers, totals the requests that have not been delivered to agents, and includes the most recent events. An agent is non-compliant if it reports the firewall, antivirus, full disk encryption, password, or screen lock check as failing.
+// @Tags Agents
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} schema.APIDashboardResponse
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /dashboard/summary [get]
+func (a *API) getDashboardSummary(req *http.Request) userver.JResponse {
+	summary, err := a.data.DashboardSummary(dashboardRecentEvents)
+	if err != nil {
+		authDetails := GetAuthDetails(req)
+		a.logger.Error(3004, "error creating dashboard summary", fields.NewFields(
+			fields.NewField("src_ip", userver.RemoteIP(req)),
+			fields.NewField("id", authDetails.ID),
+			fields.NewField("role", authDetails.Role),
+			fields.NewField("error", err.Error())))
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{
+				Status:  schema.APIStatusError,
+				Code:    http.StatusInternalServerError,
+				Details: "error creating dashboard summary"}}
+	}
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIDashboardResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "dashboard summary",
+			Data:    summary}}
+}
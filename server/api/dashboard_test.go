@@ -0,0 +1,122 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+)
+
+func TestDashboardSummary(t *testing.T) {
+	a := newTestAPI(t)
+
+	status := func(values map[string]string) *schema.AgentStatus {
+		details := schema.NewStatusDetails()
+		for key, value := range values {
+			details.Set(key, value)
+		}
+		return &schema.AgentStatus{LastUpdated: time.Now(), Details: details}
+	}
+
+	compliant := schema.NewAgentMeta("agentA")
+	compliant.Status = status(map[string]string{"firewall": "yes", "antivirus": "yes", "screen_lock": "yes"})
+
+	failing := schema.NewAgentMeta("agentB")
+	failing.Status = status(map[string]string{"firewall": "no", "full_disk_encryption": "no", "password": "unknown"})
+	failing.Offline = true
+	failing.Triggers.Lost = true
+
+	unreported := schema.NewAgentMeta("agentC")
+	unreported.Triggers.Wipe = true
+
+	for _, agent := range []schema.AgentMeta{compliant, failing, unreported} {
+		if err := a.data.SetAgentMeta(agent); err != nil {
+			t.Fatalf("failed to create agent: %v", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := a.data.AddAgentRequest(schema.AgentRequest{
+			AgentID:     "agentA",
+			Request:     commands.Ping,
+			Requester:   "admin",
+			AckRequired: true,
+			Parameters:  map[string]string{"agent_id": "agentA"}})
+		if err != nil {
+			t.Fatalf("failed to add request: %v", err)
+		}
+	}
+	if sent, _ := a.data.GetAgentRequests("agentA", true); len(sent) != 3 {
+		t.Fatalf("expected 3 requests to be sent, got %d", len(sent))
+	}
+	if _, err := a.data.AddAgentRequest(schema.AgentRequest{AgentID: "agentB", Request: commands.Ping, Requester: "admin"}); err != nil {
+		t.Fatalf("failed to add request: %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		_ = a.data.AddEvent(schema.AgentEvent{
+			AgentID:   []string{"agentA", "agentB"}[i%2],
+			Time:      base.Add(time.Duration(i) * time.Minute),
+			EventType: schema.AgentEventMessage,
+			Event:     fmt.Sprintf("event %d", i)})
+	}
+
+	summary, err := a.data.DashboardSummary(3)
+	if err != nil {
+		t.Fatalf("DashboardSummary failed: %v", err)
+	}
+	if summary.Agents != 3 || summary.Online != 2 || summary.Offline != 1 || summary.NotReported != 1 || summary.NonCompliant != 1 {
+		t.Errorf("unexpected agent counts: %+v", summary)
+	}
+	if summary.Failing["firewall"] != 1 || summary.Failing["full_disk_encryption"] != 1 || summary.Failing["password"] != 0 {
+		t.Errorf("unexpected failing checks: %v", summary.Failing)
+	}
+	if summary.Triggers.Lost != 1 || summary.Triggers.Wipe != 1 || summary.Triggers.Uninstall != 0 {
+		t.Errorf("unexpected triggers: %+v", summary.Triggers)
+	}
+	if summary.Requests.New != 1 || summary.Requests.Pending != 3 || summary.Requests.Oldest.IsZero() {
+		t.Errorf("unexpected requests: %+v", summary.Requests)
+	}
+
+	// The most recent events are returned newest first, merged across agents
+	if len(summary.RecentEvents) != 3 {
+		t.Fatalf("expected 3 recent events, got %d", len(summary.RecentEvents))
+	}
+	for i, event := range summary.RecentEvents {
+		if event.Event != fmt.Sprintf("event %d", 4-i) {
+			t.Errorf("expected event %d, got %q", 4-i, event.Event)
+		}
+	}
+}
+
+func TestDashboardRoutes(t *testing.T) {
+	a := newTestAPI(t)
+
+	get := func(route int, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		a.dashboardRoutes()[route].Handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get(0, "/ui/")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || !strings.Contains(w.Body.String(), "app.js") {
+		t.Errorf("unexpected dashboard page %d %v", w.Code, w.Header())
+	}
+	if w = get(0, "/ui/app.js"); w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/javascript") {
+		t.Errorf("unexpected script %d %v", w.Code, w.Header())
+	}
+	if w = get(1, "/ui"); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/ui/" {
+		t.Errorf("expected a redirect to /ui/, got %d %v", w.Code, w.Header())
+	}
+}
@@ -6,6 +6,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -15,10 +16,17 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/data"
+)
+
+// Events are returned in pages of at most maxEventsLimit events
+const (
+	defaultEventsLimit = 1000
+	maxEventsLimit     = 10000
 )
 
 // @Summary Retrieve events
-// @Description Retrieves server events
+// @Description Retrieves events in time order, for one agent or all agents. Results are returned in pages, and the cursor in the response continues after the last event returned, both to read the next page and to poll for new events.
 // @Tags Events
 // @Security BearerAuth
 // @Produce json
@@ -26,10 +34,14 @@ import (
 // @Param end query string false "End date in YYYYMMDD format"
 // @Param start_time query string false "Start time in Unix timestamp format"
 // @Param end_time query string false "End time in Unix timestamp format"
-// @Param agent_id query string false "Agent ID"
+// @Param agent_id query string false "Agent ID, or server for events recorded by the server. All agents if not specified."
+// @Param type query string false "Event type"
+// @Param limit query int false "Maximum number of events, default 1000, maximum 10000"
+// @Param cursor query string false "Cursor from the previous response"
 // @Success 200 {array} schema.APIEventsResponse
 // @Failure 400 {object} schema.API400
 // @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
 // @Failure 500 {object} schema.API500
 // @Router /events [get]
 func (a *API) getEvents(req *http.Request) userver.JResponse {
@@ -51,15 +63,21 @@ func (a *API) getEvents(req *http.Request) userver.JResponse {
 	startTimeStr := query.Get("start_time")
 	endTimeStr := query.Get("end_time")
 	eventType := query.Get("type")
+	limitStr := query.Get("limit")
+	cursor := query.Get("cursor")
 
-	if agentID == "" {
+	if agentID != "" {
 		logFields.Append(fields.NewField("agent_id", agentID))
 	}
 
-	if eventType == "" {
+	if eventType != "" {
 		logFields.Append(fields.NewField("type", eventType))
 	}
 
+	if limitStr != "" {
+		logFields.Append(fields.NewField("limit", limitStr))
+	}
+
 	if start != "" {
 		logFields.Append(fields.NewField("start", start))
 	}
@@ -76,8 +94,10 @@ func (a *API) getEvents(req *http.Request) userver.JResponse {
 		logFields.Append(fields.NewField("end_time", endTimeStr))
 	}
 
-	// Validate the agent ID
-	err = a.data.AgentExists(agentID)
+	// Validate the agent ID. Events recorded by the server are stored under ServerEventsID.
+	if agentID != "" && agentID != schema.ServerEventsID {
+		err = a.data.AgentExists(agentID)
+	}
 	if err != nil {
 		var msg string
 		code := http.StatusInternalServerError
@@ -151,8 +171,34 @@ func (a *API) getEvents(req *http.Request) userver.JResponse {
 		endT = tmpInt
 	}
 
-	// Retrieve events based on the optional time range
-	events, err := a.data.GetEvents(agentID, startT, endT, eventType)
+	limit := defaultEventsLimit
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxEventsLimit {
+			msg := fmt.Sprintf("limit must be from 1 to %d", maxEventsLimit)
+			logFields.Append(fields.NewField("error", msg))
+			a.logger.Info(2829, "event API error", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusBadRequest,
+				JSONData: schema.API400{Details: msg, Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+		}
+	}
+
+	// Retrieve a page of events based on the optional agent, type, and time range
+	page, err := a.data.QueryEvents(data.EventQuery{
+		AgentID:   agentID,
+		StartTime: startT,
+		EndTime:   endT,
+		EventType: eventType,
+		Limit:     limit,
+		Cursor:    cursor})
+	if errors.Is(err, data.ErrInvalidCursor) {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Info(2830, "event API error", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
 	if err != nil {
 		a.logger.Error(2873, fmt.Sprintf("error retrieving events: %s", err.Error()), logFields)
 		return userver.JResponse{
@@ -165,5 +211,7 @@ func (a *API) getEvents(req *http.Request) userver.JResponse {
 		JSONData: schema.APIEventsResponse{
 			Status: schema.APIStatusOK,
 			Code:   http.StatusOK,
-			Data:   events}}
+			Data:   page.Events,
+			Cursor: page.Cursor,
+			More:   page.More}}
 }
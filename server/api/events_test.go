@@ -0,0 +1,180 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/db"
+)
+
+func TestQueryEvents(t *testing.T) {
+	a := newTestAPI(t)
+
+	// Alternate events between two agents, one minute apart, with every third an alert
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i := 0; i < 10; i++ {
+		eventType := schema.AgentEventMessage
+		if i%3 == 0 {
+			eventType = schema.AgentEventAlert
+		}
+		err := a.data.AddEvent(schema.AgentEvent{
+			AgentID:   []string{"agentA", "agentB"}[i%2],
+			Time:      base.Add(time.Duration(i) * time.Minute),
+			EventType: eventType,
+			Event:     fmt.Sprintf("event %d", i)})
+		if err != nil {
+			t.Fatalf("failed to add event: %v", err)
+		}
+	}
+
+	// Paging through all agents returns every event once, in time order
+	var all []schema.AgentEvent
+	q := data.EventQuery{Limit: 4}
+	for pages := 0; ; pages++ {
+		page, err := a.data.QueryEvents(q)
+		if err != nil {
+			t.Fatalf("QueryEvents failed: %v", err)
+		}
+		all = append(all, page.Events...)
+		q.Cursor = page.Cursor
+		if !page.More {
+			if pages != 2 {
+				t.Errorf("expected 3 pages, got %d", pages+1)
+			}
+			break
+		}
+	}
+	if len(all) != 10 {
+		t.Fatalf("expected 10 events, got %d", len(all))
+	}
+	for i, event := range all {
+		if event.Event != fmt.Sprintf("event %d", i) {
+			t.Errorf("expected event %d, got %q", i, event.Event)
+		}
+	}
+
+	// Newest first, each page continues before the previous one
+	var newest []string
+	for nq := (data.EventQuery{Limit: 4, Newest: true}); ; {
+		page, err := a.data.QueryEvents(nq)
+		if err != nil {
+			t.Fatalf("QueryEvents failed: %v", err)
+		}
+		for _, event := range page.Events {
+			newest = append(newest, event.Event)
+		}
+		if nq.Cursor = page.Cursor; !page.More {
+			break
+		}
+	}
+	if len(newest) != 10 || newest[0] != "event 9" || newest[9] != "event 0" {
+		t.Errorf("unexpected events newest first: %v", newest)
+	}
+
+	// The last cursor returns only events recorded after it
+	page, _ := a.data.QueryEvents(q)
+	if len(page.Events) != 0 || page.Cursor != q.Cursor {
+		t.Errorf("expected no new events and the same cursor, got %+v", page)
+	}
+	_ = a.data.AddEvent(schema.AgentEvent{AgentID: "agentB", Time: time.Now(), EventType: schema.AgentEventMessage, Event: "new"})
+	if page, _ = a.data.QueryEvents(q); len(page.Events) != 1 || page.Events[0].Event != "new" {
+		t.Errorf("expected the new event, got %+v", page.Events)
+	}
+
+	// Agent, type, and time range filters
+	page, _ = a.data.QueryEvents(data.EventQuery{AgentID: "agentA", EventType: schema.AgentEventAlert})
+	if len(page.Events) != 2 || page.Events[0].Event != "event 0" || page.Events[1].Event != "event 6" {
+		t.Errorf("unexpected alerts for agentA: %+v", page.Events)
+	}
+	page, _ = a.data.QueryEvents(data.EventQuery{
+		StartTime: base.Add(2 * time.Minute).Unix(),
+		EndTime:   base.Add(4 * time.Minute).Unix()})
+	if len(page.Events) != 3 || page.Events[0].Event != "event 2" || page.More {
+		t.Errorf("unexpected events in range: %+v", page.Events)
+	}
+	if page, _ = a.data.QueryEvents(data.EventQuery{AgentID: "agentC"}); len(page.Events) != 0 {
+		t.Errorf("expected no events for an agent without any")
+	}
+
+	if _, err := a.data.QueryEvents(data.EventQuery{Cursor: "not a cursor!"}); !errors.Is(err, data.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestEventKeyMigration(t *testing.T) {
+	a := newTestAPI(t)
+
+	// Store events with the keys used by earlier versions, where a 9-digit time sorts after a
+	// 10-digit one, and set the schema version to the one before the keys changed
+	times := []int64{999999998, 999999999, 1000000000, 1700000000}
+	a.data.Close()
+	dbFile, err := data.DatabaseFile(a.conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bdb, err := bbolt.Open(dbFile, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bdb.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.Bucket([]byte(db.BucketAgentEvents)).CreateBucketIfNotExists([]byte("agentA"))
+		if err != nil {
+			return err
+		}
+		for i, eventTime := range times {
+			event := schema.AgentEvent{
+				AgentID:   "agentA",
+				EventID:   fmt.Sprintf("E-%d", i),
+				Time:      time.Unix(eventTime, 0),
+				EventType: schema.AgentEventMessage,
+				Event:     fmt.Sprintf("event %d", i)}
+			value, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if err = bucket.Put([]byte(fmt.Sprintf("%d-%s", eventTime, event.EventID)), value); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket([]byte(db.BucketSchema)).Put([]byte("schema_version"), []byte("1"))
+	})
+	_ = bdb.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := data.New(a.conf, null.Logger())
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer d.Close()
+
+	page, err := d.QueryEvents(data.EventQuery{})
+	if err != nil || len(page.Events) != len(times) {
+		t.Fatalf("expected %d events after migration, got %d (%v)", len(times), len(page.Events), err)
+	}
+	for i, event := range page.Events {
+		if event.Event != fmt.Sprintf("event %d", i) {
+			t.Errorf("expected event %d, got %q", i, event.Event)
+		}
+	}
+
+	// Time ranges are read across the boundary
+	events, err := d.GetEvents("agentA", 999999999, 1000000000, "")
+	if err != nil || len(events) != 2 || events[0].Event != "event 1" {
+		t.Errorf("unexpected events in range: %+v (%v)", events, err)
+	}
+}
@@ -0,0 +1,133 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// @Summary Export server data
+// @Description Produces a JSON bundle of agents, users, login accounts, groups, and configuration, including password hashes and the server's keys, for migration to another server. Events are included if events=true.
+// @Tags Server management
+// @Security BearerAuth
+// @Produce json
+// @Param events query bool false "Include events"
+// @Success 200 {file} file
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /admin/export [get]
+func (a *API) getExport(w http.ResponseWriter, req *http.Request) {
+	authDetails := GetAuthDetails(req)
+	events := req.URL.Query().Get("events") == "true"
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("events", events))
+
+	bundle, err := a.data.Export(events)
+	if err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Error(2975, "export failed", logFields)
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(schema.API500{Details: "export failed", Status: schema.APIStatusError, Code: http.StatusInternalServerError})
+		return
+	}
+
+	logFields.Append(fields.NewField("agents", len(bundle.Agents)))
+	a.logger.Info(2976, "server data exported", logFields)
+
+	// A bundle with events may take longer to send than the usual write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	name := strings.ToLower(global.Name) + "-export-" + time.Now().Format("20060102-150405") + ".json"
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(bundle)
+}
+
+// @Summary Import server data
+// @Description Imports a bundle produced by an export. Nothing is imported if any agent, user, login, or group in the bundle already exists unless merge=true. Keys are only imported if the server has no agents. Access tokens issued before the import are revoked.
+// @Tags Server management
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param merge query bool false "Replace existing records"
+// @Success 200 {object} schema.APIImportResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 409 {object} schema.APIGenericResponse
+// @Failure 500 {object} schema.API500
+// @Router /admin/import [post]
+func (a *API) postImport(req *http.Request) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+	merge := req.URL.Query().Get("merge") == "true"
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("merge", merge))
+
+	var bundle data.ExportBundle
+	if errResp := readJSON(req, &bundle); errResp != nil {
+		return *errResp
+	}
+
+	summary, err := a.data.Import(bundle, merge)
+	if err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Warning(2977, "import failed", logFields)
+
+		switch {
+		case errors.Is(err, data.ErrImportConflict):
+			return userver.JResponse{
+				HTTPCode: http.StatusConflict,
+				JSONData: schema.APIGenericResponse{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusConflict}}
+		case errors.Is(err, data.ErrImportVersion):
+			return userver.JResponse{
+				HTTPCode: http.StatusBadRequest,
+				JSONData: schema.API400{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+		default:
+			return userver.JResponse{
+				HTTPCode: http.StatusInternalServerError,
+				JSONData: schema.API500{Details: "import failed, the import may be incomplete", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+		}
+	}
+
+	// The import is complete even if the configuration can't be saved
+	if err = a.conf.Checkpoint(); err != nil {
+		a.logger.Errorf(2979, "unable to save configuration after import: %s", err.Error())
+	}
+
+	logFields.Append(
+		fields.NewField("agents", summary.Agents),
+		fields.NewField("users", summary.Users),
+		fields.NewField("events", summary.Events),
+		fields.NewField("keys_imported", summary.KeysImported))
+	a.logger.Info(2978, "server data imported", logFields)
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIImportResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "import complete",
+			Data:    summary}}
+}
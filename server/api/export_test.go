@@ -0,0 +1,84 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestExportImport(t *testing.T) {
+	src := newTestAPI(t)
+
+	if err := src.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	if _, err := src.data.CreateGroup("Laptops", ""); err != nil {
+		t.Fatalf("failed to create group: %v", err)
+	}
+	if err := src.data.AddEvent(schema.AgentEvent{AgentID: "agentA", Time: time.Now(), EventType: schema.AgentEventMessage, Event: "test"}); err != nil {
+		t.Fatalf("failed to add event: %v", err)
+	}
+	src.conf.SC.Set(global.ConfigShellIdleTimeout, 900)
+	src.conf.SC.Set(global.ConfigListen, "10.0.0.1:8080")
+	oldToken := loginToken(t, src, "admin", schema.RoleSuperAdmin)
+
+	// Token times have a resolution of one second
+	time.Sleep(1100 * time.Millisecond)
+
+	bundle, err := src.data.Export(true)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if len(bundle.Agents) != 1 || len(bundle.Logins) != 1 || len(bundle.Groups) != 1 || len(bundle.Events) != 1 {
+		t.Fatalf("unexpected bundle contents: %+v", bundle)
+	}
+
+	dst := newTestAPI(t)
+	summary, err := dst.data.Import(bundle, false)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if summary.Agents != 1 || summary.Logins != 1 || summary.Events != 1 || !summary.KeysImported {
+		t.Errorf("unexpected import summary: %+v", summary)
+	}
+
+	if err = dst.data.AgentExists("agentA"); err != nil {
+		t.Errorf("agent missing after import")
+	}
+	if _, _, err = dst.data.LoginGetToken("admin", "password", "127.0.0.1"); err != nil {
+		t.Errorf("imported login should work: %v", err)
+	}
+	if dst.conf.SC.Get(global.ConfigShellIdleTimeout).Int() != 900 {
+		t.Errorf("server settings should be imported")
+	}
+	if dst.conf.SC.Get(global.ConfigListen).String() == "10.0.0.1:8080" {
+		t.Errorf("host settings should not be imported")
+	}
+
+	// Access tokens issued before the import are revoked
+	if ok, _, _ := dst.NewAuthFunc(dst.AuthAnyRole())("127.0.0.1", oldToken); ok {
+		t.Errorf("access token issued before the import should be rejected")
+	}
+
+	// Importing again conflicts unless merging
+	if _, err = dst.data.Import(bundle, false); !errors.Is(err, data.ErrImportConflict) {
+		t.Errorf("expected ErrImportConflict, got %v", err)
+	}
+	if summary, err = dst.data.Import(bundle, true); err != nil || summary.KeysImported {
+		t.Errorf("merge should succeed without replacing keys, got %+v (%v)", summary, err)
+	}
+
+	bundle.Version = data.ExportVersion + 1
+	if _, err = newTestAPI(t).data.Import(bundle, false); !errors.Is(err, data.ErrImportVersion) {
+		t.Errorf("expected ErrImportVersion, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestFDERecoveryKeyEscrow(t *testing.T) {
+	a := newTestAPI(t)
+
+	_, _, privateEnc, publicEnc, err := crypto.GenerateKeyPairs()
+	if err != nil {
+		t.Fatalf("failed to generate keys: %v", err)
+	}
+	a.conf.SP.Set(global.ConfigServerECPrivateEnc, privateEnc)
+
+	if err = a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	key := schema.FDERecoveryKey{Type: schema.FDETypeBitLocker, ID: "{1234}", Key: "111111-222222-333333-444444-555555-666666-777777-888888"}
+	plaintext, _ := json.Marshal(key)
+	encrypted, err := crypto.Encrypt(plaintext, publicEnc)
+	if err != nil {
+		t.Fatalf("failed to encrypt key: %v", err)
+	}
+
+	// The key is stored when the escrow request succeeds
+	requestID, err := a.data.AddAgentRequest(schema.AgentRequest{
+		AgentID:     "agentA",
+		Request:     commands.FDEKeyEscrow,
+		AckRequired: true,
+		Parameters:  map[string]string{"agent_id": "agentA", "admin_user": "admin", "admin_password": "secret"}})
+	if err != nil {
+		t.Fatalf("failed to add request: %v", err)
+	}
+
+	response := schema.NewAgentResponse()
+	response.Cmd = commands.FDEKeyEscrow
+	response.RequestID = requestID
+	response.Success = true
+	response.RecoveryKey = encrypted
+	a.data.AgentSync(data.SyncData{AgentID: "agentA", Responses: []schema.AgentResponse{response}})
+
+	records, err := a.data.GetRequestRecord(requestID)
+	if err != nil {
+		t.Fatalf("failed to get request: %v", err)
+	}
+	if records.Requests[0].Status != schema.RequestStatusComplete {
+		t.Fatalf("expected request complete, got %+v", records.Requests[0])
+	}
+
+	if _, _, err = a.data.GetFDERecoveryKey("agentB", "super"); !errors.Is(err, data.ErrNoFDERecoveryKey) {
+		t.Errorf("expected ErrNoFDERecoveryKey, got %v", err)
+	}
+
+	record, retrieved, err := a.data.GetFDERecoveryKey("agentA", "super")
+	if err != nil {
+		t.Fatalf("GetFDERecoveryKey failed: %v", err)
+	}
+	if retrieved != key || record.RequestID != requestID {
+		t.Errorf("unexpected key %+v from request %s", retrieved, record.RequestID)
+	}
+	if strings.Contains(record.Key, key.Key) {
+		t.Errorf("recovery key stored in plain text")
+	}
+
+	events, err := a.data.GetEvents("agentA", 0, 0, schema.AgentEventAlert)
+	if err != nil || len(events) != 1 || events[0].Details["requester"] != "super" {
+		t.Errorf("expected one retrieval event, got %+v (%v)", events, err)
+	}
+}
+
+func TestFDERecoveryKeyRoute(t *testing.T) {
+	a := newTestAPI(t)
+	admin := loginToken(t, a, "admin", schema.RoleAdmin)
+	super := loginToken(t, a, "super", schema.RoleSuperAdmin)
+
+	for _, route := range a.routes() {
+		if route.Name != "agent-recovery-key" {
+			continue
+		}
+
+		if ok, _, _ := route.AuthFunc("127.0.0.1", admin); ok {
+			t.Errorf("admin role must not retrieve recovery keys")
+		}
+		if ok, _, _ := route.AuthFunc("127.0.0.1", super); !ok {
+			t.Errorf("super admin role should be permitted")
+		}
+		return
+	}
+	t.Errorf("agent-recovery-key route not found")
+}
+
+func TestRedactParameters(t *testing.T) {
+	params := map[string]string{"user": "alice", "password": "a", "admin_password": "b", "migration_token": "c"}
+	schema.RedactParameters(params)
+	if params["user"] != "alice" || params["password"] == "a" || params["admin_password"] == "b" || params["migration_token"] == "c" {
+		t.Errorf("unexpected parameters %v", params)
+	}
+}
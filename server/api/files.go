@@ -6,26 +6,42 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/hasher"
 	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/semver"
 	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/data"
 	"github.com/UnifyEM/UnifyEM/server/global"
 )
 
 // @Summary Generate deploy.json
-// @Description Creates deploy.json containing names and hashes of uem-* files
+// @Description Creates deploy.json, a manifest with the file name, version, build, hash, and minimum supported
+// @Description version of the agent binary for each OS and architecture. Unless the body lists the entries, the
+// @Description files directory is scanned for uem-agent-<os>-<arch> binaries, which are given the version, build, and
+// @Description minimum version in the body, or the server's version and build. If a channel is specified,
+// @Description deploy-<channel>.json is created from the files in the <channel> subdirectory.
 // @Tags Files
 // @Security BearerAuth
+// @Accept json
 // @Produce json
+// @Param channel query string false "Upgrade channel"
+// @Param request body schema.DeployFileRequest false "Versions or explicit entries"
 // @Success 200 {object} schema.APIGenericResponse
+// @Failure 400 {object} schema.API400
 // @Failure 401 {object} schema.API401
 // @Failure 500 {object} schema.API500
 // @Router /files/list [post]
@@ -38,6 +54,38 @@ func (a *API) createDeployFile(req *http.Request) userver.JResponse {
 		fields.NewField("id", authDetails.ID),
 		fields.NewField("role", authDetails.Role))
 
+	badRequest := func(details string) userver.JResponse {
+		logFields.Append(fields.NewField("error", details))
+		a.logger.Warning(2983, "deploy file request rejected", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: details, Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	// The uploads and reports directories are reserved for files fetched from agents and saved reports
+	channel := strings.ToLower(req.URL.Query().Get("channel"))
+	if !schema.ValidChannel(channel) || channel == global.UploadsDir || channel == global.ReportsDir {
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{
+				Details: "invalid channel",
+				Status:  schema.APIStatusError,
+				Code:    http.StatusBadRequest}}
+	}
+	logFields.Append(fields.NewField("channel", channel))
+
+	// The body is optional
+	var deployReq schema.DeployFileRequest
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return badRequest("error reading body")
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err = json.Unmarshal(body, &deployReq); err != nil {
+			return badRequest("error unmarshalling JSON")
+		}
+	}
+
 	// Get the files directory from config
 	filesPath := a.conf.SC.Get(global.ConfigFilesPath).String()
 	if filesPath == "" {
@@ -50,11 +98,14 @@ func (a *API) createDeployFile(req *http.Request) userver.JResponse {
 				Code:    http.StatusInternalServerError}}
 	}
 
-	// Create a map to store filename->hash pairs
-	fileHashes := make(map[string]string)
+	// Agent binaries for a channel are in a subdirectory of the files directory
+	srcPath := filesPath
+	if channel != "" {
+		srcPath = filepath.Join(filesPath, channel)
+	}
 
 	// List all files in the directory
-	files, err := os.ReadDir(filesPath)
+	files, err := os.ReadDir(srcPath)
 	if err != nil {
 		a.logger.Error(2902, fmt.Sprintf("error reading directory: %s", err.Error()), logFields)
 		return userver.JResponse{
@@ -65,29 +116,18 @@ func (a *API) createDeployFile(req *http.Request) userver.JResponse {
 				Code:    http.StatusInternalServerError}}
 	}
 
-	// Instantiate a hasher instance
-	h := hasher.New()
-
-	// Process each file
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
-
-		// Only process files starting with "uem-"
-		if !strings.HasPrefix(file.Name(), "uem-") {
-			continue
-		}
-
-		// Get the hash using existing function
-		hash := h.SHA256File(filepath.Join(filesPath, file.Name())).Base64()
-		if hash != "" {
-			fileHashes[file.Name()] = hash
-		}
+	entries, err := deployEntries(srcPath, files, deployReq)
+	if err != nil {
+		return badRequest(err.Error())
 	}
 
-	// Create the deploy.json file
-	deployFile := filepath.Join(filesPath, schema.DeployInfoFile)
+	manifest := schema.DeployManifest{
+		ManifestVersion: schema.DeployManifestVersion,
+		Created:         time.Now().UTC(),
+		Agents:          entries}
+
+	// Create the deploy information file
+	deployFile := filepath.Join(filesPath, schema.DeployInfoFileName(channel))
 	f, err := os.Create(deployFile)
 	if err != nil {
 		a.logger.Error(2903, fmt.Sprintf("error creating %s: %s", deployFile, err.Error()), logFields)
@@ -105,7 +145,7 @@ func (a *API) createDeployFile(req *http.Request) userver.JResponse {
 	// Write the JSON data
 	encoder := json.NewEncoder(f)
 	encoder.SetIndent("", "  ")
-	if err = encoder.Encode(fileHashes); err != nil {
+	if err = encoder.Encode(manifest); err != nil {
 		a.logger.Error(2904, fmt.Sprintf("error writing %s: %s", deployFile, err.Error()), logFields)
 		return userver.JResponse{
 			HTTPCode: http.StatusInternalServerError,
@@ -115,8 +155,15 @@ func (a *API) createDeployFile(req *http.Request) userver.JResponse {
 				Code:    http.StatusInternalServerError}}
 	}
 
-	logFields.Append(fields.NewField("file_created", deployFile))
-	msg := fmt.Sprintf("%s created successfully", schema.DeployInfoFile)
+	platforms := make([]string, 0, len(entries))
+	for _, e := range entries {
+		platforms = append(platforms, e.OS+"/"+e.Arch)
+	}
+
+	logFields.Append(
+		fields.NewField("file_created", deployFile),
+		fields.NewField("platforms", strings.Join(platforms, ",")))
+	msg := fmt.Sprintf("%s created successfully for %s", schema.DeployInfoFileName(channel), strings.Join(platforms, ", "))
 	a.logger.Info(2905, msg, logFields)
 	return userver.JResponse{
 		HTTPCode: http.StatusOK,
@@ -125,3 +172,231 @@ func (a *API) createDeployFile(req *http.Request) userver.JResponse {
 			Code:    http.StatusOK,
 			Details: msg}}
 }
+
+// deployEntries returns the manifest entries for the agent binaries in srcPath. Explicit
+// entries in the request must name files in srcPath, and any hash they include must match.
+func deployEntries(srcPath string, files []os.DirEntry, deployReq schema.DeployFileRequest) ([]schema.DeployEntry, error) {
+	// Scanned binaries are assumed to have been built with the server
+	if deployReq.Version == "" {
+		deployReq.Version = global.Version
+		if deployReq.Build == 0 {
+			deployReq.Build = global.Build
+		}
+	}
+
+	checkVersion := func(name, version string) error {
+		if version == "" {
+			return nil
+		}
+		if _, err := semver.Parse(version); err != nil {
+			return fmt.Errorf("invalid %s %s: %w", name, version, err)
+		}
+		return nil
+	}
+	if err := checkVersion("version", deployReq.Version); err != nil {
+		return nil, err
+	}
+	if err := checkVersion("min_version", deployReq.MinVersion); err != nil {
+		return nil, err
+	}
+
+	h := hasher.New()
+	var entries []schema.DeployEntry
+
+	if len(deployReq.Agents) == 0 {
+		for _, file := range files {
+			if !file.Type().IsRegular() {
+				continue
+			}
+			goos, goarch, ok := schema.ParseAgentFileName(file.Name())
+			if !ok {
+				continue
+			}
+			hash := h.SHA256File(filepath.Join(srcPath, file.Name())).Base64()
+			if hash == "" {
+				continue
+			}
+			entries = append(entries, schema.DeployEntry{
+				OS:         goos,
+				Arch:       goarch,
+				File:       file.Name(),
+				Version:    deployReq.Version,
+				Build:      deployReq.Build,
+				SHA256:     hash,
+				MinVersion: deployReq.MinVersion})
+		}
+		if len(entries) == 0 {
+			return nil, errors.New("no agent binaries found")
+		}
+	} else {
+		seen := make(map[string]bool)
+		for _, e := range deployReq.Agents {
+			if err := e.Validate(); err != nil {
+				return nil, err
+			}
+			platform := e.OS + "/" + e.Arch
+			if seen[platform] {
+				return nil, fmt.Errorf("duplicate entry for %s", platform)
+			}
+			seen[platform] = true
+
+			if err := checkVersion("version", e.Version); err != nil {
+				return nil, err
+			}
+			if err := checkVersion("min_version", e.MinVersion); err != nil {
+				return nil, err
+			}
+
+			info, err := os.Stat(filepath.Join(srcPath, e.File))
+			if err != nil || !info.Mode().IsRegular() {
+				return nil, fmt.Errorf("file %s not found", e.File)
+			}
+			hash := h.SHA256File(filepath.Join(srcPath, e.File)).Base64()
+			if hash == "" {
+				return nil, fmt.Errorf("unable to hash %s", e.File)
+			}
+			if e.SHA256 != "" && e.SHA256 != hash {
+				return nil, fmt.Errorf("sha256 of %s does not match the file", e.File)
+			}
+			e.SHA256 = hash
+			entries = append(entries, e)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].OS != entries[j].OS {
+			return entries[i].OS < entries[j].OS
+		}
+		return entries[i].Arch < entries[j].Arch
+	})
+	return entries, nil
+}
+
+// @Summary Upload a file
+// @Description Stores a file in the server's file directory, replacing any file with the same name, so that it
+// @Description can be used with download_execute and file_push. The request is multipart/form-data with the file
+// @Description in the "file" field. The name is taken from the file unless the name query parameter is set.
+// @Tags Files
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File to upload"
+// @Param name query string false "Name to store the file as"
+// @Success 200 {object} schema.APIFileUploadResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 413 {object} schema.APIGenericResponse
+// @Failure 500 {object} schema.API500
+// @Router /files [post]
+func (a *API) postFile(w http.ResponseWriter, req *http.Request) {
+	// A large file may take longer to receive than the usual read timeout
+	_ = http.NewResponseController(w).SetReadDeadline(time.Time{})
+
+	resp := a.saveFile(req)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(resp.HTTPCode)
+	_ = json.NewEncoder(w).Encode(resp.JSONData)
+}
+
+func (a *API) saveFile(req *http.Request) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	badRequest := func(details string) userver.JResponse {
+		logFields.Append(fields.NewField("error", details))
+		a.logger.Warning(2994, "file upload rejected", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: details, Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return badRequest("multipart/form-data request required")
+	}
+
+	// Find the file, ignoring any other fields
+	var part *multipart.Part
+	for {
+		part, err = reader.NextPart()
+		if err != nil {
+			return badRequest("file field not found")
+		}
+		if part.FormName() == "file" {
+			break
+		}
+	}
+
+	// Only keep the final element of the name supplied by the client
+	name := req.URL.Query().Get("name")
+	if name == "" {
+		name = filepath.Base(strings.ReplaceAll(part.FileName(), "\\", "/"))
+	}
+	logFields.Append(fields.NewField("name", name))
+
+	maxBytes := int64(a.conf.SC.Get(global.ConfigFileUploadMax).Int()) * 1024 * 1024
+	file, err := a.data.SaveFile(name, part, maxBytes)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidFileName):
+			return badRequest(err.Error())
+		case errors.Is(err, data.ErrUploadTooLarge):
+			logFields.Append(fields.NewField("error", err.Error()))
+			a.logger.Warning(2994, "file upload rejected", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusRequestEntityTooLarge,
+				JSONData: schema.APIGenericResponse{
+					Details: fmt.Sprintf("%s of %d bytes", err.Error(), maxBytes),
+					Status:  schema.APIStatusError,
+					Code:    http.StatusRequestEntityTooLarge}}
+		default:
+			logFields.Append(fields.NewField("error", err.Error()))
+			a.logger.Error(2995, "error storing file", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusInternalServerError,
+				JSONData: schema.API500{Details: "error storing file", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+		}
+	}
+
+	logFields.Append(fields.NewField("size", file.Size), fields.NewField("sha256", file.SHA256))
+	a.logger.Info(2996, "file uploaded", logFields)
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIFileUploadResponse{
+			Status: schema.APIStatusOK,
+			Code:   http.StatusOK,
+			Data:   file}}
+}
+
+// @Summary List files
+// @Description Lists the files in the server's file directory with their size, modification time, and SHA256 hash
+// @Tags Files
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} schema.APIFilesResponse
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /files [get]
+func (a *API) getFiles(req *http.Request) userver.JResponse {
+	files, err := a.data.ListFiles()
+	if err != nil {
+		a.logger.Error(2997, "error listing files",
+			fields.NewFields(
+				fields.NewField("src_ip", userver.RemoteIP(req)),
+				fields.NewField("error", err.Error())))
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error listing files", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIFilesResponse{
+			Status: schema.APIStatusOK,
+			Code:   http.StatusOK,
+			Data:   files}}
+}
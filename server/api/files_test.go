@@ -0,0 +1,172 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestFileUpload(t *testing.T) {
+	a := newTestAPI(t)
+	dir := t.TempDir()
+	a.conf.SC.Set(global.ConfigFilesPath, dir)
+	a.conf.SC.Set(global.ConfigFileUploadMax, 1)
+
+	upload := func(filename, query string, contents []byte) (int, schema.HostedFile) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		_ = mw.WriteField("comment", "ignored")
+		part, err := mw.CreateFormFile("file", filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write(contents)
+		_ = mw.Close()
+
+		req := httptest.NewRequest("POST", schema.EndpointFiles+query, &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		a.postFile(w, req)
+
+		var resp schema.APIFileUploadResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	hash := func(b []byte) string {
+		sum := sha256.Sum256(b)
+		return base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	// Directory components supplied by the client are discarded
+	code, file := upload(`..\..\installer.pkg`, "", []byte("version 1"))
+	if code != http.StatusOK || file.Name != "installer.pkg" || file.Size != 9 || file.SHA256 != hash([]byte("version 1")) {
+		t.Fatalf("unexpected upload result %d %+v", code, file)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "installer.pkg")); string(b) != "version 1" {
+		t.Errorf("unexpected file contents %q", b)
+	}
+
+	// Replacing the file updates the hash sent to agents, even though it was cached
+	files, err := a.data.ListFiles()
+	if err != nil || len(files) != 1 || files[0].SHA256 != hash([]byte("version 1")) {
+		t.Fatalf("unexpected file list %+v: %v", files, err)
+	}
+	if code, file = upload("installer.pkg", "", []byte("version 2")); code != http.StatusOK || file.SHA256 != hash([]byte("version 2")) {
+		t.Errorf("unexpected upload result %d %+v", code, file)
+	}
+	resp := a.getFiles(httptest.NewRequest("GET", schema.EndpointFiles, nil))
+	list, ok := resp.JSONData.(schema.APIFilesResponse)
+	if !ok || len(list.Data) != 1 || list.Data[0].SHA256 != hash([]byte("version 2")) {
+		t.Errorf("unexpected file list %+v", resp.JSONData)
+	}
+
+	for _, name := range []string{"../escape", ".hidden", "uploads", "a:b"} {
+		if code, _ = upload("x", "?name="+name, []byte("data")); code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %q, got %d", name, code)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(filepath.Dir(dir), "escape")); err == nil {
+		t.Error("file was written outside the files directory")
+	}
+
+	if code, _ = upload("large", "", bytes.Repeat([]byte("x"), 1024*1024+1)); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for an oversized file, got %d", code)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "large")); err == nil {
+		t.Error("oversized file was stored")
+	}
+
+	// Temporary files are not left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only installer.pkg, found %d entries", len(entries))
+	}
+}
+
+func TestCreateDeployFile(t *testing.T) {
+	a := newTestAPI(t)
+	dir := t.TempDir()
+	a.conf.SC.Set(global.ConfigFilesPath, dir)
+
+	for name, contents := range map[string]string{
+		"uem-agent-windows-amd64.exe": "windows",
+		"uem-agent-darwin-arm64":      "darwin arm64",
+		"uem-agent-darwin-amd64":      "darwin amd64",
+		"uem-agent-linux-amd64":       "linux",
+		"uem-cli-linux-amd64":         "cli",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	create := func(body string) (int, schema.DeployManifest) {
+		req := httptest.NewRequest("POST", schema.EndpointCreateDeployFile, bytes.NewBufferString(body))
+		resp := a.createDeployFile(req)
+		var m schema.DeployManifest
+		if resp.HTTPCode == http.StatusOK {
+			data, err := os.ReadFile(filepath.Join(dir, schema.DeployInfoFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m, err = schema.ParseDeployInfo(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.HTTPCode, m
+	}
+
+	// Scanned binaries get the server's version unless one is given
+	code, m := create("")
+	if code != http.StatusOK || m.ManifestVersion != schema.DeployManifestVersion || len(m.Agents) != 4 {
+		t.Fatalf("unexpected result %d %+v", code, m)
+	}
+	e, ok := m.Entry("darwin", "arm64")
+	if !ok || e.File != "uem-agent-darwin-arm64" || e.Version != global.Version || e.Build != global.Build {
+		t.Errorf("unexpected darwin/arm64 entry %+v", e)
+	}
+	sum := sha256.Sum256([]byte("darwin arm64"))
+	if e.SHA256 != base64.StdEncoding.EncodeToString(sum[:]) {
+		t.Errorf("unexpected hash %s", e.SHA256)
+	}
+
+	code, m = create(`{"version":"0.0.61","build":110,"min_version":"0.0.50"}`)
+	e, _ = m.Entry("linux", "amd64")
+	if code != http.StatusOK || e.Version != "0.0.61" || e.Build != 110 || e.MinVersion != "0.0.50" {
+		t.Errorf("unexpected result %d %+v", code, e)
+	}
+
+	// Explicit entries only include the listed platforms
+	code, m = create(`{"agents":[{"os":"linux","arch":"amd64","file":"uem-agent-linux-amd64","version":"1.0.0"}]}`)
+	if code != http.StatusOK || len(m.Agents) != 1 || m.Agents[0].SHA256 == "" {
+		t.Errorf("unexpected result %d %+v", code, m)
+	}
+
+	for _, body := range []string{
+		`{"version":"not a version"}`,
+		`{"agents":[{"os":"linux","arch":"amd64","file":"missing"}]}`,
+		`{"agents":[{"os":"linux","arch":"amd64","file":"../uem-agent-linux-amd64"}]}`,
+		`{"agents":[{"os":"linux","arch":"amd64","file":"uem-agent-linux-amd64","sha256":"d3Jvbmc="}]}`,
+		`{"agents":[{"os":"linux","arch":"amd64","file":"uem-agent-linux-amd64"},{"os":"linux","arch":"amd64","file":"uem-agent-linux-amd64"}]}`,
+	} {
+		if code, _ = create(body); code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, code)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/data"
+)
+
+// @Summary List groups
+// @Description Returns all agent groups
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} schema.APIGroupsResponse
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /group [get]
+func (a *API) getGroups(_ *http.Request) userver.JResponse {
+	groups, err := a.data.GetGroups()
+	if err != nil {
+		a.logger.Error(2945, "error retrieving groups", fields.NewFields(fields.NewField("error", err.Error())))
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error retrieving groups", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGroupsResponse{
+			Status: schema.APIStatusOK,
+			Code:   http.StatusOK,
+			Groups: groups}}
+}
+
+// @Summary Get group
+// @Description Returns a group and the agents it contains, including those in the groups it contains
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Group name"
+// @Success 200 {object} schema.APIGroupsResponse
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Router /group/{name} [get]
+func (a *API) getGroup(req *http.Request) userver.JResponse {
+	name := userver.GetParam(req, "name")
+
+	group, err := a.data.GetGroup(name)
+	if err != nil {
+		return groupError(err)
+	}
+
+	agents, err := a.data.GroupAgents(name)
+	if err != nil {
+		return groupError(err)
+	}
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGroupsResponse{
+			Status: schema.APIStatusOK,
+			Code:   http.StatusOK,
+			Groups: []schema.Group{group},
+			Agents: agents}}
+}
+
+// @Summary Create group
+// @Description Creates an empty agent group
+// @Tags Agent management
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param group body schema.GroupRequest true "Group"
+// @Success 200 {object} schema.APIGroupsResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /group [post]
+func (a *API) postGroup(req *http.Request) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	var groupReq schema.GroupRequest
+	if errResp := readJSON(req, &groupReq); errResp != nil {
+		return *errResp
+	}
+	logFields.Append(fields.NewField("group", groupReq.Name))
+
+	group, err := a.data.CreateGroup(groupReq.Name, groupReq.Description)
+	if err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Warning(2946, "error creating group", logFields)
+		return groupError(err)
+	}
+
+	a.logger.Info(2947, "group created", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGroupsResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "group created",
+			Groups:  []schema.Group{group}}}
+}
+
+// @Summary Delete group
+// @Description Deletes a group and removes it from any groups that contain it. Agents are not affected.
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Group name"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Router /group/{name} [delete]
+func (a *API) deleteGroup(req *http.Request) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+	name := userver.GetParam(req, "name")
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("group", name))
+
+	if err := a.data.DeleteGroup(name); err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Warning(2948, "error deleting group", logFields)
+		return groupError(err)
+	}
+
+	a.logger.Info(2949, "group deleted", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGenericResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "group deleted"}}
+}
+
+// @Summary Add group members
+// @Description Adds agents and other groups to a group. A group may not contain itself, directly or through another group.
+// @Tags Agent management
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param name path string true "Group name"
+// @Param members body schema.GroupMembersRequest true "Members to add"
+// @Success 200 {object} schema.APIGroupsResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Router /group/{name}/add [post]
+func (a *API) postGroupMembersAdd(req *http.Request) userver.JResponse {
+	return a.groupMembers(req, true)
+}
+
+// @Summary Remove group members
+// @Description Removes agents and other groups from a group
+// @Tags Agent management
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param name path string true "Group name"
+// @Param members body schema.GroupMembersRequest true "Members to remove"
+// @Success 200 {object} schema.APIGroupsResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Router /group/{name}/remove [post]
+func (a *API) postGroupMembersRemove(req *http.Request) userver.JResponse {
+	return a.groupMembers(req, false)
+}
+
+// groupMembers adds or removes group members
+func (a *API) groupMembers(req *http.Request, add bool) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+	name := userver.GetParam(req, "name")
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("group", name))
+
+	var membersReq schema.GroupMembersRequest
+	if errResp := readJSON(req, &membersReq); errResp != nil {
+		return *errResp
+	}
+	logFields.Append(
+		fields.NewField("agents", membersReq.Agents),
+		fields.NewField("groups", membersReq.Groups),
+		fields.NewField("add", add))
+
+	var group schema.Group
+	var err error
+	if add {
+		group, err = a.data.AddGroupMembers(name, membersReq.Agents, membersReq.Groups)
+	} else {
+		group, err = a.data.RemoveGroupMembers(name, membersReq.Agents, membersReq.Groups)
+	}
+	if err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Warning(2950, "error updating group members", logFields)
+		return groupError(err)
+	}
+
+	a.logger.Info(2951, "group members updated", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGroupsResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "group updated",
+			Groups:  []schema.Group{group}}}
+}
+
+// groupError returns the response for an error from the group functions in the data layer
+func groupError(err error) userver.JResponse {
+	switch {
+	case errors.Is(err, data.ErrGroupNotFound):
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusNotFound}}
+	case errors.Is(err, data.ErrGroupExists), errors.Is(err, data.ErrGroupCycle), errors.Is(err, data.ErrInvalidGroupName),
+		errors.Is(err, data.ErrGroupMemberNotFound):
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	default:
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error updating group", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+}
+
+// readJSON reads the request body into v
+func readJSON(req *http.Request, v any) *userver.JResponse {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return &userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "error reading body", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	if err = json.Unmarshal(body, v); err != nil {
+		return &userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "error unmarshalling JSON", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+)
+
+func TestGroups(t *testing.T) {
+	a := newTestAPI(t)
+
+	for _, id := range []string{"agentA", "agentB", "agentC"} {
+		if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: id}); err != nil {
+			t.Fatalf("failed to create agent: %v", err)
+		}
+	}
+
+	for _, name := range []string{"Laptops", "Mac Laptops", "Windows Laptops"} {
+		if _, err := a.data.CreateGroup(name, ""); err != nil {
+			t.Fatalf("failed to create group %s: %v", name, err)
+		}
+	}
+
+	if _, err := a.data.CreateGroup("laptops", ""); !errors.Is(err, data.ErrGroupExists) {
+		t.Errorf("duplicate group: expected ErrGroupExists, got %v", err)
+	}
+	if _, err := a.data.CreateGroup("a/b", ""); !errors.Is(err, data.ErrInvalidGroupName) {
+		t.Errorf("invalid name: expected ErrInvalidGroupName, got %v", err)
+	}
+
+	add := func(group string, agents, groups []string) error {
+		_, err := a.data.AddGroupMembers(group, agents, groups)
+		return err
+	}
+
+	if err := add("Mac Laptops", []string{"agentA", "agentB"}, nil); err != nil {
+		t.Fatalf("failed to add agents: %v", err)
+	}
+	if err := add("Windows Laptops", []string{"agentB", "agentC"}, nil); err != nil {
+		t.Fatalf("failed to add agents: %v", err)
+	}
+	if err := add("Laptops", nil, []string{"mac laptops", "Windows Laptops"}); err != nil {
+		t.Fatalf("failed to add groups: %v", err)
+	}
+
+	if err := add("Mac Laptops", []string{"agentX"}, nil); !errors.Is(err, data.ErrGroupMemberNotFound) {
+		t.Errorf("unknown agent: expected ErrGroupMemberNotFound, got %v", err)
+	}
+
+	// Direct and indirect cycles must be rejected
+	if err := add("Laptops", nil, []string{"Laptops"}); !errors.Is(err, data.ErrGroupCycle) {
+		t.Errorf("self: expected ErrGroupCycle, got %v", err)
+	}
+	if err := add("Mac Laptops", nil, []string{"Laptops"}); !errors.Is(err, data.ErrGroupCycle) {
+		t.Errorf("parent: expected ErrGroupCycle, got %v", err)
+	}
+
+	agents, err := a.data.GroupAgents("LAPTOPS")
+	if err != nil {
+		t.Fatalf("GroupAgents failed: %v", err)
+	}
+	if !slices.Equal(agents, []string{"agentA", "agentB", "agentC"}) {
+		t.Errorf("unexpected agents: %v", agents)
+	}
+
+	// Deleting a child group removes it from its parent
+	if err = a.data.DeleteGroup("Windows Laptops"); err != nil {
+		t.Fatalf("DeleteGroup failed: %v", err)
+	}
+	group, _ := a.data.GetGroup("Laptops")
+	if !slices.Equal(group.Groups, []string{"Mac Laptops"}) {
+		t.Errorf("unexpected child groups: %v", group.Groups)
+	}
+	agents, _ = a.data.GroupAgents("Laptops")
+	if !slices.Equal(agents, []string{"agentA", "agentB"}) {
+		t.Errorf("unexpected agents after delete: %v", agents)
+	}
+}
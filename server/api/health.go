@@ -0,0 +1,49 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/queue"
+)
+
+// queueSaturation is the percentage of the message queue that must be in use for it to be considered saturated
+const queueSaturation = 90
+
+// checkDatabase confirms that the database is open and writable
+func (a *API) checkDatabase() error {
+	return a.data.CheckDatabase()
+}
+
+// checkFiles confirms that the files path exists and is writable
+func (a *API) checkFiles() error {
+	filesPath := a.conf.SC.Get(global.ConfigFilesPath).String()
+	if filesPath == "" {
+		return errors.New("files path is not set")
+	}
+
+	f, err := os.CreateTemp(filesPath, ".health-*")
+	if err != nil {
+		return fmt.Errorf("files path is not writable: %w", err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+// checkQueue confirms that the message queue is not saturated
+func (a *API) checkQueue() error {
+	size := queue.Size()
+	capacity := queue.Capacity()
+	if capacity > 0 && size*100 >= capacity*queueSaturation {
+		return fmt.Errorf("message queue is saturated (%d of %d)", size, capacity)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestHealthChecks(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigFilesPath, t.TempDir())
+
+	s, err := userver.New(
+		userver.WithLogger(null.Logger()),
+		userver.WithHealthCheck("database", a.checkDatabase),
+		userver.WithHealthCheck("files", a.checkFiles),
+		userver.WithHealthCheck("queue", a.checkQueue),
+		userver.WithHealthInfo("database_status", a.data.DatabaseStatus))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+
+	resp := s.HandlerHealth(nil)
+	if resp.HTTPCode != http.StatusOK {
+		t.Fatalf("expected 200 when all checks pass, got %d: %+v", resp.HTTPCode, resp.JSONData)
+	}
+	if r, _ := resp.JSONData.(userver.Response); r.Data.(map[string]string)["database_status"] != "ok" {
+		t.Errorf("expected database status in health response, got %+v", r.Data)
+	}
+
+	// An unwritable files path and a closed database must both be reported
+	a.conf.SC.Set(global.ConfigFilesPath, filepath.Join(t.TempDir(), "missing"))
+	a.data.Close()
+
+	resp = s.HandlerHealth(nil)
+	if resp.HTTPCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 when checks fail, got %d", resp.HTTPCode)
+	}
+
+	r, ok := resp.JSONData.(userver.Response)
+	if !ok {
+		t.Fatalf("unexpected response type %T", resp.JSONData)
+	}
+	if !strings.Contains(r.Details, "database") || !strings.Contains(r.Details, "files") || strings.Contains(r.Details, "queue") {
+		t.Errorf("unexpected failed checks: %s", r.Details)
+	}
+}
@@ -0,0 +1,48 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestIPHistory(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigIPHistorySize, 2)
+
+	// A missing database is logged and the addresses are recorded without a location
+	a.conf.SC.Set(global.ConfigGeoIPDatabase, filepath.Join(t.TempDir(), "missing.mmdb"))
+
+	for _, ip := range []string{"203.0.113.7", "198.51.100.20", "203.0.113.7", "192.0.2.1"} {
+		a.data.AgentSync(data.SyncData{AgentID: "agentA", RemoteIP: ip})
+	}
+
+	list, err := a.data.GetAgentMeta("agentA")
+	if err != nil || len(list.Agents) != 1 {
+		t.Fatalf("failed to get agent: %v", err)
+	}
+	meta := list.Agents[0]
+
+	if meta.LastIP != "192.0.2.1" {
+		t.Errorf("expected the last IP to be 192.0.2.1, got %s", meta.LastIP)
+	}
+	if len(meta.IPHistory) != 2 {
+		t.Fatalf("expected 2 addresses, got %+v", meta.IPHistory)
+	}
+	if meta.IPHistory[0].IP != "192.0.2.1" || meta.IPHistory[1].IP != "203.0.113.7" {
+		t.Errorf("unexpected IP history: %+v", meta.IPHistory)
+	}
+
+	// The first time an address was seen is kept when it is seen again
+	previous := meta.IPHistory[1]
+	if !previous.FirstSeen.Before(previous.LastSeen) || previous.Country != "" {
+		t.Errorf("unexpected entry for a repeated address: %+v", previous)
+	}
+}
@@ -0,0 +1,67 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/metrics"
+	"github.com/UnifyEM/UnifyEM/server/queue"
+)
+
+// When many agents sync at once, for example after a network outage, waiting for a connection
+// slot causes them to time out and retry. Instead, syncs beyond the configured limits are
+// answered immediately with 429 and a Retry-After time that is randomized so that the agents
+// don't return together.
+
+// syncOverloaded returns the reason that a sync should be deferred, or "" if it can proceed.
+// inProgress includes the sync being checked.
+func (a *API) syncOverloaded(inProgress int64) string {
+	maxConcurrent := a.conf.SC.Get(global.ConfigSyncMaxConcurrent).Int64()
+	if maxConcurrent > 0 && inProgress > maxConcurrent {
+		return "too many syncs in progress"
+	}
+
+	maxQueue := a.conf.SC.Get(global.ConfigSyncMaxQueue).Int()
+	capacity := queue.Capacity()
+	if maxQueue > 0 && capacity > 0 && queue.Size()*100 >= capacity*maxQueue {
+		return "message queue is full"
+	}
+	return ""
+}
+
+// syncRetryAfter returns a random number of seconds between sync_retry_after and twice that
+func (a *API) syncRetryAfter() int {
+	base := max(a.conf.SC.Get(global.ConfigSyncRetryAfter).Int(), 1)
+	return base + rand.IntN(base+1)
+}
+
+// syncShedResponse tells the agent to retry later
+func (a *API) syncShedResponse(reason string, logFields *fields.Fields) userver.JResponse {
+	retryAfter := a.syncRetryAfter()
+	metrics.Sync(metrics.SyncShed)
+
+	// Debug only, since this is expected to happen many times in a short period
+	logFields.Append(fields.NewField("retry_after", retryAfter))
+	a.logger.Debug(2755, "sync deferred: "+reason, logFields)
+
+	return userver.JResponse{
+		HTTPCode: http.StatusTooManyRequests,
+		Headers:  map[string]string{"Retry-After": strconv.Itoa(retryAfter)},
+		JSONData: schema.APISyncResponse{
+			Status:     schema.APIStatusError,
+			Code:       http.StatusTooManyRequests,
+			Details:    "server busy, retry later",
+			ServerTime: time.Now().Unix(),
+			RetryAfter: retryAfter}}
+}
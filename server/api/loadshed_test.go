@@ -0,0 +1,72 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/queue"
+)
+
+func TestSyncOverloaded(t *testing.T) {
+	a := newTestAPI(t)
+	queue.Init(10)
+	t.Cleanup(queue.Close)
+
+	// Disabled by default in the test configuration
+	if reason := a.syncOverloaded(1000); reason != "" {
+		t.Errorf("expected no limit, got %q", reason)
+	}
+
+	a.conf.SC.Set(global.ConfigSyncMaxConcurrent, 2)
+	if reason := a.syncOverloaded(2); reason != "" {
+		t.Errorf("expected sync 2 of 2 to proceed, got %q", reason)
+	}
+	if reason := a.syncOverloaded(3); reason == "" {
+		t.Errorf("expected sync 3 of 2 to be deferred")
+	}
+
+	a.conf.SC.Set(global.ConfigSyncMaxQueue, 50)
+	for x := 0; x < 4; x++ {
+		_ = queue.Add(schema.AgentMessage{AgentID: "A-1", Sent: time.Now(), MessageType: schema.AgentEventMessage})
+	}
+	if reason := a.syncOverloaded(1); reason != "" {
+		t.Errorf("expected a sync with the queue 40%% full to proceed, got %q", reason)
+	}
+	_ = queue.Add(schema.AgentMessage{AgentID: "A-1", Sent: time.Now(), MessageType: schema.AgentEventMessage})
+	if reason := a.syncOverloaded(1); reason == "" {
+		t.Errorf("expected a sync with the queue 50%% full to be deferred")
+	}
+}
+
+func TestSyncShedResponse(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigSyncRetryAfter, 30)
+
+	for x := 0; x < 20; x++ {
+		resp := a.syncShedResponse("test", fields.NewFields())
+		if resp.HTTPCode != http.StatusTooManyRequests {
+			t.Fatalf("expected 429, got %d", resp.HTTPCode)
+		}
+
+		body, ok := resp.JSONData.(schema.APISyncResponse)
+		if !ok {
+			t.Fatalf("unexpected response type %T", resp.JSONData)
+		}
+		if body.Code != http.StatusTooManyRequests || body.RetryAfter < 30 || body.RetryAfter > 60 {
+			t.Errorf("unexpected response code %d or retry_after %d", body.Code, body.RetryAfter)
+		}
+		if resp.Headers["Retry-After"] != strconv.Itoa(body.RetryAfter) {
+			t.Errorf("Retry-After header %q does not match retry_after %d", resp.Headers["Retry-After"], body.RetryAfter)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/data"
+)
+
+func TestLostModeLocation(t *testing.T) {
+	a := newTestAPI(t)
+
+	meta := schema.NewAgentMeta("agentA")
+	if err := a.data.SetAgentMeta(meta); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	status := func() {
+		response := schema.NewAgentResponse()
+		response.Cmd = commands.Status
+		response.RequestID = "status"
+		response.Success = true
+		response.Data = map[string]any{"details": map[string]any{
+			"ip":                    "192.168.1.20",
+			schema.StatusGatewayMAC: "a4:5e:60:e1:02:03",
+		}}
+		a.data.AgentSync(data.SyncData{AgentID: "agentA", RemoteIP: "203.0.113.5", Responses: []schema.AgentResponse{response}})
+	}
+
+	// No location is recorded unless the agent is lost
+	status()
+	events, err := a.data.GetEvents("agentA", 0, 0, schema.AgentEventLocation)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("expected no location events, got %d (%v)", len(events), err)
+	}
+
+	meta.Triggers.Lost = true
+	if err = a.data.SetAgentMeta(meta); err != nil {
+		t.Fatalf("failed to set lost mode: %v", err)
+	}
+
+	status()
+	events, err = a.data.GetEvents("agentA", 0, 0, schema.AgentEventLocation)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected one location event, got %d (%v)", len(events), err)
+	}
+
+	expected := map[string]string{"public_ip": "203.0.113.5", "ip": "192.168.1.20", schema.StatusGatewayMAC: "a4:5e:60:e1:02:03"}
+	for key, value := range expected {
+		if events[0].Details[key] != value {
+			t.Errorf("expected %s=%s, got %q", key, value, events[0].Details[key])
+		}
+	}
+	if _, ok := events[0].Details[schema.StatusWiFiSSID]; ok {
+		t.Errorf("wifi_ssid must only be included if reported")
+	}
+}
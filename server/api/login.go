@@ -7,19 +7,27 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/data"
 )
 
-// failureResponse provides a consistent response to failed authentication attempts
-var failureResponse = userver.JResponse{
-	HTTPCode: http.StatusUnauthorized,
-	JSONData: authFailResponse}
+// failureResponse provides a consistent response to failed authentication attempts. It includes
+// the server's time so that agents can detect clock skew.
+func failureResponse() userver.JResponse {
+	msg := authFailResponse
+	msg.ServerTime = time.Now().Unix()
+	return userver.JResponse{
+		HTTPCode: http.StatusUnauthorized,
+		JSONData: msg}
+}
 
 var authFailResponse = schema.API401{
 	Status:  schema.APIStatusError,
@@ -33,7 +41,8 @@ var authFailResponse = schema.API401{
 // @Produce json
 // @Param credentials body schema.LoginRequest true "Username and password"
 // @Success 200 {object} schema.APILoginResponse "Authentication successful"
-// @Failure 401 {object} schema.API401 "Authentication failed"
+// @Failure 401 {object} schema.API401 "Authentication failed or account locked"
+// @Failure 429 {object} schema.API401 "Too many requests"
 // @Router /login [post]
 func (a *API) postLogin(req *http.Request) userver.JResponse {
 
@@ -42,14 +51,14 @@ func (a *API) postLogin(req *http.Request) userver.JResponse {
 	// Get the JSON post data
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		return failureResponse
+		return failureResponse()
 	}
 
 	// Deserialize the JSON
 	var loginRequest schema.LoginRequest
 	err = json.Unmarshal(body, &loginRequest)
 	if err != nil {
-		return failureResponse
+		return failureResponse()
 	}
 
 	// Information to be logged as fields
@@ -60,15 +69,26 @@ func (a *API) postLogin(req *http.Request) userver.JResponse {
 	// Check for missing required fields
 	if loginRequest.Username == "" || loginRequest.Password == "" {
 		a.logger.Error(2861, "login missing required fields", logInfo)
-		return failureResponse
+		return failureResponse()
 	}
 
 	// Authenticate user
-	accessToken, refreshToken, err := a.data.LoginGetToken(loginRequest.Username, loginRequest.Password)
+	accessToken, refreshToken, err := a.data.LoginGetToken(loginRequest.Username, loginRequest.Password, remoteIP)
 	if err != nil {
 		logInfo.Append(fields.NewField("auth-result", "failed"), fields.NewField("error", err.Error()))
 		a.logger.Error(2862, fmt.Sprintf("login failed: %s", err.Error()), logInfo)
-		return failureResponse
+
+		// Tell the user when the account is locked so they know to wait
+		var locked *data.AccountLockedError
+		if errors.As(err, &locked) {
+			return userver.JResponse{
+				HTTPCode: http.StatusUnauthorized,
+				JSONData: schema.API401{
+					Status:  schema.APIStatusError,
+					Code:    http.StatusUnauthorized,
+					Details: locked.Error()}}
+		}
+		return failureResponse()
 	}
 
 	logInfo.Append(fields.NewField("auth-result", "success"))
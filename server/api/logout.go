@@ -0,0 +1,65 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+)
+
+// @Summary Log out
+// @Description Revokes a refresh token so that it can no longer be used to obtain access tokens
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param refreshRequest body schema.RefreshRequest true "Refresh token"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 401 {object} schema.API401 "Invalid or expired refresh token"
+// @Router /logout [post]
+// postLogout revokes the refresh token presented by the client
+func (a *API) postLogout(req *http.Request) userver.JResponse {
+
+	remoteIP := userver.RemoteIP(req)
+
+	// Get the JSON post data
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return failureResponse()
+	}
+
+	// Deserialize the JSON
+	var logoutRequest schema.RefreshRequest
+	err = json.Unmarshal(body, &logoutRequest)
+	if err != nil {
+		return failureResponse()
+	}
+
+	// Information to be logged as fields
+	logInfo := fields.NewFields(
+		fields.NewField("src_ip", remoteIP))
+
+	subject, err := a.data.RevokeRefreshToken(logoutRequest.RefreshToken)
+	if err != nil {
+		logInfo.Append(fields.NewField("error", err.Error()))
+		a.logger.Warning(2987, "logout failed", logInfo)
+		return failureResponse()
+	}
+
+	logInfo.Append(fields.NewField("id", subject))
+	a.logger.Info(2988, "refresh token revoked", logInfo)
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGenericResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "logged out"}}
+}
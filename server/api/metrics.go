@@ -0,0 +1,96 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/metrics"
+	"github.com/UnifyEM/UnifyEM/server/queue"
+)
+
+// staleAgentAge is how long since an agent was last seen before it is counted as stale
+const staleAgentAge = time.Hour
+
+// metricsRoutes returns the metrics route, which is only served when metrics are enabled
+func (a *API) metricsRoutes(authFunc userver.AuthFunc) userver.Routes {
+	return userver.Routes{
+		{
+			Name:     "metrics",
+			Methods:  []string{"GET"},
+			Pattern:  global.MetricsPath,
+			Handler:  http.HandlerFunc(a.getMetrics),
+			AuthFunc: authFunc},
+	}
+}
+
+// getMetrics returns server metrics in the Prometheus text format
+func (a *API) getMetrics(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	err := metrics.Write(w, a.metricsGauges())
+	if err != nil {
+		a.logger.Error(2932, "error writing metrics", fields.NewFields(
+			fields.NewField("src_ip", userver.RemoteIP(req)),
+			fields.NewField("error", err.Error())))
+	}
+}
+
+// metricsGauges calculates the gauges that reflect the current state of the server
+func (a *API) metricsGauges() []metrics.Gauge {
+	var registered, stale float64
+
+	agents, err := a.data.GetAllAgentMeta()
+	if err != nil {
+		a.logger.Errorf(2933, "error retrieving agents for metrics: %s", err.Error())
+	}
+
+	cutoff := time.Now().Add(-staleAgentAge)
+	for _, agent := range agents.Agents {
+		registered++
+		if agent.LastSeen.Before(cutoff) {
+			stale++
+		}
+	}
+
+	return []metrics.Gauge{
+		{Name: "uem_agents_registered", Help: "Registered agents.", Value: registered},
+		{Name: "uem_agents_stale", Help: "Agents not seen in the last hour.", Value: stale},
+		{Name: "uem_db_size_bytes", Help: "Size of the database in bytes.", Value: float64(a.data.DatabaseSize())},
+		{Name: "uem_message_queue_depth", Help: "Agent messages waiting to be processed.", Value: float64(queue.Size())},
+		{Name: "uem_syncs_in_progress", Help: "Agent syncs being processed.", Value: float64(a.syncs.Load())},
+	}
+}
+
+// startMetrics serves metrics on a separate listen address without authentication.
+// It is intended for an address that is only reachable by the metrics collector.
+func (a *API) startMetrics(listen string) {
+	for {
+		s, err := userver.New(
+			userver.WithLogger(a.logger),
+			userver.WithSEid(2550),
+			userver.WithListen(listen),
+			userver.WithHealthHandler(false),
+			userver.WithHTTPTimeout(a.conf.SC.Get(global.ConfigHTTPTimeout).Int()),
+			userver.WithHTTPIdleTimeout(a.conf.SC.Get(global.ConfigHTTPIdleTimeout).Int()),
+			userver.WithHandlerTimeout(a.conf.SC.Get(global.ConfigHandlerTimeout).Int()))
+		if err == nil {
+			s.AddRoutes(a.metricsRoutes(nil))
+			err = s.Start()
+		}
+
+		if err == nil {
+			return
+		}
+		a.logger.Errorf(2934, "metrics listener error: %s", err.Error())
+
+		// Sleep before trying again
+		time.Sleep(10 * time.Second)
+	}
+}
@@ -0,0 +1,74 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/notify"
+)
+
+// @Summary Send a test notification
+// @Description Sends a test event to each configured notification sink (webhook and syslog) and reports the result for each
+// @Tags Server management
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} schema.APINotifyTestResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 502 {object} schema.APINotifyTestResponse
+// @Router /notify/test [post]
+func (a *API) postNotifyTest(req *http.Request) userver.JResponse {
+
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	results, err := notify.Test(authDetails.ID)
+	if err != nil {
+		a.logger.Error(2936, err.Error(), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	var failed []string
+	for name, result := range results {
+		logFields.Append(fields.NewField(name, result))
+		if result != "ok" {
+			failed = append(failed, name)
+		}
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		a.logger.Error(2937, "notification test failed", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadGateway,
+			JSONData: schema.APINotifyTestResponse{
+				Status:  schema.APIStatusError,
+				Code:    http.StatusBadGateway,
+				Details: "notification test failed: " + strings.Join(failed, ", "),
+				Data:    results}}
+	}
+
+	a.logger.Info(2938, "notification test sent", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APINotifyTestResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "test event sent",
+			Data:    results}}
+}
@@ -0,0 +1,83 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestOfflineAgents(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigAgentOfflineThreshold, 60)
+
+	agents := []schema.AgentMeta{
+		{AgentID: "agentA", LastSeen: time.Now().Add(-2 * time.Hour)},
+		{AgentID: "agentB", LastSeen: time.Now()},
+		{AgentID: "agentC", LastSeen: time.Now().Add(-2 * time.Hour), State: schema.AgentStateUninstalled},
+	}
+	for _, agent := range agents {
+		if err := a.data.SetAgentMeta(agent); err != nil {
+			t.Fatalf("failed to create agent: %v", err)
+		}
+	}
+
+	connectivity := func(agentID string) []string {
+		t.Helper()
+		// Agents without any events don't have an event bucket
+		events, _ := a.data.GetEvents(agentID, 0, 0, schema.AgentEventConnectivity)
+		var result []string
+		for _, event := range events {
+			result = append(result, event.Event)
+		}
+		return result
+	}
+
+	offline := func(agentID string) bool {
+		t.Helper()
+		list, err := a.data.GetAgentMeta(agentID)
+		if err != nil || len(list.Agents) != 1 {
+			t.Fatalf("failed to get agent: %v", err)
+		}
+		return list.Agents[0].Offline
+	}
+
+	// Only the agent that crossed the threshold is reported, and only once
+	a.CheckOfflineAgents()
+	a.CheckOfflineAgents()
+	if !offline("agentA") || offline("agentB") || offline("agentC") {
+		t.Errorf("expected only agentA to be offline")
+	}
+	if events := connectivity("agentA"); len(events) != 1 || events[0] != schema.AgentWentOffline {
+		t.Errorf("expected one offline event, got %v", events)
+	}
+	if events := connectivity("agentB"); len(events) != 0 {
+		t.Errorf("expected no events for agentB, got %v", events)
+	}
+
+	// A sync brings the agent back online
+	a.data.AgentSync(data.SyncData{AgentID: "agentA", RemoteIP: "203.0.113.5", Version: "0.0.61", Build: 1})
+	if offline("agentA") {
+		t.Errorf("expected agentA to be online after syncing")
+	}
+	if events := connectivity("agentA"); len(events) != 2 {
+		t.Errorf("expected offline and online events, got %v", events)
+	}
+
+	// Nothing is reported until the threshold has passed since the server started
+	if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: "agentD", LastSeen: time.Now().Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	a.started = time.Now()
+	a.CheckOfflineAgents()
+	if offline("agentD") {
+		t.Errorf("expected no offline agents immediately after starting")
+	}
+}
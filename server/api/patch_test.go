@@ -0,0 +1,63 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/data"
+)
+
+func TestPatchInstallInProgress(t *testing.T) {
+	a := newTestAPI(t)
+
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	requestID, err := a.data.AddAgentRequest(schema.AgentRequest{
+		AgentID:     "agentA",
+		Request:     commands.PatchInstall,
+		AckRequired: true,
+		Parameters:  map[string]string{"agent_id": "agentA", "reboot_allowed": "true"}})
+	if err != nil {
+		t.Fatalf("failed to add request: %v", err)
+	}
+	if _, err = a.data.GetAgentRequests("agentA", true); err != nil {
+		t.Fatalf("failed to mark request sent: %v", err)
+	}
+
+	// The initial response acknowledges the request without completing it
+	response := schema.AgentResponse{RequestID: requestID, Cmd: commands.PatchInstall, Response: "installing all pending updates", Success: true, InProgress: true}
+	a.data.AgentSync(data.SyncData{AgentID: "agentA", Responses: []schema.AgentResponse{response}})
+
+	records, _ := a.data.GetRequestRecord(requestID)
+	request := records.Requests[0]
+	if request.Status != schema.RequestStatusPending || request.TimeAcknowledged.IsZero() || !request.TimeCompleted.IsZero() {
+		t.Fatalf("expected acknowledged pending request, got %+v", request)
+	}
+
+	// The final response completes the request and updates the agent's patch status
+	response = schema.AgentResponse{RequestID: requestID, Cmd: commands.PatchInstall, Response: "done", Success: true,
+		Data: map[string]any{"source": "apt", "count": 1, "reboot_required": true, "updates": []any{map[string]any{"name": "openssl", "version": "3.0.2"}}}}
+	a.data.AgentSync(data.SyncData{AgentID: "agentA", Responses: []schema.AgentResponse{response}})
+
+	records, _ = a.data.GetRequestRecord(requestID)
+	if records.Requests[0].Status != schema.RequestStatusComplete {
+		t.Errorf("expected complete request, got %+v", records.Requests[0])
+	}
+
+	agents, err := a.data.GetAgentMeta("agentA")
+	if err != nil {
+		t.Fatalf("failed to get agent: %v", err)
+	}
+	patches := agents.Agents[0].Patches
+	if patches == nil || patches.Count != 1 || !patches.RebootRequired || patches.Updates[0].Name != "openssl" {
+		t.Errorf("unexpected patch status %+v", patches)
+	}
+}
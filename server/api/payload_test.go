@@ -0,0 +1,87 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/hasher"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestDownloadExecutePayload(t *testing.T) {
+	a := newTestAPI(t)
+	dir := t.TempDir()
+	a.conf.SC.Set(global.ConfigFilesPath, dir)
+
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	// Host a signed file
+	privateKey, publicKey, err := crypto.GenerateSingleKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "installer")
+	if err = os.WriteFile(file, []byte("payload"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash := hasher.New().SHA512File(file).Base64()
+	signature, err := crypto.Sign(schema.PayloadSignatureData(hasher.SHA512, hash), privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(file+".sig", []byte(signature+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	parameters := []map[string]string{
+		{"agent_id": "agentA", "url": "https://uem.example.com/files/installer", "hash_alg": hasher.SHA512},
+		{"agent_id": "agentA", "url": "https://vendor.example.com/setup", "hash": "vendorhash", "hash_alg": hasher.SHA512},
+	}
+	for _, p := range parameters {
+		if err = commands.Validate(commands.DownloadExecute, p); err != nil {
+			t.Fatalf("validation failed: %v", err)
+		}
+		if _, err = a.data.AddAgentRequest(schema.AgentRequest{AgentID: "agentA", Request: commands.DownloadExecute, Parameters: p}); err != nil {
+			t.Fatalf("failed to add request: %v", err)
+		}
+	}
+
+	requests, err := a.data.GetAgentRequests("agentA", true)
+	if err != nil || len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d: %v", len(requests), err)
+	}
+
+	for _, r := range requests {
+		switch r.Parameters["url"] {
+		case parameters[0]["url"]:
+			// The server hashes the hosted file and adds its signature
+			if r.Parameters["hash"] != hash || r.Parameters["signature"] != signature {
+				t.Errorf("unexpected parameters for hosted file: %v", r.Parameters)
+			}
+			ok, err := crypto.Verify(schema.PayloadSignatureData(r.Parameters["hash_alg"], r.Parameters["hash"]), r.Parameters["signature"], publicKey)
+			if err != nil || !ok {
+				t.Errorf("signature did not verify: %v", err)
+			}
+		default:
+			// The requester's hash is kept for files the server does not host
+			if r.Parameters["hash"] != "vendorhash" || r.Parameters["signature"] != "" {
+				t.Errorf("unexpected parameters for third-party file: %v", r.Parameters)
+			}
+		}
+	}
+
+	if err = commands.Validate(commands.DownloadExecute, map[string]string{"agent_id": "agentA", "url": "x", "hash_alg": "md5"}); err == nil {
+		t.Error("expected an unsupported hash_alg to be rejected")
+	}
+}
@@ -0,0 +1,43 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+)
+
+// @Summary Prune the database
+// @Description Removes agents, requests, events, and login audit records older than their retention periods, as is done every prune_interval hours, then compacts the database if at least compact_threshold percent of it is free space.
+// @Tags Server management
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} schema.APIPruneResponse
+// @Failure 401 {object} schema.API401
+// @Router /admin/prune [post]
+func (a *API) postPrune(req *http.Request) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+
+	summary := a.data.PruneDB()
+
+	a.logger.Info(2809, "database pruned on request", fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("requests_completed", summary.RequestsCompleted),
+		fields.NewField("requests_failed", summary.RequestsFailed)))
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIPruneResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "database pruned",
+			Data:    summary}}
+}
@@ -0,0 +1,111 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/queue"
+)
+
+// restart closes the queue and database without processing the queue, as if the server was
+// killed, and opens them again
+func restart(t *testing.T, a *API) {
+	t.Helper()
+
+	queue.Close()
+	a.data.Close()
+
+	d, err := data.New(a.conf, null.Logger())
+	if err != nil {
+		t.Fatalf("failed to reopen data instance: %v", err)
+	}
+	t.Cleanup(d.Close)
+	a.data = d
+
+	queue.Init(10)
+	a.openQueue()
+}
+
+func TestMessageQueueSurvivesRestart(t *testing.T) {
+	const agentID = "A-0f8fad5b-d9cb-469f-a165-70867728950e"
+
+	a := newTestAPI(t)
+	queue.Init(10)
+	t.Cleanup(queue.Close)
+	a.openQueue()
+
+	sent := time.Now().Add(-time.Minute)
+	for x := 0; x < 3; x++ {
+		err := queue.Add(schema.AgentMessage{AgentID: agentID, Sent: sent, MessageType: schema.AgentEventMessage, Message: fmt.Sprintf("message %d", x)})
+		if err != nil {
+			t.Fatalf("failed to queue message: %v", err)
+		}
+	}
+
+	// Process the first message, then stop while the second is being processed
+	item, _ := queue.Read()
+	if err := a.data.NewAgentMessage(item.Message); err != nil {
+		t.Fatalf("failed to process message: %v", err)
+	}
+	if err := queue.Done(item); err != nil {
+		t.Fatalf("failed to remove message: %v", err)
+	}
+	if item, _ = queue.Read(); item.Message.Message != "message 1" {
+		t.Fatalf("expected message 1, got %q", item.Message.Message)
+	}
+
+	restart(t, a)
+	if queue.Size() != 2 {
+		t.Fatalf("expected 2 messages after restart, got %d", queue.Size())
+	}
+
+	a.ProcessMessageQueue()
+	if queue.Size() != 0 {
+		t.Errorf("expected an empty queue, got %d", queue.Size())
+	}
+
+	events, err := a.data.GetEvents(agentID, 0, 0, schema.AgentEventMessage)
+	if err != nil {
+		t.Fatalf("failed to get events: %v", err)
+	}
+	found := make(map[string]bool)
+	for _, event := range events {
+		found[event.Event] = true
+	}
+	for x := 0; x < 3; x++ {
+		if !found[fmt.Sprintf("message %d", x)] {
+			t.Errorf("message %d was lost: %+v", x, events)
+		}
+	}
+
+	// Processed messages are not restored
+	restart(t, a)
+	if queue.Size() != 0 {
+		t.Errorf("expected no messages after processing and restart, got %d", queue.Size())
+	}
+}
+
+func TestMessageQueueFull(t *testing.T) {
+	queue.Init(1)
+	t.Cleanup(queue.Close)
+
+	if err := queue.Add(schema.AgentMessage{Message: "first"}); err != nil {
+		t.Fatalf("failed to queue message: %v", err)
+	}
+	if err := queue.Add(schema.AgentMessage{Message: "second"}); !errors.Is(err, queue.ErrFull) {
+		t.Errorf("expected ErrFull, got %v", err)
+	}
+	if queue.Size() != 1 {
+		t.Errorf("expected 1 message, got %d", queue.Size())
+	}
+}
@@ -7,6 +7,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/data"
 	"github.com/UnifyEM/UnifyEM/server/global"
 )
 
@@ -135,3 +137,64 @@ func (a *API) getAgentRecovery(req *http.Request) userver.JResponse {
 			Code:         http.StatusOK,
 			RecoveryInfo: info}}
 }
+
+// @Summary Get agent FDE recovery key
+// @Description Retrieve the escrowed BitLocker or FileVault recovery key for an agent. Every retrieval is recorded as an alert event.
+// @Tags Recovery
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Agent ID"
+// @Success 200 {object} schema.APIFDERecoveryKeyResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 403 {object} schema.API403
+// @Failure 404 {object} schema.API404
+// @Failure 500 {object} schema.API500
+// @Router /agent/{id}/recovery-key [get]
+func (a *API) getAgentFDERecoveryKey(req *http.Request) userver.JResponse {
+
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	agentID := userver.GetParam(req, "id")
+	if agentID == "" {
+		a.logger.Error(2958, "no agent ID specified", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "agent ID required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	logFields.Append(fields.NewField("agentID", agentID))
+
+	record, key, err := a.data.GetFDERecoveryKey(agentID, authDetails.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrNoFDERecoveryKey) {
+			a.logger.Info(2959, "no FDE recovery key escrowed for agent", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusNotFound,
+				JSONData: schema.API404{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusNotFound}}
+		}
+
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Error(2960, "error retrieving FDE recovery key", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error retrieving recovery key", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	logFields.Append(fields.NewField("type", record.Type), fields.NewField("request_id", record.RequestID))
+	a.logger.Info(2961, "FDE recovery key retrieved", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIFDERecoveryKeyResponse{
+			Status:    schema.APIStatusOK,
+			Code:      http.StatusOK,
+			AgentID:   agentID,
+			RequestID: record.RequestID,
+			Escrowed:  record.Escrowed,
+			Data:      key}}
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
@@ -32,14 +33,14 @@ func (a *API) postRefresh(req *http.Request) userver.JResponse {
 	// Get the JSON post data
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		return failureResponse
+		return failureResponse()
 	}
 
 	// Deserialize the JSON
 	var loginRequest schema.RefreshRequest
 	err = json.Unmarshal(body, &loginRequest)
 	if err != nil {
-		return failureResponse
+		return failureResponse()
 	}
 
 	// Information to be logged as fields
@@ -50,7 +51,7 @@ func (a *API) postRefresh(req *http.Request) userver.JResponse {
 	if err != nil {
 		logInfo.Append(fields.NewField("refresh-result", "failed"), fields.NewField("error", err.Error()))
 		a.logger.Error(2865, "access token refresh failed", logInfo)
-		return failureResponse
+		return failureResponse()
 	}
 
 	logInfo.Append(fields.NewField("refresh-result", "success"))
@@ -63,5 +64,6 @@ func (a *API) postRefresh(req *http.Request) userver.JResponse {
 			Code:            http.StatusOK,
 			AccessToken:     tokenData.AccessToken,
 			ServerPublicSig: tokenData.ServerPublicSig,
-			ServerPublicEnc: tokenData.ServerPublicEnc}}
+			ServerPublicEnc: tokenData.ServerPublicEnc,
+			ServerTime:      time.Now().Unix()}}
 }
@@ -36,7 +36,7 @@ func (a *API) postRegister(req *http.Request) userver.JResponse {
 	if err != nil {
 		a.logger.Error(2811, fmt.Sprintf("failed reading body: %s", err.Error()),
 			fields.NewFields(fields.NewField("src_ip", remoteIP)))
-		return failureResponse
+		return failureResponse()
 	}
 
 	// Deserialize the JSON
@@ -45,7 +45,7 @@ func (a *API) postRegister(req *http.Request) userver.JResponse {
 	if err != nil {
 		a.logger.Error(2812, fmt.Sprintf("deserialization error: %s", err.Error()),
 			fields.NewFields(fields.NewField("src_ip", remoteIP)))
-		return failureResponse
+		return failureResponse()
 	}
 
 	// Information to be logged as fields
@@ -57,7 +57,7 @@ func (a *API) postRegister(req *http.Request) userver.JResponse {
 	// Check for missing required fields
 	if regRequest.Token == "" || regRequest.Version == "" || regRequest.Build < 1 {
 		a.logger.Error(2813, "registration request missing required fields", logInfo)
-		return failureResponse
+		return failureResponse()
 	}
 
 	// Attempt registration, this function will verify the registration token
@@ -65,10 +65,10 @@ func (a *API) postRegister(req *http.Request) userver.JResponse {
 	regInfo, err := a.data.Register(regRequest, remoteIP)
 	if err != nil {
 		a.logger.Error(2814, "registration failed: "+err.Error(), logInfo)
-		return failureResponse
+		return failureResponse()
 	}
 
-	logInfo.Append(fields.NewField("id", regInfo.AgentID))
+	logInfo.Append(fields.NewField("id", regInfo.AgentID), fields.NewField("migrated", regInfo.Migrated))
 	a.logger.Info(2815, "registered", logInfo)
 
 	return userver.JResponse{
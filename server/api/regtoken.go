@@ -7,8 +7,11 @@ package api
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
@@ -58,9 +61,13 @@ func (a *API) getRegToken(req *http.Request) userver.JResponse {
 		externalURL = externalURL[:len(externalURL)-1]
 	}
 
-	// Generate new base64-encoded format: {"s":"server","t":"token"}
-	tokenData := fmt.Sprintf(`{"s":"%s","t":"%s"}`, externalURL, regToken)
-	rToken := base64.StdEncoding.EncodeToString([]byte(tokenData))
+	rToken, err := a.encodeRegToken(externalURL, regToken)
+	if err != nil {
+		a.logger.Error(2860, err.Error(), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
 	a.logger.Info(2850, "get regkey", logFields)
 
 	return userver.JResponse{
@@ -117,12 +124,139 @@ func (a *API) postRegToken(req *http.Request) userver.JResponse {
 		externalURL = externalURL[:len(externalURL)-1]
 	}
 
-	// Generate new base64-encoded format: {"s":"server","t":"token"}
-	tokenData := fmt.Sprintf(`{"s":"%s","t":"%s"}`, externalURL, regToken)
-	rToken := base64.StdEncoding.EncodeToString([]byte(tokenData))
+	rToken, err := a.encodeRegToken(externalURL, regToken)
+	if err != nil {
+		a.logger.Error(2864, err.Error(), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
 	a.logger.Info(2859, "generate new registration key", logFields)
 
 	return userver.JResponse{
 		HTTPCode: http.StatusOK,
 		JSONData: schema.APIGenericResponse{Details: rToken, Status: schema.APIStatusOK, Code: http.StatusOK}}
 }
+
+// @Summary Retrieve migration token
+// @Description Retrieves the current migration token. Agents moving from another server that register with it keep their agent ID.
+// @Tags "Registration token"
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} schema.APIMigrationTokenResponse
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Failure 500 {object} schema.API500
+// @Router /regtoken/migration [get]
+// getMigrationToken returns the current migration token, if it has not expired
+func (a *API) getMigrationToken(req *http.Request) userver.JResponse {
+	logFields := a.migrationLogFields(req)
+
+	token := a.conf.SP.Get(global.ConfigMigrationToken).String()
+	expires := a.conf.SP.Get(global.ConfigMigrationTokenExpires).Int64()
+	if token == "" || time.Now().Unix() >= expires {
+		a.logger.Info(2874, "no current migration token", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "no current migration token, create one with POST", Status: schema.APIStatusError, Code: http.StatusNotFound}}
+	}
+
+	return a.migrationTokenResponse(token, expires, "current migration token", logFields)
+}
+
+// @Summary Create migration token
+// @Description Creates a new migration token, valid for migration_token_days. Agents moving from another server that register with it keep their agent ID.
+// @Tags "Registration token"
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} schema.APIMigrationTokenResponse
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /regtoken/migration [post]
+// postMigrationToken creates and returns a new migration token
+func (a *API) postMigrationToken(req *http.Request) userver.JResponse {
+	logFields := a.migrationLogFields(req)
+
+	token, err := global.GenerateToken()
+	if err != nil {
+		a.logger.Error(2875, "error generating migration token", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error generating migration token", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+	expires := time.Now().AddDate(0, 0, a.conf.SC.Get(global.ConfigMigrationTokenDays).Int()).Unix()
+
+	a.conf.SP.Set(global.ConfigMigrationToken, token)
+	a.conf.SP.Set(global.ConfigMigrationTokenExpires, expires)
+	err = a.conf.Checkpoint()
+	if err != nil {
+		a.logger.Error(2876, "error saving configuration", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error saving configuration", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	logFields.Append(fields.NewField("expires", time.Unix(expires, 0).UTC().Format(time.RFC3339)))
+	a.logger.Info(2877, "migration token created", logFields)
+	return a.migrationTokenResponse(token, expires, "migration token created", logFields)
+}
+
+// migrationLogFields returns the fields logged by the migration token handlers
+func (a *API) migrationLogFields(req *http.Request) *fields.Fields {
+	authDetails := GetAuthDetails(req)
+	return fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+}
+
+// migrationTokenResponse encodes a migration token like a registration token and returns it
+func (a *API) migrationTokenResponse(token string, expires int64, details string, logFields *fields.Fields) userver.JResponse {
+	externalURL := strings.TrimSuffix(a.conf.SC.Get(global.ConfigExternalULR).String(), "/")
+	if externalURL == "" {
+		a.logger.Error(2878, "error retrieving external URL", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error retrieving external URL", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	encoded, err := a.encodeRegToken(externalURL, token)
+	if err != nil {
+		a.logger.Error(2879, err.Error(), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIMigrationTokenResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: details,
+			Data:    schema.MigrationToken{Token: encoded, Expires: expires}}}
+}
+
+// encodeRegToken returns the base64-encoded token {"s":"server","t":"token"}. If a TLS pin
+// is configured, it is included as "p" so that agents pin the server certificate.
+func (a *API) encodeRegToken(externalURL, regToken string) (string, error) {
+	tokenData := struct {
+		S string `json:"s"`
+		T string `json:"t"`
+		P string `json:"p,omitempty"`
+	}{S: externalURL, T: regToken}
+
+	pin := a.conf.SC.Get(global.ConfigTLSPin).String()
+	if pin != "" {
+		if !schema.ValidTLSPin(pin) {
+			return "", fmt.Errorf("%s is not a valid certificate pin", global.ConfigTLSPin)
+		}
+		tokenData.P = pin
+	}
+
+	data, err := json.Marshal(tokenData)
+	if err != nil {
+		return "", fmt.Errorf("error encoding registration token: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
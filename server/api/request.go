@@ -6,12 +6,16 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
 )
 
 // @Summary Retrieve request status information
@@ -20,6 +24,7 @@ import (
 // @Security BearerAuth
 // @Produce json
 // @Param id path string true "Request ID"
+// @Param status query string false "Only return requests with this status, such as expired"
 // @Success 200 {object} schema.APIRequestStatusResponse
 // @Failure 400 {object} schema.API400
 // @Failure 401 {object} schema.API401
@@ -63,12 +68,11 @@ func (a *API) getRequest(req *http.Request) userver.JResponse {
 		}
 	}
 
-	// Mask sensitive parameters before returning via API
-	for i := range requests.Requests {
-		if _, exists := requests.Requests[i].Parameters["password"]; exists {
-			requests.Requests[i].Parameters["password"] = "********"
-		}
-	}
+	// Optionally filter by status, for example to list expired requests
+	requests = filterRequests(requests, req.URL.Query().Get("status"))
+
+	// Mask sensitive parameters and decrypt responses before returning via API
+	a.prepareRequests(&requests, authDetails.Role, logFields)
 
 	return userver.JResponse{
 		HTTPCode: http.StatusOK,
@@ -178,12 +182,159 @@ func (a *API) cancelRequest(req *http.Request) userver.JResponse {
 			Details: "request cancelled"}}
 }
 
+// @Summary Requeue request
+// @Description Returns an expired request to the queue so that it is sent to the agent again
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Request ID"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Router /request/{id}/requeue [post]
+func (a *API) requeueRequest(req *http.Request) userver.JResponse {
+
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	// Extract the request ID from the URL
+	requestID := userver.GetParam(req, "id")
+	if requestID == "" {
+		a.logger.Error(2939, "no request specified", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "request ID required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	// Add request ID to log fields
+	logFields.Append(fields.NewField("requestID", requestID))
+
+	err := a.data.RequeueAgentRequest(requestID)
+	if errors.Is(err, data.ErrRequestNotExpired) {
+		a.logger.Info(2940, "requeue rejected, request has not expired", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+	if err != nil {
+		a.logger.Error(2941, fmt.Sprintf("error requeuing agent request: %s", err.Error()), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "request not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
+	}
+
+	a.logger.Info(2942, "agent request requeued", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGenericResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "request requeued"}}
+}
+
+// @Summary Approve request
+// @Description Approves a request awaiting approval so that it is sent to the agent. The request must be approved by a different administrator than the one who created it.
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Request ID"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 403 {object} schema.API403
+// @Failure 404 {object} schema.API404
+// @Router /request/{id}/approve [post]
+func (a *API) approveRequest(req *http.Request) userver.JResponse {
+	return a.reviewRequest(req, true)
+}
+
+// @Summary Deny request
+// @Description Denies a request awaiting approval so that it is never sent to the agent. The request must be denied by a different administrator than the one who created it.
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Request ID"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 403 {object} schema.API403
+// @Failure 404 {object} schema.API404
+// @Router /request/{id}/deny [post]
+func (a *API) denyRequest(req *http.Request) userver.JResponse {
+	return a.reviewRequest(req, false)
+}
+
+// reviewRequest approves or denies a request awaiting approval
+func (a *API) reviewRequest(req *http.Request, approve bool) userver.JResponse {
+
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	// Extract the request ID from the URL
+	requestID := userver.GetParam(req, "id")
+	if requestID == "" {
+		a.logger.Error(2818, "no request specified", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "request ID required", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	// Add request ID to log fields
+	logFields.Append(fields.NewField("requestID", requestID))
+
+	var err error
+	action := "approved"
+	if approve {
+		err = a.data.ApproveAgentRequest(requestID, authDetails.ID)
+	} else {
+		action = "denied"
+		err = a.data.DenyAgentRequest(requestID, authDetails.ID)
+	}
+
+	if errors.Is(err, data.ErrSelfApproval) {
+		a.logger.Warning(2819, "review rejected, same administrator as the requester", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusForbidden,
+			JSONData: schema.API403{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusForbidden}}
+	}
+	if errors.Is(err, data.ErrNotAwaitingApproval) {
+		a.logger.Info(2820, "review rejected, request is not awaiting approval", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+	if err != nil {
+		a.logger.Error(2821, fmt.Sprintf("error reviewing agent request: %s", err.Error()), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "request not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
+	}
+
+	a.logger.Info(2827, "agent request "+action, logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGenericResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "request " + action}}
+}
+
 // @Summary Retrieve all requests for an agent
 // @Description Returns all request records for a given agent ID
 // @Tags Agent management
 // @Security BearerAuth
 // @Produce json
 // @Param id path string true "Agent ID"
+// @Param status query string false "Only return requests with this status, such as expired"
 // @Success 200 {object} schema.APIRequestStatusResponse
 // @Failure 400 {object} schema.API400
 // @Failure 401 {object} schema.API401
@@ -220,12 +371,11 @@ func (a *API) getAgentRequests(req *http.Request) userver.JResponse {
 			JSONData: schema.API500{Details: "error retrieving agent requests", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
 	}
 
-	// Mask sensitive parameters before returning via API
-	for i := range requests.Requests {
-		if _, exists := requests.Requests[i].Parameters["password"]; exists {
-			requests.Requests[i].Parameters["password"] = "********"
-		}
-	}
+	// Optionally filter by status
+	requests = filterRequests(requests, req.URL.Query().Get("status"))
+
+	// Mask sensitive parameters and decrypt responses before returning via API
+	a.prepareRequests(&requests, authDetails.Role, logFields)
 
 	a.logger.Info(2857, "agent requests retrieved", logFields)
 	return userver.JResponse{
@@ -285,3 +435,38 @@ func (a *API) cancelAgentRequests(req *http.Request) userver.JResponse {
 			Code:    http.StatusOK,
 			Details: "agent requests cancelled"}}
 }
+
+// filterRequests returns the requests with the specified status, or all requests if status is empty
+func filterRequests(requests schema.AgentRequestRecordList, status string) schema.AgentRequestRecordList {
+	if status == "" {
+		return requests
+	}
+
+	filtered := schema.AgentRequestRecordList{Requests: []schema.AgentRequestRecord{}}
+	for _, request := range requests.Requests {
+		if strings.EqualFold(request.Status, status) {
+			filtered.Requests = append(filtered.Requests, request)
+		}
+	}
+	return filtered
+}
+
+// prepareRequests masks sensitive parameters and decrypts responses to sensitive commands if the
+// role is listed in the sensitive_response_roles setting. Other roles only see that the response
+// is encrypted. Encrypted responses are never returned.
+func (a *API) prepareRequests(requests *schema.AgentRequestRecordList, role int, logFields *fields.Fields) {
+	allowed := a.AuthRoleListed(global.ConfigResponseRoles, role)
+
+	for i := range requests.Requests {
+		request := &requests.Requests[i]
+		schema.RedactParameters(request.Parameters)
+
+		if allowed && request.EncryptedResponse != "" {
+			err := a.data.DecryptResponse(request)
+			if err != nil {
+				a.logger.Error(2971, fmt.Sprintf("error decrypting response to %s: %s", request.RequestID, err.Error()), logFields)
+			}
+		}
+		request.EncryptedResponse = ""
+	}
+}
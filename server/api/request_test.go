@@ -0,0 +1,446 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/db"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestRequestExpiry(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigRequestRetries, 1)
+	a.conf.SC.Set(global.ConfigRequestRetryDelay, 0)
+	requestID := pendingFetch(t, a, "agentA")
+
+	// A pending request can't be requeued
+	if err := a.data.RequeueAgentRequest(requestID); !errors.Is(err, data.ErrRequestNotExpired) {
+		t.Errorf("requeue pending request: expected ErrRequestNotExpired, got %v", err)
+	}
+
+	a.data.ExpireAgentRequests()
+
+	requests, err := a.data.GetRequestRecords()
+	if err != nil {
+		t.Fatalf("GetRequestRecords failed: %v", err)
+	}
+	expired := filterRequests(requests, schema.RequestStatusExpired)
+	if len(expired.Requests) != 1 || expired.Requests[0].RequestID != requestID {
+		t.Fatalf("expected the request to be expired, got %+v", requests.Requests)
+	}
+
+	events, err := a.data.GetEvents("agentA", 0, 0, schema.AgentEventAlert)
+	if err != nil || len(events) != 1 {
+		t.Errorf("expected one expiry event, got %d (%v)", len(events), err)
+	}
+
+	// An expired request is not sent again until it is requeued
+	sent, _ := a.data.GetAgentRequests("agentA", true)
+	if len(sent) != 0 {
+		t.Errorf("expired request was sent")
+	}
+
+	if err = a.data.RequeueAgentRequest(requestID); err != nil {
+		t.Fatalf("requeue failed: %v", err)
+	}
+	sent, _ = a.data.GetAgentRequests("agentA", true)
+	if len(sent) != 1 || sent[0].RequestID != requestID {
+		t.Errorf("requeued request was not sent")
+	}
+}
+
+func TestRequestLifecycle(t *testing.T) {
+	a := newTestAPI(t)
+	requestID := pendingFetch(t, a, "agentA")
+
+	records, err := a.data.GetRequestRecord(requestID)
+	if err != nil {
+		t.Fatalf("GetRequestRecord failed: %v", err)
+	}
+	request := records.Requests[0]
+	if request.TimeSent.IsZero() || !request.TimeAcknowledged.IsZero() || request.Success != nil {
+		t.Fatalf("unexpected pending request: %+v", request)
+	}
+
+	// A response from another agent must not update the request
+	response := schema.AgentResponse{RequestID: requestID, Cmd: commands.FileFetch, Response: "done", Success: true}
+	a.data.AgentSync(data.SyncData{AgentID: "agentB", Responses: []schema.AgentResponse{response}})
+	records, _ = a.data.GetRequestRecord(requestID)
+	if records.Requests[0].Status != schema.RequestStatusPending {
+		t.Errorf("request updated by another agent: %+v", records.Requests[0])
+	}
+
+	a.data.AgentSync(data.SyncData{AgentID: "agentA", Responses: []schema.AgentResponse{response}})
+	records, _ = a.data.GetRequestRecord(requestID)
+	request = records.Requests[0]
+	if request.Status != schema.RequestStatusComplete || request.ResponseDetails != "done" {
+		t.Errorf("unexpected completed request: %+v", request)
+	}
+	if request.TimeAcknowledged.IsZero() || request.TimeCompleted.Before(request.TimeSent) {
+		t.Errorf("unexpected timestamps: %+v", request)
+	}
+	if request.Success == nil || !*request.Success {
+		t.Errorf("expected success to be recorded")
+	}
+}
+
+func TestSensitiveResponse(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigResponseRoles, "superadmin")
+
+	_, _, privateEnc, publicEnc, err := crypto.GenerateKeyPairs()
+	if err != nil {
+		t.Fatalf("failed to generate keys: %v", err)
+	}
+	a.conf.SP.Set(global.ConfigServerECPrivateEnc, privateEnc)
+	a.conf.SP.Set(global.ConfigServerECPublicEnc, publicEnc)
+
+	if err = a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	if !commands.IsSensitive(commands.UserList) || commands.IsSensitive(commands.Ping) {
+		t.Fatalf("user_list should be sensitive and ping should not")
+	}
+
+	users := map[string]any{"alice": "admin"}
+
+	// Current agents encrypt the response, older agents send it in plaintext
+	plaintext, _ := json.Marshal(schema.ResponseBody{Response: "1 user", Data: users})
+	encrypted, err := crypto.Encrypt(plaintext, publicEnc)
+	if err != nil {
+		t.Fatalf("failed to encrypt response: %v", err)
+	}
+
+	for _, legacy := range []bool{false, true} {
+		requestID, err := a.data.AddAgentRequest(schema.AgentRequest{
+			AgentID:     "agentA",
+			Request:     commands.UserList,
+			AckRequired: true,
+			Parameters:  map[string]string{"agent_id": "agentA"}})
+		if err != nil {
+			t.Fatalf("failed to add request: %v", err)
+		}
+
+		response := schema.NewAgentResponse()
+		response.Cmd = commands.UserList
+		response.RequestID = requestID
+		response.Success = true
+		if legacy {
+			response.Response = "1 user"
+			response.Data = users
+		} else {
+			response.Response = schema.ResponseEncryptedDetails
+			response.EncryptedResponse = encrypted
+		}
+		a.data.AgentSync(data.SyncData{AgentID: "agentA", Responses: []schema.AgentResponse{response}})
+
+		// Only ciphertext is stored
+		records, err := a.data.GetRequestRecord(requestID)
+		if err != nil {
+			t.Fatalf("failed to get request: %v", err)
+		}
+		stored := records.Requests[0]
+		raw, _ := json.Marshal(stored)
+		if !stored.ResponseEncrypted || stored.EncryptedResponse == "" ||
+			stored.ResponseDetails != schema.ResponseEncryptedDetails || strings.Contains(string(raw), "alice") {
+			t.Fatalf("legacy=%v: response not stored encrypted: %+v", legacy, stored)
+		}
+
+		// Roles that are not permitted only see that the response is encrypted
+		admin := records
+		admin.Requests = []schema.AgentRequestRecord{stored}
+		a.prepareRequests(&admin, schema.RoleAdmin, fields.NewFields())
+		if admin.Requests[0].EncryptedResponse != "" || admin.Requests[0].ResponseDetails != schema.ResponseEncryptedDetails {
+			t.Errorf("legacy=%v: admin should not see the response: %+v", legacy, admin.Requests[0])
+		}
+
+		super := records
+		super.Requests = []schema.AgentRequestRecord{stored}
+		a.prepareRequests(&super, schema.RoleSuperAdmin, fields.NewFields())
+		got := super.Requests[0]
+		if got.EncryptedResponse != "" || got.ResponseDetails != "1 user" || got.ResponseData.(map[string]any)["alice"] != "admin" {
+			t.Errorf("legacy=%v: superadmin should see the decrypted response: %+v", legacy, got)
+		}
+	}
+}
+
+func TestPingLatency(t *testing.T) {
+	a := newTestAPI(t)
+	if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: "agentA", Active: true}); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	requestID, err := a.data.AddAgentRequest(schema.AgentRequest{AgentID: "agentA", Request: commands.Ping, AckRequired: true})
+	if err != nil {
+		t.Fatalf("failed to add request: %v", err)
+	}
+	if _, err = a.data.GetAgentRequests("agentA", true); err != nil {
+		t.Fatalf("failed to mark request sent: %v", err)
+	}
+
+	// The data is sent as JSON, so decode it as the API handler would
+	received := time.Now().Add(-time.Hour)
+	j, _ := json.Marshal(schema.PingData{Received: received, Responded: received.Add(25 * time.Millisecond), ElapsedMs: 25})
+	var ping any
+	if err = json.Unmarshal(j, &ping); err != nil {
+		t.Fatal(err)
+	}
+
+	response := schema.NewAgentResponse()
+	response.Cmd = commands.Ping
+	response.RequestID = requestID
+	response.Response = "pong"
+	response.Success = true
+	response.Data = ping
+	a.data.AgentSync(data.SyncData{AgentID: "agentA", Responses: []schema.AgentResponse{response}})
+
+	records, err := a.data.GetRequestRecord(requestID)
+	if err != nil || len(records.Requests) != 1 {
+		t.Fatalf("failed to get request: %v", err)
+	}
+	request := records.Requests[0]
+	latency := request.Latency
+	if latency == nil {
+		t.Fatalf("latency was not recorded: %+v", request)
+	}
+
+	// The agent's clock is an hour behind, which must not affect the server's measurements
+	if latency.AgentMs != 25 || !latency.AgentReceived.Equal(received) {
+		t.Errorf("agent times not recorded: %+v", latency)
+	}
+	if latency.QueueMs < 0 || latency.RoundTripMs < latency.QueueMs ||
+		latency.RoundTripMs != request.TimeCompleted.Sub(request.TimeCreated).Milliseconds() {
+		t.Errorf("unexpected server measurements: %+v", latency)
+	}
+}
+
+func TestRequestIndexMigration(t *testing.T) {
+	a := newTestAPI(t)
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := a.data.AddAgentRequest(schema.AgentRequest{AgentID: "agentA", Request: commands.Ping, Parameters: map[string]string{}}); err != nil {
+			t.Fatalf("failed to add request: %v", err)
+		}
+	}
+
+	// Remove the index and schema version, as in a database created by an earlier version
+	a.data.Close()
+	dbFile, err := data.DatabaseFile(a.conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bdb, err := bbolt.Open(dbFile, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bdb.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(db.BucketRequestIndex)); err != nil {
+			return err
+		}
+		return tx.DeleteBucket([]byte(db.BucketSchema))
+	})
+	_ = bdb.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := data.New(a.conf, null.Logger())
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer d.Close()
+
+	records, err := d.GetAgentRequestRecords("agentA")
+	if err != nil || len(records.Requests) != 2 {
+		t.Errorf("expected 2 requests after migration, got %d (%v)", len(records.Requests), err)
+	}
+}
+
+// BenchmarkSyncRequests measures getting the requests for an agent when it syncs, with 100,000
+// historical requests for 1,000 agents in the database
+func BenchmarkSyncRequests(b *testing.B) {
+	a := newTestAPI(b)
+	a.conf.SC.Set(global.ConfigRequestRetries, 3)
+
+	agentIDs := make([]string, 1000)
+	for i := range agentIDs {
+		agentIDs[i] = fmt.Sprintf("agent%04d", i)
+		if err := a.data.SetAgentMeta(schema.NewAgentMeta(agentIDs[i])); err != nil {
+			b.Fatalf("failed to create agent: %v", err)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		_, _, err := a.data.AddAgentRequests(schema.AgentRequest{Request: commands.Ping, Parameters: map[string]string{}}, agentIDs)
+		if err != nil {
+			b.Fatalf("failed to add requests: %v", err)
+		}
+	}
+
+	// Complete the agent's requests so that each sync only reads them
+	if _, err := a.data.GetAgentRequests(agentIDs[0], true); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.data.GetAgentRequests(agentIDs[0], true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestPruneRequests(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigRequestRetention, 30)
+	a.conf.SC.Set(global.ConfigRequestFailedRetention, 7)
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	// The status of each request, how many days ago it finished, and whether it is pruned
+	cases := []struct {
+		status    string
+		days      int
+		pruned    bool
+		requestID string
+	}{
+		{schema.RequestStatusComplete, 60, true, ""},
+		{schema.RequestStatusComplete, 10, false, ""},
+		{schema.RequestStatusExpired, 10, true, ""},
+		{schema.RequestStatusInvalid, 3, false, ""},
+		{schema.RequestStatusPending, 60, false, ""},
+	}
+	for i := range cases {
+		requestID, err := a.data.AddAgentRequest(schema.AgentRequest{AgentID: "agentA", Request: commands.Ping, Parameters: map[string]string{}})
+		if err != nil {
+			t.Fatalf("failed to add request: %v", err)
+		}
+		cases[i].requestID = requestID
+	}
+
+	// Backdate the requests, which the data layer doesn't allow
+	a.data.Close()
+	dbFile, err := data.DatabaseFile(a.conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bdb, err := bbolt.Open(dbFile, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bdb.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(db.BucketAgentRequests))
+		for _, c := range cases {
+			var request schema.AgentRequestRecord
+			if err := json.Unmarshal(bucket.Get([]byte(c.requestID)), &request); err != nil {
+				return err
+			}
+			request.Status = c.status
+			request.LastUpdated = time.Now().AddDate(0, 0, -c.days)
+			if c.status != schema.RequestStatusPending {
+				request.TimeCompleted = request.LastUpdated
+			}
+			value, err := json.Marshal(request)
+			if err != nil {
+				return err
+			}
+			if err = bucket.Put([]byte(c.requestID), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	_ = bdb.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.data, err = data.New(a.conf, null.Logger()); err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	t.Cleanup(a.data.Close)
+
+	r := a.postPrune(httptest.NewRequest("POST", schema.EndpointPrune, nil))
+	resp, ok := r.JSONData.(schema.APIPruneResponse)
+	if r.HTTPCode != http.StatusOK || !ok {
+		t.Fatalf("expected 200, got %d", r.HTTPCode)
+	}
+	if resp.Data.RequestsCompleted != 1 || resp.Data.RequestsFailed != 1 {
+		t.Errorf("expected 1 completed and 1 failed request pruned, got %+v", resp.Data)
+	}
+	if resp.Data.SizeBefore == 0 || resp.Data.SizeAfter == 0 {
+		t.Errorf("database size not reported: %+v", resp.Data)
+	}
+
+	for _, c := range cases {
+		if exists, _ := a.data.GetRequestRecord(c.requestID); (len(exists.Requests) == 0) != c.pruned {
+			t.Errorf("%s request finished %d days ago: expected pruned %v", c.status, c.days, c.pruned)
+		}
+	}
+}
+
+func TestRequestQueues(t *testing.T) {
+	a := newTestAPI(t)
+	for _, agentID := range []string{"agentA", "agentB"} {
+		if err := a.data.SetAgentMeta(schema.NewAgentMeta(agentID)); err != nil {
+			t.Fatalf("failed to create agent: %v", err)
+		}
+	}
+
+	ping := schema.AgentRequest{AgentID: "agentA", Request: commands.Ping, AckRequired: true, Parameters: map[string]string{"agent_id": "agentA"}}
+	first, _ := a.data.AddAgentRequest(ping)
+	if _, err := a.data.GetAgentRequests("agentA", true); err != nil {
+		t.Fatalf("failed to mark requests sent: %v", err)
+	}
+	_, _ = a.data.AddAgentRequest(ping)
+	cancelled, _ := a.data.AddAgentRequest(ping)
+	if err := a.data.CancelAgentRequest(cancelled); err != nil {
+		t.Fatalf("failed to cancel request: %v", err)
+	}
+
+	agents, err := a.data.GetAllAgentMeta()
+	if err != nil {
+		t.Fatalf("GetAllAgentMeta failed: %v", err)
+	}
+	if err = a.data.AddRequestQueues(&agents); err != nil {
+		t.Fatalf("AddRequestQueues failed: %v", err)
+	}
+
+	records, _ := a.data.GetRequestRecord(first)
+	for _, agent := range agents.Agents {
+		switch agent.AgentID {
+		case "agentA":
+			if agent.Queue.New != 1 || agent.Queue.Pending != 1 || !agent.Queue.Oldest.Equal(records.Requests[0].TimeCreated) {
+				t.Errorf("unexpected queue for agentA: %+v", agent.Queue)
+			}
+		case "agentB":
+			if agent.Queue == nil || agent.Queue.Count() != 0 {
+				t.Errorf("expected an empty queue for agentB, got %+v", agent.Queue)
+			}
+		}
+	}
+}
@@ -0,0 +1,445 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/notify"
+	"github.com/UnifyEM/UnifyEM/server/reports"
+)
+
+// Saved reports are run on demand or when their schedule is due. Each run writes a copy to the
+// reports directory and emails the report to its recipients in the background. Email delivery
+// is retried, and if it fails, the failure is recorded as a server event.
+
+const (
+	reportEmailAttempts = 5 // Delivery attempts before a report email is abandoned
+
+	// requesterScheduler identifies scheduled runs in logs and events
+	requesterScheduler = "scheduler"
+)
+
+// reportEmailBackoff is the delay before the first retry, doubled for each subsequent retry
+var reportEmailBackoff = time.Minute
+
+// @Summary List saved reports
+// @Description Returns all saved report definitions and the result of their last run
+// @Tags Reporting
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} schema.APISavedReportsResponse
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /report/saved [get]
+func (a *API) getSavedReports(_ *http.Request) userver.JResponse {
+	saved, err := a.data.GetSavedReports()
+	if err != nil {
+		a.logger.Error(2756, "error retrieving saved reports", fields.NewFields(fields.NewField("error", err.Error())))
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error retrieving saved reports", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APISavedReportsResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Reports: saved}}
+}
+
+// @Summary Get saved report
+// @Description Returns a saved report definition and the result of its last run
+// @Tags Reporting
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Saved report name"
+// @Success 200 {object} schema.APISavedReportsResponse
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Router /report/saved/{name} [get]
+func (a *API) getSavedReport(req *http.Request) userver.JResponse {
+	saved, err := a.data.GetSavedReport(userver.GetParam(req, "name"))
+	if err != nil {
+		return savedReportError(err)
+	}
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APISavedReportsResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Reports: []schema.SavedReport{saved}}}
+}
+
+// @Summary Create saved report
+// @Description Saves a report definition. If a schedule is given, the report is run when it is due.
+// @Tags Reporting
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param report body schema.SavedReportRequest true "Saved report"
+// @Success 200 {object} schema.APISavedReportsResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 500 {object} schema.API500
+// @Router /report/saved [post]
+func (a *API) postSavedReport(req *http.Request) userver.JResponse {
+	return a.saveSavedReport(req, false)
+}
+
+// @Summary Replace saved report
+// @Description Replaces a saved report definition, keeping the result of its last run
+// @Tags Reporting
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param name path string true "Saved report name"
+// @Param report body schema.SavedReportRequest true "Saved report"
+// @Success 200 {object} schema.APISavedReportsResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Router /report/saved/{name} [put]
+func (a *API) putSavedReport(req *http.Request) userver.JResponse {
+	return a.saveSavedReport(req, true)
+}
+
+// saveSavedReport creates or replaces a saved report
+func (a *API) saveSavedReport(req *http.Request, replace bool) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role))
+
+	var savedReq schema.SavedReportRequest
+	if errResp := readJSON(req, &savedReq); errResp != nil {
+		return *errResp
+	}
+	if replace {
+		savedReq.Name = userver.GetParam(req, "name")
+	}
+	logFields.Append(
+		fields.NewField("name", savedReq.Name),
+		fields.NewField("report", savedReq.Report),
+		fields.NewField("schedule", savedReq.Schedule),
+		fields.NewField("replace", replace))
+
+	saved, err := newSavedReport(savedReq, authDetails.ID, time.Now())
+	if err == nil {
+		if replace {
+			saved, err = a.data.ReplaceSavedReport(saved)
+		} else {
+			saved, err = a.data.CreateSavedReport(saved)
+		}
+	}
+	if err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Warning(2757, "error saving report definition", logFields)
+		return savedReportError(err)
+	}
+
+	a.logger.Info(2758, "report definition saved", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APISavedReportsResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "report saved",
+			Reports: []schema.SavedReport{saved}}}
+}
+
+// @Summary Delete saved report
+// @Description Deletes a saved report definition. Copies in the reports directory are kept.
+// @Tags Reporting
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Saved report name"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Router /report/saved/{name} [delete]
+func (a *API) deleteSavedReport(req *http.Request) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+	name := userver.GetParam(req, "name")
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", userver.RemoteIP(req)),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("name", name))
+
+	if err := a.data.DeleteSavedReport(name); err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Warning(2759, "error deleting saved report", logFields)
+		return savedReportError(err)
+	}
+
+	a.logger.Info(2760, "saved report deleted", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGenericResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "saved report deleted"}}
+}
+
+// @Summary Run saved report
+// @Description Runs a saved report now and writes a copy to the reports directory. Email is delivered in the background.
+// @Tags Reporting
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Saved report name"
+// @Success 200 {object} schema.APISavedReportRunResponse
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Failure 500 {object} schema.API500
+// @Router /report/saved/{name}/run [post]
+func (a *API) postSavedReportRun(req *http.Request) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+
+	saved, err := a.data.GetSavedReport(userver.GetParam(req, "name"))
+	if err != nil {
+		return savedReportError(err)
+	}
+
+	file, err := a.runSavedReport(saved, authDetails.ID)
+	if err != nil {
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+
+	details := "report generated"
+	if len(saved.Email) > 0 {
+		details = "report generated, email delivery started"
+	}
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APISavedReportRunResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: details,
+			File:    file}}
+}
+
+// RunScheduledReports runs the saved reports that are due. It is called periodically from
+// tasks in main.go. A report that was due while the server was stopped is run once.
+func (a *API) RunScheduledReports() {
+	saved, err := a.data.GetSavedReports()
+	if err != nil {
+		a.logger.Errorf(2761, "error retrieving saved reports: %s", err.Error())
+		return
+	}
+
+	now := time.Now()
+	for _, s := range saved {
+		if s.Schedule == "" || s.NextRun.IsZero() || s.NextRun.After(now) {
+			continue
+		}
+
+		schedule, err := reports.ParseSchedule(s.Schedule)
+		if err != nil {
+			a.logger.Errorf(2762, "invalid schedule for saved report %s: %s", s.Name, err.Error())
+			continue
+		}
+
+		// Schedule the next run first so that a report that fails is not run on every tick
+		if err = a.data.ScheduleSavedReport(s.Name, schedule.Next(now)); err != nil {
+			a.logger.Errorf(2763, "error scheduling saved report %s: %s", s.Name, err.Error())
+			continue
+		}
+
+		go func() {
+			_, _ = a.runSavedReport(s, requesterScheduler)
+		}()
+	}
+}
+
+// runSavedReport generates a saved report, writes a copy to the reports directory, and starts
+// email delivery. It returns the name of the copy.
+func (a *API) runSavedReport(saved schema.SavedReport, requester string) (string, error) {
+	now := time.Now()
+	logFields := fields.NewFields(
+		fields.NewField("name", saved.Name),
+		fields.NewField("report", saved.Report),
+		fields.NewField("requester", requester))
+
+	content, err := renderSavedReport(a.data, saved, now)
+	var file string
+	if err == nil {
+		file = fmt.Sprintf("%s-%s.%s", saved.Name, now.Format("20060102-150405"), saved.Format)
+		_, err = a.data.SaveReportCopy(file, content)
+	}
+	if err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Error(2764, "saved report failed", logFields)
+		a.savedReportFailed(saved.Name, now, requester, "report failed: "+err.Error())
+		return "", err
+	}
+
+	result := schema.APIStatusOK
+	if len(saved.Email) > 0 {
+		result = "email pending"
+		go a.emailSavedReport(saved, file, content, now, requester)
+	}
+	if err = a.data.SavedReportResult(saved.Name, now, result, file); err != nil {
+		a.logger.Errorf(2765, "error recording result of saved report %s: %s", saved.Name, err.Error())
+	}
+
+	logFields.Append(fields.NewField("file", file))
+	a.logger.Info(2766, "saved report generated", logFields)
+	return file, nil
+}
+
+// emailSavedReport emails a saved report to its recipients, retrying with backoff
+func (a *API) emailSavedReport(saved schema.SavedReport, file string, content []byte, generated time.Time, requester string) {
+	msg := notify.Email{
+		To:      saved.Email,
+		Subject: fmt.Sprintf("%s report: %s", global.Description, saved.Name),
+	}
+	if saved.Format == schema.SavedReportHTML {
+		msg.Body = string(content)
+		msg.HTML = true
+	} else {
+		msg.Body = fmt.Sprintf("The %s report %s generated %s is attached.\r\n", saved.Report, saved.Name, generated.Format(time.RFC1123))
+		msg.Attachments = []notify.Attachment{{Name: file, ContentType: "text/csv", Data: content}}
+	}
+
+	delay := reportEmailBackoff
+	for attempt := 1; ; attempt++ {
+		err := notify.SendEmail(a.conf, msg)
+		if err == nil {
+			break
+		}
+
+		f := fields.NewFields(
+			fields.NewField("name", saved.Name),
+			fields.NewField("attempt", attempt),
+			fields.NewField("error", err.Error()))
+
+		if attempt >= reportEmailAttempts || errors.Is(err, notify.ErrEmailNotConfigured) {
+			a.logger.Error(2767, "saved report email failed", f)
+			a.savedReportFailed(saved.Name, generated, requester, "email failed: "+err.Error())
+			return
+		}
+		a.logger.Warning(2770, "saved report email failed, retrying", f)
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	if err := a.data.SavedReportResult(saved.Name, generated, schema.APIStatusOK, ""); err != nil {
+		a.logger.Errorf(2765, "error recording result of saved report %s: %s", saved.Name, err.Error())
+	}
+	a.logger.Info(2771, "saved report emailed", fields.NewFields(
+		fields.NewField("name", saved.Name),
+		fields.NewField("recipients", len(saved.Email))))
+}
+
+// savedReportFailed records a failure in the saved report and as a server event
+func (a *API) savedReportFailed(name string, run time.Time, requester, result string) {
+	if err := a.data.SavedReportResult(name, run, result, ""); err != nil {
+		a.logger.Errorf(2765, "error recording result of saved report %s: %s", name, err.Error())
+	}
+
+	err := a.data.AddEvent(schema.AgentEvent{
+		AgentID:   schema.ServerEventsID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventAlert,
+		Event:     fmt.Sprintf("saved report %s failed", name),
+		Details:   map[string]string{"saved_report": name, "requester": requester, "error": result}})
+	if err != nil {
+		a.logger.Errorf(2772, "error recording saved report failure event: %s", err.Error())
+	}
+}
+
+// renderSavedReport generates a report and renders it in the saved report's format
+func renderSavedReport(d *data.Data, saved schema.SavedReport, generated time.Time) ([]byte, error) {
+	params := make(map[string]string, len(saved.Parameters)+1)
+	maps.Copy(params, saved.Parameters)
+	params["format"] = schema.ReportTypeJSON
+
+	report, err := reports.Get(d, schema.ReportRequest{Report: saved.Report, Parameters: params})
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := reports.NewTable(report.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if saved.Format == schema.SavedReportHTML {
+		var b bytes.Buffer
+		err = table.HTML(&b, fmt.Sprintf("%s (%s report)", saved.Name, saved.Report), generated)
+		return b.Bytes(), err
+	}
+	return table.CSV()
+}
+
+// newSavedReport checks a saved report request and returns the definition, scheduled to run
+// at the next time after now that matches its schedule
+func newSavedReport(req schema.SavedReportRequest, creator string, now time.Time) (schema.SavedReport, error) {
+	saved := schema.SavedReport{
+		Name:       req.Name,
+		Report:     req.Report,
+		Parameters: req.Parameters,
+		Format:     req.Format,
+		Schedule:   req.Schedule,
+		Email:      req.Email,
+		Creator:    creator,
+	}
+
+	if saved.Format == "" {
+		saved.Format = schema.SavedReportCSV
+	}
+
+	if !reports.Exists(saved.Report) {
+		return saved, fmt.Errorf("%w: report %q does not exist", data.ErrInvalidSavedReport, saved.Report)
+	}
+
+	if saved.Schedule != "" {
+		schedule, err := reports.ParseSchedule(saved.Schedule)
+		if err != nil {
+			return saved, fmt.Errorf("%w: %s", data.ErrInvalidSavedReport, err.Error())
+		}
+		saved.NextRun = schedule.Next(now)
+		if saved.NextRun.IsZero() {
+			return saved, fmt.Errorf("%w: the schedule never runs", data.ErrInvalidSavedReport)
+		}
+	}
+	return saved, nil
+}
+
+// savedReportError returns the response for an error from the saved report functions in the data layer
+func savedReportError(err error) userver.JResponse {
+	switch {
+	case errors.Is(err, data.ErrSavedReportNotFound):
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusNotFound}}
+	case errors.Is(err, data.ErrSavedReportExists), errors.Is(err, data.ErrInvalidSavedReport):
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	default:
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error updating saved report", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+}
@@ -0,0 +1,118 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestSavedReports(t *testing.T) {
+	a := newTestAPI(t)
+	filesPath := t.TempDir()
+	a.conf.SC.Set(global.ConfigFilesPath, filesPath)
+
+	if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: "agentA", FriendlyName: "host1"}); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	now := time.Now()
+	req := schema.SavedReportRequest{Name: "Weekly-Agents", Report: "agents", Schedule: "0 8 * * mon"}
+	saved, err := newSavedReport(req, "admin", now)
+	if err != nil {
+		t.Fatalf("newSavedReport failed: %v", err)
+	}
+	if saved.Format != schema.SavedReportCSV {
+		t.Errorf("expected default format csv, got %s", saved.Format)
+	}
+	if !saved.NextRun.After(now) || saved.NextRun.Weekday() != time.Monday {
+		t.Errorf("unexpected next run: %s", saved.NextRun)
+	}
+	if _, err = a.data.CreateSavedReport(saved); err != nil {
+		t.Fatalf("CreateSavedReport failed: %v", err)
+	}
+
+	// Names are not case-sensitive
+	if _, err = a.data.CreateSavedReport(schema.SavedReport{Name: "weekly-agents", Report: "agents", Format: schema.SavedReportCSV}); !errors.Is(err, data.ErrSavedReportExists) {
+		t.Errorf("duplicate: expected ErrSavedReportExists, got %v", err)
+	}
+
+	invalid := []schema.SavedReportRequest{
+		{Name: "x", Report: "nonexistent"},
+		{Name: "x", Report: "agents", Schedule: "every monday"},
+		{Name: "x", Report: "agents", Schedule: "0 0 31 feb *"},
+	}
+	for _, r := range invalid {
+		if _, err = newSavedReport(r, "admin", now); !errors.Is(err, data.ErrInvalidSavedReport) {
+			t.Errorf("%+v: expected ErrInvalidSavedReport, got %v", r, err)
+		}
+	}
+	for _, s := range []schema.SavedReport{
+		{Name: "../x", Report: "agents", Format: schema.SavedReportCSV},
+		{Name: "x", Report: "agents", Format: "pdf"},
+		{Name: "x", Report: "agents", Format: schema.SavedReportCSV, Email: []string{"Admin <admin@example.com>"}},
+	} {
+		if _, err = a.data.CreateSavedReport(s); !errors.Is(err, data.ErrInvalidSavedReport) {
+			t.Errorf("%+v: expected ErrInvalidSavedReport, got %v", s, err)
+		}
+	}
+
+	// Running the report writes a copy and records the result
+	file, err := a.runSavedReport(saved, "admin")
+	if err != nil {
+		t.Fatalf("runSavedReport failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(filesPath, global.ReportsDir, file))
+	if err != nil {
+		t.Fatalf("report copy not written: %v", err)
+	}
+	if !strings.Contains(string(content), "host1") {
+		t.Errorf("unexpected report content: %s", content)
+	}
+
+	// Replacing keeps the creator and the result of the last run
+	replacement, err := newSavedReport(schema.SavedReportRequest{Name: "Weekly-Agents", Report: "offline", Format: schema.SavedReportHTML}, "other", now)
+	if err != nil {
+		t.Fatalf("newSavedReport failed: %v", err)
+	}
+	if _, err = a.data.ReplaceSavedReport(replacement); err != nil {
+		t.Fatalf("ReplaceSavedReport failed: %v", err)
+	}
+	got, err := a.data.GetSavedReport("WEEKLY-AGENTS")
+	if err != nil {
+		t.Fatalf("GetSavedReport failed: %v", err)
+	}
+	if got.Report != "offline" || got.Creator != "admin" || got.LastFile != file || got.LastResult != schema.APIStatusOK {
+		t.Errorf("unexpected saved report after replace: %+v", got)
+	}
+
+	// An email failure is recorded as a server event
+	got.Email = []string{"admin@example.com"}
+	a.emailSavedReport(got, file, content, now, "admin")
+	got, _ = a.data.GetSavedReport("Weekly-Agents")
+	if !strings.HasPrefix(got.LastResult, "email failed") {
+		t.Errorf("unexpected result after email failure: %s", got.LastResult)
+	}
+	events, err := a.data.GetEvents(schema.ServerEventsID, 0, 0, "")
+	if err != nil || len(events) != 1 || events[0].Details["saved_report"] != "Weekly-Agents" {
+		t.Errorf("expected a server event, got %v %v", events, err)
+	}
+
+	if err = a.data.DeleteSavedReport("weekly-agents"); err != nil {
+		t.Fatalf("DeleteSavedReport failed: %v", err)
+	}
+	if _, err = a.data.GetSavedReport("Weekly-Agents"); !errors.Is(err, data.ErrSavedReportNotFound) {
+		t.Errorf("expected ErrSavedReportNotFound after delete, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func TestAgentSearch(t *testing.T) {
+	a := newTestAPI(t)
+
+	agents := []schema.AgentMeta{
+		{AgentID: "A-1", FriendlyName: "Front desk", LastIP: "203.0.113.7", Users: []string{"alice"},
+			Status: &schema.AgentStatus{Details: schema.StatusDetails{
+				Hostname: "LAPTOP-7GQ2", IP: "10.0.0.5,192.168.1.20", SerialNumber: "C02XK1"}}},
+		{AgentID: "A-2", FriendlyName: "Lab", Users: []string{"bob"},
+			Status: &schema.AgentStatus{Details: schema.StatusDetails{Hostname: "lab-01"}}},
+	}
+	for _, agent := range agents {
+		if err := a.data.SetAgentMeta(agent); err != nil {
+			t.Fatalf("failed to create agent: %v", err)
+		}
+	}
+
+	search := func(term string) []schema.AgentSearchResult {
+		t.Helper()
+		results, err := a.data.SearchAgents(term)
+		if err != nil {
+			t.Fatalf("search for %q failed: %v", term, err)
+		}
+		return results
+	}
+
+	tests := []struct {
+		term    string
+		agentID string
+		matched []string
+	}{
+		{"laptop-7gq2", "A-1", []string{schema.AgentSearchHostname}},
+		{"DESK", "A-1", []string{schema.AgentSearchFriendlyName}},
+		{"192.168.1", "A-1", []string{schema.AgentSearchIP}},
+		{"203.0.113.7", "A-1", []string{schema.AgentSearchIP}},
+		{"c02x", "A-1", []string{schema.AgentSearchSerialNumber}},
+		{"bob", "A-2", []string{schema.AgentSearchUser}},
+		{"lab", "A-2", []string{schema.AgentSearchHostname, schema.AgentSearchFriendlyName}},
+	}
+	for _, tt := range tests {
+		results := search(tt.term)
+		if len(results) != 1 || results[0].Agent.AgentID != tt.agentID || !slices.Equal(results[0].Matched, tt.matched) {
+			t.Errorf("%q: expected %s matching %v, got %+v", tt.term, tt.agentID, tt.matched, results)
+		}
+	}
+
+	if results := search("a-"); len(results) != 2 {
+		t.Errorf("expected both agents to match their IDs, got %d", len(results))
+	}
+
+	// The index follows updates and deletions
+	err := a.data.UpdateAgentMeta("A-2", func(meta *schema.AgentMeta) error {
+		meta.Status.Details.Hostname = "LAPTOP-9ZZ1"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to update agent: %v", err)
+	}
+	if results := search("laptop"); len(results) != 2 {
+		t.Errorf("expected the renamed agent to match, got %d results", len(results))
+	}
+	if err = a.data.AgentDelete("A-1"); err != nil {
+		t.Fatalf("failed to delete agent: %v", err)
+	}
+	if results := search("laptop"); len(results) != 1 || results[0].Agent.AgentID != "A-2" {
+		t.Errorf("expected the deleted agent to be removed, got %+v", results)
+	}
+
+	// A search term is required
+	req := httptest.NewRequest("GET", schema.EndpointAgent+"/search?q=+", nil)
+	if resp := a.getAgentSearch(req); resp.HTTPCode != http.StatusBadRequest {
+		t.Errorf("expected 400 without a search term, got %d", resp.HTTPCode)
+	}
+}
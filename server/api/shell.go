@@ -0,0 +1,315 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/shell"
+)
+
+// shellMaxBody is the largest shell input or output accepted in a single request
+const shellMaxBody = 4 * 1024 * 1024
+
+// @Summary Open remote shell session
+// @Description Opens an interactive shell session on an agent and queues a shell_start request for it.
+// @Description Only roles listed in the shell_roles server setting may open sessions, and the agent must
+// @Description have the shell_enabled setting. All input and output is recorded as shell events.
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Param agent_id path string true "Agent ID"
+// @Success 200 {object} schema.APIShellResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 403 {object} schema.API403
+// @Failure 404 {object} schema.API404
+// @Failure 500 {object} schema.API500
+// @Router /shell/{agent_id} [post]
+func (a *API) postShell(req *http.Request) userver.JResponse {
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	agentID := userver.GetParam(req, "agent_id")
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("agent_id", agentID))
+
+	if !a.shellRoleAllowed(authDetails.Role) {
+		a.logger.Warning(2963, "role not permitted to open shell sessions", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusForbidden,
+			JSONData: schema.API403{Details: "not authorized for this operation", Status: schema.APIStatusError, Code: http.StatusForbidden}}
+	}
+
+	if !a.conf.AC.Get(schema.ConfigAgentShell).Bool() {
+		a.logger.Info(2964, "remote shell is disabled", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "remote shell is disabled by the shell_enabled agent setting", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	if err := a.data.AgentExists(agentID); err != nil {
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "agent not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
+	}
+
+	session := shell.Open(agentID, authDetails.ID)
+	logFields.Append(fields.NewField("session_id", session.ID))
+
+	requestID, err := a.data.AddAgentRequest(schema.AgentRequest{
+		Requester:   authDetails.ID,
+		Request:     commands.ShellStart,
+		AckRequired: commands.IsAckRequired(commands.ShellStart),
+		Parameters:  map[string]string{commands.AgentID: agentID, "session_id": session.ID},
+	})
+	if err != nil {
+		session.Close("unable to queue request")
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Error(2965, "unable to queue shell request", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "unable to queue request", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+	}
+	session.RequestID = requestID
+
+	a.shellEvent(session, "shell session opened", "")
+	logFields.Append(fields.NewField("request_id", requestID))
+	a.logger.Info(2966, "shell session opened", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIShellResponse{
+			Status:    schema.APIStatusOK,
+			Code:      http.StatusOK,
+			Details:   "shell session opened, waiting for the agent",
+			SessionID: session.ID,
+			RequestID: requestID}}
+}
+
+// @Summary Exchange remote shell input and output
+// @Description Sends keyboard input and the terminal size to a shell session and returns the output received from
+// @Description the agent. Only the administrator who opened the session may use it.
+// @Tags Agent management
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param session_id path string true "Session ID"
+// @Param io body schema.ShellAdminIO true "Input and terminal size"
+// @Success 200 {object} schema.APIShellResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 403 {object} schema.API403
+// @Failure 404 {object} schema.API404
+// @Router /shell/session/{session_id} [post]
+func (a *API) postShellSession(req *http.Request) userver.JResponse {
+	session, errResp := a.adminShellSession(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	var in schema.ShellAdminIO
+	if errResp = readShellBody(req, &in); errResp != nil {
+		return *errResp
+	}
+
+	if len(in.Input) > 0 {
+		a.shellData(session, "input", in.Input)
+	}
+
+	output, connected, closed, reason := session.AdminIO(in.Input, in.Rows, in.Cols)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIShellResponse{
+			Status:    schema.APIStatusOK,
+			Code:      http.StatusOK,
+			SessionID: session.ID,
+			RequestID: session.RequestID,
+			Output:    output,
+			Connected: connected,
+			Closed:    closed,
+			Reason:    reason}}
+}
+
+// @Summary Close remote shell session
+// @Description Ends a shell session. The agent terminates the shell the next time it contacts the server.
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce json
+// @Param session_id path string true "Session ID"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 401 {object} schema.API401
+// @Failure 403 {object} schema.API403
+// @Failure 404 {object} schema.API404
+// @Router /shell/session/{session_id} [delete]
+func (a *API) deleteShellSession(req *http.Request) userver.JResponse {
+	session, errResp := a.adminShellSession(req)
+	if errResp != nil {
+		return *errResp
+	}
+
+	if session.Close("closed by administrator") {
+		a.shellEvent(session, "shell session closed", "closed by administrator")
+		a.logger.Info(2967, "shell session closed by administrator", fields.NewFields(
+			fields.NewField("src_ip", userver.RemoteIP(req)),
+			fields.NewField("id", session.Requester),
+			fields.NewField("agent_id", session.AgentID),
+			fields.NewField("session_id", session.ID)))
+	}
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGenericResponse{
+			Status:  schema.APIStatusOK,
+			Code:    http.StatusOK,
+			Details: "shell session closed"}}
+}
+
+// @Summary Relay remote shell output from an agent
+// @Description Used by agents to send shell output and receive input for a session
+// @Tags Agent communication
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param session_id path string true "Session ID"
+// @Param io body schema.ShellAgentIO true "Shell output"
+// @Success 200 {object} schema.APIShellAgentResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Router /agent-shell/{session_id} [post]
+func (a *API) postAgentShell(req *http.Request) userver.JResponse {
+	authDetails := GetAuthDetails(req)
+	sessionID := userver.GetParam(req, "session_id")
+
+	// Agents can only use their own sessions
+	session, ok := shell.Get(sessionID)
+	if !ok || session.AgentID != authDetails.ID {
+		return userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.APIShellAgentResponse{Details: "session not found", Status: schema.APIStatusError, Code: http.StatusNotFound, Close: true}}
+	}
+
+	var out schema.ShellAgentIO
+	if errResp := readShellBody(req, &out); errResp != nil {
+		return *errResp
+	}
+
+	if len(out.Output) > 0 {
+		a.shellData(session, "output", out.Output)
+	}
+
+	input, rows, cols, closed := session.AgentIO(out.Output)
+	if out.Closed && session.Close(out.Reason) {
+		a.shellEvent(session, "shell session closed", out.Reason)
+		a.logger.Info(2968, "shell session closed by agent", fields.NewFields(
+			fields.NewField("agent_id", session.AgentID),
+			fields.NewField("session_id", session.ID),
+			fields.NewField("reason", out.Reason)))
+		closed = true
+	}
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIShellAgentResponse{
+			Status: schema.APIStatusOK,
+			Code:   http.StatusOK,
+			Input:  input,
+			Rows:   rows,
+			Cols:   cols,
+			Close:  closed}}
+}
+
+// ExpireShellSessions closes shell sessions that have been idle for longer than the configured timeout
+func (a *API) ExpireShellSessions() {
+	idle := time.Duration(a.conf.SC.Get(global.ConfigShellIdleTimeout).Int()) * time.Second
+	for _, session := range shell.Expire(idle) {
+		a.shellEvent(session, "shell session closed", shell.ReasonIdle)
+		a.logger.Info(2969, "shell session closed after idle timeout", fields.NewFields(
+			fields.NewField("id", session.Requester),
+			fields.NewField("agent_id", session.AgentID),
+			fields.NewField("session_id", session.ID)))
+	}
+}
+
+// adminShellSession returns the session specified in the request if the administrator opened
+// it and their role is still permitted to use remote shells
+func (a *API) adminShellSession(req *http.Request) (*shell.Session, *userver.JResponse) {
+	authDetails := GetAuthDetails(req)
+	sessionID := userver.GetParam(req, "session_id")
+
+	if !a.shellRoleAllowed(authDetails.Role) {
+		a.logger.Warning(2963, "role not permitted to use shell sessions", fields.NewFields(
+			fields.NewField("src_ip", userver.RemoteIP(req)),
+			fields.NewField("id", authDetails.ID),
+			fields.NewField("role", authDetails.Role),
+			fields.NewField("session_id", sessionID)))
+		return nil, &userver.JResponse{
+			HTTPCode: http.StatusForbidden,
+			JSONData: schema.API403{Details: "not authorized for this operation", Status: schema.APIStatusError, Code: http.StatusForbidden}}
+	}
+
+	session, ok := shell.Get(sessionID)
+	if !ok || session.Requester != authDetails.ID {
+		return nil, &userver.JResponse{
+			HTTPCode: http.StatusNotFound,
+			JSONData: schema.API404{Details: "session not found", Status: schema.APIStatusError, Code: http.StatusNotFound}}
+	}
+	return session, nil
+}
+
+// shellRoleAllowed returns true if the role is listed in the shell_roles server setting
+func (a *API) shellRoleAllowed(role int) bool {
+	return a.AuthRoleListed(global.ConfigShellRoles, role)
+}
+
+// shellEvent records that a session was opened or closed
+func (a *API) shellEvent(session *shell.Session, event, reason string) {
+	err := a.data.AddShellEvent(session.AgentID, session.ID, session.Requester, event, reason)
+	if err != nil {
+		a.logger.Error(2970, "error recording shell event", fields.NewFields(
+			fields.NewField("agent_id", session.AgentID),
+			fields.NewField("session_id", session.ID),
+			fields.NewField("error", err.Error())))
+	}
+}
+
+// shellData records session input or output for audit
+func (a *API) shellData(session *shell.Session, direction string, data []byte) {
+	err := a.data.AddShellData(session.AgentID, session.ID, session.Requester, direction, data)
+	if err != nil {
+		a.logger.Error(2970, "error recording shell event", fields.NewFields(
+			fields.NewField("agent_id", session.AgentID),
+			fields.NewField("session_id", session.ID),
+			fields.NewField("error", err.Error())))
+	}
+}
+
+// readShellBody reads a JSON shell request body into v
+func readShellBody(req *http.Request, v any) *userver.JResponse {
+	body, err := io.ReadAll(io.LimitReader(req.Body, shellMaxBody+1))
+	if err != nil || len(body) > shellMaxBody {
+		return &userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "error reading body", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+
+	if err = json.Unmarshal(body, v); err != nil {
+		return &userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "error unmarshalling JSON", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/shell"
+)
+
+func TestShellRoles(t *testing.T) {
+	a := newTestAPI(t)
+
+	if a.shellRoleAllowed(schema.RoleSuperAdmin) {
+		t.Errorf("no roles should be permitted when shell_roles is empty")
+	}
+
+	a.conf.SC.Set(global.ConfigShellRoles, "superadmin, readonly")
+	if !a.shellRoleAllowed(schema.RoleSuperAdmin) || !a.shellRoleAllowed(schema.RoleReadOnly) {
+		t.Errorf("listed roles should be permitted")
+	}
+	if a.shellRoleAllowed(schema.RoleAdmin) || a.shellRoleAllowed(schema.RoleAgent) {
+		t.Errorf("unlisted roles should not be permitted")
+	}
+
+	if !commands.IsServerOnly(commands.ShellStart) || commands.IsServerOnly(commands.Execute) {
+		t.Errorf("shell_start should only be queued by the server")
+	}
+}
+
+func TestShellSessionRelay(t *testing.T) {
+	a := newTestAPI(t)
+
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	s := shell.Open("agentA", "admin")
+	if _, connected, closed, _ := s.AdminIO([]byte("ls\r"), 24, 80); connected || closed {
+		t.Fatalf("new session should be open and not connected")
+	}
+	a.shellData(s, "input", []byte("ls\r"))
+
+	// The agent collects the input and terminal size, then sends output
+	input, rows, cols, closed := s.AgentIO(nil)
+	if string(input) != "ls\r" || rows != 24 || cols != 80 || closed {
+		t.Fatalf("unexpected agent input %q %dx%d closed=%v", input, rows, cols, closed)
+	}
+	if _, rows, _, _ = s.AgentIO([]byte("file1\r\n")); rows != 0 {
+		t.Errorf("terminal size should only be sent when it changes")
+	}
+
+	// Output is delivered before the administrator learns that the session closed
+	if !s.Close("shell exited") || s.Close("again") {
+		t.Fatalf("session should only close once")
+	}
+	output, connected, closed, _ := s.AdminIO(nil, 24, 80)
+	if string(output) != "file1\r\n" || !connected || closed {
+		t.Fatalf("unexpected output %q connected=%v closed=%v", output, connected, closed)
+	}
+	if _, _, closed, reason := s.AdminIO([]byte("ignored"), 0, 0); !closed || reason != "shell exited" {
+		t.Fatalf("expected closed session, got closed=%v reason=%q", closed, reason)
+	}
+	if _, _, _, closed = s.AgentIO(nil); !closed {
+		t.Errorf("agent should be told to end a closed session")
+	}
+
+	events, err := a.data.GetEvents("agentA", 0, 0, schema.AgentEventShell)
+	if err != nil || len(events) != 1 || events[0].Details["data"] != "ls\r" || events[0].Details["session_id"] != s.ID {
+		t.Errorf("expected shell input event, got %+v (%v)", events, err)
+	}
+}
+
+func TestShellSessionIdle(t *testing.T) {
+	a := newTestAPI(t)
+
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	s := shell.Open("agentA", "admin")
+	time.Sleep(10 * time.Millisecond)
+
+	expired := shell.Expire(5 * time.Millisecond)
+	if len(expired) != 1 || expired[0].ID != s.ID {
+		t.Fatalf("expected idle session to expire, got %d", len(expired))
+	}
+	if _, _, closed, reason := s.AdminIO(nil, 0, 0); !closed || reason != shell.ReasonIdle {
+		t.Errorf("expected idle timeout, got closed=%v reason=%q", closed, reason)
+	}
+	if len(shell.Expire(5*time.Millisecond)) != 0 {
+		t.Errorf("a closed session should not expire again")
+	}
+}
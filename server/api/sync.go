@@ -11,12 +11,15 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/UnifyEM/UnifyEM/common/crypto"
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/userver"
 	"github.com/UnifyEM/UnifyEM/server/data"
 	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/metrics"
 	"github.com/UnifyEM/UnifyEM/server/queue"
 )
 
@@ -29,6 +32,7 @@ import (
 // @Param syncRequest body schema.AgentSyncRequest true "Agent sync request"
 // @Success 200 {object} schema.APISyncResponse
 // @Failure 401 {object} schema.API401
+// @Failure 429 {object} schema.APISyncResponse
 // @Router /sync [post]
 // postSync handles sync requests from agents
 func (a *API) postSync(req *http.Request) userver.JResponse {
@@ -40,6 +44,13 @@ func (a *API) postSync(req *http.Request) userver.JResponse {
 		fields.NewField("id", authDetails.ID),
 		fields.NewField("role", authDetails.Role))
 
+	// Defer the sync if the server is overloaded, before doing any work
+	inProgress := a.syncs.Add(1)
+	defer a.syncs.Add(-1)
+	if reason := a.syncOverloaded(inProgress); reason != "" {
+		return a.syncShedResponse(reason, logFields)
+	}
+
 	// Check if the agent exists - it might have been deleted
 	err := a.data.AgentExists(authDetails.ID)
 	if err != nil {
@@ -50,15 +61,17 @@ func (a *API) postSync(req *http.Request) userver.JResponse {
 			msg = fmt.Sprintf("agent check failed, denying access: %s", err.Error())
 		}
 		a.logger.Error(2800, msg, logFields)
+		metrics.Sync(metrics.SyncRejected)
 
 		// Deny access - agent will attempt to re-register if it has a valid token
-		return failureResponse
+		return failureResponse()
 	}
 
 	// Get the JSON post data
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
 		a.logger.Error(2801, fmt.Sprintf("error reading post body: %s", err.Error()), logFields)
+		metrics.Sync(metrics.SyncError)
 		return userver.JResponse{
 			HTTPCode: http.StatusBadRequest,
 			JSONData: schema.API400{Details: "error reading body", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
@@ -69,6 +82,7 @@ func (a *API) postSync(req *http.Request) userver.JResponse {
 	err = json.Unmarshal(body, &syncRequest)
 	if err != nil {
 		a.logger.Error(2802, fmt.Sprintf("deserialization error: %s", err.Error()), logFields)
+		metrics.Sync(metrics.SyncError)
 		return userver.JResponse{
 			HTTPCode: http.StatusBadRequest,
 			JSONData: schema.API400{Details: "error unmarshalling JSON", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
@@ -84,12 +98,15 @@ func (a *API) postSync(req *http.Request) userver.JResponse {
 	for _, message := range syncRequest.Messages {
 		// Overwrite the agent ID with the authenticated user and queue it
 		message.AgentID = authDetails.ID
-		queue.Add(message)
+		if err = queue.Add(message); err != nil {
+			a.queueError(message, err)
+		}
 	}
 
 	// Check for missing required fields
 	if syncRequest.Version == "" || syncRequest.Build < 1 {
 		a.logger.Error(2803, "syn request missing required fields", logFields)
+		metrics.Sync(metrics.SyncError)
 		return userver.JResponse{
 			HTTPCode: http.StatusBadRequest,
 			JSONData: schema.API400{Details: "missing required fields", Status: schema.APIStatusError, Code: http.StatusBadRequest}}
@@ -121,7 +138,15 @@ func (a *API) postSync(req *http.Request) userver.JResponse {
 	// Get the recovery public key from server config
 	recoveryPublicKey := a.conf.SC.Get(global.ConfigRecoveryPublicKey).String()
 
+	// Offer the next certificate pin so that pinned agents survive a certificate rotation
+	tlsPinNext, tlsPinNextSig := a.nextTLSPin(logFields)
+
+	// Sign the triggers so that the agent only acts on triggers from this server
+	serverTime := time.Now().Unix()
+	triggersSig := a.signTriggers(authDetails.ID, serverTime, triggers, logFields)
+
 	// Return the response
+	metrics.Sync(metrics.SyncOK)
 	return userver.JResponse{
 		HTTPCode: http.StatusOK,
 		JSONData: schema.APISyncResponse{
@@ -129,8 +154,45 @@ func (a *API) postSync(req *http.Request) userver.JResponse {
 			Code:               http.StatusOK,
 			Conf:               a.conf.AC.GetMap(),
 			Triggers:           triggers,
+			TriggersSig:        triggersSig,
 			Details:            "ok",
 			Requests:           requests,
 			ServiceCredentials: serviceCredentials,
-			RecoveryPublicKey:  recoveryPublicKey}}
+			RecoveryPublicKey:  recoveryPublicKey,
+			ServerTime:         serverTime,
+			TLSPinNext:         tlsPinNext,
+			TLSPinNextSig:      tlsPinNextSig}}
+}
+
+// nextTLSPin returns the next certificate pin and the server's signature of the rotation
+// from the current pin. Nothing is returned unless both pins are configured and valid.
+func (a *API) nextTLSPin(logFields *fields.Fields) (string, string) {
+	pin := a.conf.SC.Get(global.ConfigTLSPin).String()
+	next := a.conf.SC.Get(global.ConfigTLSPinNext).String()
+	if pin == "" || next == "" {
+		return "", ""
+	}
+
+	if !schema.ValidTLSPin(pin) || !schema.ValidTLSPin(next) {
+		a.logger.Error(2805, "tls_pin or tls_pin_next is not a valid certificate pin", logFields)
+		return "", ""
+	}
+
+	sig, err := crypto.Sign(schema.TLSPinData(pin, next), a.conf.SP.Get(global.ConfigServerECPrivateSig).String())
+	if err != nil {
+		a.logger.Error(2806, fmt.Sprintf("error signing next certificate pin: %s", err.Error()), logFields)
+		return "", ""
+	}
+	return next, sig
+}
+
+// signTriggers returns the server's signature of the triggers sent to an agent, or "" if they
+// can't be signed, in which case the agent ignores them
+func (a *API) signTriggers(agentID string, serverTime int64, triggers schema.AgentTriggers, logFields *fields.Fields) string {
+	sig, err := crypto.Sign(schema.TriggersData(agentID, serverTime, triggers), a.conf.SP.Get(global.ConfigServerECPrivateSig).String())
+	if err != nil {
+		a.logger.Error(2810, fmt.Sprintf("error signing triggers: %s", err.Error()), logFields)
+		return ""
+	}
+	return sig
 }
@@ -0,0 +1,126 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tag, err := schema.NormalizeTag("  Kiosk ")
+	if err != nil || tag != "kiosk" {
+		t.Errorf("expected kiosk, got %q (%v)", tag, err)
+	}
+
+	for _, bad := range []string{"", "   ", "a/b", "front desk", "all", strings.Repeat("a", schema.MaxTagLength+1)} {
+		if _, err = schema.NormalizeTag(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestAgentTags(t *testing.T) {
+	a := newTestAPI(t)
+
+	// Tags stored before normalization may differ only in case
+	for id, tags := range map[string][]string{
+		"agentA": {"Kiosk", "kiosk", "lobby"},
+		"agentB": {"KIOSK"},
+	} {
+		if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: id, Tags: tags}); err != nil {
+			t.Fatalf("failed to create agent: %v", err)
+		}
+	}
+
+	a.data.NormalizeAgentTags()
+
+	agents, err := a.data.GetAgentMeta("agentA")
+	if err != nil {
+		t.Fatalf("GetAgentMeta failed: %v", err)
+	}
+	if !slices.Equal(agents.Agents[0].Tags, []string{"kiosk", "lobby"}) {
+		t.Errorf("unexpected tags: %v", agents.Agents[0].Tags)
+	}
+
+	tags, err := a.data.GetTags()
+	if err != nil {
+		t.Fatalf("GetTags failed: %v", err)
+	}
+	expected := []schema.TagCount{{Tag: "kiosk", Agents: 2}, {Tag: "lobby", Agents: 1}}
+	if !slices.Equal(tags, expected) {
+		t.Errorf("expected %v, got %v", expected, tags)
+	}
+}
+
+func TestDeleteTag(t *testing.T) {
+	a := newTestAPI(t)
+
+	for id, tags := range map[string][]string{
+		"agentA": {"kiosk", "lobby"},
+		"agentB": {"Kiosk"},
+		"agentC": {"lobby"},
+	} {
+		if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: id, Tags: tags}); err != nil {
+			t.Fatalf("failed to create agent: %v", err)
+		}
+	}
+
+	count, err := a.data.DeleteTag("KIOSK")
+	if err != nil || count != 2 {
+		t.Fatalf("expected 2 agents, got %d (%v)", count, err)
+	}
+
+	tags, err := a.data.GetTags()
+	if err != nil {
+		t.Fatalf("GetTags failed: %v", err)
+	}
+	expected := []schema.TagCount{{Tag: "lobby", Agents: 2}}
+	if !slices.Equal(tags, expected) {
+		t.Errorf("expected %v, got %v", expected, tags)
+	}
+}
+
+func TestConcurrentTagAdds(t *testing.T) {
+	a := newTestAPI(t)
+
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	// Each request adds a different tag; none may be lost to a concurrent update
+	const count = 25
+	var wg sync.WaitGroup
+	for n := range count {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"tags":["tag%d"]}`, n)
+			req := mux.SetURLVars(httptest.NewRequest("POST", "/", strings.NewReader(body)), map[string]string{"id": "agentA"})
+			if r := a.postAgentTagsAdd(req); r.HTTPCode != http.StatusOK {
+				t.Errorf("tag add %d failed with %d", n, r.HTTPCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	agents, err := a.data.GetAgentMeta("agentA")
+	if err != nil || len(agents.Agents) != 1 {
+		t.Fatalf("failed to get agent: %v", err)
+	}
+	if len(agents.Agents[0].Tags) != count {
+		t.Errorf("expected %d tags, got %d: %v", count, len(agents.Agents[0].Tags), agents.Agents[0].Tags)
+	}
+}
@@ -6,7 +6,11 @@
 package api
 
 import (
+	"errors"
+
 	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/metrics"
 	"github.com/UnifyEM/UnifyEM/server/queue"
 )
 
@@ -16,11 +20,13 @@ import (
 // arrived from the API and requires access to the underlying data
 // layer to store the messages in the agent metadata
 func (a *API) ProcessMessageQueue() {
+	processed := 0
 	for {
-		message, ok := queue.Read()
+		item, ok := queue.Read()
 		if !ok {
 			break
 		}
+		message := item.Message
 
 		// Log the message
 		a.logger.Info(2050, "agent message",
@@ -30,10 +36,55 @@ func (a *API) ProcessMessageQueue() {
 				fields.NewField("type", message.MessageType),
 				fields.NewField("message", message.Message)))
 
-		// Send the message to the data layer
+		// Send the message to the data layer. Messages that it rejects are not retried.
 		err := a.data.NewAgentMessage(message)
 		if err != nil {
 			a.logger.Errorf(2051, "error adding message to event store: %s", err.Error())
 		}
+
+		// Remove the message from the persistent queue
+		if err = queue.Done(item); err != nil {
+			a.logger.Errorf(2052, "error removing message from queue: %s", err.Error())
+		}
+		processed++
+	}
+
+	if processed > 0 {
+		a.logger.Debug(2053, "processed queued messages",
+			fields.NewFields(
+				fields.NewField("processed", processed),
+				fields.NewField("depth", queue.Size())))
 	}
 }
+
+// openQueue persists the message queue in the database and restores any messages that were
+// not processed before the server stopped
+func (a *API) openQueue() {
+	restored, err := queue.Open(a.data)
+	if err != nil {
+		a.logger.Errorf(2054, "unable to restore message queue, messages will not survive a restart: %s", err.Error())
+		return
+	}
+	if restored > 0 {
+		a.logger.Info(2055, "restored queued messages",
+			fields.NewFields(fields.NewField("count", restored)))
+	}
+}
+
+// queueError logs a message from an agent that could not be queued or stored
+func (a *API) queueError(message schema.AgentMessage, err error) {
+	logFields := fields.NewFields(
+		fields.NewField("id", message.AgentID),
+		fields.NewField("type", message.MessageType),
+		fields.NewField("depth", queue.Size()),
+		fields.NewField("capacity", queue.Capacity()))
+
+	if errors.Is(err, queue.ErrFull) {
+		metrics.MessageDropped()
+		a.logger.Warning(2056, "message queue is full, agent message dropped", logFields)
+		return
+	}
+
+	logFields.Append(fields.NewField("error", err.Error()))
+	a.logger.Error(2057, "unable to store queued message, it will be lost if the server stops", logFields)
+}
@@ -0,0 +1,165 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+// The dashboard logs in with the login endpoint and reads everything it displays from the JSON
+// API. The access token is kept in session storage so that it is discarded when the tab closes.
+
+"use strict";
+
+const api = "/api/v1";
+const refreshSeconds = 30;
+const tokenKey = "uem-access-token";
+
+const checks = {
+  firewall: "Firewall",
+  antivirus: "Antivirus",
+  full_disk_encryption: "Full disk encryption",
+  password: "Password",
+  screen_lock: "Screen lock",
+};
+
+const triggers = {
+  lost: "Lost mode",
+  wipe: "Wipe",
+  wipe_pending: "Wipe awaiting confirmation",
+  uninstall: "Uninstall",
+};
+
+let timer = null;
+
+// Unauthorized is thrown when the token is missing, expired, or rejected
+class Unauthorized extends Error {}
+
+async function get(path) {
+  const token = sessionStorage.getItem(tokenKey);
+  if (!token) {
+    throw new Unauthorized();
+  }
+
+  const resp = await fetch(api + path, { headers: { Authorization: "Bearer " + token } });
+  if (resp.status === 401) {
+    throw new Unauthorized();
+  }
+  const body = await resp.json();
+  if (!resp.ok) {
+    throw new Error(body.details || "HTTP " + resp.status);
+  }
+  return body;
+}
+
+async function login(event) {
+  event.preventDefault();
+  const form = event.target;
+  const error = document.getElementById("login-error");
+  error.textContent = "";
+
+  try {
+    const resp = await fetch(api + "/login", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ username: form.username.value, password: form.password.value }),
+    });
+    const body = await resp.json();
+    if (!resp.ok || !body.access_token) {
+      error.textContent = body.details || "Login failed";
+      return;
+    }
+    sessionStorage.setItem(tokenKey, body.access_token);
+    form.reset();
+    show(true);
+    refresh();
+  } catch (err) {
+    error.textContent = err.message;
+  }
+}
+
+function logout() {
+  sessionStorage.removeItem(tokenKey);
+  show(false);
+}
+
+// show displays the dashboard or the login form
+function show(loggedIn) {
+  document.getElementById("login").hidden = loggedIn;
+  document.getElementById("dashboard").hidden = !loggedIn;
+  document.getElementById("logout").hidden = !loggedIn;
+  clearTimeout(timer);
+  if (!loggedIn) {
+    document.getElementById("updated").textContent = "";
+  }
+}
+
+async function refresh() {
+  const error = document.getElementById("error");
+  try {
+    const [summary, agents] = await Promise.all([get("/dashboard/summary"), get("/agent")]);
+    render(summary.data, agents.data.agents || []);
+    error.textContent = "";
+  } catch (err) {
+    if (err instanceof Unauthorized) {
+      logout();
+      return;
+    }
+    error.textContent = err.message;
+  }
+  timer = setTimeout(refresh, refreshSeconds * 1000);
+}
+
+function render(summary, agents) {
+  setText("agents", summary.agents);
+  setText("online", summary.online);
+  setText("offline", summary.offline);
+  setText("non-compliant", summary.non_compliant);
+  setText("not-reported", summary.not_reported);
+  setText("requests", summary.requests.new + summary.requests.pending);
+  setText("updated", "Updated " + formatTime(summary.generated));
+
+  fill("failing", Object.entries(checks).map(([key, label]) => [label, summary.failing[key] || 0]));
+  fill("triggers", Object.entries(triggers).map(([key, label]) => [label, summary.triggers[key] || 0]));
+  fill("offline-agents", agents
+    .filter((agent) => agent.offline)
+    .sort((a, b) => new Date(a.last_seen) - new Date(b.last_seen))
+    .map((agent) => [agent.agent_id, agent.friendly_name || hostname(agent), formatTime(agent.last_seen)]));
+  fill("events", summary.recent_events.map((event) =>
+    [formatTime(event.time), event.agent_id, event.type, event.event]));
+}
+
+function hostname(agent) {
+  return (agent.status && agent.status.details && agent.status.details.hostname) || "";
+}
+
+function setText(id, value) {
+  document.getElementById(id).textContent = value;
+}
+
+// fill replaces the rows of a table. Values are set as text so that nothing is interpreted as HTML.
+function fill(id, rows) {
+  const body = document.querySelector("#" + id + " tbody");
+  body.replaceChildren(...rows.map((row) => {
+    const tr = document.createElement("tr");
+    for (const value of row) {
+      const td = document.createElement("td");
+      td.textContent = value;
+      tr.appendChild(td);
+    }
+    return tr;
+  }));
+}
+
+function formatTime(value) {
+  const t = new Date(value);
+  return isNaN(t) || t.getFullYear() < 2000 ? "-" : t.toLocaleString();
+}
+
+document.addEventListener("DOMContentLoaded", () => {
+  document.getElementById("login").addEventListener("submit", login);
+  document.getElementById("logout").addEventListener("click", logout);
+
+  const loggedIn = sessionStorage.getItem(tokenKey) !== null;
+  show(loggedIn);
+  if (loggedIn) {
+    refresh();
+  }
+});
@@ -0,0 +1,71 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func TestUninstallState(t *testing.T) {
+	a := newTestAPI(t)
+
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	send := func(status string) {
+		err := a.data.NewAgentMessage(schema.AgentMessage{
+			AgentID:     "agentA",
+			Sent:        time.Now(),
+			MessageType: schema.AgentEventUninstall,
+			Message:     status})
+		if err != nil {
+			t.Fatalf("failed to process %q: %v", status, err)
+		}
+	}
+
+	state := func() string {
+		list, err := a.data.GetAgentMeta("agentA")
+		if err != nil || len(list.Agents) != 1 {
+			t.Fatalf("failed to get agent: %v", err)
+		}
+		return list.Agents[0].State
+	}
+
+	if s := state(); s != "" {
+		t.Fatalf("expected no state for a new agent, got %q", s)
+	}
+
+	send(schema.UninstallStarting)
+	if s := state(); s != schema.AgentStateUninstalling {
+		t.Fatalf("expected %q, got %q", schema.AgentStateUninstalling, s)
+	}
+
+	send(schema.UninstallComplete)
+	if s := state(); s != schema.AgentStateUninstalled {
+		t.Fatalf("expected %q, got %q", schema.AgentStateUninstalled, s)
+	}
+
+	// A late acknowledgement must not undo the completion
+	send(schema.UninstallStarting)
+	if s := state(); s != schema.AgentStateUninstalled {
+		t.Fatalf("expected %q after late message, got %q", schema.AgentStateUninstalled, s)
+	}
+
+	// All messages are retained in the event history
+	events, err := a.data.GetEvents("agentA", 0, 0, schema.AgentEventMessage)
+	if err != nil || len(events) != 3 {
+		t.Fatalf("expected three message events, got %d (%v)", len(events), err)
+	}
+
+	err = a.data.NewAgentMessage(schema.AgentMessage{AgentID: "agentA", MessageType: schema.AgentEventUninstall, Message: "bogus"})
+	if err == nil {
+		t.Errorf("expected an error for an unknown uninstall status")
+	}
+}
@@ -0,0 +1,62 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// upgradeRequests returns the number of upgrade requests queued for the agent
+func upgradeRequests(t *testing.T, a *API, agentID string) int {
+	t.Helper()
+
+	records, err := a.data.GetAgentRequestRecords(agentID)
+	if err != nil {
+		t.Fatalf("failed to get requests: %v", err)
+	}
+
+	count := 0
+	for _, r := range records.Requests {
+		if r.Request == commands.Upgrade {
+			count++
+		}
+	}
+	return count
+}
+
+func TestMinimumAgentVersion(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigMinimumAgentVersion, "0.0.60+108")
+	if err := a.data.SetAgentMeta(schema.NewAgentMeta("agentA")); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	// Build 107 is older than the minimum even though the version matches
+	a.data.AgentSync(data.SyncData{AgentID: "agentA", Version: "0.0.60", Build: 107})
+	a.data.AgentSync(data.SyncData{AgentID: "agentA", Version: "0.0.60", Build: 107})
+	if n := upgradeRequests(t, a, "agentA"); n != 1 {
+		t.Fatalf("expected 1 upgrade request, got %d", n)
+	}
+
+	agents, _ := a.data.GetAgentMeta("agentA")
+	if !agents.Agents[0].UpgradePending {
+		t.Error("upgrade should be pending")
+	}
+
+	a.data.AgentSync(data.SyncData{AgentID: "agentA", Version: "0.0.61", Build: 1})
+	agents, _ = a.data.GetAgentMeta("agentA")
+	if agents.Agents[0].UpgradePending {
+		t.Error("upgrade should no longer be pending")
+	}
+	if n := upgradeRequests(t, a, "agentA"); n != 1 {
+		t.Errorf("expected 1 upgrade request, got %d", n)
+	}
+}
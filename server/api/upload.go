@@ -0,0 +1,134 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/data"
+)
+
+// @Summary Upload a file from an agent
+// @Description Used by agents to upload the file requested by a file_fetch command. The request body is the file contents.
+// @Tags Agent communication
+// @Security BearerAuth
+// @Accept octet-stream
+// @Produce json
+// @Param request_id path string true "Request ID of the file_fetch command"
+// @Param name query string true "Name of the file"
+// @Success 200 {object} schema.APIAgentUploadResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 403 {object} schema.API403
+// @Failure 413 {object} schema.APIGenericResponse
+// @Failure 500 {object} schema.API500
+// @Router /agent-upload/{request_id} [post]
+func (a *API) postAgentUpload(req *http.Request) userver.JResponse {
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	requestID := userver.GetParam(req, "request_id")
+	name := req.URL.Query().Get("name")
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("request_id", requestID),
+		fields.NewField("name", name))
+
+	maxBytes := int64(a.conf.AC.Get(schema.ConfigAgentFileFetchMax).Int()) * 1024 * 1024
+	upload, err := a.data.SaveAgentUpload(authDetails.ID, requestID, name, req.Body, maxBytes)
+	if err != nil {
+		logFields.Append(fields.NewField("error", err.Error()))
+		a.logger.Warning(2922, "agent upload rejected", logFields)
+
+		switch {
+		case errors.Is(err, data.ErrUploadNotPermitted):
+			return userver.JResponse{
+				HTTPCode: http.StatusForbidden,
+				JSONData: schema.API403{Details: err.Error(), Status: schema.APIStatusError, Code: http.StatusForbidden}}
+		case errors.Is(err, data.ErrUploadTooLarge):
+			return userver.JResponse{
+				HTTPCode: http.StatusRequestEntityTooLarge,
+				JSONData: schema.APIGenericResponse{
+					Details: fmt.Sprintf("%s of %d bytes", err.Error(), maxBytes),
+					Status:  schema.APIStatusError,
+					Code:    http.StatusRequestEntityTooLarge}}
+		default:
+			return userver.JResponse{
+				HTTPCode: http.StatusInternalServerError,
+				JSONData: schema.API500{Details: "error storing upload", Status: schema.APIStatusError, Code: http.StatusInternalServerError}}
+		}
+	}
+
+	logFields.Append(fields.NewField("size", upload.Size), fields.NewField("sha256", upload.SHA256))
+	a.logger.Info(2923, "agent upload received", logFields)
+
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIAgentUploadResponse{
+			Status: schema.APIStatusOK,
+			Code:   http.StatusOK,
+			Data:   upload}}
+}
+
+// @Summary Download a file uploaded by an agent
+// @Description Retrieves the file uploaded by an agent in response to a file_fetch command
+// @Tags Agent management
+// @Security BearerAuth
+// @Produce octet-stream
+// @Param request_id path string true "Request ID of the file_fetch command"
+// @Success 200 {file} file
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Router /agent-upload/{request_id} [get]
+func (a *API) getAgentUpload(w http.ResponseWriter, req *http.Request) {
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	requestID := userver.GetParam(req, "request_id")
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("request_id", requestID))
+
+	path, upload, err := a.data.GetAgentUpload(requestID)
+	if err == nil {
+		var f *os.File
+		if f, err = os.Open(path); err == nil {
+			defer func(f *os.File) {
+				_ = f.Close()
+			}(f)
+
+			var info os.FileInfo
+			if info, err = f.Stat(); err == nil {
+				logFields.Append(fields.NewField("name", upload.Name), fields.NewField("size", upload.Size))
+				a.logger.Info(2924, "agent upload retrieved", logFields)
+
+				// Always download rather than display, regardless of the file type
+				w.Header().Set("Content-Type", "application/octet-stream")
+				w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": upload.Name}))
+				w.Header().Set("X-Content-Type-Options", "nosniff")
+				http.ServeContent(w, req, upload.Name, info.ModTime(), f)
+				return
+			}
+		}
+	}
+
+	logFields.Append(fields.NewField("error", err.Error()))
+	a.logger.Info(2925, "agent upload not found", logFields)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusNotFound)
+	_ = json.NewEncoder(w).Encode(schema.API404{Details: "upload not found", Status: schema.APIStatusError, Code: http.StatusNotFound})
+}
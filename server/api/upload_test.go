@@ -0,0 +1,79 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// pendingFetch creates a file_fetch request for the agent and marks it as sent
+func pendingFetch(t *testing.T, a *API, agentID string) string {
+	t.Helper()
+
+	if err := a.data.SetAgentMeta(schema.AgentMeta{AgentID: agentID, Active: true}); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	requestID, err := a.data.AddAgentRequest(schema.AgentRequest{
+		AgentID:     agentID,
+		Request:     commands.FileFetch,
+		AckRequired: true,
+		Parameters:  map[string]string{"agent_id": agentID, "path": "/var/log/system.log"}})
+	if err != nil {
+		t.Fatalf("failed to add request: %v", err)
+	}
+
+	if _, err = a.data.GetAgentRequests(agentID, true); err != nil {
+		t.Fatalf("failed to mark request sent: %v", err)
+	}
+	return requestID
+}
+
+func TestAgentUpload(t *testing.T) {
+	a := newTestAPI(t)
+	a.conf.SC.Set(global.ConfigFilesPath, t.TempDir())
+	requestID := pendingFetch(t, a, "agentA")
+	pendingFetch(t, a, "agentB")
+
+	// Another agent must not be able to upload for the request
+	_, err := a.data.SaveAgentUpload("agentB", requestID, "log", strings.NewReader("data"), 1024)
+	if !errors.Is(err, data.ErrUploadNotPermitted) {
+		t.Errorf("upload by another agent: expected ErrUploadNotPermitted, got %v", err)
+	}
+
+	_, err = a.data.SaveAgentUpload("agentA", requestID, "log", strings.NewReader("0123456789"), 5)
+	if !errors.Is(err, data.ErrUploadTooLarge) {
+		t.Errorf("oversized upload: expected ErrUploadTooLarge, got %v", err)
+	}
+
+	// Directory components in the name must be discarded
+	upload, err := a.data.SaveAgentUpload("agentA", requestID, "../../system.log", strings.NewReader("data"), 1024)
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if upload.Name != "system.log" || upload.Size != 4 {
+		t.Errorf("unexpected upload details: %+v", upload)
+	}
+
+	path, got, err := a.data.GetAgentUpload(requestID)
+	if err != nil {
+		t.Fatalf("GetAgentUpload failed: %v", err)
+	}
+	if got.Name != "system.log" || got.AgentID != "agentA" {
+		t.Errorf("unexpected upload details: %+v", got)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "data" {
+		t.Errorf("unexpected upload contents: %q", b)
+	}
+}
@@ -7,6 +7,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/userver"
+	"github.com/UnifyEM/UnifyEM/server/data"
 )
 
 // @Summary List all users
@@ -117,6 +119,7 @@ func (a *API) getUser(req *http.Request) userver.JResponse {
 // @Success 200 {object} schema.UserCreateResponse
 // @Failure 400 {object} schema.API400
 // @Failure 401 {object} schema.API401
+// @Failure 403 {object} schema.API403
 // @Failure 409 {object} schema.API400
 // @Failure 500 {object} schema.API500
 // @Router /user [post]
@@ -143,12 +146,36 @@ func (a *API) postUser(req *http.Request) userver.JResponse {
 			HTTPCode: http.StatusBadRequest,
 			JSONData: schema.API400{Details: "error unmarshalling JSON", Status: "error", Code: http.StatusBadRequest}}
 	}
+
+	// If a login account is requested, make sure the role may be assigned
+	if createReq.Password != "" {
+		logFields.Append(fields.NewField("new_role", createReq.Role))
+		role := schema.RoleByName(createReq.Role)
+		if role != schema.RoleReadOnly && role != schema.RoleAdmin {
+			a.logger.Error(3213, "invalid role for new user", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusBadRequest,
+				JSONData: schema.API400{Details: "role must be readonly or admin", Status: "error", Code: http.StatusBadRequest}}
+		}
+
+		// Only super admins may create admins
+		if role == schema.RoleAdmin && authDetails.Role != schema.RoleSuperAdmin {
+			a.logger.Warning(3214, "admin role requested by non-super admin", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusForbidden,
+				JSONData: schema.API403{Details: "only super admins may create admin users", Status: "error", Code: http.StatusForbidden}}
+		}
+	}
+
 	user, err := a.data.AddUser(createReq)
 	if err != nil {
 		code := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "exists") {
 			code = http.StatusConflict
 		}
+		if errors.Is(err, data.ErrAgentUserID) {
+			code = http.StatusBadRequest
+		}
 		a.logger.Error(3208, err.Error(), logFields)
 		return userver.JResponse{
 			HTTPCode: code,
@@ -210,3 +237,54 @@ func (a *API) deleteUser(req *http.Request) userver.JResponse {
 		JSONData: schema.UserDeleteResponse{Status: "ok", Code: http.StatusOK},
 	}
 }
+
+// @Summary Revoke a user's sessions
+// @Description Revokes all access and refresh tokens issued to a user so far. The user must log in again.
+// @Tags User management
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} schema.APIGenericResponse
+// @Failure 400 {object} schema.API400
+// @Failure 401 {object} schema.API401
+// @Failure 404 {object} schema.API404
+// @Failure 500 {object} schema.API500
+// @Router /user/{id}/revoke-sessions [post]
+func (a *API) postUserRevokeSessions(req *http.Request) userver.JResponse {
+	userID := userver.GetParam(req, "id")
+	remoteIP := userver.RemoteIP(req)
+	authDetails := GetAuthDetails(req)
+	logFields := fields.NewFields(
+		fields.NewField("src_ip", remoteIP),
+		fields.NewField("id", authDetails.ID),
+		fields.NewField("role", authDetails.Role),
+		fields.NewField("user_id", userID),
+	)
+
+	if userID == "" {
+		a.logger.Error(3215, "user ID required", logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusBadRequest,
+			JSONData: schema.API400{Details: "user ID required", Status: "error", Code: http.StatusBadRequest}}
+	}
+
+	err := a.data.RevokeUserSessions(userID)
+	if err != nil {
+		if errors.Is(err, data.ErrUserNotFound) {
+			a.logger.Warning(3216, "user not found", logFields)
+			return userver.JResponse{
+				HTTPCode: http.StatusNotFound,
+				JSONData: schema.API404{Details: "user not found", Status: "not found", Code: http.StatusNotFound}}
+		}
+		a.logger.Error(3216, fmt.Sprintf("error revoking sessions: %s", err.Error()), logFields)
+		return userver.JResponse{
+			HTTPCode: http.StatusInternalServerError,
+			JSONData: schema.API500{Details: "error revoking sessions", Status: "error", Code: http.StatusInternalServerError}}
+	}
+
+	a.logger.Info(3217, "user sessions revoked", logFields)
+	return userver.JResponse{
+		HTTPCode: http.StatusOK,
+		JSONData: schema.APIGenericResponse{Status: schema.APIStatusOK, Code: http.StatusOK, Details: "sessions revoked"},
+	}
+}
@@ -0,0 +1,51 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+func TestPostUserExistingLogin(t *testing.T) {
+	a := newTestAPI(t)
+
+	// The super admin created by "uem-server admin" has a login account but no user record
+	if err := a.data.SetAuth("superadmin", "password", schema.RoleSuperAdmin); err != nil {
+		t.Fatalf("failed to set auth: %v", err)
+	}
+	agentID := "A-3f1c2a4e-8b7d-4c6e-9a0b-1d2e3f4a5b6c"
+	if err := a.data.SetAuth(agentID, "secret", schema.RoleAgent); err != nil {
+		t.Fatalf("failed to set auth: %v", err)
+	}
+
+	post := func(user string) int {
+		body, _ := json.Marshal(schema.UserCreateRequest{User: user, Password: "x", Role: "readonly"})
+		return a.postUser(httptest.NewRequest("POST", schema.EndpointUser, bytes.NewReader(body))).HTTPCode
+	}
+
+	if code := post("superadmin"); code != http.StatusConflict {
+		t.Errorf("expected 409 for the super admin, got %d", code)
+	}
+	if _, _, err := a.data.LoginGetToken("superadmin", "password", "127.0.0.1"); err != nil {
+		t.Errorf("super admin password was changed: %v", err)
+	}
+	if logins, err := a.data.ListLogins(); err != nil || len(logins) != 1 || logins[0].Role != schema.RoleSuperAdmin {
+		t.Errorf("super admin role was changed: %+v %v", logins, err)
+	}
+
+	if code := post(agentID); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an agent ID, got %d", code)
+	}
+	if code := post("operator"); code != http.StatusOK {
+		t.Errorf("expected a new user to be created, got %d", code)
+	}
+}
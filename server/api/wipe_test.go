@@ -0,0 +1,93 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestWipeConfirmation(t *testing.T) {
+	a := newTestAPI(t)
+
+	meta := schema.NewAgentMeta("agentA")
+	pending, code, err := a.data.NewWipePending("agentA", "admin")
+	if err != nil {
+		t.Fatalf("NewWipePending failed: %v", err)
+	}
+	if strings.Contains(pending.CodeHash, code) {
+		t.Errorf("code stored in plain text")
+	}
+	pending.Mode = schema.WipeModeCorporate
+	meta.WipePending = pending
+	if err = a.data.SetAgentMeta(meta); err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+
+	if err = a.data.ConfirmWipe("agentA", "WRONG", "admin"); !errors.Is(err, data.ErrWipeCodeInvalid) {
+		t.Errorf("expected ErrWipeCodeInvalid, got %v", err)
+	}
+
+	// A code for another agent is not accepted
+	if err = a.data.ConfirmWipe("agentB", code, "admin"); err == nil {
+		t.Errorf("expected an error for an unknown agent")
+	}
+
+	agents, _ := a.data.GetAgentMeta("agentA")
+	if agents.Agents[0].Triggers.Wipe || agents.Agents[0].WipePending == nil {
+		t.Fatalf("wipe must remain pending until confirmed")
+	}
+
+	if err = a.data.ConfirmWipe("agentA", " "+strings.ToLower(code)+" ", "admin"); err != nil {
+		t.Fatalf("ConfirmWipe failed: %v", err)
+	}
+	agents, _ = a.data.GetAgentMeta("agentA")
+	if !agents.Agents[0].Triggers.Wipe || agents.Agents[0].WipePending != nil {
+		t.Errorf("expected wipe trigger set and nothing pending, got %+v", agents.Agents[0])
+	}
+	if agents.Agents[0].Triggers.WipeMode != schema.WipeModeCorporate {
+		t.Errorf("expected the requested wipe mode to be sent, got %q", agents.Agents[0].Triggers.WipeMode)
+	}
+
+	if err = a.data.ConfirmWipe("agentA", code, "admin"); !errors.Is(err, data.ErrNoWipePending) {
+		t.Errorf("expected ErrNoWipePending, got %v", err)
+	}
+}
+
+func TestWipeConfirmationLimits(t *testing.T) {
+	a := newTestAPI(t)
+
+	// Expired codes are rejected and cancel the pending wipe
+	meta := schema.NewAgentMeta("agentA")
+	pending, code, _ := a.data.NewWipePending("agentA", "admin")
+	pending.Expires = time.Now().Add(-time.Second)
+	meta.WipePending = pending
+	_ = a.data.SetAgentMeta(meta)
+
+	if err := a.data.ConfirmWipe("agentA", code, "admin"); !errors.Is(err, data.ErrWipeCodeExpired) {
+		t.Errorf("expected ErrWipeCodeExpired, got %v", err)
+	}
+	agents, _ := a.data.GetAgentMeta("agentA")
+	if agents.Agents[0].Triggers.Wipe || agents.Agents[0].WipePending != nil {
+		t.Errorf("expected the expired wipe to be cancelled")
+	}
+
+	// Too many incorrect codes cancel the pending wipe
+	meta.WipePending, code, _ = a.data.NewWipePending("agentA", "admin")
+	_ = a.data.SetAgentMeta(meta)
+	for x := 0; x < global.WipeCodeAttempts; x++ {
+		_ = a.data.ConfirmWipe("agentA", "WRONG", "admin")
+	}
+	if err := a.data.ConfirmWipe("agentA", code, "admin"); !errors.Is(err, data.ErrNoWipePending) {
+		t.Errorf("expected ErrNoWipePending, got %v", err)
+	}
+}
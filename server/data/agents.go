@@ -6,11 +6,20 @@
 package data
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
+// ErrAgentNotFound is returned when an agent does not exist
+var ErrAgentNotFound = errors.New("agent not found")
+
+// errAgentUnchanged is returned by an UpdateAgentMeta function that has nothing to change,
+// so that the update is not stored
+var errAgentUnchanged = errors.New("agent metadata unchanged")
+
 // GetAllAgentMeta returns a list of all agent metadata
 func (d *Data) GetAllAgentMeta() (schema.AgentList, error) {
 	return d.database.GetAllAgentMeta()
@@ -26,10 +35,46 @@ func (d *Data) GetAgentMeta(agentID string) (schema.AgentList, error) {
 	return schema.AgentList{Agents: []schema.AgentMeta{agent}}, nil
 }
 
+// AddRequestQueues adds a summary of each agent's new and pending requests to the agents in
+// the list
+func (d *Data) AddRequestQueues(list *schema.AgentList) error {
+	agentIDs := make([]string, len(list.Agents))
+	for i, agent := range list.Agents {
+		agentIDs[i] = agent.AgentID
+	}
+
+	queues, err := d.database.GetRequestQueues(agentIDs...)
+	if err != nil {
+		return err
+	}
+	for i := range list.Agents {
+		queue := queues[list.Agents[i].AgentID]
+		list.Agents[i].Queue = &queue
+	}
+	return nil
+}
+
+// SearchAgents returns the agents with a hostname, friendly name, IP address, serial number,
+// agent ID, or assigned user that contains the term, ignoring case
+func (d *Data) SearchAgents(term string) ([]schema.AgentSearchResult, error) {
+	return d.database.SearchAgents(term)
+}
+
 func (d *Data) SetAgentMeta(meta schema.AgentMeta) error {
 	return d.database.SetAgentMeta(meta)
 }
 
+// UpdateAgentMeta calls fn to modify the agent's metadata and stores the result in a single
+// transaction, so that concurrent updates to the same agent are not lost. fn must not call
+// other Data methods that write to the database. Errors returned by fn are passed through.
+func (d *Data) UpdateAgentMeta(agentID string, fn func(*schema.AgentMeta) error) error {
+	err := d.database.UpdateAgentMeta(agentID, fn)
+	if err != nil && (strings.Contains(err.Error(), "key not found") || strings.Contains(err.Error(), "bucket not found")) {
+		return ErrAgentNotFound
+	}
+	return err
+}
+
 func (d *Data) AgentExists(agentID string) error {
 	return d.database.AgentExists(agentID)
 }
@@ -46,12 +91,10 @@ func (d *Data) GetServiceCredentials(agentID string) string {
 
 // SetAgentRecoveryInfo stores the encrypted recovery info blob for an agent
 func (d *Data) SetAgentRecoveryInfo(agentID string, info string) error {
-	meta, err := d.database.GetAgentMeta(agentID)
-	if err != nil {
-		return fmt.Errorf("failed to get agent metadata: %w", err)
-	}
-	meta.RecoveryInfo = info
-	return d.database.SetAgentMeta(meta)
+	return d.UpdateAgentMeta(agentID, func(meta *schema.AgentMeta) error {
+		meta.RecoveryInfo = info
+		return nil
+	})
 }
 
 // GetAgentRecoveryInfo returns the encrypted recovery info blob for an agent
@@ -77,14 +120,64 @@ func (d *Data) AgentDelete(agentID string) error {
 	err = d.database.DeleteAllEvents(agentID)
 
 	// Delete agent metadata
-	return d.database.DeleteAgentMeta(agentID)
+	err = d.database.DeleteAgentMeta(agentID)
+	if err != nil {
+		return err
+	}
+
+	// Tokens already issued to the agent must not outlive it
+	return d.revokeAgentSessions(agentID)
 }
 
 // NewAgentMessage adds a message event to the database
 func (d *Data) NewAgentMessage(message schema.AgentMessage) error {
-	return d.database.AddEvent(schema.AgentEvent{
+	eventType := schema.AgentEventMessage
+	if message.MessageType == schema.AgentEventAlert {
+		eventType = schema.AgentEventAlert
+	}
+
+	// Wipe progress is recorded as an alert so that it is forwarded to notification sinks
+	if message.MessageType == schema.AgentEventWipe {
+		eventType = schema.AgentEventAlert
+	}
+
+	// Tampering is recorded as its own event type so that it can be searched for and forwarded
+	if message.MessageType == schema.AgentEventTamper {
+		eventType = schema.AgentEventTamper
+	}
+
+	// Uninstall progress is recorded as a message and also updates the agent state
+	if message.MessageType == schema.AgentEventUninstall {
+		err := d.setUninstallState(message.AgentID, message.Message)
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.AddEvent(schema.AgentEvent{
 		AgentID:   message.AgentID,
 		Event:     message.Message,
 		Time:      message.Sent,
-		EventType: schema.AgentEventMessage})
+		EventType: eventType})
+}
+
+// setUninstallState updates the agent state based on an uninstall status message
+func (d *Data) setUninstallState(agentID, status string) error {
+	var state string
+	switch status {
+	case schema.UninstallStarting:
+		state = schema.AgentStateUninstalling
+	case schema.UninstallComplete:
+		state = schema.AgentStateUninstalled
+	default:
+		return fmt.Errorf("unknown uninstall status: %s", status)
+	}
+
+	return d.UpdateAgentMeta(agentID, func(meta *schema.AgentMeta) error {
+		// Never move an uninstalled agent back to uninstalling if messages arrive out of order
+		if meta.State != schema.AgentStateUninstalled {
+			meta.State = state
+		}
+		return nil
+	})
 }
@@ -0,0 +1,143 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/metrics"
+)
+
+// Requests for the commands listed in approval_commands are created awaiting approval and are
+// not sent to agents until a different administrator approves them. Listing wipe requires a
+// different administrator to confirm a wipe, and wipes can't be forced.
+
+// ApprovalWipe is listed in approval_commands to require a second administrator for wipes
+const ApprovalWipe = "wipe"
+
+var (
+	ErrNotAwaitingApproval = errors.New("request is not awaiting approval")
+	ErrSelfApproval        = errors.New("a request must be approved or denied by a different administrator")
+)
+
+// ApprovalRequired returns true if the command is listed in approval_commands
+func (d *Data) ApprovalRequired(cmd string) bool {
+	for _, name := range d.conf.SC.Get(global.ConfigApprovalCommands).SplitList() {
+		if strings.EqualFold(strings.TrimSpace(name), cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+// initialStatus returns the status of a new request for an agent
+func (d *Data) initialStatus(cmd string) string {
+	if d.ApprovalRequired(cmd) {
+		return schema.RequestStatusAwaitingApproval
+	}
+	return schema.RequestStatusNew
+}
+
+// ApproveAgentRequest allows a request awaiting approval to be sent to the agent. A tag request
+// is delivered for tag_request_days from the time it is approved.
+func (d *Data) ApproveAgentRequest(requestKey, reviewer string) error {
+	return d.reviewAgentRequest(requestKey, reviewer, true)
+}
+
+// DenyAgentRequest denies a request awaiting approval, which is then never sent
+func (d *Data) DenyAgentRequest(requestKey, reviewer string) error {
+	return d.reviewAgentRequest(requestKey, reviewer, false)
+}
+
+func (d *Data) reviewAgentRequest(requestKey, reviewer string, approve bool) error {
+	now := time.Now()
+	request, err := d.database.UpdateAgentRequest(requestKey, func(r *schema.AgentRequestRecord) error {
+		if r.Status != schema.RequestStatusAwaitingApproval || r.Cancelled {
+			return ErrNotAwaitingApproval
+		}
+		if r.Requester == reviewer {
+			return ErrSelfApproval
+		}
+
+		r.Reviewer = reviewer
+		r.TimeReviewed = now
+		switch {
+		case !approve:
+			r.Status = schema.RequestStatusDenied
+			r.TimeCompleted = now
+			r.ResponseDetails = "denied by " + reviewer
+		case r.Tag != "":
+			r.Status = schema.RequestStatusActive
+			r.Expires = now.AddDate(0, 0, d.conf.SC.Get(global.ConfigTagRequestDays).Int())
+		default:
+			r.Status = schema.RequestStatusNew
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrNotAwaitingApproval) || errors.Is(err, ErrSelfApproval) {
+			return err
+		}
+		return fmt.Errorf("error reviewing agent request: %w", err)
+	}
+
+	action := "denied"
+	if approve {
+		action = "approved"
+		if request.Tag == "" {
+			metrics.Command(request.Request, metrics.CommandQueued)
+		}
+	}
+
+	d.logger.Info(2773, "agent request "+action, fields.NewFields(
+		fields.NewField("request", request.Request),
+		fields.NewField("id", request.AgentID),
+		fields.NewField("requestID", request.RequestID),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("reviewer", reviewer)))
+
+	d.addApprovalEvent(request, action)
+	return nil
+}
+
+// addApprovalEvent records a request awaiting approval, approved, or denied. Events for tag
+// requests are recorded as server events because they are not for a single agent.
+func (d *Data) addApprovalEvent(request schema.AgentRequestRecord, action string) {
+	agentID := request.AgentID
+	if request.Tag != "" {
+		agentID = schema.ServerEventsID
+	}
+
+	details := map[string]string{
+		"request_id": request.RequestID,
+		"cmd":        request.Request,
+		"requester":  request.Requester,
+	}
+	if request.Tag != "" {
+		details["tag"] = request.Tag
+	}
+	if request.Reviewer != "" {
+		details["reviewer"] = request.Reviewer
+	}
+
+	err := d.AddEvent(schema.AgentEvent{
+		AgentID:   agentID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventApproval,
+		Event:     fmt.Sprintf("%s request %s", request.Request, action),
+		Details:   details})
+	if err != nil {
+		d.logger.Error(2774, "failed to add approval event", fields.NewFields(
+			fields.NewField("requestID", request.RequestID),
+			fields.NewField("error", err.Error())))
+	}
+}
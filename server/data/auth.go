@@ -6,15 +6,26 @@
 package data
 
 import (
+	"errors"
 	"math/rand"
 	"time"
 
+	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/db"
+	"github.com/UnifyEM/UnifyEM/server/global"
 )
 
+// AccountLockedError is returned when authentication is refused because the account is locked
+type AccountLockedError = db.AccountLockedError
+
 // Auth validates a user id and password and returns the role of the user
+// Repeated failures lock the account as configured in the server configuration
 func (d *Data) Auth(id string, pass string) (int, error) {
-	role, err := d.database.CheckAuth(id, pass)
+	maxFailures := d.conf.SC.Get(global.ConfigLoginMaxFailures).Int()
+	lockout := time.Duration(d.conf.SC.Get(global.ConfigLoginLockoutMinutes).Int()) * time.Minute
+
+	role, err := d.database.CheckAuth(id, pass, maxFailures, lockout)
 	if err != nil {
 		// Impose a random delay to prevent timing attacks and make
 		// brute force attacks take longer
@@ -31,7 +42,8 @@ func (d *Data) SetAuth(id string, pass string, role int) error {
 }
 
 // LoginGetToken authenticates a user and returns access and refresh tokens or an error
-func (d *Data) LoginGetToken(user string, pass string) (string, string, error) {
+// Every attempt is recorded in the login audit trail
+func (d *Data) LoginGetToken(user string, pass string, srcIP string) (string, string, error) {
 	var err error
 	var role int
 	var refreshToken, accessToken string
@@ -39,8 +51,10 @@ func (d *Data) LoginGetToken(user string, pass string) (string, string, error) {
 	// Authenticate the user
 	role, err = d.Auth(user, pass)
 	if err != nil {
+		d.auditLogin(user, srcIP, err)
 		return "", "", err
 	}
+	d.auditLogin(user, srcIP, nil)
 
 	accessToken, err = d.createToken(tokenRequest{
 		subject: user,
@@ -62,6 +76,52 @@ func (d *Data) LoginGetToken(user string, pass string) (string, string, error) {
 	return accessToken, refreshToken, nil
 }
 
+// GetLoginAudit returns login audit events within the specified time range, optionally filtered by username
+func (d *Data) GetLoginAudit(startTime, endTime int64, username string) ([]schema.LoginAuditEvent, error) {
+	return d.database.GetLoginAudit(startTime, endTime, username)
+}
+
+// auditLogin records the result of a login attempt. Errors are logged rather than
+// returned so that an audit failure does not change the outcome of the login.
+func (d *Data) auditLogin(user, srcIP string, authErr error) {
+	event := schema.LoginAuditEvent{
+		Time:     time.Now(),
+		Username: user,
+		SourceIP: srcIP,
+		Result:   schema.LoginResultSuccess,
+	}
+
+	if authErr != nil {
+		event.Result = schema.LoginResultFailure
+		event.Details = authErr.Error()
+
+		var locked *AccountLockedError
+		if errors.As(authErr, &locked) {
+			event.LockedUntil = locked.Until
+			if locked.New {
+				event.Result = schema.LoginResultLockout
+			} else {
+				event.Result = schema.LoginResultLocked
+			}
+		}
+	}
+
+	f := fields.NewFields(
+		fields.NewField("id", user),
+		fields.NewField("src_ip", srcIP),
+		fields.NewField("result", event.Result))
+
+	if event.Result == schema.LoginResultLockout {
+		f.Append(fields.NewField("locked_until", event.LockedUntil))
+		d.logger.Warning(2713, "account locked due to repeated login failures", f)
+	}
+
+	if err := d.database.AddLoginAudit(event); err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		d.logger.Error(2714, "failed to record login audit event", f)
+	}
+}
+
 // randomDelay imposes a random delay between 0 and 1000ms
 func randomDelay() {
 	delay := rand.Intn(1000)
@@ -0,0 +1,97 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// snapshotPrefix and snapshotSuffix identify snapshot files so that others are never pruned
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".db"
+)
+
+// Backup writes a consistent copy of the database to w. If size is not nil, it is called with
+// the number of bytes that will be written before writing starts.
+func (d *Data) Backup(w io.Writer, size func(int64)) (int64, error) {
+	return d.database.Backup(w, size)
+}
+
+// Snapshot writes a timestamped copy of the database to the backup path and removes the oldest
+// snapshots beyond the retention count. The copy is written to a temporary file and renamed so
+// that an interrupted snapshot never looks complete.
+func (d *Data) Snapshot() (string, error) {
+	dir := d.conf.SC.Get(global.ConfigBackupPath).String()
+	if dir == "" {
+		return "", fmt.Errorf("%s is not set", global.ConfigBackupPath)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("unable to create backup path: %w", err)
+	}
+
+	name := filepath.Join(dir, snapshotPrefix+time.Now().Format("20060102-150405")+snapshotSuffix)
+	f, err := os.CreateTemp(dir, ".snapshot-*")
+	if err != nil {
+		return "", fmt.Errorf("unable to create snapshot: %w", err)
+	}
+	tmp := f.Name()
+
+	size, err := d.Backup(f, nil)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, name)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return "", fmt.Errorf("unable to write snapshot: %w", err)
+	}
+
+	d.logger.Info(2735, "database snapshot written", fields.NewFields(
+		fields.NewField("file", name),
+		fields.NewField("size", size)))
+
+	d.pruneSnapshots(dir, d.conf.SC.Get(global.ConfigBackupRetention).Int())
+	return name, nil
+}
+
+// pruneSnapshots removes the oldest snapshots so that no more than keep remain
+func (d *Data) pruneSnapshots(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		d.logger.Errorf(2736, "unable to read backup path: %s", err.Error())
+		return
+	}
+
+	var snapshots []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, snapshotPrefix) && strings.HasSuffix(name, snapshotSuffix) {
+			snapshots = append(snapshots, name)
+		}
+	}
+
+	// Timestamps in the names sort chronologically
+	sort.Strings(snapshots)
+	for len(snapshots) > keep {
+		if err = os.Remove(filepath.Join(dir, snapshots[0])); err != nil {
+			d.logger.Errorf(2737, "unable to remove snapshot %s: %s", snapshots[0], err.Error())
+		}
+		snapshots = snapshots[1:]
+	}
+}
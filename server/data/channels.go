@@ -0,0 +1,97 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// SetAgentChannel assigns an upgrade channel to an agent. An empty channel removes
+// the assignment so that the agent follows its tags or the default deploy information.
+func (d *Data) SetAgentChannel(agentID, channel string) error {
+	if !schema.ValidChannel(channel) {
+		return fmt.Errorf("invalid channel: %s", channel)
+	}
+
+	err := d.UpdateAgentMeta(agentID, func(meta *schema.AgentMeta) error {
+		meta.Channel = channel
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.logger.Info(2716, "agent channel set",
+		fields.NewFields(
+			fields.NewField("id", agentID),
+			fields.NewField("channel", channel)))
+	return nil
+}
+
+// SetTagChannel assigns an upgrade channel to all agents with the tag that
+// do not have a channel of their own. An empty channel removes the assignment.
+func (d *Data) SetTagChannel(tag, channel string) error {
+	if !schema.ValidChannel(channel) {
+		return fmt.Errorf("invalid channel: %s", channel)
+	}
+
+	err := d.database.SetTagChannel(tag, channel)
+	if err != nil {
+		return err
+	}
+
+	d.logger.Info(2717, "tag channel set",
+		fields.NewFields(
+			fields.NewField("tag", tag),
+			fields.NewField("channel", channel)))
+	return nil
+}
+
+// GetTagChannels returns a map of tags to upgrade channels
+func (d *Data) GetTagChannels() (map[string]string, error) {
+	return d.database.GetTagChannels()
+}
+
+// AgentChannel returns the upgrade channel for an agent. A channel assigned to the agent
+// takes precedence, followed by the channels assigned to its tags in alphabetical order.
+// An empty string means that the default deploy information is used.
+func (d *Data) AgentChannel(agentID string) string {
+	meta, err := d.database.GetAgentMeta(agentID)
+	if err != nil {
+		return ""
+	}
+
+	if meta.Channel != "" {
+		return meta.Channel
+	}
+
+	if len(meta.Tags) == 0 {
+		return ""
+	}
+
+	tagChannels, err := d.database.GetTagChannels()
+	if err != nil || len(tagChannels) == 0 {
+		return ""
+	}
+
+	tags := make([]string, 0, len(meta.Tags))
+	for _, tag := range meta.Tags {
+		tags = append(tags, strings.ToLower(tag))
+	}
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		if channel, ok := tagChannels[tag]; ok {
+			return channel
+		}
+	}
+	return ""
+}
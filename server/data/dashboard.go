@@ -0,0 +1,86 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// DashboardSummary counts the agents by connectivity, compliance, and triggers, totals the
+// requests that have not been delivered, and includes up to recent of the most recent events
+func (d *Data) DashboardSummary(recent int) (schema.DashboardSummary, error) {
+	summary := schema.DashboardSummary{Generated: time.Now(), Failing: make(map[string]int)}
+	for _, check := range schema.DashboardChecks {
+		summary.Failing[check] = 0
+	}
+
+	agents, err := d.database.GetAllAgentMeta()
+	if err != nil {
+		return summary, fmt.Errorf("error retrieving agents: %w", err)
+	}
+
+	for _, agent := range agents.Agents {
+		summary.Agents++
+		if agent.Offline {
+			summary.Offline++
+		} else {
+			summary.Online++
+		}
+
+		if agent.Triggers.Lost {
+			summary.Triggers.Lost++
+		}
+		if agent.Triggers.Wipe {
+			summary.Triggers.Wipe++
+		}
+		if agent.WipePending != nil {
+			summary.Triggers.WipePending++
+		}
+		if agent.Triggers.Uninstall {
+			summary.Triggers.Uninstall++
+		}
+
+		if agent.Status == nil {
+			summary.NotReported++
+			continue
+		}
+		compliant := true
+		for _, check := range schema.DashboardChecks {
+			if agent.Status.Details.Get(check) == "no" {
+				summary.Failing[check]++
+				compliant = false
+			}
+		}
+		if !compliant {
+			summary.NonCompliant++
+		}
+	}
+
+	queues, err := d.database.GetRequestQueues()
+	if err != nil {
+		return summary, err
+	}
+	for _, queue := range queues {
+		summary.Requests.New += queue.New
+		summary.Requests.Pending += queue.Pending
+		if summary.Requests.Oldest.IsZero() || (!queue.Oldest.IsZero() && queue.Oldest.Before(summary.Requests.Oldest)) {
+			summary.Requests.Oldest = queue.Oldest
+		}
+	}
+
+	summary.RecentEvents = []schema.AgentEvent{}
+	if recent > 0 {
+		page, err := d.QueryEvents(EventQuery{Limit: recent, Newest: true})
+		if err != nil {
+			return summary, fmt.Errorf("error retrieving recent events: %w", err)
+		}
+		summary.RecentEvents = append(summary.RecentEvents, page.Events...)
+	}
+	return summary, nil
+}
@@ -10,19 +10,33 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
 
+	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/hasher"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 	"github.com/UnifyEM/UnifyEM/server/db"
+	"github.com/UnifyEM/UnifyEM/server/geoip"
 	"github.com/UnifyEM/UnifyEM/server/global"
 )
 
+var (
+	ErrDatabaseLocked  = db.ErrLocked
+	ErrDatabaseCorrupt = db.ErrCorrupt
+)
+
 type Data struct {
 	logger            interfaces.Logger
 	conf              *global.ServerConfig
 	database          *db.DB
+	dbStatus          string
 	hasher            *hasher.Hasher
+	jwtMu             sync.RWMutex // protects jwtKey, which is replaced by an import
 	jwtKey            []byte
+	geoMu             sync.Mutex // protects geoDB and geoPath
+	geoDB             *geoip.DB
+	geoPath           string // the GeoIP database that was opened, or that failed to open
+	pruneMu           sync.Mutex
 	BucketAuth        string
 	BucketRequests    string
 	BucketAgentMeta   string
@@ -47,13 +61,19 @@ func New(conf *global.ServerConfig, logger interfaces.Logger) (*Data, error) {
 		conf.SP.Set(global.ConfigJWTKey, jwtKey)
 	}
 
-	// Get database path. If it doesn't exist, it will be created by global.Config()
-	dbPath := conf.SC.Get(global.ConfigDBPath).String()
-	if dbPath == "" {
-		return nil, errors.New("database path missing from configuration")
+	dbFile, err := DatabaseFile(conf)
+	if err != nil {
+		return nil, err
 	}
 
-	dbInstance, err := db.Open(filepath.Join(dbPath, strings.ToLower(global.Name)+".db"), logger)
+	dbStatus := "ok"
+	dbInstance, err := db.Open(dbFile, logger)
+	if errors.Is(err, db.ErrCorrupt) && conf.SC.Get(global.ConfigDBAutoSalvage).Bool() {
+		dbStatus, err = salvage(dbFile, err, logger)
+		if err == nil {
+			dbInstance, err = db.Open(dbFile, logger)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("unable to open or create database: %w", err)
 	}
@@ -62,6 +82,7 @@ func New(conf *global.ServerConfig, logger interfaces.Logger) (*Data, error) {
 		logger:          logger,
 		conf:            conf,
 		database:        dbInstance,
+		dbStatus:        dbStatus,
 		jwtKey:          jwtKey,
 		hasher:          hasher.New(hasher.WithCache(global.MemoryCacheTTL)),
 		BucketAuth:      db.BucketAuth,
@@ -70,6 +91,62 @@ func New(conf *global.ServerConfig, logger interfaces.Logger) (*Data, error) {
 	}, nil
 }
 
+// key returns the key used to sign tokens
+func (d *Data) key() []byte {
+	d.jwtMu.RLock()
+	defer d.jwtMu.RUnlock()
+	return d.jwtKey
+}
+
+// DatabaseFile returns the path of the database file
+func DatabaseFile(conf *global.ServerConfig) (string, error) {
+	// Get database path. If it doesn't exist, it will be created by global.Config()
+	dbPath := conf.SC.Get(global.ConfigDBPath).String()
+	if dbPath == "" {
+		return "", errors.New("database path missing from configuration")
+	}
+	return filepath.Join(dbPath, strings.ToLower(global.Name)+".db"), nil
+}
+
+// CheckDatabase confirms that the database is open and writable
+func (d *Data) CheckDatabase() error {
+	return d.database.Check()
+}
+
+// DatabaseStatus describes the state of the database when it was opened, including any salvage
+func (d *Data) DatabaseStatus() string {
+	return d.dbStatus
+}
+
+// salvage copies what can be read from a corrupted database into a new one and returns a
+// description for DatabaseStatus. If nothing can be salvaged, the original error is returned.
+func salvage(dbFile string, openErr error, logger interfaces.Logger) (string, error) {
+	logger.Warningf(2732, "database is corrupted, attempting to salvage it: %s", openErr.Error())
+
+	report, err := db.Salvage(dbFile)
+	if err != nil {
+		logger.Errorf(2733, "unable to salvage database: %s", err.Error())
+		return "", fmt.Errorf("%w (salvage failed: %w)", openErr, err)
+	}
+
+	logger.Warning(2734, "database salvaged", fields.NewFields(
+		fields.NewField("records", report.Records),
+		fields.NewField("buckets", report.Buckets),
+		fields.NewField("errors", strings.Join(report.Errors, "; ")),
+		fields.NewField("backup", report.Backup)))
+
+	status := fmt.Sprintf("salvaged at startup, %d records recovered, damaged file moved to %s", report.Records, report.Backup)
+	if len(report.Errors) > 0 {
+		status += fmt.Sprintf(", %d buckets incomplete", len(report.Errors))
+	}
+	return status, nil
+}
+
+// DatabaseSize returns the size of the database in bytes
+func (d *Data) DatabaseSize() int64 {
+	return d.database.Size()
+}
+
 // Close anything data-related that requires it.
 func (d *Data) Close() {
 
@@ -82,4 +159,11 @@ func (d *Data) Close() {
 	if d.database != nil {
 		d.database.Close()
 	}
+
+	d.geoMu.Lock()
+	if d.geoDB != nil {
+		_ = d.geoDB.Close()
+		d.geoDB = nil
+	}
+	d.geoMu.Unlock()
 }
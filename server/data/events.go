@@ -5,8 +5,92 @@
 
 package data
 
-import "github.com/UnifyEM/UnifyEM/common/schema"
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/db"
+	"github.com/UnifyEM/UnifyEM/server/notify"
+)
+
+// ErrInvalidCursor is returned when an events cursor can't be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EventQuery selects the events returned by QueryEvents
+type EventQuery struct {
+	AgentID   string // All agents if empty
+	StartTime int64  // Unix time, 0 for no limit
+	EndTime   int64  // Unix time, 0 for no limit
+	EventType string // All types if empty
+	Limit     int    // Maximum number of events, 0 for no limit
+	Cursor    string // Cursor of the previous page, empty for the first page
+	Newest    bool   // Return the newest events first
+}
+
+// EventsPage is a page of events returned by QueryEvents
+type EventsPage struct {
+	Events []schema.AgentEvent
+	Cursor string // Continues after the last event, or is the query's cursor if there are none
+	More   bool   // More events are available now
+}
+
+// QueryEvents returns a page of the events selected by q in time order. The page's Cursor is
+// used to read the next page or to poll for new events.
+func (d *Data) QueryEvents(q EventQuery) (EventsPage, error) {
+	query := db.EventQuery{StartTime: q.StartTime, EndTime: q.EndTime, EventType: q.EventType, Limit: q.Limit, Newest: q.Newest}
+	if q.AgentID != "" {
+		query.AgentIDs = []string{q.AgentID}
+	}
+	if q.Cursor != "" {
+		after, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+		if err != nil || len(after) == 0 {
+			return EventsPage{}, ErrInvalidCursor
+		}
+		query.After = after
+	}
+
+	events, last, more, err := d.database.QueryEvents(query)
+	if err != nil {
+		return EventsPage{}, err
+	}
+
+	page := EventsPage{Events: events, Cursor: q.Cursor, More: more}
+	if last != nil {
+		page.Cursor = base64.RawURLEncoding.EncodeToString(last)
+	}
+	return page, nil
+}
 
 func (d *Data) GetEvents(agentID string, startTime, endTime int64, eventType string) ([]schema.AgentEvent, error) {
 	return d.database.GetEvents(agentID, startTime, endTime, eventType)
 }
+
+// AddEvent stores an event and forwards it to any configured notification sinks
+func (d *Data) AddEvent(event schema.AgentEvent) error {
+	if event.EventID == "" {
+		event.EventID = "E-" + uuid.New().String()
+	}
+
+	err := d.database.AddEvent(event)
+	if err != nil {
+		return err
+	}
+
+	notify.Event(event)
+	return nil
+}
+
+// AddTriggerEvent records that an administrator set a trigger such as lost mode or wipe
+func (d *Data) AddTriggerEvent(agentID, trigger, requester string) error {
+	return d.AddEvent(schema.AgentEvent{
+		AgentID:   agentID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventAlert,
+		Event:     fmt.Sprintf("%s trigger set", trigger),
+		Details:   map[string]string{"trigger": trigger, "requester": requester}})
+}
@@ -0,0 +1,271 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/db"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// ExportVersion is the version of the export bundle format. Bundles with a newer version
+// are refused.
+const ExportVersion = 1
+
+var (
+	ErrImportConflict = errors.New("records in the bundle already exist on this server")
+	ErrImportVersion  = errors.New("unsupported export bundle version")
+)
+
+// hostSettings are server settings that describe the host rather than the installation, so
+// they are neither exported nor imported
+var hostSettings = []string{
+	global.ConfigListen,
+	global.ConfigLogFile,
+	global.ConfigDataPath,
+	global.ConfigFilesPath,
+	global.ConfigDBPath,
+	global.ConfigBackupPath,
+	global.ConfigGeoIPDatabase,
+}
+
+// ExportBundle contains everything needed to move a server to a new host without copying the
+// database. It includes password hashes and the server's private keys, so it must be protected.
+type ExportBundle struct {
+	Version       int                           `json:"version"`
+	Created       time.Time                     `json:"created"`
+	ServerVersion string                        `json:"server_version"`
+	Agents        []schema.AgentMeta            `json:"agents"`
+	FDEKeys       []schema.FDERecoveryKeyRecord `json:"fde_keys"`
+	Users         []schema.UserMeta             `json:"users"`
+	Logins        map[string]db.AuthInfo        `json:"logins"`
+	Groups        []schema.Group                `json:"groups"`
+	TagChannels   map[string]string             `json:"tag_channels"`
+	Config        map[string]map[string]string  `json:"config"` // configuration sets, including the registration token and keys
+	Events        []schema.AgentEvent           `json:"events,omitempty"`
+}
+
+// Export returns a bundle of agents, users, login accounts, groups, and configuration, and
+// optionally every agent's events
+func (d *Data) Export(events bool) (ExportBundle, error) {
+	bundle := ExportBundle{
+		Version:       ExportVersion,
+		Created:       time.Now(),
+		ServerVersion: global.Version,
+		Config:        make(map[string]map[string]string),
+	}
+
+	agents, err := d.database.GetAllAgentMeta()
+	if err != nil {
+		return bundle, fmt.Errorf("unable to export agents: %w", err)
+	}
+	bundle.Agents = agents.Agents
+
+	for _, agent := range bundle.Agents {
+		if key, keyErr := d.database.GetFDERecoveryKey(agent.AgentID); keyErr == nil {
+			bundle.FDEKeys = append(bundle.FDEKeys, key)
+		}
+	}
+
+	if events {
+		eventAgents, eventErr := d.database.EventAgents()
+		if eventErr != nil {
+			return bundle, fmt.Errorf("unable to export events: %w", eventErr)
+		}
+		for _, agentID := range eventAgents {
+			agentEvents, eventErr := d.database.GetEvents(agentID, 0, 0, "")
+			if eventErr != nil {
+				return bundle, fmt.Errorf("unable to export events for %s: %w", agentID, eventErr)
+			}
+			bundle.Events = append(bundle.Events, agentEvents...)
+		}
+	}
+
+	if bundle.Users, err = d.ListUsers(); err != nil {
+		return bundle, fmt.Errorf("unable to export users: %w", err)
+	}
+
+	if bundle.Logins, err = d.database.GetAllAuth(); err != nil {
+		return bundle, fmt.Errorf("unable to export logins: %w", err)
+	}
+
+	if bundle.Groups, err = d.database.GetGroups(); err != nil {
+		return bundle, fmt.Errorf("unable to export groups: %w", err)
+	}
+
+	if bundle.TagChannels, err = d.database.GetTagChannels(); err != nil {
+		return bundle, fmt.Errorf("unable to export tag channels: %w", err)
+	}
+
+	for name, set := range d.conf.C.GetSets() {
+		values := set.GetMap()
+		if name == global.ConfigServerSet {
+			for _, key := range hostSettings {
+				delete(values, key)
+			}
+		}
+		bundle.Config[name] = values
+	}
+
+	return bundle, nil
+}
+
+// Import stores the contents of an export bundle. Unless merge is true, nothing is imported
+// if any agent, user, login account, or group in the bundle already exists. The registration
+// token and keys are only imported if the server has no agents of its own, because replacing
+// them would disconnect its agents. Access tokens issued before the import are rejected, but
+// refresh tokens remain valid so that agents reconnect without registering again. The caller
+// is responsible for saving the configuration.
+func (d *Data) Import(bundle ExportBundle, merge bool) (schema.ImportSummary, error) {
+	var summary schema.ImportSummary
+
+	if bundle.Version < 1 || bundle.Version > ExportVersion {
+		return summary, fmt.Errorf("%w: %d", ErrImportVersion, bundle.Version)
+	}
+
+	existing, err := d.database.GetAllAgentMeta()
+	if err != nil {
+		return summary, fmt.Errorf("unable to read agents: %w", err)
+	}
+
+	if !merge {
+		if conflicts := d.importConflicts(bundle); len(conflicts) > 0 {
+			return summary, fmt.Errorf("%w: %s", ErrImportConflict, strings.Join(conflicts, ", "))
+		}
+	}
+
+	for _, agent := range bundle.Agents {
+		if err = d.database.SetAgentMeta(agent); err != nil {
+			return summary, fmt.Errorf("unable to import agent %s: %w", agent.AgentID, err)
+		}
+		summary.Agents++
+	}
+
+	for _, key := range bundle.FDEKeys {
+		if err = d.database.SetFDERecoveryKey(key); err != nil {
+			return summary, fmt.Errorf("unable to import recovery key for %s: %w", key.AgentID, err)
+		}
+	}
+
+	for _, user := range bundle.Users {
+		if err = d.database.SetData(db.BucketUserMeta, user.User, user); err != nil {
+			return summary, fmt.Errorf("unable to import user %s: %w", user.User, err)
+		}
+		summary.Users++
+	}
+
+	for id, info := range bundle.Logins {
+		if err = d.database.ImportAuth(id, info); err != nil {
+			return summary, fmt.Errorf("unable to import login %s: %w", id, err)
+		}
+		summary.Logins++
+	}
+
+	for _, group := range bundle.Groups {
+		if err = d.database.SetGroup(group); err != nil {
+			return summary, fmt.Errorf("unable to import group %s: %w", group.Name, err)
+		}
+		summary.Groups++
+	}
+
+	for tag, channel := range bundle.TagChannels {
+		if err = d.database.SetTagChannel(tag, channel); err != nil {
+			return summary, fmt.Errorf("unable to import channel for tag %s: %w", tag, err)
+		}
+	}
+
+	// Events are not forwarded to notification sinks, they were forwarded when they occurred
+	for _, event := range bundle.Events {
+		if err = d.database.AddEvent(event); err != nil {
+			return summary, fmt.Errorf("unable to import event %s: %w", event.EventID, err)
+		}
+		summary.Events++
+	}
+
+	summary.KeysImported = len(existing.Agents) == 0
+	d.importConfig(bundle.Config, summary.KeysImported)
+	return summary, nil
+}
+
+// importConflicts returns a description of the records in the bundle that already exist
+func (d *Data) importConflicts(bundle ExportBundle) []string {
+	var conflicts []string
+	count := func(name string, n int) {
+		if n > 0 {
+			conflicts = append(conflicts, fmt.Sprintf("%d %s", n, name))
+		}
+	}
+
+	n := 0
+	for _, agent := range bundle.Agents {
+		if exists, _ := d.database.KeyExists(db.BucketAgentMeta, agent.AgentID); exists {
+			n++
+		}
+	}
+	count("agents", n)
+
+	n = 0
+	for _, user := range bundle.Users {
+		if exists, _ := d.database.KeyExists(db.BucketUserMeta, user.User); exists {
+			n++
+		}
+	}
+	count("users", n)
+
+	n = 0
+	for id := range bundle.Logins {
+		if exists, _ := d.database.AuthExists(id); exists {
+			n++
+		}
+	}
+	count("logins", n)
+
+	n = 0
+	for _, group := range bundle.Groups {
+		if exists, _ := d.database.GroupExists(group.Name); exists {
+			n++
+		}
+	}
+	count("groups", n)
+
+	return conflicts
+}
+
+// importConfig applies the imported configuration sets. Settings that describe the host are
+// skipped, as are the private settings unless keys is true.
+func (d *Data) importConfig(config map[string]map[string]string, keys bool) {
+	for name, values := range config {
+		switch name {
+		case global.ConfigServerSet:
+			for key, value := range values {
+				if !slices.Contains(hostSettings, key) {
+					d.conf.SC.Set(key, value)
+				}
+			}
+		case global.ConfigPrivate:
+			if keys {
+				d.conf.SP.SetStringMap(values)
+			}
+		case schema.ConfigAgentSet:
+			d.conf.AC.SetStringMap(values)
+		}
+	}
+
+	if keys {
+		d.jwtMu.Lock()
+		d.jwtKey = d.conf.SP.Get(global.ConfigJWTKey).Bytes()
+		d.jwtMu.Unlock()
+	}
+
+	// Reject access tokens issued before the import
+	d.conf.SP.Set(global.ConfigTokensNotBefore, time.Now().Unix())
+}
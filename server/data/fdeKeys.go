@@ -0,0 +1,96 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+var ErrNoFDERecoveryKey = errors.New("no recovery key has been escrowed for this agent")
+
+// storeFDERecoveryKey stores the recovery key from an fde_key_escrow response. The key remains
+// encrypted with the server's public key and is only decrypted when it is retrieved.
+func (d *Data) storeFDERecoveryKey(agentID string, response schema.AgentResponse) error {
+	if response.RecoveryKey == "" {
+		return errors.New("no recovery key in response")
+	}
+
+	// Decrypt to verify that the key can be recovered later
+	key, err := d.decryptFDERecoveryKey(response.RecoveryKey)
+	if err != nil {
+		return err
+	}
+
+	return d.database.SetFDERecoveryKey(schema.FDERecoveryKeyRecord{
+		AgentID:   agentID,
+		RequestID: response.RequestID,
+		Type:      key.Type,
+		ID:        key.ID,
+		Escrowed:  time.Now(),
+		Key:       response.RecoveryKey,
+	})
+}
+
+// GetFDERecoveryKey returns an agent's decrypted recovery key. Every retrieval is recorded as
+// an alert event, and the key is not returned if the event cannot be recorded.
+func (d *Data) GetFDERecoveryKey(agentID, requester string) (schema.FDERecoveryKeyRecord, schema.FDERecoveryKey, error) {
+	record, err := d.database.GetFDERecoveryKey(agentID)
+	if err != nil {
+		if strings.Contains(err.Error(), "key not found") {
+			return record, schema.FDERecoveryKey{}, ErrNoFDERecoveryKey
+		}
+		return record, schema.FDERecoveryKey{}, err
+	}
+
+	key, err := d.decryptFDERecoveryKey(record.Key)
+	if err != nil {
+		return record, schema.FDERecoveryKey{}, err
+	}
+
+	err = d.AddEvent(schema.AgentEvent{
+		AgentID:   agentID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventAlert,
+		Event:     "recovery key retrieved",
+		Details:   map[string]string{"type": record.Type, "request_id": record.RequestID, "requester": requester}})
+	if err != nil {
+		return record, schema.FDERecoveryKey{}, fmt.Errorf("failed to record recovery key retrieval: %w", err)
+	}
+	return record, key, nil
+}
+
+// decryptFDERecoveryKey decrypts a recovery key using the server's private key
+func (d *Data) decryptFDERecoveryKey(encrypted string) (schema.FDERecoveryKey, error) {
+	var key schema.FDERecoveryKey
+
+	serverPrivateEnc := d.conf.SP.Get(global.ConfigServerECPrivateEnc).String()
+	if serverPrivateEnc == "" {
+		return key, errors.New("server private encryption key not available")
+	}
+
+	plaintext, err := crypto.Decrypt(encrypted, serverPrivateEnc)
+	if err != nil {
+		return key, fmt.Errorf("failed to decrypt recovery key: %w", err)
+	}
+
+	err = json.Unmarshal(plaintext, &key)
+	if err != nil {
+		return key, fmt.Errorf("failed to unmarshal recovery key: %w", err)
+	}
+
+	if key.Key == "" {
+		return key, errors.New("recovery key is empty")
+	}
+	return key, nil
+}
@@ -0,0 +1,123 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// ErrInvalidFileName is returned when an uploaded file could not be stored under the requested name
+var ErrInvalidFileName = errors.New("invalid file name")
+
+// SaveFile stores a file uploaded by an administrator in our file directory, replacing any file
+// with the same name. The file is written to a temporary file and renamed so that agents never
+// download a partial file. Cached hashes are discarded so that requests for the file are sent
+// the hash of the new file.
+func (d *Data) SaveFile(name string, r io.Reader, maxBytes int64) (schema.HostedFile, error) {
+	file := schema.HostedFile{Name: name}
+
+	if !validFileName(name) || strings.EqualFold(name, global.UploadsDir) || strings.EqualFold(name, global.ReportsDir) ||
+		strings.IndexFunc(name, func(c rune) bool { return unicode.IsControl(c) || c == ':' }) >= 0 {
+		return file, ErrInvalidFileName
+	}
+
+	filesPath := d.conf.SC.Get(global.ConfigFilesPath).String()
+	if filesPath == "" {
+		return file, errors.New("files path not configured")
+	}
+	path := filepath.Join(filesPath, name)
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return file, ErrInvalidFileName
+	}
+
+	// Temporary files start with a . so that they cannot be requested for download_execute or file_push
+	tmp, err := os.CreateTemp(filesPath, ".upload-*.tmp")
+	if err != nil {
+		return file, fmt.Errorf("error creating temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	// Copy one byte more than the maximum to detect oversized files
+	size, err := io.Copy(tmp, io.LimitReader(r, maxBytes+1))
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && size > maxBytes {
+		err = ErrUploadTooLarge
+	}
+	if err == nil {
+		err = os.Chmod(tmpName, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpName, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		if errors.Is(err, ErrUploadTooLarge) {
+			return file, err
+		}
+		return file, fmt.Errorf("error writing file: %w", err)
+	}
+
+	// Discard the cached hash of the file that was replaced
+	d.hasher.ClearCache()
+
+	file.Size = size
+	file.SHA256 = d.getHashOfFile(name)
+	if info, err := os.Stat(path); err == nil {
+		file.Modified = info.ModTime()
+	}
+
+	d.logger.Info(2747, "file stored",
+		fields.NewFields(
+			fields.NewField("name", name),
+			fields.NewField("size", size),
+			fields.NewField("sha256", file.SHA256)))
+
+	return file, nil
+}
+
+// ListFiles returns the files in our file directory, excluding subdirectories and hidden files
+func (d *Data) ListFiles() ([]schema.HostedFile, error) {
+	filesPath := d.conf.SC.Get(global.ConfigFilesPath).String()
+	if filesPath == "" {
+		return nil, errors.New("files path not configured")
+	}
+
+	entries, err := os.ReadDir(filesPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading files directory: %w", err)
+	}
+
+	files := make([]schema.HostedFile, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !validFileName(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, schema.HostedFile{
+			Name:     entry.Name(),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+			SHA256:   d.getHashOfFile(entry.Name()),
+		})
+	}
+	return files, nil
+}
@@ -0,0 +1,92 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/geoip"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// locateIP returns an IP history entry for the address an agent synced from, with its
+// location if a GeoIP database is configured
+func (d *Data) locateIP(ip string) schema.AgentIP {
+	entry := schema.AgentIP{IP: ip}
+
+	d.geoMu.Lock()
+	defer d.geoMu.Unlock()
+
+	g := d.geoIP()
+	if g == nil {
+		return entry
+	}
+
+	location, err := g.Lookup(ip)
+	if err != nil {
+		d.logger.Debug(2745, "GeoIP lookup failed",
+			fields.NewFields(
+				fields.NewField("ip", ip),
+				fields.NewField("error", err.Error())))
+		return entry
+	}
+	entry.Country = location.Country
+	entry.City = location.City
+	return entry
+}
+
+// geoIP returns the configured GeoIP database, or nil if there is none. The database is opened
+// when it is first needed and again if the setting changes. geoMu must be held.
+func (d *Data) geoIP() *geoip.DB {
+	path := d.conf.SC.Get(global.ConfigGeoIPDatabase).String()
+	if path == d.geoPath {
+		return d.geoDB
+	}
+
+	if d.geoDB != nil {
+		_ = d.geoDB.Close()
+		d.geoDB = nil
+	}
+	d.geoPath = path
+	if path == "" {
+		return nil
+	}
+
+	g, err := geoip.Open(path)
+	if err != nil {
+		d.logger.Error(2744, "unable to open GeoIP database",
+			fields.NewFields(
+				fields.NewField("path", path),
+				fields.NewField("error", err.Error())))
+		return nil
+	}
+	d.geoDB = g
+	return g
+}
+
+// checkCountry records an event if an agent synced from a different country than it last did
+func (d *Data) checkCountry(agentID string, previous, current schema.AgentIP) {
+	if previous.Country == "" || current.Country == "" || previous.Country == current.Country {
+		return
+	}
+	if !d.conf.SC.Get(global.ConfigCountryChangeEvents).Bool() {
+		return
+	}
+
+	d.logger.Warning(2746, "agent country changed",
+		fields.NewFields(
+			fields.NewField("id", agentID),
+			fields.NewField("previous_ip", previous.IP),
+			fields.NewField("previous_country", previous.Country),
+			fields.NewField("ip", current.IP),
+			fields.NewField("country", current.Country)))
+
+	d.addConnectivityEvent(agentID, schema.AgentCountryChanged, map[string]string{
+		"previous_ip":      previous.IP,
+		"previous_country": previous.Country,
+		"ip":               current.IP,
+		"country":          current.Country})
+}
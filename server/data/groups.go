@@ -0,0 +1,226 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+var (
+	ErrGroupExists         = errors.New("group already exists")
+	ErrGroupNotFound       = errors.New("group not found")
+	ErrGroupCycle          = errors.New("a group cannot contain itself, directly or through another group")
+	ErrGroupMemberNotFound = errors.New("group member not found")
+	ErrInvalidGroupName    = fmt.Errorf("invalid group name: names may be up to %d letters, numbers, spaces, and . _ -", schema.MaxGroupNameLength)
+)
+
+// CreateGroup creates an empty group
+func (d *Data) CreateGroup(name, description string) (schema.Group, error) {
+	name = strings.TrimSpace(name)
+	if !schema.ValidGroupName(name) {
+		return schema.Group{}, ErrInvalidGroupName
+	}
+
+	exists, err := d.database.GroupExists(name)
+	if err != nil {
+		return schema.Group{}, err
+	}
+	if exists {
+		return schema.Group{}, ErrGroupExists
+	}
+
+	group := schema.Group{
+		Name:        name,
+		Description: description,
+		Agents:      []string{},
+		Groups:      []string{},
+		Created:     time.Now(),
+	}
+
+	err = d.database.SetGroup(group)
+	if err != nil {
+		return schema.Group{}, err
+	}
+	return group, nil
+}
+
+// GetGroup returns a group by name
+func (d *Data) GetGroup(name string) (schema.Group, error) {
+	group, err := d.database.GetGroup(name)
+	if err != nil {
+		return schema.Group{}, ErrGroupNotFound
+	}
+	return group, nil
+}
+
+// GetGroups returns all groups sorted by name
+func (d *Data) GetGroups() ([]schema.Group, error) {
+	groups, err := d.database.GetGroups()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(groups, func(i, j int) bool { return schema.GroupKey(groups[i].Name) < schema.GroupKey(groups[j].Name) })
+	return groups, nil
+}
+
+// DeleteGroup deletes a group and removes it from any groups that contain it
+func (d *Data) DeleteGroup(name string) error {
+	if _, err := d.GetGroup(name); err != nil {
+		return err
+	}
+
+	err := d.database.DeleteGroup(name)
+	if err != nil {
+		return err
+	}
+
+	groups, err := d.database.GetGroups()
+	if err != nil {
+		return err
+	}
+
+	for _, parent := range groups {
+		if !containsGroup(parent.Groups, name) {
+			continue
+		}
+
+		parent.Groups = slices.DeleteFunc(parent.Groups, func(g string) bool { return strings.EqualFold(g, name) })
+		err = d.database.SetGroup(parent)
+		if err != nil {
+			d.logger.Error(2728, "error removing deleted group from parent", fields.NewFields(
+				fields.NewField("group", name),
+				fields.NewField("parent", parent.Name),
+				fields.NewField("error", err.Error())))
+		}
+	}
+	return nil
+}
+
+// AddGroupMembers adds agents and child groups to a group. The agents and groups must exist
+// and a child group may not contain the group, directly or through other groups.
+func (d *Data) AddGroupMembers(name string, agents, groups []string) (schema.Group, error) {
+	group, err := d.GetGroup(name)
+	if err != nil {
+		return schema.Group{}, err
+	}
+
+	for _, agentID := range agents {
+		if err = d.database.AgentExists(agentID); err != nil {
+			return schema.Group{}, fmt.Errorf("%w: agent %s", ErrGroupMemberNotFound, agentID)
+		}
+		if !slices.Contains(group.Agents, agentID) {
+			group.Agents = append(group.Agents, agentID)
+		}
+	}
+
+	for _, childName := range groups {
+		child, err := d.GetGroup(childName)
+		if err != nil {
+			return schema.Group{}, fmt.Errorf("%w: group %s", ErrGroupMemberNotFound, childName)
+		}
+
+		// Adding the child would create a cycle if the group is the child or is reachable from it
+		if schema.GroupKey(child.Name) == schema.GroupKey(group.Name) || d.descendantGroups(child)[schema.GroupKey(group.Name)] {
+			return schema.Group{}, ErrGroupCycle
+		}
+
+		if !containsGroup(group.Groups, child.Name) {
+			group.Groups = append(group.Groups, child.Name)
+		}
+	}
+
+	err = d.database.SetGroup(group)
+	if err != nil {
+		return schema.Group{}, err
+	}
+	return group, nil
+}
+
+// RemoveGroupMembers removes agents and child groups from a group
+func (d *Data) RemoveGroupMembers(name string, agents, groups []string) (schema.Group, error) {
+	group, err := d.GetGroup(name)
+	if err != nil {
+		return schema.Group{}, err
+	}
+
+	group.Agents = slices.DeleteFunc(group.Agents, func(a string) bool { return slices.Contains(agents, a) })
+	group.Groups = slices.DeleteFunc(group.Groups, func(g string) bool { return containsGroup(groups, g) })
+
+	err = d.database.SetGroup(group)
+	if err != nil {
+		return schema.Group{}, err
+	}
+	return group, nil
+}
+
+// GroupAgents returns the agents in a group and all the groups it contains, sorted and
+// without duplicates. Agents that no longer exist are omitted.
+func (d *Data) GroupAgents(name string) ([]string, error) {
+	group, err := d.GetGroup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{schema.GroupKey(group.Name): true}
+	members := make(map[string]bool)
+	d.walkGroup(group, seen, func(g schema.Group) {
+		for _, agentID := range g.Agents {
+			members[agentID] = true
+		}
+	})
+
+	agents := make([]string, 0, len(members))
+	for agentID := range members {
+		if d.database.AgentExists(agentID) == nil {
+			agents = append(agents, agentID)
+		}
+	}
+	sort.Strings(agents)
+	return agents, nil
+}
+
+// descendantGroups returns the keys of all groups contained by the group, directly or indirectly
+func (d *Data) descendantGroups(group schema.Group) map[string]bool {
+	seen := map[string]bool{schema.GroupKey(group.Name): true}
+	descendants := make(map[string]bool)
+	d.walkGroup(group, seen, func(g schema.Group) {
+		if schema.GroupKey(g.Name) != schema.GroupKey(group.Name) {
+			descendants[schema.GroupKey(g.Name)] = true
+		}
+	})
+	return descendants
+}
+
+// walkGroup calls fn for the group and each group it contains. Groups in seen are skipped
+// so that each group is visited once. Child groups that no longer exist are ignored.
+func (d *Data) walkGroup(group schema.Group, seen map[string]bool, fn func(schema.Group)) {
+	fn(group)
+	for _, childName := range group.Groups {
+		key := schema.GroupKey(childName)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		child, err := d.database.GetGroup(childName)
+		if err == nil {
+			d.walkGroup(child, seen, fn)
+		}
+	}
+}
+
+// containsGroup returns true if the list contains the group name, which is not case-sensitive
+func containsGroup(list []string, name string) bool {
+	return slices.ContainsFunc(list, func(g string) bool { return strings.EqualFold(g, name) })
+}
@@ -9,23 +9,57 @@ import (
 	"os"
 	"strings"
 
+	"github.com/UnifyEM/UnifyEM/common/hasher"
 	"github.com/UnifyEM/UnifyEM/server/global"
 )
 
+// signatureSuffix is appended to the name of a hosted file to find its detached signature
+const signatureSuffix = ".sig"
+
 // getHashOfFile builds a path to the file in our file directory, checks if it exists, and if so
 // returns the base64 encoded SHA256 hash of the file. Otherwise, it returns an empty string
 func (d *Data) getHashOfFile(file string) string {
-	if file == "" {
+	path := d.hostedFile(file)
+	if path == "" {
 		return ""
 	}
 
-	// If there are any path separators of any kind in the filename, reject the file
-	if strings.ContainsAny(file, string(os.PathSeparator)+"/"+"\\") {
-		return ""
+	// Get the hash of the file
+	return d.hasher.SHA256File(path).Base64()
+}
+
+// setPayloadParameters adds the hash, hash algorithm, and signature to the parameters of a
+// download_execute request for a file in our file directory. The signature is read from a file
+// with the same name and a .sig suffix, if present, unless the requester provided one. For a
+// file we do not host, the hash provided by the requester, if any, is passed to the agent.
+func (d *Data) setPayloadParameters(parameters map[string]string, file string) {
+	path := d.hostedFile(file)
+	if path == "" {
+		// The agent will follow its policy if there is no hash or signature
+		if _, ok := parameters["hash"]; !ok {
+			parameters["hash"] = ""
+		}
+		return
 	}
 
-	// If the file starts with a ., reject it
-	if strings.HasPrefix(file, ".") {
+	alg := parameters["hash_alg"]
+	if alg == "" {
+		alg = hasher.SHA256
+	}
+	parameters["hash_alg"] = alg
+	parameters["hash"] = d.hasher.File(alg, path).Base64()
+
+	if parameters["signature"] == "" {
+		if sig, err := os.ReadFile(path + signatureSuffix); err == nil {
+			parameters["signature"] = strings.TrimSpace(string(sig))
+		}
+	}
+}
+
+// hostedFile returns the path to the file in our file directory, or an empty string if the name
+// is not valid or the file does not exist
+func (d *Data) hostedFile(file string) string {
+	if !validFileName(file) {
 		return ""
 	}
 
@@ -44,7 +78,20 @@ func (d *Data) getHashOfFile(file string) string {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return ""
 	}
+	return path
+}
 
-	// Get the hash of the file
-	return d.hasher.SHA256File(path).Base64()
+// validFileName returns true if the name can only refer to a file directly in our file directory
+func validFileName(file string) bool {
+	if file == "" {
+		return false
+	}
+
+	// If there are any path separators of any kind in the filename, reject the file
+	if strings.ContainsAny(file, string(os.PathSeparator)+"/"+"\\") {
+		return false
+	}
+
+	// If the file starts with a ., reject it
+	return !strings.HasPrefix(file, ".")
 }
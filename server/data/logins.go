@@ -0,0 +1,145 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/db"
+)
+
+// Login accounts are stored separately from user metadata, and the auth bucket also holds
+// the credentials of agents. These functions manage the accounts used to log in to the API
+// and are used by the server's console commands to recover access while the service is stopped.
+
+// ErrLoginNotFound is returned when a login account does not exist
+var ErrLoginNotFound = errors.New("login account not found")
+
+// LoginAccount describes an account that may log in to the API
+type LoginAccount struct {
+	User        string
+	Role        int
+	Active      bool
+	LockedUntil time.Time
+	LastAuth    time.Time
+	LastFail    time.Time
+	FailCount   int
+}
+
+// Locked returns true if the account is locked out
+func (l LoginAccount) Locked() bool {
+	return time.Now().Before(l.LockedUntil)
+}
+
+// ListLogins returns the login accounts, excluding agents, sorted by name
+func (d *Data) ListLogins() ([]LoginAccount, error) {
+	all, err := d.database.GetAllAuth()
+	if err != nil {
+		return nil, err
+	}
+
+	var logins []LoginAccount
+	for id, info := range all {
+		if info.Role == schema.RoleAgent {
+			continue
+		}
+		logins = append(logins, LoginAccount{
+			User:        id,
+			Role:        info.Role,
+			Active:      info.Active,
+			LockedUntil: info.LockedUntil,
+			LastAuth:    info.LastAuth,
+			LastFail:    info.LastFail,
+			FailCount:   info.FailCount,
+		})
+	}
+
+	sort.Slice(logins, func(i, j int) bool { return logins[i].User < logins[j].User })
+	return logins, nil
+}
+
+// UnlockLogin clears the lockout and failure count of a login account
+func (d *Data) UnlockLogin(user string) error {
+	if _, err := d.getLogin(user); err != nil {
+		return err
+	}
+	return d.database.UnlockAuth(user)
+}
+
+// SetLoginRole changes the role of a login account. Tokens already issued carry the old role,
+// so they are revoked.
+func (d *Data) SetLoginRole(user string, role int) error {
+	if _, err := d.getLogin(user); err != nil {
+		return err
+	}
+
+	name := schema.RoleName(role)
+	if schema.RoleByName(name) != role {
+		return fmt.Errorf("invalid role: %d", role)
+	}
+
+	if err := d.database.SetAuthRole(user, role); err != nil {
+		return err
+	}
+
+	// Keep the role shown by user management in step
+	if meta, err := d.GetUserByID(user); err == nil && meta.Role != "" {
+		meta.Role = strings.ToLower(name)
+		meta.LastUpdated = time.Now()
+		if err = d.database.SetData(db.BucketUserMeta, user, meta); err != nil {
+			return err
+		}
+	}
+
+	return d.revokeUserSessions(user)
+}
+
+// DeleteLogin removes a login account and revokes its tokens. Any user metadata is kept, but no
+// longer shows a role.
+func (d *Data) DeleteLogin(user string) error {
+	if _, err := d.getLogin(user); err != nil {
+		return err
+	}
+
+	if err := d.database.DeleteAuth(user); err != nil {
+		return err
+	}
+
+	if meta, err := d.GetUserByID(user); err == nil && meta.Role != "" {
+		meta.Role = ""
+		meta.LastUpdated = time.Now()
+		if err = d.database.SetData(db.BucketUserMeta, user, meta); err != nil {
+			return err
+		}
+	}
+
+	return d.revokeUserSessions(user)
+}
+
+// getLogin returns the authentication information of a login account, refusing agents
+func (d *Data) getLogin(user string) (db.AuthInfo, error) {
+	exists, err := d.database.AuthExists(user)
+	if err != nil {
+		return db.AuthInfo{}, err
+	}
+	if !exists {
+		return db.AuthInfo{}, ErrLoginNotFound
+	}
+
+	info, err := d.database.GetAuth(user)
+	if err != nil {
+		return db.AuthInfo{}, err
+	}
+	if info.Role == schema.RoleAgent {
+		return db.AuthInfo{}, ErrLoginNotFound
+	}
+	return info, nil
+}
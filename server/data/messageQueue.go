@@ -0,0 +1,25 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// QueueMessage stores a message from an agent until it has been processed
+func (d *Data) QueueMessage(message schema.AgentMessage) (uint64, error) {
+	return d.database.QueueMessage(message)
+}
+
+// DeleteQueuedMessage removes a message from an agent that has been processed
+func (d *Data) DeleteQueuedMessage(id uint64) error {
+	return d.database.DeleteQueuedMessage(id)
+}
+
+// QueuedMessages calls fn for each stored message from an agent that has not been processed
+func (d *Data) QueuedMessages(fn func(id uint64, message schema.AgentMessage)) error {
+	return d.database.QueuedMessages(fn)
+}
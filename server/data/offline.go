@@ -0,0 +1,66 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// CheckOfflineAgents marks agents that have not synced within the offline threshold as
+// offline and records an event for each one the first time it crosses the threshold
+func (d *Data) CheckOfflineAgents() {
+	minutes := d.conf.SC.Get(global.ConfigAgentOfflineThreshold).Int()
+	if minutes <= 0 {
+		return
+	}
+
+	agents, err := d.database.SetAgentsOffline(time.Now().Add(-time.Duration(minutes) * time.Minute))
+	if err != nil {
+		d.logger.Error(2740, "error checking for offline agents",
+			fields.NewFields(fields.NewField("error", err.Error())))
+	}
+
+	for _, agent := range agents {
+		d.logger.Warning(2741, "agent offline",
+			fields.NewFields(
+				fields.NewField("id", agent.AgentID),
+				fields.NewField("last_seen", agent.LastSeen),
+				fields.NewField("last_ip", agent.LastIP)))
+
+		d.addConnectivityEvent(agent.AgentID, schema.AgentWentOffline, map[string]string{
+			"last_seen": agent.LastSeen.Format(time.RFC3339),
+			"last_ip":   agent.LastIP})
+	}
+}
+
+// agentBackOnline records an event for an agent that synced after being marked offline
+func (d *Data) agentBackOnline(agentID, ip string) {
+	d.logger.Info(2742, "agent back online",
+		fields.NewFields(
+			fields.NewField("id", agentID),
+			fields.NewField("src_ip", ip)))
+
+	d.addConnectivityEvent(agentID, schema.AgentBackOnline, map[string]string{"ip": ip})
+}
+
+func (d *Data) addConnectivityEvent(agentID, event string, details map[string]string) {
+	err := d.AddEvent(schema.AgentEvent{
+		AgentID:   agentID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventConnectivity,
+		Event:     event,
+		Details:   details})
+	if err != nil {
+		d.logger.Error(2743, "error recording connectivity event",
+			fields.NewFields(
+				fields.NewField("id", agentID),
+				fields.NewField("error", err.Error())))
+	}
+}
@@ -0,0 +1,31 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// agentPatches stores the patch status included in a patch_status or patch_install response
+func (d *Data) agentPatches(agentID string, response schema.AgentResponse) error {
+
+	// response.Data was decoded without knowing its type
+	j, err := json.Marshal(response.Data)
+	if err != nil {
+		return fmt.Errorf("unable to marshal patch status: %w", err)
+	}
+
+	var status schema.PatchStatus
+	err = json.Unmarshal(j, &status)
+	if err != nil {
+		return fmt.Errorf("unable to unmarshal patch status: %w", err)
+	}
+
+	return d.database.UpdateAgentPatches(agentID, status)
+}
@@ -0,0 +1,37 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// pingLatency calculates the latency of a ping request whose response arrived at the specified
+// time. Agents that predate latency reporting send no data, so only the server's measurements
+// are available for them.
+func pingLatency(request *schema.AgentRequestRecord, data any, now time.Time) *schema.RequestLatency {
+	latency := &schema.RequestLatency{RoundTripMs: now.Sub(request.TimeCreated).Milliseconds()}
+	if !request.TimeSent.IsZero() {
+		latency.QueueMs = request.TimeSent.Sub(request.TimeCreated).Milliseconds()
+	}
+
+	// response.Data was decoded without knowing its type
+	j, err := json.Marshal(data)
+	if err != nil {
+		return latency
+	}
+
+	var ping schema.PingData
+	if json.Unmarshal(j, &ping) == nil {
+		latency.AgentMs = ping.ElapsedMs
+		latency.AgentReceived = ping.Received
+		latency.AgentResponded = ping.Responded
+	}
+	return latency
+}
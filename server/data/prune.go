@@ -6,40 +6,81 @@
 package data
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/server/global"
 )
 
-// PruneDB removes old data from the database
-// It is intended to run as a goroutine and therefore
+// PruneDB removes old data from the database, compacts it if enough of it is free space, and
+// returns a summary. It is intended to run as a goroutine and therefore
 // logs and handles its own errors. Pruning also deletes any
 // bad records that are found, including if deserialization fails
-func (d *Data) PruneDB() {
+func (d *Data) PruneDB() schema.PruneSummary {
+	// Scheduled and manual pruning don't run at the same time
+	d.pruneMu.Lock()
+	defer d.pruneMu.Unlock()
+
 	agentRetention := d.conf.SC.Get(global.ConfigAgentRetention).Int()
 	requestRetention := d.conf.SC.Get(global.ConfigRequestRetention).Int()
+	failedRetention := d.conf.SC.Get(global.ConfigRequestFailedRetention).Int()
 	eventRetention := d.conf.SC.Get(global.ConfigEventRetention).Int()
+	auditRetention := d.conf.SC.Get(global.ConfigLoginAuditRetention).Int()
+	compactThreshold := d.conf.SC.Get(global.ConfigCompactThreshold).Int()
 	startTime := time.Now()
+	summary := schema.PruneSummary{SizeBefore: d.database.Size()}
 
 	d.logger.Info(3000, "Pruning database started", fields.NewFields(
 		fields.NewField(global.ConfigAgentRetention, agentRetention),
 		fields.NewField(global.ConfigRequestRetention, requestRetention),
-		fields.NewField(global.ConfigEventRetention, eventRetention)))
+		fields.NewField(global.ConfigRequestFailedRetention, failedRetention),
+		fields.NewField(global.ConfigEventRetention, eventRetention),
+		fields.NewField(global.ConfigLoginAuditRetention, auditRetention)))
 
+	var err error
 	if agentRetention > 0 {
-		d.pruneError(d.database.PruneAgents(agentRetention))
+		summary.Agents, err = d.database.PruneAgents(agentRetention)
+		d.pruneError(err)
 	}
 
-	if requestRetention > 0 {
-		d.pruneError(d.database.PruneAgentRequests(requestRetention))
-	}
+	summary.RequestsCompleted, summary.RequestsFailed, err = d.database.PruneRequests(requestRetention, failedRetention)
+	d.pruneError(err)
 
 	if eventRetention > 0 {
-		d.pruneError(d.database.PruneEvents(eventRetention))
+		summary.Events, err = d.database.PruneEvents(eventRetention)
+		d.pruneError(err)
+	}
+
+	if auditRetention > 0 {
+		summary.LoginAudit, err = d.database.PruneLoginAudit(auditRetention)
+		d.pruneError(err)
+	}
+
+	// Revoked tokens are only recorded until they expire
+	d.pruneError(d.database.PruneRevokedTokens())
+
+	// Return the space freed to the file system if enough of the database is free
+	report, err := d.database.Compact(compactThreshold)
+	if err != nil {
+		d.logger.Warningf(3003, "error compacting database: %s", err.Error())
 	}
+	summary.Compacted = report.Compacted
 
-	d.logger.Infof(3001, "Pruning database completed in %.2f seconds", time.Since(startTime).Seconds())
+	summary.SizeAfter = d.database.Size()
+	summary.Seconds = time.Since(startTime).Seconds()
+	d.logger.Info(3001, fmt.Sprintf("Pruning database completed in %.2f seconds", summary.Seconds), fields.NewFields(
+		fields.NewField("agents", summary.Agents),
+		fields.NewField("events", summary.Events),
+		fields.NewField("login_audit", summary.LoginAudit),
+		fields.NewField("requests_completed", summary.RequestsCompleted),
+		fields.NewField("requests_failed", summary.RequestsFailed),
+		fields.NewField("free_bytes", report.FreeBytes),
+		fields.NewField("compacted", summary.Compacted),
+		fields.NewField("size_before", summary.SizeBefore),
+		fields.NewField("size_after", summary.SizeAfter)))
+	return summary
 }
 
 func (d *Data) pruneError(err error) {
@@ -7,10 +7,12 @@ package data
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,6 +28,7 @@ type RegistrationData struct {
 	RefreshToken    string
 	ServerPublicSig string
 	ServerPublicEnc string
+	Migrated        bool // the agent kept the ID it had on another server
 }
 
 // Register validates a registration token and returns an agent ID and password or a failure
@@ -36,19 +39,32 @@ func (d *Data) Register(regRequest schema.AgentRegisterRequest, remoteIP string)
 	// Get the registration token from the config
 	expectedToken := d.conf.SP.Get(global.ConfigRegToken).String()
 
-	// Check for an empty token
-	if expectedToken == "" {
-		return r, errors.New("registration token is empty")
+	// A migration token is accepted in place of the registration token
+	migration := d.validMigrationToken(regRequest.Token)
+	if !migration {
+		// Check for an empty token
+		if expectedToken == "" {
+			return r, errors.New("registration token is empty")
+		}
+
+		// Check if the registration token is correct
+		if regRequest.Token != expectedToken {
+			return r, errors.New("invalid registration token")
+		}
 	}
 
-	// Check if the registration token is correct
-	if regRequest.Token != expectedToken {
-		return r, errors.New("invalid registration token")
+	if regRequest.AgentID != "" {
+		// Agents moving from another server keep their ID, which requires a migration token
+		if !migration {
+			return r, errors.New("an agent ID can only be kept with a migration token")
+		}
+		r.AgentID, err = d.migratedAgentID(regRequest.AgentID)
+		r.Migrated = true
+	} else {
+		// Always generate a new agent ID. While it is tempting to reuse the agent ID,
+		// that would allow attacker to impersonate an agent.
+		r.AgentID, err = d.generateAgentID()
 	}
-
-	// Always generate a new agent ID. While it is tempting to reuse the agent ID,
-	// that would allow attacker to impersonate an agent.
-	r.AgentID, err = d.generateAgentID()
 	if err != nil {
 		return RegistrationData{}, err
 	}
@@ -124,6 +140,33 @@ func (d *Data) generateAgentID() (string, error) {
 	return id, nil
 }
 
+// validMigrationToken returns true if token is the migration token and it has not expired
+func (d *Data) validMigrationToken(token string) bool {
+	expected := d.conf.SP.Get(global.ConfigMigrationToken).String()
+	if expected == "" || time.Now().Unix() >= d.conf.SP.Get(global.ConfigMigrationTokenExpires).Int64() {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// migratedAgentID checks the ID of an agent moving from another server. IDs that are already
+// registered are refused, so that a migration token can't be used to impersonate an agent that
+// is registered with this server.
+func (d *Data) migratedAgentID(id string) (string, error) {
+	if !strings.HasPrefix(id, "A-") || uuid.Validate(id[2:]) != nil {
+		return "", fmt.Errorf("invalid agent ID %s", id)
+	}
+
+	exists, err := d.database.KeyExists(db.BucketAgentMeta, id)
+	if err != nil {
+		return "", fmt.Errorf("unable to verify agent ID: %w", err)
+	}
+	if exists {
+		return "", fmt.Errorf("agent ID %s is already registered", id)
+	}
+	return id, nil
+}
+
 // GenerateToken creates a random token and encodes it in base64
 func (d *Data) generateToken() (string, error) {
 
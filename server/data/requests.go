@@ -6,6 +6,7 @@
 package data
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
@@ -17,8 +18,12 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/schema/commands"
 	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/metrics"
 )
 
+// ErrRequestNotExpired is returned when attempting to requeue a request that has not expired
+var ErrRequestNotExpired = errors.New("only expired requests can be requeued")
+
 // GetAgentRequest returns a single request for an agent
 func (d *Data) GetAgentRequest(requestKey string) (schema.AgentRequest, error) {
 	request, err := d.database.GetAgentRequest(requestKey)
@@ -35,18 +40,31 @@ func (d *Data) GetAgentRequest(requestKey string) (schema.AgentRequest, error) {
 }
 
 // GetRequestRecord returns the entire record for an administrator
-// A list is used for consistency with GetAllRequestRecords
+// A list is used for consistency with GetAllRequestRecords. A tag request is
+// followed by the requests delivered to each agent.
 func (d *Data) GetRequestRecord(requestKey string) (schema.AgentRequestRecordList, error) {
 	request, err := d.database.GetAgentRequest(requestKey)
 	if err != nil {
 		return schema.AgentRequestRecordList{}, fmt.Errorf("error getting agent request: %w", err)
 	}
 
-	return schema.AgentRequestRecordList{Requests: []schema.AgentRequestRecord{request}}, nil
+	list := schema.AgentRequestRecordList{Requests: []schema.AgentRequestRecord{request}}
+	for _, requestID := range request.Deliveries {
+		if child, err := d.database.GetAgentRequest(requestID); err == nil {
+			list.Requests = append(list.Requests, child)
+		}
+	}
+	d.addTagOutcomes(&list)
+	return list, nil
 }
 
 func (d *Data) GetRequestRecords() (schema.AgentRequestRecordList, error) {
-	return d.database.GetAllRequestRecords()
+	list, err := d.database.GetAllRequestRecords()
+	if err != nil {
+		return list, err
+	}
+	d.addTagOutcomes(&list)
+	return list, nil
 }
 
 // GetAgentRequestRecords returns all request records for a given agent
@@ -59,9 +77,19 @@ func (d *Data) DeleteAgentRequest(requestKey string) error {
 	return d.database.DeleteAgentRequest(requestKey)
 }
 
-// CancelAgentRequest cancels a pending request by ID
+// CancelAgentRequest cancels a pending request by ID. Cancelling a tag request stops it from
+// being delivered and cancels the deliveries that are still pending.
 func (d *Data) CancelAgentRequest(requestKey string) error {
-	return d.database.CancelAgentRequest(requestKey)
+	err := d.database.CancelAgentRequest(requestKey)
+	if err != nil {
+		return err
+	}
+
+	request, err := d.database.GetAgentRequest(requestKey)
+	if err != nil || request.Tag == "" {
+		return err
+	}
+	return d.cancelTagDeliveries(request)
 }
 
 // CancelAgentRequests cancels all pending requests for the specified agent
@@ -74,6 +102,9 @@ func (d *Data) CancelAgentRequests(agentID string) error {
 func (d *Data) GetAgentRequests(agentID string, markSent bool) ([]schema.AgentRequest, error) {
 	var requestList []schema.AgentRequest
 
+	// Add any tag requests for the tags the agent currently carries
+	d.deliverTagRequests(agentID)
+
 	// Get a list of requests for this agent
 	requests, err := d.database.GetAgentRequests(agentID)
 	if err != nil {
@@ -91,6 +122,12 @@ func (d *Data) GetAgentRequests(agentID string, markSent bool) ([]schema.AgentRe
 	// Iterate through the requests and select the ones to send
 	for _, request := range requests {
 
+		// Requests are never sent before they are approved. They aren't new or pending, so this
+		// only makes it explicit.
+		if request.Status == schema.RequestStatusAwaitingApproval || request.Status == schema.RequestStatusDenied {
+			continue
+		}
+
 		// Assume not wanted
 		selected := false
 
@@ -100,13 +137,20 @@ func (d *Data) GetAgentRequests(agentID string, markSent bool) ([]schema.AgentRe
 		}
 
 		// Check if the request is pending, hasn't been sent for at least global.RequestRetryTime minutes,
-		// and hasn't failed more than global.RequestRetries times
-		if request.Status == schema.RequestStatusPending {
+		// and hasn't failed more than global.RequestRetries times. Requests that the agent has
+		// acknowledged are still running on the agent and are not sent again.
+		if request.Status == schema.RequestStatusPending && request.TimeAcknowledged.IsZero() {
 			if request.LastUpdated.Before(time.Now().Add(-retryDelay*time.Minute)) && request.SendCount < retryLimit {
 				selected = true
 			}
 		}
 
+		// Requests that have been sent the maximum number of times without a response expire
+		if requestExpired(request, retryLimit, retryDelay*time.Minute) {
+			d.expireRequest(request)
+			continue
+		}
+
 		if selected {
 			// Validate the command before sending it to the agent
 			err = commands.Validate(request.Request, request.Parameters)
@@ -123,6 +167,7 @@ func (d *Data) GetAgentRequests(agentID string, markSent bool) ([]schema.AgentRe
 
 				// Mark the request as failed
 				request.Status = schema.RequestStatusInvalid
+				request.TimeCompleted = time.Now()
 				updateErr := d.database.SetAgentRequest(request)
 				if updateErr != nil {
 					// Log the error but continue
@@ -138,23 +183,13 @@ func (d *Data) GetAgentRequests(agentID string, markSent bool) ([]schema.AgentRe
 			} else {
 
 				// If the request involves downloading a file add a hash if the file exist in our server
-				if request.Request == commands.DownloadExecute {
-					dlURL, ok := request.Parameters["url"]
-					if !ok {
-						d.logger.Error(2705, "download_execute command missing url parameter",
-							fields.NewFields(
-								fields.NewField("id", agentID),
-								fields.NewField("requestID", request.RequestID),
-								fields.NewField("requester", request.Requester),
-							))
-						continue
-					}
+				if request.Request == commands.DownloadExecute || request.Request == commands.FilePush {
 
-					// Obtain the filename from the URL
+					// Obtain the filename from the file parameter or the URL
 					var filename string
-					filename, err = getFilename(dlURL)
+					filename, err = requestFilename(request.Parameters)
 					if err != nil {
-						d.logger.Error(2705, "error parsing filename from download URL",
+						d.logger.Error(2705, "error obtaining filename from download request",
 							fields.NewFields(
 								fields.NewField("error", err.Error()),
 								fields.NewField("id", agentID),
@@ -166,17 +201,30 @@ func (d *Data) GetAgentRequests(agentID string, markSent bool) ([]schema.AgentRe
 
 					// If the file does not exist, GetHash will return an empty
 					// string. We'll let the agent follow its policy
-					request.Parameters["hash"] = d.getHashOfFile(filename)
+					if request.Request == commands.DownloadExecute {
+						d.setPayloadParameters(request.Parameters, filename)
+					} else {
+						request.Parameters["hash"] = d.getHashOfFile(filename)
+					}
 				}
 
 				// if the request is an upgrade, sent the hash of the upgrade information file
 				// If the file doesn't exist, this will add an empty string and the agent can
 				// follow it's policy with respect to downloading the file
 				if request.Request == commands.Upgrade {
+					// Use the channel specified in the request or the channel assigned to the agent
+					channel, ok := request.Parameters["channel"]
+					if !ok {
+						channel = d.AgentChannel(agentID)
+						if channel != "" {
+							request.Parameters["channel"] = channel
+						}
+					}
+
 					// Hash needs to be suppressed for legacy clients
 					h, ok := request.Parameters["hash"]
 					if !ok {
-						request.Parameters["hash"] = d.getHashOfFile(schema.DeployInfoFile)
+						request.Parameters["hash"] = d.getHashOfFile(schema.DeployInfoFileName(channel))
 					} else {
 						if strings.ToLower(h) == "false" {
 							delete(request.Parameters, "hash")
@@ -186,6 +234,21 @@ func (d *Data) GetAgentRequests(agentID string, markSent bool) ([]schema.AgentRe
 					}
 				}
 
+				// The agent only moves to another server if this server signed the request
+				if request.Request == commands.SetServerURL {
+					err = d.signServerMove(agentID, request.RequestID, request.Parameters)
+					if err != nil {
+						d.logger.Error(2754, "error signing server move",
+							fields.NewFields(
+								fields.NewField("error", err.Error()),
+								fields.NewField("id", agentID),
+								fields.NewField("requestID", request.RequestID),
+								fields.NewField("requester", request.Requester),
+							))
+						continue
+					}
+				}
+
 				// Add the request to the list
 				requestList = append(requestList, schema.AgentRequest{
 					Created:    request.TimeCreated,
@@ -196,9 +259,13 @@ func (d *Data) GetAgentRequests(agentID string, markSent bool) ([]schema.AgentRe
 				})
 
 				// Update the request status
+				if request.TimeSent.IsZero() {
+					request.TimeSent = time.Now()
+				}
 				if markSent && !request.AckRequired {
 					// Only mark as complete if acknowledgment is not required
 					request.Status = schema.RequestStatusComplete
+					request.TimeCompleted = time.Now()
 				} else {
 					request.Status = schema.RequestStatusPending
 				}
@@ -222,7 +289,105 @@ func (d *Data) GetAgentRequests(agentID string, markSent bool) ([]schema.AgentRe
 	return requestList, nil
 }
 
-// AddAgentRequest adds a new agent for an agent
+// ExpireAgentRequests expires pending requests that have been sent the maximum number of
+// times without a response. GetAgentRequests does the same when an agent syncs, but this
+// also catches requests for agents that no longer sync.
+func (d *Data) ExpireAgentRequests() {
+	requests, err := d.database.GetAllRequestRecords()
+	if err != nil {
+		d.logger.Error(2721, "error getting agent requests", fields.NewFields(
+			fields.NewField("error", err.Error())))
+		return
+	}
+
+	retryLimit := d.conf.SC.Get(global.ConfigRequestRetries).Int()
+	retryDelay := time.Duration(d.conf.SC.Get(global.ConfigRequestRetryDelay).Int()) * time.Minute
+
+	for _, request := range requests.Requests {
+		if requestExpired(request, retryLimit, retryDelay) {
+			d.expireRequest(request)
+		}
+	}
+
+	d.expireTagRequests()
+}
+
+// RequeueAgentRequest returns an expired request to the queue so that it is sent again
+func (d *Data) RequeueAgentRequest(requestKey string) error {
+	request, err := d.database.GetAgentRequest(requestKey)
+	if err != nil {
+		return fmt.Errorf("error getting agent request: %w", err)
+	}
+
+	if request.Status != schema.RequestStatusExpired {
+		return ErrRequestNotExpired
+	}
+
+	request.Status = schema.RequestStatusNew
+	request.SendCount = 0
+	request.TimeSent = time.Time{}
+	request.TimeCompleted = time.Time{}
+	request.ResponseDetails = ""
+	err = d.database.SetAgentRequest(request)
+	if err != nil {
+		return fmt.Errorf("failed to requeue agent request: %w", err)
+	}
+
+	metrics.Command(request.Request, metrics.CommandQueued)
+	return nil
+}
+
+// requestExpired returns true if a pending request has been sent the maximum number of
+// times without being acknowledged and the retry delay has elapsed since it was last sent
+func requestExpired(request schema.AgentRequestRecord, retryLimit int, retryDelay time.Duration) bool {
+	return request.Status == schema.RequestStatusPending &&
+		request.TimeAcknowledged.IsZero() &&
+		request.SendCount >= retryLimit &&
+		request.LastUpdated.Before(time.Now().Add(-retryDelay))
+}
+
+// expireRequest marks a request as expired and records an event so that
+// administrators can see which requests never reached the agent
+func (d *Data) expireRequest(request schema.AgentRequestRecord) {
+	f := fields.NewFields(
+		fields.NewField("request", request.Request),
+		fields.NewField("id", request.AgentID),
+		fields.NewField("requestID", request.RequestID),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("attempts", request.SendCount),
+	)
+
+	request.Status = schema.RequestStatusExpired
+	request.TimeCompleted = time.Now()
+	request.ResponseDetails = fmt.Sprintf("no response after %d attempts", request.SendCount)
+	err := d.database.SetAgentRequest(request)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		d.logger.Error(2722, "error marking agent request as expired", f)
+		return
+	}
+
+	d.logger.Warning(2723, "agent request expired", f)
+	metrics.Command(request.Request, metrics.CommandExpired)
+
+	err = d.AddEvent(schema.AgentEvent{
+		AgentID:   request.AgentID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventAlert,
+		Event:     fmt.Sprintf("%s request expired", request.Request),
+		Details: map[string]string{
+			"request_id": request.RequestID,
+			"requester":  request.Requester,
+			"details":    request.ResponseDetails,
+		}})
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		d.logger.Error(2724, "failed to add event to event store", f)
+	}
+}
+
+// AddAgentRequest adds a new request for an agent, or for the agents with a tag if the
+// parameters include a tag instead of an agent ID
 func (d *Data) AddAgentRequest(request schema.AgentRequest) (string, error) {
 
 	// Start with the structure fields
@@ -230,9 +395,12 @@ func (d *Data) AddAgentRequest(request schema.AgentRequest) (string, error) {
 	requestID := request.RequestID
 
 	// If no Agent ID, look for one in the parameters
+	var tag string
 	if agentID == "" {
 		if v, ok := request.Parameters[commands.AgentID]; ok {
 			agentID = v
+		} else if v, ok = request.Parameters["tag"]; ok {
+			tag = v
 		} else {
 			return "", fmt.Errorf("agent ID is required")
 		}
@@ -251,12 +419,25 @@ func (d *Data) AddAgentRequest(request schema.AgentRequest) (string, error) {
 		requestID = d.generateRequestID()
 	}
 
+	// Requests for a tag are delivered to the agents that carry it when they sync
+	if tag != "" {
+		return d.addTagRequest(request, requestID, tag)
+	}
+
 	// Check if the agent exists
 	err := d.AgentExists(agentID)
 	if err != nil {
 		return "", err
 	}
 
+	// Requests queued by the server bypass the API's validation, so check the values here too
+	if err = commands.CheckParams(request.Request, request.Parameters); err != nil {
+		return "", err
+	}
+
+	// Agents don't all accept the same spellings of boolean and enum values
+	commands.Normalize(request.Request, request.Parameters)
+
 	// Create a new agent record in the DB
 	newRequest := schema.NewDBAgentRequest()
 	newRequest.AgentID = agentID
@@ -265,7 +446,7 @@ func (d *Data) AddAgentRequest(request schema.AgentRequest) (string, error) {
 	newRequest.Request = request.Request
 	newRequest.AckRequired = request.AckRequired
 	newRequest.Parameters = request.Parameters
-	newRequest.Status = schema.RequestStatusNew
+	newRequest.Status = d.initialStatus(request.Request)
 	newRequest.TimeCreated = time.Now()
 	newRequest.SendCount = 0
 	newRequest.Cancelled = false
@@ -281,17 +462,115 @@ func (d *Data) AddAgentRequest(request schema.AgentRequest) (string, error) {
 		fields.NewField("id", agentID),
 		fields.NewField("requestID", requestID),
 		fields.NewField("requester", request.Requester),
+		fields.NewField("status", newRequest.Status),
 	))
 
+	if newRequest.Status == schema.RequestStatusAwaitingApproval {
+		d.addApprovalEvent(newRequest, "awaiting approval")
+		return newRequest.RequestID, nil
+	}
+
+	metrics.Command(request.Request, metrics.CommandQueued)
 	return newRequest.RequestID, nil
 }
 
+// AddAgentRequests queues the same request for each of the agents and stores all of the
+// requests in a single transaction. It returns the request ID for each agent that the
+// request was queued for, and the reason for each agent that it was not.
+func (d *Data) AddAgentRequests(request schema.AgentRequest, agentIDs []string) (map[string]string, map[string]string, error) {
+	requests := make(map[string]string)
+	failed := make(map[string]string)
+
+	agents, err := d.database.GetAllAgentMeta()
+	if err != nil {
+		return requests, failed, fmt.Errorf("failed to get agents: %w", err)
+	}
+	exists := make(map[string]bool, len(agents.Agents))
+	for _, agent := range agents.Agents {
+		exists[agent.AgentID] = true
+	}
+
+	now := time.Now()
+	records := make([]schema.AgentRequestRecord, 0, len(agentIDs))
+	for _, agentID := range agentIDs {
+		if agentID == "" {
+			continue
+		}
+		if _, ok := requests[agentID]; ok {
+			continue
+		}
+		if !exists[agentID] {
+			failed[agentID] = "agent does not exist"
+			continue
+		}
+
+		// Each agent gets its own copy of the parameters
+		params := make(map[string]string, len(request.Parameters)+1)
+		for k, v := range request.Parameters {
+			params[k] = v
+		}
+		params[commands.AgentID] = agentID
+		commands.Normalize(request.Request, params)
+
+		newRequest := schema.NewDBAgentRequest()
+		newRequest.AgentID = agentID
+		newRequest.RequestID = d.generateRequestID()
+		newRequest.Requester = request.Requester
+		newRequest.Request = request.Request
+		newRequest.AckRequired = request.AckRequired
+		newRequest.Parameters = params
+		newRequest.Status = d.initialStatus(request.Request)
+		newRequest.TimeCreated = now
+
+		records = append(records, newRequest)
+		requests[agentID] = newRequest.RequestID
+	}
+
+	if len(records) == 0 {
+		return requests, failed, nil
+	}
+
+	err = d.database.SetAgentRequests(records)
+	if err != nil {
+		return make(map[string]string), failed, fmt.Errorf("failed to add agent requests: %w", err)
+	}
+
+	d.logger.Info(2738, "new agent requests", fields.NewFields(
+		fields.NewField("request", request.Request),
+		fields.NewField("agents", len(records)),
+		fields.NewField("requester", request.Requester),
+	))
+
+	for _, record := range records {
+		if record.Status == schema.RequestStatusAwaitingApproval {
+			d.addApprovalEvent(record, "awaiting approval")
+		} else {
+			metrics.Command(request.Request, metrics.CommandQueued)
+		}
+	}
+	return requests, failed, nil
+}
+
 func (d *Data) generateRequestID() string {
 	// This should always be a unique ID
 	return "R-" + uuid.New().String()
 }
 
-// Get the filename from a download URL
+// requestFilename returns the name of the file to be downloaded, either from the
+// file parameter (a file hosted by this server) or the last element of the url parameter
+func requestFilename(parameters map[string]string) (string, error) {
+	if file, ok := parameters["file"]; ok {
+		return file, nil
+	}
+
+	dlURL, ok := parameters["url"]
+	if !ok {
+		return "", errors.New("missing url parameter")
+	}
+	return getFilename(dlURL)
+}
+
+// getFilename returns the last element of the path of a download URL
 func getFilename(urlStr string) (string, error) {
 	u, err := url.Parse(urlStr)
 	if err != nil {
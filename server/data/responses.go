@@ -0,0 +1,68 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// encryptResponse encrypts the response to a sensitive command with the server's public key.
+// Agents encrypt these responses themselves, this is only required for older agents that send
+// them in plaintext.
+func (d *Data) encryptResponse(response *schema.AgentResponse) error {
+	serverPublicEnc := d.conf.SP.Get(global.ConfigServerECPublicEnc).String()
+	if serverPublicEnc == "" {
+		return errors.New("server public encryption key not available")
+	}
+
+	plaintext, err := json.Marshal(schema.ResponseBody{Response: response.Response, Data: response.Data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
+	}
+
+	response.EncryptedResponse, err = crypto.Encrypt(plaintext, serverPublicEnc)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt response: %w", err)
+	}
+
+	response.Response = schema.ResponseEncryptedDetails
+	response.Data = nil
+	return nil
+}
+
+// DecryptResponse replaces the response details and data of a request record with the
+// decrypted response. Records without an encrypted response are not changed.
+func (d *Data) DecryptResponse(record *schema.AgentRequestRecord) error {
+	if record.EncryptedResponse == "" {
+		return nil
+	}
+
+	serverPrivateEnc := d.conf.SP.Get(global.ConfigServerECPrivateEnc).String()
+	if serverPrivateEnc == "" {
+		return errors.New("server private encryption key not available")
+	}
+
+	plaintext, err := crypto.Decrypt(record.EncryptedResponse, serverPrivateEnc)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt response: %w", err)
+	}
+
+	var body schema.ResponseBody
+	err = json.Unmarshal(plaintext, &body)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	record.ResponseDetails = body.Response
+	record.ResponseData = body.Data
+	return nil
+}
@@ -0,0 +1,176 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/notify"
+)
+
+var (
+	ErrSavedReportExists   = errors.New("saved report already exists")
+	ErrSavedReportNotFound = errors.New("saved report not found")
+	ErrInvalidSavedReport  = errors.New("invalid saved report")
+)
+
+// CreateSavedReport stores a new saved report. The report type and schedule are checked by
+// the caller, which also sets the next run time.
+func (d *Data) CreateSavedReport(report schema.SavedReport) (schema.SavedReport, error) {
+	report.Name = strings.TrimSpace(report.Name)
+	if err := validateSavedReport(report); err != nil {
+		return schema.SavedReport{}, err
+	}
+
+	exists, err := d.database.SavedReportExists(report.Name)
+	if err != nil {
+		return schema.SavedReport{}, err
+	}
+	if exists {
+		return schema.SavedReport{}, ErrSavedReportExists
+	}
+
+	report.Created = time.Now()
+	report.Updated = report.Created
+	if err = d.database.SetSavedReport(report); err != nil {
+		return schema.SavedReport{}, err
+	}
+	return report, nil
+}
+
+// ReplaceSavedReport replaces the definition of a saved report, keeping its creator and the
+// result of its last run
+func (d *Data) ReplaceSavedReport(report schema.SavedReport) (schema.SavedReport, error) {
+	if err := validateSavedReport(report); err != nil {
+		return schema.SavedReport{}, err
+	}
+
+	var updated schema.SavedReport
+	err := d.database.UpdateSavedReport(report.Name, func(existing *schema.SavedReport) error {
+		report.Name = existing.Name
+		report.Creator = existing.Creator
+		report.Created = existing.Created
+		report.Updated = time.Now()
+		report.LastRun = existing.LastRun
+		report.LastResult = existing.LastResult
+		report.LastFile = existing.LastFile
+		*existing = report
+		updated = report
+		return nil
+	})
+	if err != nil {
+		return schema.SavedReport{}, savedReportError(err)
+	}
+	return updated, nil
+}
+
+// GetSavedReport returns a saved report by name
+func (d *Data) GetSavedReport(name string) (schema.SavedReport, error) {
+	report, err := d.database.GetSavedReport(name)
+	if err != nil {
+		return schema.SavedReport{}, ErrSavedReportNotFound
+	}
+	return report, nil
+}
+
+// GetSavedReports returns all saved reports sorted by name
+func (d *Data) GetSavedReports() ([]schema.SavedReport, error) {
+	reports, err := d.database.GetSavedReports()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return schema.SavedReportKey(reports[i].Name) < schema.SavedReportKey(reports[j].Name)
+	})
+	return reports, nil
+}
+
+// DeleteSavedReport deletes a saved report. Copies in the reports directory are not affected.
+func (d *Data) DeleteSavedReport(name string) error {
+	if _, err := d.GetSavedReport(name); err != nil {
+		return err
+	}
+	return d.database.DeleteSavedReport(name)
+}
+
+// ScheduleSavedReport sets the time that a saved report next runs
+func (d *Data) ScheduleSavedReport(name string, next time.Time) error {
+	return savedReportError(d.database.UpdateSavedReport(name, func(report *schema.SavedReport) error {
+		report.NextRun = next
+		return nil
+	}))
+}
+
+// SavedReportResult records the result of running a saved report. If file is empty, the
+// previous file is kept, since the result may be from a later stage such as email delivery.
+func (d *Data) SavedReportResult(name string, run time.Time, result, file string) error {
+	return savedReportError(d.database.UpdateSavedReport(name, func(report *schema.SavedReport) error {
+		report.LastRun = run
+		report.LastResult = result
+		if file != "" {
+			report.LastFile = file
+		}
+		return nil
+	}))
+}
+
+// SaveReportCopy writes a copy of a saved report to the reports directory, which is not
+// served to agents, and returns its path
+func (d *Data) SaveReportCopy(name string, content []byte) (string, error) {
+	if !validFileName(name) {
+		return "", ErrInvalidFileName
+	}
+
+	filesPath := d.conf.SC.Get(global.ConfigFilesPath).String()
+	if filesPath == "" {
+		return "", errors.New("files path not configured")
+	}
+
+	dir := filepath.Join(filesPath, global.ReportsDir)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("error creating reports directory: %w", err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0640); err != nil {
+		return "", fmt.Errorf("error writing report: %w", err)
+	}
+	return path, nil
+}
+
+// validateSavedReport checks the fields of a saved report that the data layer can check
+func validateSavedReport(report schema.SavedReport) error {
+	if !schema.ValidSavedReportName(report.Name) {
+		return fmt.Errorf("%w: names may be up to %d letters, numbers, and . _ -", ErrInvalidSavedReport, schema.MaxSavedReportNameLength)
+	}
+
+	if report.Format != schema.SavedReportCSV && report.Format != schema.SavedReportHTML {
+		return fmt.Errorf("%w: format must be %s or %s", ErrInvalidSavedReport, schema.SavedReportCSV, schema.SavedReportHTML)
+	}
+
+	for _, address := range report.Email {
+		if !notify.ValidEmailAddress(address) {
+			return fmt.Errorf("%w: invalid email address %q", ErrInvalidSavedReport, address)
+		}
+	}
+	return nil
+}
+
+// savedReportError converts a missing key to ErrSavedReportNotFound
+func savedReportError(err error) error {
+	if err != nil && strings.Contains(err.Error(), "key not found") {
+		return ErrSavedReportNotFound
+	}
+	return err
+}
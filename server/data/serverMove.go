@@ -0,0 +1,25 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// signServerMove adds the server's signature to the parameters of a set_server_url request.
+// The agent refuses to change servers unless the signature verifies with the key it received
+// when it registered, so the request can't be forged by anyone without the server's key.
+func (d *Data) signServerMove(agentID, requestID string, parameters map[string]string) error {
+	data := schema.ServerMoveData(agentID, requestID, parameters["url"], parameters["migration_token"])
+	sig, err := crypto.Sign(data, d.conf.SP.Get(global.ConfigServerECPrivateSig).String())
+	if err != nil {
+		return err
+	}
+	parameters["signature"] = sig
+	return nil
+}
@@ -0,0 +1,41 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// AddShellEvent records that a remote shell session was opened or closed. These are alerts so
+// that they are forwarded to notification sinks by default.
+func (d *Data) AddShellEvent(agentID, sessionID, requester, event, reason string) error {
+	details := map[string]string{"session_id": sessionID, "requester": requester}
+	if reason != "" {
+		details["reason"] = reason
+	}
+
+	return d.AddEvent(schema.AgentEvent{
+		AgentID:   agentID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventAlert,
+		Event:     event,
+		Details:   details})
+}
+
+// AddShellData records remote shell input or output so that sessions can be audited
+func (d *Data) AddShellData(agentID, sessionID, requester, direction string, data []byte) error {
+	return d.AddEvent(schema.AgentEvent{
+		AgentID:   agentID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventShell,
+		Event:     "shell " + direction,
+		Details: map[string]string{
+			"session_id": sessionID,
+			"requester":  requester,
+			"data":       string(data)}})
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/schema/commands"
 	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/metrics"
 )
 
 type SyncData struct {
@@ -45,13 +46,24 @@ func (d *Data) AgentSync(data SyncData) schema.AgentTriggers {
 			fields.NewField("build", data.Build)))
 
 	// Update the agent metadata
-	triggers, err := d.database.AgentSync(data.AgentID, data.RemoteIP, data.Version, data.Build)
+	ip := d.locateIP(data.RemoteIP)
+	result, err := d.database.AgentSync(data.AgentID, ip, data.Version, data.Build,
+		d.conf.SC.Get(global.ConfigIPHistorySize).Int())
 	if err != nil {
 		d.logger.Error(2708, "error updating agent metadata",
 			fields.NewFields(
 				fields.NewField("error", err.Error()),
 				fields.NewField("id", data.AgentID)))
 	}
+	if result.WasOffline {
+		d.agentBackOnline(data.AgentID, data.RemoteIP)
+	}
+	if err == nil {
+		d.checkCountry(data.AgentID, result.PreviousIP, ip)
+	}
+
+	// Upgrade the agent if it is older than the minimum version
+	d.enforceMinimumVersion(data.AgentID, data.Version, data.Build)
 
 	// If there are any responses, process them
 	for index, response := range data.Responses {
@@ -77,22 +89,18 @@ func (d *Data) AgentSync(data SyncData) schema.AgentTriggers {
 
 	// Store recovery info if provided
 	if data.RecoveryInfo != "" {
-		meta, err := d.database.GetAgentMeta(data.AgentID)
-		if err == nil {
-			meta.RecoveryInfo = data.RecoveryInfo
-			if setErr := d.database.SetAgentMeta(meta); setErr != nil {
-				d.logger.Error(2711, "failed to store recovery info",
-					fields.NewFields(
-						fields.NewField("error", setErr.Error()),
-						fields.NewField("id", data.AgentID)))
-			} else {
-				d.logger.Info(2712, "recovery info stored",
-					fields.NewFields(fields.NewField("id", data.AgentID)))
-			}
+		if err := d.SetAgentRecoveryInfo(data.AgentID, data.RecoveryInfo); err != nil {
+			d.logger.Error(2711, "failed to store recovery info",
+				fields.NewFields(
+					fields.NewField("error", err.Error()),
+					fields.NewField("id", data.AgentID)))
+		} else {
+			d.logger.Info(2712, "recovery info stored",
+				fields.NewFields(fields.NewField("id", data.AgentID)))
 		}
 	}
 
-	return triggers
+	return result.Triggers
 }
 
 // processAgentResponse processes a single response from an agent
@@ -119,40 +127,112 @@ func (d *Data) processAgentResponse(agentID string, response schema.AgentRespons
 		return d.queueResponse(agentID, response)
 	}
 
-	// Validate the agent response
-	request, err := d.database.GetAgentRequest(response.RequestID)
-	if err != nil {
-		return fmt.Errorf("failed to get agent request: %w", err)
+	// Recovery keys are stored separately and are never included in the request record
+	if response.Cmd == commands.FDEKeyEscrow && response.Success {
+		err := d.storeFDERecoveryKey(agentID, response)
+		if err != nil {
+			d.logger.Error(2730, "failed to store recovery key",
+				fields.NewFields(
+					fields.NewField("error", err.Error()),
+					fields.NewField("id", agentID),
+					fields.NewField("requestID", response.RequestID)))
+			response.Success = false
+			response.Response = fmt.Sprintf("server failed to store recovery key: %s", err.Error())
+		}
 	}
 
-	// Verify the agent ID matches the request
-	if request.AgentID != agentID {
-		return fmt.Errorf("agent ID does not match request")
+	// Responses to sensitive commands are only stored encrypted. Agents that predate response
+	// encryption send them in plaintext, so the server encrypts them itself, or discards them if
+	// that fails. The command itself still succeeded.
+	if response.Success && !response.InProgress && response.EncryptedResponse == "" && commands.IsSensitive(response.Cmd) {
+		err := d.encryptResponse(&response)
+		if err != nil {
+			d.logger.Error(2731, "failed to encrypt response",
+				fields.NewFields(
+					fields.NewField("error", err.Error()),
+					fields.NewField("id", agentID),
+					fields.NewField("requestID", response.RequestID)))
+			response.Response = fmt.Sprintf("response discarded, server failed to encrypt it: %s", err.Error())
+			response.Data = nil
+		}
 	}
 
-	// Update the request record with the response
-	request.ResponseDetails = response.Response
-	if response.Success {
-		request.Status = schema.RequestStatusComplete
-	} else {
-		request.Status = schema.RequestStatusFailed
-	}
+	// Update the request record with the response in a single transaction so that
+	// concurrent updates to the request are not lost
+	request, err := d.database.UpdateAgentRequest(response.RequestID, func(request *schema.AgentRequestRecord) error {
 
-	request.ResponseData = response.Data
+		// Verify the agent ID matches the request
+		if request.AgentID != agentID {
+			return fmt.Errorf("agent ID does not match request")
+		}
 
-	// Redact sensitive parameters from completed or failed requests
-	if request.Status == schema.RequestStatusComplete || request.Status == schema.RequestStatusFailed {
-		if _, exists := request.Parameters["password"]; exists {
-			request.Parameters["password"] = "********"
+		// Requests that were never approved can't have been sent to the agent
+		if request.Status == schema.RequestStatusAwaitingApproval || request.Status == schema.RequestStatusDenied {
+			return fmt.Errorf("request has not been approved")
+		}
+
+		now := time.Now()
+		if request.TimeAcknowledged.IsZero() {
+			request.TimeAcknowledged = now
+		}
+
+		// The command continues in the background and a final response will follow
+		if response.InProgress {
+			request.ResponseDetails = response.Response
+			return nil
+		}
+
+		if request.TimeCompleted.IsZero() {
+			request.TimeCompleted = now
+		}
+
+		if response.Cmd == commands.Ping && response.Success {
+			request.Latency = pingLatency(request, response.Data, request.TimeCompleted)
 		}
-	}
 
-	// Update the request record
-	err = d.database.SetAgentRequest(request)
+		request.ResponseDetails = response.Response
+		request.Success = &response.Success
+		if response.Success {
+			request.Status = schema.RequestStatusComplete
+		} else if response.Refused {
+			request.Status = schema.RequestStatusRefused
+		} else {
+			request.Status = schema.RequestStatusFailed
+		}
+
+		request.ResponseData = response.Data
+		if response.EncryptedResponse != "" {
+			request.ResponseEncrypted = true
+			request.EncryptedResponse = response.EncryptedResponse
+			request.ResponseData = nil
+		}
+
+		// Redact sensitive parameters from completed or failed requests
+		schema.RedactParameters(request.Parameters)
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update agent request: %w", err)
 	}
 
+	if response.InProgress {
+		return d.queueResponse(agentID, response)
+	}
+
+	if response.Success {
+		metrics.Command(request.Request, metrics.CommandCompleted)
+	} else {
+		metrics.Command(request.Request, metrics.CommandFailed)
+	}
+
+	// Record failed commands and changes to user accounts in the event store
+	if !response.Success || userCommands[request.Request] {
+		err = d.AddEvent(commandEvent(request, response))
+		if err != nil {
+			return fmt.Errorf("failed to add event to event store: %w", err)
+		}
+	}
+
 	// If the request was for status, add it to the status bucket as well
 	if response.Cmd == commands.Status {
 		err = d.agentStatus(agentID, response)
@@ -161,9 +241,52 @@ func (d *Data) processAgentResponse(agentID string, response schema.AgentRespons
 		}
 	}
 
+	// Keep the most recent list of pending updates for reporting
+	if (response.Cmd == commands.PatchStatus || response.Cmd == commands.PatchInstall) && response.Data != nil {
+		err = d.agentPatches(agentID, response)
+		if err != nil {
+			return err
+		}
+	}
+
 	return d.queueResponse(agentID, response)
 }
 
+// userCommands are the commands that change user accounts and are recorded as events
+var userCommands = map[string]bool{
+	commands.UserAdd:      true,
+	commands.UserAdmin:    true,
+	commands.UserDelete:   true,
+	commands.UserLock:     true,
+	commands.UserPassword: true,
+	commands.UserUnlock:   true,
+}
+
+// commandEvent returns an event describing the result of a command. Failures are alerts.
+func commandEvent(request schema.AgentRequestRecord, response schema.AgentResponse) schema.AgentEvent {
+	event := schema.AgentEvent{
+		AgentID:   request.AgentID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventMessage,
+		Event:     fmt.Sprintf("%s completed: %s", request.Request, response.Response),
+		Details: map[string]string{
+			"command":    request.Request,
+			"request_id": request.RequestID,
+			"requester":  request.Requester,
+		}}
+
+	if !response.Success {
+		event.EventType = schema.AgentEventAlert
+		event.Event = fmt.Sprintf("%s failed: %s", request.Request, response.Response)
+	}
+
+	// Only the user is included, never a password
+	if user, ok := request.Parameters["user"]; ok {
+		event.Details["user"] = user
+	}
+	return event
+}
+
 // processServiceCredentials handles incoming service credentials from agents
 // Credentials arrive double-encrypted: first with agent's public key, then with server's public key
 // This function decrypts the outer layer and stores the agent-encrypted version in the database
@@ -184,17 +307,11 @@ func (d *Data) processServiceCredentials(agentID string, response schema.AgentRe
 		return fmt.Errorf("failed to decrypt service credentials: %w", err)
 	}
 
-	// Get current agent metadata
-	meta, err := d.database.GetAgentMeta(agentID)
-	if err != nil {
-		return fmt.Errorf("failed to get agent metadata: %w", err)
-	}
-
 	// Store the agent-encrypted credentials
-	meta.ServiceCredentials = string(decrypted)
-
-	// Update agent metadata
-	err = d.database.SetAgentMeta(meta)
+	err = d.UpdateAgentMeta(agentID, func(meta *schema.AgentMeta) error {
+		meta.ServiceCredentials = string(decrypted)
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update agent metadata with credentials: %w", err)
 	}
@@ -220,27 +337,61 @@ func (d *Data) agentStatus(agentID string, response schema.AgentResponse) error
 	}
 
 	// Add to the event store
-	err = d.database.AddEvent(schema.AgentEvent{
+	err = d.AddEvent(schema.AgentEvent{
 		AgentID:   agentID,
 		Time:      time.Now(),
 		EventType: schema.AgentEventStatus,
 		Event:     "status",
-		Details:   statusData.Details})
+		Details:   statusData.Details.Map()})
 	if err != nil {
 		return fmt.Errorf("failed to add event to event store: %w", err)
 	}
 
 	// Update the agent status
 	err = d.database.UpdateAgentStatus(agentID, schema.AgentStatus{
-		LastUpdated: time.Now(),
-		Details:     statusData.Details,
-		Info:        statusData.Info})
+		LastUpdated:       time.Now(),
+		Details:           statusData.Details,
+		Info:              statusData.Info,
+		AntivirusProducts: statusData.AntivirusProducts})
 	if err != nil {
 		return fmt.Errorf("failed to update agent status: %w", err)
 	}
+
+	// While lost mode is active, record the network context as a location event
+	meta, err := d.database.GetAgentMeta(agentID)
+	if err == nil && meta.Triggers.Lost {
+		err = d.AddEvent(locationEvent(meta, statusData.Details))
+		if err != nil {
+			return fmt.Errorf("failed to add location event to event store: %w", err)
+		}
+	}
 	return nil
 }
 
+// locationEvent creates an event from the network context reported by a lost agent. The
+// public IP address is the address the sync was received from.
+func locationEvent(meta schema.AgentMeta, details schema.StatusDetails) schema.AgentEvent {
+	location := map[string]string{"public_ip": meta.LastIP}
+	if len(meta.IPHistory) > 0 && meta.IPHistory[0].Country != "" {
+		location["country"] = meta.IPHistory[0].Country
+		if meta.IPHistory[0].City != "" {
+			location["city"] = meta.IPHistory[0].City
+		}
+	}
+	for _, key := range []string{"ip", schema.StatusGatewayMAC, schema.StatusWiFiSSID} {
+		if value := details.Get(key); value != "" {
+			location[key] = value
+		}
+	}
+
+	return schema.AgentEvent{
+		AgentID:   meta.AgentID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventLocation,
+		Event:     "location",
+		Details:   location}
+}
+
 // queueResponse adds a response to the response queue
 func (d *Data) queueResponse(agentID string, response schema.AgentResponse) error {
 
@@ -0,0 +1,249 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/global"
+	"github.com/UnifyEM/UnifyEM/server/metrics"
+)
+
+// errDelivered is returned to abandon the delivery of a tag request that the agent already has
+var errDelivered = errors.New("tag request already delivered")
+
+// addTagRequest stores a request for the agents with a tag. It is delivered to each agent that
+// carries the tag when the agent syncs, until the request expires or is cancelled, so agents
+// that gain the tag later receive it and agents that lose it first do not.
+func (d *Data) addTagRequest(request schema.AgentRequest, requestID, tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", fmt.Errorf("tag is required")
+	}
+
+	params := make(map[string]string, len(request.Parameters))
+	for k, v := range request.Parameters {
+		if k != "tag" && k != commands.AgentID {
+			params[k] = v
+		}
+	}
+
+	if err := commands.CheckParams(request.Request, params); err != nil {
+		return "", err
+	}
+	commands.Normalize(request.Request, params)
+
+	days := d.conf.SC.Get(global.ConfigTagRequestDays).Int()
+
+	newRequest := schema.NewDBAgentRequest()
+	newRequest.Tag = tag
+	newRequest.RequestID = requestID
+	newRequest.Requester = request.Requester
+	newRequest.Request = request.Request
+	newRequest.AckRequired = request.AckRequired
+	newRequest.Parameters = params
+	newRequest.Status = schema.RequestStatusActive
+	newRequest.TimeCreated = time.Now()
+	newRequest.Expires = newRequest.TimeCreated.AddDate(0, 0, days)
+	newRequest.Deliveries = make(map[string]string)
+
+	// The request is delivered for tag_request_days from when it is approved
+	if d.ApprovalRequired(request.Request) {
+		newRequest.Status = schema.RequestStatusAwaitingApproval
+		newRequest.Expires = time.Time{}
+	}
+
+	err := d.database.SetAgentRequest(newRequest)
+	if err != nil {
+		return "", fmt.Errorf("failed to add tag request: %w", err)
+	}
+
+	d.logger.Info(2748, "new tag request", fields.NewFields(
+		fields.NewField("request", request.Request),
+		fields.NewField("tag", tag),
+		fields.NewField("requestID", requestID),
+		fields.NewField("requester", request.Requester),
+		fields.NewField("expires", newRequest.Expires),
+		fields.NewField("status", newRequest.Status),
+	))
+
+	if newRequest.Status == schema.RequestStatusAwaitingApproval {
+		d.addApprovalEvent(newRequest, "awaiting approval")
+	}
+	return requestID, nil
+}
+
+// deliverTagRequests creates a request for the agent from each active tag request for a tag
+// that the agent currently carries, unless the agent has already received it. The parent is
+// updated in a transaction so that each agent receives a tag request once even if it syncs
+// more than once at the same time.
+func (d *Data) deliverTagRequests(agentID string) {
+	requests, err := d.database.GetTagRequests()
+	if err != nil {
+		d.logger.Error(2749, "error getting tag requests", fields.NewFields(
+			fields.NewField("error", err.Error()),
+			fields.NewField("id", agentID)))
+		return
+	}
+	if len(requests) == 0 {
+		return
+	}
+
+	meta, err := d.database.GetAgentMeta(agentID)
+	if err != nil {
+		return
+	}
+	tags := MergeTags(meta.Tags)
+
+	now := time.Now()
+	for _, parent := range requests {
+		if parent.Status != schema.RequestStatusActive || parent.Cancelled {
+			continue
+		}
+		if now.After(parent.Expires) {
+			d.closeTagRequest(parent.RequestID)
+			continue
+		}
+		if _, ok := parent.Deliveries[agentID]; ok {
+			continue
+		}
+		if parent.Tag != "all" && !slices.Contains(tags, parent.Tag) {
+			continue
+		}
+
+		child := schema.NewDBAgentRequest()
+		child.AgentID = agentID
+		child.RequestID = d.generateRequestID()
+		child.ParentID = parent.RequestID
+		child.Requester = parent.Requester
+		child.Request = parent.Request
+		child.AckRequired = parent.AckRequired
+		child.Status = schema.RequestStatusNew
+		child.TimeCreated = now
+		for k, v := range parent.Parameters {
+			child.Parameters[k] = v
+		}
+		child.Parameters[commands.AgentID] = agentID
+
+		_, err = d.database.UpdateAgentRequest(parent.RequestID, func(r *schema.AgentRequestRecord) error {
+			if r.Status != schema.RequestStatusActive {
+				return errDelivered
+			}
+			if _, ok := r.Deliveries[agentID]; ok {
+				return errDelivered
+			}
+			if r.Deliveries == nil {
+				r.Deliveries = make(map[string]string)
+			}
+			r.Deliveries[agentID] = child.RequestID
+			return nil
+		})
+		if errors.Is(err, errDelivered) {
+			continue
+		}
+		if err == nil {
+			err = d.database.SetAgentRequest(child)
+		}
+		if err != nil {
+			d.logger.Error(2750, "error delivering tag request", fields.NewFields(
+				fields.NewField("error", err.Error()),
+				fields.NewField("id", agentID),
+				fields.NewField("requestID", parent.RequestID)))
+			continue
+		}
+
+		d.logger.Info(2751, "tag request delivered to agent", fields.NewFields(
+			fields.NewField("request", parent.Request),
+			fields.NewField("tag", parent.Tag),
+			fields.NewField("id", agentID),
+			fields.NewField("requestID", child.RequestID),
+			fields.NewField("parentID", parent.RequestID)))
+		metrics.Command(parent.Request, metrics.CommandQueued)
+	}
+}
+
+// expireTagRequests closes the tag requests that are no longer delivered
+func (d *Data) expireTagRequests() {
+	requests, err := d.database.GetTagRequests()
+	if err != nil {
+		d.logger.Error(2752, "error getting tag requests", fields.NewFields(
+			fields.NewField("error", err.Error())))
+		return
+	}
+
+	now := time.Now()
+	for _, request := range requests {
+		if request.Status == schema.RequestStatusActive && now.After(request.Expires) {
+			d.closeTagRequest(request.RequestID)
+		}
+	}
+}
+
+// closeTagRequest marks a tag request complete so that it is not delivered to more agents
+func (d *Data) closeTagRequest(requestID string) {
+	_, err := d.database.UpdateAgentRequest(requestID, func(r *schema.AgentRequestRecord) error {
+		if r.Status != schema.RequestStatusActive {
+			return errDelivered
+		}
+		r.Status = schema.RequestStatusComplete
+		r.TimeCompleted = time.Now()
+		r.ResponseDetails = fmt.Sprintf("delivered to %d agents", len(r.Deliveries))
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDelivered) {
+		d.logger.Error(2753, "error closing tag request", fields.NewFields(
+			fields.NewField("error", err.Error()),
+			fields.NewField("requestID", requestID)))
+	}
+}
+
+// cancelTagDeliveries cancels the deliveries of a tag request that agents have not responded to
+func (d *Data) cancelTagDeliveries(parent schema.AgentRequestRecord) error {
+	for _, requestID := range parent.Deliveries {
+		if err := d.database.CancelAgentRequest(requestID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTagOutcomes summarizes the deliveries of each tag request in the list. The deliveries are
+// found in the list if present, and otherwise read from the database.
+func (d *Data) addTagOutcomes(list *schema.AgentRequestRecordList) {
+	byID := make(map[string]schema.AgentRequestRecord, len(list.Requests))
+	for _, request := range list.Requests {
+		byID[request.RequestID] = request
+	}
+
+	for i, request := range list.Requests {
+		if request.Tag == "" {
+			continue
+		}
+
+		outcomes := &schema.TagOutcomes{Delivered: len(request.Deliveries), Statuses: make(map[string]int)}
+		if agents, err := d.TagAgents(request.Tag); err == nil {
+			outcomes.Agents = len(agents)
+		}
+		for _, requestID := range request.Deliveries {
+			child, ok := byID[requestID]
+			if !ok {
+				var err error
+				if child, err = d.database.GetAgentRequest(requestID); err != nil {
+					continue
+				}
+			}
+			outcomes.Statuses[child.Status]++
+		}
+		list.Requests[i].Outcomes = outcomes
+	}
+}
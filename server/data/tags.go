@@ -0,0 +1,158 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// GetTags returns all tags assigned to agents with the number of agents for each
+func (d *Data) GetTags() ([]schema.TagCount, error) {
+	agents, err := d.database.GetAllAgentMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, agent := range agents.Agents {
+		for _, tag := range MergeTags(agent.Tags) {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]schema.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, schema.TagCount{Tag: tag, Agents: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+	return tags, nil
+}
+
+// TagAgents returns the IDs of the agents with a tag. The tag "all" matches every agent.
+func (d *Data) TagAgents(tag string) ([]string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	agents, err := d.database.GetAllAgentMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0)
+	for _, agent := range agents.Agents {
+		if tag == "all" || slices.Contains(MergeTags(agent.Tags), tag) {
+			ids = append(ids, agent.AgentID)
+		}
+	}
+	return ids, nil
+}
+
+// DeleteTag removes a tag from every agent, and any upgrade channel assigned
+// to it, and returns the number of agents that had the tag
+func (d *Data) DeleteTag(tag string) (int, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	agents, err := d.database.GetAllAgentMeta()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, agent := range agents.Agents {
+		tags := MergeTags(agent.Tags)
+		if !slices.Contains(tags, tag) {
+			continue
+		}
+
+		// The snapshot only selects the agents, the tags are removed from the stored metadata
+		err = d.UpdateAgentMeta(agent.AgentID, func(meta *schema.AgentMeta) error {
+			tags := MergeTags(meta.Tags)
+			if !slices.Contains(tags, tag) {
+				return errAgentUnchanged
+			}
+			meta.Tags = slices.DeleteFunc(tags, func(t string) bool { return t == tag })
+			return nil
+		})
+		if errors.Is(err, errAgentUnchanged) || errors.Is(err, ErrAgentNotFound) {
+			continue
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to update agent %s: %w", agent.AgentID, err)
+		}
+		count++
+	}
+
+	err = d.database.SetTagChannel(tag, "")
+	if err != nil {
+		return count, fmt.Errorf("failed to remove tag channel: %w", err)
+	}
+	return count, nil
+}
+
+// NormalizeAgentTags converts the tags of all agents to lower case and removes duplicates.
+// Tags stored before tags were normalized on write may differ only in case.
+func (d *Data) NormalizeAgentTags() {
+	agents, err := d.database.GetAllAgentMeta()
+	if err != nil {
+		d.logger.Error(2725, "error getting agents to normalize tags", fields.NewFields(
+			fields.NewField("error", err.Error())))
+		return
+	}
+
+	updated := 0
+	for _, agent := range agents.Agents {
+		tags := MergeTags(agent.Tags)
+		if slices.Equal(tags, agent.Tags) {
+			continue
+		}
+
+		err = d.UpdateAgentMeta(agent.AgentID, func(meta *schema.AgentMeta) error {
+			tags := MergeTags(meta.Tags)
+			if slices.Equal(tags, meta.Tags) {
+				return errAgentUnchanged
+			}
+			meta.Tags = tags
+			return nil
+		})
+		if errors.Is(err, errAgentUnchanged) || errors.Is(err, ErrAgentNotFound) {
+			continue
+		}
+		if err != nil {
+			d.logger.Error(2726, "error normalizing agent tags", fields.NewFields(
+				fields.NewField("id", agent.AgentID),
+				fields.NewField("error", err.Error())))
+			continue
+		}
+		updated++
+	}
+
+	if updated > 0 {
+		d.logger.Info(2727, "agent tags normalized", fields.NewFields(
+			fields.NewField("agents", updated)))
+	}
+}
+
+// MergeTags returns the tags trimmed, in lower case, sorted, and without duplicates
+// or empty tags. Tags are not otherwise validated so that existing tags are preserved.
+func MergeTags(lists ...[]string) []string {
+	tags := make([]string, 0)
+	for _, list := range lists {
+		for _, tag := range list {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
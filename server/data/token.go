@@ -13,7 +13,9 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 
+	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/db"
 	"github.com/UnifyEM/UnifyEM/server/global"
 )
 
@@ -72,7 +74,7 @@ func (d *Data) createToken(request tokenRequest) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Sign the token with the secret key
-	tokenString, err := token.SignedString(d.jwtKey)
+	tokenString, err := token.SignedString(d.key())
 	if err != nil {
 		return "", err
 	}
@@ -81,23 +83,135 @@ func (d *Data) createToken(request tokenRequest) (string, error) {
 
 // ValidateToken validates the supplied token (including purpose) and returns the user, role, and error
 func (d *Data) ValidateToken(tokenString string, purpose string) (string, int, error) {
+	claims, err := d.parseToken(tokenString, purpose)
+	if err != nil {
+		return "", 0, err
+	}
+	return claims.Subject, claims.Role, nil
+}
+
+// parseToken validates the supplied token (including purpose) and returns its claims
+func (d *Data) parseToken(tokenString string, purpose string) (*CustomClaims, error) {
 
-	// Parse the token
+	// Parse the token, allowing for clock skew
+	leeway := time.Duration(d.conf.SC.Get(global.ConfigTokenLeeway).Int()) * time.Second
 	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return d.jwtKey, nil
-	})
+		return d.key(), nil
+	}, jwt.WithLeeway(leeway))
 	if err != nil {
-		return "", 0, err
+		return nil, err
 	}
 
 	// Validate the token and extract the claims
 	if claims, ok := token.Claims.(*CustomClaims); ok && token.Valid {
 		// Check the purpose
-		if claims.Purpose == purpose {
-			return claims.Subject, claims.Role, nil
+		if claims.Purpose != purpose {
+			return nil, errors.New("invalid token")
+		}
+
+		// Access tokens issued before an import are no longer accepted
+		notBefore := d.conf.SP.Get(global.ConfigTokensNotBefore).Int64()
+		if purpose == schema.TokenPurposeAccess && notBefore > 0 &&
+			(claims.IssuedAt == nil || claims.IssuedAt.Unix() < notBefore) {
+			return nil, errors.New("token revoked")
+		}
+
+		// Tokens can be revoked individually, such as when a user logs out
+		if d.database.TokenRevoked(claims.ID) {
+			return nil, errors.New("token revoked")
+		}
+
+		// All tokens issued to a user or agent before its sessions were revoked are rejected
+		revoked := d.database.SubjectNotBefore(claims.Subject)
+		if revoked > 0 && (claims.IssuedAt == nil || claims.IssuedAt.Unix() < revoked) {
+			return nil, errors.New("token revoked")
 		}
+		return claims, nil
 	}
-	return "", 0, errors.New("invalid token")
+	return nil, errors.New("invalid token")
+}
+
+// RevokeRefreshToken revokes a refresh token so that it can no longer be used to obtain
+// access tokens, and returns its subject
+func (d *Data) RevokeRefreshToken(tokenString string) (string, error) {
+	claims, err := d.parseToken(tokenString, schema.TokenPurposeRefresh)
+	if err != nil {
+		return "", err
+	}
+
+	var expires time.Time
+	if claims.ExpiresAt != nil {
+		expires = claims.ExpiresAt.Time
+	}
+
+	err = d.database.RevokeToken(claims.ID, expires)
+	if err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+// RevokeUserSessions revokes all access and refresh tokens issued to a user so far, which
+// must then log in again. Login accounts without user metadata are included.
+func (d *Data) RevokeUserSessions(user string) error {
+	exists, _ := d.UserExists(user)
+	if !exists {
+		authExists, _ := d.database.AuthExists(user)
+		if !authExists {
+			return ErrUserNotFound
+		}
+	}
+	return d.revokeUserSessions(user)
+}
+
+// RevokeAgentSessions revokes all access and refresh tokens issued to an agent so far,
+// which must then be registered again
+func (d *Data) RevokeAgentSessions(agentID string) error {
+	exists, _ := d.database.KeyExists(db.BucketAgentMeta, agentID)
+	if !exists {
+		return ErrAgentNotFound
+	}
+	return d.revokeAgentSessions(agentID)
+}
+
+func (d *Data) revokeUserSessions(user string) error {
+	return d.revokeSessions(user,
+		d.conf.SC.Get(global.ConfigAccessTokenLife).Int(),
+		d.conf.SC.Get(global.ConfigRefreshTokenLifeUsers).Int())
+}
+
+func (d *Data) revokeAgentSessions(agentID string) error {
+	return d.revokeSessions(agentID,
+		d.conf.SC.Get(global.ConfigAccessTokenLife).Int(),
+		d.conf.SC.Get(global.ConfigRefreshTokenLifeAgents).Int())
+}
+
+// revokeSessions rejects tokens issued to the subject before the start of the next second,
+// because tokens record the time they were issued in seconds. The record is only needed
+// until the longest-lived of those tokens expires; if either lifetime is unlimited, it is
+// kept indefinitely.
+func (d *Data) revokeSessions(subject string, lifetimes ...int) error {
+	notBefore := time.Now().Truncate(time.Second).Add(time.Second)
+
+	var expires time.Time
+	maxLife := 0
+	for _, life := range lifetimes {
+		if life <= 0 {
+			maxLife = 0
+			break
+		}
+		maxLife = max(maxLife, life)
+	}
+	if maxLife > 0 {
+		expires = notBefore.Add(time.Duration(maxLife) * time.Minute)
+	}
+
+	err := d.database.RevokeSubject(subject, notBefore, expires)
+	if err != nil {
+		return err
+	}
+	d.logger.Info(2739, "sessions revoked", fields.NewFields(fields.NewField("subject", subject)))
+	return nil
 }
 
 type TokenRefreshData struct {
@@ -133,42 +247,40 @@ func (d *Data) RefreshToken(refreshToken string, clientPublicSig string, clientP
 
 	// If this is an agent and new client public keys are provided, update them (for rekey scenarios)
 	if role == schema.RoleAgent && (clientPublicSig != "" || clientPublicEnc != "") {
-		meta, err := d.database.GetAgentMeta(subject)
-		if err != nil {
-			return TokenRefreshData{}, fmt.Errorf("failed to get agent metadata: %w", err)
-		}
-
-		// Check for agent public key changes (should never happen - indicates potential security issue)
-		keyUpdated := false
-		if clientPublicSig != "" {
-			if meta.ClientPublicSig == "" {
-				// No existing key - store it
-				meta.ClientPublicSig = clientPublicSig
-				keyUpdated = true
-				d.logger.Info(6020, "agent public signature key received and stored", nil)
-			} else if meta.ClientPublicSig != clientPublicSig {
-				// Key changed - security warning, do NOT update
-				d.logger.Warning(6022, "different agent public signature key received and ignored (possible security issue)", nil)
+		err := d.UpdateAgentMeta(subject, func(meta *schema.AgentMeta) error {
+			// Check for agent public key changes (should never happen - indicates potential security issue)
+			keyUpdated := false
+			if clientPublicSig != "" {
+				if meta.ClientPublicSig == "" {
+					// No existing key - store it
+					meta.ClientPublicSig = clientPublicSig
+					keyUpdated = true
+					d.logger.Info(6020, "agent public signature key received and stored", nil)
+				} else if meta.ClientPublicSig != clientPublicSig {
+					// Key changed - security warning, do NOT update
+					d.logger.Warning(6022, "different agent public signature key received and ignored (possible security issue)", nil)
+				}
 			}
-		}
-		if clientPublicEnc != "" {
-			if meta.ClientPublicEnc == "" {
-				// No existing key - store it
-				meta.ClientPublicEnc = clientPublicEnc
-				keyUpdated = true
-				d.logger.Info(6021, "agent public encryption key received and stored", nil)
-			} else if meta.ClientPublicEnc != clientPublicEnc {
-				// Key changed - security warning, do NOT update
-				d.logger.Warning(6023, "different agent public encryption key received and ignored (possible security issue)", nil)
+			if clientPublicEnc != "" {
+				if meta.ClientPublicEnc == "" {
+					// No existing key - store it
+					meta.ClientPublicEnc = clientPublicEnc
+					keyUpdated = true
+					d.logger.Info(6021, "agent public encryption key received and stored", nil)
+				} else if meta.ClientPublicEnc != clientPublicEnc {
+					// Key changed - security warning, do NOT update
+					d.logger.Warning(6023, "different agent public encryption key received and ignored (possible security issue)", nil)
+				}
 			}
-		}
 
-		// Only update metadata if keys were actually changed
-		if keyUpdated {
-			err = d.database.SetAgentMeta(meta)
-			if err != nil {
-				return TokenRefreshData{}, fmt.Errorf("failed to update agent metadata: %w", err)
+			// Only update metadata if keys were actually changed
+			if !keyUpdated {
+				return errAgentUnchanged
 			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errAgentUnchanged) {
+			return TokenRefreshData{}, fmt.Errorf("failed to update agent metadata: %w", err)
 		}
 	}
 
@@ -0,0 +1,120 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/common/semver"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// upgradeRequestInterval limits automatic upgrade requests to avoid loops
+// if an agent is unable to upgrade
+const upgradeRequestInterval = 24 * time.Hour
+
+// enforceMinimumVersion queues an upgrade request if the agent is older than the
+// configured minimum version. It is called each time an agent syncs.
+func (d *Data) enforceMinimumVersion(agentID, version string, build int) {
+	minimum := d.conf.SC.Get(global.ConfigMinimumAgentVersion).String()
+	if minimum == "" {
+		return
+	}
+
+	f := fields.NewFields(
+		fields.NewField("id", agentID),
+		fields.NewField("version", version),
+		fields.NewField("build", build),
+		fields.NewField("minimum", minimum))
+
+	minVersion, err := semver.Parse(minimum)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		d.logger.Warning(2718, "invalid minimum agent version", f)
+		return
+	}
+
+	agentVersion, err := semver.New(version, build)
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		d.logger.Warning(2768, "unable to parse agent version", f)
+		return
+	}
+
+	// Clear the pending flag once the agent has been upgraded
+	if !agentVersion.Less(minVersion) {
+		err = d.UpdateAgentMeta(agentID, func(meta *schema.AgentMeta) error {
+			if !meta.UpgradePending {
+				return errAgentUnchanged
+			}
+			meta.UpgradePending = false
+			return nil
+		})
+		if err != nil && !errors.Is(err, errAgentUnchanged) && !errors.Is(err, ErrAgentNotFound) {
+			f.Append(fields.NewField("error", err.Error()))
+			d.logger.Error(2719, "error updating agent metadata", f)
+		}
+		return
+	}
+
+	// Claim the request before queuing it, so that concurrent syncs do not queue it twice
+	var previous time.Time
+	err = d.UpdateAgentMeta(agentID, func(meta *schema.AgentMeta) error {
+		if time.Since(meta.UpgradeRequested) < upgradeRequestInterval {
+			return errAgentUnchanged
+		}
+		previous = meta.UpgradeRequested
+		meta.UpgradePending = true
+		meta.UpgradeRequested = time.Now()
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, errAgentUnchanged) && !errors.Is(err, ErrAgentNotFound) {
+			f.Append(fields.NewField("error", err.Error()))
+			d.logger.Error(2769, "error updating agent metadata", f)
+		}
+		return
+	}
+
+	requestID, err := d.AddAgentRequest(schema.AgentRequest{
+		AgentID:     agentID,
+		Requester:   "server",
+		Request:     commands.Upgrade,
+		AckRequired: false,
+		Parameters:  map[string]string{commands.AgentID: agentID}})
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		d.logger.Error(2775, "error queuing automatic upgrade", f)
+
+		// Release the claim so that the next sync tries again
+		_ = d.UpdateAgentMeta(agentID, func(meta *schema.AgentMeta) error {
+			meta.UpgradePending = false
+			meta.UpgradeRequested = previous
+			return nil
+		})
+		return
+	}
+
+	msg := fmt.Sprintf("automatic upgrade requested, agent version %s is older than minimum %s",
+		agentVersion.String(), minVersion.String())
+	err = d.AddEvent(schema.AgentEvent{
+		AgentID:   agentID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventMessage,
+		Event:     msg})
+	if err != nil {
+		f.Append(fields.NewField("error", err.Error()))
+		d.logger.Error(2776, "failed to add event to event store", f)
+	}
+
+	f.Append(fields.NewField("requestID", requestID))
+	d.logger.Info(2720, "automatic upgrade requested", f)
+}
@@ -0,0 +1,163 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/schema/commands"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// Errors returned by the agent upload functions so that the API can select a suitable status code
+var (
+	ErrUploadNotPermitted = errors.New("upload not permitted for this request")
+	ErrUploadTooLarge     = errors.New("upload exceeds the maximum file size")
+	ErrUploadNotFound     = errors.New("upload not found")
+)
+
+// SaveAgentUpload stores a file uploaded by an agent in response to a file_fetch or logs_fetch request.
+// The file is stored in <files path>/uploads/<agent ID>/<request ID>/<name>.
+func (d *Data) SaveAgentUpload(agentID, requestID, name string, r io.Reader, maxBytes int64) (schema.AgentUpload, error) {
+	upload := schema.AgentUpload{AgentID: agentID, RequestID: requestID}
+
+	// The request must be a pending file_fetch or logs_fetch request for the uploading agent
+	record, err := d.database.GetAgentRequest(requestID)
+	if err != nil {
+		return upload, ErrUploadNotPermitted
+	}
+	if record.AgentID != agentID || !uploadPermitted(record.Request) ||
+		record.Cancelled || record.Status != schema.RequestStatusPending {
+		return upload, ErrUploadNotPermitted
+	}
+
+	// Only keep the final element of the name and reject anything that could escape the directory
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return upload, errors.New("invalid file name")
+	}
+	upload.Name = name
+
+	dir, err := d.uploadDir(agentID, requestID)
+	if err != nil {
+		return upload, err
+	}
+
+	// Remove any previous upload for this request
+	_ = os.RemoveAll(dir)
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return upload, fmt.Errorf("error creating upload directory: %w", err)
+	}
+
+	// The temporary file is created alongside the request directory so that it is never served
+	tmp, err := os.CreateTemp(filepath.Dir(dir), requestID+"-*.tmp")
+	if err != nil {
+		return upload, fmt.Errorf("error creating temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	// Copy one byte more than the maximum to detect oversized files
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, maxBytes+1))
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && size > maxBytes {
+		err = ErrUploadTooLarge
+	}
+	if err == nil {
+		err = os.Rename(tmpName, filepath.Join(dir, name))
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		if errors.Is(err, ErrUploadTooLarge) {
+			return upload, err
+		}
+		return upload, fmt.Errorf("error writing upload: %w", err)
+	}
+
+	upload.Size = size
+	upload.SHA256 = base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	d.logger.Info(2715, "agent upload stored",
+		fields.NewFields(
+			fields.NewField("agent_id", agentID),
+			fields.NewField("request_id", requestID),
+			fields.NewField("name", name),
+			fields.NewField("size", size),
+			fields.NewField("sha256", upload.SHA256)))
+
+	return upload, nil
+}
+
+// GetAgentUpload returns the path and details of the file uploaded in response to a file_fetch or logs_fetch request
+func (d *Data) GetAgentUpload(requestID string) (string, schema.AgentUpload, error) {
+	upload := schema.AgentUpload{RequestID: requestID}
+
+	record, err := d.database.GetAgentRequest(requestID)
+	if err != nil || !uploadPermitted(record.Request) {
+		return "", upload, ErrUploadNotFound
+	}
+	upload.AgentID = record.AgentID
+
+	dir, err := d.uploadDir(record.AgentID, requestID)
+	if err != nil {
+		return "", upload, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", upload, ErrUploadNotFound
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		upload.Name = entry.Name()
+		upload.Size = info.Size()
+		return filepath.Join(dir, entry.Name()), upload, nil
+	}
+	return "", upload, ErrUploadNotFound
+}
+
+// uploadDir returns the directory used to store an agent's upload for a request
+func (d *Data) uploadDir(agentID, requestID string) (string, error) {
+	filesPath := d.conf.SC.Get(global.ConfigFilesPath).String()
+	if filesPath == "" {
+		return "", errors.New("files path not configured")
+	}
+
+	// Agent and request IDs are generated by the server, but check them anyway
+	for _, id := range []string{agentID, requestID} {
+		if id == "" || strings.ContainsAny(id, "/\\.") {
+			return "", fmt.Errorf("invalid ID: %s", id)
+		}
+	}
+
+	return filepath.Join(filesPath, global.UploadsDir, agentID, requestID), nil
+}
+
+// uploadPermitted returns true if agents may upload a file in response to the command
+func uploadPermitted(cmd string) bool {
+	return cmd == commands.FileFetch || cmd == commands.LogsFetch
+}
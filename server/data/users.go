@@ -7,20 +7,54 @@ package data
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/server/db"
 )
 
+// ErrUserNotFound is returned when neither a user nor a login account exists
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrAgentUserID is returned when a user would be created with an agent ID
+var ErrAgentUserID = errors.New("user ID is reserved for agents")
+
 // AddUser adds a new user to the database. Returns error if username already exists.
+// If a password is supplied, a login account is created with the requested role.
+// The caller is responsible for deciding whether the role may be assigned.
 func (d *Data) AddUser(user schema.UserCreateRequest) (schema.UserMeta, error) {
-	// Check for existing username
+	if schema.IsAgentID(user.User) {
+		return schema.UserMeta{}, ErrAgentUserID
+	}
+
+	// Check for existing username. Login accounts without a user record, such as the super admin
+	// created by "uem-server admin", and agents are also in the auth bucket, so SetAuth must not
+	// be allowed to replace them.
 	existing, _ := d.GetUserByID(user.User)
 	if existing != nil {
 		return schema.UserMeta{}, fmt.Errorf("user already exists")
 	}
+	exists, err := d.database.AuthExists(user.User)
+	if err != nil {
+		return schema.UserMeta{}, err
+	}
+	if exists {
+		return schema.UserMeta{}, fmt.Errorf("user already exists")
+	}
+
+	if user.Password != "" {
+		role := schema.RoleByName(user.Role)
+		if role == schema.RoleNone {
+			return schema.UserMeta{}, fmt.Errorf("invalid role: %s", user.Role)
+		}
+
+		if err := d.database.SetAuth(user.User, user.Password, role); err != nil {
+			return schema.UserMeta{}, err
+		}
+	}
 
 	now := time.Now()
 	meta := schema.UserMeta{
@@ -30,7 +64,11 @@ func (d *Data) AddUser(user schema.UserCreateRequest) (schema.UserMeta, error) {
 		CreatedAt:   now,
 		LastUpdated: now,
 	}
-	err := d.database.SetData(db.BucketUserMeta, user.User, meta)
+	if user.Password != "" {
+		meta.Role = strings.ToLower(user.Role)
+	}
+
+	err = d.database.SetData(db.BucketUserMeta, user.User, meta)
 	if err != nil {
 		return schema.UserMeta{}, err
 	}
@@ -74,7 +112,20 @@ func (d *Data) UserExists(user string) (bool, error) {
 	return true, nil
 }
 
-// DeleteUser deletes a user by UserID.
+// DeleteUser deletes a user by UserID along with any login account.
 func (d *Data) DeleteUser(user string) error {
-	return d.database.DeleteData(db.BucketUserMeta, user)
+	meta, _ := d.GetUserByID(user)
+
+	err := d.database.DeleteData(db.BucketUserMeta, user)
+	if err != nil {
+		return err
+	}
+
+	// Only remove login accounts that were created through user management
+	if meta != nil && meta.Role != "" {
+		_ = d.database.DeleteAuth(user)
+	}
+
+	// Tokens already issued to the user must not outlive the account
+	return d.revokeUserSessions(user)
 }
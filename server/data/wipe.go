@@ -0,0 +1,135 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package data
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// Confirmation codes avoid characters that are easily confused, such as 0 and O
+const (
+	wipeCodeLength   = 8
+	wipeCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	ErrNoWipePending   = errors.New("no wipe is pending for this agent")
+	ErrWipeCodeExpired = errors.New("wipe confirmation code has expired")
+	ErrWipeCodeInvalid = errors.New("wipe confirmation code is incorrect")
+)
+
+// NewWipePending creates a pending wipe for the agent and returns it with the confirmation
+// code. Only a keyed hash of the code is stored so that it cannot be read from the agent's
+// metadata.
+func (d *Data) NewWipePending(agentID, requester string) (*schema.WipePending, string, error) {
+	random := make([]byte, wipeCodeLength)
+	_, err := rand.Read(random)
+	if err != nil {
+		return nil, "", err
+	}
+
+	code := make([]byte, wipeCodeLength)
+	for x, b := range random {
+		code[x] = wipeCodeAlphabet[int(b)%len(wipeCodeAlphabet)]
+	}
+
+	now := time.Now()
+	return &schema.WipePending{
+		Requester: requester,
+		Requested: now,
+		Expires:   now.Add(global.WipeCodeTTL * time.Second),
+		CodeHash:  d.wipeCodeHash(agentID, string(code)),
+	}, string(code), nil
+}
+
+// ConfirmWipe sets the wipe trigger if the code matches the agent's pending wipe. Expired
+// wipes, and wipes with too many incorrect codes, are cancelled.
+func (d *Data) ConfirmWipe(agentID, code, requester string) error {
+	// The code is checked and the trigger is set in a single update, so that a concurrent
+	// cancellation or trigger reset is not overwritten. Results that change the pending wipe
+	// are returned after the update is stored.
+	var result error
+	cancelled := false
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	err := d.UpdateAgentMeta(agentID, func(meta *schema.AgentMeta) error {
+		pending := meta.WipePending
+		if pending == nil {
+			return ErrNoWipePending
+		}
+
+		if time.Now().After(pending.Expires) {
+			meta.WipePending = nil
+			result = ErrWipeCodeExpired
+			return nil
+		}
+
+		// With wipe in approval_commands, a wipe must be confirmed by a different administrator
+		if d.ApprovalRequired(ApprovalWipe) && requester == pending.Requester {
+			return ErrSelfApproval
+		}
+
+		if !hmac.Equal([]byte(d.wipeCodeHash(agentID, code)), []byte(pending.CodeHash)) {
+			pending.Attempts++
+			if pending.Attempts >= global.WipeCodeAttempts {
+				meta.WipePending = nil
+				cancelled = true
+			}
+			result = ErrWipeCodeInvalid
+			return nil
+		}
+
+		meta.WipePending = nil
+		meta.Triggers.Wipe = true
+		meta.Triggers.WipeMode = pending.Mode
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if cancelled {
+		err = d.AddWipeEvent(agentID, "cancelled after too many incorrect confirmation codes", requester)
+		if err != nil {
+			d.logger.Error(2729, "failed to add wipe event", fields.NewFields(
+				fields.NewField("agent_id", agentID),
+				fields.NewField("error", err.Error())))
+		}
+	}
+	if result != nil {
+		return result
+	}
+
+	return d.AddTriggerEvent(agentID, "wipe", requester)
+}
+
+// AddWipeEvent records a change to a pending wipe, such as a request or cancellation
+func (d *Data) AddWipeEvent(agentID, action, requester string) error {
+	return d.AddEvent(schema.AgentEvent{
+		AgentID:   agentID,
+		Time:      time.Now(),
+		EventType: schema.AgentEventAlert,
+		Event:     fmt.Sprintf("wipe %s", action),
+		Details:   map[string]string{"trigger": "wipe", "requester": requester}})
+}
+
+// wipeCodeHash returns the hex HMAC-SHA256 of the agent ID and confirmation code
+func (d *Data) wipeCodeHash(agentID, code string) string {
+	mac := hmac.New(sha256.New, d.key())
+	mac.Write([]byte(agentID + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -22,6 +22,29 @@ func (d *DB) SetAgentMeta(meta schema.AgentMeta) error {
 		return fmt.Errorf("failed to store agent metadata: %w", err)
 	}
 
+	d.indexAgent(meta)
+	return nil
+}
+
+// UpdateAgentMeta calls fn to modify the agent metadata and stores the result within a single
+// transaction so that concurrent updates are not lost. If fn returns an error, nothing is changed
+// and the error is returned unwrapped.
+func (d *DB) UpdateAgentMeta(agentID string, fn func(*schema.AgentMeta) error) error {
+	var meta schema.AgentMeta
+	var fnErr error
+
+	err := d.UpdateData(BucketAgentMeta, validateKey(agentID), &meta, func() error {
+		fnErr = fn(&meta)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update agent metadata: %w", err)
+	}
+
+	d.indexAgent(meta)
 	return nil
 }
 
@@ -42,6 +65,8 @@ func (d *DB) DeleteAgentMeta(agentID string) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete agent metadata: %w", err)
 	}
+
+	d.unindexAgent(agentID)
 	return nil
 }
 
@@ -58,30 +83,44 @@ func (d *DB) GetOrCreateAgentMeta(agentID string) (schema.AgentMeta, error) {
 	return meta, nil
 }
 
-// AgentSync updates the agent metadata and returns triggers
-func (d *DB) AgentSync(agentID string, ip string, version string, build int) (schema.AgentTriggers, error) {
+// SyncResult is returned by AgentSync
+type SyncResult struct {
+	Triggers   schema.AgentTriggers
+	WasOffline bool           // the agent had been marked offline
+	PreviousIP schema.AgentIP // the address the agent last synced from before this sync
+}
 
-	// Ensure all flags are set to false
-	triggers := schema.NewAgentTriggers()
+// AgentSync updates the agent metadata, including its IP history, which is limited to
+// historySize addresses, and returns its triggers
+func (d *DB) AgentSync(agentID string, ip schema.AgentIP, version string, build int, historySize int) (SyncResult, error) {
 
-	meta, err := d.GetOrCreateAgentMeta(agentID)
-	if err != nil {
-		return triggers, err
+	// Ensure all flags are set to false
+	result := SyncResult{Triggers: schema.NewAgentTriggers()}
+
+	update := func(meta *schema.AgentMeta) error {
+		meta.LastSeen = time.Now()
+		meta.LastIP = ip.IP
+		meta.Version = version
+		meta.Build = build
+		result.WasOffline = meta.Offline
+		meta.Offline = false
+		result.PreviousIP = meta.RecordIP(ip, historySize)
+
+		// Update any triggers
+		result.Triggers = meta.Triggers
+		return nil
 	}
 
-	meta.LastSeen = time.Now()
-	meta.LastIP = ip
-	meta.Version = version
-	meta.Build = build
-
-	// Update any triggers
-	triggers = meta.Triggers
-
-	// Save the updated metadata
-	err = d.SetAgentMeta(meta)
+	err := d.UpdateAgentMeta(agentID, update)
+	if err != nil && strings.Contains(err.Error(), "key not found") {
+		// Create the metadata for an agent that does not have any
+		meta := schema.NewAgentMeta(agentID)
+		_ = update(&meta)
+		err = d.SetAgentMeta(meta)
+	}
 	if err != nil {
-		return triggers, err
+		return result, err
 	}
 
-	return triggers, nil
+	return result, nil
 }
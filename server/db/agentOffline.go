@@ -0,0 +1,53 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// errNotOffline is returned by the update function to leave an agent unchanged
+var errNotOffline = errors.New("agent is not offline")
+
+// SetAgentsOffline marks agents that have not synced since cutoff as offline and returns the
+// ones that were not already marked. Agents that have uninstalled themselves are ignored.
+func (d *DB) SetAgentsOffline(cutoff time.Time) ([]schema.AgentMeta, error) {
+	all, err := d.GetAllAgentMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	offline := func(meta *schema.AgentMeta) bool {
+		return !meta.Offline && meta.State != schema.AgentStateUninstalled && meta.LastSeen.Before(cutoff)
+	}
+
+	var changed []schema.AgentMeta
+	for _, agent := range all.Agents {
+		if !offline(&agent) {
+			continue
+		}
+
+		// Check again within the transaction in case the agent synced in the meantime
+		var updated schema.AgentMeta
+		err = d.UpdateAgentMeta(agent.AgentID, func(meta *schema.AgentMeta) error {
+			if !offline(meta) {
+				return errNotOffline
+			}
+			meta.Offline = true
+			updated = *meta
+			return nil
+		})
+		if err == nil {
+			changed = append(changed, updated)
+		} else if !errors.Is(err, errNotOffline) {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
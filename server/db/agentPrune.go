@@ -14,12 +14,17 @@ import (
 )
 
 // PruneAgents removes agents that have been inactive for more than the specified number of days
-func (d *DB) PruneAgents(days int) error {
+// and returns the number removed
+func (d *DB) PruneAgents(days int) (int, error) {
 
 	// Calculate the cutoff time
 	cutoff := time.Now().AddDate(0, 0, -days)
+	count := 0
 
-	// Iterate over all keys in the AgentMeta bucket
+	// Find the agents to delete, then delete them. Writing while the bucket is being read
+	// deadlocks if the write has to grow the database.
+	var badKeys []string
+	prune := make(map[string]schema.AgentMeta)
 	err := d.ForEach(BucketAgentMeta, func(key, value []byte) error {
 		var meta schema.AgentMeta
 		err := d.deserialize(value, &meta)
@@ -30,38 +35,49 @@ func (d *DB) PruneAgents(days int) error {
 					fields.NewField("error", err.Error())))
 
 			// Attempt to delete the bad record
-			_ = d.DeleteData(BucketAgentMeta, string(key))
+			badKeys = append(badKeys, string(key))
 			return nil
 		}
 
 		// Check if the agent is inactive and should be pruned
 		if meta.LastSeen.Before(cutoff) {
+			prune[string(key)] = meta
+		}
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("failed to prune agents: %w", err)
+	}
+
+	for _, key := range badKeys {
+		_ = d.DeleteData(BucketAgentMeta, key)
+	}
+
+	for key, meta := range prune {
 
-			// Delete any outstanding requests on a best-effort basis
-			_ = d.DeleteAgentRequests(meta.AgentID)
+		// Delete any outstanding requests on a best-effort basis
+		_ = d.DeleteAgentRequests(meta.AgentID)
 
-			// Delete agent events on a best-effort basis
-			_ = d.DeleteAllEvents(meta.AgentID)
+		// Delete agent events on a best-effort basis
+		_ = d.DeleteAllEvents(meta.AgentID)
 
-			// Delete the agent metadata
-			err = d.DeleteData(BucketAgentMeta, string(key))
-			if err != nil {
-				// Log the error but continue so that one bad record doesn't stop the whole process
-				d.logger.Warning(3012, "pruning failed to delete agent metadata",
-					fields.NewFields(
-						fields.NewField("agent_id", meta.AgentID),
-						fields.NewField("last_seen", meta.LastSeen),
-						fields.NewField("error", err.Error())))
-			} else {
-				d.logger.Info(3010, "pruned agent", fields.NewFields(
+		// Delete the agent metadata
+		err = d.DeleteData(BucketAgentMeta, key)
+		if err != nil {
+			// Log the error but continue so that one bad record doesn't stop the whole process
+			d.logger.Warning(3012, "pruning failed to delete agent metadata",
+				fields.NewFields(
 					fields.NewField("agent_id", meta.AgentID),
-					fields.NewField("last_seen", meta.LastSeen)))
-			}
+					fields.NewField("last_seen", meta.LastSeen),
+					fields.NewField("error", err.Error())))
+			continue
 		}
-		return nil
-	})
-	if err != nil {
-		return fmt.Errorf("failed to prune agents: %w", err)
+
+		count++
+		d.unindexAgent(meta.AgentID)
+		d.logger.Info(3010, "pruned agent", fields.NewFields(
+			fields.NewField("agent_id", meta.AgentID),
+			fields.NewField("last_seen", meta.LastSeen)))
 	}
-	return nil
+	return count, nil
 }
@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"time"
 
+	"go.etcd.io/bbolt"
+
 	"github.com/UnifyEM/UnifyEM/common/fields"
 
 	"github.com/UnifyEM/UnifyEM/common/schema"
@@ -22,18 +24,59 @@ func (d *DB) SetAgentRequest(request schema.AgentRequestRecord) error {
 	request.LastUpdated = time.Now()
 
 	// Validate critical fields
-	if request.AgentID == "" {
-		return errors.New("agentID is required")
+	if request.AgentID == "" && request.Tag == "" {
+		return errors.New("agentID or tag is required")
 	}
 	if request.RequestID == "" {
 		return errors.New("requestID is required")
 	}
 
-	// Use the SetData function to serialize and store the agent
-	//err := d.SetData(BucketAgentRequests, CombineKeys(request.AgentID, request.RequestID), request)
-	err := d.SetData(BucketAgentRequests, request.RequestID, request)
+	data, err := d.serialize(request)
+	if err != nil {
+		return fmt.Errorf("failed to serialize request %s: %w", request.RequestID, err)
+	}
+
+	// Store the request and add it to the index
+	err = d.update(func(tx *bbolt.Tx) error {
+		return putRequest(tx, request, data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store agent request: %w", err)
+	}
+	return nil
+}
+
+// SetAgentRequests stores multiple agent requests in a single transaction. Either all
+// of the requests are stored or none of them are.
+func (d *DB) SetAgentRequests(requests []schema.AgentRequestRecord) error {
+	now := time.Now()
+	data := make([][]byte, len(requests))
+	for i, request := range requests {
+		if request.AgentID == "" {
+			return errors.New("agentID is required")
+		}
+		if request.RequestID == "" {
+			return errors.New("requestID is required")
+		}
+		request.LastUpdated = now
+
+		value, err := d.serialize(request)
+		if err != nil {
+			return fmt.Errorf("failed to serialize request %s: %w", request.RequestID, err)
+		}
+		data[i] = value
+	}
+
+	err := d.update(func(tx *bbolt.Tx) error {
+		for i, request := range requests {
+			if err := putRequest(tx, request, data[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to store agent agent: %w", err)
+		return fmt.Errorf("failed to store agent requests: %w", err)
 	}
 	return nil
 }
@@ -45,56 +88,69 @@ func (d *DB) GetAgentRequest(requestKey string) (schema.AgentRequestRecord, erro
 	return result, err
 }
 
-// GetAgentRequests retrieves all agent requests for a given agent
-func (d *DB) GetAgentRequests(agentID string) ([]schema.AgentRequestRecord, error) {
-	var result []schema.AgentRequestRecord
-	err := d.ForEach(BucketAgentRequests, func(key, value []byte) error {
-		var request schema.AgentRequestRecord
-		err := d.deserialize(value, &request)
+// UpdateAgentRequest retrieves an agent request, calls fn to modify it, and stores the
+// result within a single transaction. The updated request is returned.
+func (d *DB) UpdateAgentRequest(requestKey string, fn func(request *schema.AgentRequestRecord) error) (schema.AgentRequestRecord, error) {
+	request := schema.NewDBAgentRequest()
+	err := d.UpdateData(BucketAgentRequests, requestKey, &request, func() error {
+		err := fn(&request)
 		if err != nil {
-			// Delete the bad record
-			_ = d.DeleteData(BucketAgentRequests, string(key))
-			return fmt.Errorf("failed to deserialize agent request, deleting bad record %s: %w", key, err)
-		}
-		if request.AgentID == agentID {
-			result = append(result, request)
+			return err
 		}
+
+		// Always update the LastUpdated field
+		request.LastUpdated = time.Now()
 		return nil
 	})
+	return request, err
+}
 
+// GetAgentRequests retrieves all agent requests for a given agent
+func (d *DB) GetAgentRequests(agentID string) ([]schema.AgentRequestRecord, error) {
+	result, err := d.getIndexedRequests(agentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve agent requests: %w", err)
 	}
+	return result, nil
+}
 
+// GetTagRequests retrieves all tag requests, which are delivered to each agent with the tag
+func (d *DB) GetTagRequests() ([]schema.AgentRequestRecord, error) {
+	result, err := d.getIndexedRequests(tagRequestIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve tag requests: %w", err)
+	}
 	return result, nil
 }
 
 // DeleteAgentRequest deletes an agent request from the database
 func (d *DB) DeleteAgentRequest(requestKey string) error {
-	return d.DeleteData(BucketAgentRequests, requestKey)
+	return d.update(func(tx *bbolt.Tx) error {
+		return deleteRequest(tx, requestKey)
+	})
 }
 
 // DeleteAgentRequests deletes all requests for the specified agent
 func (d *DB) DeleteAgentRequests(agentID string) error {
-	// Iterate over all requests and delete those that match the agentID
-	// TODO: optimize with prefix scan when request keys include agentID prefix
-	err := d.ForEach(BucketAgentRequests, func(key, value []byte) error {
-		var request schema.AgentRequestRecord
-		err := d.deserialize(value, &request)
-		if err != nil {
-			return fmt.Errorf("failed to deserialize agent request: %w", err)
-		}
-		if request.AgentID == agentID {
-			err = d.DeleteData(BucketAgentRequests, string(key))
-			if err != nil {
-				return fmt.Errorf("failed to delete agent request: %w", err)
-			}
+	return d.update(func(tx *bbolt.Tx) error {
+		index := tx.Bucket([]byte(BucketRequestIndex))
+		if index == nil || index.Bucket([]byte(agentID)) == nil {
+			return nil
 		}
 
-		return nil
+		requests := tx.Bucket([]byte(BucketAgentRequests))
+		if requests == nil {
+			return fmt.Errorf("%s bucket not found", BucketAgentRequests)
+		}
 
+		err := index.Bucket([]byte(agentID)).ForEach(func(key, _ []byte) error {
+			return requests.Delete(key)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete agent request: %w", err)
+		}
+		return index.DeleteBucket([]byte(agentID))
 	})
-	return err
 }
 
 // CancelAgentRequest cancels an agent request
@@ -107,8 +163,10 @@ func (d *DB) CancelAgentRequest(requestKey string) error {
 		return fmt.Errorf("failed to get agent request: %w", err)
 	}
 
-	if result.Status == schema.RequestStatusNew || result.Status == schema.RequestStatusPending {
+	if result.Status == schema.RequestStatusNew || result.Status == schema.RequestStatusPending ||
+		result.Status == schema.RequestStatusActive || result.Status == schema.RequestStatusAwaitingApproval {
 		result.Status = schema.RequestStatusCancelled
+		result.TimeCompleted = time.Now()
 		return d.SetAgentRequest(result)
 
 	}
@@ -117,30 +175,34 @@ func (d *DB) CancelAgentRequest(requestKey string) error {
 
 // CancelAgentRequests sets status to cancelled for all pending requests for the specified agent
 func (d *DB) CancelAgentRequests(agentID string) error {
-	// Iterate over all requests and cancel those that match the agentID
-	// TODO: optimize with prefix scan when request keys include agentID prefix
-	err := d.ForEach(BucketAgentRequests, func(key, value []byte) error {
-		var request schema.AgentRequestRecord
-		err := d.deserialize(value, &request)
+	requests, err := d.GetAgentRequests(agentID)
+	if err != nil {
+		return err
+	}
+
+	for _, request := range requests {
+		err = d.CancelAgentRequest(request.RequestID)
 		if err != nil {
-			return fmt.Errorf("failed to deserialize agent request: %w", err)
+			return fmt.Errorf("failed to cancel agent request: %w", err)
 		}
-		if request.AgentID == agentID {
-			err = d.CancelAgentRequest(string(key))
-			if err != nil {
-				return fmt.Errorf("failed to cancel agent request: %w", err)
-			}
-		}
-		return nil
-	})
-	return err
+	}
+	return nil
 }
 
-// PruneAgentRequests deletes all request older than the specified number of days
-func (d *DB) PruneAgentRequests(days int) error {
-	cutoffTime := time.Now().AddDate(0, 0, -days).Unix()
+// PruneRequests deletes requests that reached a terminal status before a cutoff. Completed,
+// cancelled, and denied requests are kept for completedDays and failed, refused, invalid, and
+// expired requests for failedDays; zero keeps them indefinitely. Requests that are still
+// pending, awaiting approval, or being delivered are never pruned. It returns the number of
+// completed and failed requests deleted.
+func (d *DB) PruneRequests(completedDays, failedDays int) (int, int, error) {
+	now := time.Now()
+	completedCutoff := now.AddDate(0, 0, -completedDays)
+	failedCutoff := now.AddDate(0, 0, -failedDays)
 
-	return d.ForEach(BucketAgentRequests, func(key, value []byte) error {
+	// Find the requests to delete, then delete them so that the bucket isn't
+	// modified while it is being read
+	var prune []schema.AgentRequestRecord
+	err := d.ForEach(BucketAgentRequests, func(key, value []byte) error {
 		var request schema.AgentRequestRecord
 		err := d.deserialize(value, &request)
 		if err != nil {
@@ -150,28 +212,60 @@ func (d *DB) PruneAgentRequests(days int) error {
 					fields.NewField("error", err.Error())))
 
 			// Attempt to delete the bad record
-			_ = d.DeleteData(BucketAgentRequests, string(key))
+			prune = append(prune, schema.AgentRequestRecord{RequestID: string(key)})
 			return nil
 		}
 
-		if request.LastUpdated.Unix() < cutoffTime {
-			err = d.DeleteData(BucketAgentRequests, string(key))
-			if err != nil {
-				// Log the error but continue so that one bad record doesn't stop the whole process
-				d.logger.Warning(3031, "pruning failed to delete request",
-					fields.NewFields(
-						fields.NewField("key", string(key)),
-						fields.NewField("last_updated", request.LastUpdated),
-						fields.NewField("error", err.Error())))
-			} else {
-				d.logger.Info(3030, "pruned request", fields.NewFields(
-					fields.NewField("key", string(key)),
-					fields.NewField("last_updated", request.LastUpdated)))
-			}
+		// Requests completed before TimeCompleted was recorded fall back to their last update
+		finished := request.TimeCompleted
+		if finished.IsZero() {
+			finished = request.LastUpdated
 		}
 
+		switch request.Status {
+		case schema.RequestStatusComplete, schema.RequestStatusCancelled, schema.RequestStatusDenied:
+			if completedDays > 0 && finished.Before(completedCutoff) {
+				prune = append(prune, request)
+			}
+		case schema.RequestStatusFailed, schema.RequestStatusRefused, schema.RequestStatusInvalid, schema.RequestStatusExpired:
+			if failedDays > 0 && finished.Before(failedCutoff) {
+				prune = append(prune, request)
+			}
+		}
 		return nil
 	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var completed, failed int
+	for _, request := range prune {
+		err = d.DeleteAgentRequest(request.RequestID)
+		if err != nil {
+			// Log the error but continue so that one bad record doesn't stop the whole process
+			d.logger.Warning(3031, "pruning failed to delete request",
+				fields.NewFields(
+					fields.NewField("key", request.RequestID),
+					fields.NewField("status", request.Status),
+					fields.NewField("error", err.Error())))
+			continue
+		}
+
+		// Bad records are not counted
+		if request.Status == "" {
+			continue
+		}
+		if request.Status == schema.RequestStatusComplete || request.Status == schema.RequestStatusCancelled || request.Status == schema.RequestStatusDenied {
+			completed++
+		} else {
+			failed++
+		}
+		d.logger.Debug(3030, "pruned request", fields.NewFields(
+			fields.NewField("key", request.RequestID),
+			fields.NewField("status", request.Status),
+			fields.NewField("last_updated", request.LastUpdated)))
+	}
+	return completed, failed, nil
 }
 
 // RequestExists checks if a request exists in the database
@@ -0,0 +1,157 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// agentIndex holds the values that agents can be searched by, in lower case, so that a search
+// does not deserialize the metadata and status of every agent. It is built by the first search
+// and then kept up to date as agent metadata is stored and deleted.
+type agentIndex struct {
+	mu      sync.Mutex
+	entries map[string]agentIndexEntry // nil until built
+}
+
+type agentIndexEntry struct {
+	agentID      string
+	friendlyName string
+	hostname     string
+	ips          []string // address the agent last connected from and the addresses it reported
+	serialNumber string
+	users        []string
+}
+
+func newAgentIndexEntry(meta schema.AgentMeta) agentIndexEntry {
+	entry := agentIndexEntry{
+		agentID:      strings.ToLower(meta.AgentID),
+		friendlyName: strings.ToLower(meta.FriendlyName),
+	}
+	if meta.LastIP != "" {
+		entry.ips = append(entry.ips, strings.ToLower(meta.LastIP))
+	}
+	if meta.Status != nil {
+		entry.hostname = strings.ToLower(meta.Status.Details.Hostname)
+		entry.serialNumber = strings.ToLower(meta.Status.Details.SerialNumber)
+		for _, ip := range strings.Split(meta.Status.Details.IP, ",") {
+			ip = strings.ToLower(strings.TrimSpace(ip))
+			if ip != "" && ip != "unknown" {
+				entry.ips = append(entry.ips, ip)
+			}
+		}
+	}
+	for _, user := range meta.Users {
+		entry.users = append(entry.users, strings.ToLower(user))
+	}
+	return entry
+}
+
+// matches returns the fields that contain the term, which must be in lower case
+func (e agentIndexEntry) matches(term string) []string {
+	var matched []string
+	check := func(field string, values ...string) {
+		for _, v := range values {
+			if strings.Contains(v, term) {
+				matched = append(matched, field)
+				return
+			}
+		}
+	}
+
+	check(schema.AgentSearchHostname, e.hostname)
+	check(schema.AgentSearchFriendlyName, e.friendlyName)
+	check(schema.AgentSearchIP, e.ips...)
+	check(schema.AgentSearchSerialNumber, e.serialNumber)
+	check(schema.AgentSearchAgentID, e.agentID)
+	check(schema.AgentSearchUser, e.users...)
+	return matched
+}
+
+// indexAgent updates the search index after agent metadata is stored
+func (d *DB) indexAgent(meta schema.AgentMeta) {
+	d.agents.mu.Lock()
+	defer d.agents.mu.Unlock()
+	if d.agents.entries != nil {
+		d.agents.entries[validateKey(meta.AgentID)] = newAgentIndexEntry(meta)
+	}
+}
+
+// unindexAgent removes an agent from the search index after its metadata is deleted
+func (d *DB) unindexAgent(agentID string) {
+	d.agents.mu.Lock()
+	defer d.agents.mu.Unlock()
+	delete(d.agents.entries, validateKey(agentID))
+}
+
+// SearchAgents returns the agents with a hostname, friendly name, IP address, serial number,
+// agent ID, or assigned user that contains the term, ignoring case, sorted by agent ID
+func (d *DB) SearchAgents(term string) ([]schema.AgentSearchResult, error) {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		return nil, fmt.Errorf("search term is required")
+	}
+
+	matches, err := d.searchIndex(term)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(matches))
+	for id := range matches {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// Only the agents that matched are read from the database
+	results := make([]schema.AgentSearchResult, 0, len(ids))
+	for _, id := range ids {
+		meta, err := d.GetAgentMeta(id)
+		if err != nil {
+			// Deleted since the index was searched
+			continue
+		}
+		results = append(results, schema.AgentSearchResult{Matched: matches[id], Agent: meta})
+	}
+	return results, nil
+}
+
+// searchIndex returns the fields that matched for each matching agent, building the index
+// if this is the first search
+func (d *DB) searchIndex(term string) (map[string][]string, error) {
+	d.agents.mu.Lock()
+	defer d.agents.mu.Unlock()
+
+	if d.agents.entries == nil {
+		entries := make(map[string]agentIndexEntry)
+		err := d.ForEach(BucketAgentMeta, func(key, value []byte) error {
+			var meta schema.AgentMeta
+			if d.deserialize(value, &meta) != nil {
+				// Skip damaged records, they are removed by pruning
+				return nil
+			}
+			entries[string(key)] = newAgentIndexEntry(meta)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build agent search index: %w", err)
+		}
+		d.agents.entries = entries
+	}
+
+	matches := make(map[string][]string)
+	for id, entry := range d.agents.entries {
+		if matched := entry.matches(term); len(matched) > 0 {
+			matches[id] = matched
+		}
+	}
+	return matches, nil
+}
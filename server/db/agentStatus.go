@@ -18,16 +18,10 @@ func (d *DB) UpdateAgentStatus(agentID string, status schema.AgentStatus) error
 	// Always update the LastUpdated field
 	status.LastUpdated = time.Now()
 
-	// Retrieve the existing agent meta
-	meta, err := d.GetAgentMeta(agentID)
-	if err != nil {
-		return fmt.Errorf("failed to retrieve agent metadata: %w", err)
-	}
-
-	meta.Status = &status
-
-	// Use the SetAgentMeta function to store the updated metadata
-	err = d.SetAgentMeta(meta)
+	err := d.UpdateAgentMeta(agentID, func(meta *schema.AgentMeta) error {
+		meta.Status = &status
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update agent status: %w", err)
 	}
@@ -12,13 +12,24 @@ import (
 )
 
 type AuthInfo struct {
-	Active     bool      `json:"active"`
-	HashedPass string    `json:"hashed_pass"`
-	Role       int       `json:"role"`
-	FailCount  int       `json:"fail_count"`
-	LastUpdate time.Time `json:"time_added"`
-	LastAuth   time.Time `json:"last_auth"`
-	LastFail   time.Time `json:"last_fail"`
+	Active      bool      `json:"active"`
+	HashedPass  string    `json:"hashed_pass"`
+	Role        int       `json:"role"`
+	FailCount   int       `json:"fail_count"`
+	LastUpdate  time.Time `json:"time_added"`
+	LastAuth    time.Time `json:"last_auth"`
+	LastFail    time.Time `json:"last_fail"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// AccountLockedError is returned by CheckAuth when an account is locked out
+type AccountLockedError struct {
+	Until time.Time
+	New   bool // True if this attempt caused the lockout
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("account locked until %s", e.Until.Format(time.RFC3339))
 }
 
 func NewAuthInfo() AuthInfo {
@@ -64,9 +75,34 @@ func (d *DB) GetAuth(id string) (AuthInfo, error) {
 	return result, err
 }
 
+// UpdateAuth calls fn to modify the authentication information for a given userid and stores the
+// result within a single transaction so that concurrent updates are not lost. If fn returns an
+// error, nothing is changed and the error is returned unwrapped.
+func (d *DB) UpdateAuth(id string, fn func(*AuthInfo) error) error {
+	info := NewAuthInfo()
+	var fnErr error
+
+	err := d.UpdateData(BucketAuth, validateKey(id), &info, func() error {
+		fnErr = fn(&info)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		if err.Error() == "key not found" {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to update auth info: %w", err)
+	}
+	return nil
+}
+
 // CheckAuth verifies the provided password by comparing it to the stored hashed token
-// It also updates LastAuth and FailCount depending on success or failure
-func (d *DB) CheckAuth(id, pass string) (int, error) {
+// It also updates LastAuth and FailCount depending on success or failure. If maxFailures
+// is greater than zero, the account is locked for the lockout duration once FailCount
+// reaches maxFailures and an *AccountLockedError is returned until the lockout expires.
+func (d *DB) CheckAuth(id, pass string, maxFailures int, lockout time.Duration) (int, error) {
 
 	info, err := d.GetAuth(id)
 	if err != nil {
@@ -77,39 +113,118 @@ func (d *DB) CheckAuth(id, pass string) (int, error) {
 		return 0, errors.New("account disabled")
 	}
 
-	// Compare the provided password with the stored hashed token
+	// Refuse to check the password while the account is locked
+	if time.Now().Before(info.LockedUntil) {
+		return 0, &AccountLockedError{Until: info.LockedUntil}
+	}
+
+	// Compare the provided password with the stored hashed token. This is slow, so it is
+	// done before the update rather than while holding the database lock.
 	auth, err := VerifyHash(pass, info.HashedPass)
 	if err != nil {
 		return 0, fmt.Errorf("VerifyHash error: %w", err)
 	}
 
-	if auth {
-		// Authentication successful
-		info.FailCount = 0
-		info.LastAuth = time.Now()
+	// Apply the outcome to the current record, which may have changed since it was read
+	var role int
+	var locked *AccountLockedError
+	err = d.UpdateAuth(id, func(current *AuthInfo) error {
+		if !current.Active {
+			return errors.New("account disabled")
+		}
 
-		// Update the record in the database
-		// If this fails something is wrong - fail authorization
-		if err = d.SetData(BucketAuth, validateKey(id), info); err != nil {
-			return 0, err
+		now := time.Now()
+		if now.Before(current.LockedUntil) {
+			return &AccountLockedError{Until: current.LockedUntil}
 		}
 
-		return info.Role, nil
-	}
+		// The password was changed while it was being verified
+		if current.HashedPass != info.HashedPass {
+			return errors.New("invalid password")
+		}
+
+		if auth {
+			current.FailCount = 0
+			current.LastAuth = now
+			current.LockedUntil = time.Time{}
+			role = current.Role
+			return nil
+		}
 
-	// Authentication failed: increment FailCount
-	info.FailCount++
-	info.LastFail = time.Now()
+		current.FailCount++
+		current.LastFail = now
 
-	// Update the record in the database
-	if err = d.SetData(BucketAuth, validateKey(id), info); err != nil {
+		// Lock the account if the threshold has been reached
+		if maxFailures > 0 && current.FailCount >= maxFailures {
+			current.LockedUntil = now.Add(lockout)
+			current.FailCount = 0
+			locked = &AccountLockedError{Until: current.LockedUntil, New: true}
+		}
+		return nil
+	})
+
+	// If the update fails something is wrong - fail authorization
+	if err != nil {
 		return 0, err
 	}
 
+	if auth {
+		return role, nil
+	}
+
+	if locked != nil {
+		return 0, locked
+	}
+
 	// Return invalid password error
 	return 0, errors.New("invalid password")
 }
 
+// GetAllAuth returns the authentication information for every login account, keyed by ID
+func (d *DB) GetAllAuth() (map[string]AuthInfo, error) {
+	result := make(map[string]AuthInfo)
+	err := d.ForEach(BucketAuth, func(key, value []byte) error {
+		info := NewAuthInfo()
+		if err := d.deserialize(value, &info); err != nil {
+			return fmt.Errorf("failed to deserialize auth info for %s: %w", key, err)
+		}
+		result[string(key)] = info
+		return nil
+	})
+	return result, err
+}
+
+// ImportAuth stores authentication information exported from another server. Failure counts
+// and lockouts are not carried over.
+func (d *DB) ImportAuth(id string, info AuthInfo) error {
+	info.FailCount = 0
+	info.LockedUntil = time.Time{}
+	return d.SetData(BucketAuth, validateKey(id), info)
+}
+
+// UnlockAuth clears the failure count and any lockout of a login account
+func (d *DB) UnlockAuth(id string) error {
+	return d.UpdateAuth(id, func(info *AuthInfo) error {
+		info.FailCount = 0
+		info.LockedUntil = time.Time{}
+		return nil
+	})
+}
+
+// SetAuthRole changes the role of a login account
+func (d *DB) SetAuthRole(id string, role int) error {
+	return d.UpdateAuth(id, func(info *AuthInfo) error {
+		info.Role = role
+		info.LastUpdate = time.Now()
+		return nil
+	})
+}
+
+// AuthExists checks if a login account exists
+func (d *DB) AuthExists(id string) (bool, error) {
+	return d.KeyExists(BucketAuth, validateKey(id))
+}
+
 // DeleteAuth removes the authentication data for a given agent ID
 func (d *DB) DeleteAuth(id string) error {
 	return d.DeleteData(BucketAuth, validateKey(id))
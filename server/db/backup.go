@@ -0,0 +1,29 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"io"
+
+	"go.etcd.io/bbolt"
+)
+
+// Backup writes a consistent copy of the database to w. It uses a read transaction, so other
+// operations continue while it runs. If size is not nil, it is called with the number of bytes
+// that will be written before writing starts.
+func (d *DB) Backup(w io.Writer, size func(int64)) (int64, error) {
+	var written int64
+	err := d.view(func(tx *bbolt.Tx) error {
+		if size != nil {
+			size(tx.Size())
+		}
+
+		var err error
+		written, err = tx.WriteTo(w)
+		return err
+	})
+	return written, err
+}
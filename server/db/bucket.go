@@ -23,7 +23,7 @@ func (d *DB) SetData(bucketName string, key string, value interface{}) error {
 	}
 
 	// Store the serialized data in the bucket
-	err = d.db.Update(func(tx *bbolt.Tx) error {
+	err = d.update(func(tx *bbolt.Tx) error {
 
 		// Get or create the specified bucket
 		bucket, err := tx.CreateBucketIfNotExists([]byte(bucketName))
@@ -45,7 +45,7 @@ func (d *DB) SetData(bucketName string, key string, value interface{}) error {
 
 // GetData retrieves and deserializes data from a specified bucket using a given key
 func (d *DB) GetData(bucketName string, key string, result interface{}) error {
-	err := d.db.View(func(tx *bbolt.Tx) error {
+	err := d.view(func(tx *bbolt.Tx) error {
 
 		// Get the specified bucket
 		bucket := tx.Bucket([]byte(bucketName))
@@ -74,9 +74,49 @@ func (d *DB) GetData(bucketName string, key string, result interface{}) error {
 	return err
 }
 
+// UpdateData retrieves and deserializes data into result, calls fn to modify it, and stores
+// the result within a single transaction. If fn returns an error, the data is not changed.
+func (d *DB) UpdateData(bucketName string, key string, result interface{}, fn func() error) error {
+	return d.update(func(tx *bbolt.Tx) error {
+
+		// Get the specified bucket
+		bucket := tx.Bucket([]byte(bucketName))
+		if bucket == nil {
+			return errors.New("bucket not found")
+		}
+
+		// Retrieve and deserialize the existing data
+		data := bucket.Get([]byte(key))
+		if data == nil {
+			return errors.New("key not found")
+		}
+
+		err := d.deserialize(data, result)
+		if err != nil {
+			return fmt.Errorf("failed to deserialize data: %w", err)
+		}
+
+		err = fn()
+		if err != nil {
+			return err
+		}
+
+		data, err = d.serialize(result)
+		if err != nil {
+			return fmt.Errorf("failed to serialize data: %w", err)
+		}
+
+		err = bucket.Put([]byte(key), data)
+		if err != nil {
+			return fmt.Errorf("failed to store data in bucket: %w", err)
+		}
+		return nil
+	})
+}
+
 // DeleteData deletes data from a specified bucket using a given key
 func (d *DB) DeleteData(bucketName string, key string) error {
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.update(func(tx *bbolt.Tx) error {
 
 		// Get the specified bucket
 		bucket := tx.Bucket([]byte(bucketName))
@@ -102,7 +142,7 @@ func (d *DB) DeleteData(bucketName string, key string) error {
 // KeyExists checks if a key exists in a specified bucket
 func (d *DB) KeyExists(bucketName string, key string) (bool, error) {
 	var exists bool
-	err := d.db.View(func(tx *bbolt.Tx) error {
+	err := d.view(func(tx *bbolt.Tx) error {
 		// Get the specified bucket
 		bucket := tx.Bucket([]byte(bucketName))
 		if bucket == nil {
@@ -119,7 +159,7 @@ func (d *DB) KeyExists(bucketName string, key string) (bool, error) {
 
 // ForEach iterates over all keys in the specified bucket and applies the given function
 func (d *DB) ForEach(bucketName string, fn func(key, value []byte) error) error {
-	return d.db.View(func(tx *bbolt.Tx) error {
+	return d.view(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte(bucketName))
 		if b == nil {
 			return fmt.Errorf("bucket %s not found", bucketName)
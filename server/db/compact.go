@@ -0,0 +1,132 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Deleted records leave free pages that Bolt reuses but never returns to the file system.
+// Compacting copies the database into a new file without them.
+
+const (
+	compactWait   = 30 * time.Second // how long to wait for operations in progress to finish
+	compactTxSize = 64 * 1024 * 1024 // bytes copied in each transaction
+)
+
+// CompactReport describes the result of compacting the database
+type CompactReport struct {
+	Compacted  bool  // false if the free space was below the threshold
+	FreeBytes  int64 // free space in the database before compacting
+	SizeBefore int64
+	SizeAfter  int64
+}
+
+// Compact replaces the database with a compacted copy if at least threshold percent of it is
+// free. A threshold of zero disables compaction. Other operations wait while the copy is made.
+// The copy is written to a temporary file, synced, and renamed over the database, so a crash
+// at any point leaves either the original or the compacted database in place.
+func (d *DB) Compact(threshold int) (CompactReport, error) {
+	var report CompactReport
+	report.FreeBytes, report.SizeBefore = d.freeSpace()
+	report.SizeAfter = report.SizeBefore
+	if threshold <= 0 || report.SizeBefore == 0 || report.FreeBytes*100 < report.SizeBefore*int64(threshold) {
+		return report, nil
+	}
+
+	// Wait for operations in progress to finish. TryLock is used instead of Lock because a
+	// waiting Lock would block an operation that nests transactions, which would deadlock.
+	deadline := time.Now().Add(compactWait)
+	for !d.mu.TryLock() {
+		if time.Now().After(deadline) {
+			return report, errors.New("database busy, compaction skipped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer d.mu.Unlock()
+
+	tmpPath := d.path + ".compact"
+	_ = os.Remove(tmpPath)
+
+	// The copy is synced once when it is complete rather than after each transaction
+	dst, err := bbolt.Open(tmpPath, 0600, &bbolt.Options{Timeout: 1 * time.Second, NoSync: true})
+	if err != nil {
+		return report, fmt.Errorf("unable to create compacted database: %w", err)
+	}
+	err = bbolt.Compact(dst, d.db, compactTxSize)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return report, fmt.Errorf("unable to compact database: %w", err)
+	}
+
+	if err = d.db.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return report, fmt.Errorf("unable to close database: %w", err)
+	}
+
+	renameErr := os.Rename(tmpPath, d.path)
+	if renameErr == nil {
+		syncDir(filepath.Dir(d.path))
+	} else {
+		_ = os.Remove(tmpPath)
+	}
+
+	// Reopen the compacted database, or the original if it couldn't be replaced
+	db, err := openBolt(d.path, &bbolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return report, fmt.Errorf("unable to reopen database: %w", err)
+	}
+	d.db = db
+	if renameErr != nil {
+		return report, fmt.Errorf("unable to replace database: %w", renameErr)
+	}
+
+	report.Compacted = true
+	_ = d.db.View(func(tx *bbolt.Tx) error {
+		report.SizeAfter = tx.Size()
+		return nil
+	})
+	return report, nil
+}
+
+// freeSpace returns the bytes in free pages and the size of the database
+func (d *DB) freeSpace() (int64, int64) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	stats := d.db.Stats()
+	free := int64(stats.FreePageN+stats.PendingPageN) * int64(d.db.Info().PageSize)
+
+	var size int64
+	_ = d.db.View(func(tx *bbolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	return free, size
+}
+
+// syncDir flushes a directory so that a rename in it survives a crash. This isn't possible on
+// all platforms, so errors are ignored.
+func syncDir(dir string) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = f.Sync()
+	_ = f.Close()
+}
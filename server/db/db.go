@@ -6,10 +6,13 @@
 package db
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"go.etcd.io/bbolt"
+	berrors "go.etcd.io/bbolt/errors"
 
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 )
@@ -17,8 +20,11 @@ import (
 // A separate package with a struct are used for looser coupling with the database
 
 type DB struct {
+	mu     sync.RWMutex // held exclusively while the database is replaced by a compacted copy
 	db     *bbolt.DB
+	path   string
 	logger interfaces.Logger
+	agents agentIndex
 }
 
 const BucketAuth = "Auth"
@@ -26,8 +32,26 @@ const BucketAgentRequests = "Requests"
 const BucketAgentMeta = "AgentMeta"
 const BucketAgentEvents = "AgentEvents"
 const BucketUserMeta = "UserMeta"
+const BucketLoginAudit = "LoginAudit"
+const BucketTagChannels = "TagChannels"
+const BucketGroups = "Groups"
+const BucketFDEKeys = "FDERecoveryKeys"
+const BucketRevokedTokens = "RevokedTokens"
+const BucketRevokedSubjects = "RevokedSubjects"
+const BucketMessageQueue = "MessageQueue"
+const BucketRequestIndex = "RequestIndex"
+const BucketSchema = "Schema"
+const BucketSavedReports = "SavedReports"
 
-var bucketList = []string{BucketAuth, BucketAgentRequests, BucketAgentMeta, BucketAgentEvents, BucketUserMeta}
+var bucketList = []string{BucketAuth, BucketAgentRequests, BucketAgentMeta, BucketAgentEvents, BucketUserMeta, BucketLoginAudit, BucketTagChannels, BucketGroups, BucketFDEKeys, BucketRevokedTokens, BucketRevokedSubjects, BucketMessageQueue, BucketRequestIndex, BucketSchema, BucketSavedReports}
+
+var (
+	// ErrLocked is returned by Open when another process holds the database lock
+	ErrLocked = errors.New("database is locked by another process")
+
+	// ErrCorrupt is returned by Open when the database file is damaged
+	ErrCorrupt = errors.New("database is corrupted")
+)
 
 // Open opens (or creates) a Bolt DB at the specified path.
 // It also creates three buckets if they do not already exist.
@@ -36,13 +60,64 @@ func Open(filePath string, logger interfaces.Logger) (*DB, error) {
 	logger.Infof(2201, "Opening database: %s", filePath)
 	// Open the Bolt DB file. 0600 means read/write permissions for the current user only.
 	// The Timeout option allows Bolt to wait if the file is locked by another process.
-	db, err := bbolt.Open(filePath, 0600, &bbolt.Options{Timeout: 1 * time.Second})
+	db, err := openBolt(filePath, &bbolt.Options{Timeout: 1 * time.Second})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open bolt db: %w", err)
+		return nil, err
 	}
 
 	// Create all buckets within a single transaction if they don't already exist.
-	err = db.Update(func(tx *bbolt.Tx) error {
+	err = createBuckets(db)
+	if err != nil {
+		// If creating buckets failed, close the DB to avoid resource leaks.
+		_ = db.Close()
+		return nil, err
+	}
+
+	// Upgrade databases created by earlier versions
+	err = migrate(db, logger)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &DB{db: db, path: filePath, logger: logger}, nil
+}
+
+// openBolt opens a Bolt DB and identifies failures caused by a lock held by another process or
+// a damaged file. Bolt panics on some forms of damage, so panics are reported as corruption.
+func openBolt(filePath string, options *bbolt.Options) (db *bbolt.DB, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			db = nil
+			err = fmt.Errorf("%w: %v", ErrCorrupt, r)
+		}
+	}()
+
+	db, err = bbolt.Open(filePath, 0600, options)
+	switch {
+	case err == nil:
+		return db, nil
+	case errors.Is(err, berrors.ErrTimeout):
+		if holder := lockHolder(filePath); holder != "" {
+			return nil, fmt.Errorf("%w: %s", ErrLocked, holder)
+		}
+		return nil, ErrLocked
+	case errors.Is(err, berrors.ErrInvalid), errors.Is(err, berrors.ErrChecksum):
+		return nil, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	default:
+		return nil, fmt.Errorf("failed to open bolt db: %w", err)
+	}
+}
+
+// createBuckets creates any buckets that don't exist in a single transaction
+func createBuckets(db *bbolt.DB) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrCorrupt, r)
+		}
+	}()
+
+	return db.Update(func(tx *bbolt.Tx) error {
 		for _, bucketName := range bucketList {
 			_, createErr := tx.CreateBucketIfNotExists([]byte(bucketName))
 			if createErr != nil {
@@ -51,16 +126,55 @@ func Open(filePath string, logger interfaces.Logger) (*DB, error) {
 		}
 		return nil
 	})
-	if err != nil {
-		// If creating buckets failed, close the DB to avoid resource leaks.
-		_ = db.Close()
-		return nil, err
+}
+
+// view runs fn in a read-only transaction
+func (d *DB) view(fn func(*bbolt.Tx) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db.View(fn)
+}
+
+// update runs fn in a read-write transaction
+func (d *DB) update(fn func(*bbolt.Tx) error) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.db.Update(fn)
+}
+
+// Check confirms that the database is open and writable using a read-only transaction
+func (d *DB) Check() error {
+	d.mu.RLock()
+	readOnly := d.db.IsReadOnly()
+	d.mu.RUnlock()
+	if readOnly {
+		return errors.New("database is read-only")
 	}
 
-	return &DB{db: db}, nil
+	return d.view(func(tx *bbolt.Tx) error {
+		for _, bucketName := range bucketList {
+			if tx.Bucket([]byte(bucketName)) == nil {
+				return fmt.Errorf("bucket %s does not exist", bucketName)
+			}
+		}
+		return nil
+	})
+}
+
+// Size returns the size of the database in bytes
+func (d *DB) Size() int64 {
+	var size int64
+	_ = d.view(func(tx *bbolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	return size
 }
 
 // Close the database, ignore any errors
 func (d *DB) Close() {
-	_ = d.db.Close()
+	d.mu.RLock()
+	db := d.db
+	d.mu.RUnlock()
+	_ = db.Close()
 }
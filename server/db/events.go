@@ -6,7 +6,11 @@
 package db
 
 import (
+	"bytes"
+	"container/heap"
+	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,7 +23,7 @@ import (
 // AddEvent adds an event to the database. Each agent has their own child bucket for events and the
 // event time plus an event ID is used as the key
 func (d *DB) AddEvent(event schema.AgentEvent) error {
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.update(func(tx *bbolt.Tx) error {
 
 		// Get or create the parent bucket
 		parentBucket, err := tx.CreateBucketIfNotExists([]byte(BucketAgentEvents))
@@ -39,7 +43,7 @@ func (d *DB) AddEvent(event schema.AgentEvent) error {
 		}
 
 		// Create the key using event time and event ID
-		key := fmt.Sprintf("%d-%s", event.Time.Unix(), event.EventID)
+		key := eventKey(event.Time, event.EventID)
 
 		// Serialize the event
 		data, err := d.serialize(event)
@@ -48,15 +52,24 @@ func (d *DB) AddEvent(event schema.AgentEvent) error {
 		}
 
 		// Store the serialized event in the child bucket
-		return childBucket.Put([]byte(key), data)
+		return childBucket.Put(key, data)
 	})
 }
 
 // GetEvents returns a list of events for an agent within a specified time range
 func (d *DB) GetEvents(agentID string, startTime, endTime int64, eventType string) ([]schema.AgentEvent, error) {
 	var events []schema.AgentEvent
+	err := d.ForEachEvent(agentID, startTime, endTime, eventType, func(event schema.AgentEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	return events, err
+}
 
-	err := d.db.View(func(tx *bbolt.Tx) error {
+// ForEachEvent iterates over all events for an agent within a specified time range. The range is
+// read with a cursor from the start time to the end time, so events outside it are not read.
+func (d *DB) ForEachEvent(agentID string, startTime, endTime int64, eventType string, callback func(schema.AgentEvent) error) error {
+	return d.view(func(tx *bbolt.Tx) error {
 		// Get the parent bucket
 		parentBucket := tx.Bucket([]byte(BucketAgentEvents))
 		if parentBucket == nil {
@@ -69,76 +82,207 @@ func (d *DB) GetEvents(agentID string, startTime, endTime int64, eventType strin
 			return fmt.Errorf("agent bucket not found")
 		}
 
-		// Iterate over the events in the child bucket
-		return childBucket.ForEach(func(k, v []byte) error {
-			// Parse the event time from the key
-			var eventTime int64
-			_, err := fmt.Sscanf(string(k), "%d-", &eventTime)
+		// Iterate over the events in the time range
+		start, end := eventBounds(startTime, endTime)
+		c := childBucket.Cursor()
+		for k, v := c.Seek(start); k != nil && (end == nil || bytes.Compare(k, end) < 0); k, v = c.Next() {
+			var event schema.AgentEvent
+			err := d.deserialize(v, &event)
 			if err != nil {
-				return fmt.Errorf("failed to parse event time: %w", err)
+				return fmt.Errorf("failed to deserialize event: %w", err)
 			}
 
-			// Check if the event is within the specified time range
-			if (startTime == 0 || eventTime >= startTime) && (endTime == 0 || eventTime <= endTime) {
-				var event schema.AgentEvent
-				err := d.deserialize(v, &event)
-				if err != nil {
-					return fmt.Errorf("failed to deserialize event: %w", err)
-				}
-				if eventType == "" || event.EventType == eventType {
-					events = append(events, event)
+			// Check if the event type matches
+			if eventType == "" || event.EventType == eventType {
+				if err = callback(event); err != nil {
+					return err
 				}
 			}
-			return nil
-		})
+		}
+		return nil
 	})
-	return events, err
 }
 
-// ForEachEvent iterates over all events for an agent within a specified time range
-func (d *DB) ForEachEvent(agentID string, startTime, endTime int64, eventType string, callback func(schema.AgentEvent) error) error {
-	return d.db.View(func(tx *bbolt.Tx) error {
-		// Get the parent bucket
+// EventQuery selects the events returned by QueryEvents
+type EventQuery struct {
+	AgentIDs  []string // Agents to include, all agents if empty
+	StartTime int64    // Unix time of the earliest event, 0 for no limit
+	EndTime   int64    // Unix time of the latest event, 0 for no limit
+	EventType string   // Only events of this type if set
+	After     []byte   // Key of the last event of the previous page
+	Limit     int      // Maximum number of events, 0 for no limit
+	Newest    bool     // Return the newest events first
+}
+
+// QueryEvents returns the events selected by q in time order, or newest first if q.Newest is set,
+// merging the agents' buckets, along with the key of the last event returned and whether there
+// are more events. Each bucket is read with a cursor that starts at the start time or after the
+// previous page, and reading stops at the end time, so only the events that are returned and the
+// one after them are deserialized.
+func (d *DB) QueryEvents(q EventQuery) ([]schema.AgentEvent, []byte, bool, error) {
+	var events []schema.AgentEvent
+	var last []byte
+	more := false
+
+	err := d.view(func(tx *bbolt.Tx) error {
 		parentBucket := tx.Bucket([]byte(BucketAgentEvents))
 		if parentBucket == nil {
 			return fmt.Errorf("parent bucket not found")
 		}
 
-		// Get the child bucket for the agent
-		childBucket := parentBucket.Bucket([]byte(agentID))
-		if childBucket == nil {
-			return fmt.Errorf("child bucket not found")
+		agentIDs := q.AgentIDs
+		if len(agentIDs) == 0 {
+			err := parentBucket.ForEachBucket(func(k []byte) error {
+				agentIDs = append(agentIDs, string(k))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
 		}
 
-		// Iterate over the events in the child bucket
-		return childBucket.ForEach(func(k, v []byte) error {
-			// Parse the event time from the key
-			var eventTime int64
-			_, err := fmt.Sscanf(string(k), "%d-", &eventTime)
-			if err != nil {
-				return fmt.Errorf("failed to parse event time: %w", err)
+		// Position a cursor in each agent's bucket at its first event
+		start, end := eventBounds(q.StartTime, q.EndTime)
+		cursors := &eventCursors{newest: q.Newest}
+		for _, agentID := range agentIDs {
+			childBucket := parentBucket.Bucket([]byte(agentID))
+			if childBucket == nil {
+				continue
 			}
 
-			// Check if the event is within the specified time range
-			if (startTime == 0 || eventTime >= startTime) && (endTime == 0 || eventTime <= endTime) {
-				var event schema.AgentEvent
-				err := d.deserialize(v, &event)
-				if err != nil {
-					return fmt.Errorf("failed to deserialize event: %w", err)
+			ec := &eventCursor{cursor: childBucket.Cursor()}
+			switch {
+			case q.Newest:
+				// Start before the previous page or the end time, or at the newest event
+				before := q.After
+				if before == nil {
+					before = end
+				}
+				if before == nil {
+					ec.key, ec.value = ec.cursor.Last()
+				} else if k, _ := ec.cursor.Seek(before); k == nil {
+					ec.key, ec.value = ec.cursor.Last()
+				} else {
+					ec.key, ec.value = ec.cursor.Prev()
+				}
+			case q.After != nil:
+				ec.key, ec.value = ec.cursor.Seek(q.After)
+				if bytes.Equal(ec.key, q.After) {
+					ec.key, ec.value = ec.cursor.Next()
 				}
-				// Check if the event type matches
-				if eventType == "" || event.EventType == eventType {
-					return callback(event)
+			default:
+				ec.key, ec.value = ec.cursor.Seek(start)
+			}
+			if ec.key != nil {
+				cursors.items = append(cursors.items, ec)
+			}
+		}
+		heap.Init(cursors)
+
+		// Take the next event from all of the cursors until the end of the time range or the limit
+		for cursors.Len() > 0 {
+			ec := cursors.items[0]
+			key, value := ec.key, ec.value
+			if q.Newest {
+				ec.key, ec.value = ec.cursor.Prev()
+			} else {
+				ec.key, ec.value = ec.cursor.Next()
+			}
+			if ec.key == nil {
+				heap.Pop(cursors)
+			} else {
+				heap.Fix(cursors, 0)
+			}
+
+			if end != nil && bytes.Compare(key, end) >= 0 {
+				if q.Newest {
+					continue
 				}
+				break
+			}
+			if bytes.Compare(key, start) < 0 {
+				if q.Newest {
+					break
+				}
+				continue
+			}
+
+			var event schema.AgentEvent
+			err := d.deserialize(value, &event)
+			if err != nil {
+				return fmt.Errorf("failed to deserialize event: %w", err)
+			}
+			if q.EventType != "" && event.EventType != q.EventType {
+				continue
+			}
+
+			if q.Limit > 0 && len(events) == q.Limit {
+				more = true
+				break
+			}
+			events = append(events, event)
+			last = append([]byte(nil), key...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return events, last, more, nil
+}
+
+// eventCursor is the position in an agent's event bucket
+type eventCursor struct {
+	cursor *bbolt.Cursor
+	key    []byte
+	value  []byte
+}
+
+// eventCursors is a heap of cursors ordered by the key of their next event, the earliest first
+// or the newest first
+type eventCursors struct {
+	items  []*eventCursor
+	newest bool
+}
+
+func (h *eventCursors) Len() int { return len(h.items) }
+func (h *eventCursors) Less(i, j int) bool {
+	if h.newest {
+		return bytes.Compare(h.items[i].key, h.items[j].key) > 0
+	}
+	return bytes.Compare(h.items[i].key, h.items[j].key) < 0
+}
+func (h *eventCursors) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *eventCursors) Push(x any)    { h.items = append(h.items, x.(*eventCursor)) }
+func (h *eventCursors) Pop() any {
+	x := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return x
+}
+
+// EventAgents returns the IDs of the agents that have events
+func (d *DB) EventAgents() ([]string, error) {
+	var agents []string
+	err := d.view(func(tx *bbolt.Tx) error {
+		parentBucket := tx.Bucket([]byte(BucketAgentEvents))
+		if parentBucket == nil {
+			return fmt.Errorf("parent bucket not found")
+		}
+
+		// Each agent has a child bucket, which has a nil value
+		return parentBucket.ForEach(func(k, v []byte) error {
+			if v == nil {
+				agents = append(agents, string(k))
 			}
 			return nil
 		})
 	})
+	return agents, err
 }
 
 // DeleteAllEvents removes the child bucket for the agent thus removing all events
 func (d *DB) DeleteAllEvents(agentID string) error {
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	return d.update(func(tx *bbolt.Tx) error {
 
 		// Get the parent bucket
 		parentBucket := tx.Bucket([]byte(BucketAgentEvents))
@@ -151,11 +295,13 @@ func (d *DB) DeleteAllEvents(agentID string) error {
 	})
 }
 
-// PruneEvents iterates over all child buckets and removes events older than the specified number of days
-func (d *DB) PruneEvents(days int) error {
-	cutoffTime := time.Now().AddDate(0, 0, -days).Unix()
+// PruneEvents iterates over all child buckets and removes events older than the specified number
+// of days. It returns the number of events removed.
+func (d *DB) PruneEvents(days int) (int, error) {
+	cutoff, _ := eventBounds(time.Now().AddDate(0, 0, -days).Unix(), 0)
+	count := 0
 
-	return d.db.Update(func(tx *bbolt.Tx) error {
+	err := d.update(func(tx *bbolt.Tx) error {
 
 		// Get the parent bucket
 		parentBucket := tx.Bucket([]byte(BucketAgentEvents))
@@ -173,27 +319,11 @@ func (d *DB) PruneEvents(days int) error {
 				return nil
 			}
 
-			// Collect keys to delete
+			// Collect the keys before the cutoff, which are the oldest events
 			var keysToDelete [][]byte
-			err := childBucket.ForEach(func(k, v []byte) error {
-				var eventTime int64
-				_, err := fmt.Sscanf(string(k), "%d-", &eventTime)
-				if err != nil {
-
-					// Log the error
-					d.logger.Warningf(3022, "failed to parse event time for agent %s key %s: %s", string(agentID), string(k), err.Error())
-
-					// Attempt to delete the bad record
-					_ = childBucket.Delete(k)
-				}
-
-				if eventTime < cutoffTime {
-					keysToDelete = append(keysToDelete, k)
-				}
-				return nil
-			})
-			if err != nil {
-				return err
+			c := childBucket.Cursor()
+			for k, _ := c.First(); k != nil && bytes.Compare(k, cutoff) < 0; k, _ = c.Next() {
+				keysToDelete = append(keysToDelete, k)
 			}
 
 			// Delete the collected keys
@@ -207,7 +337,8 @@ func (d *DB) PruneEvents(days int) error {
 							fields.NewField("key", string(k)),
 							fields.NewField("error", err.Error())))
 				} else {
-					d.logger.Info(3020, "pruned event", fields.NewFields(
+					count++
+					d.logger.Debug(3020, "pruned event", fields.NewFields(
 						fields.NewField("agent_id", string(agentID)),
 						fields.NewField("key", string(k))))
 				}
@@ -215,4 +346,98 @@ func (d *DB) PruneEvents(days int) error {
 			return nil
 		})
 	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// maxEventTime is the latest time that can be represented in an event key
+var maxEventTime = time.Unix(0, math.MaxInt64)
+
+// eventKey returns a fixed-width key so that events sort chronologically. Times before 1970 or
+// after maxEventTime are stored as those limits.
+func eventKey(t time.Time, eventID string) []byte {
+	return []byte(eventTimeKey(t) + "-" + eventID)
+}
+
+// eventTimeKey returns the time portion of an event key
+func eventTimeKey(t time.Time) string {
+	switch {
+	case t.Before(time.Unix(0, 0)):
+		t = time.Unix(0, 0)
+	case t.After(maxEventTime):
+		t = maxEventTime
+	}
+	return fmt.Sprintf("%020d", t.UnixNano())
+}
+
+// eventBounds returns the key to seek to for events from startTime and the key that events up
+// to endTime sort before, or nil if there is no end time. Times are Unix seconds, 0 for no limit.
+func eventBounds(startTime, endTime int64) ([]byte, []byte) {
+	start := []byte(eventTimeKey(time.Unix(startTime, 0)))
+	if endTime == 0 {
+		return start, nil
+	}
+	return start, []byte(eventTimeKey(time.Unix(endTime+1, 0)))
+}
+
+// rekeyEvents replaces the event keys used before version 2 of the schema, which were the Unix
+// time in seconds without padding, with fixed-width keys. It returns the number of events
+// rekeyed. Events whose key can't be parsed are deleted, as they would be when pruned.
+func rekeyEvents(tx *bbolt.Tx) (int, error) {
+	parentBucket := tx.Bucket([]byte(BucketAgentEvents))
+	if parentBucket == nil {
+		return 0, nil
+	}
+
+	var agentIDs [][]byte
+	err := parentBucket.ForEachBucket(func(k []byte) error {
+		agentIDs = append(agentIDs, append([]byte(nil), k...))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, agentID := range agentIDs {
+		childBucket := parentBucket.Bucket(agentID)
+
+		// Collect the events before changing the bucket
+		type record struct{ key, value []byte }
+		var records []record
+		err = childBucket.ForEach(func(k, v []byte) error {
+			records = append(records, record{append([]byte(nil), k...), append([]byte(nil), v...)})
+			return nil
+		})
+		if err != nil {
+			return count, err
+		}
+
+		for _, r := range records {
+			if err = childBucket.Delete(r.key); err != nil {
+				return count, err
+			}
+
+			var seconds int64
+			var eventID string
+			if _, err = fmt.Sscanf(string(r.key), "%d-%s", &seconds, &eventID); err != nil {
+				continue
+			}
+
+			// The stored time is more precise than the key
+			eventTime := time.Unix(seconds, 0)
+			var event schema.AgentEvent
+			if json.Unmarshal(r.value, &event) == nil && !event.Time.IsZero() {
+				eventTime = event.Time
+			}
+
+			if err = childBucket.Put(eventKey(eventTime, eventID), r.value); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
 }
@@ -0,0 +1,28 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// SetFDERecoveryKey stores an agent's recovery key, replacing any previous key
+func (d *DB) SetFDERecoveryKey(record schema.FDERecoveryKeyRecord) error {
+	err := d.SetData(BucketFDEKeys, record.AgentID, record)
+	if err != nil {
+		return fmt.Errorf("failed to store recovery key: %w", err)
+	}
+	return nil
+}
+
+// GetFDERecoveryKey retrieves an agent's recovery key
+func (d *DB) GetFDERecoveryKey(agentID string) (schema.FDERecoveryKeyRecord, error) {
+	var record schema.FDERecoveryKeyRecord
+	err := d.GetData(BucketFDEKeys, agentID, &record)
+	return record, err
+}
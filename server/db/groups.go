@@ -0,0 +1,61 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// SetGroup stores a group. Group names are not case-sensitive.
+func (d *DB) SetGroup(group schema.Group) error {
+
+	// Always update the LastUpdated field
+	group.LastUpdated = time.Now()
+
+	err := d.SetData(BucketGroups, schema.GroupKey(group.Name), group)
+	if err != nil {
+		return fmt.Errorf("failed to store group: %w", err)
+	}
+	return nil
+}
+
+// GetGroup retrieves a group by name
+func (d *DB) GetGroup(name string) (schema.Group, error) {
+	var group schema.Group
+	err := d.GetData(BucketGroups, schema.GroupKey(name), &group)
+	return group, err
+}
+
+// GroupExists returns true if the group exists
+func (d *DB) GroupExists(name string) (bool, error) {
+	return d.KeyExists(BucketGroups, schema.GroupKey(name))
+}
+
+// DeleteGroup deletes a group by name
+func (d *DB) DeleteGroup(name string) error {
+	return d.DeleteData(BucketGroups, schema.GroupKey(name))
+}
+
+// GetGroups retrieves all groups
+func (d *DB) GetGroups() ([]schema.Group, error) {
+	groups := make([]schema.Group, 0)
+	err := d.ForEach(BucketGroups, func(key, value []byte) error {
+		var group schema.Group
+		if err := d.deserialize(value, &group); err != nil {
+			return fmt.Errorf("failed to deserialize group %s: %w", key, err)
+		}
+		groups = append(groups, group)
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve groups: %w", err)
+	}
+	return groups, nil
+}
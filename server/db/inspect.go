@@ -0,0 +1,101 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// BucketReport describes the contents of a root bucket. Records in nested buckets, such as
+// the per-agent event buckets, are included in the counts of their root bucket.
+type BucketReport struct {
+	Name    string
+	Records int
+	Buckets int      // nested buckets
+	Invalid []string // keys of records that can't be deserialized
+	Error   string   // set if the bucket could not be read in full
+}
+
+// InspectReport describes the contents of a database
+type InspectReport struct {
+	Buckets []BucketReport
+	Errors  []string // consistency errors reported by Bolt
+}
+
+// Inspect opens the database read-only, walks every bucket, and reports the number of records
+// in each and any that can't be deserialized. The server must not be running because it holds
+// an exclusive lock on the database.
+func Inspect(filePath string) (InspectReport, error) {
+	var report InspectReport
+
+	bdb, err := openBolt(filePath, &bbolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return report, err
+	}
+	defer func() {
+		_ = bdb.Close()
+	}()
+
+	err = bdb.View(func(tx *bbolt.Tx) error {
+		inspectRoot(tx, &report)
+
+		// Check the structure of the file
+		for checkErr := range tx.Check() {
+			report.Errors = append(report.Errors, checkErr.Error())
+		}
+		return nil
+	})
+	return report, err
+}
+
+// inspectRoot adds a report for each root bucket
+func inspectRoot(tx *bbolt.Tx, report *InspectReport) {
+	defer func() {
+		if r := recover(); r != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("unable to read root: %v", r))
+		}
+	}()
+
+	c := tx.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		br := BucketReport{Name: string(k)}
+		inspectBucket(tx.Bucket(k), "", &br)
+		report.Buckets = append(report.Buckets, br)
+	}
+}
+
+// inspectBucket counts the records in a bucket and its nested buckets. Bolt panics when it
+// reads a damaged page, in which case the error is recorded and the rest of the bucket skipped.
+func inspectBucket(b *bbolt.Bucket, prefix string, br *BucketReport) {
+	defer func() {
+		if r := recover(); r != nil {
+			br.Error = fmt.Sprintf("%v", r)
+		}
+	}()
+
+	if b == nil {
+		return
+	}
+
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		// Nested buckets have nil values
+		if v == nil {
+			br.Buckets++
+			inspectBucket(b.Bucket(k), prefix+string(k)+"/", br)
+			continue
+		}
+
+		br.Records++
+		if !json.Valid(v) {
+			br.Invalid = append(br.Invalid, prefix+string(k))
+		}
+	}
+}
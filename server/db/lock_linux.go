@@ -0,0 +1,60 @@
+//go:build linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// lockHolder returns a description of the process holding a lock on the file, or "" if it
+// can't be determined. Locks are listed in /proc/locks with the PID and the device and inode
+// of the locked file.
+func lockHolder(filePath string) string {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return ""
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	inode := strconv.FormatUint(stat.Ino, 10)
+
+	f, err := os.Open("/proc/locks")
+	if err != nil {
+		return ""
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	// For example: 1: FLOCK  ADVISORY  WRITE 1234 08:01:131074 0 EOF
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.Fields(scanner.Text())
+		if len(line) < 6 || line[1] != "FLOCK" {
+			continue
+		}
+		device := strings.Split(line[5], ":")
+		if device[len(device)-1] != inode {
+			continue
+		}
+
+		pid := line[4]
+		if name, err := os.ReadFile("/proc/" + pid + "/comm"); err == nil {
+			return fmt.Sprintf("held by process %s (%s)", pid, strings.TrimSpace(string(name)))
+		}
+		return fmt.Sprintf("held by process %s", pid)
+	}
+	return ""
+}
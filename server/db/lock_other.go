@@ -0,0 +1,13 @@
+//go:build !linux
+
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+// lockHolder is only implemented on Linux
+func lockHolder(_ string) string {
+	return ""
+}
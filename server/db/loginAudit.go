@@ -0,0 +1,128 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.etcd.io/bbolt"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// loginAuditKey returns a fixed-width key so that audit records sort chronologically
+func loginAuditKey(t time.Time) string {
+	return fmt.Sprintf("%020d-%s", t.UnixNano(), uuid.New().String())
+}
+
+// loginAuditTime parses the time from a login audit key
+func loginAuditTime(key []byte) (time.Time, error) {
+	var nano int64
+	_, err := fmt.Sscanf(string(key), "%d-", &nano)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, nano), nil
+}
+
+// AddLoginAudit stores a login audit event
+func (d *DB) AddLoginAudit(event schema.LoginAuditEvent) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	return d.SetData(BucketLoginAudit, loginAuditKey(event.Time), event)
+}
+
+// GetLoginAudit returns login audit events within the specified time range (unix seconds, 0 for no limit),
+// optionally filtered by username. Events are returned in chronological order.
+func (d *DB) GetLoginAudit(startTime, endTime int64, username string) ([]schema.LoginAuditEvent, error) {
+	events := make([]schema.LoginAuditEvent, 0)
+
+	err := d.view(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BucketLoginAudit))
+		if b == nil {
+			return fmt.Errorf("bucket %s not found", BucketLoginAudit)
+		}
+
+		c := b.Cursor()
+
+		// Seek to the start time if one was specified
+		var k, v []byte
+		if startTime > 0 {
+			k, v = c.Seek([]byte(fmt.Sprintf("%020d", time.Unix(startTime, 0).UnixNano())))
+		} else {
+			k, v = c.First()
+		}
+
+		for ; k != nil; k, v = c.Next() {
+			t, err := loginAuditTime(k)
+			if err != nil {
+				continue
+			}
+
+			if endTime > 0 && t.Unix() > endTime {
+				break
+			}
+
+			var event schema.LoginAuditEvent
+			if err = d.deserialize(v, &event); err != nil {
+				continue
+			}
+
+			if username == "" || strings.EqualFold(event.Username, username) {
+				events = append(events, event)
+			}
+		}
+		return nil
+	})
+	return events, err
+}
+
+// PruneLoginAudit removes login audit events older than the specified number of days and
+// returns the number removed
+func (d *DB) PruneLoginAudit(days int) (int, error) {
+	cutoff := time.Now().AddDate(0, 0, -days)
+	count := 0
+
+	err := d.update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BucketLoginAudit))
+		if b == nil {
+			return fmt.Errorf("bucket %s not found", BucketLoginAudit)
+		}
+
+		// Keys are chronological, so collect keys from the beginning until the cutoff is reached.
+		// Bad keys are deleted as well.
+		var keysToDelete [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			t, err := loginAuditTime(k)
+			if err == nil && !t.Before(cutoff) {
+				break
+			}
+			keysToDelete = append(keysToDelete, k)
+		}
+
+		for _, k := range keysToDelete {
+			if err := b.Delete(k); err != nil {
+				d.logger.Warning(3041, "pruning failed to delete login audit event",
+					fields.NewFields(
+						fields.NewField("key", string(k)),
+						fields.NewField("error", err.Error())))
+				continue
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune login audit: %w", err)
+	}
+	return count, nil
+}
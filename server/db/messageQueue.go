@@ -0,0 +1,82 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Messages from agents are stored until they have been processed so that they survive a
+// restart. Keys are the bucket's sequence number in big-endian order so that iteration
+// returns the messages in the order they were received.
+
+// QueueMessage stores a message from an agent and returns its ID
+func (d *DB) QueueMessage(message schema.AgentMessage) (uint64, error) {
+	data, err := d.serialize(message)
+	if err != nil {
+		return 0, fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	var id uint64
+	err = d.update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BucketMessageQueue))
+		if b == nil {
+			return fmt.Errorf("bucket %s not found", BucketMessageQueue)
+		}
+
+		id, err = b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return b.Put(queueKey(id), data)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue message: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteQueuedMessage removes a message that has been processed
+func (d *DB) DeleteQueuedMessage(id uint64) error {
+	return d.update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BucketMessageQueue))
+		if b == nil {
+			return fmt.Errorf("bucket %s not found", BucketMessageQueue)
+		}
+		return b.Delete(queueKey(id))
+	})
+}
+
+// QueuedMessages calls fn for each stored message in the order they were received. Messages
+// that can't be deserialized are skipped.
+func (d *DB) QueuedMessages(fn func(id uint64, message schema.AgentMessage)) error {
+	return d.view(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(BucketMessageQueue))
+		if b == nil {
+			return fmt.Errorf("bucket %s not found", BucketMessageQueue)
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var message schema.AgentMessage
+			if len(k) != 8 || d.deserialize(v, &message) != nil {
+				return nil
+			}
+			fn(binary.BigEndian.Uint64(k), message)
+			return nil
+		})
+	})
+}
+
+func queueKey(id uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, id)
+	return k
+}
@@ -0,0 +1,24 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// UpdateAgentPatches replaces the agent's patch status
+func (d *DB) UpdateAgentPatches(agentID string, status schema.PatchStatus) error {
+	err := d.UpdateAgentMeta(agentID, func(meta *schema.AgentMeta) error {
+		meta.Patches = &status
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update agent patch status: %w", err)
+	}
+	return nil
+}
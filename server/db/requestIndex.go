@@ -0,0 +1,271 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Requests are stored by request ID. The request index has a child bucket for each agent
+// containing the IDs of the agent's requests, so that an agent's requests can be read
+// without scanning every request. Tag requests are indexed in their own child bucket.
+
+// tagRequestIndex is the child bucket of the request index for tag requests. Agent IDs
+// never contain ':'.
+const tagRequestIndex = ":tags"
+
+// Database schema versions. Each version's migration runs once when the database is opened.
+const (
+	schemaVersionKey          = "schema_version"
+	schemaVersionRequestIndex = 1 // requests are indexed by agent
+	schemaVersionEventKeys    = 2 // event keys are fixed-width
+	schemaVersion             = schemaVersionEventKeys
+)
+
+// indexName returns the child bucket of the request index that a request belongs in
+func indexName(request schema.AgentRequestRecord) string {
+	if request.AgentID == "" {
+		return tagRequestIndex
+	}
+	return request.AgentID
+}
+
+// putRequest stores a serialized request and adds it to the index
+func putRequest(tx *bbolt.Tx, request schema.AgentRequestRecord, data []byte) error {
+	requests := tx.Bucket([]byte(BucketAgentRequests))
+	if requests == nil {
+		return fmt.Errorf("%s bucket not found", BucketAgentRequests)
+	}
+	err := requests.Put([]byte(request.RequestID), data)
+	if err != nil {
+		return fmt.Errorf("failed to store request %s: %w", request.RequestID, err)
+	}
+	return indexRequest(tx, request)
+}
+
+// indexRequest adds a request to the index
+func indexRequest(tx *bbolt.Tx, request schema.AgentRequestRecord) error {
+	index, err := tx.CreateBucketIfNotExists([]byte(BucketRequestIndex))
+	if err != nil {
+		return fmt.Errorf("failed to create request index: %w", err)
+	}
+	child, err := index.CreateBucketIfNotExists([]byte(indexName(request)))
+	if err != nil {
+		return fmt.Errorf("failed to create request index for %s: %w", indexName(request), err)
+	}
+	return child.Put([]byte(request.RequestID), []byte{})
+}
+
+// deleteRequest deletes a request and removes it from the index
+func deleteRequest(tx *bbolt.Tx, requestKey string) error {
+	requests := tx.Bucket([]byte(BucketAgentRequests))
+	if requests == nil {
+		return errors.New("bucket not found")
+	}
+
+	data := requests.Get([]byte(requestKey))
+	if data == nil {
+		return errors.New("key not found")
+	}
+
+	// A record that can't be read is deleted without updating the index, where it is ignored
+	var request schema.AgentRequestRecord
+	if json.Unmarshal(data, &request) == nil {
+		if index := tx.Bucket([]byte(BucketRequestIndex)); index != nil {
+			if child := index.Bucket([]byte(indexName(request))); child != nil {
+				_ = child.Delete([]byte(requestKey))
+			}
+		}
+	}
+
+	if err := requests.Delete([]byte(requestKey)); err != nil {
+		return fmt.Errorf("error deleting data %w", err)
+	}
+	return nil
+}
+
+// getIndexedRequests returns the requests in a child bucket of the request index. Index
+// entries for requests that no longer exist are ignored.
+func (d *DB) getIndexedRequests(name string) ([]schema.AgentRequestRecord, error) {
+	var result []schema.AgentRequestRecord
+	err := d.view(func(tx *bbolt.Tx) error {
+		requests := tx.Bucket([]byte(BucketAgentRequests))
+		index := tx.Bucket([]byte(BucketRequestIndex))
+		if requests == nil || index == nil {
+			return fmt.Errorf("bucket %s or %s not found", BucketAgentRequests, BucketRequestIndex)
+		}
+
+		child := index.Bucket([]byte(name))
+		if child == nil {
+			return nil
+		}
+
+		return child.ForEach(func(key, _ []byte) error {
+			data := requests.Get(key)
+			if data == nil {
+				return nil
+			}
+
+			var request schema.AgentRequestRecord
+			if err := d.deserialize(data, &request); err != nil {
+				d.logger.Warning(3033, "failed to deserialize request record",
+					fields.NewFields(
+						fields.NewField("key", string(key)),
+						fields.NewField("error", err.Error())))
+				return nil
+			}
+			result = append(result, request)
+			return nil
+		})
+	})
+	return result, err
+}
+
+// GetRequestQueues returns a summary of the new and pending requests for each of the specified
+// agents, or for every agent if none are specified, using a single read of the request index.
+// Agents without undelivered requests are not included. Only the status and creation time of
+// each request are decoded.
+func (d *DB) GetRequestQueues(agentIDs ...string) (map[string]schema.AgentQueue, error) {
+	result := make(map[string]schema.AgentQueue)
+	err := d.view(func(tx *bbolt.Tx) error {
+		requests := tx.Bucket([]byte(BucketAgentRequests))
+		index := tx.Bucket([]byte(BucketRequestIndex))
+		if requests == nil || index == nil {
+			return fmt.Errorf("bucket %s or %s not found", BucketAgentRequests, BucketRequestIndex)
+		}
+
+		summarize := func(agentID string, child *bbolt.Bucket) error {
+			return child.ForEach(func(key, _ []byte) error {
+				data := requests.Get(key)
+				if data == nil {
+					return nil
+				}
+
+				var request struct {
+					Status      string    `json:"status"`
+					TimeCreated time.Time `json:"time_created"`
+				}
+				if d.deserialize(data, &request) != nil {
+					return nil
+				}
+
+				queue := result[agentID]
+				switch request.Status {
+				case schema.RequestStatusNew:
+					queue.New++
+				case schema.RequestStatusPending:
+					queue.Pending++
+				default:
+					return nil
+				}
+				if queue.Oldest.IsZero() || request.TimeCreated.Before(queue.Oldest) {
+					queue.Oldest = request.TimeCreated
+				}
+				result[agentID] = queue
+				return nil
+			})
+		}
+
+		if len(agentIDs) > 0 {
+			for _, agentID := range agentIDs {
+				if child := index.Bucket([]byte(agentID)); child != nil {
+					if err := summarize(agentID, child); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+
+		return index.ForEachBucket(func(name []byte) error {
+			if string(name) == tagRequestIndex {
+				return nil
+			}
+			return summarize(string(name), index.Bucket(name))
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize agent requests: %w", err)
+	}
+	return result, nil
+}
+
+// migrate brings the database up to the current schema version. Each migration runs in a
+// single transaction with the update to the version, so an interrupted migration is repeated
+// the next time the database is opened.
+func migrate(db *bbolt.DB, logger interfaces.Logger) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists([]byte(BucketSchema))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", BucketSchema, err)
+		}
+
+		version, _ := strconv.Atoi(string(meta.Get([]byte(schemaVersionKey))))
+		if version >= schemaVersion {
+			return nil
+		}
+
+		if version < schemaVersionRequestIndex {
+			count, err := buildRequestIndex(tx)
+			if err != nil {
+				return fmt.Errorf("failed to index requests: %w", err)
+			}
+			logger.Info(2202, "requests indexed by agent", fields.NewFields(
+				fields.NewField("requests", count)))
+		}
+
+		if version < schemaVersionEventKeys {
+			count, err := rekeyEvents(tx)
+			if err != nil {
+				return fmt.Errorf("failed to rekey events: %w", err)
+			}
+			logger.Info(2203, "event keys converted to fixed width", fields.NewFields(
+				fields.NewField("events", count)))
+		}
+
+		return meta.Put([]byte(schemaVersionKey), []byte(strconv.Itoa(schemaVersion)))
+	})
+}
+
+// buildRequestIndex replaces the request index with one built from the stored requests, and
+// returns the number of requests indexed
+func buildRequestIndex(tx *bbolt.Tx) (int, error) {
+	if tx.Bucket([]byte(BucketRequestIndex)) != nil {
+		if err := tx.DeleteBucket([]byte(BucketRequestIndex)); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.CreateBucket([]byte(BucketRequestIndex)); err != nil {
+		return 0, err
+	}
+
+	requests := tx.Bucket([]byte(BucketAgentRequests))
+	if requests == nil {
+		return 0, nil
+	}
+
+	count := 0
+	err := requests.ForEach(func(key, value []byte) error {
+		var request schema.AgentRequestRecord
+		if json.Unmarshal(value, &request) != nil {
+			return nil
+		}
+		request.RequestID = string(key)
+		count++
+		return indexRequest(tx, request)
+	})
+	return count, err
+}
@@ -13,26 +13,12 @@ import (
 
 // GetAgentRequestRecords retrieves all request records for a given agent
 func (d *DB) GetAgentRequestRecords(agentID string) (schema.AgentRequestRecordList, error) {
-	var result schema.AgentRequestRecordList
-
-	// TODO: optimize with prefix scan when request keys include agentID prefix
-	err := d.ForEach(BucketAgentRequests, func(key, value []byte) error {
-		var request schema.AgentRequestRecord
-		err := d.deserialize(value, &request)
-		if err != nil {
-			return fmt.Errorf("failed to deserialize request record: %w", err)
-		}
-		if request.AgentID == agentID {
-			result.Requests = append(result.Requests, request)
-		}
-		return nil
-	})
-
+	requests, err := d.getIndexedRequests(agentID)
 	if err != nil {
 		return schema.AgentRequestRecordList{}, fmt.Errorf("failed to retrieve agent request records: %w", err)
 	}
 
-	return result, nil
+	return schema.AgentRequestRecordList{Requests: requests}, nil
 }
 
 // GetAllRequestRecords retrieves all agent metadata from the AgentMeta bucket
@@ -40,7 +26,6 @@ func (d *DB) GetAllRequestRecords() (schema.AgentRequestRecordList, error) {
 	var allRequests schema.AgentRequestRecordList
 
 	// Iterate over all keys in the bucket
-	// TODO: optimize with prefix scan when request keys include agentID prefix
 	err := d.ForEach(BucketAgentRequests, func(key, value []byte) error {
 		var request schema.AgentRequestRecord
 		err := d.deserialize(value, &request)
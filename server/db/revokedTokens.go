@@ -0,0 +1,134 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+)
+
+// subjectRevocation rejects the tokens issued to a user or agent before NotBefore. It is
+// kept until Expires, when all of those tokens have expired, or indefinitely if zero.
+type subjectRevocation struct {
+	NotBefore int64 `json:"not_before"`
+	Expires   int64 `json:"expires"`
+}
+
+// RevokeToken records that the token with the specified ID is no longer valid. The
+// record is kept until the token expires, or indefinitely if expires is zero.
+func (d *DB) RevokeToken(tokenID string, expires time.Time) error {
+	if tokenID == "" {
+		return fmt.Errorf("token ID is required")
+	}
+
+	err := d.SetData(BucketRevokedTokens, tokenID, unixOrZero(expires))
+	if err != nil {
+		return fmt.Errorf("failed to store revoked token: %w", err)
+	}
+	return nil
+}
+
+// TokenRevoked returns true if the token with the specified ID has been revoked
+func (d *DB) TokenRevoked(tokenID string) bool {
+	exists, err := d.KeyExists(BucketRevokedTokens, tokenID)
+	return err == nil && exists
+}
+
+// RevokeSubject records that tokens issued to a user or agent before notBefore are no
+// longer valid. The record is kept until expires, or indefinitely if expires is zero.
+func (d *DB) RevokeSubject(subject string, notBefore, expires time.Time) error {
+	if subject == "" {
+		return fmt.Errorf("subject is required")
+	}
+
+	err := d.SetData(BucketRevokedSubjects, subject, subjectRevocation{
+		NotBefore: notBefore.Unix(),
+		Expires:   unixOrZero(expires)})
+	if err != nil {
+		return fmt.Errorf("failed to store revoked subject: %w", err)
+	}
+	return nil
+}
+
+// SubjectNotBefore returns the time, in seconds since the epoch, before which tokens issued
+// to the user or agent are not valid, or zero if its tokens have not been revoked
+func (d *DB) SubjectNotBefore(subject string) int64 {
+	var revocation subjectRevocation
+	if err := d.GetData(BucketRevokedSubjects, subject, &revocation); err != nil {
+		return 0
+	}
+	return revocation.NotBefore
+}
+
+// PruneRevokedTokens removes revocation records that are no longer needed because the
+// tokens they apply to have expired
+func (d *DB) PruneRevokedTokens() error {
+	now := time.Now().Unix()
+	count := 0
+
+	err := d.update(func(tx *bbolt.Tx) error {
+		for _, bucketName := range []string{BucketRevokedTokens, BucketRevokedSubjects} {
+			b := tx.Bucket([]byte(bucketName))
+			if b == nil {
+				return fmt.Errorf("bucket %s not found", bucketName)
+			}
+
+			// Records that can't be deserialized are deleted as well
+			var keysToDelete [][]byte
+			err := b.ForEach(func(k, v []byte) error {
+				var expires int64
+				var err error
+				if bucketName == BucketRevokedSubjects {
+					var revocation subjectRevocation
+					err = d.deserialize(v, &revocation)
+					expires = revocation.Expires
+				} else {
+					err = d.deserialize(v, &expires)
+				}
+				if err != nil || (expires > 0 && expires < now) {
+					keysToDelete = append(keysToDelete, k)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, k := range keysToDelete {
+				if err = b.Delete(k); err != nil {
+					d.logger.Warning(3043, "pruning failed to delete revocation record",
+						fields.NewFields(
+							fields.NewField("bucket", bucketName),
+							fields.NewField("key", string(k)),
+							fields.NewField("error", err.Error())))
+					continue
+				}
+				count++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to prune revoked tokens: %w", err)
+	}
+
+	if count > 0 {
+		d.logger.Info(3042, "pruned revocation records", fields.NewFields(fields.NewField("count", count)))
+	}
+	return nil
+}
+
+// unixOrZero returns the time in seconds since the epoch, or zero for the zero time
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
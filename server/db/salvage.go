@@ -0,0 +1,127 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// SalvageReport describes the result of salvaging a damaged database
+type SalvageReport struct {
+	Records int      // records copied to the new database
+	Buckets int      // buckets copied to the new database, including nested buckets
+	Errors  []string // buckets that could not be read in full
+	Backup  string   // path the damaged file was moved to
+}
+
+// Salvage copies everything that can be read from a damaged database into a new file, moves
+// the damaged file aside with a timestamp, and moves the new file into its place. If the
+// damaged file can't be opened at all, nothing is changed and an error is returned.
+func Salvage(filePath string) (SalvageReport, error) {
+	var report SalvageReport
+
+	src, err := openBolt(filePath, &bbolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return report, fmt.Errorf("unable to open damaged database: %w", err)
+	}
+
+	newPath := filePath + ".salvage"
+	_ = os.Remove(newPath)
+	dst, err := bbolt.Open(newPath, 0600, &bbolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		_ = src.Close()
+		return report, fmt.Errorf("unable to create new database: %w", err)
+	}
+
+	// The source transaction must remain open until the copy is committed
+	err = src.View(func(srcTx *bbolt.Tx) error {
+		return dst.Update(func(dstTx *bbolt.Tx) error {
+			copyRoot(srcTx, dstTx, &report)
+
+			// Rebuild the request index from the requests that were recovered
+			_, err := buildRequestIndex(dstTx)
+			return err
+		})
+	})
+	_ = src.Close()
+	_ = dst.Close()
+	if err != nil {
+		_ = os.Remove(newPath)
+		return report, fmt.Errorf("unable to write new database: %w", err)
+	}
+
+	// Move the damaged file aside and the new one into place
+	report.Backup = filePath + ".corrupt-" + time.Now().Format("20060102-150405")
+	if err = os.Rename(filePath, report.Backup); err != nil {
+		_ = os.Remove(newPath)
+		return report, fmt.Errorf("unable to move damaged database: %w", err)
+	}
+	if err = os.Rename(newPath, filePath); err != nil {
+		return report, fmt.Errorf("unable to move new database into place: %w", err)
+	}
+
+	return report, nil
+}
+
+// copyRoot copies the root buckets, stopping if the root itself can't be read
+func copyRoot(src, dst *bbolt.Tx, report *SalvageReport) {
+	defer func() {
+		if r := recover(); r != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("root: %v", r))
+		}
+	}()
+
+	c := src.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		name := string(k)
+		b, err := dst.CreateBucketIfNotExists(k)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		report.Buckets++
+		copyBucket(src.Bucket(k), b, name, report)
+	}
+}
+
+// copyBucket copies the records and nested buckets in src to dst. Bolt panics when it reads
+// a damaged page, in which case the records copied so far are kept and the rest are skipped.
+func copyBucket(src, dst *bbolt.Bucket, name string, report *SalvageReport) {
+	defer func() {
+		if r := recover(); r != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", name, r))
+		}
+	}()
+
+	if src == nil {
+		return
+	}
+
+	c := src.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		// Nested buckets have nil values
+		if v == nil {
+			child, err := dst.CreateBucketIfNotExists(k)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s/%s: %v", name, k, err))
+				continue
+			}
+			report.Buckets++
+			copyBucket(src.Bucket(k), child, name+"/"+string(k), report)
+			continue
+		}
+
+		if err := dst.Put(k, v); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s/%s: %v", name, k, err))
+			continue
+		}
+		report.Records++
+	}
+}
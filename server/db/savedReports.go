@@ -0,0 +1,64 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// SetSavedReport stores a saved report. Names are not case-sensitive.
+func (d *DB) SetSavedReport(report schema.SavedReport) error {
+	err := d.SetData(BucketSavedReports, schema.SavedReportKey(report.Name), report)
+	if err != nil {
+		return fmt.Errorf("failed to store saved report: %w", err)
+	}
+	return nil
+}
+
+// GetSavedReport retrieves a saved report by name
+func (d *DB) GetSavedReport(name string) (schema.SavedReport, error) {
+	var report schema.SavedReport
+	err := d.GetData(BucketSavedReports, schema.SavedReportKey(name), &report)
+	return report, err
+}
+
+// UpdateSavedReport calls fn to modify a saved report within a single transaction
+func (d *DB) UpdateSavedReport(name string, fn func(*schema.SavedReport) error) error {
+	var report schema.SavedReport
+	return d.UpdateData(BucketSavedReports, schema.SavedReportKey(name), &report, func() error {
+		return fn(&report)
+	})
+}
+
+// SavedReportExists returns true if the saved report exists
+func (d *DB) SavedReportExists(name string) (bool, error) {
+	return d.KeyExists(BucketSavedReports, schema.SavedReportKey(name))
+}
+
+// DeleteSavedReport deletes a saved report by name
+func (d *DB) DeleteSavedReport(name string) error {
+	return d.DeleteData(BucketSavedReports, schema.SavedReportKey(name))
+}
+
+// GetSavedReports retrieves all saved reports
+func (d *DB) GetSavedReports() ([]schema.SavedReport, error) {
+	reports := make([]schema.SavedReport, 0)
+	err := d.ForEach(BucketSavedReports, func(key, value []byte) error {
+		var report schema.SavedReport
+		if err := d.deserialize(value, &report); err != nil {
+			return fmt.Errorf("failed to deserialize saved report %s: %w", key, err)
+		}
+		reports = append(reports, report)
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve saved reports: %w", err)
+	}
+	return reports, nil
+}
@@ -0,0 +1,52 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetTagChannel assigns an upgrade channel to a tag. Tags are not case-sensitive.
+// An empty channel removes the assignment.
+func (d *DB) SetTagChannel(tag, channel string) error {
+	key := strings.ToLower(tag)
+	if key == "" {
+		return fmt.Errorf("tag is required")
+	}
+
+	if channel == "" {
+		exists, err := d.KeyExists(BucketTagChannels, key)
+		if err != nil || !exists {
+			return err
+		}
+		return d.DeleteData(BucketTagChannels, key)
+	}
+
+	err := d.SetData(BucketTagChannels, key, channel)
+	if err != nil {
+		return fmt.Errorf("failed to store tag channel: %w", err)
+	}
+	return nil
+}
+
+// GetTagChannels returns a map of tags to upgrade channels
+func (d *DB) GetTagChannels() (map[string]string, error) {
+	result := make(map[string]string)
+	err := d.ForEach(BucketTagChannels, func(key, value []byte) error {
+		var channel string
+		if err := d.deserialize(value, &channel); err != nil {
+			return fmt.Errorf("failed to deserialize channel for tag %s: %w", key, err)
+		}
+		result[string(key)] = channel
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve tag channels: %w", err)
+	}
+	return result, nil
+}
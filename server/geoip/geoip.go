@@ -0,0 +1,65 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+// Package geoip looks up the location of IP addresses in a local MaxMind database. Lookups
+// do not make any network requests.
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DB is an open GeoIP2 or GeoLite2 Country or City database
+type DB struct {
+	reader *maxminddb.Reader
+}
+
+// Location is the location of an IP address. City is only available from City databases.
+type Location struct {
+	Country string // ISO 3166-1 country code
+	City    string // English name
+}
+
+// record contains the fields used from a database record
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// Open opens a database
+func Open(path string) (*DB, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open GeoIP database %s: %w", path, err)
+	}
+	return &DB{reader: reader}, nil
+}
+
+// Lookup returns the location of an IP address. The location is empty if the address is not
+// in the database, such as a private address.
+func (g *DB) Lookup(ip string) (Location, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return Location{}, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	var r record
+	if err := g.reader.Lookup(addr, &r); err != nil {
+		return Location{}, fmt.Errorf("GeoIP lookup failed: %w", err)
+	}
+	return Location{Country: r.Country.ISOCode, City: r.City.Names["en"]}, nil
+}
+
+// Close closes the database
+func (g *DB) Close() error {
+	return g.reader.Close()
+}
@@ -12,10 +12,12 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"sort"
 
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/common/uconfig"
+	"github.com/UnifyEM/UnifyEM/common/ulogger"
 )
 
 type ServerConfig struct {
@@ -165,6 +167,95 @@ func GenerateToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(token), nil
 }
 
+// startupSettings are only read when the server starts, so changing them requires a restart
+var startupSettings = map[string]bool{
+	ConfigListen:           true,
+	ConfigDataPath:         true,
+	ConfigDBPath:           true,
+	ConfigHTTPTimeout:      true,
+	ConfigHTTPIdleTimeout:  true,
+	ConfigHandlerTimeout:   true,
+	ConfigMaxConcurrent:    true,
+	ConfigMaxBodyBytes:     true,
+	ConfigPenaltyBoxMin:    true,
+	ConfigPenaltyBoxMax:    true,
+	ConfigLoginRateLimit:   true,
+	ConfigLoginRateBurst:   true,
+	ConfigCompression:      true,
+	ConfigMetricsEnabled:   true,
+	ConfigMetricsListen:    true,
+	ConfigDashboardEnabled: true,
+	ConfigCORSOrigins:      true,
+	ConfigTrustedProxies:   true,
+	ConfigNotifyQueueSize:  true,
+}
+
+// SecretMask is returned by the API in place of the value of a secret setting that is set
+const SecretMask = "********"
+
+// SecretSettings can be set through the API but their values are never returned by it
+var SecretSettings = map[string]bool{
+	ConfigNotifyWebhookSecret: true,
+	ConfigSMTPPassword:        true,
+}
+
+// Reload reads the server and agent settings from the file or registry again and applies those
+// that changed, so that they take effect without a restart. It returns the settings that
+// changed, and those among them that are only read at startup. Private settings, such as keys
+// and the registration token, are not reloaded.
+func (c *ServerConfig) Reload() (changed []string, restart []string, err error) {
+	var fresh interfaces.Config
+	if runtime.GOOS == "windows" {
+		fresh, err = uconfig.New(uconfig.WithWindowsRegistry(Name))
+	} else {
+		fresh, err = uconfig.New(uconfig.WithFind(UnixConfigFiles))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sc, _ := setDefaults(fresh)
+	for _, key := range reloadSet(c.SC, sc) {
+		changed = append(changed, key)
+		if startupSettings[key] {
+			restart = append(restart, key)
+		}
+	}
+
+	// Agent settings are sent to agents when they sync
+	for _, key := range reloadSet(c.AC, schema.SetAgentDefaults(fresh)) {
+		changed = append(changed, schema.ConfigAgentSet+"."+key)
+	}
+	return changed, restart, nil
+}
+
+// reloadSet copies the values in fresh that differ from current and returns their keys
+func reloadSet(current, fresh interfaces.Parameters) []string {
+	before := current.GetMap()
+	var changed []string
+	for key, value := range fresh.GetMap() {
+		if before[key] != value {
+			current.Set(key, value)
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
 func (c *ServerConfig) Checkpoint() error {
 	return c.C.Checkpoint()
 }
+
+// LoggerOptions returns the logger options for the server configuration
+func (c *ServerConfig) LoggerOptions() []ulogger.Option {
+	return []ulogger.Option{
+		ulogger.WithPrefix(LogName),
+		ulogger.WithLogFile(c.SC.Get(ConfigLogFile).String()),
+		ulogger.WithLogStdout(c.SC.Get(ConfigLogStdout).Bool()),
+		ulogger.WithRetention(c.SC.Get(ConfigLogRetention).Int()),
+		ulogger.WithMaxSizeMB(c.SC.Get(ConfigLogMaxSize).Int()),
+		ulogger.WithMaxFiles(c.SC.Get(ConfigLogMaxFiles).Int()),
+		ulogger.WithWindowsEvents(true),
+		ulogger.WithDebug(Debug)}
+}
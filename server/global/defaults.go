@@ -12,39 +12,93 @@ import (
 )
 
 const (
-	ConfigServerSet             = "server_config"
-	ConfigLogFile               = "log_file"
-	ConfigLogStdout             = "log_stdout"
-	ConfigLogRetention          = "log_retention"
-	ConfigListen                = "listen"
-	ConfigExternalULR           = "external_url"
-	ConfigDataPath              = "data_path"
-	ConfigFilesPath             = "files_path"
-	ConfigDBPath                = "db_path"
-	ConfigHTTPTimeout           = "http_timeout"
-	ConfigHTTPIdleTimeout       = "http_idle_timeout"
-	ConfigMaxConcurrent         = "max_concurrent"
-	ConfigPenaltyBoxMin         = "penalty_box_min"
-	ConfigPenaltyBoxMax         = "penalty_box_max"
-	ConfigHandlerTimeout        = "handler_timeout"
-	ConfigAccessTokenLife       = "access_token_life"
-	ConfigRefreshTokenLifeUsers = "refresh_token_life_users"
-	ConfigAuthorizedAdminIPs    = "authorized_admin_ips"
-	ConfigRequestRetries        = "request_retries"
-	ConfigRequestRetryDelay     = "request_retry_delay"
-	ConfigAgentRetention        = "agent_retention_days"
-	ConfigEventRetention        = "event_retention_days"
-	ConfigRequestRetention      = "request_retention_days"
-	ConfigRecoveryPublicKey     = "recovery_public_key"
+	ConfigServerSet              = "server_config"
+	ConfigLogFile                = "log_file"
+	ConfigLogStdout              = "log_stdout"
+	ConfigLogRetention           = "log_retention"
+	ConfigLogMaxSize             = "log_max_size_mb"
+	ConfigLogMaxFiles            = "log_max_files"
+	ConfigListen                 = "listen"
+	ConfigExternalULR            = "external_url"
+	ConfigDataPath               = "data_path"
+	ConfigFilesPath              = "files_path"
+	ConfigDBPath                 = "db_path"
+	ConfigHTTPTimeout            = "http_timeout"
+	ConfigHTTPIdleTimeout        = "http_idle_timeout"
+	ConfigMaxConcurrent          = "max_concurrent"
+	ConfigPenaltyBoxMin          = "penalty_box_min"
+	ConfigPenaltyBoxMax          = "penalty_box_max"
+	ConfigHandlerTimeout         = "handler_timeout"
+	ConfigMaxBodyBytes           = "max_body_bytes"
+	ConfigCompression            = "compression"
+	ConfigAccessTokenLife        = "access_token_life"
+	ConfigRefreshTokenLifeUsers  = "refresh_token_life_users"
+	ConfigAuthorizedAdminIPs     = "authorized_admin_ips"
+	ConfigRequestRetries         = "request_retries"
+	ConfigRequestRetryDelay      = "request_retry_delay"
+	ConfigAgentRetention         = "agent_retention_days"
+	ConfigAgentOfflineThreshold  = "agent_offline_threshold"
+	ConfigEventRetention         = "event_retention_days"
+	ConfigRequestRetention       = "request_retention_days"
+	ConfigRequestFailedRetention = "request_failed_retention_days"
+	ConfigLoginAuditRetention    = "login_audit_retention_days"
+	ConfigPruneInterval          = "prune_interval"
+	ConfigCompactThreshold       = "compact_threshold"
+	ConfigTagRequestDays         = "tag_request_days"
+	ConfigRecoveryPublicKey      = "recovery_public_key"
+	ConfigLoginRateLimit         = "login_rate_limit"
+	ConfigLoginRateBurst         = "login_rate_burst"
+	ConfigLoginMaxFailures       = "login_max_failures"
+	ConfigLoginLockoutMinutes    = "login_lockout_minutes"
+	ConfigMinimumAgentVersion    = "minimum_agent_version"
+	ConfigMetricsEnabled         = "metrics_enabled"
+	ConfigMetricsListen          = "metrics_listen"
+	ConfigDashboardEnabled       = "dashboard_enabled"
+	ConfigCORSOrigins            = "cors_allowed_origins"
+	ConfigTrustedProxies         = "trusted_proxies"
+	ConfigNotifyWebhookURL       = "notify_webhook_url"
+	ConfigNotifyWebhookSecret    = "notify_webhook_secret"
+	ConfigNotifySyslogAddress    = "notify_syslog_address"
+	ConfigNotifySyslogTLS        = "notify_syslog_tls"
+	ConfigNotifyEventTypes       = "notify_event_types"
+	ConfigNotifyQueueSize        = "notify_queue_size"
+	ConfigShellRoles             = "shell_roles"
+	ConfigShellIdleTimeout       = "shell_idle_timeout"
+	ConfigResponseRoles          = "sensitive_response_roles"
+	ConfigTokenLeeway            = "token_leeway"
+	ConfigDBAutoSalvage          = "db_auto_salvage"
+	ConfigBackupPath             = "backup_path"
+	ConfigBackupInterval         = "backup_interval"
+	ConfigBackupRetention        = "backup_retention"
+	ConfigTLSPin                 = "tls_pin"
+	ConfigTLSPinNext             = "tls_pin_next"
+	ConfigIPHistorySize          = "ip_history_size"
+	ConfigGeoIPDatabase          = "geoip_database"
+	ConfigCountryChangeEvents    = "country_change_events"
+	ConfigFileUploadMax          = "file_upload_max_mb"
+	ConfigMigrationTokenDays     = "migration_token_days"
+	ConfigSyncMaxConcurrent      = "sync_max_concurrent"
+	ConfigSyncMaxQueue           = "sync_max_queue_percent"
+	ConfigSyncRetryAfter         = "sync_retry_after"
+	ConfigSMTPHost               = "smtp_host"
+	ConfigSMTPPort               = "smtp_port"
+	ConfigSMTPTLS                = "smtp_tls"
+	ConfigSMTPUsername           = "smtp_username"
+	ConfigSMTPPassword           = "smtp_password"
+	ConfigSMTPFrom               = "smtp_from"
+	ConfigApprovalCommands       = "approval_commands"
 
 	ConfigPrivate                = "server_private"
 	ConfigRegToken               = "reg_token"
+	ConfigMigrationToken         = "migration_token"
+	ConfigMigrationTokenExpires  = "migration_token_expires"
 	ConfigJWTKey                 = "jwt_key"
 	ConfigRefreshTokenLifeAgents = "refresh_token_life_agents"
 	ConfigServerECPrivateSig     = "ec_private_sig"
 	ConfigServerECPublicSig      = "ec_public_sig"
 	ConfigServerECPrivateEnc     = "ec_private_enc"
 	ConfigServerECPublicEnc      = "ec_public_enc"
+	ConfigTokensNotBefore        = "tokens_not_before"
 )
 
 // setDefaults makes sure the sets exist, sets default values, and constraints
@@ -55,6 +109,8 @@ func setDefaults(c interfaces.Config) (interfaces.Parameters, interfaces.Paramet
 	sc.SetConstraint(ConfigLogFile, 0, 0, "")                          // no log file by default
 	sc.SetConstraint(ConfigLogStdout, 0, 0, true)                      // by default log to stdout
 	sc.SetConstraint(ConfigLogRetention, 1, 0, 365)                    // days
+	sc.SetConstraint(ConfigLogMaxSize, 0, 0, 100)                      // MB before the log is rotated and compressed (0 to rotate daily only)
+	sc.SetConstraint(ConfigLogMaxFiles, 1, 0, 10)                      // logs rotated by size to keep
 	sc.SetConstraint(ConfigListen, 0, 0, "127.0.0.1:8080")             // listen address
 	sc.SetConstraint(ConfigExternalULR, 0, 0, "http://127.0.0.1:8080") // external URL (should be FQDN for production)
 	sc.SetConstraint(ConfigDataPath, 0, 0, "")                         // data path (base directory for data)
@@ -66,25 +122,77 @@ func setDefaults(c interfaces.Config) (interfaces.Parameters, interfaces.Paramet
 	sc.SetConstraint(ConfigPenaltyBoxMin, 0, 0, 1000)                  // Minimum penalty box time in milliseconds
 	sc.SetConstraint(ConfigPenaltyBoxMax, 0, 0, 5000)                  // Maximum penalty box time in milliseconds
 	sc.SetConstraint(ConfigHandlerTimeout, 0, 0, 30)                   // seconds
+	sc.SetConstraint(ConfigMaxBodyBytes, 0, 0, 10485760)               // largest request body accepted by most endpoints (0 for no limit)
+	sc.SetConstraint(ConfigCompression, 0, 0, true)                    // accept gzip request bodies and compress responses (requires restart)
 	sc.SetConstraint(ConfigAccessTokenLife, 0, 0, 720)                 // minutes
 	sc.SetConstraint(ConfigRefreshTokenLifeUsers, 0, 0, 1440)          // minutes
 	sc.SetConstraint(ConfigAuthorizedAdminIPs, 0, 0, "127.0.0.1")      // default to localhost
 	sc.SetConstraint(ConfigRequestRetries, 0, 0, 3)                    // default to 3 retries
 	sc.SetConstraint(ConfigRequestRetryDelay, 0, 0, 600)               // seconds
 	sc.SetConstraint(ConfigAgentRetention, 1, 0, 365)                  // days
+	sc.SetConstraint(ConfigAgentOfflineThreshold, 0, 0, 60)            // minutes without a sync before an agent is reported offline (0 to disable)
 	sc.SetConstraint(ConfigEventRetention, 1, 0, 365)                  // days
-	sc.SetConstraint(ConfigRequestRetention, 1, 0, 365)                // days
+	sc.SetConstraint(ConfigRequestRetention, 1, 0, 365)                // days that completed, cancelled, and denied requests are kept
+	sc.SetConstraint(ConfigRequestFailedRetention, 1, 0, 90)           // days that failed, refused, invalid, and expired requests are kept
+	sc.SetConstraint(ConfigLoginAuditRetention, 1, 0, 365)             // days
+	sc.SetConstraint(ConfigPruneInterval, 1, 0, 6)                     // hours between database pruning
+	sc.SetConstraint(ConfigCompactThreshold, 0, 100, 50)               // percent of the database that is free space before it is compacted after pruning (0 to disable)
+	sc.SetConstraint(ConfigTagRequestDays, 1, 0, 7)                    // days that tag requests are delivered to agents that sync or gain the tag
 	sc.SetConstraint(ConfigRecoveryPublicKey, 0, 0, "")
+	sc.SetConstraint(ConfigLoginRateLimit, 0, 0, 10)                       // requests per minute per IP for login, register, and refresh (0 to disable)
+	sc.SetConstraint(ConfigLoginRateBurst, 1, 0, 5)                        // requests allowed in a burst before rate limiting applies
+	sc.SetConstraint(ConfigLoginMaxFailures, 0, 0, 5)                      // failed logins before an account is locked (0 to disable)
+	sc.SetConstraint(ConfigLoginLockoutMinutes, 1, 0, 15)                  // minutes
+	sc.SetConstraint(ConfigMinimumAgentVersion, 0, 0, "")                  // agents older than this are upgraded automatically (empty to disable)
+	sc.SetConstraint(ConfigMetricsEnabled, 0, 0, false)                    // expose Prometheus metrics at /metrics
+	sc.SetConstraint(ConfigMetricsListen, 0, 0, "")                        // separate unauthenticated listen address for metrics (empty to use the API with admin auth)
+	sc.SetConstraint(ConfigDashboardEnabled, 0, 0, false)                  // serve the read-only web dashboard at /ui/ (requires restart)
+	sc.SetConstraint(ConfigCORSOrigins, 0, 0, "")                          // comma separated origins permitted to call the API from a browser, * for any (empty to disable, requires restart)
+	sc.SetConstraint(ConfigTrustedProxies, 0, 0, "")                       // comma separated addresses of reverse proxies whose X-Forwarded-For header is trusted for rate limiting (requires restart)
+	sc.SetConstraint(ConfigNotifyWebhookURL, 0, 0, "")                     // https URL that events are posted to (empty to disable)
+	sc.SetConstraint(ConfigNotifyWebhookSecret, 0, 0, "")                  // shared secret used to sign webhook requests
+	sc.SetConstraint(ConfigNotifySyslogAddress, 0, 0, "")                  // host:port of a TCP syslog receiver (empty to disable)
+	sc.SetConstraint(ConfigNotifySyslogTLS, 0, 0, true)                    // use TLS for syslog
+	sc.SetConstraint(ConfigNotifyEventTypes, 0, 0, "message,alert,tamper") // comma separated event types to forward (empty for all)
+	sc.SetConstraint(ConfigNotifyQueueSize, 1, 100000, 1000)               // events waiting for delivery before new events are dropped (requires restart)
+	sc.SetConstraint(ConfigShellRoles, 0, 0, "superadmin")                 // comma separated roles permitted to open remote shell sessions (empty for none)
+	sc.SetConstraint(ConfigShellIdleTimeout, 60, 86400, 600)               // seconds without input or output before a remote shell session is closed
+	sc.SetConstraint(ConfigResponseRoles, 0, 0, "superadmin")              // comma separated roles permitted to read responses to sensitive commands (empty for none)
+	sc.SetConstraint(ConfigTokenLeeway, 0, 3600, 120)                      // seconds of clock skew tolerated when validating token expiry
+	sc.SetConstraint(ConfigDBAutoSalvage, 0, 0, false)                     // copy what can be read from a corrupted database into a new one at startup
+	sc.SetConstraint(ConfigBackupPath, 0, 0, "")                           // directory for scheduled database snapshots (empty to disable)
+	sc.SetConstraint(ConfigBackupInterval, 1, 8760, 24)                    // hours between scheduled snapshots
+	sc.SetConstraint(ConfigBackupRetention, 1, 1000, 7)                    // number of snapshots kept
+	sc.SetConstraint(ConfigTLSPin, 0, 0, "")                               // pin for the server certificate included in registration tokens (empty to disable)
+	sc.SetConstraint(ConfigTLSPinNext, 0, 0, "")                           // pin for a replacement certificate offered to pinned agents before rotation
+	sc.SetConstraint(ConfigIPHistorySize, 1, 100, 10)                      // distinct public IP addresses kept for each agent
+	sc.SetConstraint(ConfigGeoIPDatabase, 0, 0, "")                        // path to a MaxMind GeoIP2 or GeoLite2 Country or City database (empty to disable)
+	sc.SetConstraint(ConfigCountryChangeEvents, 0, 0, true)                // record an event when an agent syncs from a different country
+	sc.SetConstraint(ConfigFileUploadMax, 1, 16384, 1024)                  // maximum size in MB of files uploaded to the files directory
+	sc.SetConstraint(ConfigMigrationTokenDays, 1, 90, 7)                   // days that a new migration token is valid for
+	sc.SetConstraint(ConfigSyncMaxConcurrent, 0, 0, 75)                    // syncs in progress before agents are told to retry later (0 to disable)
+	sc.SetConstraint(ConfigSyncMaxQueue, 0, 100, 80)                       // percent of the message queue in use before agents are told to retry later (0 to disable)
+	sc.SetConstraint(ConfigSyncRetryAfter, 1, 3600, 60)                    // minimum seconds an agent told to retry later waits, up to twice this with jitter
+	sc.SetConstraint(ConfigSMTPHost, 0, 0, "")                             // mail server for emailed reports (empty to disable email)
+	sc.SetConstraint(ConfigSMTPPort, 1, 65535, 587)                        // mail server port
+	sc.SetConstraint(ConfigSMTPTLS, 0, 0, "starttls")                      // starttls, tls (implicit, usually port 465), or none
+	sc.SetConstraint(ConfigSMTPUsername, 0, 0, "")                         // username for SMTP authentication (empty for none)
+	sc.SetConstraint(ConfigSMTPPassword, 0, 0, "")                         // password for SMTP authentication
+	sc.SetConstraint(ConfigSMTPFrom, 0, 0, "")                             // sender address for emailed reports
+	sc.SetConstraint(ConfigApprovalCommands, 0, 0, "")                     // comma separated commands that a second administrator must approve before they are sent, and wipe for wipe confirmations
 
 	// Protected configuration items
 	sp := c.NewSet(ConfigPrivate)
 	sp.SetConstraint(ConfigJWTKey, 0, 0, "")
 	sp.SetConstraint(ConfigRegToken, 0, 0, "")
+	sp.SetConstraint(ConfigMigrationToken, 0, 0, "")       // registration token that lets agents moving from another server keep their ID
+	sp.SetConstraint(ConfigMigrationTokenExpires, 0, 0, 0) // unix time
 	sp.SetConstraint(ConfigRefreshTokenLifeAgents, 0, 0, 0)
 	sp.SetConstraint(ConfigServerECPrivateSig, 0, 0, "")
 	sp.SetConstraint(ConfigServerECPublicSig, 0, 0, "")
 	sp.SetConstraint(ConfigServerECPrivateEnc, 0, 0, "")
 	sp.SetConstraint(ConfigServerECPublicEnc, 0, 0, "")
+	sp.SetConstraint(ConfigTokensNotBefore, 0, 0, 0) // unix time, access tokens issued earlier are rejected
 
 	// Return the sets
 	return sc, sp
@@ -15,12 +15,18 @@ const (
 	Description       = "UnifyEM Server"
 	WindowsBinaryName = "uem-server.exe"
 	UnixBinaryName    = "uem-server"
-	FileDirPattern    = "/files/" // URL pattern for file downloads
-	MessageQueueSize  = 500       // Size of the message queue
-	TaskTicker        = 10        // seconds between task checks
-	ConsoleExitDelay  = 10        // seconds to wait so that user can read the console output when exiting
-	TokenLength       = 64        // Length of registration token and JWT authentication key prior to base-64 encoding
-	MemoryCacheTTL    = 600       // Time to live for memory cache items in seconds
+	FileDirPattern    = "/files/"  // URL pattern for file downloads
+	UploadsDir        = "uploads"  // Subdirectory of the files path for files uploaded by agents
+	ReportsDir        = "reports"  // Subdirectory of the files path for copies of saved reports
+	MetricsPath       = "/metrics" // URL pattern for Prometheus metrics
+	DashboardPath     = "/ui/"     // URL prefix for the web dashboard
+	MessageQueueSize  = 500        // Size of the message queue
+	TaskTicker        = 10         // seconds between task checks
+	ConsoleExitDelay  = 10         // seconds to wait so that user can read the console output when exiting
+	TokenLength       = 64         // Length of registration token and JWT authentication key prior to base-64 encoding
+	MemoryCacheTTL    = 600        // Time to live for memory cache items in seconds
+	WipeCodeTTL       = 600        // seconds a wipe confirmation code remains valid
+	WipeCodeAttempts  = 5          // Incorrect confirmation codes allowed before a pending wipe is cancelled
 )
 
 var (
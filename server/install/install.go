@@ -23,6 +23,8 @@ func New(conf *global.ServerConfig) *Install {
 
 // Check displays the current configuration
 func (i *Install) Check() {
+	fmt.Printf("Recovery: %s\n\n", i.serviceRecovery())
+
 	c, err := i.conf.SC.Dump()
 	if err != nil {
 		fmt.Printf("Error dumping configuration: %v\n", err)
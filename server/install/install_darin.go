@@ -188,3 +188,8 @@ func (i *Install) startService() error {
 	}
 	return nil
 }
+
+// serviceRecovery returns a description of the restart policy for Check
+func (i *Install) serviceRecovery() string {
+	return "launchd starts the server every 60 seconds if it is not running"
+}
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"time"
 )
 
@@ -36,6 +37,7 @@ Group=root
 Restart=always
 RestartSec=1
 ExecStart=/usr/local/bin/uem-server
+ExecReload=/bin/kill -HUP $MAINPID
 
 [Install]
 WantedBy=multi-user.target
@@ -197,3 +199,12 @@ func (i *Install) startService() error {
 	}
 	return nil
 }
+
+// serviceRecovery returns a description of the restart policy for Check
+func (i *Install) serviceRecovery() string {
+	out, err := exec.Command("systemctl", "show", "--property=Restart,RestartUSec", serviceFile).Output()
+	if err != nil {
+		return "unknown"
+	}
+	return strings.Join(strings.Fields(string(out)), ", ")
+}
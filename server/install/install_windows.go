@@ -13,50 +13,18 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"syscall"
 	"time"
-	"unsafe"
 
 	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
 
 	"github.com/UnifyEM/UnifyEM/common/uemservice/privcheck"
+	"github.com/UnifyEM/UnifyEM/common/uemservice/winsvcutil"
+	"github.com/UnifyEM/UnifyEM/common/ulogger"
 	"github.com/UnifyEM/UnifyEM/server/global"
 )
 
-// SERVICE_FAILURE_ACTIONS structure
-//
-//goland:noinspection GoSnakeCaseUsage
-type SERVICE_FAILURE_ACTIONS struct {
-	DwResetPeriod uint32
-	LpRebootMsg   *uint16
-	LpCommand     *uint16
-	CActions      uint32
-	LpActions     *SC_ACTION
-}
-
-// SC_ACTION_TYPE constants
-//
-//goland:noinspection ALL
-const (
-	SC_ACTION_NONE        = 0
-	SC_ACTION_RESTART     = 1
-	SC_ACTION_REBOOT      = 2
-	SC_ACTION_RUN_COMMAND = 3
-)
-
-// SC_ACTION structure
-//
-//goland:noinspection GoSnakeCaseUsage
-type SC_ACTION struct {
-	Type  uint32
-	Delay uint32
-}
-
-//goland:noinspection GoSnakeCaseUsage
-const SERVICE_CONFIG_FAILURE_ACTIONS = 2
-
 // Install the service
 func (i *Install) installService() error {
 
@@ -87,6 +55,11 @@ func (i *Install) installService() error {
 	}
 	fmt.Printf("Binary copied to %s\n", targetPath)
 
+	// Register the event log source so that events are displayed correctly
+	if err = ulogger.InstallEventSource(global.LogName); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
 	// Install the service
 	m, err := mgr.Connect()
 	if err != nil {
@@ -117,12 +90,12 @@ func (i *Install) installService() error {
 
 	fmt.Println("Windows service created")
 
-	// Set the service failure actions
-	err = setServiceFailureActions(service.Handle)
+	// Set the description, delayed start, and failure actions
+	err = winsvcutil.Configure(service.Handle, global.Description)
 	if err != nil {
-		return fmt.Errorf("could not set failure actions: %w", err)
+		return err
 	}
-	fmt.Println("Windows service failure actions set")
+	fmt.Println("Windows service recovery options set")
 
 	// Start the service
 	err = service.Start()
@@ -135,37 +108,6 @@ func (i *Install) installService() error {
 	return nil
 }
 
-// setServiceFailureActions sets the failure actions for the service.
-func setServiceFailureActions(serviceHandle windows.Handle) error {
-	actions := []SC_ACTION{
-		{Type: SC_ACTION_RESTART, Delay: 10000}, // Restart the service after 10 seconds
-		{Type: SC_ACTION_RESTART, Delay: 10000}, // Restart the service after 10 seconds
-		{Type: SC_ACTION_RESTART, Delay: 60000}, // Restart the service after 60 seconds
-	}
-
-	// Prepare the SERVICE_FAILURE_ACTIONS structure
-	failureActions := SERVICE_FAILURE_ACTIONS{
-		DwResetPeriod: 86400, // Reset the failure count after 1 day (86400 seconds)
-		LpCommand:     nil,
-		LpRebootMsg:   nil,
-		CActions:      uint32(len(actions)),
-		LpActions:     &actions[0],
-	}
-
-	// Call the ChangeServiceConfig2 function to set the failure actions
-	r1, _, e1 := syscall.NewLazyDLL("advapi32.dll").NewProc("ChangeServiceConfig2W").Call(
-		uintptr(serviceHandle),
-		uintptr(SERVICE_CONFIG_FAILURE_ACTIONS),
-		uintptr(unsafe.Pointer(&failureActions)),
-	)
-
-	if r1 == 0 {
-		return fmt.Errorf("ChangeServiceConfig2W failed: %v", e1)
-	}
-
-	return nil
-}
-
 // Uninstall the service
 func (i *Install) uninstallService(removeData bool) error {
 
@@ -204,6 +146,11 @@ func (i *Install) uninstallService(removeData bool) error {
 	}
 	fmt.Println("Service uninstalled")
 
+	// Remove the event log source, events already logged are kept
+	if err = ulogger.RemoveEventSource(global.LogName); err != nil {
+		fmt.Printf("Warning: unable to remove event log source: %v\n", err)
+	}
+
 	// Define the target directory and file
 	targetDir := filepath.Join(os.Getenv("ProgramFiles"), global.Name)
 	targetPath := filepath.Join(targetDir, global.WindowsBinaryName)
@@ -330,3 +277,24 @@ func (i *Install) stopService() error {
 func (i *Install) startService() error {
 	return nil
 }
+
+// serviceRecovery returns a description of the recovery actions for Check
+func (i *Install) serviceRecovery() string {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Sprintf("error connecting to service manager: %v", err)
+	}
+	defer func(m *mgr.Mgr) {
+		_ = m.Disconnect()
+	}(m)
+
+	service, err := m.OpenService(global.Name)
+	if err != nil {
+		return "not installed"
+	}
+	defer func(service *mgr.Service) {
+		_ = service.Close()
+	}(service)
+
+	return winsvcutil.RecoveryActions(service)
+}
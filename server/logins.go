@@ -0,0 +1,128 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+)
+
+// userCommand manages login accounts directly in the database, so that access can be recovered
+// when no account is able to log in to the API. The service must be stopped first.
+func userCommand(args []string) {
+	if len(args) == 0 {
+		userUsage()
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list":
+		if len(args) != 1 {
+			userUsage()
+			return
+		}
+	case "unlock", "delete":
+		if len(args) != 2 {
+			userUsage()
+			return
+		}
+	case "role":
+		if len(args) != 3 {
+			userUsage()
+			return
+		}
+	default:
+		userUsage()
+		return
+	}
+
+	d := openOffline()
+	if d == nil {
+		return
+	}
+	defer d.Close()
+
+	var err error
+	switch strings.ToLower(args[0]) {
+	case "list":
+		err = listLogins(d)
+
+	case "unlock":
+		if err = d.UnlockLogin(args[1]); err == nil {
+			fmt.Printf("Login account \"%s\" unlocked\n", args[1])
+		}
+
+	case "delete":
+		if err = d.DeleteLogin(args[1]); err == nil {
+			fmt.Printf("Login account \"%s\" deleted\n", args[1])
+		}
+
+	case "role":
+		role := schema.RoleByName(args[2])
+		if role == schema.RoleNone {
+			fmt.Printf("Invalid role \"%s\", must be readonly, admin, or superadmin\n", args[2])
+			return
+		}
+		if err = d.SetLoginRole(args[1], role); err == nil {
+			fmt.Printf("Role of login account \"%s\" set to %s\n", args[1], schema.RoleName(role))
+		}
+	}
+
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+	}
+}
+
+// listLogins displays the login accounts and whether each is locked out
+func listLogins(d *data.Data) error {
+	logins, err := d.ListLogins()
+	if err != nil {
+		return err
+	}
+
+	if len(logins) == 0 {
+		fmt.Println("There are no login accounts, create one with: admin <username> <password>")
+		return nil
+	}
+
+	fmt.Printf("%-24s %-12s %-8s %s\n", "USER", "ROLE", "ACTIVE", "LOCKED")
+	for _, login := range logins {
+		locked := "no"
+		if login.Locked() {
+			locked = "until " + login.LockedUntil.Local().Format(time.RFC3339)
+		}
+		fmt.Printf("%-24s %-12s %-8t %s\n", login.User, schema.RoleName(login.Role), login.Active, locked)
+	}
+	return nil
+}
+
+// openOffline opens the database for a console command. The service holds an exclusive lock
+// on the database while it is running, so it must be stopped first. Errors are displayed and
+// nil is returned.
+func openOffline() *data.Data {
+	d, err := data.New(conf, null.Logger())
+	if err != nil {
+		if errors.Is(err, data.ErrDatabaseLocked) {
+			fmt.Println("The database is in use, stop the uem-server service and try again")
+		}
+		fmt.Printf("Data error: %s\n", err.Error())
+		return nil
+	}
+	return d
+}
+
+func userUsage() {
+	fmt.Println("Usage: user list")
+	fmt.Println("       user unlock <username>")
+	fmt.Println("       user delete <username>")
+	fmt.Println("       user role <username> <readonly | admin | superadmin>")
+}
@@ -6,6 +6,8 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -16,6 +18,7 @@ import (
 
 	"github.com/UnifyEM/UnifyEM/common"
 	"github.com/UnifyEM/UnifyEM/common/crypto"
+	"github.com/UnifyEM/UnifyEM/common/fields"
 	"github.com/UnifyEM/UnifyEM/common/interfaces"
 	"github.com/UnifyEM/UnifyEM/common/null"
 	"github.com/UnifyEM/UnifyEM/common/schema"
@@ -23,8 +26,10 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/ulogger"
 	"github.com/UnifyEM/UnifyEM/server/api"
 	"github.com/UnifyEM/UnifyEM/server/data"
+	"github.com/UnifyEM/UnifyEM/server/db"
 	"github.com/UnifyEM/UnifyEM/server/global"
 	"github.com/UnifyEM/UnifyEM/server/install"
+	"github.com/UnifyEM/UnifyEM/server/notify"
 	"github.com/UnifyEM/UnifyEM/server/queue"
 )
 
@@ -43,6 +48,9 @@ var conf *global.ServerConfig
 var logger interfaces.Logger
 var apiInstance *api.API
 var lastDBPrune time.Time
+var lastRequestExpiry time.Time
+var lastOfflineCheck time.Time
+var lastBackup time.Time
 
 func main() {
 
@@ -64,8 +72,9 @@ func main() {
 	// Set debug mode to on
 	global.Debug = true
 
-	// To avoid triggering database pruning on startup, set the last time to now
+	// To avoid triggering database pruning and snapshots on startup, set the last times to now
 	lastDBPrune = time.Now()
+	lastBackup = time.Now()
 
 	// launch() provides OS-specific functionality and then calls startService() or console() below
 	launch()
@@ -98,9 +107,8 @@ func console() {
 		}
 
 		// Set up data access
-		d, dataErr := data.New(conf, null.Logger())
-		if dataErr != nil {
-			fmt.Printf("Data error: %s\n", dataErr.Error())
+		d := openOffline()
+		if d == nil {
 			return
 		}
 
@@ -118,6 +126,9 @@ func console() {
 		d.Close()
 		return
 
+	case "user":
+		userCommand(os.Args[2:])
+
 	case "install":
 		installer := install.New(conf)
 		err = installer.Install()
@@ -149,6 +160,23 @@ func console() {
 		installer := install.New(conf)
 		installer.Check()
 
+	case "dbcheck":
+		dbCheck()
+
+	case "export":
+		if len(os.Args) < 3 || len(os.Args) > 4 || (len(os.Args) == 4 && os.Args[3] != "--events") {
+			fmt.Println("Usage: export <file> [--events]")
+			return
+		}
+		exportData(os.Args[2], len(os.Args) == 4)
+
+	case "import":
+		if len(os.Args) < 3 || len(os.Args) > 4 || (len(os.Args) == 4 && os.Args[3] != "--merge") {
+			fmt.Println("Usage: import <file> [--merge]")
+			return
+		}
+		importData(os.Args[2], len(os.Args) == 4)
+
 	case "foreground":
 		startService(false)
 
@@ -212,7 +240,139 @@ func ensureECKeys(conf *global.ServerConfig, logger interfaces.Logger) error {
 }
 
 func usage() {
-	fmt.Printf("Usage: %s <install | uninstall | upgrade | check | foreground | listen <address> | admin | version>\n", os.Args[0])
+	fmt.Printf("Usage: %s <install | uninstall | upgrade | check | dbcheck | export <file> | import <file> | foreground | listen <address> | admin | user | version>\n", os.Args[0])
+}
+
+// dbCheck reports the number of records in each bucket and any that can't be read. The
+// database is opened read-only, so the service must be stopped first.
+func dbCheck() {
+	dbFile, err := data.DatabaseFile(conf)
+	if err != nil {
+		fmt.Printf("Data error: %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("Checking database: %s\n\n", dbFile)
+	report, err := db.Inspect(dbFile)
+	if err != nil {
+		if errors.Is(err, db.ErrLocked) {
+			fmt.Println("Stop the service before checking the database")
+		}
+		fmt.Printf("Unable to check database: %s\n", err.Error())
+		return
+	}
+
+	problems := len(report.Errors)
+	for _, b := range report.Buckets {
+		fmt.Printf("%-20s %8d records", b.Name, b.Records)
+		if b.Buckets > 0 {
+			fmt.Printf(" in %d buckets", b.Buckets)
+		}
+		fmt.Println()
+
+		for _, key := range b.Invalid {
+			fmt.Printf("  unable to deserialize: %s\n", key)
+		}
+		if b.Error != "" {
+			fmt.Printf("  unable to read bucket: %s\n", b.Error)
+			problems++
+		}
+		problems += len(b.Invalid)
+	}
+
+	for _, e := range report.Errors {
+		fmt.Printf("Consistency error: %s\n", e)
+	}
+
+	if problems > 0 {
+		fmt.Printf("\n%d problems found. Setting %s to true salvages the database at startup.\n", problems, global.ConfigDBAutoSalvage)
+	} else {
+		fmt.Println("\nNo problems found")
+	}
+}
+
+// exportData writes agents, users, login accounts, groups, and configuration to a file for
+// migration to another server. The service must be stopped first.
+func exportData(file string, events bool) {
+	d, err := data.New(conf, null.Logger())
+	if err != nil {
+		fmt.Printf("Data error: %s\n", err.Error())
+		return
+	}
+	defer d.Close()
+
+	bundle, err := d.Export(events)
+	if err != nil {
+		fmt.Printf("Export failed: %s\n", err.Error())
+		return
+	}
+
+	j, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		fmt.Printf("Export failed: %s\n", err.Error())
+		return
+	}
+
+	// Never overwrite an existing file. The bundle contains password hashes and keys.
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		fmt.Printf("Export failed: %s\n", err.Error())
+		return
+	}
+	_, err = f.Write(j)
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		_ = os.Remove(file)
+		fmt.Printf("Export failed: %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("Exported %d agents, %d users, %d groups, and %d events to %s\n",
+		len(bundle.Agents), len(bundle.Users), len(bundle.Groups), len(bundle.Events), file)
+	fmt.Println("The export contains password hashes and the server's keys, protect it accordingly")
+}
+
+// importData imports a file produced by exportData. The service must be stopped first.
+func importData(file string, merge bool) {
+	j, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Printf("Import failed: %s\n", err.Error())
+		return
+	}
+
+	var bundle data.ExportBundle
+	if err = json.Unmarshal(j, &bundle); err != nil {
+		fmt.Printf("Import failed: %s\n", err.Error())
+		return
+	}
+
+	d, err := data.New(conf, null.Logger())
+	if err != nil {
+		fmt.Printf("Data error: %s\n", err.Error())
+		return
+	}
+	defer d.Close()
+
+	summary, err := d.Import(bundle, merge)
+	if err != nil {
+		fmt.Printf("Import failed: %s\n", err.Error())
+		if errors.Is(err, data.ErrImportConflict) {
+			fmt.Println("Use --merge to replace the existing records")
+		}
+		return
+	}
+
+	if err = conf.Checkpoint(); err != nil {
+		fmt.Printf("Unable to save configuration: %s\n", err.Error())
+	}
+
+	fmt.Printf("Imported %d agents, %d users, %d logins, %d groups, and %d events\n",
+		summary.Agents, summary.Users, summary.Logins, summary.Groups, summary.Events)
+	if !summary.KeysImported {
+		fmt.Println("This server already had agents, so its registration token and keys were kept. Imported agents must register again.")
+	}
 }
 
 func exit(code int, delay bool) {
@@ -249,12 +409,7 @@ func startService(daemon bool) {
 	}
 
 	// Create a logger using the loaded configuration
-	logger, err = ulogger.New(
-		ulogger.WithPrefix(global.LogName),
-		ulogger.WithLogFile(conf.SC.Get(global.ConfigLogFile).String()),
-		ulogger.WithLogStdout(conf.SC.Get(global.ConfigLogStdout).Bool()),
-		ulogger.WithRetention(conf.SC.Get(global.ConfigLogRetention).Int()),
-		ulogger.WithDebug(global.Debug))
+	logger, err = ulogger.New(conf.LoggerOptions()...)
 
 	if err != nil {
 		fmt.Printf("error creating logger: %v\n", err)
@@ -271,6 +426,9 @@ func startService(daemon bool) {
 	// Initialize the message queue
 	queue.Init(global.MessageQueueSize)
 
+	// Start forwarding events to any configured notification sinks
+	notify.Start(conf, logger)
+
 	// Check for foreground option (used for testing)
 	if !daemon {
 		// This function will never return
@@ -287,6 +445,7 @@ func startService(daemon bool) {
 		uemservice.WithBackgroundFunc(ServiceBackground),
 		uemservice.WithTasksFunc(ServiceTasks),
 		uemservice.WithStopFunc(ServiceStopping),
+		uemservice.WithReloadFunc(ServiceReload),
 		uemservice.WithSEid(1500))
 
 	if err != nil {
@@ -328,23 +487,83 @@ func ServiceBackground(logger interfaces.Logger) {
 // ServiceTasks will be called at the interval specified by TaskTicker
 func ServiceTasks(_ interfaces.Logger) {
 
+	// Wait for the API to open the database
+	if !apiInstance.Ready() {
+		return
+	}
+
 	// Process any messages in the queue
 	if queue.Size() > 0 {
 		apiInstance.ProcessMessageQueue()
 	}
 
-	// Prune the database every 6 hours
-	if time.Since(lastDBPrune) > 6*time.Hour {
+	// Prune the database at the configured interval
+	if time.Since(lastDBPrune) > time.Duration(conf.SC.Get(global.ConfigPruneInterval).Int())*time.Hour {
 		lastDBPrune = time.Now()
 
 		// Send the request through the API layer because it
 		// owns the data layer
 		apiInstance.PruneDB()
 	}
+
+	// Write a database snapshot at the configured interval if enabled
+	if conf.SC.Get(global.ConfigBackupPath).String() != "" &&
+		time.Since(lastBackup) > time.Duration(conf.SC.Get(global.ConfigBackupInterval).Int())*time.Hour {
+		lastBackup = time.Now()
+		apiInstance.Snapshot()
+	}
+
+	// Run saved reports that are due
+	apiInstance.RunScheduledReports()
+
+	// Close idle remote shell sessions
+	apiInstance.ExpireShellSessions()
+
+	// Check for agents that have gone offline every minute
+	if time.Since(lastOfflineCheck) > time.Minute {
+		lastOfflineCheck = time.Now()
+		apiInstance.CheckOfflineAgents()
+	}
+
+	// Expire requests that were never acknowledged every 10 minutes
+	if time.Since(lastRequestExpiry) > 10*time.Minute {
+		lastRequestExpiry = time.Now()
+		apiInstance.ExpireRequests()
+	}
+}
+
+// ServiceReload is called when the service is asked to reload its configuration, such as by
+// systemctl reload. The API keeps listening while the settings are applied and the logger is
+// reconfigured.
+func ServiceReload(logger interfaces.Logger) {
+	changed, restart, err := conf.Reload()
+	if err != nil {
+		logger.Errorf(1008, "error reloading configuration: %s", err.Error())
+		return
+	}
+
+	if r, ok := logger.(interface{ Reconfigure(...ulogger.Option) error }); ok {
+		if err = r.Reconfigure(conf.LoggerOptions()...); err != nil {
+			logger.Errorf(1011, "error applying logging settings: %s", err.Error())
+		}
+	}
+
+	logger.Info(1009, "configuration reloaded", fields.NewFields(
+		fields.NewField("changed", len(changed)),
+		fields.NewField("settings", strings.Join(changed, ", "))))
+	if len(restart) > 0 {
+		logger.Warning(1010, "server restart required to apply settings: "+strings.Join(restart, ", "), nil)
+	}
 }
 
 // ServiceStopping is called when the service is about to exit
 func ServiceStopping(logger interfaces.Logger) {
+	// Process any messages in the queue. Messages that arrive after this remain in the
+	// database and are processed after the next start.
+	if apiInstance.Ready() && queue.Size() > 0 {
+		apiInstance.ProcessMessageQueue()
+	}
+
 	// Close the database
 	apiInstance.Close()
 
@@ -0,0 +1,217 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+// Package metrics collects server metrics and writes them in the Prometheus text format. Like
+// the message queue, it maintains its state within the package and exports functions so that
+// it can be updated from various parts of the application. Nothing is collected unless Enable
+// has been called.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sync results
+const (
+	SyncOK       = "ok"
+	SyncError    = "error"
+	SyncRejected = "rejected"
+	SyncShed     = "shed" // deferred because the server is overloaded
+)
+
+// Command statuses
+const (
+	CommandQueued    = "queued"
+	CommandCompleted = "completed"
+	CommandFailed    = "failed"
+	CommandExpired   = "expired"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the handler latency histogram
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Gauge is a value calculated when the metrics are written
+type Gauge struct {
+	Name  string
+	Help  string
+	Value float64
+}
+
+type histogram struct {
+	counts []uint64 // Per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+var (
+	enabled   atomic.Bool
+	mu        sync.Mutex
+	syncs     = make(map[string]uint64)
+	commands  = make(map[[2]string]uint64)  // command, status
+	responses = make(map[[2]string]uint64)  // handler, code
+	latency   = make(map[string]*histogram) // handler
+	dropped   uint64
+)
+
+// Enable starts metric collection
+func Enable() {
+	enabled.Store(true)
+}
+
+// Enabled returns true if metrics are being collected
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Sync counts an agent sync by result
+func Sync(result string) {
+	if !enabled.Load() {
+		return
+	}
+	mu.Lock()
+	syncs[result]++
+	mu.Unlock()
+}
+
+// Command counts a command by type and status
+func Command(command, status string) {
+	if !enabled.Load() {
+		return
+	}
+	mu.Lock()
+	commands[[2]string{command, status}]++
+	mu.Unlock()
+}
+
+// MessageDropped counts a message from an agent that was dropped because the queue was full
+func MessageDropped() {
+	if !enabled.Load() {
+		return
+	}
+	mu.Lock()
+	dropped++
+	mu.Unlock()
+}
+
+// ObserveRequest records the status code and latency of an HTTP request
+func ObserveRequest(handler string, _ string, code int, duration time.Duration) {
+	if !enabled.Load() {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	responses[[2]string{handler, strconv.Itoa(code)}]++
+
+	h, ok := latency[handler]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		latency[handler] = h
+	}
+
+	seconds := duration.Seconds()
+	for x, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.counts[x]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// Write writes all metrics, followed by the supplied gauges, in the Prometheus text format
+func Write(w io.Writer, gauges []Gauge) error {
+	var b strings.Builder
+
+	mu.Lock()
+
+	b.WriteString("# HELP uem_syncs_total Agent syncs by result.\n")
+	b.WriteString("# TYPE uem_syncs_total counter\n")
+	for _, k := range sortedKeys(syncs) {
+		fmt.Fprintf(&b, "uem_syncs_total{result=%s} %d\n", quote(k), syncs[k])
+	}
+
+	b.WriteString("# HELP uem_commands_total Agent commands by command and status.\n")
+	b.WriteString("# TYPE uem_commands_total counter\n")
+	for _, k := range sortedPairs(commands) {
+		fmt.Fprintf(&b, "uem_commands_total{command=%s,status=%s} %d\n", quote(k[0]), quote(k[1]), commands[k])
+	}
+
+	b.WriteString("# HELP uem_messages_dropped_total Agent messages dropped because the queue was full.\n")
+	b.WriteString("# TYPE uem_messages_dropped_total counter\n")
+	fmt.Fprintf(&b, "uem_messages_dropped_total %d\n", dropped)
+
+	b.WriteString("# HELP uem_http_responses_total HTTP responses by handler and status code.\n")
+	b.WriteString("# TYPE uem_http_responses_total counter\n")
+	for _, k := range sortedPairs(responses) {
+		fmt.Fprintf(&b, "uem_http_responses_total{handler=%s,code=%s} %d\n", quote(k[0]), quote(k[1]), responses[k])
+	}
+
+	b.WriteString("# HELP uem_http_request_duration_seconds HTTP request latency by handler.\n")
+	b.WriteString("# TYPE uem_http_request_duration_seconds histogram\n")
+	for _, handler := range sortedKeys(latency) {
+		h := latency[handler]
+		var cumulative uint64
+		for x, bound := range latencyBuckets {
+			cumulative += h.counts[x]
+			fmt.Fprintf(&b, "uem_http_request_duration_seconds_bucket{handler=%s,le=\"%s\"} %d\n",
+				quote(handler), strconv.FormatFloat(bound, 'f', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "uem_http_request_duration_seconds_bucket{handler=%s,le=\"+Inf\"} %d\n", quote(handler), h.count)
+		fmt.Fprintf(&b, "uem_http_request_duration_seconds_sum{handler=%s} %s\n", quote(handler), strconv.FormatFloat(h.sum, 'f', -1, 64))
+		fmt.Fprintf(&b, "uem_http_request_duration_seconds_count{handler=%s} %d\n", quote(handler), h.count)
+	}
+
+	mu.Unlock()
+
+	for _, g := range gauges {
+		fmt.Fprintf(&b, "# HELP %s %s\n", g.Name, g.Help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", g.Name)
+		fmt.Fprintf(&b, "%s %s\n", g.Name, strconv.FormatFloat(g.Value, 'f', -1, 64))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quote returns a quoted and escaped label value
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedPairs(m map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
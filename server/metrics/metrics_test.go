@@ -0,0 +1,52 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	// Nothing is collected until metrics are enabled
+	Sync(SyncOK)
+	Enable()
+	Sync(SyncOK)
+	Sync(SyncError)
+	Command("status", CommandQueued)
+	Command(`a"b`, CommandFailed)
+	ObserveRequest("sync", "POST", 200, 20*time.Millisecond)
+	ObserveRequest("sync", "POST", 200, 20*time.Second)
+	MessageDropped()
+
+	var b strings.Builder
+	err := Write(&b, []Gauge{{Name: "uem_agents_registered", Help: "Registered agents.", Value: 3}})
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	out := b.String()
+
+	expected := []string{
+		`uem_syncs_total{result="ok"} 1`,
+		`uem_syncs_total{result="error"} 1`,
+		`uem_commands_total{command="status",status="queued"} 1`,
+		`uem_commands_total{command="a\"b",status="failed"} 1`,
+		"uem_messages_dropped_total 1",
+		`uem_http_responses_total{handler="sync",code="200"} 2`,
+		`uem_http_request_duration_seconds_bucket{handler="sync",le="0.01"} 0`,
+		`uem_http_request_duration_seconds_bucket{handler="sync",le="0.025"} 1`,
+		`uem_http_request_duration_seconds_bucket{handler="sync",le="10"} 1`,
+		`uem_http_request_duration_seconds_bucket{handler="sync",le="+Inf"} 2`,
+		`uem_http_request_duration_seconds_count{handler="sync"} 2`,
+		"# TYPE uem_agents_registered gauge\nuem_agents_registered 3",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("output missing %q:\n%s", e, out)
+		}
+	}
+}
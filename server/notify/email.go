@@ -0,0 +1,195 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+// emailTimeout limits the time taken to send a single message
+const emailTimeout = 60 * time.Second
+
+// ErrEmailNotConfigured is returned when smtp_host or smtp_from is not set
+var ErrEmailNotConfigured = errors.New("email is not configured, set smtp_host and smtp_from")
+
+// Email is a message sent by SendEmail
+type Email struct {
+	To          []string
+	Subject     string
+	Body        string
+	HTML        bool // the body is HTML rather than plain text
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// ValidEmailAddress returns true if the address is a plain email address, without a name
+func ValidEmailAddress(address string) bool {
+	a, err := mail.ParseAddress(address)
+	return err == nil && a.Name == "" && a.Address == address
+}
+
+// SendEmail sends a message using the SMTP settings in the server configuration. Unlike
+// events, messages are not queued or retried.
+func SendEmail(c *global.ServerConfig, msg Email) error {
+	host := c.SC.Get(global.ConfigSMTPHost).String()
+	from := c.SC.Get(global.ConfigSMTPFrom).String()
+	if host == "" || from == "" {
+		return ErrEmailNotConfigured
+	}
+	if len(msg.To) == 0 {
+		return errors.New("no recipients")
+	}
+	for _, address := range append([]string{from}, msg.To...) {
+		if !ValidEmailAddress(address) {
+			return fmt.Errorf("invalid email address: %q", address)
+		}
+	}
+
+	data, err := buildEmail(from, msg)
+	if err != nil {
+		return err
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(c.SC.Get(global.ConfigSMTPPort).Int()))
+	mode := strings.ToLower(c.SC.Get(global.ConfigSMTPTLS).String())
+	tlsConfig := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: sendTimeout}
+	switch mode {
+	case "tls":
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	case "starttls", "none":
+		conn, err = dialer.Dial("tcp", address)
+	default:
+		return fmt.Errorf("invalid %s %q, use starttls, tls, or none", global.ConfigSMTPTLS, mode)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to connect to %s: %w", address, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(emailTimeout))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	if mode == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s does not support STARTTLS", address)
+		}
+		if err = client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if username := c.SC.Get(global.ConfigSMTPUsername).String(); username != "" {
+		auth := smtp.PlainAuth("", username, c.SC.Get(global.ConfigSMTPPassword).String(), host)
+		if err = client.Auth(auth); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	if err = client.Mail(from); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err = client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildEmail returns the message in MIME format
+func buildEmail(from string, msg Email) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	mw := multipart.NewWriter(&b)
+	b.WriteString("Content-Type: multipart/mixed; boundary=" + mw.Boundary() + "\r\n\r\n")
+
+	contentType := "text/plain; charset=utf-8"
+	if msg.HTML {
+		contentType = "text/html; charset=utf-8"
+	}
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"}})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err = qp.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err = qp.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, a := range msg.Attachments {
+		part, err = mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})}})
+		if err != nil {
+			return nil, err
+		}
+
+		// Lines of encoded data are limited to 76 characters
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			_, _ = part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		_, _ = part.Write([]byte(encoded + "\r\n"))
+	}
+
+	if err = mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+
+	"github.com/UnifyEM/UnifyEM/common/uconfig"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestBuildEmail(t *testing.T) {
+	msg := Email{
+		To:          []string{"a@example.com", "b@example.com"},
+		Subject:     "Report: compliance",
+		Body:        "The report is attached",
+		Attachments: []Attachment{{Name: "report.csv", ContentType: "text/csv", Data: []byte("name,value\nhost1,1\n")}},
+	}
+
+	data, err := buildEmail("uem@example.com", msg)
+	if err != nil {
+		t.Fatalf("buildEmail failed: %v", err)
+	}
+
+	m, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	if m.Header.Get("To") != "a@example.com, b@example.com" {
+		t.Errorf("unexpected To: %s", m.Header.Get("To"))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("unexpected content type: %s", m.Header.Get("Content-Type"))
+	}
+
+	// multipart.Reader decodes quoted-printable, but not base64
+	r := multipart.NewReader(m.Body, params["boundary"])
+	part, err := r.NextPart()
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	body, _ := io.ReadAll(part)
+	if string(body) != msg.Body {
+		t.Errorf("unexpected body: %q", body)
+	}
+
+	part, err = r.NextPart()
+	if err != nil {
+		t.Fatalf("failed to read attachment: %v", err)
+	}
+	if part.FileName() != "report.csv" {
+		t.Errorf("unexpected attachment name: %s", part.FileName())
+	}
+	if _, err = r.NextPart(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected two parts, got %v", err)
+	}
+}
+
+func TestSendEmailNotConfigured(t *testing.T) {
+	c := uconfig.Null()
+	conf := &global.ServerConfig{C: c, SC: c.NewSet(global.ConfigServerSet)}
+
+	if err := SendEmail(conf, Email{To: []string{"a@example.com"}}); !errors.Is(err, ErrEmailNotConfigured) {
+		t.Errorf("expected ErrEmailNotConfigured, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+// Package notify forwards agent events to external systems such as a SIEM. Like the message
+// queue, it maintains its state within the package and exports functions so that it can be
+// used from various parts of the application. Events are queued and delivered by a single
+// goroutine so that slow or unavailable sinks never delay the caller.
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/fields"
+	"github.com/UnifyEM/UnifyEM/common/interfaces"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+const (
+	maxAttempts  = 5                // Delivery attempts per sink before an event is dropped
+	firstBackoff = 2 * time.Second  // Delay before the first retry, doubled for each subsequent retry
+	maxBackoff   = 60 * time.Second // Maximum delay between retries
+	sendTimeout  = 10 * time.Second // Timeout for a single delivery attempt
+)
+
+// sink delivers events to an external system
+type sink interface {
+	Name() string
+	Send(ctx context.Context, event schema.AgentEvent) error
+}
+
+var (
+	mu      sync.Mutex
+	conf    *global.ServerConfig
+	logger  interfaces.Logger
+	events  chan schema.AgentEvent
+	syslog  = &syslogSink{}
+	backoff = firstBackoff
+)
+
+// Start creates the event queue and starts delivering events to the configured sinks.
+// Configuration changes other than the queue size take effect without a restart.
+func Start(c *global.ServerConfig, l interfaces.Logger) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if events != nil {
+		return
+	}
+
+	conf = c
+	logger = l
+	events = make(chan schema.AgentEvent, conf.SC.Get(global.ConfigNotifyQueueSize).Int())
+	go deliver(events)
+}
+
+// Event queues an event for delivery if any sinks are configured and the event type
+// is selected by the filter. If the queue is full, the event is dropped.
+func Event(event schema.AgentEvent) {
+	mu.Lock()
+	ch := events
+	mu.Unlock()
+
+	if ch == nil || len(sinks()) == 0 || !selected(event.EventType) {
+		return
+	}
+
+	select {
+	case ch <- event:
+	default:
+		logger.Warning(2600, "notification queue full, event dropped", fields.NewFields(
+			fields.NewField("agent_id", event.AgentID),
+			fields.NewField("type", event.EventType),
+			fields.NewField("event", event.Event)))
+	}
+}
+
+// Test sends a test event to each configured sink without retrying and returns the result for each
+func Test(requester string) (map[string]string, error) {
+	all := sinks()
+	if len(all) == 0 {
+		return nil, errors.New("no notification sinks are configured")
+	}
+
+	event := schema.AgentEvent{
+		EventID:   "test",
+		Time:      time.Now(),
+		EventType: schema.AgentEventMessage,
+		Event:     "notification test",
+		Details:   map[string]string{"requester": requester},
+	}
+
+	results := make(map[string]string)
+	for _, s := range all {
+		err := send(s, event)
+		if err != nil {
+			results[s.Name()] = err.Error()
+		} else {
+			results[s.Name()] = "ok"
+		}
+	}
+	return results, nil
+}
+
+// deliver sends each queued event to every configured sink, retrying with backoff
+func deliver(ch chan schema.AgentEvent) {
+	for event := range ch {
+		for _, s := range sinks() {
+			delay := backoff
+			for attempt := 1; ; attempt++ {
+				err := send(s, event)
+				if err == nil {
+					break
+				}
+
+				f := fields.NewFields(
+					fields.NewField("sink", s.Name()),
+					fields.NewField("agent_id", event.AgentID),
+					fields.NewField("type", event.EventType),
+					fields.NewField("attempt", attempt),
+					fields.NewField("error", err.Error()))
+
+				if attempt >= maxAttempts {
+					logger.Error(2601, "notification failed, event dropped", f)
+					break
+				}
+				logger.Warning(2602, "notification failed, retrying", f)
+
+				time.Sleep(delay)
+				delay = min(delay*2, maxBackoff)
+			}
+		}
+	}
+}
+
+// send makes a single delivery attempt
+func send(s sink, event schema.AgentEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	return s.Send(ctx, event)
+}
+
+// sinks returns the sinks enabled in the current configuration
+func sinks() []sink {
+	if conf == nil {
+		return nil
+	}
+
+	var list []sink
+	if url := conf.SC.Get(global.ConfigNotifyWebhookURL).String(); url != "" {
+		list = append(list, &webhookSink{
+			url:    url,
+			secret: conf.SC.Get(global.ConfigNotifyWebhookSecret).String(),
+			server: conf.SC.Get(global.ConfigExternalULR).String(),
+		})
+	}
+
+	if address := conf.SC.Get(global.ConfigNotifySyslogAddress).String(); address != "" {
+		syslog.configure(address, conf.SC.Get(global.ConfigNotifySyslogTLS).Bool())
+		list = append(list, syslog)
+	}
+	return list
+}
+
+// selected returns true if the event type is included in the configured filter
+func selected(eventType string) bool {
+	filter := strings.TrimSpace(conf.SC.Get(global.ConfigNotifyEventTypes).String())
+	if filter == "" {
+		return true
+	}
+
+	for _, t := range strings.Split(filter, ",") {
+		if strings.EqualFold(strings.TrimSpace(t), eventType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/null"
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/common/uconfig"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+func TestWebhook(t *testing.T) {
+	received := make(chan Notification, 10)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(HeaderSignature) != "sha256="+Sign("secret", r.Header.Get(HeaderTimestamp), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var n Notification
+		if err := json.Unmarshal(body, &n); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- n
+	}))
+	defer srv.Close()
+	httpClient = srv.Client()
+	backoff = time.Millisecond
+
+	c := uconfig.Null()
+	conf := &global.ServerConfig{C: c, SC: c.NewSet(global.ConfigServerSet)}
+	conf.SC.Set(global.ConfigNotifyWebhookURL, srv.URL)
+	conf.SC.Set(global.ConfigNotifyWebhookSecret, "secret")
+	conf.SC.Set(global.ConfigNotifyEventTypes, "alert")
+	conf.SC.Set(global.ConfigNotifyQueueSize, 10)
+	Start(conf, null.Logger())
+
+	// Events that do not match the filter are not sent
+	Event(schema.AgentEvent{AgentID: "A-1", EventType: schema.AgentEventStatus, Event: "status"})
+	Event(schema.AgentEvent{AgentID: "A-1", EventType: schema.AgentEventAlert, Event: "lost trigger set"})
+
+	select {
+	case n := <-received:
+		if n.Event.AgentID != "A-1" || n.Event.Event != "lost trigger set" {
+			t.Errorf("unexpected notification: %+v", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification not received")
+	}
+
+	results, err := Test("admin")
+	if err != nil || results["webhook"] != "ok" {
+		t.Fatalf("unexpected test result: %v %v", results, err)
+	}
+	if n := <-received; n.Event.Event != "notification test" || n.Event.Details["requester"] != "admin" {
+		t.Errorf("unexpected test notification: %+v", n)
+	}
+
+	// A bad signature is rejected by the receiver
+	conf.SC.Set(global.ConfigNotifyWebhookSecret, "wrong")
+	results, _ = Test("admin")
+	if !strings.Contains(results["webhook"], "401") {
+		t.Errorf("expected webhook failure, got %v", results)
+	}
+
+	if len(received) != 0 {
+		t.Errorf("unexpected notifications: %d", len(received))
+	}
+}
+
+func TestFormatSyslog(t *testing.T) {
+	msg := formatSyslog(schema.AgentEvent{
+		AgentID:   "A-1",
+		EventID:   "E-1",
+		Time:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		EventType: schema.AgentEventAlert,
+		Event:     "user_lock failed: denied",
+		Details:   map[string]string{"user": `bob"]\`, "bad key=": "x"},
+	})
+
+	if !strings.HasPrefix(msg, "<132>1 2026-01-02T03:04:05Z ") {
+		t.Errorf("unexpected header: %s", msg)
+	}
+
+	expected := ` uem-server - alert [uem@32473 agent_id="A-1" event_id="E-1" bad_key_="x" user="bob\"\]\\"] user_lock failed: denied`
+	if !strings.HasSuffix(msg, expected) {
+		t.Errorf("unexpected message:\n%s\nexpected suffix:\n%s", msg, expected)
+	}
+}
@@ -0,0 +1,165 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/global"
+)
+
+const (
+	syslogFacility   = 16      // local0
+	syslogEnterprise = "32473" // Private enterprise number used in the structured data ID
+)
+
+// Syslog severities
+const (
+	severityWarning = 4
+	severityInfo    = 6
+)
+
+// syslogSink sends events as RFC 5424 messages over TCP, optionally with TLS, using
+// octet-counting framing (RFC 6587). The connection is kept open between events.
+type syslogSink struct {
+	mu      sync.Mutex
+	address string
+	useTLS  bool
+	conn    net.Conn
+}
+
+func (s *syslogSink) Name() string {
+	return "syslog"
+}
+
+// configure updates the destination, closing the connection if it has changed
+func (s *syslogSink) configure(address string, useTLS bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if address != s.address || useTLS != s.useTLS {
+		s.close()
+		s.address = address
+		s.useTLS = useTLS
+	}
+}
+
+func (s *syslogSink) Send(ctx context.Context, event schema.AgentEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		var err error
+		if s.useTLS {
+			host, _, _ := net.SplitHostPort(s.address)
+			dialer := &tls.Dialer{Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}
+			s.conn, err = dialer.DialContext(ctx, "tcp", s.address)
+		} else {
+			dialer := &net.Dialer{}
+			s.conn, err = dialer.DialContext(ctx, "tcp", s.address)
+		}
+		if err != nil {
+			s.conn = nil
+			return err
+		}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.conn.SetWriteDeadline(deadline)
+	}
+
+	msg := formatSyslog(event)
+	_, err := fmt.Fprintf(s.conn, "%d %s", len(msg), msg)
+	if err != nil {
+		s.close()
+		return err
+	}
+	return nil
+}
+
+// close closes the connection, the caller must hold the lock
+func (s *syslogSink) close() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// formatSyslog returns an RFC 5424 message for the event
+func formatSyslog(event schema.AgentEvent) string {
+	severity := severityInfo
+	if event.EventType == schema.AgentEventAlert {
+		severity = severityWarning
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	msgID := "-"
+	if event.EventType != "" {
+		msgID = sdName(event.EventType)
+	}
+
+	// Structured data includes the agent, event ID, and event details
+	var sd strings.Builder
+	sd.WriteString("[uem@" + syslogEnterprise)
+	sd.WriteString(` agent_id="` + sdEscape(event.AgentID) + `"`)
+	sd.WriteString(` event_id="` + sdEscape(event.EventID) + `"`)
+
+	keys := make([]string, 0, len(event.Details))
+	for k := range event.Details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sd.WriteString(" " + sdName(k) + `="` + sdEscape(event.Details[k]) + `"`)
+	}
+	sd.WriteString("]")
+
+	return fmt.Sprintf("<%d>1 %s %s %s - %s %s %s",
+		syslogFacility*8+severity,
+		event.Time.UTC().Format(time.RFC3339),
+		hostname,
+		global.LogName,
+		msgID,
+		sd.String(),
+		event.Event)
+}
+
+// sdName returns a valid structured data name of up to 32 printable characters
+func sdName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r > 32 && r < 127 && r != '=' && r != ']' && r != '"' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+		if b.Len() == 32 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// sdEscape escapes a structured data parameter value
+func sdEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
@@ -0,0 +1,99 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+)
+
+// Webhook request headers. The signature is the hex encoded HMAC-SHA256 of the timestamp,
+// a period, and the request body, using the shared secret as the key.
+const (
+	HeaderTimestamp = "X-UEM-Timestamp"
+	HeaderSignature = "X-UEM-Signature"
+)
+
+// httpClient is used for webhook requests
+var httpClient = &http.Client{Timeout: sendTimeout}
+
+// Notification is the body of a webhook request
+type Notification struct {
+	Server string            `json:"server"`
+	Sent   time.Time         `json:"sent"`
+	Event  schema.AgentEvent `json:"event"`
+}
+
+// webhookSink posts events as JSON to an HTTPS URL
+type webhookSink struct {
+	url    string
+	secret string
+	server string
+}
+
+func (w *webhookSink) Name() string {
+	return "webhook"
+}
+
+func (w *webhookSink) Send(ctx context.Context, event schema.AgentEvent) error {
+	u, err := url.Parse(w.url)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("webhook URL must be an https URL")
+	}
+
+	body, err := json.Marshal(Notification{Server: w.server, Sent: time.Now().UTC(), Event: event})
+	if err != nil {
+		return fmt.Errorf("serialization failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if w.secret != "" {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(w.secret, timestamp, body))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the webhook signature for a timestamp and body
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -3,46 +3,147 @@
  * Please see the LICENSE file for details                                    *
  ******************************************************************************/
 
-// Package queue provides a simple in-memory queue for messages from agents. It maintains the
-// queue within the package and exports functions so that it can be accessed from various parts
-// of the application.
+// Package queue provides a queue for messages from agents. It maintains the queue within the
+// package and exports functions so that it can be accessed from various parts of the
+// application. Once a Store has been opened, messages are written to it when they are added
+// and deleted when they have been processed, so that they survive a restart.
 package queue
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/UnifyEM/UnifyEM/common/schema"
 )
 
-// Queue holds the channels used as memory queue
-var messages chan schema.AgentMessage
+// ErrFull is returned by Add when the queue is at capacity and the message was dropped
+var ErrFull = errors.New("message queue is full")
+
+// Store persists queued messages
+type Store interface {
+	QueueMessage(message schema.AgentMessage) (uint64, error)
+	DeleteQueuedMessage(id uint64) error
+	QueuedMessages(fn func(id uint64, message schema.AgentMessage)) error
+}
+
+// Item is a queued message and the ID it was stored with, or zero if it was not stored
+type Item struct {
+	ID      uint64
+	Message schema.AgentMessage
+}
+
+var (
+	mu       sync.Mutex
+	items    []Item
+	capacity int
+	store    Store
+)
 
-// Init the queue with a buffered channel for AgentMessages
+// Init the queue with the maximum number of messages it can hold
 func Init(bufferSize int) {
-	messages = make(chan schema.AgentMessage, bufferSize)
+	mu.Lock()
+	defer mu.Unlock()
+	items = nil
+	capacity = bufferSize
+	store = nil
+}
+
+// Open persists the queue in the store. Messages that were stored and not processed before
+// a restart are queued ahead of any that have been added since Init, which are then stored.
+// It returns the number of messages that were restored.
+func Open(s Store) (int, error) {
+	var restored []Item
+	err := s.QueuedMessages(func(id uint64, message schema.AgentMessage) {
+		restored = append(restored, Item{ID: id, Message: message})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for x := range items {
+		if items[x].ID == 0 {
+			if id, err := s.QueueMessage(items[x].Message); err == nil {
+				items[x].ID = id
+			}
+		}
+	}
+	items = append(restored, items...)
+	store = s
+	return len(restored), nil
+}
+
+// Add a message to the queue. If a store is open, the message is written to it before Add
+// returns. If the queue is full, the message is dropped and ErrFull is returned.
+func Add(msg schema.AgentMessage) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(items) >= capacity {
+		return ErrFull
+	}
+
+	item := Item{Message: msg}
+	if store != nil {
+		id, err := store.QueueMessage(msg)
+		if err != nil {
+			// Keep the message in memory so that it is still processed unless the server stops
+			items = append(items, item)
+			return err
+		}
+		item.ID = id
+	}
+	items = append(items, item)
+	return nil
 }
 
-// Add a message to the queue
-func Add(msg schema.AgentMessage) {
-	messages <- msg
+// Read is a non-blocking function that returns the oldest item in the queue. The item remains
+// in the store until Done is called so that it is processed again after a restart.
+func Read() (Item, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(items) == 0 {
+		return Item{}, false
+	}
+	item := items[0]
+	items = items[1:]
+	return item, true
 }
 
-// Read is a non-blocking function tht returns an item from the queue
-func Read() (schema.AgentMessage, bool) {
-	select {
-	case msg := <-messages:
-		return msg, true
-	default:
-		return schema.AgentMessage{}, false
+// Done removes a processed item from the store
+func Done(item Item) error {
+	mu.Lock()
+	s := store
+	mu.Unlock()
+
+	if s == nil || item.ID == 0 {
+		return nil
 	}
+	return s.DeleteQueuedMessage(item.ID)
 }
 
-// Size returns the number of requests currently in the queue
+// Size returns the number of messages currently in the queue
 func Size() int {
-	return len(messages)
+	mu.Lock()
+	defer mu.Unlock()
+	return len(items)
+}
+
+// Capacity returns the maximum number of messages the queue can hold
+func Capacity() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return capacity
 }
 
-// Close closes the queue
-//
-//goland:noinspection GoUnusedExportedFunction
+// Close stops using the store. Messages that have not been processed remain in it and are
+// restored by Open.
 func Close() {
-	close(messages)
+	mu.Lock()
+	defer mu.Unlock()
+	items = nil
+	store = nil
 }
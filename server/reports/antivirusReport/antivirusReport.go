@@ -0,0 +1,91 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package antivirusReport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+)
+
+type Report struct{}
+
+// agentAntivirus is the antivirus status of a single agent
+type agentAntivirus struct {
+	AgentID      string                    `json:"agent_id"`
+	FriendlyName string                    `json:"friendly_name"`
+	Reported     bool                      `json:"reported"`
+	Antivirus    *bool                     `json:"antivirus"` // nil if unknown
+	Products     []schema.AntivirusProduct `json:"antivirus_products"`
+}
+
+// Report lists the antivirus products most recently reported by each agent
+func (r *Report) Report(data *data.Data, req schema.ReportRequest) (schema.Report, error) {
+	var agents []agentAntivirus
+	report := schema.NewReport()
+
+	err := data.ForEach(data.BucketAgentMeta, func(key, value []byte) error {
+		var agent schema.AgentMeta
+		if err := json.Unmarshal(value, &agent); err != nil {
+			return fmt.Errorf("error unmarshalling agent data: %w", err)
+		}
+
+		entry := agentAntivirus{AgentID: agent.AgentID, FriendlyName: agent.FriendlyName}
+		if agent.Status != nil {
+			entry.Reported = true
+			entry.Antivirus = agent.Status.Details.Antivirus
+			entry.Products = agent.Status.AntivirusProducts
+		}
+		agents = append(agents, entry)
+		return nil
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	// Check schema.CmdRequest.Parameters for a format option
+	if format, ok := req.Parameters["format"]; ok {
+		if format == schema.ReportTypeJSON {
+			jsonData, err := json.Marshal(agents)
+			if err != nil {
+				return report, fmt.Errorf("failed to serialize antivirus data: %w", err)
+			}
+			report.Type = schema.ReportTypeJSON
+			report.Data = jsonData
+			return report, nil
+		}
+	}
+
+	// Fall back to string format
+	var buffer bytes.Buffer
+	buffer.WriteString("Agents, antivirus, products (version, enabled, updated):\n")
+	for _, agent := range agents {
+		if !agent.Reported {
+			buffer.WriteString(fmt.Sprintf("%s, %s, not reported\n", agent.AgentID, agent.FriendlyName))
+			continue
+		}
+		antivirus := "unknown"
+		if agent.Antivirus != nil {
+			antivirus = schema.YesNo(*agent.Antivirus)
+		}
+		buffer.WriteString(fmt.Sprintf("%s, %s, %s", agent.AgentID, agent.FriendlyName, antivirus))
+		for _, p := range agent.Products {
+			version := p.Version
+			if version == "" {
+				version = "unknown"
+			}
+			buffer.WriteString(fmt.Sprintf(", %s (%s, %s, %s)", p.Name, version, p.Enabled, p.Updated))
+		}
+		buffer.WriteString("\n")
+	}
+	report.Data = buffer.Bytes()
+	report.Type = schema.ReportTypeString
+	return report, nil
+}
@@ -0,0 +1,130 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package complianceReport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+)
+
+type Report struct{}
+
+// check is a status item included in the report
+type check struct {
+	key   string
+	value func(d *schema.StatusDetails) *bool
+}
+
+// checks are listed in this order
+var checks = []check{
+	{"firewall", func(d *schema.StatusDetails) *bool { return d.Firewall }},
+	{"antivirus", func(d *schema.StatusDetails) *bool { return d.Antivirus }},
+	{"full_disk_encryption", func(d *schema.StatusDetails) *bool { return d.FullDiskEncryption }},
+	{"password", func(d *schema.StatusDetails) *bool { return d.Password }},
+	{"screen_lock", func(d *schema.StatusDetails) *bool { return d.ScreenLock }},
+	{"auto_updates", func(d *schema.StatusDetails) *bool { return d.AutoUpdates }},
+	{schema.StatusMDMEnrolled, func(d *schema.StatusDetails) *bool { return d.MDMEnrolled }},
+	{schema.StatusActivationLock, func(d *schema.StatusDetails) *bool { return d.ActivationLock }},
+	{schema.StatusSystemIntegrity, func(d *schema.StatusDetails) *bool { return d.SystemIntegrity }},
+	{schema.StatusSSHPasswordAuth, func(d *schema.StatusDetails) *bool { return d.SSHPasswordAuth }},
+	{schema.StatusUpdatesRecent, func(d *schema.StatusDetails) *bool { return d.UpdatesRecent }},
+	{schema.StatusRebootRequired, func(d *schema.StatusDetails) *bool { return d.RebootRequired }},
+}
+
+// agentCompliance is the security state most recently reported by a single agent
+type agentCompliance struct {
+	AgentID       string           `json:"agent_id"`
+	FriendlyName  string           `json:"friendly_name"`
+	Reported      bool             `json:"reported"`
+	OS            string           `json:"os"`
+	Checks        map[string]*bool `json:"checks"` // nil if unknown or not applicable
+	AccessControl string           `json:"access_control,omitempty"`
+	values        []string         // checks as reported, for the string format
+}
+
+// Report lists the security state of each agent. Checks that the agent's platform does not
+// report are "n/a".
+func (r *Report) Report(data *data.Data, req schema.ReportRequest) (schema.Report, error) {
+	var agents []agentCompliance
+	report := schema.NewReport()
+
+	err := data.ForEach(data.BucketAgentMeta, func(key, value []byte) error {
+		var agent schema.AgentMeta
+		if err := json.Unmarshal(value, &agent); err != nil {
+			return fmt.Errorf("error unmarshalling agent data: %w", err)
+		}
+
+		entry := agentCompliance{AgentID: agent.AgentID, FriendlyName: agent.FriendlyName}
+		if agent.Status != nil {
+			details := &agent.Status.Details
+			entry.Reported = true
+			entry.OS = details.OS
+			entry.AccessControl = details.AccessControl
+			entry.Checks = make(map[string]*bool, len(checks))
+			for _, c := range checks {
+				entry.Checks[c.key] = c.value(details)
+				entry.values = append(entry.values, reported(details, c.key))
+			}
+			entry.values = append(entry.values, reported(details, schema.StatusAccessControl))
+		}
+		agents = append(agents, entry)
+		return nil
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	// Check schema.CmdRequest.Parameters for a format option
+	if format, ok := req.Parameters["format"]; ok {
+		if format == schema.ReportTypeJSON {
+			jsonData, err := json.Marshal(agents)
+			if err != nil {
+				return report, fmt.Errorf("failed to serialize compliance data: %w", err)
+			}
+			report.Type = schema.ReportTypeJSON
+			report.Data = jsonData
+			return report, nil
+		}
+	}
+
+	// Fall back to string format
+	var buffer bytes.Buffer
+	buffer.WriteString("Agents, os")
+	for _, c := range checks {
+		buffer.WriteString(", " + c.key)
+	}
+	buffer.WriteString(", " + schema.StatusAccessControl + ":\n")
+
+	for _, agent := range agents {
+		if !agent.Reported {
+			buffer.WriteString(fmt.Sprintf("%s, %s, not reported\n", agent.AgentID, agent.FriendlyName))
+			continue
+		}
+
+		buffer.WriteString(fmt.Sprintf("%s, %s, %s", agent.AgentID, agent.FriendlyName, agent.OS))
+		for _, value := range agent.values {
+			buffer.WriteString(", " + value)
+		}
+		buffer.WriteString("\n")
+	}
+	report.Data = buffer.Bytes()
+	report.Type = schema.ReportTypeString
+	return report, nil
+}
+
+// reported returns the reported value of a status item, such as "yes" or "unknown", or "n/a"
+// if the agent did not report it
+func reported(details *schema.StatusDetails, key string) string {
+	if value := details.Get(key); value != "" {
+		return value
+	}
+	return "n/a"
+}
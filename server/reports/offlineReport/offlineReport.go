@@ -0,0 +1,81 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package offlineReport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+)
+
+type Report struct{}
+
+// offlineAgent is an agent that has not synced within the offline threshold
+type offlineAgent struct {
+	AgentID      string    `json:"agent_id"`
+	FriendlyName string    `json:"friendly_name"`
+	Hostname     string    `json:"hostname"`
+	LastSeen     time.Time `json:"last_seen"`
+	LastIP       string    `json:"last_ip"`
+	Version      string    `json:"version"`
+}
+
+// Report lists the agents that are marked offline
+func (r *Report) Report(data *data.Data, req schema.ReportRequest) (schema.Report, error) {
+	agents := []offlineAgent{}
+	report := schema.NewReport()
+
+	err := data.ForEach(data.BucketAgentMeta, func(key, value []byte) error {
+		var agent schema.AgentMeta
+		if err := json.Unmarshal(value, &agent); err != nil {
+			return fmt.Errorf("error unmarshalling agent data: %w", err)
+		}
+
+		if !agent.Offline {
+			return nil
+		}
+
+		entry := offlineAgent{AgentID: agent.AgentID, FriendlyName: agent.FriendlyName, LastSeen: agent.LastSeen,
+			LastIP: agent.LastIP, Version: agent.Version}
+		if agent.Status != nil {
+			entry.Hostname = agent.Status.Details.Hostname
+		}
+		agents = append(agents, entry)
+		return nil
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	// Check schema.CmdRequest.Parameters for a format option
+	if format, ok := req.Parameters["format"]; ok {
+		if format == schema.ReportTypeJSON {
+			jsonData, err := json.Marshal(agents)
+			if err != nil {
+				return report, fmt.Errorf("failed to serialize agent data: %w", err)
+			}
+			report.Type = schema.ReportTypeJSON
+			report.Data = jsonData
+			return report, nil
+		}
+	}
+
+	// Fall back to string format
+	var buffer bytes.Buffer
+	buffer.WriteString("Offline agents, hostname, friendly name, last seen, last IP, version:\n")
+	for _, agent := range agents {
+		buffer.WriteString(fmt.Sprintf("%s, %s, %s, %s, %s, %s\n", agent.AgentID, agent.Hostname, agent.FriendlyName,
+			agent.LastSeen.Format(time.RFC3339), agent.LastIP, agent.Version))
+	}
+	report.Data = buffer.Bytes()
+	report.Type = schema.ReportTypeString
+	return report, nil
+}
@@ -0,0 +1,73 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package patchReport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/UnifyEM/UnifyEM/common/schema"
+	"github.com/UnifyEM/UnifyEM/server/data"
+)
+
+type Report struct{}
+
+// agentPatches is the patch status of a single agent
+type agentPatches struct {
+	AgentID      string              `json:"agent_id"`
+	FriendlyName string              `json:"friendly_name"`
+	Patches      *schema.PatchStatus `json:"patches"` // nil if the agent has not reported
+}
+
+// Report lists the most recent patch status of each agent
+func (r *Report) Report(data *data.Data, req schema.ReportRequest) (schema.Report, error) {
+	var agents []agentPatches
+	report := schema.NewReport()
+
+	err := data.ForEach(data.BucketAgentMeta, func(key, value []byte) error {
+		var agent schema.AgentMeta
+		if err := json.Unmarshal(value, &agent); err != nil {
+			return fmt.Errorf("error unmarshalling agent data: %w", err)
+		}
+
+		agents = append(agents, agentPatches{AgentID: agent.AgentID, FriendlyName: agent.FriendlyName, Patches: agent.Patches})
+		return nil
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	// Check schema.CmdRequest.Parameters for a format option
+	if format, ok := req.Parameters["format"]; ok {
+		if format == schema.ReportTypeJSON {
+			jsonData, err := json.Marshal(agents)
+			if err != nil {
+				return report, fmt.Errorf("failed to serialize patch data: %w", err)
+			}
+			report.Type = schema.ReportTypeJSON
+			report.Data = jsonData
+			return report, nil
+		}
+	}
+
+	// Fall back to string format
+	var buffer bytes.Buffer
+	buffer.WriteString("Agents, pending updates, restart required, collected:\n")
+	for _, agent := range agents {
+		if agent.Patches == nil {
+			buffer.WriteString(fmt.Sprintf("%s, %s, not reported\n", agent.AgentID, agent.FriendlyName))
+			continue
+		}
+		buffer.WriteString(fmt.Sprintf("%s, %s, %d, %t, %s\n", agent.AgentID, agent.FriendlyName,
+			agent.Patches.Count, agent.Patches.RebootRequired, agent.Patches.Collected.Format(time.RFC3339)))
+	}
+	report.Data = buffer.Bytes()
+	report.Type = schema.ReportTypeString
+	return report, nil
+}
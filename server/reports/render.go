@@ -0,0 +1,143 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// Saved reports are rendered from the JSON format of a report, which is a list of objects.
+// Nested objects are flattened into columns named with their path, such as checks.firewall,
+// and lists are written as JSON. Columns are in the order that they first appear.
+
+// Table is a report converted to rows and columns
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// NewTable converts a report in the JSON format to a table
+func NewTable(data []byte) (Table, error) {
+	var table Table
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return table, fmt.Errorf("report is not a list: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for _, item := range items {
+		values := make(map[string]string)
+		err := flatten(item, "", func(key, value string) {
+			if _, ok := columns[key]; !ok {
+				columns[key] = len(table.Header)
+				table.Header = append(table.Header, key)
+			}
+			values[key] = value
+		})
+		if err != nil {
+			return table, err
+		}
+
+		row := make([]string, len(table.Header))
+		for key, value := range values {
+			row[columns[key]] = value
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	// Earlier rows are shorter if later rows added columns
+	for x := range table.Rows {
+		for len(table.Rows[x]) < len(table.Header) {
+			table.Rows[x] = append(table.Rows[x], "")
+		}
+	}
+	return table, nil
+}
+
+// flatten calls add for each value in a JSON object, in order
+func flatten(raw json.RawMessage, prefix string, add func(key, value string)) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("error reading report: %w", err)
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '[' {
+			var compact bytes.Buffer
+			if err = json.Compact(&compact, raw); err != nil {
+				return err
+			}
+			add(strings.TrimSuffix(prefix, "."), compact.String())
+			return nil
+		}
+
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("error reading report: %w", err)
+			}
+			var value json.RawMessage
+			if err = dec.Decode(&value); err != nil {
+				return fmt.Errorf("error reading report: %w", err)
+			}
+			if err = flatten(value, prefix+fmt.Sprint(keyTok)+".", add); err != nil {
+				return err
+			}
+		}
+		return nil
+	case nil:
+		add(strings.TrimSuffix(prefix, "."), "")
+	case string:
+		add(strings.TrimSuffix(prefix, "."), v)
+	default:
+		add(strings.TrimSuffix(prefix, "."), fmt.Sprint(v))
+	}
+	return nil
+}
+
+// CSV returns the table as CSV
+func (t Table) CSV() ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	_ = w.Write(t.Header)
+	_ = w.WriteAll(t.Rows)
+	return b.Bytes(), w.Error()
+}
+
+var htmlTable = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family: sans-serif">
+<h2>{{.Title}}</h2>
+<p>Generated {{.Generated}}</p>
+<table border="1" cellpadding="4" cellspacing="0" style="border-collapse: collapse; font-size: small">
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// HTML writes the table as an HTML document
+func (t Table) HTML(w io.Writer, title string, generated time.Time) error {
+	return htmlTable.Execute(w, struct {
+		Title     string
+		Generated string
+		Header    []string
+		Rows      [][]string
+	}{title, generated.Format(time.RFC1123), t.Header, t.Rows})
+}
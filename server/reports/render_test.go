@@ -0,0 +1,57 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package reports
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTable(t *testing.T) {
+	data := []byte(`[
+		{"name": "host1", "checks": {"firewall": true, "fde": false}, "users": ["a", "b"]},
+		{"name": "<host2>", "checks": {"firewall": false}, "version": 1.5, "note": null}
+	]`)
+
+	table, err := NewTable(data)
+	if err != nil {
+		t.Fatalf("NewTable failed: %v", err)
+	}
+
+	header := []string{"name", "checks.firewall", "checks.fde", "users", "version", "note"}
+	if !slices.Equal(table.Header, header) {
+		t.Errorf("unexpected header: %v", table.Header)
+	}
+	if !slices.Equal(table.Rows[0], []string{"host1", "true", "false", `["a","b"]`, "", ""}) {
+		t.Errorf("unexpected first row: %q", table.Rows[0])
+	}
+	if !slices.Equal(table.Rows[1], []string{"<host2>", "false", "", "", "1.5", ""}) {
+		t.Errorf("unexpected second row: %q", table.Rows[1])
+	}
+
+	csv, err := table.CSV()
+	if err != nil {
+		t.Fatalf("CSV failed: %v", err)
+	}
+	if !strings.HasPrefix(string(csv), "name,checks.firewall,checks.fde,users,version,note\nhost1,true,false,\"[\"\"a\"\",\"\"b\"\"]\",,\n") {
+		t.Errorf("unexpected CSV: %s", csv)
+	}
+
+	var b bytes.Buffer
+	if err = table.HTML(&b, "Test", time.Now()); err != nil {
+		t.Fatalf("HTML failed: %v", err)
+	}
+	if !strings.Contains(b.String(), "<td>&lt;host2&gt;</td>") {
+		t.Errorf("HTML is not escaped: %s", b.String())
+	}
+
+	if _, err = NewTable([]byte(`{"name": "host1"}`)); err == nil {
+		t.Error("expected an error for an object")
+	}
+}
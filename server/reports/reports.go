@@ -11,6 +11,10 @@ import (
 	"github.com/UnifyEM/UnifyEM/common/schema"
 	"github.com/UnifyEM/UnifyEM/server/data"
 	"github.com/UnifyEM/UnifyEM/server/reports/agentReport"
+	"github.com/UnifyEM/UnifyEM/server/reports/antivirusReport"
+	"github.com/UnifyEM/UnifyEM/server/reports/complianceReport"
+	"github.com/UnifyEM/UnifyEM/server/reports/offlineReport"
+	"github.com/UnifyEM/UnifyEM/server/reports/patchReport"
 )
 
 type ReportHandler interface {
@@ -18,7 +22,11 @@ type ReportHandler interface {
 }
 
 var handlers = map[string]ReportHandler{
-	"agents": &agentReport.Report{},
+	"agents":     &agentReport.Report{},
+	"antivirus":  &antivirusReport.Report{},
+	"compliance": &complianceReport.Report{},
+	"offline":    &offlineReport.Report{},
+	"patches":    &patchReport.Report{},
 }
 
 func Get(data *data.Data, req schema.ReportRequest) (schema.Report, error) {
@@ -28,3 +36,9 @@ func Get(data *data.Data, req schema.ReportRequest) (schema.Report, error) {
 	}
 	return handler.Report(data, req)
 }
+
+// Exists returns true if the report type exists
+func Exists(report string) bool {
+	_, ok := handlers[report]
+	return ok
+}
@@ -0,0 +1,175 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Saved reports are scheduled with cron expressions: minute, hour, day of month, month, and
+// day of week. Fields may be *, a number, a range (1-5), a list (1,15), or a step (*/15 or
+// 0-30/10). Months and days of the week may also be given by their first three letters, and
+// Sunday is 0 or 7. As with cron, if both the day of month and the day of week are
+// restricted, a day matching either runs the report.
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // bit sets of the permitted values
+	domAny, dowAny                bool   // the field was *
+}
+
+var shortcuts = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseSchedule parses a cron expression or one of @hourly, @daily, @weekly, or @monthly
+func ParseSchedule(spec string) (Schedule, error) {
+	var s Schedule
+	var err error
+
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if expanded, ok := shortcuts[spec]; ok {
+		spec = expanded
+	}
+
+	f := strings.Fields(spec)
+	if len(f) != 5 {
+		return s, errors.New("a schedule requires five fields: minute, hour, day of month, month, and day of week")
+	}
+
+	if s.minute, err = parseField(f[0], 0, 59, nil); err != nil {
+		return s, fmt.Errorf("invalid minute: %w", err)
+	}
+	if s.hour, err = parseField(f[1], 0, 23, nil); err != nil {
+		return s, fmt.Errorf("invalid hour: %w", err)
+	}
+	if s.dom, err = parseField(f[2], 1, 31, nil); err != nil {
+		return s, fmt.Errorf("invalid day of month: %w", err)
+	}
+	if s.month, err = parseField(f[3], 1, 12, monthNames); err != nil {
+		return s, fmt.Errorf("invalid month: %w", err)
+	}
+	if s.dow, err = parseField(f[4], 0, 7, dayNames); err != nil {
+		return s, fmt.Errorf("invalid day of week: %w", err)
+	}
+
+	// Sunday may be 0 or 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	s.domAny = f[2] == "*"
+	s.dowAny = f[4] == "*"
+	return s, nil
+}
+
+// parseField returns the set of values permitted by a field. names, if provided, are the
+// names of the values starting with min.
+func parseField(field string, min, max int, names []string) (uint64, error) {
+	var set uint64
+
+	for _, item := range strings.Split(field, ",") {
+		expr, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+		}
+
+		low, high := min, max
+		if expr != "*" {
+			lowStr, highStr, isRange := strings.Cut(expr, "-")
+			var err error
+			if low, err = parseValue(lowStr, min, max, names); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = parseValue(highStr, min, max, names); err != nil {
+					return 0, err
+				}
+				if high < low {
+					return 0, fmt.Errorf("invalid range %q", expr)
+				}
+			} else if hasStep {
+				high = max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// parseValue parses a number or name
+func parseValue(s string, min, max int, names []string) (int, error) {
+	for x, name := range names {
+		if s == name {
+			return min + x, nil
+		}
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("%q is not between %d and %d", s, min, max)
+	}
+	return v, nil
+}
+
+// Next returns the first time after t that matches the schedule, or the zero time if there
+// is none, such as for February 30
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Every schedule that can match does so within five years, allowing for leap days
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the cron rule for the day of month and day of week fields
+func (s Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,58 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+package reports
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	// Thursday, January 15, 2026
+	start := time.Date(2026, time.January, 15, 10, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 8 * * mon", time.Date(2026, 1, 19, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 7", time.Date(2026, 1, 18, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * mon-fri", time.Date(2026, 1, 16, 8, 0, 0, 0, time.UTC)},
+		{"30 6 1 */3 *", time.Date(2026, 4, 1, 6, 30, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+
+		// Either the day of month or the day of week
+		{"0 9 20 * sun", time.Date(2026, 1, 18, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(start); !got.Equal(tt.want) {
+			t.Errorf("%q: expected %s, got %s", tt.spec, tt.want, got)
+		}
+	}
+
+	s, err := ParseSchedule("0 0 30 feb *")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next := s.Next(start); !next.IsZero() {
+		t.Errorf("February 30: expected zero time, got %s", next)
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "5-1 * * * *", "0 0 * * funday"} {
+		if _, err = ParseSchedule(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
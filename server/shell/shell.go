@@ -0,0 +1,160 @@
+/******************************************************************************
+ * Copyright (c) 2024-2026 Tenebris Technologies Inc.                         *
+ * Please see the LICENSE file for details                                    *
+ ******************************************************************************/
+
+// Package shell relays remote shell sessions between administrators and agents. Like the
+// message queue, it maintains the sessions within the package and exports functions so that
+// they can be accessed from various parts of the application. Sessions are held in memory
+// only and do not survive a server restart.
+package shell
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxBuffer  = 1024 * 1024      // bytes of input or output held for a session, older data is discarded
+	closedTTL  = 60 * time.Second // time a closed session is retained so that both sides learn that it ended
+	ReasonIdle = "idle timeout"
+)
+
+// Session is a remote shell session. Input is held until the agent collects it, and output
+// until the administrator collects it.
+type Session struct {
+	ID        string
+	AgentID   string
+	Requester string
+	RequestID string
+	Created   time.Time
+
+	mu        sync.Mutex
+	activity  time.Time
+	input     []byte
+	output    []byte
+	rows      int
+	cols      int
+	resized   bool
+	connected bool
+	closed    time.Time
+	reason    string
+}
+
+var (
+	mu       sync.Mutex
+	sessions = make(map[string]*Session)
+)
+
+// Open creates a new session for the agent
+func Open(agentID, requester string) *Session {
+	now := time.Now()
+	s := &Session{
+		ID:        "S-" + uuid.New().String(),
+		AgentID:   agentID,
+		Requester: requester,
+		Created:   now,
+		activity:  now,
+	}
+
+	mu.Lock()
+	sessions[s.ID] = s
+	mu.Unlock()
+	return s
+}
+
+// Get returns the session with the specified ID
+func Get(id string) (*Session, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	s, ok := sessions[id]
+	return s, ok
+}
+
+// Expire closes sessions that have been idle for longer than idle and returns them. Sessions
+// that closed more than a minute ago are removed.
+func Expire(idle time.Duration) []*Session {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var expired []*Session
+	for id, s := range sessions {
+		s.mu.Lock()
+		if s.closed.IsZero() && time.Since(s.activity) > idle {
+			s.closed = time.Now()
+			s.reason = ReasonIdle
+			expired = append(expired, s)
+		} else if !s.closed.IsZero() && time.Since(s.closed) > closedTTL {
+			delete(sessions, id)
+		}
+		s.mu.Unlock()
+	}
+	return expired
+}
+
+// Close ends the session and returns true if it was open
+func (s *Session) Close(reason string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed.IsZero() {
+		return false
+	}
+	s.closed = time.Now()
+	s.reason = reason
+	return true
+}
+
+// AdminIO queues input and the terminal size for the agent, and returns the output waiting
+// for the administrator. Closed is only true once all output has been collected.
+func (s *Session) AdminIO(input []byte, rows, cols int) (output []byte, connected, closed bool, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed.IsZero() {
+		if len(input) > 0 {
+			s.input = appendLimited(s.input, input)
+			s.activity = time.Now()
+		}
+		if rows > 0 && cols > 0 && (rows != s.rows || cols != s.cols) {
+			s.rows, s.cols, s.resized = rows, cols, true
+		}
+	}
+
+	output, s.output = s.output, nil
+	return output, s.connected, !s.closed.IsZero() && len(output) == 0, s.reason
+}
+
+// AgentIO queues output from the agent and returns the input waiting for it, the terminal
+// size if it has changed, and whether the agent should end the session
+func (s *Session) AgentIO(output []byte) (input []byte, rows, cols int, closed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.connected = true
+	if len(output) > 0 {
+		s.output = appendLimited(s.output, output)
+		s.activity = time.Now()
+	}
+
+	if !s.closed.IsZero() {
+		return nil, 0, 0, true
+	}
+
+	if s.resized {
+		rows, cols, s.resized = s.rows, s.cols, false
+	}
+	input, s.input = s.input, nil
+	return input, rows, cols, false
+}
+
+// appendLimited appends data to buf, discarding the oldest data if buf would exceed maxBuffer
+func appendLimited(buf, data []byte) []byte {
+	buf = append(buf, data...)
+	if len(buf) > maxBuffer {
+		buf = buf[len(buf)-maxBuffer:]
+	}
+	return buf
+}